@@ -1,15 +1,35 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Accreditation interface {
 	CreateAccountWithContext(ctx context.Context, input *CreateAccountInput) (*CreateAccountOutput, error)
 	GetAccountWithContext(ctx context.Context, input *GetAccountInput) (*GetAccountOutput, error)
+	ChangeStatusWithContext(ctx context.Context, input *ChangeStatusInput) (*ChangeStatusOutput, error)
+	ChangeProfileWithContext(ctx context.Context, input *ChangeProfileInput) (*ChangeProfileOutput, error)
+	ListAccountsWithContext(ctx context.Context, input *ListAccountsInput) (*ListAccountsOutput, error)
+	ChangeLimitsWithContext(ctx context.Context, input *ChangeLimitsInput) (*ChangeLimitsOutput, error)
+	RecountDocumentsWithContext(ctx context.Context, input *RecountDocumentsInput) (*RecountDocumentsOutput, error)
+}
+
+// AccountLimits are enforced by the debit service; a zero value means no limit.
+type AccountLimits struct {
+	MaxWithdrawAmount   int
+	DailyWithdrawAmount int
+	MonthlyBuyingAmount int
+	MaxInstallments     int
 }
 
 type CreateAccountInput struct {
 	DocumentNumber string
 	ExternalKey    string
+	Name           string
+	Email          string
+	Phone          string
+	Currencies     []string
 }
 
 type CreateAccountOutput struct {
@@ -25,4 +45,83 @@ type GetAccountInput struct {
 type GetAccountOutput struct {
 	DocumentNumber string
 	ExternalKey    string
+	Status         string
+	StatusReason   string
+	Name           string
+	PersonType     string
+	Email          string
+	Phone          string
+	CreatedAt      time.Time
+	Limits         *AccountLimits
+	Currencies     []string
+}
+
+type ChangeStatusInput struct {
+	ExternalKey string
+	Status      string
+	Reason      string
+}
+
+type ChangeStatusOutput struct {
+	Error        bool
+	Code         string
+	Detail       string
+	ExternalKey  string
+	Status       string
+	StatusReason string
+	UpdatedAt    time.Time
+}
+
+type ChangeProfileInput struct {
+	ExternalKey string
+	Name        string
+	Email       string
+	Phone       string
+}
+
+type ChangeProfileOutput struct {
+	Error     bool
+	Code      string
+	Detail    string
+	Name      string
+	Email     string
+	Phone     string
+	UpdatedAt time.Time
+}
+
+type ListAccountsInput struct {
+	DocumentNumber string
+	Status         string
+	Limit          int
+	Cursor         string
+}
+
+type ListAccountsOutput struct {
+	Error      bool
+	Code       string
+	Detail     string
+	Accounts   []*GetAccountOutput
+	NextCursor string
+}
+
+type ChangeLimitsInput struct {
+	ExternalKey string
+	Limits      AccountLimits
+}
+
+type ChangeLimitsOutput struct {
+	Error     bool
+	Code      string
+	Detail    string
+	Limits    *AccountLimits
+	UpdatedAt time.Time
+}
+
+// RecountDocumentsInput without document numbers recounts every document.
+type RecountDocumentsInput struct {
+	DocumentNumbers []string
+}
+
+type RecountDocumentsOutput struct {
+	DocumentNumbers []string
 }
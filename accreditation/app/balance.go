@@ -0,0 +1,16 @@
+package app
+
+import "context"
+
+type Balance interface {
+	GetBalanceWithContext(ctx context.Context, input *GetBalanceInput) (*GetBalanceOutput, error)
+}
+
+type GetBalanceInput struct {
+	AccountKey string
+	Currency   string
+}
+type GetBalanceOutput struct {
+	AvailableBalance int
+	HeldAmount       int
+}
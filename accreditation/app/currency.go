@@ -0,0 +1,43 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultCurrency = "BRL"
+	CurrencyInvalid = "currency-invalid"
+)
+
+// supportedCurrencies are the ISO 4217 codes balance knows the minor unit of.
+var supportedCurrencies = []string{
+	"ARS", "AUD", "BHD", "BRL", "CAD", "CHF", "CLP", "CNY", "COP", "EUR", "GBP",
+	"JOD", "JPY", "KRW", "KWD", "MXN", "OMR", "PYG", "TND", "USD", "UYU",
+}
+
+// accountCurrencies reads accounts created before currencies existed as holding BRL.
+func accountCurrencies(currencies []string) []string {
+	if len(currencies) == 0 {
+		return []string{DefaultCurrency}
+	}
+	return currencies
+}
+
+func validateCurrencies(currencies []string) ([]string, string) {
+	if len(currencies) == 0 {
+		return []string{DefaultCurrency}, ""
+	}
+
+	v := make([]string, 0, len(currencies))
+	for _, c := range currencies {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if !contains(supportedCurrencies, c) {
+			return nil, fmt.Sprintf("currency %s is not supported", c)
+		}
+		if !contains(v, c) {
+			v = append(v, c)
+		}
+	}
+	return v, ""
+}
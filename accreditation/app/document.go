@@ -0,0 +1,80 @@
+package app
+
+import "strings"
+
+const DocumentInvalid = "document-invalid"
+
+var (
+	cpfWeights  = []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}
+	cnpjWeights = []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+)
+
+func documentInvalid(detail string) *CreateAccountOutput {
+	return &CreateAccountOutput{
+		Error:  true,
+		Code:   DocumentInvalid,
+		Detail: detail,
+	}
+}
+
+// normalizeDocumentNumber drops the usual CPF and CNPJ punctuation, returning false
+// when anything else than digits is left.
+func normalizeDocumentNumber(v string) (string, bool) {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(v) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '.' || r == '/' || r == '-':
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// checkDigit is the modulo 11 digit over the leading digits, using the last
+// len(digits) weights.
+func checkDigit(digits string, weights []int) byte {
+	weights = weights[len(weights)-len(digits):]
+	sum := 0
+	for i := range digits {
+		sum += int(digits[i]-'0') * weights[i]
+	}
+	if r := sum % 11; r >= 2 {
+		return byte('0' + 11 - r)
+	}
+	return '0'
+}
+
+func validCheckDigits(v string, weights []int) bool {
+	n := len(v) - 2
+	first := checkDigit(v[:n], weights)
+	second := checkDigit(v[:n]+string(first), weights)
+	return v[n] == first && v[n+1] == second
+}
+
+func validateDocumentNumber(v string) (string, *CreateAccountOutput) {
+	n, ok := normalizeDocumentNumber(v)
+	if !ok || n == "" {
+		return "", documentInvalid("document number must contain only digits, dots, slashes and dashes")
+	}
+
+	if len(n) != 11 && len(n) != 14 {
+		return "", documentInvalid("document number must have 11 digits for CPF or 14 digits for CNPJ")
+	}
+
+	if strings.Count(n, n[:1]) == len(n) {
+		return "", documentInvalid("document number must not repeat a single digit")
+	}
+
+	if len(n) == 11 && !validCheckDigits(n, cpfWeights) {
+		return "", documentInvalid("CPF check digits do not match")
+	}
+
+	if len(n) == 14 && !validCheckDigits(n, cnpjWeights) {
+		return "", documentInvalid("CNPJ check digits do not match")
+	}
+
+	return n, nil
+}
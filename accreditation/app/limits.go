@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	LimitsInvalid = "limits-invalid"
+
+	MaxInstallmentsLimit = 12
+)
+
+func validateLimits(l AccountLimits) string {
+	if l.MaxWithdrawAmount < 0 || l.DailyWithdrawAmount < 0 || l.MonthlyBuyingAmount < 0 {
+		return "limits must be positive"
+	}
+
+	if l.MaxInstallments < 0 || l.MaxInstallments > MaxInstallmentsLimit {
+		return fmt.Sprintf("max installments must be between 0 and %d", MaxInstallmentsLimit)
+	}
+
+	if l.MaxWithdrawAmount > 0 && l.DailyWithdrawAmount > 0 && l.MaxWithdrawAmount > l.DailyWithdrawAmount {
+		return "max withdraw amount cannot exceed the daily withdraw amount"
+	}
+
+	return ""
+}
+
+func (a *accreditation) ChangeLimitsWithContext(ctx context.Context, input *ChangeLimitsInput) (*ChangeLimitsOutput, error) {
+	if detail := validateLimits(input.Limits); detail != "" {
+		return &ChangeLimitsOutput{
+			Error:  true,
+			Code:   LimitsInvalid,
+			Detail: detail,
+		}, nil
+	}
+
+	o, err := a.repository.GetWithContext(ctx, &GetInput{
+		ExternalKey: input.ExternalKey,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get error %s", err.Error()))
+		return nil, err
+	}
+
+	if o == nil {
+		return &ChangeLimitsOutput{
+			Error:  true,
+			Code:   AccountNotFound,
+			Detail: "account not found",
+		}, nil
+	}
+
+	if accountStatus(o.Status) == StatusClosed {
+		return &ChangeLimitsOutput{
+			Error:  true,
+			Code:   AccountClosed,
+			Detail: "closed accounts cannot be updated",
+		}, nil
+	}
+
+	limits := input.Limits
+	updatedAt := a.clock()
+	res, err := a.repository.UpdateLimitsWithContext(ctx, &UpdateLimitsInput{
+		ExternalKey: input.ExternalKey,
+		Limits:      &limits,
+		UpdatedAt:   updatedAt,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository update limits error %s", err.Error()))
+		return nil, err
+	}
+
+	if res != nil && res.AccountClosed {
+		return &ChangeLimitsOutput{
+			Error:  true,
+			Code:   AccountClosed,
+			Detail: "closed accounts cannot be updated",
+		}, nil
+	}
+
+	return &ChangeLimitsOutput{
+		Error:     false,
+		Limits:    &limits,
+		UpdatedAt: updatedAt,
+	}, nil
+}
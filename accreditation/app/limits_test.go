@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func (r statusRepositoryMock) UpdateLimitsWithContext(ctx context.Context, input *UpdateLimitsInput) (*UpdateLimitsOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	return &UpdateLimitsOutput{
+		AccountClosed: r.changed,
+	}, nil
+}
+
+func changeLimits(t *testing.T, a Accreditation, i *ChangeLimitsInput) string {
+	res, err := a.ChangeLimitsWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(validate)
+}
+
+func TestAccreditation_ChangeLimits(t *testing.T) {
+	r := newStatusRepositoryMock("{\"ExternalKey\":\"1\",\"Limits\":{\"MaxWithdrawAmount\":50000,\"DailyWithdrawAmount\":100000,\"MonthlyBuyingAmount\":500000,\"MaxInstallments\":6},\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", StatusBlocked, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeLimits(t, a, &ChangeLimitsInput{
+		ExternalKey: "1",
+		Limits: AccountLimits{
+			MaxWithdrawAmount:   50000,
+			DailyWithdrawAmount: 100000,
+			MonthlyBuyingAmount: 500000,
+			MaxInstallments:     6,
+		},
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Limits\":{\"MaxWithdrawAmount\":50000,\"DailyWithdrawAmount\":100000,\"MonthlyBuyingAmount\":500000,\"MaxInstallments\":6},\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeLimitsWhenNegative(t *testing.T) {
+	a := newWithClock(newStatusRepositoryMock("", StatusActive, t), &balanceMock{}, newLogMock())
+	res := changeLimits(t, a, &ChangeLimitsInput{
+		ExternalKey: "1",
+		Limits: AccountLimits{
+			MonthlyBuyingAmount: -1,
+		},
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limits-invalid\",\"Detail\":\"limits must be positive\",\"Limits\":null,\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeLimitsWhenInstallmentsTooHigh(t *testing.T) {
+	a := newWithClock(newStatusRepositoryMock("", StatusActive, t), &balanceMock{}, newLogMock())
+	res := changeLimits(t, a, &ChangeLimitsInput{
+		ExternalKey: "1",
+		Limits: AccountLimits{
+			MaxInstallments: 13,
+		},
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limits-invalid\",\"Detail\":\"max installments must be between 0 and 12\",\"Limits\":null,\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeLimitsWhenWithdrawAboveDaily(t *testing.T) {
+	a := newWithClock(newStatusRepositoryMock("", StatusActive, t), &balanceMock{}, newLogMock())
+	res := changeLimits(t, a, &ChangeLimitsInput{
+		ExternalKey: "1",
+		Limits: AccountLimits{
+			MaxWithdrawAmount:   2000,
+			DailyWithdrawAmount: 1000,
+		},
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limits-invalid\",\"Detail\":\"max withdraw amount cannot exceed the daily withdraw amount\",\"Limits\":null,\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeLimitsWhenAccountNotFound(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	r.o = nil
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeLimits(t, a, &ChangeLimitsInput{
+		ExternalKey: "1",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-not-found\",\"Detail\":\"account not found\",\"Limits\":null,\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeLimitsWhenGetError(t *testing.T) {
+	a := newWithClock(newStatusRepositoryMock("", StatusActive, t), &balanceMock{}, newLogMock())
+	res, err := a.ChangeLimitsWithContext(context.Background(), &ChangeLimitsInput{
+		ExternalKey: "error",
+	})
+	assert.Equal(t, "get error", err.Error())
+	assert.Nil(t, res)
+}
+
+func TestAccreditation_NotChangeLimitsWhenAccountClosed(t *testing.T) {
+	a := newWithClock(newStatusRepositoryMock("", StatusClosed, t), &balanceMock{}, newLogMock())
+	res := changeLimits(t, a, &ChangeLimitsInput{
+		ExternalKey: "1",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-closed\",\"Detail\":\"closed accounts cannot be updated\",\"Limits\":null,\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeLimitsWhenClosedConcurrently(t *testing.T) {
+	r := newStatusRepositoryMock("{\"ExternalKey\":\"1\",\"Limits\":{\"MaxWithdrawAmount\":0,\"DailyWithdrawAmount\":0,\"MonthlyBuyingAmount\":0,\"MaxInstallments\":0},\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", StatusActive, t)
+	r.changed = true
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeLimits(t, a, &ChangeLimitsInput{
+		ExternalKey: "1",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-closed\",\"Detail\":\"closed accounts cannot be updated\",\"Limits\":null,\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	CursorInvalid        = "cursor-invalid"
+	DefaultAccountsLimit = 20
+	MaxAccountsLimit     = 100
+)
+
+func (a *accreditation) ListAccountsWithContext(ctx context.Context, input *ListAccountsInput) (*ListAccountsOutput, error) {
+	documentNumber, ok := normalizeDocumentNumber(input.DocumentNumber)
+	if !ok || documentNumber == "" {
+		return &ListAccountsOutput{
+			Error:  true,
+			Code:   DocumentInvalid,
+			Detail: "document number must contain only digits, dots, slashes and dashes",
+		}, nil
+	}
+
+	if input.Status != "" && input.Status != StatusActive && input.Status != StatusBlocked && input.Status != StatusClosed {
+		return &ListAccountsOutput{
+			Error:  true,
+			Code:   StatusInvalid,
+			Detail: fmt.Sprintf("status must be %s, %s or %s", StatusActive, StatusBlocked, StatusClosed),
+		}, nil
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultAccountsLimit
+	}
+	if limit > MaxAccountsLimit {
+		limit = MaxAccountsLimit
+	}
+
+	res, err := a.repository.ListWithContext(ctx, &ListInput{
+		DocumentNumber: documentNumber,
+		Status:         input.Status,
+		Limit:          limit,
+		Cursor:         input.Cursor,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository list error %s", err.Error()))
+		return nil, err
+	}
+
+	if res.CursorInvalid {
+		return &ListAccountsOutput{
+			Error:  true,
+			Code:   CursorInvalid,
+			Detail: "cursor is invalid",
+		}, nil
+	}
+
+	accounts := make([]*GetAccountOutput, 0, len(res.Accounts))
+	for _, o := range res.Accounts {
+		accounts = append(accounts, accountOutput(o))
+	}
+
+	return &ListAccountsOutput{
+		Accounts:   accounts,
+		NextCursor: res.NextCursor,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func listAccounts(t *testing.T, a Accreditation, i *ListAccountsInput) string {
+	res, err := a.ListAccountsWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(validate)
+}
+
+func TestAccreditation_ListAccounts(t *testing.T) {
+	r := newRepositoryMock("{\"DocumentNumber\":\"52998224725\",\"Status\":\"\",\"Limit\":20,\"Cursor\":\"\"}", t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := listAccounts(t, a, &ListAccountsInput{
+		DocumentNumber: "529.982.247-25",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Accounts\":[{\"DocumentNumber\":\"52998224725\",\"ExternalKey\":\"1\",\"Status\":\"active\",\"StatusReason\":\"\",\"Name\":\"Maria Silva\",\"PersonType\":\"individual\",\"Email\":\"\",\"Phone\":\"\",\"CreatedAt\":\"2022-01-18T10:00:00Z\",\"Limits\":null,\"Currencies\":[\"BRL\"]},{\"DocumentNumber\":\"52998224725\",\"ExternalKey\":\"2\",\"Status\":\"blocked\",\"StatusReason\":\"fraud-suspected\",\"Name\":\"Maria Silva\",\"PersonType\":\"individual\",\"Email\":\"\",\"Phone\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"Limits\":null,\"Currencies\":[\"BRL\"]}],\"NextCursor\":\"next\"}", res)
+}
+
+func TestAccreditation_ListAccountsWithFilters(t *testing.T) {
+	r := newRepositoryMock("{\"DocumentNumber\":\"52998224725\",\"Status\":\"blocked\",\"Limit\":100,\"Cursor\":\"abc\"}", t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := listAccounts(t, a, &ListAccountsInput{
+		DocumentNumber: "52998224725",
+		Status:         StatusBlocked,
+		Limit:          500,
+		Cursor:         "abc",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Accounts\":[{\"DocumentNumber\":\"52998224725\",\"ExternalKey\":\"1\",\"Status\":\"active\",\"StatusReason\":\"\",\"Name\":\"Maria Silva\",\"PersonType\":\"individual\",\"Email\":\"\",\"Phone\":\"\",\"CreatedAt\":\"2022-01-18T10:00:00Z\",\"Limits\":null,\"Currencies\":[\"BRL\"]},{\"DocumentNumber\":\"52998224725\",\"ExternalKey\":\"2\",\"Status\":\"blocked\",\"StatusReason\":\"fraud-suspected\",\"Name\":\"Maria Silva\",\"PersonType\":\"individual\",\"Email\":\"\",\"Phone\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"Limits\":null,\"Currencies\":[\"BRL\"]}],\"NextCursor\":\"next\"}", res)
+}
+
+func TestAccreditation_NotListAccountsWhenDocumentInvalid(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := listAccounts(t, a, &ListAccountsInput{
+		DocumentNumber: "529982247a5",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"document-invalid\",\"Detail\":\"document number must contain only digits, dots, slashes and dashes\",\"Accounts\":null,\"NextCursor\":\"\"}", res)
+}
+
+func TestAccreditation_NotListAccountsWhenStatusInvalid(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := listAccounts(t, a, &ListAccountsInput{
+		DocumentNumber: "52998224725",
+		Status:         "frozen",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"status-invalid\",\"Detail\":\"status must be active, blocked or closed\",\"Accounts\":null,\"NextCursor\":\"\"}", res)
+}
+
+func TestAccreditation_NotListAccountsWhenCursorInvalid(t *testing.T) {
+	r := newRepositoryMock("{\"DocumentNumber\":\"52998224725\",\"Status\":\"\",\"Limit\":20,\"Cursor\":\"invalid\"}", t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := listAccounts(t, a, &ListAccountsInput{
+		DocumentNumber: "52998224725",
+		Cursor:         "invalid",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"cursor-invalid\",\"Detail\":\"cursor is invalid\",\"Accounts\":null,\"NextCursor\":\"\"}", res)
+}
+
+func TestAccreditation_NotListAccountsWhenRepositoryError(t *testing.T) {
+	r := newRepositoryMock("{\"DocumentNumber\":\"12345678909\",\"Status\":\"\",\"Limit\":20,\"Cursor\":\"\"}", t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res, err := a.ListAccountsWithContext(context.Background(), &ListAccountsInput{
+		DocumentNumber: "12345678909",
+	})
+	assert.Equal(t, "list error", err.Error())
+	assert.Nil(t, res)
+}
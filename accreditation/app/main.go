@@ -3,26 +3,20 @@ package app
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"time"
+)
+
+const (
+	ItemAlreadyExists         = "item-already-exists"
+	DocumentAlreadyRegistered = "document-already-registered"
 )
 
 type accreditation struct {
 	log        Logger
 	repository Persistence
-}
-
-func validateDocumentNumber(v string) *CreateAccountOutput {
-	_, err := strconv.Atoi(v)
-
-	if err == nil && (len([]rune(v)) == 11 || len([]rune(v)) == 14) {
-		return nil
-	} else {
-		return &CreateAccountOutput{
-			Error:  true,
-			Code:   "document-invalid",
-			Detail: "invalid document number",
-		}
-	}
+	balance    Balance
+	notifier   Notifier
+	clock      func() time.Time
 }
 
 func (a *accreditation) CreateAccountWithContext(ctx context.Context, input *CreateAccountInput) (*CreateAccountOutput, error) {
@@ -30,14 +24,38 @@ func (a *accreditation) CreateAccountWithContext(ctx context.Context, input *Cre
 		Error: false,
 	}
 
-	v := validateDocumentNumber(input.DocumentNumber)
+	documentNumber, v := validateDocumentNumber(input.DocumentNumber)
 	if v != nil {
 		return v, nil
 	}
 
+	p, detail := validateProfile(input.Name, input.Email, input.Phone)
+	if detail != "" {
+		return &CreateAccountOutput{
+			Error:  true,
+			Code:   ProfileInvalid,
+			Detail: detail,
+		}, nil
+	}
+
+	currencies, detail := validateCurrencies(input.Currencies)
+	if detail != "" {
+		return &CreateAccountOutput{
+			Error:  true,
+			Code:   CurrencyInvalid,
+			Detail: detail,
+		}, nil
+	}
+
 	i := &InsertInput{
-		DocumentNumber: input.DocumentNumber,
+		DocumentNumber: documentNumber,
 		ExternalKey:    input.ExternalKey,
+		Status:         StatusActive,
+		HolderName:     p.HolderName,
+		Email:          p.Email,
+		Phone:          p.Phone,
+		Currencies:     currencies,
+		CreatedAt:      a.clock(),
 	}
 
 	res, err := a.repository.InsertWithContext(ctx, i)
@@ -50,11 +68,27 @@ func (a *accreditation) CreateAccountWithContext(ctx context.Context, input *Cre
 	if res != nil && res.AlreadyExists {
 		return &CreateAccountOutput{
 			Error:  true,
-			Code:   "item-already-exists",
+			Code:   ItemAlreadyExists,
 			Detail: "item already exists",
 		}, nil
 	}
 
+	if res != nil && res.DocumentLimitReached {
+		return &CreateAccountOutput{
+			Error:  true,
+			Code:   DocumentAlreadyRegistered,
+			Detail: "document number already has the maximum number of accounts",
+		}, nil
+	}
+
+	a.notifyWithContext(ctx, &NotifyInput{
+		EventType:  AccountCreated,
+		AccountKey: i.ExternalKey,
+		PersonType: personType(i.DocumentNumber),
+		Currencies: i.Currencies,
+		OccurredAt: i.CreatedAt,
+	})
+
 	return createAccountOuput, nil
 }
 
@@ -69,18 +103,34 @@ func (a *accreditation) GetAccountWithContext(ctx context.Context, input *GetAcc
 	}
 
 	if o != nil {
-		return &GetAccountOutput{
-			ExternalKey:    o.ExternalKey,
-			DocumentNumber: o.DocumentNumber,
-		}, nil
+		return accountOutput(o), nil
 	}
 
 	return nil, nil
 }
 
-func New(r Persistence, log Logger) Accreditation {
+func accountOutput(o *GetOutput) *GetAccountOutput {
+	return &GetAccountOutput{
+		ExternalKey:    o.ExternalKey,
+		DocumentNumber: o.DocumentNumber,
+		Status:         accountStatus(o.Status),
+		StatusReason:   o.StatusReason,
+		Name:           o.HolderName,
+		PersonType:     personType(o.DocumentNumber),
+		Email:          o.Email,
+		Phone:          o.Phone,
+		CreatedAt:      o.CreatedAt,
+		Limits:         o.Limits,
+		Currencies:     accountCurrencies(o.Currencies),
+	}
+}
+
+func New(r Persistence, b Balance, n Notifier, log Logger) Accreditation {
 	return &accreditation{
 		repository: r,
+		balance:    b,
+		notifier:   n,
 		log:        log,
+		clock:      time.Now,
 	}
 }
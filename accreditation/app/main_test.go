@@ -17,16 +17,22 @@ func (r repositoryMock) InsertWithContext(ctx context.Context, input *InsertInpu
 	v, err := json.Marshal(input)
 	assert.Nil(r.t, err)
 	assert.Equal(r.t, r.v, string(v))
-	if input.DocumentNumber == "11111111112" {
+	if input.DocumentNumber == "12345678909" {
 		return nil, errors.New("insert error")
 	}
 
-	if input.DocumentNumber == "11111111113" {
+	if input.DocumentNumber == "11144477735" {
 		return &InsertOutput{
 			AlreadyExists: true,
 		}, nil
 	}
 
+	if input.DocumentNumber == "39053344705" {
+		return &InsertOutput{
+			DocumentLimitReached: true,
+		}, nil
+	}
+
 	return &InsertOutput{
 		AlreadyExists: false,
 	}, nil
@@ -40,12 +46,63 @@ func (r repositoryMock) GetWithContext(ctx context.Context, input *GetInput) (*G
 		assert.Nil(r.t, err)
 		assert.Equal(r.t, r.v, string(val))
 		return &GetOutput{
-			DocumentNumber: "1",
+			DocumentNumber: "11222333000181",
 			ExternalKey:    "2",
+			HolderName:     "Loja da Maria",
+			Email:          "contato@lojadamaria.com.br",
+			CreatedAt:      testNow,
 		}, nil
 	}
 	return nil, nil
 }
+func (r repositoryMock) UpdateStatusWithContext(ctx context.Context, input *UpdateStatusInput) (*UpdateStatusOutput, error) {
+	return nil, nil
+}
+func (r repositoryMock) UpdateProfileWithContext(ctx context.Context, input *UpdateProfileInput) (*UpdateProfileOutput, error) {
+	return nil, nil
+}
+func (r repositoryMock) UpdateLimitsWithContext(ctx context.Context, input *UpdateLimitsInput) (*UpdateLimitsOutput, error) {
+	return nil, nil
+}
+func (r repositoryMock) ListDocumentNumbersWithContext(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+func (r repositoryMock) PutAccountCountWithContext(ctx context.Context, input *PutAccountCountInput) error {
+	return nil
+}
+func (r repositoryMock) ListWithContext(ctx context.Context, input *ListInput) (*ListOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	if input.DocumentNumber == "12345678909" {
+		return nil, errors.New("list error")
+	}
+
+	if input.Cursor == "invalid" {
+		return &ListOutput{
+			CursorInvalid: true,
+		}, nil
+	}
+
+	return &ListOutput{
+		Accounts: []*GetOutput{
+			{
+				ExternalKey:    "1",
+				DocumentNumber: input.DocumentNumber,
+				HolderName:     "Maria Silva",
+				CreatedAt:      testNow,
+			},
+			{
+				ExternalKey:    "2",
+				DocumentNumber: input.DocumentNumber,
+				Status:         StatusBlocked,
+				StatusReason:   ReasonFraudSuspected,
+				HolderName:     "Maria Silva",
+			},
+		},
+		NextCursor: "next",
+	}, nil
+}
 func newRepositoryMock(v string, t *testing.T) Persistence {
 	return &repositoryMock{
 		v: v,
@@ -53,6 +110,28 @@ func newRepositoryMock(v string, t *testing.T) Persistence {
 	}
 }
 
+type balanceMock struct {
+	o          *GetBalanceOutput
+	err        error
+	byCurrency map[string]*GetBalanceOutput
+}
+
+func (b *balanceMock) GetBalanceWithContext(ctx context.Context, input *GetBalanceInput) (*GetBalanceOutput, error) {
+	if b.byCurrency != nil {
+		return b.byCurrency[input.Currency], b.err
+	}
+	return b.o, b.err
+}
+
+type notifierMock struct {
+	notified []*NotifyInput
+}
+
+func (n *notifierMock) NotifyWithContext(ctx context.Context, input *NotifyInput) error {
+	n.notified = append(n.notified, input)
+	return nil
+}
+
 type log struct{}
 
 func (l log) Info(msg string)  {}
@@ -63,11 +142,12 @@ func newLogMock() Logger {
 
 func TestAccreditation_CreateAccountWhenCPF(t *testing.T) {
 	l := newLogMock()
-	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"11111111111\"}", t)
-	a := New(r, l)
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"52998224725\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	a := newWithClock(r, &balanceMock{}, l)
 	i := &CreateAccountInput{
-		DocumentNumber: "11111111111",
+		DocumentNumber: "52998224725",
 		ExternalKey:    "123",
+		Name:           "Maria Silva",
 	}
 	res, err := a.CreateAccountWithContext(context.Background(), i)
 	assert.Nil(t, err)
@@ -78,11 +158,12 @@ func TestAccreditation_CreateAccountWhenCPF(t *testing.T) {
 
 func TestAccreditation_CreateAccountWhenCNPJ(t *testing.T) {
 	l := newLogMock()
-	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"11111111111111\"}", t)
-	a := New(r, l)
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"11222333000181\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	a := newWithClock(r, &balanceMock{}, l)
 	i := &CreateAccountInput{
-		DocumentNumber: "11111111111111",
+		DocumentNumber: "11222333000181",
 		ExternalKey:    "123",
+		Name:           "Maria Silva",
 	}
 	res, err := a.CreateAccountWithContext(context.Background(), i)
 	assert.Nil(t, err)
@@ -94,25 +175,157 @@ func TestAccreditation_CreateAccountWhenCNPJ(t *testing.T) {
 func TestAccreditation_NotCreateAccountWhenInvalidDocument(t *testing.T) {
 	l := newLogMock()
 	r := newRepositoryMock("", t)
-	a := New(r, l)
+	a := newWithClock(r, &balanceMock{}, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "529.982.247-2A",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+	}
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"document-invalid\",\"Detail\":\"document number must contain only digits, dots, slashes and dashes\"}", string(validate))
+}
+
+func TestAccreditation_CreateAccountWhenFormattedCPF(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"52998224725\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	a := newWithClock(r, &balanceMock{}, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "529.982.247-25",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+	}
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\"}", string(validate))
+}
+
+func TestAccreditation_CreateAccountWhenFormattedCNPJ(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"11222333000181\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	a := newWithClock(r, &balanceMock{}, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "11.222.333/0001-81",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+	}
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\"}", string(validate))
+}
+
+func TestAccreditation_NotCreateAccountWhenDocumentLengthInvalid(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, &balanceMock{}, l)
 	i := &CreateAccountInput{
-		DocumentNumber: "111111111111-11",
+		DocumentNumber: "123456789",
 		ExternalKey:    "123",
+		Name:           "Maria Silva",
 	}
 	res, err := a.CreateAccountWithContext(context.Background(), i)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"Error\":true,\"Code\":\"document-invalid\",\"Detail\":\"invalid document number\"}", string(validate))
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"document-invalid\",\"Detail\":\"document number must have 11 digits for CPF or 14 digits for CNPJ\"}", string(validate))
+}
+
+func TestAccreditation_NotCreateAccountWhenRepeatedDigits(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, &balanceMock{}, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "11111111111",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+	}
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"document-invalid\",\"Detail\":\"document number must not repeat a single digit\"}", string(validate))
+}
+
+func TestAccreditation_NotCreateAccountWhenCPFCheckDigitsInvalid(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, &balanceMock{}, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "52998224726",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+	}
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"document-invalid\",\"Detail\":\"CPF check digits do not match\"}", string(validate))
+}
+
+func TestAccreditation_NotCreateAccountWhenCNPJCheckDigitsInvalid(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, &balanceMock{}, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "11222333000182",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+	}
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"document-invalid\",\"Detail\":\"CNPJ check digits do not match\"}", string(validate))
+}
+
+func TestAccreditation_CreateAccountWithCurrencies(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"52998224725\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"USD\",\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	a := newWithClock(r, &balanceMock{}, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+		Currencies:     []string{"usd", "BRL", "USD"},
+	}
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\"}", string(validate))
+}
+
+func TestAccreditation_NotCreateAccountWhenCurrencyInvalid(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, &balanceMock{}, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+		Currencies:     []string{"BRL", "XYZ"},
+	}
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"currency-invalid\",\"Detail\":\"currency XYZ is not supported\"}", string(validate))
 }
 
 func TestAccreditation_NotCreateAccountWhenInsertError(t *testing.T) {
 	l := newLogMock()
-	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"11111111112\"}", t)
-	a := New(r, l)
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"12345678909\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	a := newWithClock(r, &balanceMock{}, l)
 	i := &CreateAccountInput{
-		DocumentNumber: "11111111112",
+		DocumentNumber: "12345678909",
 		ExternalKey:    "123",
+		Name:           "Maria Silva",
 	}
 	res, err := a.CreateAccountWithContext(context.Background(), i)
 	assert.Equal(t, "insert error", err.Error())
@@ -121,11 +334,12 @@ func TestAccreditation_NotCreateAccountWhenInsertError(t *testing.T) {
 
 func TestAccreditation_NotCreateAccountWhenItemAlreadyExists(t *testing.T) {
 	l := newLogMock()
-	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"11111111113\"}", t)
-	a := New(r, l)
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"11144477735\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	a := newWithClock(r, &balanceMock{}, l)
 	i := &CreateAccountInput{
-		DocumentNumber: "11111111113",
+		DocumentNumber: "11144477735",
 		ExternalKey:    "123",
+		Name:           "Maria Silva",
 	}
 	res, err := a.CreateAccountWithContext(context.Background(), i)
 	assert.Nil(t, err)
@@ -134,10 +348,26 @@ func TestAccreditation_NotCreateAccountWhenItemAlreadyExists(t *testing.T) {
 	assert.Equal(t, "{\"Error\":true,\"Code\":\"item-already-exists\",\"Detail\":\"item already exists\"}", string(validate))
 }
 
+func TestAccreditation_NotCreateAccountWhenDocumentLimitReached(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"39053344705\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	a := newWithClock(r, &balanceMock{}, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "390.533.447-05",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+	}
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"document-already-registered\",\"Detail\":\"document number already has the maximum number of accounts\"}", string(validate))
+}
+
 func TestAccreditation_GetAccount(t *testing.T) {
 	l := newLogMock()
 	r := newRepositoryMock("{\"ExternalKey\":\"1\"}", t)
-	a := New(r, l)
+	a := newWithClock(r, &balanceMock{}, l)
 	i := &GetAccountInput{
 		ExternalKey: "1",
 	}
@@ -145,13 +375,13 @@ func TestAccreditation_GetAccount(t *testing.T) {
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"DocumentNumber\":\"1\",\"ExternalKey\":\"2\"}", string(validate))
+	assert.Equal(t, "{\"DocumentNumber\":\"11222333000181\",\"ExternalKey\":\"2\",\"Status\":\"active\",\"StatusReason\":\"\",\"Name\":\"Loja da Maria\",\"PersonType\":\"company\",\"Email\":\"contato@lojadamaria.com.br\",\"Phone\":\"\",\"CreatedAt\":\"2022-01-18T10:00:00Z\",\"Limits\":null,\"Currencies\":[\"BRL\"]}", string(validate))
 }
 
 func TestAccreditation_NotGetAccountWhenGetError(t *testing.T) {
 	l := newLogMock()
 	r := newRepositoryMock("1", t)
-	a := New(r, l)
+	a := newWithClock(r, &balanceMock{}, l)
 	i := &GetAccountInput{
 		ExternalKey: "1",
 	}
@@ -163,7 +393,7 @@ func TestAccreditation_NotGetAccountWhenGetError(t *testing.T) {
 func TestAccreditation_NotGetAccountWhenGetNotFound(t *testing.T) {
 	l := newLogMock()
 	r := newRepositoryMock("", t)
-	a := New(r, l)
+	a := newWithClock(r, &balanceMock{}, l)
 	i := &GetAccountInput{
 		ExternalKey: "1",
 	}
@@ -171,3 +401,35 @@ func TestAccreditation_NotGetAccountWhenGetNotFound(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, res)
 }
+
+func TestAccreditation_CreateAccountNotifiesAccountCreated(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"52998224725\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	n := &notifierMock{}
+	a := newWithNotifier(r, &balanceMock{}, n, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+	}
+	_, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(n.notified)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"EventID\":\"0e84aeadb7f3c197a2a407c9ca2f782cefd02bbc1f62c3cdf7fef025abcbd851\",\"EventType\":\"AccountCreated\",\"AccountKey\":\"123\",\"PersonType\":\"individual\",\"Currencies\":[\"BRL\"],\"OccurredAt\":\"2022-01-18T10:00:00Z\"}]", string(validate))
+}
+
+func TestAccreditation_CreateAccountNotNotifyWhenAlreadyExists(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"11144477735\",\"Status\":\"active\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	n := &notifierMock{}
+	a := newWithNotifier(r, &balanceMock{}, n, l)
+	i := &CreateAccountInput{
+		DocumentNumber: "11144477735",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+	}
+	_, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(n.notified))
+}
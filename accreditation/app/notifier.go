@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const AccountCreated = "AccountCreated"
+
+// Notifier reports account lifecycle events to the webhook service.
+type Notifier interface {
+	NotifyWithContext(ctx context.Context, input *NotifyInput) error
+}
+
+type NotifyInput struct {
+	EventID    string
+	EventType  string
+	AccountKey string
+	PersonType string
+	Currencies []string
+	OccurredAt time.Time
+}
+
+// notifyWithContext does not fail the request: the account exists either way, so
+// a lost notification only costs the merchant an event.
+func (a *accreditation) notifyWithContext(ctx context.Context, input *NotifyInput) {
+	h := sha256.Sum256([]byte(fmt.Sprintf("accreditation|%s|%s", input.EventType, input.AccountKey)))
+	input.EventID = hex.EncodeToString(h[:])
+	if err := a.notifier.NotifyWithContext(ctx, input); err != nil {
+		a.log.Error(fmt.Sprintf("notify error %s", err.Error()))
+	}
+}
@@ -1,18 +1,34 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Persistence interface {
 	InsertWithContext(ctx context.Context, input *InsertInput) (*InsertOutput, error)
 	GetWithContext(ctx context.Context, input *GetInput) (*GetOutput, error)
+	UpdateStatusWithContext(ctx context.Context, input *UpdateStatusInput) (*UpdateStatusOutput, error)
+	UpdateProfileWithContext(ctx context.Context, input *UpdateProfileInput) (*UpdateProfileOutput, error)
+	ListWithContext(ctx context.Context, input *ListInput) (*ListOutput, error)
+	UpdateLimitsWithContext(ctx context.Context, input *UpdateLimitsInput) (*UpdateLimitsOutput, error)
+	ListDocumentNumbersWithContext(ctx context.Context) ([]string, error)
+	PutAccountCountWithContext(ctx context.Context, input *PutAccountCountInput) error
 }
 
 type InsertInput struct {
 	ExternalKey    string
 	DocumentNumber string
+	Status         string
+	HolderName     string
+	Email          string
+	Phone          string
+	Currencies     []string
+	CreatedAt      time.Time
 }
 type InsertOutput struct {
-	AlreadyExists bool
+	AlreadyExists        bool
+	DocumentLimitReached bool
 }
 
 type GetInput struct {
@@ -21,4 +37,63 @@ type GetInput struct {
 type GetOutput struct {
 	ExternalKey    string
 	DocumentNumber string
+	Status         string
+	StatusReason   string
+	HolderName     string
+	Email          string
+	Phone          string
+	CreatedAt      time.Time
+	Limits         *AccountLimits
+	Currencies     []string
+}
+
+// UpdateStatusInput carries the document number so that closing the account frees
+// its place in the document's account count.
+type UpdateStatusInput struct {
+	ExternalKey    string
+	DocumentNumber string
+	From           string
+	To             string
+	Reason         string
+	UpdatedAt      time.Time
+}
+type UpdateStatusOutput struct {
+	StatusChanged bool
+}
+
+type UpdateProfileInput struct {
+	ExternalKey string
+	HolderName  string
+	Email       string
+	Phone       string
+	UpdatedAt   time.Time
+}
+type UpdateProfileOutput struct {
+	AccountClosed bool
+}
+
+type ListInput struct {
+	DocumentNumber string
+	Status         string
+	Limit          int
+	Cursor         string
+}
+type ListOutput struct {
+	Accounts      []*GetOutput
+	NextCursor    string
+	CursorInvalid bool
+}
+
+type UpdateLimitsInput struct {
+	ExternalKey string
+	Limits      *AccountLimits
+	UpdatedAt   time.Time
+}
+type UpdateLimitsOutput struct {
+	AccountClosed bool
+}
+
+type PutAccountCountInput struct {
+	DocumentNumber string
+	AccountCount   int
 }
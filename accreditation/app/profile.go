@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode"
+)
+
+const (
+	PersonIndividual = "individual"
+	PersonCompany    = "company"
+
+	ProfileInvalid = "profile-invalid"
+	AccountClosed  = "account-closed"
+
+	MinNameLength  = 2
+	MaxNameLength  = 120
+	MaxEmailLength = 254
+	MinPhoneDigits = 10
+	MaxPhoneDigits = 15
+)
+
+type profile struct {
+	HolderName string
+	Email      string
+	Phone      string
+}
+
+// personType follows the document: 11 digits are a CPF, 14 digits a CNPJ.
+func personType(documentNumber string) string {
+	switch len(documentNumber) {
+	case 11:
+		return PersonIndividual
+	case 14:
+		return PersonCompany
+	}
+	return ""
+}
+
+func validateName(v string) (string, string) {
+	n := strings.Join(strings.Fields(v), " ")
+	if n == "" {
+		return "", "name is missing or null"
+	}
+
+	if l := len([]rune(n)); l < MinNameLength || l > MaxNameLength {
+		return "", fmt.Sprintf("name must have between %d and %d characters", MinNameLength, MaxNameLength)
+	}
+
+	for _, r := range n {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" '.,&-", r) {
+			return "", "name contains invalid characters"
+		}
+	}
+
+	return n, ""
+}
+
+func validateEmail(v string) (string, string) {
+	e := strings.TrimSpace(v)
+	if e == "" {
+		return "", ""
+	}
+
+	if len(e) > MaxEmailLength {
+		return "", fmt.Sprintf("email must have at most %d characters", MaxEmailLength)
+	}
+
+	a, err := mail.ParseAddress(e)
+	if err != nil || a.Address != e || !strings.Contains(e[strings.LastIndex(e, "@"):], ".") {
+		return "", "email invalid"
+	}
+
+	return strings.ToLower(e), ""
+}
+
+// validatePhone accepts the number with the usual punctuation and stores it in E.164.
+func validatePhone(v string) (string, string) {
+	p := strings.TrimSpace(v)
+	if p == "" {
+		return "", ""
+	}
+
+	if !strings.HasPrefix(p, "+") {
+		return "", "phone must start with + and the country code"
+	}
+
+	var b strings.Builder
+	for _, r := range p[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+		default:
+			return "", "phone must contain only digits, spaces, dashes and parentheses"
+		}
+	}
+
+	if l := b.Len(); l < MinPhoneDigits || l > MaxPhoneDigits {
+		return "", fmt.Sprintf("phone must have between %d and %d digits", MinPhoneDigits, MaxPhoneDigits)
+	}
+
+	return "+" + b.String(), ""
+}
+
+// validateProfile returns the normalized profile or the first rule that failed.
+func validateProfile(name string, email string, phone string) (*profile, string) {
+	n, detail := validateName(name)
+	if detail != "" {
+		return nil, detail
+	}
+
+	e, detail := validateEmail(email)
+	if detail != "" {
+		return nil, detail
+	}
+
+	p, detail := validatePhone(phone)
+	if detail != "" {
+		return nil, detail
+	}
+
+	return &profile{
+		HolderName: n,
+		Email:      e,
+		Phone:      p,
+	}, ""
+}
+
+func (a *accreditation) ChangeProfileWithContext(ctx context.Context, input *ChangeProfileInput) (*ChangeProfileOutput, error) {
+	p, detail := validateProfile(input.Name, input.Email, input.Phone)
+	if detail != "" {
+		return &ChangeProfileOutput{
+			Error:  true,
+			Code:   ProfileInvalid,
+			Detail: detail,
+		}, nil
+	}
+
+	o, err := a.repository.GetWithContext(ctx, &GetInput{
+		ExternalKey: input.ExternalKey,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get error %s", err.Error()))
+		return nil, err
+	}
+
+	if o == nil {
+		return &ChangeProfileOutput{
+			Error:  true,
+			Code:   AccountNotFound,
+			Detail: "account not found",
+		}, nil
+	}
+
+	if accountStatus(o.Status) == StatusClosed {
+		return &ChangeProfileOutput{
+			Error:  true,
+			Code:   AccountClosed,
+			Detail: "closed accounts cannot be updated",
+		}, nil
+	}
+
+	updatedAt := a.clock()
+	res, err := a.repository.UpdateProfileWithContext(ctx, &UpdateProfileInput{
+		ExternalKey: input.ExternalKey,
+		HolderName:  p.HolderName,
+		Email:       p.Email,
+		Phone:       p.Phone,
+		UpdatedAt:   updatedAt,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository update profile error %s", err.Error()))
+		return nil, err
+	}
+
+	if res != nil && res.AccountClosed {
+		return &ChangeProfileOutput{
+			Error:  true,
+			Code:   AccountClosed,
+			Detail: "closed accounts cannot be updated",
+		}, nil
+	}
+
+	return &ChangeProfileOutput{
+		Error:     false,
+		Name:      p.HolderName,
+		Email:     p.Email,
+		Phone:     p.Phone,
+		UpdatedAt: updatedAt,
+	}, nil
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func (r statusRepositoryMock) UpdateProfileWithContext(ctx context.Context, input *UpdateProfileInput) (*UpdateProfileOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	return &UpdateProfileOutput{
+		AccountClosed: r.changed,
+	}, nil
+}
+
+func createAccount(t *testing.T, a Accreditation, i *CreateAccountInput) string {
+	res, err := a.CreateAccountWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(validate)
+}
+
+func changeProfile(t *testing.T, a Accreditation, i *ChangeProfileInput) string {
+	res, err := a.ChangeProfileWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(validate)
+}
+
+func TestAccreditation_CreateAccountWithProfile(t *testing.T) {
+	r := newRepositoryMock("{\"ExternalKey\":\"123\",\"DocumentNumber\":\"52998224725\",\"Status\":\"active\",\"HolderName\":\"Maria da Silva\",\"Email\":\"maria@example.com\",\"Phone\":\"+5511987654321\",\"Currencies\":[\"BRL\"],\"CreatedAt\":\"2022-01-18T10:00:00Z\"}", t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := createAccount(t, a, &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "  Maria   da Silva ",
+		Email:          "Maria@Example.com",
+		Phone:          "+55 (11) 98765-4321",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\"}", res)
+}
+
+func TestAccreditation_NotCreateAccountWhenNameMissing(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := createAccount(t, a, &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"profile-invalid\",\"Detail\":\"name is missing or null\"}", res)
+}
+
+func TestAccreditation_NotCreateAccountWhenNameTooShort(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := createAccount(t, a, &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "M",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"profile-invalid\",\"Detail\":\"name must have between 2 and 120 characters\"}", res)
+}
+
+func TestAccreditation_NotCreateAccountWhenNameHasInvalidCharacters(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := createAccount(t, a, &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "Maria <script>",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"profile-invalid\",\"Detail\":\"name contains invalid characters\"}", res)
+}
+
+func TestAccreditation_NotCreateAccountWhenEmailInvalid(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := createAccount(t, a, &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+		Email:          "Maria <maria@example.com>",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"profile-invalid\",\"Detail\":\"email invalid\"}", res)
+}
+
+func TestAccreditation_NotCreateAccountWhenEmailWithoutDomain(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := createAccount(t, a, &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+		Email:          "maria@localhost",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"profile-invalid\",\"Detail\":\"email invalid\"}", res)
+}
+
+func TestAccreditation_NotCreateAccountWhenPhoneWithoutCountryCode(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := createAccount(t, a, &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+		Phone:          "11987654321",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"profile-invalid\",\"Detail\":\"phone must start with + and the country code\"}", res)
+}
+
+func TestAccreditation_NotCreateAccountWhenPhoneTooShort(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := createAccount(t, a, &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+		Phone:          "+55 1234",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"profile-invalid\",\"Detail\":\"phone must have between 10 and 15 digits\"}", res)
+}
+
+func TestAccreditation_NotCreateAccountWhenPhoneHasLetters(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), &balanceMock{}, newLogMock())
+	res := createAccount(t, a, &CreateAccountInput{
+		DocumentNumber: "52998224725",
+		ExternalKey:    "123",
+		Name:           "Maria Silva",
+		Phone:          "+55 11 CALL-NOW",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"profile-invalid\",\"Detail\":\"phone must contain only digits, spaces, dashes and parentheses\"}", res)
+}
+
+func TestAccreditation_ChangeProfile(t *testing.T) {
+	r := newStatusRepositoryMock("{\"ExternalKey\":\"1\",\"HolderName\":\"Maria Souza\",\"Email\":\"\",\"Phone\":\"+5511987654321\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", StatusBlocked, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeProfile(t, a, &ChangeProfileInput{
+		ExternalKey: "1",
+		Name:        "Maria Souza",
+		Phone:       "+55 11 98765-4321",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Name\":\"Maria Souza\",\"Email\":\"\",\"Phone\":\"+5511987654321\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeProfileWhenInvalid(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeProfile(t, a, &ChangeProfileInput{
+		ExternalKey: "1",
+		Name:        "Maria Souza",
+		Email:       "maria",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"profile-invalid\",\"Detail\":\"email invalid\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeProfileWhenAccountNotFound(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	r.o = nil
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeProfile(t, a, &ChangeProfileInput{
+		ExternalKey: "1",
+		Name:        "Maria Souza",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-not-found\",\"Detail\":\"account not found\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeProfileWhenGetError(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res, err := a.ChangeProfileWithContext(context.Background(), &ChangeProfileInput{
+		ExternalKey: "error",
+		Name:        "Maria Souza",
+	})
+	assert.Equal(t, "get error", err.Error())
+	assert.Nil(t, res)
+}
+
+func TestAccreditation_NotChangeProfileWhenAccountClosed(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusClosed, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeProfile(t, a, &ChangeProfileInput{
+		ExternalKey: "1",
+		Name:        "Maria Souza",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-closed\",\"Detail\":\"closed accounts cannot be updated\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeProfileWhenClosedConcurrently(t *testing.T) {
+	r := newStatusRepositoryMock("{\"ExternalKey\":\"1\",\"HolderName\":\"Maria Souza\",\"Email\":\"\",\"Phone\":\"\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", StatusActive, t)
+	r.changed = true
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeProfile(t, a, &ChangeProfileInput{
+		ExternalKey: "1",
+		Name:        "Maria Souza",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-closed\",\"Detail\":\"closed accounts cannot be updated\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+const recountPageSize = 100
+
+// RecountDocumentsWithContext sets the account count of each document to its
+// accounts that are not closed, which also counts the accounts opened before the
+// count existed. It must run while no account is being created or closed.
+func (a *accreditation) RecountDocumentsWithContext(ctx context.Context, input *RecountDocumentsInput) (*RecountDocumentsOutput, error) {
+	documentNumbers := input.DocumentNumbers
+	if len(documentNumbers) == 0 {
+		numbers, err := a.repository.ListDocumentNumbersWithContext(ctx)
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository list document numbers error %s", err.Error()))
+			return nil, err
+		}
+		documentNumbers = numbers
+	}
+
+	for _, documentNumber := range documentNumbers {
+		count := 0
+		cursor := ""
+		for {
+			o, err := a.repository.ListWithContext(ctx, &ListInput{
+				DocumentNumber: documentNumber,
+				Limit:          recountPageSize,
+				Cursor:         cursor,
+			})
+			if err != nil {
+				a.log.Error(fmt.Sprintf("Repository list error %s", err.Error()))
+				return nil, err
+			}
+
+			for _, account := range o.Accounts {
+				if accountStatus(account.Status) != StatusClosed {
+					count++
+				}
+			}
+
+			if o.NextCursor == "" {
+				break
+			}
+			cursor = o.NextCursor
+		}
+
+		err := a.repository.PutAccountCountWithContext(ctx, &PutAccountCountInput{
+			DocumentNumber: documentNumber,
+			AccountCount:   count,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository put account count error %s", err.Error()))
+			return nil, err
+		}
+	}
+
+	return &RecountDocumentsOutput{
+		DocumentNumbers: documentNumbers,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// recountRepositoryMock pages the accounts of a document one at a time.
+type recountRepositoryMock struct {
+	repositoryMock
+	accounts map[string][]*GetOutput
+	counts   []*PutAccountCountInput
+}
+
+func (r *recountRepositoryMock) ListDocumentNumbersWithContext(ctx context.Context) ([]string, error) {
+	return []string{"52998224725", "11222333000181"}, nil
+}
+func (r *recountRepositoryMock) ListWithContext(ctx context.Context, input *ListInput) (*ListOutput, error) {
+	if input.DocumentNumber == "12345678909" {
+		return nil, errors.New("list error")
+	}
+	accounts := r.accounts[input.DocumentNumber]
+	i := 0
+	if input.Cursor != "" {
+		i = len(input.Cursor)
+	}
+	if i >= len(accounts) {
+		return &ListOutput{}, nil
+	}
+	o := &ListOutput{
+		Accounts: accounts[i : i+1],
+	}
+	if i+1 < len(accounts) {
+		o.NextCursor = input.Cursor + "x"
+	}
+	return o, nil
+}
+func (r *recountRepositoryMock) PutAccountCountWithContext(ctx context.Context, input *PutAccountCountInput) error {
+	r.counts = append(r.counts, input)
+	return nil
+}
+
+func newRecountRepositoryMock() *recountRepositoryMock {
+	return &recountRepositoryMock{
+		accounts: map[string][]*GetOutput{
+			"52998224725": {
+				{ExternalKey: "1"},
+				{ExternalKey: "2", Status: StatusBlocked},
+				{ExternalKey: "3", Status: StatusClosed},
+			},
+			"11222333000181": {
+				{ExternalKey: "4", Status: StatusClosed},
+			},
+		},
+	}
+}
+
+func TestAccreditation_RecountDocuments(t *testing.T) {
+	r := newRecountRepositoryMock()
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res, err := a.RecountDocumentsWithContext(context.Background(), &RecountDocumentsInput{})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"DocumentNumbers\":[\"52998224725\",\"11222333000181\"]}", string(b))
+	b, err = json.Marshal(r.counts)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"DocumentNumber\":\"52998224725\",\"AccountCount\":2},{\"DocumentNumber\":\"11222333000181\",\"AccountCount\":0}]", string(b))
+}
+
+func TestAccreditation_RecountGivenDocuments(t *testing.T) {
+	r := newRecountRepositoryMock()
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	_, err := a.RecountDocumentsWithContext(context.Background(), &RecountDocumentsInput{
+		DocumentNumbers: []string{"11222333000181"},
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(r.counts)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"DocumentNumber\":\"11222333000181\",\"AccountCount\":0}]", string(b))
+}
+
+func TestAccreditation_NotRecountDocumentsWhenListError(t *testing.T) {
+	r := newRecountRepositoryMock()
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res, err := a.RecountDocumentsWithContext(context.Background(), &RecountDocumentsInput{
+		DocumentNumbers: []string{"12345678909"},
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "list error", err.Error())
+	assert.Equal(t, 0, len(r.counts))
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	StatusActive  = "active"
+	StatusBlocked = "blocked"
+	StatusClosed  = "closed"
+
+	ReasonCustomerRequest = "customer-request"
+	ReasonFraudSuspected  = "fraud-suspected"
+	ReasonComplianceCheck = "compliance-check"
+	ReasonCourtOrder      = "court-order"
+	ReasonIssueResolved   = "issue-resolved"
+
+	AccountNotFound         = "account-not-found"
+	StatusInvalid           = "status-invalid"
+	StatusReasonInvalid     = "status-reason-invalid"
+	StatusTransitionInvalid = "status-transition-invalid"
+	BalanceNotZero          = "balance-not-zero"
+)
+
+var statusTransitions = map[string][]string{
+	StatusActive:  {StatusBlocked, StatusClosed},
+	StatusBlocked: {StatusActive, StatusClosed},
+}
+
+var statusReasons = []string{
+	ReasonCustomerRequest,
+	ReasonFraudSuspected,
+	ReasonComplianceCheck,
+	ReasonCourtOrder,
+	ReasonIssueResolved,
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// accountStatus treats accounts created before statuses existed as active.
+func accountStatus(status string) string {
+	if status == "" {
+		return StatusActive
+	}
+	return status
+}
+
+func (a *accreditation) ChangeStatusWithContext(ctx context.Context, input *ChangeStatusInput) (*ChangeStatusOutput, error) {
+	if input.Status != StatusActive && input.Status != StatusBlocked && input.Status != StatusClosed {
+		return &ChangeStatusOutput{
+			Error:  true,
+			Code:   StatusInvalid,
+			Detail: fmt.Sprintf("status must be %s, %s or %s", StatusActive, StatusBlocked, StatusClosed),
+		}, nil
+	}
+
+	if !contains(statusReasons, input.Reason) {
+		return &ChangeStatusOutput{
+			Error:  true,
+			Code:   StatusReasonInvalid,
+			Detail: "reason invalid",
+		}, nil
+	}
+
+	o, err := a.repository.GetWithContext(ctx, &GetInput{
+		ExternalKey: input.ExternalKey,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get error %s", err.Error()))
+		return nil, err
+	}
+
+	if o == nil {
+		return &ChangeStatusOutput{
+			Error:  true,
+			Code:   AccountNotFound,
+			Detail: "account not found",
+		}, nil
+	}
+
+	from := accountStatus(o.Status)
+	if !contains(statusTransitions[from], input.Status) {
+		return &ChangeStatusOutput{
+			Error:  true,
+			Code:   StatusTransitionInvalid,
+			Detail: fmt.Sprintf("account cannot change from %s to %s", from, input.Status),
+		}, nil
+	}
+
+	if input.Status == StatusClosed {
+		for _, currency := range accountCurrencies(o.Currencies) {
+			bo, err := a.balance.GetBalanceWithContext(ctx, &GetBalanceInput{
+				AccountKey: input.ExternalKey,
+				Currency:   currency,
+			})
+			if err != nil {
+				a.log.Error(fmt.Sprintf("Balance get error %s", err.Error()))
+				return nil, err
+			}
+
+			// Funds on hold still belong to the account until they are captured or released.
+			if bo != nil && (bo.AvailableBalance != 0 || bo.HeldAmount != 0) {
+				return &ChangeStatusOutput{
+					Error:  true,
+					Code:   BalanceNotZero,
+					Detail: "account balance must be zero to close it",
+				}, nil
+			}
+		}
+	}
+
+	updatedAt := a.clock()
+	res, err := a.repository.UpdateStatusWithContext(ctx, &UpdateStatusInput{
+		ExternalKey:    input.ExternalKey,
+		DocumentNumber: o.DocumentNumber,
+		From:           from,
+		To:             input.Status,
+		Reason:         input.Reason,
+		UpdatedAt:      updatedAt,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository update status error %s", err.Error()))
+		return nil, err
+	}
+
+	if res != nil && res.StatusChanged {
+		return &ChangeStatusOutput{
+			Error:  true,
+			Code:   StatusTransitionInvalid,
+			Detail: "account status changed, try again",
+		}, nil
+	}
+
+	return &ChangeStatusOutput{
+		Error:        false,
+		ExternalKey:  input.ExternalKey,
+		Status:       input.Status,
+		StatusReason: input.Reason,
+		UpdatedAt:    updatedAt,
+	}, nil
+}
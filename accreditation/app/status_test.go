@@ -0,0 +1,229 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC)
+
+type statusRepositoryMock struct {
+	repositoryMock
+	o       *GetOutput
+	changed bool
+}
+
+func (r statusRepositoryMock) GetWithContext(ctx context.Context, input *GetInput) (*GetOutput, error) {
+	if input.ExternalKey == "error" {
+		return nil, errors.New("get error")
+	}
+	return r.o, nil
+}
+func (r statusRepositoryMock) UpdateStatusWithContext(ctx context.Context, input *UpdateStatusInput) (*UpdateStatusOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	return &UpdateStatusOutput{
+		StatusChanged: r.changed,
+	}, nil
+}
+
+func newStatusRepositoryMock(v string, status string, t *testing.T) *statusRepositoryMock {
+	return &statusRepositoryMock{
+		repositoryMock: repositoryMock{
+			v: v,
+			t: t,
+		},
+		o: &GetOutput{
+			ExternalKey:    "1",
+			DocumentNumber: "52998224725",
+			Status:         status,
+		},
+	}
+}
+
+func newWithClock(r Persistence, b Balance, l Logger) Accreditation {
+	return newWithNotifier(r, b, &notifierMock{}, l)
+}
+
+func newWithNotifier(r Persistence, b Balance, n Notifier, l Logger) Accreditation {
+	a := New(r, b, n, l)
+	a.(*accreditation).clock = func() time.Time {
+		return testNow
+	}
+	return a
+}
+
+func changeStatus(t *testing.T, a Accreditation, i *ChangeStatusInput) string {
+	res, err := a.ChangeStatusWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(validate)
+}
+
+func TestAccreditation_BlockAccount(t *testing.T) {
+	r := newStatusRepositoryMock("{\"ExternalKey\":\"1\",\"DocumentNumber\":\"52998224725\",\"From\":\"active\",\"To\":\"blocked\",\"Reason\":\"fraud-suspected\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", "", t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusBlocked,
+		Reason:      ReasonFraudSuspected,
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"ExternalKey\":\"1\",\"Status\":\"blocked\",\"StatusReason\":\"fraud-suspected\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", res)
+}
+
+func TestAccreditation_UnblockAccount(t *testing.T) {
+	r := newStatusRepositoryMock("{\"ExternalKey\":\"1\",\"DocumentNumber\":\"52998224725\",\"From\":\"blocked\",\"To\":\"active\",\"Reason\":\"issue-resolved\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", StatusBlocked, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusActive,
+		Reason:      ReasonIssueResolved,
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"ExternalKey\":\"1\",\"Status\":\"active\",\"StatusReason\":\"issue-resolved\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", res)
+}
+
+func TestAccreditation_CloseAccountWhenBalanceZero(t *testing.T) {
+	r := newStatusRepositoryMock("{\"ExternalKey\":\"1\",\"DocumentNumber\":\"52998224725\",\"From\":\"blocked\",\"To\":\"closed\",\"Reason\":\"customer-request\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", StatusBlocked, t)
+	a := newWithClock(r, &balanceMock{o: &GetBalanceOutput{AvailableBalance: 0}}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusClosed,
+		Reason:      ReasonCustomerRequest,
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"ExternalKey\":\"1\",\"Status\":\"closed\",\"StatusReason\":\"customer-request\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotCloseAccountWhenBalanceNotZero(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	a := newWithClock(r, &balanceMock{o: &GetBalanceOutput{AvailableBalance: -100}}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusClosed,
+		Reason:      ReasonCustomerRequest,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"balance-not-zero\",\"Detail\":\"account balance must be zero to close it\",\"ExternalKey\":\"\",\"Status\":\"\",\"StatusReason\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotCloseAccountWhenBalanceError(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	a := newWithClock(r, &balanceMock{err: errors.New("balance error")}, newLogMock())
+	res, err := a.ChangeStatusWithContext(context.Background(), &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusClosed,
+		Reason:      ReasonCustomerRequest,
+	})
+	assert.Equal(t, "balance error", err.Error())
+	assert.Nil(t, res)
+}
+
+func TestAccreditation_NotChangeStatusWhenClosed(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusClosed, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusActive,
+		Reason:      ReasonIssueResolved,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"status-transition-invalid\",\"Detail\":\"account cannot change from closed to active\",\"ExternalKey\":\"\",\"Status\":\"\",\"StatusReason\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeStatusWhenSameStatus(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusBlocked, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusBlocked,
+		Reason:      ReasonFraudSuspected,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"status-transition-invalid\",\"Detail\":\"account cannot change from blocked to blocked\",\"ExternalKey\":\"\",\"Status\":\"\",\"StatusReason\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeStatusWhenStatusInvalid(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      "frozen",
+		Reason:      ReasonFraudSuspected,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"status-invalid\",\"Detail\":\"status must be active, blocked or closed\",\"ExternalKey\":\"\",\"Status\":\"\",\"StatusReason\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeStatusWhenReasonInvalid(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusBlocked,
+		Reason:      "because",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"status-reason-invalid\",\"Detail\":\"reason invalid\",\"ExternalKey\":\"\",\"Status\":\"\",\"StatusReason\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeStatusWhenAccountNotFound(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	r.o = nil
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusBlocked,
+		Reason:      ReasonFraudSuspected,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-not-found\",\"Detail\":\"account not found\",\"ExternalKey\":\"\",\"Status\":\"\",\"StatusReason\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotChangeStatusWhenGetError(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res, err := a.ChangeStatusWithContext(context.Background(), &ChangeStatusInput{
+		ExternalKey: "error",
+		Status:      StatusBlocked,
+		Reason:      ReasonFraudSuspected,
+	})
+	assert.Equal(t, "get error", err.Error())
+	assert.Nil(t, res)
+}
+
+func TestAccreditation_NotChangeStatusWhenChangedConcurrently(t *testing.T) {
+	r := newStatusRepositoryMock("{\"ExternalKey\":\"1\",\"DocumentNumber\":\"52998224725\",\"From\":\"active\",\"To\":\"blocked\",\"Reason\":\"compliance-check\",\"UpdatedAt\":\"2022-01-18T10:00:00Z\"}", StatusActive, t)
+	r.changed = true
+	a := newWithClock(r, &balanceMock{}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusBlocked,
+		Reason:      ReasonComplianceCheck,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"status-transition-invalid\",\"Detail\":\"account status changed, try again\",\"ExternalKey\":\"\",\"Status\":\"\",\"StatusReason\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotCloseAccountWhenFundsOnHold(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	a := newWithClock(r, &balanceMock{o: &GetBalanceOutput{AvailableBalance: 0, HeldAmount: 500}}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusClosed,
+		Reason:      ReasonCustomerRequest,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"balance-not-zero\",\"Detail\":\"account balance must be zero to close it\",\"ExternalKey\":\"\",\"Status\":\"\",\"StatusReason\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
+
+func TestAccreditation_NotCloseAccountWhenBalanceInOtherCurrency(t *testing.T) {
+	r := newStatusRepositoryMock("", StatusActive, t)
+	r.o.Currencies = []string{"BRL", "USD"}
+	a := newWithClock(r, &balanceMock{byCurrency: map[string]*GetBalanceOutput{
+		"BRL": {AvailableBalance: 0},
+		"USD": {AvailableBalance: 500},
+	}}, newLogMock())
+	res := changeStatus(t, a, &ChangeStatusInput{
+		ExternalKey: "1",
+		Status:      StatusClosed,
+		Reason:      ReasonCustomerRequest,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"balance-not-zero\",\"Detail\":\"account balance must be zero to close it\",\"ExternalKey\":\"\",\"Status\":\"\",\"StatusReason\":\"\",\"UpdatedAt\":\"0001-01-01T00:00:00Z\"}", res)
+}
@@ -0,0 +1,66 @@
+package balance
+
+import (
+	"accreditation/app"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+type balance struct {
+	log         Logger
+	config      *Config
+	httpService Http
+}
+
+type Http interface {
+	GetWithContext(ctx context.Context, url string) ([]byte, int, error)
+}
+
+type BalanceResponse struct {
+	AccountKey       string `json:"account_key"`
+	AvailableBalance int    `json:"available_balance"`
+	HeldAmount       int    `json:"held_amount"`
+}
+
+func (b *balance) GetBalanceWithContext(ctx context.Context, input *app.GetBalanceInput) (*app.GetBalanceOutput, error) {
+	u := b.config.Url + input.AccountKey
+	if input.Currency != "" {
+		u += "?currency=" + url.QueryEscape(input.Currency)
+	}
+	body, statusCode, err := b.httpService.GetWithContext(ctx, u)
+	if err != nil {
+		b.log.Error(fmt.Sprintf("http get error %s", err.Error()))
+		return nil, err
+	}
+
+	// balance only knows accounts that already had an entry.
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("balance returned status %d", statusCode)
+	}
+
+	br := &BalanceResponse{}
+	if err := json.Unmarshal(body, br); err != nil {
+		b.log.Error(fmt.Sprintf("balance response error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.GetBalanceOutput{
+		AvailableBalance: br.AvailableBalance,
+		HeldAmount:       br.HeldAmount,
+	}, nil
+}
+
+func New(log Logger, config *Config, httpService Http) app.Balance {
+	return &balance{
+		log:         log,
+		config:      config,
+		httpService: httpService,
+	}
+}
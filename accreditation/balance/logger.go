@@ -0,0 +1,6 @@
+package balance
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
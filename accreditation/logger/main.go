@@ -2,6 +2,9 @@ package logger
 
 import (
 	"accreditation/app"
+	"accreditation/balance"
+	"accreditation/notifier"
+	"accreditation/recount"
 	"accreditation/repository"
 	"accreditation/routes"
 	"accreditation/server"
@@ -22,6 +25,6 @@ func (l *logs) Error(msg string) {
 	log.Print(msg)
 }
 
-func New() (app.Logger, server.Logger, routes.Logger, repository.Logger) {
-	return &logs{}, &logs{}, &logs{}, &logs{}
+func New() (app.Logger, server.Logger, routes.Logger, repository.Logger, balance.Logger, notifier.Logger, recount.Logger) {
+	return &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}
 }
@@ -2,7 +2,10 @@ package main
 
 import (
 	"accreditation/app"
+	"accreditation/balance"
 	"accreditation/logger"
+	"accreditation/notifier"
+	"accreditation/recount"
 	"accreditation/repository"
 	"accreditation/routes"
 	"accreditation/server"
@@ -11,13 +14,28 @@ import (
 )
 
 func main() {
-	logApp, logServer, logRoutes, logDynamodb := logger.New()
+	logApp, logServer, logRoutes, logDynamodb, logBalance, logNotifier, logRecount := logger.New()
 	dynamodbService := services.NewDynamodb()
 	dynamodbConfig := repository.Config{
-		TableName: os.Getenv("TABLE_NAME"),
+		TableName:         os.Getenv("TABLE_NAME"),
+		DocumentTableName: os.Getenv("DOCUMENT_TABLE_NAME"),
+		StatusTableName:   os.Getenv("STATUS_TABLE_NAME"),
 	}
+	dynamodbConfig.WithMaxAccountsPerDocument(os.Getenv("MAX_ACCOUNTS_PER_DOCUMENT"))
 	dynamodb := repository.NewDynamodb(dynamodbService, logDynamodb, dynamodbConfig)
-	accreditation := app.New(dynamodb, logApp)
+	confBalance := &balance.Config{}
+	confBalance.WithUrl(os.Getenv("URL_BALANCE"))
+	balanceHttp, notifierHttp := services.NewHttp()
+	balanceService := balance.New(logBalance, confBalance, balanceHttp)
+	confNotifier := &notifier.Config{}
+	confNotifier.WithUrl(os.Getenv("URL_WEBHOOK_EVENTS"))
+	webhook := notifier.New(logNotifier, confNotifier, notifierHttp)
+	accreditation := app.New(dynamodb, balanceService, webhook, logApp)
+	if len(os.Args) > 1 && os.Args[1] == "recount" {
+		command := recount.New(accreditation, logRecount)
+		command.Run(os.Args[2:])
+		return
+	}
 	routes := routes.New(accreditation, logRoutes)
 	serverHttp := server.New(routes, logServer)
 	serverHttp.Start()
@@ -0,0 +1,6 @@
+package notifier
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
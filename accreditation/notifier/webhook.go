@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"accreditation/app"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const postTimeout = 5 * time.Second
+
+type webhook struct {
+	log         Logger
+	config      *Config
+	httpService Http
+}
+
+type Http interface {
+	PostWithContext(ctx context.Context, url string, payload []byte) ([]byte, int, error)
+}
+
+type AccountPayload struct {
+	PersonType string   `json:"person_type"`
+	Currencies []string `json:"currencies"`
+}
+
+type EventPayload struct {
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	AccountKey string          `json:"account_key"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       *AccountPayload `json:"data"`
+}
+
+// NotifyWithContext posts in the background so account creation never waits on
+// the webhook service.
+func (w *webhook) NotifyWithContext(ctx context.Context, input *app.NotifyInput) error {
+	if w.config.Url == "" {
+		return nil
+	}
+
+	pb, err := json.Marshal(&EventPayload{
+		EventID:    input.EventID,
+		EventType:  input.EventType,
+		AccountKey: input.AccountKey,
+		OccurredAt: input.OccurredAt,
+		Data: &AccountPayload{
+			PersonType: input.PersonType,
+			Currencies: input.Currencies,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	go w.post(input.EventID, pb)
+	return nil
+}
+
+func (w *webhook) post(eventID string, payload []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), postTimeout)
+	defer cancel()
+
+	_, statusCode, err := w.httpService.PostWithContext(ctx, w.config.Url, payload)
+	if err != nil {
+		w.log.Error(fmt.Sprintf("notify event %s error %s", eventID, err.Error()))
+		return
+	}
+	if statusCode != http.StatusAccepted {
+		w.log.Error(fmt.Sprintf("notify event %s returned status %d", eventID, statusCode))
+	}
+}
+
+func New(log Logger, config *Config, httpService Http) app.Notifier {
+	return &webhook{
+		log:         log,
+		config:      config,
+		httpService: httpService,
+	}
+}
@@ -0,0 +1,36 @@
+package recount
+
+import (
+	"accreditation/app"
+	"context"
+	"fmt"
+)
+
+// Command recomputes the account count of each document from its accounts. It
+// must run while no account is being created or closed, otherwise a concurrent
+// change may be counted twice or not at all.
+type Command struct {
+	log           Logger
+	accreditation app.Accreditation
+}
+
+func (c *Command) Run(documentNumbers []string) {
+	c.log.Info("Recounting document accounts")
+	i := &app.RecountDocumentsInput{
+		DocumentNumbers: documentNumbers,
+	}
+
+	o, err := c.accreditation.RecountDocumentsWithContext(context.Background(), i)
+	if err != nil {
+		c.log.Fatal(fmt.Sprintf("Could not recount document accounts %s", err.Error()))
+	}
+
+	c.log.Info(fmt.Sprintf("Recounted %d documents", len(o.DocumentNumbers)))
+}
+
+func New(accreditation app.Accreditation, log Logger) *Command {
+	return &Command{
+		accreditation: accreditation,
+		log:           log,
+	}
+}
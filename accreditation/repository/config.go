@@ -1,10 +1,36 @@
 package repository
 
+import "strconv"
+
+const DefaultMaxAccountsPerDocument = 1
+
 type Config struct {
-	TableName string
+	TableName              string
+	DocumentTableName      string
+	StatusTableName        string
+	MaxAccountsPerDocument int
 }
 
 func (c *Config) WithTableName(tableName string) *Config {
 	c.TableName = tableName
 	return c
 }
+
+func (c *Config) WithDocumentTableName(tableName string) *Config {
+	c.DocumentTableName = tableName
+	return c
+}
+
+func (c *Config) WithStatusTableName(tableName string) *Config {
+	c.StatusTableName = tableName
+	return c
+}
+
+func (c *Config) WithMaxAccountsPerDocument(max string) *Config {
+	n, err := strconv.Atoi(max)
+	if err != nil || n <= 0 {
+		n = DefaultMaxAccountsPerDocument
+	}
+	c.MaxAccountsPerDocument = n
+	return c
+}
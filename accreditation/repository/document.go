@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+)
+
+// ListDocumentNumbersWithContext scans the accounts for the documents that opened them.
+func (d *db) ListDocumentNumbersWithContext(ctx context.Context) ([]string, error) {
+	scanInput := &dynamodb.ScanInput{
+		ProjectionExpression: aws.String("DocumentNumber"),
+		TableName:            aws.String(d.config.TableName),
+	}
+
+	seen := make(map[string]bool)
+	documentNumbers := make([]string, 0)
+	for {
+		scanOutput, err := d.dynamodbService.ScanWithContext(ctx, scanInput)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error scan %s", err.Error()))
+			return nil, err
+		}
+
+		for _, item := range scanOutput.Items {
+			documentNumber := stringAttribute(item, "DocumentNumber")
+			if documentNumber != "" && !seen[documentNumber] {
+				seen[documentNumber] = true
+				documentNumbers = append(documentNumbers, documentNumber)
+			}
+		}
+
+		if len(scanOutput.LastEvaluatedKey) == 0 {
+			break
+		}
+		scanInput.ExclusiveStartKey = scanOutput.LastEvaluatedKey
+	}
+
+	return documentNumbers, nil
+}
+
+func (d *db) PutAccountCountWithContext(ctx context.Context, input *app.PutAccountCountInput) error {
+	i := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"DocumentNumber": {
+				S: aws.String(input.DocumentNumber),
+			},
+		},
+		UpdateExpression: aws.String("SET AccountCount = :count"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":count": {
+				N: aws.String(strconv.Itoa(input.AccountCount)),
+			},
+		},
+		TableName: aws.String(d.config.DocumentTableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb put account count %v", input))
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error put account count %s", err.Error()))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newDocumentDb(v string, t *testing.T) app.Persistence {
+	c := Config{
+		TableName:         "account",
+		DocumentTableName: "account-document",
+	}
+	return NewDynamodb(newServiceMock(v, t), newLogMock(), c)
+}
+
+func TestDb_ListDocumentNumbers(t *testing.T) {
+	d := newDocumentDb("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"FilterExpression\":null,\"IndexName\":null,\"Limit\":null,\"ProjectionExpression\":\"DocumentNumber\",\"ReturnConsumedCapacity\":null,\"ScanFilter\":null,\"Segment\":null,\"Select\":null,\"TableName\":\"account\",\"TotalSegments\":null}", t)
+	res, err := d.ListDocumentNumbersWithContext(context.Background())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "[\"52998224725\",\"11222333000181\"]", string(b))
+}
+
+func TestDb_NotListDocumentNumbersWhenScanError(t *testing.T) {
+	d := newDocumentDb("", t)
+	res, err := d.ListDocumentNumbersWithContext(context.Background())
+	assert.Nil(t, res)
+	assert.Equal(t, "scan error", err.Error())
+}
+
+func TestDb_PutAccountCount(t *testing.T) {
+	d := newDocumentDb("{\"AttributeUpdates\":null,\"ConditionExpression\":null,\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"DocumentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"52998224725\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"account-document\",\"UpdateExpression\":\"SET AccountCount = :count\"}", t)
+	err := d.PutAccountCountWithContext(context.Background(), &app.PutAccountCountInput{
+		DocumentNumber: "52998224725",
+		AccountCount:   2,
+	})
+	assert.Nil(t, err)
+}
+
+func TestDb_NotPutAccountCountWhenError(t *testing.T) {
+	d := newDocumentDb("", t)
+	err := d.PutAccountCountWithContext(context.Background(), &app.PutAccountCountInput{
+		DocumentNumber: "52998224725",
+		AccountCount:   2,
+	})
+	assert.Equal(t, "db error", err.Error())
+}
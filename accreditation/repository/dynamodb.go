@@ -5,13 +5,18 @@ import (
 	"context"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
 )
 
 type Dynamodb interface {
 	PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
 	GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	TransactWriteItemsWithContext(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
+	UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	ScanWithContext(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
 }
 
 type db struct {
@@ -21,26 +26,91 @@ type db struct {
 }
 
 func (d *db) InsertWithContext(ctx context.Context, input *app.InsertInput) (*app.InsertOutput, error) {
-	putItemInput := &dynamodb.PutItemInput{
-		Item: map[string]*dynamodb.AttributeValue{
-			"ExternalKey": {
-				S: aws.String(input.ExternalKey),
+	max := d.config.MaxAccountsPerDocument
+	if max <= 0 {
+		max = DefaultMaxAccountsPerDocument
+	}
+
+	item := map[string]*dynamodb.AttributeValue{
+		"ExternalKey": {
+			S: aws.String(input.ExternalKey),
+		},
+		"DocumentNumber": {
+			S: aws.String(input.DocumentNumber),
+		},
+		"Status": {
+			S: aws.String(input.Status),
+		},
+		"HolderName": {
+			S: aws.String(input.HolderName),
+		},
+		"CreatedAt": {
+			S: aws.String(input.CreatedAt.UTC().Format(time.RFC3339Nano)),
+		},
+	}
+	if input.Email != "" {
+		item["Email"] = &dynamodb.AttributeValue{
+			S: aws.String(input.Email),
+		}
+	}
+	if input.Phone != "" {
+		item["Phone"] = &dynamodb.AttributeValue{
+			S: aws.String(input.Phone),
+		}
+	}
+	if len(input.Currencies) > 0 {
+		item["Currencies"] = &dynamodb.AttributeValue{
+			L: currenciesAttribute(input.Currencies),
+		}
+	}
+
+	transactWriteItemsInput := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Put: &dynamodb.Put{
+					Item:                item,
+					TableName:           aws.String(d.config.TableName),
+					ConditionExpression: aws.String("attribute_not_exists(ExternalKey)"),
+				},
 			},
-			"DocumentNumber": {
-				S: aws.String(input.DocumentNumber),
+			{
+				// Counts the accounts opened for the document, refusing to go past the limit.
+				Update: &dynamodb.Update{
+					Key: map[string]*dynamodb.AttributeValue{
+						"DocumentNumber": {
+							S: aws.String(input.DocumentNumber),
+						},
+					},
+					UpdateExpression:    aws.String("ADD AccountCount :one"),
+					ConditionExpression: aws.String("attribute_not_exists(AccountCount) OR AccountCount < :max"),
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+						":one": {
+							N: aws.String("1"),
+						},
+						":max": {
+							N: aws.String(strconv.Itoa(max)),
+						},
+					},
+					TableName: aws.String(d.config.DocumentTableName),
+				},
 			},
 		},
-		TableName:           aws.String(d.config.TableName),
-		ConditionExpression: aws.String("attribute_not_exists(ExternalKey)"),
 	}
 	d.log.Info(fmt.Sprintf("Dynamodb input item %v", input))
-	_, err := d.dynamodbService.PutItemWithContext(ctx, putItemInput)
+	_, err := d.dynamodbService.TransactWriteItemsWithContext(ctx, transactWriteItemsInput)
 	if err != nil {
-		if ae, ok := err.(awserr.RequestFailure); ok && ae.Code() == "ConditionalCheckFailedException" {
-			d.log.Info(fmt.Sprintf("%s", ae.Code()))
-			return &app.InsertOutput{
-				AlreadyExists: true,
-			}, nil
+		if ae, ok := err.(*dynamodb.TransactionCanceledException); ok && len(ae.CancellationReasons) == 2 {
+			d.log.Info(fmt.Sprintf("%s %s", ae.Code(), ae.Message()))
+			if conditionalCheckFailed(ae.CancellationReasons[0]) {
+				return &app.InsertOutput{
+					AlreadyExists: true,
+				}, nil
+			}
+			if conditionalCheckFailed(ae.CancellationReasons[1]) {
+				return &app.InsertOutput{
+					DocumentLimitReached: true,
+				}, nil
+			}
 		}
 		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
 		return nil, err
@@ -51,6 +121,38 @@ func (d *db) InsertWithContext(ctx context.Context, input *app.InsertInput) (*ap
 	}, nil
 }
 
+// stringAttribute reads optional attributes, which older accounts may not have.
+func stringAttribute(item map[string]*dynamodb.AttributeValue, name string) string {
+	if v, ok := item[name]; ok && v != nil {
+		return aws.StringValue(v.S)
+	}
+	return ""
+}
+
+func currenciesAttribute(currencies []string) []*dynamodb.AttributeValue {
+	l := make([]*dynamodb.AttributeValue, 0, len(currencies))
+	for _, c := range currencies {
+		l = append(l, &dynamodb.AttributeValue{S: aws.String(c)})
+	}
+	return l
+}
+
+func currenciesFromItem(item map[string]*dynamodb.AttributeValue) []string {
+	v, ok := item["Currencies"]
+	if !ok || v == nil || len(v.L) == 0 {
+		return nil
+	}
+	currencies := make([]string, 0, len(v.L))
+	for _, c := range v.L {
+		currencies = append(currencies, aws.StringValue(c.S))
+	}
+	return currencies
+}
+
+func conditionalCheckFailed(reason *dynamodb.CancellationReason) bool {
+	return reason != nil && aws.StringValue(reason.Code) == "ConditionalCheckFailed"
+}
+
 func (d *db) GetWithContext(ctx context.Context, input *app.GetInput) (*app.GetOutput, error) {
 	attributeValue := make(map[string]*dynamodb.AttributeValue)
 	attributeValue["ExternalKey"] = &dynamodb.AttributeValue{
@@ -70,10 +172,41 @@ func (d *db) GetWithContext(ctx context.Context, input *app.GetInput) (*app.GetO
 		return nil, nil
 	}
 
-	return &app.GetOutput{
-		ExternalKey:    *getItemOutput.Item["ExternalKey"].S,
-		DocumentNumber: *getItemOutput.Item["DocumentNumber"].S,
-	}, nil
+	getOutput, err := accountFromItem(getItemOutput.Item)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get item %s", err.Error()))
+		return nil, err
+	}
+
+	return getOutput, nil
+}
+
+func accountFromItem(item map[string]*dynamodb.AttributeValue) (*app.GetOutput, error) {
+	o := &app.GetOutput{
+		ExternalKey:    stringAttribute(item, "ExternalKey"),
+		DocumentNumber: stringAttribute(item, "DocumentNumber"),
+		Status:         stringAttribute(item, "Status"),
+		StatusReason:   stringAttribute(item, "StatusReason"),
+		HolderName:     stringAttribute(item, "HolderName"),
+		Email:          stringAttribute(item, "Email"),
+		Phone:          stringAttribute(item, "Phone"),
+		Currencies:     currenciesFromItem(item),
+	}
+	if v := stringAttribute(item, "CreatedAt"); v != "" {
+		createdAt, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, err
+		}
+		o.CreatedAt = createdAt
+	}
+
+	limits, err := limitsFromItem(item)
+	if err != nil {
+		return nil, err
+	}
+	o.Limits = limits
+
+	return o, nil
 }
 
 func NewDynamodb(d Dynamodb, log Logger, config Config) app.Persistence {
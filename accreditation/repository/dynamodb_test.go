@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 type serviceMock struct {
@@ -43,6 +44,18 @@ func ErrorAws() awserr.RequestFailure {
 	return &ea{}
 }
 
+func transactionCanceled(reasons ...string) error {
+	cancellationReasons := make([]*dynamodb.CancellationReason, 0)
+	for _, r := range reasons {
+		cancellationReasons = append(cancellationReasons, &dynamodb.CancellationReason{
+			Code: aws.String(r),
+		})
+	}
+	return &dynamodb.TransactionCanceledException{
+		CancellationReasons: cancellationReasons,
+	}
+}
+
 func (s serviceMock) PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
 	if s.v != "" {
 		if s.v == "1" {
@@ -55,6 +68,81 @@ func (s serviceMock) PutItemWithContext(ctx context.Context, input *dynamodb.Put
 	}
 	return nil, errors.New("db error")
 }
+func (s serviceMock) UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	if s.v != "" {
+		if s.v == "1" {
+			return nil, ErrorAws()
+		}
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return nil, nil
+	}
+	return nil, errors.New("db error")
+}
+func (s serviceMock) QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("query error")
+	}
+	v, err := json.Marshal(input)
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, s.v, string(v))
+	return &dynamodb.QueryOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{
+				"ExternalKey":    {S: aws.String("1")},
+				"DocumentNumber": {S: aws.String("52998224725")},
+				"Status":         {S: aws.String("blocked")},
+				"HolderName":     {S: aws.String("Maria Silva")},
+				"CreatedAt":      {S: aws.String("2022-01-18T10:00:00Z")},
+			},
+		},
+		LastEvaluatedKey: map[string]*dynamodb.AttributeValue{
+			"ExternalKey":    {S: aws.String("1")},
+			"DocumentNumber": {S: aws.String("52998224725")},
+		},
+	}, nil
+}
+func (s serviceMock) TransactWriteItemsWithContext(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	if s.v != "" {
+		if s.v == "1" {
+			return nil, transactionCanceled("ConditionalCheckFailed", "None")
+		}
+		if s.v == "2" {
+			return nil, transactionCanceled("None", "ConditionalCheckFailed")
+		}
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return nil, nil
+	}
+	return nil, errors.New("db error")
+}
+func (s serviceMock) ScanWithContext(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("scan error")
+	}
+	if input.ExclusiveStartKey == nil {
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return &dynamodb.ScanOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{"DocumentNumber": {S: aws.String("52998224725")}},
+				{"DocumentNumber": {S: aws.String("11222333000181")}},
+			},
+			LastEvaluatedKey: map[string]*dynamodb.AttributeValue{
+				"ExternalKey": {S: aws.String("2")},
+			},
+		}, nil
+	}
+	return &dynamodb.ScanOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{"DocumentNumber": {S: aws.String("52998224725")}},
+			{},
+		},
+	}, nil
+}
 func (s serviceMock) GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
 	if s.v == "1" {
 		return nil, errors.New("get error")
@@ -70,6 +158,34 @@ func (s serviceMock) GetItemWithContext(ctx context.Context, input *dynamodb.Get
 		attributeValue["DocumentNumber"] = &dynamodb.AttributeValue{
 			S: aws.String("2"),
 		}
+		attributeValue["Status"] = &dynamodb.AttributeValue{
+			S: aws.String("blocked"),
+		}
+		attributeValue["StatusReason"] = &dynamodb.AttributeValue{
+			S: aws.String("fraud-suspected"),
+		}
+		attributeValue["HolderName"] = &dynamodb.AttributeValue{
+			S: aws.String("Maria Silva"),
+		}
+		attributeValue["Phone"] = &dynamodb.AttributeValue{
+			S: aws.String("+5511987654321"),
+		}
+		attributeValue["CreatedAt"] = &dynamodb.AttributeValue{
+			S: aws.String("2022-01-18T10:00:00Z"),
+		}
+		attributeValue["Limits"] = &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"MaxWithdrawAmount":   {N: aws.String("50000")},
+				"DailyWithdrawAmount": {N: aws.String("100000")},
+				"MaxInstallments":     {N: aws.String("6")},
+			},
+		}
+		attributeValue["Currencies"] = &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				{S: aws.String("BRL")},
+				{S: aws.String("USD")},
+			},
+		}
 		return &dynamodb.GetItemOutput{
 			Item: attributeValue,
 		}, nil
@@ -93,20 +209,45 @@ func newLogMock() Logger {
 
 func TestDb_Insert(t *testing.T) {
 	l := newLogMock()
-	s := newServiceMock("{\"ConditionExpression\":\"attribute_not_exists(ExternalKey)\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"DocumentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"account\"}", t)
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(ExternalKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null},\"DocumentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Email\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"maria@example.com\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"HolderName\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Maria Silva\",\"SS\":null},\"Status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"account\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"attribute_not_exists(AccountCount) OR AccountCount \\u003c :max\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":max\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"DocumentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"account-document\",\"UpdateExpression\":\"ADD AccountCount :one\"}}]}", t)
 	c := Config{
-		TableName: "account",
+		TableName:              "account",
+		DocumentTableName:      "account-document",
+		MaxAccountsPerDocument: 2,
 	}
 	d := NewDynamodb(s, l, c)
 	i := &app.InsertInput{
 		DocumentNumber: "1",
 		ExternalKey:    "2",
+		Status:         "active",
+		HolderName:     "Maria Silva",
+		Email:          "maria@example.com",
+		CreatedAt:      time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
 	}
 	res, err := d.InsertWithContext(context.Background(), i)
 	assert.Nil(t, err)
 	b, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"AlreadyExists\":false}", string(b))
+	assert.Equal(t, "{\"AlreadyExists\":false,\"DocumentLimitReached\":false}", string(b))
+}
+
+func TestDb_NotInsertWhenDocumentLimitReached(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("2", t)
+	c := Config{
+		TableName:         "account",
+		DocumentTableName: "account-document",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		DocumentNumber: "1",
+		ExternalKey:    "2",
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"DocumentLimitReached\":true}", string(b))
 }
 
 func TestDb_NotInsert(t *testing.T) {
@@ -140,7 +281,7 @@ func TestDb_NotInsertWhenExternalKeyHasExists(t *testing.T) {
 	assert.Nil(t, err)
 	b, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"AlreadyExists\":true}", string(b))
+	assert.Equal(t, "{\"AlreadyExists\":true,\"DocumentLimitReached\":false}", string(b))
 }
 
 func TestDb_Get(t *testing.T) {
@@ -157,7 +298,7 @@ func TestDb_Get(t *testing.T) {
 	assert.Nil(t, err)
 	b, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"ExternalKey\":\"1\",\"DocumentNumber\":\"2\"}", string(b))
+	assert.Equal(t, "{\"ExternalKey\":\"1\",\"DocumentNumber\":\"2\",\"Status\":\"blocked\",\"StatusReason\":\"fraud-suspected\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"+5511987654321\",\"CreatedAt\":\"2022-01-18T10:00:00Z\",\"Limits\":{\"MaxWithdrawAmount\":50000,\"DailyWithdrawAmount\":100000,\"MonthlyBuyingAmount\":0,\"MaxInstallments\":6},\"Currencies\":[\"BRL\",\"USD\"]}", string(b))
 }
 
 func TestDb_NotGetWhenGetItemError(t *testing.T) {
@@ -189,3 +330,10 @@ func TestDb_NotGetWhenGetItemNotFound(t *testing.T) {
 	assert.Nil(t, res)
 	assert.Nil(t, err)
 }
+
+func TestConfig_WithMaxAccountsPerDocument(t *testing.T) {
+	c := &Config{}
+	assert.Equal(t, 3, c.WithMaxAccountsPerDocument("3").MaxAccountsPerDocument)
+	assert.Equal(t, 1, c.WithMaxAccountsPerDocument("").MaxAccountsPerDocument)
+	assert.Equal(t, 1, c.WithMaxAccountsPerDocument("0").MaxAccountsPerDocument)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+)
+
+func (d *db) UpdateLimitsWithContext(ctx context.Context, input *app.UpdateLimitsInput) (*app.UpdateLimitsOutput, error) {
+	i := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"ExternalKey": {
+				S: aws.String(input.ExternalKey),
+			},
+		},
+		UpdateExpression:    aws.String("SET Limits = :limits, UpdatedAt = :updatedAt"),
+		ConditionExpression: aws.String(accountOpenCondition),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":limits": {
+				M: limitsAttribute(input.Limits),
+			},
+			":updatedAt": {
+				S: aws.String(input.UpdatedAt.UTC().Format(time.RFC3339Nano)),
+			},
+			":closed": {
+				S: aws.String(app.StatusClosed),
+			},
+		},
+		TableName: aws.String(d.config.TableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb update limits %v", input))
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		if ae, ok := err.(awserr.RequestFailure); ok && ae.Code() == "ConditionalCheckFailedException" {
+			return &app.UpdateLimitsOutput{
+				AccountClosed: true,
+			}, nil
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.UpdateLimitsOutput{
+		AccountClosed: false,
+	}, nil
+}
+
+func limitsAttribute(l *app.AccountLimits) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"MaxWithdrawAmount":   {N: aws.String(strconv.Itoa(l.MaxWithdrawAmount))},
+		"DailyWithdrawAmount": {N: aws.String(strconv.Itoa(l.DailyWithdrawAmount))},
+		"MonthlyBuyingAmount": {N: aws.String(strconv.Itoa(l.MonthlyBuyingAmount))},
+		"MaxInstallments":     {N: aws.String(strconv.Itoa(l.MaxInstallments))},
+	}
+}
+
+func intAttribute(item map[string]*dynamodb.AttributeValue, name string) (int, error) {
+	if v, ok := item[name]; ok && v != nil && v.N != nil {
+		return strconv.Atoi(aws.StringValue(v.N))
+	}
+	return 0, nil
+}
+
+// limitsFromItem returns nil for accounts that never had limits configured.
+func limitsFromItem(item map[string]*dynamodb.AttributeValue) (*app.AccountLimits, error) {
+	v, ok := item["Limits"]
+	if !ok || v == nil || v.M == nil {
+		return nil, nil
+	}
+
+	l := &app.AccountLimits{}
+	for _, f := range []struct {
+		name  string
+		value *int
+	}{
+		{"MaxWithdrawAmount", &l.MaxWithdrawAmount},
+		{"DailyWithdrawAmount", &l.DailyWithdrawAmount},
+		{"MonthlyBuyingAmount", &l.MonthlyBuyingAmount},
+		{"MaxInstallments", &l.MaxInstallments},
+	} {
+		n, err := intAttribute(v.M, f.name)
+		if err != nil {
+			return nil, err
+		}
+		*f.value = n
+	}
+
+	return l, nil
+}
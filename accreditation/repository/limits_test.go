@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDb_UpdateLimits(t *testing.T) {
+	d := NewDynamodb(newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":\"attribute_exists(ExternalKey) AND (attribute_not_exists(#status) OR #status \\u003c\\u003e :closed)\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":closed\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"closed\",\"SS\":null},\":limits\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":{\"DailyWithdrawAmount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"100000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"MaxInstallments\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"6\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"MaxWithdrawAmount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"50000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"MonthlyBuyingAmount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"N\":null,\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":updatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null}},\"Key\":{\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"account\",\"UpdateExpression\":\"SET Limits = :limits, UpdatedAt = :updatedAt\"}", t), newLogMock(), Config{TableName: "account"})
+	i := &app.UpdateLimitsInput{
+		ExternalKey: "1",
+		Limits: &app.AccountLimits{
+			MaxWithdrawAmount:   50000,
+			DailyWithdrawAmount: 100000,
+			MonthlyBuyingAmount: 500000,
+			MaxInstallments:     6,
+		},
+		UpdatedAt: time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+	}
+	res, err := d.UpdateLimitsWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountClosed\":false}", string(b))
+}
+
+func TestDb_NotUpdateLimitsWhenAccountClosed(t *testing.T) {
+	d := NewDynamodb(newServiceMock("1", t), newLogMock(), Config{TableName: "account"})
+	i := &app.UpdateLimitsInput{
+		ExternalKey: "1",
+		Limits:      &app.AccountLimits{},
+	}
+	res, err := d.UpdateLimitsWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountClosed\":true}", string(b))
+}
+
+func TestDb_NotUpdateLimitsWhenError(t *testing.T) {
+	d := NewDynamodb(newServiceMock("", t), newLogMock(), Config{TableName: "account"})
+	i := &app.UpdateLimitsInput{
+		ExternalKey: "1",
+		Limits:      &app.AccountLimits{},
+	}
+	res, err := d.UpdateLimitsWithContext(context.Background(), i)
+	assert.Equal(t, "db error", err.Error())
+	assert.Nil(t, res)
+}
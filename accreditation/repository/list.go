@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const accountDocumentIndex = "DocumentNumber-ExternalKey-index"
+
+func (d *db) ListWithContext(ctx context.Context, input *app.ListInput) (*app.ListOutput, error) {
+	queryInput := &dynamodb.QueryInput{
+		IndexName:              aws.String(accountDocumentIndex),
+		KeyConditionExpression: aws.String("DocumentNumber = :documentNumber"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":documentNumber": {
+				S: aws.String(input.DocumentNumber),
+			},
+		},
+		Limit:     aws.Int64(int64(input.Limit)),
+		TableName: aws.String(d.config.TableName),
+	}
+
+	if input.Status != "" {
+		// Accounts created before statuses existed have no Status and are active.
+		filter := "#status = :status"
+		if input.Status == app.StatusActive {
+			filter = "attribute_not_exists(#status) OR #status = :status"
+		}
+		queryInput.FilterExpression = aws.String(filter)
+		queryInput.ExpressionAttributeNames = map[string]*string{
+			"#status": aws.String("Status"),
+		}
+		queryInput.ExpressionAttributeValues[":status"] = &dynamodb.AttributeValue{
+			S: aws.String(input.Status),
+		}
+	}
+
+	if input.Cursor != "" {
+		startKey, err := decodeCursor(input.Cursor)
+		if err != nil || stringAttribute(startKey, "DocumentNumber") != input.DocumentNumber {
+			return &app.ListOutput{
+				CursorInvalid: true,
+			}, nil
+		}
+		queryInput.ExclusiveStartKey = startKey
+	}
+
+	queryOutput, err := d.dynamodbService.QueryWithContext(ctx, queryInput)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error query %s", err.Error()))
+		return nil, err
+	}
+
+	accounts := make([]*app.GetOutput, 0)
+	for _, item := range queryOutput.Items {
+		o, err := accountFromItem(item)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error query item %s", err.Error()))
+			return nil, err
+		}
+		accounts = append(accounts, o)
+	}
+
+	nextCursor, err := encodeCursor(queryOutput.LastEvaluatedKey)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error encode cursor %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.ListOutput{
+		Accounts:   accounts,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// The cursor is the LastEvaluatedKey of the index, whose attributes are all strings.
+func encodeCursor(key map[string]*dynamodb.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+
+	values := make(map[string]string)
+	for name, v := range key {
+		values[name] = aws.StringValue(v.S)
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(cursor string) (map[string]*dynamodb.AttributeValue, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+
+	key := make(map[string]*dynamodb.AttributeValue)
+	for _, name := range []string{"DocumentNumber", "ExternalKey"} {
+		v, ok := values[name]
+		if !ok || v == "" {
+			return nil, fmt.Errorf("cursor missing %s", name)
+		}
+		key[name] = &dynamodb.AttributeValue{
+			S: aws.String(v),
+		}
+	}
+
+	return key, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDb_List(t *testing.T) {
+	d := NewDynamodb(newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":documentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"52998224725\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":\"DocumentNumber-ExternalKey-index\",\"KeyConditionExpression\":\"DocumentNumber = :documentNumber\",\"KeyConditions\":null,\"Limit\":20,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"account\"}", t), newLogMock(), Config{TableName: "account"})
+	i := &app.ListInput{
+		DocumentNumber: "52998224725",
+		Limit:          20,
+	}
+	res, err := d.ListWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Accounts\":[{\"ExternalKey\":\"1\",\"DocumentNumber\":\"52998224725\",\"Status\":\"blocked\",\"StatusReason\":\"\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"CreatedAt\":\"2022-01-18T10:00:00Z\",\"Limits\":null,\"Currencies\":null}],\"NextCursor\":\"eyJEb2N1bWVudE51bWJlciI6IjUyOTk4MjI0NzI1IiwiRXh0ZXJuYWxLZXkiOiIxIn0\",\"CursorInvalid\":false}", string(b))
+}
+
+func TestDb_ListWithFilters(t *testing.T) {
+	d := NewDynamodb(newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":{\"DocumentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"52998224725\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":documentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"52998224725\",\"SS\":null},\":status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"blocked\",\"SS\":null}},\"FilterExpression\":\"#status = :status\",\"IndexName\":\"DocumentNumber-ExternalKey-index\",\"KeyConditionExpression\":\"DocumentNumber = :documentNumber\",\"KeyConditions\":null,\"Limit\":10,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"account\"}", t), newLogMock(), Config{TableName: "account"})
+	i := &app.ListInput{
+		DocumentNumber: "52998224725",
+		Status:         "blocked",
+		Limit:          10,
+		Cursor:         "eyJEb2N1bWVudE51bWJlciI6IjUyOTk4MjI0NzI1IiwiRXh0ZXJuYWxLZXkiOiIxIn0",
+	}
+	res, err := d.ListWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Accounts\":[{\"ExternalKey\":\"1\",\"DocumentNumber\":\"52998224725\",\"Status\":\"blocked\",\"StatusReason\":\"\",\"HolderName\":\"Maria Silva\",\"Email\":\"\",\"Phone\":\"\",\"CreatedAt\":\"2022-01-18T10:00:00Z\",\"Limits\":null,\"Currencies\":null}],\"NextCursor\":\"eyJEb2N1bWVudE51bWJlciI6IjUyOTk4MjI0NzI1IiwiRXh0ZXJuYWxLZXkiOiIxIn0\",\"CursorInvalid\":false}", string(b))
+}
+
+func TestDb_ListWhenActive(t *testing.T) {
+	d := NewDynamodb(newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":documentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"52998224725\",\"SS\":null},\":status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null}},\"FilterExpression\":\"attribute_not_exists(#status) OR #status = :status\",\"IndexName\":\"DocumentNumber-ExternalKey-index\",\"KeyConditionExpression\":\"DocumentNumber = :documentNumber\",\"KeyConditions\":null,\"Limit\":20,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"account\"}", t), newLogMock(), Config{TableName: "account"})
+	i := &app.ListInput{
+		DocumentNumber: "52998224725",
+		Status:         "active",
+		Limit:          20,
+	}
+	_, err := d.ListWithContext(context.Background(), i)
+	assert.Nil(t, err)
+}
+
+func TestDb_NotListWhenCursorInvalid(t *testing.T) {
+	d := NewDynamodb(newServiceMock("1", t), newLogMock(), Config{TableName: "account"})
+	i := &app.ListInput{
+		DocumentNumber: "52998224725",
+		Limit:          20,
+		Cursor:         "not-a-cursor",
+	}
+	res, err := d.ListWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Accounts\":null,\"NextCursor\":\"\",\"CursorInvalid\":true}", string(b))
+}
+
+func TestDb_NotListWhenCursorFromAnotherDocument(t *testing.T) {
+	d := NewDynamodb(newServiceMock("1", t), newLogMock(), Config{TableName: "account"})
+	i := &app.ListInput{
+		DocumentNumber: "11144477735",
+		Limit:          20,
+		Cursor:         "eyJEb2N1bWVudE51bWJlciI6IjUyOTk4MjI0NzI1IiwiRXh0ZXJuYWxLZXkiOiIxIn0",
+	}
+	res, err := d.ListWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Accounts\":null,\"NextCursor\":\"\",\"CursorInvalid\":true}", string(b))
+}
+
+func TestDb_NotListWhenQueryError(t *testing.T) {
+	d := NewDynamodb(newServiceMock("", t), newLogMock(), Config{TableName: "account"})
+	i := &app.ListInput{
+		DocumentNumber: "52998224725",
+		Limit:          20,
+	}
+	res, err := d.ListWithContext(context.Background(), i)
+	assert.Equal(t, "query error", err.Error())
+	assert.Nil(t, res)
+}
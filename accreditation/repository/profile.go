@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"time"
+)
+
+// accountOpenCondition refuses updates to missing or closed accounts.
+const accountOpenCondition = "attribute_exists(ExternalKey) AND (attribute_not_exists(#status) OR #status <> :closed)"
+
+func (d *db) UpdateProfileWithContext(ctx context.Context, input *app.UpdateProfileInput) (*app.UpdateProfileOutput, error) {
+	values := map[string]*dynamodb.AttributeValue{
+		":holderName": {
+			S: aws.String(input.HolderName),
+		},
+		":updatedAt": {
+			S: aws.String(input.UpdatedAt.UTC().Format(time.RFC3339Nano)),
+		},
+		":closed": {
+			S: aws.String(app.StatusClosed),
+		},
+	}
+
+	// Email and phone are optional, so an empty value removes the attribute.
+	set := "SET HolderName = :holderName, UpdatedAt = :updatedAt"
+	remove := ""
+	optional := []struct {
+		name  string
+		value string
+	}{
+		{"Email", input.Email},
+		{"Phone", input.Phone},
+	}
+	for _, o := range optional {
+		name, v := o.name, o.value
+		if v == "" {
+			remove = appendClause(remove, name)
+			continue
+		}
+		values[":"+name] = &dynamodb.AttributeValue{
+			S: aws.String(v),
+		}
+		set = fmt.Sprintf("%s, %s = :%s", set, name, name)
+	}
+	updateExpression := set
+	if remove != "" {
+		updateExpression = fmt.Sprintf("%s REMOVE %s", set, remove)
+	}
+
+	i := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"ExternalKey": {
+				S: aws.String(input.ExternalKey),
+			},
+		},
+		UpdateExpression:    aws.String(updateExpression),
+		ConditionExpression: aws.String(accountOpenCondition),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: values,
+		TableName:                 aws.String(d.config.TableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb update profile %v", input))
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		if ae, ok := err.(awserr.RequestFailure); ok && ae.Code() == "ConditionalCheckFailedException" {
+			return &app.UpdateProfileOutput{
+				AccountClosed: true,
+			}, nil
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.UpdateProfileOutput{
+		AccountClosed: false,
+	}, nil
+}
+
+func appendClause(clauses string, clause string) string {
+	if clauses == "" {
+		return clause
+	}
+	return clauses + ", " + clause
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDb_UpdateProfile(t *testing.T) {
+	d := NewDynamodb(newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":\"attribute_exists(ExternalKey) AND (attribute_not_exists(#status) OR #status \\u003c\\u003e :closed)\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":Email\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"maria@example.com\",\"SS\":null},\":Phone\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"+5511987654321\",\"SS\":null},\":closed\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"closed\",\"SS\":null},\":holderName\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Maria Silva\",\"SS\":null},\":updatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null}},\"Key\":{\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"account\",\"UpdateExpression\":\"SET HolderName = :holderName, UpdatedAt = :updatedAt, Email = :Email, Phone = :Phone\"}", t), newLogMock(), Config{TableName: "account"})
+	i := &app.UpdateProfileInput{
+		ExternalKey: "1",
+		HolderName:  "Maria Silva",
+		Email:       "maria@example.com",
+		Phone:       "+5511987654321",
+		UpdatedAt:   time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+	}
+	res, err := d.UpdateProfileWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountClosed\":false}", string(b))
+}
+
+func TestDb_UpdateProfileWhenRemovingContacts(t *testing.T) {
+	d := NewDynamodb(newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":\"attribute_exists(ExternalKey) AND (attribute_not_exists(#status) OR #status \\u003c\\u003e :closed)\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":closed\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"closed\",\"SS\":null},\":holderName\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Maria Silva\",\"SS\":null},\":updatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null}},\"Key\":{\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"account\",\"UpdateExpression\":\"SET HolderName = :holderName, UpdatedAt = :updatedAt REMOVE Email, Phone\"}", t), newLogMock(), Config{TableName: "account"})
+	i := &app.UpdateProfileInput{
+		ExternalKey: "1",
+		HolderName:  "Maria Silva",
+		UpdatedAt:   time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+	}
+	res, err := d.UpdateProfileWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountClosed\":false}", string(b))
+}
+
+func TestDb_NotUpdateProfileWhenAccountClosed(t *testing.T) {
+	d := NewDynamodb(newServiceMock("1", t), newLogMock(), Config{TableName: "account"})
+	i := &app.UpdateProfileInput{
+		ExternalKey: "1",
+		HolderName:  "Maria Silva",
+	}
+	res, err := d.UpdateProfileWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountClosed\":true}", string(b))
+}
+
+func TestDb_NotUpdateProfileWhenError(t *testing.T) {
+	d := NewDynamodb(newServiceMock("", t), newLogMock(), Config{TableName: "account"})
+	i := &app.UpdateProfileInput{
+		ExternalKey: "1",
+		HolderName:  "Maria Silva",
+	}
+	res, err := d.UpdateProfileWithContext(context.Background(), i)
+	assert.Equal(t, "db error", err.Error())
+	assert.Nil(t, res)
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"time"
+)
+
+// UpdateStatusWithContext moves the account from input.From to input.To and appends the
+// change to the status history in the same transaction. Closing the account also
+// takes it out of the account count of its document.
+func (d *db) UpdateStatusWithContext(ctx context.Context, input *app.UpdateStatusInput) (*app.UpdateStatusOutput, error) {
+	condition := "#status = :from"
+	if input.From == app.StatusActive {
+		condition = "attribute_exists(ExternalKey) AND (attribute_not_exists(#status) OR #status = :from)"
+	}
+	updatedAt := input.UpdatedAt.UTC().Format(time.RFC3339Nano)
+
+	transactWriteItemsInput := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Update: &dynamodb.Update{
+					Key: map[string]*dynamodb.AttributeValue{
+						"ExternalKey": {
+							S: aws.String(input.ExternalKey),
+						},
+					},
+					UpdateExpression:    aws.String("SET #status = :to, StatusReason = :reason, StatusUpdatedAt = :updatedAt"),
+					ConditionExpression: aws.String(condition),
+					ExpressionAttributeNames: map[string]*string{
+						"#status": aws.String("Status"),
+					},
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+						":from": {
+							S: aws.String(input.From),
+						},
+						":to": {
+							S: aws.String(input.To),
+						},
+						":reason": {
+							S: aws.String(input.Reason),
+						},
+						":updatedAt": {
+							S: aws.String(updatedAt),
+						},
+					},
+					TableName: aws.String(d.config.TableName),
+				},
+			},
+			{
+				Put: &dynamodb.Put{
+					Item: map[string]*dynamodb.AttributeValue{
+						"ExternalKey": {
+							S: aws.String(input.ExternalKey),
+						},
+						"UpdatedAt": {
+							S: aws.String(updatedAt),
+						},
+						"FromStatus": {
+							S: aws.String(input.From),
+						},
+						"ToStatus": {
+							S: aws.String(input.To),
+						},
+						"Reason": {
+							S: aws.String(input.Reason),
+						},
+					},
+					TableName:           aws.String(d.config.StatusTableName),
+					ConditionExpression: aws.String("attribute_not_exists(UpdatedAt)"),
+				},
+			},
+		},
+	}
+	if input.To == app.StatusClosed && input.DocumentNumber != "" {
+		transactWriteItemsInput.TransactItems = append(transactWriteItemsInput.TransactItems, &dynamodb.TransactWriteItem{
+			Update: &dynamodb.Update{
+				Key: map[string]*dynamodb.AttributeValue{
+					"DocumentNumber": {
+						S: aws.String(input.DocumentNumber),
+					},
+				},
+				UpdateExpression: aws.String("ADD AccountCount :minusOne"),
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":minusOne": {
+						N: aws.String("-1"),
+					},
+				},
+				TableName: aws.String(d.config.DocumentTableName),
+			},
+		})
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb update status %v", input))
+	_, err := d.dynamodbService.TransactWriteItemsWithContext(ctx, transactWriteItemsInput)
+	if err != nil {
+		if ae, ok := err.(*dynamodb.TransactionCanceledException); ok && len(ae.CancellationReasons) >= 2 {
+			d.log.Info(fmt.Sprintf("%s %s", ae.Code(), ae.Message()))
+			if conditionalCheckFailed(ae.CancellationReasons[0]) || conditionalCheckFailed(ae.CancellationReasons[1]) {
+				return &app.UpdateStatusOutput{
+					StatusChanged: true,
+				}, nil
+			}
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.UpdateStatusOutput{
+		StatusChanged: false,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"accreditation/app"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newStatusDb(v string, t *testing.T) app.Persistence {
+	c := Config{
+		TableName:         "account",
+		DocumentTableName: "account-document",
+		StatusTableName:   "account-status",
+	}
+	return NewDynamodb(newServiceMock(v, t), newLogMock(), c)
+}
+
+func TestDb_UpdateStatusWhenActive(t *testing.T) {
+	d := newStatusDb("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"attribute_exists(ExternalKey) AND (attribute_not_exists(#status) OR #status = :from)\",\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":from\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null},\":reason\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"fraud-suspected\",\"SS\":null},\":to\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"blocked\",\"SS\":null},\":updatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null}},\"Key\":{\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"account\",\"UpdateExpression\":\"SET #status = :to, StatusReason = :reason, StatusUpdatedAt = :updatedAt\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(UpdatedAt)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"FromStatus\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null},\"Reason\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"fraud-suspected\",\"SS\":null},\"ToStatus\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"blocked\",\"SS\":null},\"UpdatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"account-status\"},\"Update\":null}]}", t)
+	i := &app.UpdateStatusInput{
+		ExternalKey: "1",
+		From:        "active",
+		To:          "blocked",
+		Reason:      "fraud-suspected",
+		UpdatedAt:   time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+	}
+	res, err := d.UpdateStatusWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"StatusChanged\":false}", string(b))
+}
+
+func TestDb_UpdateStatusWhenBlocked(t *testing.T) {
+	d := newStatusDb("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"#status = :from\",\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":from\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"blocked\",\"SS\":null},\":reason\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"customer-request\",\"SS\":null},\":to\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"closed\",\"SS\":null},\":updatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null}},\"Key\":{\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"account\",\"UpdateExpression\":\"SET #status = :to, StatusReason = :reason, StatusUpdatedAt = :updatedAt\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(UpdatedAt)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"FromStatus\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"blocked\",\"SS\":null},\"Reason\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"customer-request\",\"SS\":null},\"ToStatus\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"closed\",\"SS\":null},\"UpdatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"account-status\"},\"Update\":null}]}", t)
+	i := &app.UpdateStatusInput{
+		ExternalKey: "1",
+		From:        "blocked",
+		To:          "closed",
+		Reason:      "customer-request",
+		UpdatedAt:   time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+	}
+	res, err := d.UpdateStatusWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"StatusChanged\":false}", string(b))
+}
+
+func TestDb_UpdateStatusWhenClosedTakesAccountOutOfDocumentCount(t *testing.T) {
+	d := newStatusDb("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"attribute_exists(ExternalKey) AND (attribute_not_exists(#status) OR #status = :from)\",\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":from\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null},\":reason\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"customer-request\",\"SS\":null},\":to\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"closed\",\"SS\":null},\":updatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null}},\"Key\":{\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"account\",\"UpdateExpression\":\"SET #status = :to, StatusReason = :reason, StatusUpdatedAt = :updatedAt\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(UpdatedAt)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"FromStatus\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null},\"Reason\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"customer-request\",\"SS\":null},\"ToStatus\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"closed\",\"SS\":null},\"UpdatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-18T10:00:00Z\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"account-status\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":minusOne\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"DocumentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"52998224725\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"account-document\",\"UpdateExpression\":\"ADD AccountCount :minusOne\"}}]}", t)
+	i := &app.UpdateStatusInput{
+		ExternalKey:    "1",
+		DocumentNumber: "52998224725",
+		From:           "active",
+		To:             "closed",
+		Reason:         "customer-request",
+		UpdatedAt:      time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+	}
+	res, err := d.UpdateStatusWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"StatusChanged\":false}", string(b))
+}
+
+func TestDb_NotUpdateStatusWhenStatusChanged(t *testing.T) {
+	d := newStatusDb("1", t)
+	i := &app.UpdateStatusInput{
+		ExternalKey: "1",
+		From:        "active",
+		To:          "blocked",
+		Reason:      "fraud-suspected",
+	}
+	res, err := d.UpdateStatusWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"StatusChanged\":true}", string(b))
+}
+
+func TestDb_NotUpdateStatusWhenError(t *testing.T) {
+	d := newStatusDb("", t)
+	i := &app.UpdateStatusInput{
+		ExternalKey: "1",
+		From:        "active",
+		To:          "blocked",
+		Reason:      "fraud-suspected",
+	}
+	res, err := d.UpdateStatusWithContext(context.Background(), i)
+	assert.Equal(t, "db error", err.Error())
+	assert.Nil(t, res)
+}
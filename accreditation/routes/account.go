@@ -7,11 +7,13 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"time"
 )
 
 const (
 	BadRequest     = "bad_request"
 	Conflict       = "conflict"
+	NotFound       = "not_found"
 	InvalidRequest = "invalid_request"
 )
 
@@ -23,14 +25,19 @@ func stringValue(v *string) string {
 }
 
 type AccountRequest struct {
-	DocumentNumber *string `json:"document_number,omitempty"`
-	ExternalKey    *string `json:"external_key,omitempty"`
+	DocumentNumber *string  `json:"document_number,omitempty"`
+	ExternalKey    *string  `json:"external_key,omitempty"`
+	Name           *string  `json:"name,omitempty"`
+	Email          *string  `json:"email,omitempty"`
+	Phone          *string  `json:"phone,omitempty"`
+	Currencies     []string `json:"currencies,omitempty"`
 }
 
 type AccountError struct {
 	StatusCode int    `json:"-"`
 	Type       string `json:"type,omitempty"`
 	Category   string `json:"category,omitempty"`
+	Code       string `json:"code,omitempty"`
 	Message    string `json:"message,omitempty"`
 }
 
@@ -39,8 +46,17 @@ type AccountErrorResponse struct {
 }
 
 type AccountGetResponse struct {
-	DocumentNumber string `json:"document_number,omitempty"`
-	ExternalKey    string `json:"external_key,omitempty"`
+	DocumentNumber string                 `json:"document_number,omitempty"`
+	ExternalKey    string                 `json:"external_key,omitempty"`
+	Status         string                 `json:"status,omitempty"`
+	StatusReason   string                 `json:"status_reason,omitempty"`
+	Name           string                 `json:"name,omitempty"`
+	PersonType     string                 `json:"person_type,omitempty"`
+	Email          string                 `json:"email,omitempty"`
+	Phone          string                 `json:"phone,omitempty"`
+	CreatedAt      *time.Time             `json:"created_at,omitempty"`
+	Limits         *AccountLimitsResponse `json:"limits,omitempty"`
+	Currencies     []string               `json:"currencies,omitempty"`
 }
 
 func responseBuild(msg string, statusCode int, category string) *AccountErrorResponse {
@@ -56,6 +72,12 @@ func responseBuild(msg string, statusCode int, category string) *AccountErrorRes
 	return ae
 }
 
+func codeResponseBuild(code string, msg string, statusCode int, category string) *AccountErrorResponse {
+	ae := responseBuild(msg, statusCode, category)
+	ae.Error.Code = code
+	return ae
+}
+
 func buildAccountRequest(a []byte) (*AccountRequest, *AccountErrorResponse) {
 	va := &AccountRequest{}
 
@@ -90,6 +112,10 @@ func createAccountWithContext(ctx context.Context, body io.ReadCloser, log Logge
 	i := &app.CreateAccountInput{
 		DocumentNumber: stringValue(accountRequest.DocumentNumber),
 		ExternalKey:    stringValue(accountRequest.ExternalKey),
+		Name:           stringValue(accountRequest.Name),
+		Email:          stringValue(accountRequest.Email),
+		Phone:          stringValue(accountRequest.Phone),
+		Currencies:     accountRequest.Currencies,
 	}
 
 	res, err := a.CreateAccountWithContext(ctx, i)
@@ -98,14 +124,26 @@ func createAccountWithContext(ctx context.Context, body io.ReadCloser, log Logge
 		return nil, err
 	}
 
-	if res != nil && res.Error && res.Code == "document-invalid" {
+	if res != nil && res.Error && res.Code == app.DocumentInvalid {
 		return responseBuild(res.Detail, http.StatusBadRequest, BadRequest), nil
 	}
 
-	if res != nil && res.Error && res.Code == "item-already-exists" {
+	if res != nil && res.Error && res.Code == app.ProfileInvalid {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.CurrencyInvalid {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.ItemAlreadyExists {
 		return responseBuild(res.Detail, http.StatusConflict, Conflict), nil
 	}
 
+	if res != nil && res.Error && res.Code == app.DocumentAlreadyRegistered {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict), nil
+	}
+
 	return nil, nil
 }
 
@@ -121,11 +159,27 @@ func getAccountWithContext(ctx context.Context, externalKey string, log Logger,
 	}
 
 	if res != nil {
-		return &AccountGetResponse{
-			ExternalKey:    res.ExternalKey,
-			DocumentNumber: res.DocumentNumber,
-		}, nil
+		return accountResponse(res), nil
 	}
 
 	return nil, nil
 }
+
+func accountResponse(res *app.GetAccountOutput) *AccountGetResponse {
+	o := &AccountGetResponse{
+		ExternalKey:    res.ExternalKey,
+		DocumentNumber: res.DocumentNumber,
+		Status:         res.Status,
+		StatusReason:   res.StatusReason,
+		Name:           res.Name,
+		PersonType:     res.PersonType,
+		Email:          res.Email,
+		Phone:          res.Phone,
+		Limits:         limitsResponse(res.Limits),
+		Currencies:     res.Currencies,
+	}
+	if !res.CreatedAt.IsZero() {
+		o.CreatedAt = &res.CreatedAt
+	}
+	return o
+}
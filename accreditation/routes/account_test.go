@@ -9,6 +9,7 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 )
 
 type accreditationMock struct {
@@ -41,6 +42,30 @@ func (r *accreditationMock) CreateAccountWithContext(ctx context.Context, input
 		}, nil
 	}
 
+	if input.DocumentNumber == "123456789" {
+		return &app.CreateAccountOutput{
+			Error:  true,
+			Code:   "profile-invalid",
+			Detail: "name is missing or null",
+		}, nil
+	}
+
+	if input.DocumentNumber == "1234567890" {
+		return &app.CreateAccountOutput{
+			Error:  true,
+			Code:   "currency-invalid",
+			Detail: "currency XYZ is not supported",
+		}, nil
+	}
+
+	if input.DocumentNumber == "12345678" {
+		return &app.CreateAccountOutput{
+			Error:  true,
+			Code:   "document-already-registered",
+			Detail: "test3",
+		}, nil
+	}
+
 	return nil, nil
 }
 func (r *accreditationMock) GetAccountWithContext(ctx context.Context, input *app.GetAccountInput) (*app.GetAccountOutput, error) {
@@ -54,10 +79,158 @@ func (r *accreditationMock) GetAccountWithContext(ctx context.Context, input *ap
 		return &app.GetAccountOutput{
 			ExternalKey:    "1",
 			DocumentNumber: "123",
+			Status:         "active",
+			Name:           "Maria Silva",
+			PersonType:     "individual",
+			CreatedAt:      time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
 		}, nil
 	}
 	return nil, nil
 }
+func (r *accreditationMock) ChangeStatusWithContext(ctx context.Context, input *app.ChangeStatusInput) (*app.ChangeStatusOutput, error) {
+	vt, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(vt))
+
+	switch input.ExternalKey {
+	case "error":
+		return nil, errors.New("status error")
+	case "invalid":
+		return &app.ChangeStatusOutput{
+			Error:  true,
+			Code:   app.StatusReasonInvalid,
+			Detail: "reason invalid",
+		}, nil
+	case "missing":
+		return &app.ChangeStatusOutput{
+			Error:  true,
+			Code:   app.AccountNotFound,
+			Detail: "account not found",
+		}, nil
+	case "funded":
+		return &app.ChangeStatusOutput{
+			Error:  true,
+			Code:   app.BalanceNotZero,
+			Detail: "account balance must be zero to close it",
+		}, nil
+	}
+
+	return &app.ChangeStatusOutput{
+		ExternalKey:  input.ExternalKey,
+		Status:       input.Status,
+		StatusReason: input.Reason,
+		UpdatedAt:    time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+	}, nil
+}
+func (r *accreditationMock) ChangeProfileWithContext(ctx context.Context, input *app.ChangeProfileInput) (*app.ChangeProfileOutput, error) {
+	vt, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(vt))
+
+	switch input.ExternalKey {
+	case "error":
+		return nil, errors.New("profile error")
+	case "invalid":
+		return &app.ChangeProfileOutput{
+			Error:  true,
+			Code:   app.ProfileInvalid,
+			Detail: "email invalid",
+		}, nil
+	case "missing":
+		return &app.ChangeProfileOutput{
+			Error:  true,
+			Code:   app.AccountNotFound,
+			Detail: "account not found",
+		}, nil
+	case "closed":
+		return &app.ChangeProfileOutput{
+			Error:  true,
+			Code:   app.AccountClosed,
+			Detail: "closed accounts cannot be updated",
+		}, nil
+	}
+
+	return &app.ChangeProfileOutput{
+		Name:      input.Name,
+		Email:     input.Email,
+		Phone:     input.Phone,
+		UpdatedAt: time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+	}, nil
+}
+func (r *accreditationMock) ListAccountsWithContext(ctx context.Context, input *app.ListAccountsInput) (*app.ListAccountsOutput, error) {
+	vt, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(vt))
+
+	switch input.DocumentNumber {
+	case "error":
+		return nil, errors.New("list error")
+	case "invalid":
+		return &app.ListAccountsOutput{
+			Error:  true,
+			Code:   app.DocumentInvalid,
+			Detail: "document number must contain only digits, dots, slashes and dashes",
+		}, nil
+	}
+
+	if input.Cursor == "invalid" {
+		return &app.ListAccountsOutput{
+			Error:  true,
+			Code:   app.CursorInvalid,
+			Detail: "cursor is invalid",
+		}, nil
+	}
+
+	return &app.ListAccountsOutput{
+		Accounts: []*app.GetAccountOutput{
+			{
+				ExternalKey:    "1",
+				DocumentNumber: input.DocumentNumber,
+				Status:         "active",
+				Name:           "Maria Silva",
+				PersonType:     "individual",
+				CreatedAt:      time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		NextCursor: "next",
+	}, nil
+}
+func (r *accreditationMock) RecountDocumentsWithContext(ctx context.Context, input *app.RecountDocumentsInput) (*app.RecountDocumentsOutput, error) {
+	return nil, nil
+}
+func (r *accreditationMock) ChangeLimitsWithContext(ctx context.Context, input *app.ChangeLimitsInput) (*app.ChangeLimitsOutput, error) {
+	vt, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(vt))
+
+	switch input.ExternalKey {
+	case "error":
+		return nil, errors.New("limits error")
+	case "invalid":
+		return &app.ChangeLimitsOutput{
+			Error:  true,
+			Code:   app.LimitsInvalid,
+			Detail: "limits must be positive",
+		}, nil
+	case "missing":
+		return &app.ChangeLimitsOutput{
+			Error:  true,
+			Code:   app.AccountNotFound,
+			Detail: "account not found",
+		}, nil
+	case "closed":
+		return &app.ChangeLimitsOutput{
+			Error:  true,
+			Code:   app.AccountClosed,
+			Detail: "closed accounts cannot be updated",
+		}, nil
+	}
+
+	return &app.ChangeLimitsOutput{
+		Limits:    &input.Limits,
+		UpdatedAt: time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC),
+	}, nil
+}
 func newAccreditationMock(v string, t *testing.T) app.Accreditation {
 	return &accreditationMock{
 		v: v,
@@ -75,8 +248,8 @@ func newLogMock() Logger {
 
 func TestRoutes_CreateAccount(t *testing.T) {
 	l := newLogMock()
-	rc := io.NopCloser(strings.NewReader("{\"document_number\": \"123\", \"external_key\": \"1234\"}"))
-	accreditation := newAccreditationMock("{\"DocumentNumber\":\"123\",\"ExternalKey\":\"1234\"}", t)
+	rc := io.NopCloser(strings.NewReader("{\"document_number\": \"123\", \"external_key\": \"1234\", \"name\": \"Maria Silva\", \"email\": \"maria@example.com\", \"phone\": \"+5511987654321\"}"))
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"123\",\"ExternalKey\":\"1234\",\"Name\":\"Maria Silva\",\"Email\":\"maria@example.com\",\"Phone\":\"+5511987654321\",\"Currencies\":null}", t)
 	res, err := createAccountWithContext(context.Background(), rc, l, accreditation)
 	assert.Nil(t, err)
 	assert.Nil(t, res)
@@ -145,7 +318,7 @@ func TestRoutes_NotCreateAccountWheExternalKeyMissing(t *testing.T) {
 func TestRoutes_NotCreateAccountWheAccreditationError(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"document_number\": \"12345\", \"external_key\": \"1234\"}"))
-	accreditation := newAccreditationMock("{\"DocumentNumber\":\"12345\",\"ExternalKey\":\"1234\"}", t)
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"12345\",\"ExternalKey\":\"1234\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":null}", t)
 	res, err := createAccountWithContext(context.Background(), rc, l, accreditation)
 	assert.Nil(t, res)
 	assert.Equal(t, "account error", err.Error())
@@ -154,7 +327,7 @@ func TestRoutes_NotCreateAccountWheAccreditationError(t *testing.T) {
 func TestRoutes_NotCreateAccountWhenAccreditationInvalidInput(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"document_number\": \"123456\", \"external_key\": \"1234\"}"))
-	accreditation := newAccreditationMock("{\"DocumentNumber\":\"123456\",\"ExternalKey\":\"1234\"}", t)
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"123456\",\"ExternalKey\":\"1234\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":null}", t)
 	res, err := createAccountWithContext(context.Background(), rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
@@ -166,7 +339,7 @@ func TestRoutes_NotCreateAccountWhenAccreditationInvalidInput(t *testing.T) {
 func TestRoutes_NotCreateAccountWhenAccreditationItemAlreadyExists(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"document_number\": \"1234567\", \"external_key\": \"1234\"}"))
-	accreditation := newAccreditationMock("{\"DocumentNumber\":\"1234567\",\"ExternalKey\":\"1234\"}", t)
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"1234567\",\"ExternalKey\":\"1234\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":null}", t)
 
 	res, err := createAccountWithContext(context.Background(), rc, l, accreditation)
 	assert.Nil(t, err)
@@ -176,6 +349,45 @@ func TestRoutes_NotCreateAccountWhenAccreditationItemAlreadyExists(t *testing.T)
 	assert.Equal(t, expected, string(validate))
 }
 
+func TestRoutes_NotCreateAccountWhenDocumentAlreadyRegistered(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"document_number\": \"12345678\", \"external_key\": \"1234\"}"))
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"12345678\",\"ExternalKey\":\"1234\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":null}", t)
+
+	res, err := createAccountWithContext(context.Background(), rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	expected := "{\"error\":{\"type\":\"invalid_request\",\"category\":\"conflict\",\"code\":\"document-already-registered\",\"message\":\"test3\"}}"
+	assert.Equal(t, expected, string(validate))
+}
+
+func TestRoutes_NotCreateAccountWhenProfileInvalid(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"document_number\": \"123456789\", \"external_key\": \"1234\"}"))
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"123456789\",\"ExternalKey\":\"1234\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":null}", t)
+
+	res, err := createAccountWithContext(context.Background(), rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	expected := "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"profile-invalid\",\"message\":\"name is missing or null\"}}"
+	assert.Equal(t, expected, string(validate))
+}
+
+func TestRoutes_NotCreateAccountWhenCurrencyInvalid(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"document_number\": \"1234567890\", \"external_key\": \"1234\", \"currencies\": [\"XYZ\"]}"))
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"1234567890\",\"ExternalKey\":\"1234\",\"Name\":\"\",\"Email\":\"\",\"Phone\":\"\",\"Currencies\":[\"XYZ\"]}", t)
+
+	res, err := createAccountWithContext(context.Background(), rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	expected := "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"currency-invalid\",\"message\":\"currency XYZ is not supported\"}}"
+	assert.Equal(t, expected, string(validate))
+}
+
 func TestRoutes_StringValueWhenNilValue(t *testing.T) {
 	r := stringValue(nil)
 	assert.Equal(t, "", r)
@@ -188,7 +400,7 @@ func TestRoutes_GetAccount(t *testing.T) {
 	assert.Nil(t, err)
 	val, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"document_number\":\"123\",\"external_key\":\"1\"}", string(val))
+	assert.Equal(t, "{\"document_number\":\"123\",\"external_key\":\"1\",\"status\":\"active\",\"name\":\"Maria Silva\",\"person_type\":\"individual\",\"created_at\":\"2022-01-18T10:00:00Z\"}", string(val))
 }
 
 func TestRoutes_NotGetAccountWhenError(t *testing.T) {
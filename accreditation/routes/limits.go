@@ -0,0 +1,92 @@
+package routes
+
+import (
+	"accreditation/app"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+type LimitsRequest struct {
+	MaxWithdrawAmount   *int `json:"max_withdraw_amount,omitempty"`
+	DailyWithdrawAmount *int `json:"daily_withdraw_amount,omitempty"`
+	MonthlyBuyingAmount *int `json:"monthly_buying_amount,omitempty"`
+	MaxInstallments     *int `json:"max_installments,omitempty"`
+}
+
+type AccountLimitsResponse struct {
+	MaxWithdrawAmount   int `json:"max_withdraw_amount"`
+	DailyWithdrawAmount int `json:"daily_withdraw_amount"`
+	MonthlyBuyingAmount int `json:"monthly_buying_amount"`
+	MaxInstallments     int `json:"max_installments"`
+}
+
+type LimitsResponse struct {
+	ExternalKey string                 `json:"external_key"`
+	Limits      *AccountLimitsResponse `json:"limits"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+func intValue(v *int) int {
+	if v != nil {
+		return *v
+	}
+	return 0
+}
+
+func limitsResponse(l *app.AccountLimits) *AccountLimitsResponse {
+	if l == nil {
+		return nil
+	}
+	return &AccountLimitsResponse{
+		MaxWithdrawAmount:   l.MaxWithdrawAmount,
+		DailyWithdrawAmount: l.DailyWithdrawAmount,
+		MonthlyBuyingAmount: l.MonthlyBuyingAmount,
+		MaxInstallments:     l.MaxInstallments,
+	}
+}
+
+func changeLimitsWithContext(ctx context.Context, externalKey string, body io.ReadCloser, log Logger, a app.Accreditation) (*LimitsResponse, *AccountErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+
+	limitsRequest := &LimitsRequest{}
+	if err := json.Unmarshal(buf.Bytes(), &limitsRequest); err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest), nil
+	}
+
+	res, err := a.ChangeLimitsWithContext(ctx, &app.ChangeLimitsInput{
+		ExternalKey: externalKey,
+		Limits: app.AccountLimits{
+			MaxWithdrawAmount:   intValue(limitsRequest.MaxWithdrawAmount),
+			DailyWithdrawAmount: intValue(limitsRequest.DailyWithdrawAmount),
+			MonthlyBuyingAmount: intValue(limitsRequest.MonthlyBuyingAmount),
+			MaxInstallments:     intValue(limitsRequest.MaxInstallments),
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error && res.Code == app.LimitsInvalid {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.AccountNotFound {
+		return nil, responseBuild(res.Detail, http.StatusNotFound, NotFound), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.AccountClosed {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	return &LimitsResponse{
+		ExternalKey: externalKey,
+		Limits:      limitsResponse(res.Limits),
+		UpdatedAt:   res.UpdatedAt,
+	}, nil, nil
+}
@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRoutes_ChangeLimits(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"max_withdraw_amount\": 50000, \"daily_withdraw_amount\": 100000, \"max_installments\": 6}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"1\",\"Limits\":{\"MaxWithdrawAmount\":50000,\"DailyWithdrawAmount\":100000,\"MonthlyBuyingAmount\":0,\"MaxInstallments\":6}}", t)
+
+	res, errorResponse, err := changeLimitsWithContext(context.Background(), "1", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"external_key\":\"1\",\"limits\":{\"max_withdraw_amount\":50000,\"daily_withdraw_amount\":100000,\"monthly_buying_amount\":0,\"max_installments\":6},\"updated_at\":\"2022-01-18T10:00:00Z\"}", string(validate))
+}
+
+func TestRoutes_NotChangeLimitsWhenInvalidPayload(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"max_installments\": \"6\"}"))
+	accreditation := newAccreditationMock("", t)
+
+	res, errorResponse, err := changeLimitsWithContext(context.Background(), "1", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"invalid payload\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeLimitsWhenLimitsInvalid(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"monthly_buying_amount\": -1}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"invalid\",\"Limits\":{\"MaxWithdrawAmount\":0,\"DailyWithdrawAmount\":0,\"MonthlyBuyingAmount\":-1,\"MaxInstallments\":0}}", t)
+
+	res, errorResponse, err := changeLimitsWithContext(context.Background(), "invalid", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"limits-invalid\",\"message\":\"limits must be positive\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeLimitsWhenAccountNotFound(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"missing\",\"Limits\":{\"MaxWithdrawAmount\":0,\"DailyWithdrawAmount\":0,\"MonthlyBuyingAmount\":0,\"MaxInstallments\":0}}", t)
+
+	res, errorResponse, err := changeLimitsWithContext(context.Background(), "missing", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"message\":\"account not found\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeLimitsWhenAccountClosed(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"closed\",\"Limits\":{\"MaxWithdrawAmount\":0,\"DailyWithdrawAmount\":0,\"MonthlyBuyingAmount\":0,\"MaxInstallments\":0}}", t)
+
+	res, errorResponse, err := changeLimitsWithContext(context.Background(), "closed", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"conflict\",\"code\":\"account-closed\",\"message\":\"closed accounts cannot be updated\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeLimitsWhenAccreditationError(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"error\",\"Limits\":{\"MaxWithdrawAmount\":0,\"DailyWithdrawAmount\":0,\"MonthlyBuyingAmount\":0,\"MaxInstallments\":0}}", t)
+
+	res, errorResponse, err := changeLimitsWithContext(context.Background(), "error", rc, newLogMock(), accreditation)
+	assert.Equal(t, "limits error", err.Error())
+	assert.Nil(t, res)
+	assert.Nil(t, errorResponse)
+}
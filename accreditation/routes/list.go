@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"accreditation/app"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type AccountListResponse struct {
+	Accounts   []*AccountGetResponse `json:"accounts"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+func buildListAccountsInput(query url.Values) (*app.ListAccountsInput, *AccountErrorResponse) {
+	i := &app.ListAccountsInput{
+		DocumentNumber: query.Get("document_number"),
+		Status:         query.Get("status"),
+		Cursor:         query.Get("cursor"),
+	}
+
+	if i.DocumentNumber == "" {
+		return nil, responseBuild("document_number is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > app.MaxAccountsLimit {
+			return nil, responseBuild("limit must be between 1 and "+strconv.Itoa(app.MaxAccountsLimit), http.StatusBadRequest, BadRequest)
+		}
+		i.Limit = limit
+	}
+
+	return i, nil
+}
+
+func listAccountsWithContext(ctx context.Context, query url.Values, log Logger, a app.Accreditation) (*AccountListResponse, *AccountErrorResponse, error) {
+	i, errorResponse := buildListAccountsInput(query)
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	res, err := a.ListAccountsWithContext(ctx, i)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.Error && (res.Code == app.DocumentInvalid || res.Code == app.StatusInvalid || res.Code == app.CursorInvalid) {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	accounts := make([]*AccountGetResponse, 0, len(res.Accounts))
+	for _, o := range res.Accounts {
+		accounts = append(accounts, accountResponse(o))
+	}
+
+	return &AccountListResponse{
+		Accounts:   accounts,
+		NextCursor: res.NextCursor,
+	}, nil, nil
+}
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestRoutes_ListAccounts(t *testing.T) {
+	query := url.Values{"document_number": {"52998224725"}}
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"52998224725\",\"Status\":\"\",\"Limit\":0,\"Cursor\":\"\"}", t)
+
+	res, errorResponse, err := listAccountsWithContext(context.Background(), query, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"accounts\":[{\"document_number\":\"52998224725\",\"external_key\":\"1\",\"status\":\"active\",\"name\":\"Maria Silva\",\"person_type\":\"individual\",\"created_at\":\"2022-01-18T10:00:00Z\"}],\"next_cursor\":\"next\"}", string(validate))
+}
+
+func TestRoutes_ListAccountsWithFilters(t *testing.T) {
+	query := url.Values{"document_number": {"52998224725"}, "status": {"active"}, "limit": {"10"}, "cursor": {"abc"}}
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"52998224725\",\"Status\":\"active\",\"Limit\":10,\"Cursor\":\"abc\"}", t)
+
+	res, errorResponse, err := listAccountsWithContext(context.Background(), query, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, 1, len(res.Accounts))
+	assert.Equal(t, "next", res.NextCursor)
+}
+
+func TestRoutes_NotListAccountsWhenDocumentNumberMissing(t *testing.T) {
+	query := url.Values{"status": {"active"}}
+	accreditation := newAccreditationMock("", t)
+
+	res, errorResponse, err := listAccountsWithContext(context.Background(), query, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"document_number is missing or null\"}}", string(validate))
+}
+
+func TestRoutes_NotListAccountsWhenLimitInvalid(t *testing.T) {
+	query := url.Values{"document_number": {"52998224725"}, "limit": {"101"}}
+	accreditation := newAccreditationMock("", t)
+
+	res, errorResponse, err := listAccountsWithContext(context.Background(), query, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"limit must be between 1 and 100\"}}", string(validate))
+}
+
+func TestRoutes_NotListAccountsWhenDocumentInvalid(t *testing.T) {
+	query := url.Values{"document_number": {"invalid"}}
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"invalid\",\"Status\":\"\",\"Limit\":0,\"Cursor\":\"\"}", t)
+
+	res, errorResponse, err := listAccountsWithContext(context.Background(), query, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"document-invalid\",\"message\":\"document number must contain only digits, dots, slashes and dashes\"}}", string(validate))
+}
+
+func TestRoutes_NotListAccountsWhenCursorInvalid(t *testing.T) {
+	query := url.Values{"document_number": {"52998224725"}, "cursor": {"invalid"}}
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"52998224725\",\"Status\":\"\",\"Limit\":0,\"Cursor\":\"invalid\"}", t)
+
+	res, errorResponse, err := listAccountsWithContext(context.Background(), query, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"cursor-invalid\",\"message\":\"cursor is invalid\"}}", string(validate))
+}
+
+func TestRoutes_NotListAccountsWhenError(t *testing.T) {
+	query := url.Values{"document_number": {"error"}}
+	accreditation := newAccreditationMock("{\"DocumentNumber\":\"error\",\"Status\":\"\",\"Limit\":0,\"Cursor\":\"\"}", t)
+
+	res, errorResponse, err := listAccountsWithContext(context.Background(), query, newLogMock(), accreditation)
+	assert.Equal(t, "list error", err.Error())
+	assert.Nil(t, res)
+	assert.Nil(t, errorResponse)
+}
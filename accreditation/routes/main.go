@@ -22,6 +22,21 @@ func healthz() http.Handler {
 	})
 }
 
+func writeResponse(w http.ResponseWriter, statusCode int, v interface{}) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(res); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func accounts(a app.Accreditation, log Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -49,6 +64,19 @@ func accounts(a app.Accreditation, log Logger) http.Handler {
 			}
 
 			w.WriteHeader(http.StatusCreated)
+		} else if r.Method == http.MethodGet && strings.TrimSuffix(r.URL.Path, "/") == "/v1/accounts" {
+			o, errorResponse, err := listAccountsWithContext(ctx, r.URL.Query(), log, a)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+
+			writeResponse(w, http.StatusOK, o)
 		} else if r.Method == http.MethodGet {
 			externalKey := strings.TrimPrefix(r.URL.Path, "/v1/accounts/")
 
@@ -75,6 +103,38 @@ func accounts(a app.Accreditation, log Logger) http.Handler {
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
+		} else if r.Method == http.MethodPut || r.Method == http.MethodPatch {
+			externalKey := strings.TrimPrefix(r.URL.Path, "/v1/accounts/")
+			limits := r.Method == http.MethodPut && strings.HasSuffix(externalKey, "/limits")
+			if limits {
+				externalKey = strings.TrimSuffix(externalKey, "/limits")
+			}
+			if externalKey == "" || externalKey == r.URL.Path || strings.Contains(externalKey, "/") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			var o interface{}
+			var errorResponse *AccountErrorResponse
+			var err error
+			if limits {
+				o, errorResponse, err = changeLimitsWithContext(ctx, externalKey, r.Body, log, a)
+			} else if r.Method == http.MethodPut {
+				o, errorResponse, err = changeProfileWithContext(ctx, externalKey, r.Body, log, a)
+			} else {
+				o, errorResponse, err = changeStatusWithContext(ctx, externalKey, r.Body, log, a)
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+
+			writeResponse(w, http.StatusOK, o)
 		} else {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
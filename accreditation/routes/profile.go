@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"accreditation/app"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+type ProfileRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+	Phone *string `json:"phone,omitempty"`
+}
+
+type ProfileResponse struct {
+	ExternalKey string    `json:"external_key"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email,omitempty"`
+	Phone       string    `json:"phone,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func changeProfileWithContext(ctx context.Context, externalKey string, body io.ReadCloser, log Logger, a app.Accreditation) (*ProfileResponse, *AccountErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+
+	profileRequest := &ProfileRequest{}
+	if err := json.Unmarshal(buf.Bytes(), &profileRequest); err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest), nil
+	}
+
+	res, err := a.ChangeProfileWithContext(ctx, &app.ChangeProfileInput{
+		ExternalKey: externalKey,
+		Name:        stringValue(profileRequest.Name),
+		Email:       stringValue(profileRequest.Email),
+		Phone:       stringValue(profileRequest.Phone),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error && res.Code == app.ProfileInvalid {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.AccountNotFound {
+		return nil, responseBuild(res.Detail, http.StatusNotFound, NotFound), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.AccountClosed {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	return &ProfileResponse{
+		ExternalKey: externalKey,
+		Name:        res.Name,
+		Email:       res.Email,
+		Phone:       res.Phone,
+		UpdatedAt:   res.UpdatedAt,
+	}, nil, nil
+}
@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRoutes_ChangeProfile(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"name\": \"Maria Souza\", \"phone\": \"+5511987654321\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"1\",\"Name\":\"Maria Souza\",\"Email\":\"\",\"Phone\":\"+5511987654321\"}", t)
+
+	res, errorResponse, err := changeProfileWithContext(context.Background(), "1", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"external_key\":\"1\",\"name\":\"Maria Souza\",\"phone\":\"+5511987654321\",\"updated_at\":\"2022-01-18T10:00:00Z\"}", string(validate))
+}
+
+func TestRoutes_NotChangeProfileWhenInvalidPayload(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"name\": 1}"))
+	accreditation := newAccreditationMock("", t)
+
+	res, errorResponse, err := changeProfileWithContext(context.Background(), "1", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"invalid payload\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeProfileWhenProfileInvalid(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"name\": \"Maria Souza\", \"email\": \"maria\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"invalid\",\"Name\":\"Maria Souza\",\"Email\":\"maria\",\"Phone\":\"\"}", t)
+
+	res, errorResponse, err := changeProfileWithContext(context.Background(), "invalid", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"profile-invalid\",\"message\":\"email invalid\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeProfileWhenAccountNotFound(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"name\": \"Maria Souza\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"missing\",\"Name\":\"Maria Souza\",\"Email\":\"\",\"Phone\":\"\"}", t)
+
+	res, errorResponse, err := changeProfileWithContext(context.Background(), "missing", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"message\":\"account not found\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeProfileWhenAccountClosed(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"name\": \"Maria Souza\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"closed\",\"Name\":\"Maria Souza\",\"Email\":\"\",\"Phone\":\"\"}", t)
+
+	res, errorResponse, err := changeProfileWithContext(context.Background(), "closed", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"conflict\",\"code\":\"account-closed\",\"message\":\"closed accounts cannot be updated\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeProfileWhenAccreditationError(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"name\": \"Maria Souza\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"error\",\"Name\":\"Maria Souza\",\"Email\":\"\",\"Phone\":\"\"}", t)
+
+	res, errorResponse, err := changeProfileWithContext(context.Background(), "error", rc, newLogMock(), accreditation)
+	assert.Equal(t, "profile error", err.Error())
+	assert.Nil(t, res)
+	assert.Nil(t, errorResponse)
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"accreditation/app"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+type StatusRequest struct {
+	Status *string `json:"status,omitempty"`
+	Reason *string `json:"reason,omitempty"`
+}
+
+type StatusResponse struct {
+	ExternalKey  string    `json:"external_key"`
+	Status       string    `json:"status"`
+	StatusReason string    `json:"status_reason"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func buildStatusRequest(b []byte) (*StatusRequest, *AccountErrorResponse) {
+	sr := &StatusRequest{}
+
+	err := json.Unmarshal(b, &sr)
+	if err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest)
+	}
+
+	if sr.Status == nil || stringValue(sr.Status) == "" {
+		return nil, responseBuild("status is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if sr.Reason == nil || stringValue(sr.Reason) == "" {
+		return nil, responseBuild("reason is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	return sr, nil
+}
+
+func changeStatusWithContext(ctx context.Context, externalKey string, body io.ReadCloser, log Logger, a app.Accreditation) (*StatusResponse, *AccountErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	statusRequest, errorResponse := buildStatusRequest(b)
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	res, err := a.ChangeStatusWithContext(ctx, &app.ChangeStatusInput{
+		ExternalKey: externalKey,
+		Status:      stringValue(statusRequest.Status),
+		Reason:      stringValue(statusRequest.Reason),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error && (res.Code == app.StatusInvalid || res.Code == app.StatusReasonInvalid) {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.AccountNotFound {
+		return nil, responseBuild(res.Detail, http.StatusNotFound, NotFound), nil
+	}
+
+	if res != nil && res.Error && (res.Code == app.StatusTransitionInvalid || res.Code == app.BalanceNotZero) {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	return &StatusResponse{
+		ExternalKey:  res.ExternalKey,
+		Status:       res.Status,
+		StatusReason: res.StatusReason,
+		UpdatedAt:    res.UpdatedAt,
+	}, nil, nil
+}
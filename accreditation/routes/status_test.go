@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRoutes_ChangeStatus(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"status\": \"blocked\", \"reason\": \"fraud-suspected\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"1\",\"Status\":\"blocked\",\"Reason\":\"fraud-suspected\"}", t)
+
+	res, errorResponse, err := changeStatusWithContext(context.Background(), "1", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"external_key\":\"1\",\"status\":\"blocked\",\"status_reason\":\"fraud-suspected\",\"updated_at\":\"2022-01-18T10:00:00Z\"}", string(validate))
+}
+
+func TestRoutes_NotChangeStatusWhenInvalidPayload(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{"))
+	accreditation := newAccreditationMock("", t)
+
+	res, errorResponse, err := changeStatusWithContext(context.Background(), "1", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"invalid payload\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeStatusWhenStatusMissing(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"reason\": \"fraud-suspected\"}"))
+	accreditation := newAccreditationMock("", t)
+
+	res, errorResponse, err := changeStatusWithContext(context.Background(), "1", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"status is missing or null\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeStatusWhenReasonMissing(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"status\": \"blocked\"}"))
+	accreditation := newAccreditationMock("", t)
+
+	res, errorResponse, err := changeStatusWithContext(context.Background(), "1", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"reason is missing or null\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeStatusWhenReasonInvalid(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"status\": \"blocked\", \"reason\": \"because\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"invalid\",\"Status\":\"blocked\",\"Reason\":\"because\"}", t)
+
+	res, errorResponse, err := changeStatusWithContext(context.Background(), "invalid", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"status-reason-invalid\",\"message\":\"reason invalid\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeStatusWhenAccountNotFound(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"status\": \"blocked\", \"reason\": \"fraud-suspected\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"missing\",\"Status\":\"blocked\",\"Reason\":\"fraud-suspected\"}", t)
+
+	res, errorResponse, err := changeStatusWithContext(context.Background(), "missing", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"message\":\"account not found\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeStatusWhenBalanceNotZero(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"status\": \"closed\", \"reason\": \"customer-request\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"funded\",\"Status\":\"closed\",\"Reason\":\"customer-request\"}", t)
+
+	res, errorResponse, err := changeStatusWithContext(context.Background(), "funded", rc, newLogMock(), accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"conflict\",\"code\":\"balance-not-zero\",\"message\":\"account balance must be zero to close it\"}}", string(validate))
+}
+
+func TestRoutes_NotChangeStatusWhenAccreditationError(t *testing.T) {
+	rc := io.NopCloser(strings.NewReader("{\"status\": \"blocked\", \"reason\": \"fraud-suspected\"}"))
+	accreditation := newAccreditationMock("{\"ExternalKey\":\"error\",\"Status\":\"blocked\",\"Reason\":\"fraud-suspected\"}", t)
+
+	res, errorResponse, err := changeStatusWithContext(context.Background(), "error", rc, newLogMock(), accreditation)
+	assert.Equal(t, "status error", err.Error())
+	assert.Nil(t, res)
+	assert.Nil(t, errorResponse)
+}
@@ -20,6 +20,22 @@ func (d *db) PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInpu
 	return d.svc.PutItemWithContext(ctx, input)
 }
 
+func (d *db) TransactWriteItemsWithContext(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	return d.svc.TransactWriteItemsWithContext(ctx, input)
+}
+
+func (d *db) UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	return d.svc.UpdateItemWithContext(ctx, input)
+}
+
+func (d *db) QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	return d.svc.QueryWithContext(ctx, input)
+}
+
+func (d *db) ScanWithContext(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	return d.svc.ScanWithContext(ctx, input)
+}
+
 func NewDynamodb() repository.Dynamodb {
 	mySession := session.Must(session.NewSession())
 	svc := dynamodb.New(mySession, aws.NewConfig().WithRegion("us-east-1"), aws.NewConfig().WithEndpoint("http://localstack:4566"))
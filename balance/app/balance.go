@@ -1,20 +1,204 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Balance interface {
 	SettlementWithContext(ctx context.Context, input *SettlementInput) (*SettlementOutput, error)
+	GetBalanceWithContext(ctx context.Context, input *GetBalanceInput) (*GetBalanceOutput, error)
+	SetOverdraftLimitWithContext(ctx context.Context, input *SetOverdraftLimitInput) (*SetOverdraftLimitOutput, error)
+	RebuildSummariesWithContext(ctx context.Context, input *RebuildSummariesInput) (*RebuildSummariesOutput, error)
+	SummariesRebuiltWithContext(ctx context.Context) (bool, error)
+	PostDueInstallmentsWithContext(ctx context.Context, input *PostDueInstallmentsInput) (*PostDueInstallmentsOutput, error)
+	ListTransactionsWithContext(ctx context.Context, input *ListTransactionsInput) (*ListTransactionsOutput, error)
+	GetTransactionWithContext(ctx context.Context, input *GetTransactionInput) (*GetTransactionOutput, error)
+	GetUsageWithContext(ctx context.Context, input *GetUsageInput) (*GetUsageOutput, error)
+	TransferWithContext(ctx context.Context, input *TransferInput) (*TransferOutput, error)
+	HoldWithContext(ctx context.Context, input *HoldInput) (*HoldOutput, error)
+	GetHoldWithContext(ctx context.Context, input *GetHoldInput) (*HoldOutput, error)
+	CaptureHoldWithContext(ctx context.Context, input *CaptureHoldInput) (*HoldOutput, error)
+	VoidHoldWithContext(ctx context.Context, input *VoidHoldInput) (*HoldOutput, error)
+	ReleaseExpiredHoldsWithContext(ctx context.Context, input *ReleaseExpiredHoldsInput) (*ReleaseExpiredHoldsOutput, error)
 }
 
 type SettlementInput struct {
-	AccountKey    string
-	ExternalKey   string
-	OperationType string
-	Amount        int
+	AccountKey          string
+	ExternalKey         string
+	OperationType       string
+	Amount              int
+	Currency            string
+	Installments        int
+	OriginalExternalKey string
+	Origin              string
+	RequestID           string
+	Description         string
+	Metadata            map[string]string
+	RiskScore           int
 }
 
 type SettlementOutput struct {
+	Error     bool
+	Code      string
+	Detail    string
+	Amount    int
+	Currency  string
+	CreatedAt time.Time
+	RequestID string
+}
+
+type GetBalanceInput struct {
+	AccountKey string
+	Currency   string
+}
+
+type GetBalanceOutput struct {
+	Error            bool
+	Code             string
+	Detail           string
+	AccountKey       string
+	Currency         string
+	Exponent         int
+	AvailableBalance int
+	HeldAmount       int
+	LedgerBalance    int
+	TotalCredits     int
+	TotalDebits      int
+	EntryCount       int
+}
+
+type SetOverdraftLimitInput struct {
+	AccountKey     string
+	Currency       string
+	OverdraftLimit int
+}
+
+type SetOverdraftLimitOutput struct {
 	Error  bool
 	Code   string
 	Detail string
 }
+
+type RebuildSummariesInput struct {
+	AccountKeys []string
+}
+
+type RebuildSummariesOutput struct {
+	AccountKeys []string
+}
+
+type PostDueInstallmentsInput struct {
+	Now time.Time
+}
+
+type PostDueInstallmentsOutput struct {
+	Posted int
+	Failed int
+}
+
+type ListTransactionsInput struct {
+	AccountKey    string
+	OperationType string
+	From          time.Time
+	To            time.Time
+	Limit         int
+	Cursor        string
+}
+
+type ListTransactionsOutput struct {
+	Error        bool
+	Code         string
+	Detail       string
+	Transactions []*Entry
+	NextCursor   string
+}
+
+// GetTransactionInput finds the entry a service settled under its external key; a
+// reversal is looked up by its reversal key.
+type GetTransactionInput struct {
+	AccountKey  string
+	ExternalKey string
+	Origin      string
+	Reversal    bool
+}
+
+type GetTransactionOutput struct {
+	Error       bool
+	Code        string
+	Detail      string
+	Transaction *Entry
+}
+
+type TransferInput struct {
+	TransferKey    string
+	FromAccountKey string
+	ToAccountKey   string
+	Amount         int
+	Currency       string
+	RequestID      string
+	Description    string
+	Metadata       map[string]string
+}
+
+type TransferOutput struct {
+	Error      bool
+	Code       string
+	Detail     string
+	TransferID string
+	Currency   string
+	CreatedAt  time.Time
+	RequestID  string
+}
+
+type HoldInput struct {
+	AccountKey    string
+	ExternalKey   string
+	OperationType string
+	Amount        int
+	Currency      string
+	Origin        string
+	RequestID     string
+	Description   string
+	Metadata      map[string]string
+	RiskScore     int
+}
+
+// CaptureHoldInput captures the whole hold when Amount is zero.
+type CaptureHoldInput struct {
+	AccountKey  string
+	ExternalKey string
+	Amount      int
+	RequestID   string
+	Description string
+	Metadata    map[string]string
+}
+
+type VoidHoldInput struct {
+	AccountKey  string
+	ExternalKey string
+}
+
+type HoldOutput struct {
+	Error          bool
+	Code           string
+	Detail         string
+	OperationType  string
+	Status         string
+	Amount         int
+	Currency       string
+	CapturedAmount int
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	RequestID      string
+	RiskScore      int
+}
+
+type ReleaseExpiredHoldsInput struct {
+	Now time.Time
+}
+
+type ReleaseExpiredHoldsOutput struct {
+	Released int
+	Failed   int
+}
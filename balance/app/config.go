@@ -0,0 +1,18 @@
+package app
+
+import "time"
+
+const DefaultHoldExpiration = 7 * 24 * time.Hour
+
+type Config struct {
+	HoldExpiration time.Duration
+}
+
+func (c *Config) WithHoldExpiration(expiration string) *Config {
+	d, err := time.ParseDuration(expiration)
+	if err != nil || d <= 0 {
+		d = DefaultHoldExpiration
+	}
+	c.HoldExpiration = d
+	return c
+}
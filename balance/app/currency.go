@@ -0,0 +1,83 @@
+package app
+
+import "fmt"
+
+const (
+	DefaultCurrency = "BRL"
+	CurrencyInvalid = "currency-invalid"
+	CurrencyNotHeld = "currency-not-held"
+)
+
+// currencyExponents lists the accepted ISO 4217 currencies with the number of
+// decimal places of their minor unit. Amounts are always integers in that unit, so
+// 100 is one real, 100 yen or a tenth of a Bahraini dinar.
+var currencyExponents = map[string]int{
+	"ARS": 2,
+	"AUD": 2,
+	"BHD": 3,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CLP": 0,
+	"CNY": 2,
+	"COP": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MXN": 2,
+	"OMR": 3,
+	"PYG": 0,
+	"TND": 3,
+	"USD": 2,
+	"UYU": 2,
+}
+
+func CurrencyExponent(currency string) (int, bool) {
+	e, ok := currencyExponents[currency]
+	return e, ok
+}
+
+// currencyOrDefault reads a missing currency as BRL, the currency of every amount
+// written before balances were kept per currency.
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
+
+// currencySuffix keeps the request hashes of BRL operations as they were, so
+// retries of requests made before currencies existed still match.
+func currencySuffix(currency string) string {
+	if currency == "" || currency == DefaultCurrency {
+		return ""
+	}
+	return fmt.Sprintf("|%s", currency)
+}
+
+func validateCurrency(currency string) (string, bool) {
+	currency = currencyOrDefault(currency)
+	_, ok := CurrencyExponent(currency)
+	return currency, ok
+}
+
+func currencyInvalidDetail(currency string) string {
+	return fmt.Sprintf("currency %s is not supported", currency)
+}
+
+// holdsCurrency treats an account without currencies as holding only BRL, as every
+// account did before.
+func holdsCurrency(currencies []string, currency string) bool {
+	if len(currencies) == 0 {
+		return currency == DefaultCurrency
+	}
+	for _, c := range currencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
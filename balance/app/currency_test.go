@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCurrency_SettlementInUSD(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"EntryKey\":\"#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"test\",\"Amount\":1000,\"Currency\":\"USD\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"4f89cf115b349ac1733d11d0e7c265c12d2cd8ed1d8b8fc0fae68ce0a09ffc33\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"10ee38057e611cb4722703493f02a3a3a818fceacbdfd3a0fb93daa574d7093d\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "123",
+		OperationType: "test",
+		Amount:        1000,
+		Currency:      "USD",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":1000,\"Currency\":\"USD\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestCurrency_NotSettlementWhenCurrencyUnknown(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "123",
+		OperationType: "test",
+		Amount:        1000,
+		Currency:      "XYZ",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"currency-invalid\",\"Detail\":\"currency XYZ is not supported\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestCurrency_RequestHashKeptForBRL(t *testing.T) {
+	i := &SettlementInput{
+		OperationType: "Payment",
+		Amount:        500,
+	}
+	brl := &SettlementInput{
+		OperationType: "Payment",
+		Amount:        500,
+		Currency:      "BRL",
+	}
+	usd := &SettlementInput{
+		OperationType: "Payment",
+		Amount:        500,
+		Currency:      "USD",
+	}
+	assert.Equal(t, requestHash(i), requestHash(brl))
+	assert.NotEqual(t, requestHash(i), requestHash(usd))
+}
+
+func TestCurrency_GetBalanceInJPY(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	res, err := a.GetBalanceWithContext(context.Background(), &GetBalanceInput{
+		AccountKey: "11111111111",
+		Currency:   "JPY",
+	})
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"AccountKey\":\"11111111111\",\"Currency\":\"JPY\",\"Exponent\":0,\"AvailableBalance\":100,\"HeldAmount\":200,\"LedgerBalance\":300,\"TotalCredits\":1000,\"TotalDebits\":900,\"EntryCount\":4}", string(validate))
+}
+
+func TestCurrency_GetBalanceInBHD(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	res, err := a.GetBalanceWithContext(context.Background(), &GetBalanceInput{
+		AccountKey: "11111111116",
+		Currency:   "BHD",
+	})
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"AccountKey\":\"11111111116\",\"Currency\":\"BHD\",\"Exponent\":3,\"AvailableBalance\":0,\"HeldAmount\":0,\"LedgerBalance\":0,\"TotalCredits\":0,\"TotalDebits\":0,\"EntryCount\":0}", string(validate))
+}
+
+func TestCurrency_NotGetBalanceWhenCurrencyUnknown(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	res, err := a.GetBalanceWithContext(context.Background(), &GetBalanceInput{
+		AccountKey: "11111111111",
+		Currency:   "XYZ",
+	})
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"currency-invalid\",\"Detail\":\"currency XYZ is not supported\",\"AccountKey\":\"\",\"Currency\":\"\",\"Exponent\":0,\"AvailableBalance\":0,\"HeldAmount\":0,\"LedgerBalance\":0,\"TotalCredits\":0,\"TotalDebits\":0,\"EntryCount\":0}", string(validate))
+}
+
+func TestCurrency_SetOverdraftLimitInUSD(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"Currency\":\"USD\",\"OverdraftLimit\":1000}", t)
+	a := newWithClock(r, l, testNow)
+	res, err := a.SetOverdraftLimitWithContext(context.Background(), &SetOverdraftLimitInput{
+		AccountKey:     "11111111111",
+		Currency:       "USD",
+		OverdraftLimit: 1000,
+	})
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\"}", string(validate))
+}
+
+func TestCurrency_RebuildSummariesPerCurrency(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	res, err := a.RebuildSummariesWithContext(context.Background(), &RebuildSummariesInput{
+		AccountKeys: []string{"33333333333"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"33333333333"}, res.AccountKeys)
+}
+
+func TestCurrency_TransferInUSD(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"Debit\":{\"AccountKey\":\"33333333333\",\"EntryKey\":\"balance#transfer#t1\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferOut\",\"Amount\":-1000,\"Currency\":\"USD\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"8160ac97910368a12b9413e160073cc7cbbb9618e1e2e9fd831aeae69dda56aa\",\"TransferID\":\"bac2bb1fd7a0376728a9f1c1b8265e1b\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"e2e29c25f03c6830c9246dea1d4665a33b653fee7b31238e5ae0972644cc6045\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}},\"Credit\":{\"AccountKey\":\"44444444444\",\"EntryKey\":\"balance#transfer#bac2bb1fd7a0376728a9f1c1b8265e1b\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferIn\",\"Amount\":1000,\"Currency\":\"USD\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"8160ac97910368a12b9413e160073cc7cbbb9618e1e2e9fd831aeae69dda56aa\",\"TransferID\":\"bac2bb1fd7a0376728a9f1c1b8265e1b\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"46a06ce989797f5266245e48d3ba8c81bfd0c8356eba1edf1bef595046ebdfff\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "33333333333",
+		ToAccountKey:   "44444444444",
+		Amount:         1000,
+		Currency:       "USD",
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"TransferID\":\"bac2bb1fd7a0376728a9f1c1b8265e1b\",\"Currency\":\"USD\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestCurrency_NotTransferWhenCurrencyNotHeld(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "33333333333",
+		ToAccountKey:   "22222222222",
+		Amount:         1000,
+		Currency:       "USD",
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"currency-not-held\",\"Detail\":\"account 22222222222 does not hold USD\",\"TransferID\":\"\",\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestCurrency_NotReverseWhenCurrencyDiffers(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		Currency:            "USD",
+		OriginalExternalKey: "123",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"reversal-invalid\",\"Detail\":\"the original transaction is in BRL\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestCurrency_HoldInUSD(t *testing.T) {
+	a := newWithClock(newRepositoryMock("{\"Hold\":{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"USD\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"35e192bda19b0cb0bce30293404983d43d89657a02ece74be04a0c9d413250f9\"},\"OverdraftLimit\":500}", t), newLogMock(), testNow)
+	res, err := a.HoldWithContext(context.Background(), &HoldInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "a1",
+		OperationType: "Buying",
+		Amount:        1000,
+		Currency:      "USD",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"active\",\"Amount\":1000,\"Currency\":\"USD\",\"CapturedAmount\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
@@ -0,0 +1,368 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	HoldActive   = "active"
+	HoldCaptured = "captured"
+	HoldVoided   = "voided"
+	HoldExpired  = "expired"
+
+	HoldInvalid        = "hold-invalid"
+	HoldNotFound       = "hold-not-found"
+	HoldNotActive      = "hold-not-active"
+	HoldHasExpired     = "hold-expired"
+	CaptureExceedsHold = "capture-exceeds-hold"
+)
+
+func holdHash(input *HoldInput) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%d%s", input.OperationType, input.Amount, currencySuffix(input.Currency))))
+	return hex.EncodeToString(h[:])
+}
+
+func holdOutput(h *Hold) *HoldOutput {
+	return &HoldOutput{
+		Error:          false,
+		OperationType:  h.OperationType,
+		Status:         h.Status,
+		Amount:         h.Amount,
+		Currency:       currencyOrDefault(h.Currency),
+		CapturedAmount: h.CapturedAmount,
+		CreatedAt:      h.CreatedAt,
+		ExpiresAt:      h.ExpiresAt,
+		RequestID:      h.RequestID,
+		RiskScore:      h.RiskScore,
+	}
+}
+
+// closedHoldOutput explains why a hold that is no longer active cannot change.
+func closedHoldOutput(h *Hold) *HoldOutput {
+	if h.Status == HoldExpired {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldHasExpired,
+			Detail: "hold expired",
+		}
+	}
+
+	return &HoldOutput{
+		Error:  true,
+		Code:   HoldNotActive,
+		Detail: fmt.Sprintf("hold is %s", h.Status),
+	}
+}
+
+func (a *accreditation) getHoldWithContext(ctx context.Context, accountKey string, externalKey string) (*Hold, error) {
+	h, err := a.repository.GetHoldWithContext(ctx, &GetHoldInput{
+		AccountKey:  accountKey,
+		ExternalKey: externalKey,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get hold error %s", err.Error()))
+		return nil, err
+	}
+	return h, nil
+}
+
+func (a *accreditation) HoldWithContext(ctx context.Context, input *HoldInput) (*HoldOutput, error) {
+	if input.Amount <= 0 {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldInvalid,
+			Detail: "amount must be positive",
+		}, nil
+	}
+
+	currency, ok := validateCurrency(input.Currency)
+	if !ok {
+		return &HoldOutput{
+			Error:  true,
+			Code:   CurrencyInvalid,
+			Detail: currencyInvalidDetail(input.Currency),
+		}, nil
+	}
+
+	createdAt := a.clock()
+	h := &Hold{
+		AccountKey:    input.AccountKey,
+		ExternalKey:   input.ExternalKey,
+		OperationType: input.OperationType,
+		Amount:        input.Amount,
+		Currency:      currency,
+		Status:        HoldActive,
+		CreatedAt:     createdAt,
+		ExpiresAt:     createdAt.Add(a.config.HoldExpiration),
+		Origin:        input.Origin,
+		RequestID:     input.RequestID,
+		Description:   input.Description,
+		Metadata:      input.Metadata,
+		RiskScore:     input.RiskScore,
+		RequestHash:   holdHash(input),
+	}
+	i := &InsertHoldInput{
+		Hold: h,
+	}
+
+	var res *InsertHoldOutput
+	for attempt := 0; attempt < maxOverdraftLimitAttempts; attempt++ {
+		limit, err := a.overdraftLimitWithContext(ctx, input.AccountKey, currency)
+		if err != nil {
+			return nil, err
+		}
+		i.OverdraftLimit = limit
+
+		res, err = a.repository.InsertHoldWithContext(ctx, i)
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository insert hold error %s", err.Error()))
+			return nil, err
+		}
+		if res == nil || !res.OverdraftLimitChanged {
+			break
+		}
+		a.log.Info("Overdraft limit changed during the hold, retrying")
+	}
+
+	if res != nil && res.OverdraftLimitChanged {
+		a.log.Error(fmt.Sprintf("Repository insert hold error %s", errOverdraftLimitChanged.Error()))
+		return nil, errOverdraftLimitChanged
+	}
+
+	if res != nil && res.AlreadyExists {
+		existing, err := a.getHoldWithContext(ctx, input.AccountKey, input.ExternalKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil || existing.RequestHash != h.RequestHash {
+			return &HoldOutput{
+				Error:  true,
+				Code:   IdempotencyKeyReused,
+				Detail: "external_key was already used for a different request",
+			}, nil
+		}
+		return holdOutput(existing), nil
+	}
+
+	if res != nil && res.InsufficientFunds {
+		return &HoldOutput{
+			Error:  true,
+			Code:   InsufficientFunds,
+			Detail: "insufficient funds",
+		}, nil
+	}
+
+	return holdOutput(h), nil
+}
+
+func (a *accreditation) GetHoldWithContext(ctx context.Context, input *GetHoldInput) (*HoldOutput, error) {
+	h, err := a.getHoldWithContext(ctx, input.AccountKey, input.ExternalKey)
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldNotFound,
+			Detail: "hold not found",
+		}, nil
+	}
+
+	return holdOutput(h), nil
+}
+
+func (a *accreditation) CaptureHoldWithContext(ctx context.Context, input *CaptureHoldInput) (*HoldOutput, error) {
+	if input.Amount < 0 {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldInvalid,
+			Detail: "amount must be positive",
+		}, nil
+	}
+
+	h, err := a.getHoldWithContext(ctx, input.AccountKey, input.ExternalKey)
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldNotFound,
+			Detail: "hold not found",
+		}, nil
+	}
+
+	amount := input.Amount
+	if amount == 0 {
+		amount = h.Amount
+	}
+	if amount > h.Amount {
+		return &HoldOutput{
+			Error:  true,
+			Code:   CaptureExceedsHold,
+			Detail: fmt.Sprintf("capture amount must be at most the held amount %d", h.Amount),
+		}, nil
+	}
+
+	// A capture retried after it went through gets the same answer back.
+	if h.Status == HoldCaptured && h.CapturedAmount == amount {
+		return holdOutput(h), nil
+	}
+	if h.Status != HoldActive {
+		return closedHoldOutput(h), nil
+	}
+
+	capturedAt := a.clock()
+	if !capturedAt.Before(h.ExpiresAt) {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldHasExpired,
+			Detail: "hold expired",
+		}, nil
+	}
+
+	e := &InsertInput{
+		AccountKey:     h.AccountKey,
+		EntryKey:       entryKey(h.Origin, transactionScope, h.ExternalKey),
+		ExternalKey:    h.ExternalKey,
+		OperatiionType: h.OperationType,
+		Amount:         amount * -1,
+		Currency:       currencyOrDefault(h.Currency),
+		CreatedAt:      capturedAt,
+		Origin:         h.Origin,
+		RequestID:      h.RequestID,
+		Description:    h.Description,
+		Metadata:       h.Metadata,
+		RiskScore:      h.RiskScore,
+	}
+	if input.RequestID != "" {
+		e.RequestID = input.RequestID
+	}
+	if input.Description != "" {
+		e.Description = input.Description
+	}
+	if len(input.Metadata) > 0 {
+		e.Metadata = input.Metadata
+	}
+	e.RequestHash = requestHash(&SettlementInput{
+		OperationType: e.OperatiionType,
+		Amount:        e.Amount,
+		Currency:      e.Currency,
+	})
+	e.Event = settledEvent(e.AccountKey, e.EntryKey)
+
+	res, err := a.repository.CaptureHoldWithContext(ctx, &CaptureHoldRecordInput{
+		AccountKey:  h.AccountKey,
+		ExternalKey: h.ExternalKey,
+		HeldAmount:  h.Amount,
+		Entry:       e,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository capture hold error %s", err.Error()))
+		return nil, err
+	}
+
+	if res != nil && res.HoldChanged {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldNotActive,
+			Detail: "hold is no longer active",
+		}, nil
+	}
+
+	if res != nil && res.AlreadyExists {
+		return &HoldOutput{
+			Error:  true,
+			Code:   IdempotencyKeyReused,
+			Detail: "external_key was already used by another transaction",
+		}, nil
+	}
+
+	o := holdOutput(h)
+	o.Status = HoldCaptured
+	o.CapturedAmount = amount
+	o.RequestID = e.RequestID
+	return o, nil
+}
+
+func (a *accreditation) VoidHoldWithContext(ctx context.Context, input *VoidHoldInput) (*HoldOutput, error) {
+	h, err := a.getHoldWithContext(ctx, input.AccountKey, input.ExternalKey)
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldNotFound,
+			Detail: "hold not found",
+		}, nil
+	}
+
+	if h.Status == HoldVoided {
+		return holdOutput(h), nil
+	}
+	if h.Status != HoldActive {
+		return closedHoldOutput(h), nil
+	}
+
+	res, err := a.repository.ReleaseHoldWithContext(ctx, &ReleaseHoldInput{
+		AccountKey:  h.AccountKey,
+		ExternalKey: h.ExternalKey,
+		Amount:      h.Amount,
+		Currency:    currencyOrDefault(h.Currency),
+		Status:      HoldVoided,
+		ClosedAt:    a.clock(),
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository release hold error %s", err.Error()))
+		return nil, err
+	}
+
+	if res != nil && res.HoldChanged {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldNotActive,
+			Detail: "hold is no longer active",
+		}, nil
+	}
+
+	o := holdOutput(h)
+	o.Status = HoldVoided
+	return o, nil
+}
+
+func (a *accreditation) ReleaseExpiredHoldsWithContext(ctx context.Context, input *ReleaseExpiredHoldsInput) (*ReleaseExpiredHoldsOutput, error) {
+	o, err := a.repository.ListExpiredHoldsWithContext(ctx, &ListExpiredHoldsInput{
+		ExpiresBefore: input.Now,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository list expired holds error %s", err.Error()))
+		return nil, err
+	}
+
+	releaseExpiredHoldsOutput := &ReleaseExpiredHoldsOutput{}
+	for _, h := range o.Holds {
+		res, err := a.repository.ReleaseHoldWithContext(ctx, &ReleaseHoldInput{
+			AccountKey:  h.AccountKey,
+			ExternalKey: h.ExternalKey,
+			Amount:      h.Amount,
+			Currency:    currencyOrDefault(h.Currency),
+			Status:      HoldExpired,
+			ClosedAt:    input.Now,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository release hold %s error %s", h.ExternalKey, err.Error()))
+			releaseExpiredHoldsOutput.Failed++
+			continue
+		}
+		if res != nil && res.HoldChanged {
+			continue
+		}
+		releaseExpiredHoldsOutput.Released++
+	}
+
+	return releaseExpiredHoldsOutput, nil
+}
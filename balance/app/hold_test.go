@@ -0,0 +1,423 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func (r repositoryMock) InsertHoldWithContext(ctx context.Context, input *InsertHoldInput) (*InsertHoldOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	switch input.Hold.AccountKey {
+	case "11111111112":
+		return nil, errors.New("insert hold error")
+	case "11111111113":
+		return &InsertHoldOutput{AlreadyExists: true}, nil
+	case "11111111115":
+		return &InsertHoldOutput{InsufficientFunds: true}, nil
+	case "11111111119":
+		return &InsertHoldOutput{OverdraftLimitChanged: true}, nil
+	}
+	return &InsertHoldOutput{}, nil
+}
+func (r repositoryMock) GetHoldWithContext(ctx context.Context, input *GetHoldInput) (*Hold, error) {
+	h := &Hold{
+		AccountKey:    input.AccountKey,
+		ExternalKey:   input.ExternalKey,
+		OperationType: "Buying",
+		Amount:        1000,
+		Status:        HoldActive,
+		CreatedAt:     testNow.Add(-time.Hour),
+		ExpiresAt:     testNow.Add(time.Hour),
+		Origin:        "debit",
+		RequestID:     "req-1",
+		RequestHash:   holdHash(&HoldInput{OperationType: "Buying", Amount: 1000}),
+	}
+	switch input.ExternalKey {
+	case "error":
+		return nil, errors.New("get hold error")
+	case "404":
+		return nil, nil
+	case "captured":
+		h.Status = HoldCaptured
+		h.CapturedAmount = 600
+	case "voided":
+		h.Status = HoldVoided
+	case "expired":
+		h.Status = HoldExpired
+	case "late":
+		h.ExpiresAt = testNow
+	case "scored":
+		h.RiskScore = 40
+	}
+	return h, nil
+}
+func (r repositoryMock) CaptureHoldWithContext(ctx context.Context, input *CaptureHoldRecordInput) (*CaptureHoldRecordOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	switch input.ExternalKey {
+	case "changed":
+		return &CaptureHoldRecordOutput{HoldChanged: true}, nil
+	case "taken":
+		return &CaptureHoldRecordOutput{AlreadyExists: true}, nil
+	}
+	return &CaptureHoldRecordOutput{}, nil
+}
+func (r repositoryMock) ReleaseHoldWithContext(ctx context.Context, input *ReleaseHoldInput) (*ReleaseHoldOutput, error) {
+	if r.v != "" {
+		v, err := json.Marshal(input)
+		assert.Nil(r.t, err)
+		assert.Equal(r.t, r.v, string(v))
+	}
+	switch input.ExternalKey {
+	case "changed":
+		return &ReleaseHoldOutput{HoldChanged: true}, nil
+	case "fail":
+		return nil, errors.New("release hold error")
+	}
+	return &ReleaseHoldOutput{}, nil
+}
+func (r repositoryMock) ListExpiredHoldsWithContext(ctx context.Context, input *ListExpiredHoldsInput) (*ListHoldsOutput, error) {
+	if r.v == "1" {
+		return nil, errors.New("list expired holds error")
+	}
+	return &ListHoldsOutput{
+		Holds: []*Hold{
+			{AccountKey: "1", ExternalKey: "a1", Amount: 1000, Status: HoldActive},
+			{AccountKey: "1", ExternalKey: "changed", Amount: 500, Status: HoldActive},
+			{AccountKey: "2", ExternalKey: "fail", Amount: 300, Status: HoldActive},
+		},
+	}, nil
+}
+func (r repositoryMock) ListHoldsWithContext(ctx context.Context, input *ListHoldsInput) (*ListHoldsOutput, error) {
+	if input.AccountKey != "11111111118" {
+		return &ListHoldsOutput{}, nil
+	}
+	return &ListHoldsOutput{
+		Holds: []*Hold{
+			{AccountKey: input.AccountKey, ExternalKey: "a1", Amount: 200, Status: HoldActive},
+			{AccountKey: input.AccountKey, ExternalKey: "a2", Amount: 400, Status: HoldCaptured, CapturedAmount: 400},
+		},
+	}, nil
+}
+
+func holdResult(t *testing.T, res *HoldOutput, err error) string {
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(validate)
+}
+
+func TestAccreditation_Hold(t *testing.T) {
+	r := newRepositoryMock("{\"Hold\":{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"debit\",\"RequestID\":\"req-1\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":20,\"RequestHash\":\"75bf66b1bf425729ff9590738bc6b669021ff1e40c440efcefffecb1ce99663b\"},\"OverdraftLimit\":500}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.HoldWithContext(context.Background(), &HoldInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "a1",
+		OperationType: "Buying",
+		Amount:        1000,
+		Origin:        "debit",
+		RequestID:     "req-1",
+		RiskScore:     20,
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"active\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"RequestID\":\"req-1\",\"RiskScore\":20}", holdResult(t, res, err))
+}
+
+func TestAccreditation_HoldExpiresAfterConfiguredPeriod(t *testing.T) {
+	r := newRepositoryMock("{\"Hold\":{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-01-31T10:30:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"75bf66b1bf425729ff9590738bc6b669021ff1e40c440efcefffecb1ce99663b\"},\"OverdraftLimit\":500}", t)
+	a := New(r, &authorizerMock{}, (&Config{}).WithHoldExpiration("30m"), newLogMock())
+	a.(*accreditation).clock = func() time.Time {
+		return testNow
+	}
+	res, err := a.HoldWithContext(context.Background(), &HoldInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "a1",
+		OperationType: "Buying",
+		Amount:        1000,
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"active\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-01-31T10:30:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotHoldWhenAmountNotPositive(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.HoldWithContext(context.Background(), &HoldInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "a1",
+		OperationType: "Buying",
+		Amount:        -1000,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-invalid\",\"Detail\":\"amount must be positive\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotHoldWhenInsufficientFunds(t *testing.T) {
+	r := newRepositoryMock("{\"Hold\":{\"AccountKey\":\"11111111115\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"75bf66b1bf425729ff9590738bc6b669021ff1e40c440efcefffecb1ce99663b\"},\"OverdraftLimit\":500}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.HoldWithContext(context.Background(), &HoldInput{
+		AccountKey:    "11111111115",
+		ExternalKey:   "a1",
+		OperationType: "Buying",
+		Amount:        1000,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"insufficient-funds\",\"Detail\":\"insufficient funds\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotHoldWhenOverdraftLimitKeepsChanging(t *testing.T) {
+	r := newRepositoryMock("{\"Hold\":{\"AccountKey\":\"11111111119\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"75bf66b1bf425729ff9590738bc6b669021ff1e40c440efcefffecb1ce99663b\"},\"OverdraftLimit\":500}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.HoldWithContext(context.Background(), &HoldInput{
+		AccountKey:    "11111111119",
+		ExternalKey:   "a1",
+		OperationType: "Buying",
+		Amount:        1000,
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "overdraft limit changed while debiting", err.Error())
+}
+
+func TestAccreditation_HoldReplayedWhenAlreadyExists(t *testing.T) {
+	r := newRepositoryMock("{\"Hold\":{\"AccountKey\":\"11111111113\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"75bf66b1bf425729ff9590738bc6b669021ff1e40c440efcefffecb1ce99663b\"},\"OverdraftLimit\":500}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.HoldWithContext(context.Background(), &HoldInput{
+		AccountKey:    "11111111113",
+		ExternalKey:   "a1",
+		OperationType: "Buying",
+		Amount:        1000,
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"active\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"ExpiresAt\":\"2022-01-31T11:00:00Z\",\"RequestID\":\"req-1\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotHoldWhenKeyReused(t *testing.T) {
+	r := newRepositoryMock("{\"Hold\":{\"AccountKey\":\"11111111113\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":700,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"e652deaad40aa49ca4fa95b227c210e5cb3c99c96ffe5b0976461e25aa4740c9\"},\"OverdraftLimit\":500}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.HoldWithContext(context.Background(), &HoldInput{
+		AccountKey:    "11111111113",
+		ExternalKey:   "a1",
+		OperationType: "Buying",
+		Amount:        700,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"idempotency-key-reused\",\"Detail\":\"external_key was already used for a different request\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotHoldWhenInsertError(t *testing.T) {
+	r := newRepositoryMock("{\"Hold\":{\"AccountKey\":\"11111111112\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"75bf66b1bf425729ff9590738bc6b669021ff1e40c440efcefffecb1ce99663b\"},\"OverdraftLimit\":500}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.HoldWithContext(context.Background(), &HoldInput{
+		AccountKey:    "11111111112",
+		ExternalKey:   "a1",
+		OperationType: "Buying",
+		Amount:        1000,
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "insert hold error", err.Error())
+}
+
+func TestAccreditation_GetHold(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.GetHoldWithContext(context.Background(), &GetHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "scored",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"active\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"ExpiresAt\":\"2022-01-31T11:00:00Z\",\"RequestID\":\"req-1\",\"RiskScore\":40}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotGetHoldWhenNotFound(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.GetHoldWithContext(context.Background(), &GetHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "404",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-not-found\",\"Detail\":\"hold not found\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotGetHoldWhenGetError(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.GetHoldWithContext(context.Background(), &GetHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "error",
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "get hold error", err.Error())
+}
+
+func TestAccreditation_CaptureHoldPartially(t *testing.T) {
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"a1\",\"HeldAmount\":1000,\"Entry\":{\"AccountKey\":\"11111111111\",\"EntryKey\":\"debit#transaction#a1\",\"ExternalKey\":\"a1\",\"OperatiionType\":\"Buying\",\"Amount\":-600,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"debit\",\"RequestID\":\"req-2\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"32d50919682b7072f3200150bc8a085c33ba669a9f410714670ba0b5a910a372\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"64afead108beab52eac17276b52c03c138801b07f90438ba87d3cc7bd7737942\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "a1",
+		Amount:      600,
+		RequestID:   "req-2",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"captured\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":600,\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"ExpiresAt\":\"2022-01-31T11:00:00Z\",\"RequestID\":\"req-2\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_CaptureHoldFully(t *testing.T) {
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"a1\",\"HeldAmount\":1000,\"Entry\":{\"AccountKey\":\"11111111111\",\"EntryKey\":\"debit#transaction#a1\",\"ExternalKey\":\"a1\",\"OperatiionType\":\"Buying\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"debit\",\"RequestID\":\"req-1\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"50174ff23bce102d9ed70f833f456643239843a2ac7f892c8e6c9903300a6278\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"64afead108beab52eac17276b52c03c138801b07f90438ba87d3cc7bd7737942\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "a1",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"captured\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":1000,\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"ExpiresAt\":\"2022-01-31T11:00:00Z\",\"RequestID\":\"req-1\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_CaptureHoldReplayed(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "captured",
+		Amount:      600,
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"captured\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":600,\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"ExpiresAt\":\"2022-01-31T11:00:00Z\",\"RequestID\":\"req-1\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotCaptureHoldWhenAlreadyCapturedWithAnotherAmount(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "captured",
+		Amount:      500,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-not-active\",\"Detail\":\"hold is captured\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotCaptureHoldWhenExceedsHold(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "a1",
+		Amount:      1001,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"capture-exceeds-hold\",\"Detail\":\"capture amount must be at most the held amount 1000\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotCaptureHoldWhenNotFound(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "404",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-not-found\",\"Detail\":\"hold not found\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotCaptureHoldWhenVoided(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "voided",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-not-active\",\"Detail\":\"hold is voided\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotCaptureHoldWhenPastExpiration(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "late",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-expired\",\"Detail\":\"hold expired\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotCaptureHoldWhenChangedConcurrently(t *testing.T) {
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"changed\",\"HeldAmount\":1000,\"Entry\":{\"AccountKey\":\"11111111111\",\"EntryKey\":\"debit#transaction#changed\",\"ExternalKey\":\"changed\",\"OperatiionType\":\"Buying\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"debit\",\"RequestID\":\"req-1\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"50174ff23bce102d9ed70f833f456643239843a2ac7f892c8e6c9903300a6278\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"c2caa539f13a1503e32cbdee58d14859ebc32a979d31c2a5c39f3e852c8d8ebc\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "changed",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-not-active\",\"Detail\":\"hold is no longer active\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotCaptureHoldWhenExternalKeyTaken(t *testing.T) {
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"taken\",\"HeldAmount\":1000,\"Entry\":{\"AccountKey\":\"11111111111\",\"EntryKey\":\"debit#transaction#taken\",\"ExternalKey\":\"taken\",\"OperatiionType\":\"Buying\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"debit\",\"RequestID\":\"req-1\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"50174ff23bce102d9ed70f833f456643239843a2ac7f892c8e6c9903300a6278\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"8ae251bd779fd29e46bb81784c1b7be9903df49f198d3d2ec78068813daeb967\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "taken",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"idempotency-key-reused\",\"Detail\":\"external_key was already used by another transaction\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotCaptureHoldWhenGetError(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.CaptureHoldWithContext(context.Background(), &CaptureHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "error",
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "get hold error", err.Error())
+}
+
+func TestAccreditation_VoidHold(t *testing.T) {
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"a1\",\"Amount\":1000,\"Currency\":\"BRL\",\"Status\":\"voided\",\"ClosedAt\":\"2022-01-31T10:00:00Z\"}", t)
+	a := newWithClock(r, newLogMock(), testNow)
+	res, err := a.VoidHoldWithContext(context.Background(), &VoidHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "a1",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"voided\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"ExpiresAt\":\"2022-01-31T11:00:00Z\",\"RequestID\":\"req-1\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_VoidHoldReplayed(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.VoidHoldWithContext(context.Background(), &VoidHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "voided",
+	})
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"OperationType\":\"Buying\",\"Status\":\"voided\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"ExpiresAt\":\"2022-01-31T11:00:00Z\",\"RequestID\":\"req-1\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotVoidHoldWhenCaptured(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.VoidHoldWithContext(context.Background(), &VoidHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "captured",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-not-active\",\"Detail\":\"hold is captured\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_NotVoidHoldWhenExpired(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.VoidHoldWithContext(context.Background(), &VoidHoldInput{
+		AccountKey:  "11111111111",
+		ExternalKey: "expired",
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-expired\",\"Detail\":\"hold expired\",\"OperationType\":\"\",\"Status\":\"\",\"Amount\":0,\"Currency\":\"\",\"CapturedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"ExpiresAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"RiskScore\":0}", holdResult(t, res, err))
+}
+
+func TestAccreditation_ReleaseExpiredHolds(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.ReleaseExpiredHoldsWithContext(context.Background(), &ReleaseExpiredHoldsInput{
+		Now: testNow,
+	})
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Released\":1,\"Failed\":1}", string(validate))
+}
+
+func TestAccreditation_NotReleaseExpiredHoldsWhenListError(t *testing.T) {
+	a := newWithClock(newRepositoryMock("1", t), newLogMock(), testNow)
+	res, err := a.ReleaseExpiredHoldsWithContext(context.Background(), &ReleaseExpiredHoldsInput{
+		Now: testNow,
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "list expired holds error", err.Error())
+}
+
+func TestAccreditation_RebuildSummariesWithActiveHolds(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.RebuildSummariesWithContext(context.Background(), &RebuildSummariesInput{
+		AccountKeys: []string{"11111111118"},
+	})
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountKeys\":[\"11111111118\"]}", string(validate))
+}
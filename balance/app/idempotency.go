@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	IdempotencyKeyReused = "idempotency-key-reused"
+	transactionScope     = "transaction"
+	reversalScope        = "reversal"
+)
+
+// entryKey scopes an external key to the service that sent it and to the kind of
+// operation, so credit and debit keys never collide while a key reused for another
+// debit operation type is still caught.
+func entryKey(origin string, scope string, externalKey string) string {
+	return fmt.Sprintf("%s#%s#%s", origin, scope, externalKey)
+}
+
+// requestHash fingerprints the fields that make two settlements the same request.
+func requestHash(input *SettlementInput) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%s%s", input.OperationType, input.Amount, input.Installments, input.OriginalExternalKey, currencySuffix(input.Currency))))
+	return hex.EncodeToString(h[:])
+}
+
+// replayWithContext answers a settlement whose key is already taken: the same request
+// gets the original result back, a different one is a reuse of the key.
+func (a *accreditation) replayWithContext(ctx context.Context, input *SettlementInput, key string, hash string) (*SettlementOutput, error) {
+	existing, err := a.repository.GetEntryWithContext(ctx, &GetEntryInput{
+		AccountKey: input.AccountKey,
+		EntryKey:   key,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get entry error %s", err.Error()))
+		return nil, err
+	}
+
+	return replayOutput(input, existing, hash), nil
+}
+
+func replayOutput(input *SettlementInput, existing *Entry, hash string) *SettlementOutput {
+	if existing == nil {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   ItemAlreadyExists,
+			Detail: "item already exists",
+		}
+	}
+
+	if existing.RequestHash != hash {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   IdempotencyKeyReused,
+			Detail: "external_key was already used for a different request",
+		}
+	}
+
+	amount := input.Amount
+	if input.OriginalExternalKey != "" {
+		amount = existing.Amount
+	}
+
+	return &SettlementOutput{
+		Error:     false,
+		Amount:    amount,
+		Currency:  currencyOrDefault(existing.Currency),
+		CreatedAt: existing.CreatedAt,
+		RequestID: existing.RequestID,
+	}
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	InstallmentScheduled = "scheduled"
+	InstallmentPosted    = "posted"
+	MaxInstallments      = 12
+)
+
+func installmentEntryKey(key string, number int) string {
+	if number == 1 {
+		return key
+	}
+	return fmt.Sprintf("%s#%02d", key, number)
+}
+
+// addMonths keeps the day of month, moving it back to the last day of shorter months.
+func addMonths(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// installmentSchedule splits a debit into monthly installments. The first one is due
+// right away and carries the remainder of the division, in minor units.
+func installmentSchedule(input *SettlementInput, currency string, key string, now time.Time) []*Installment {
+	total := input.Amount * -1
+	amount := total / input.Installments
+	remainder := total % input.Installments
+
+	schedule := make([]*Installment, 0, input.Installments)
+	for n := 1; n <= input.Installments; n++ {
+		i := &Installment{
+			AccountKey:    input.AccountKey,
+			ExternalKey:   input.ExternalKey,
+			EntryKey:      installmentEntryKey(key, n),
+			OperationType: input.OperationType,
+			Number:        n,
+			Count:         input.Installments,
+			Amount:        amount * -1,
+			Currency:      currency,
+			DueDate:       addMonths(now, n-1),
+			Status:        InstallmentScheduled,
+			Origin:        input.Origin,
+			RequestID:     input.RequestID,
+			Description:   input.Description,
+			Metadata:      input.Metadata,
+		}
+		if n == 1 {
+			i.Amount = (amount + remainder) * -1
+			i.Status = InstallmentPosted
+		}
+		schedule = append(schedule, i)
+	}
+
+	return schedule
+}
+
+func validateInstallments(input *SettlementInput) *SettlementOutput {
+	if input.Installments <= 1 {
+		return nil
+	}
+
+	if input.Amount >= 0 || input.Installments > MaxInstallments || input.Amount*-1 < input.Installments {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   InstallmentsInvalid,
+			Detail: fmt.Sprintf("installments must be between 2 and %d on a debit of at least one minor unit per installment", MaxInstallments),
+		}
+	}
+
+	return nil
+}
+
+func (a *accreditation) PostDueInstallmentsWithContext(ctx context.Context, input *PostDueInstallmentsInput) (*PostDueInstallmentsOutput, error) {
+	i := &ListDueInstallmentsInput{
+		DueBefore: input.Now,
+	}
+
+	o, err := a.repository.ListDueInstallmentsWithContext(ctx, i)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository list due installments error %s", err.Error()))
+		return nil, err
+	}
+
+	postDueInstallmentsOutput := &PostDueInstallmentsOutput{}
+	for _, installment := range o.Installments {
+		installment.PostedAt = input.Now
+		installment.Event = settledEvent(installment.AccountKey, installment.EntryKey)
+		res, err := a.repository.PostInstallmentWithContext(ctx, installment)
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository post installment %s error %s", installment.EntryKey, err.Error()))
+			postDueInstallmentsOutput.Failed++
+			continue
+		}
+		if res != nil && res.AlreadyPosted {
+			continue
+		}
+		postDueInstallmentsOutput.Posted++
+	}
+
+	return postDueInstallmentsOutput, nil
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInstallment_SettlementWithInstallments(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"EntryKey\":\"#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"InstallmentBuying\",\"Amount\":-334,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"192daaa154f17c4c320a46e6e23fb077ce22d9bc9e5bd4d7a17fbb3524ea3123\",\"TransferID\":\"\",\"Schedule\":[{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"123\",\"EntryKey\":\"#transaction#123\",\"OperationType\":\"InstallmentBuying\",\"Number\":1,\"Count\":3,\"Amount\":-334,\"Currency\":\"BRL\",\"DueDate\":\"2022-01-31T10:00:00Z\",\"Status\":\"posted\",\"PostedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"Event\":null},{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"123\",\"EntryKey\":\"#transaction#123#02\",\"OperationType\":\"InstallmentBuying\",\"Number\":2,\"Count\":3,\"Amount\":-333,\"Currency\":\"BRL\",\"DueDate\":\"2022-02-28T10:00:00Z\",\"Status\":\"scheduled\",\"PostedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"Event\":null},{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"123\",\"EntryKey\":\"#transaction#123#03\",\"OperationType\":\"InstallmentBuying\",\"Number\":3,\"Count\":3,\"Amount\":-333,\"Currency\":\"BRL\",\"DueDate\":\"2022-03-31T10:00:00Z\",\"Status\":\"scheduled\",\"PostedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"Event\":null}],\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"10ee38057e611cb4722703493f02a3a3a818fceacbdfd3a0fb93daa574d7093d\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	a.(*accreditation).clock = func() time.Time {
+		return time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)
+	}
+	i := &SettlementInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "123",
+		OperationType: "InstallmentBuying",
+		Amount:        -1000,
+		Installments:  3,
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":-1000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestInstallment_NotSettlementWhenTooManyInstallments(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "123",
+		OperationType: "InstallmentBuying",
+		Amount:        -1000,
+		Installments:  13,
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"installments-invalid\",\"Detail\":\"installments must be between 2 and 12 on a debit of at least one minor unit per installment\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestInstallment_NotSettlementWhenInstallmentsOnCredit(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "123",
+		OperationType: "Payment",
+		Amount:        1000,
+		Installments:  2,
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"installments-invalid\",\"Detail\":\"installments must be between 2 and 12 on a debit of at least one minor unit per installment\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestInstallment_PostDueInstallments(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &PostDueInstallmentsInput{
+		Now: time.Date(2022, 3, 31, 10, 0, 0, 0, time.UTC),
+	}
+	res, err := a.PostDueInstallmentsWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Posted\":1,\"Failed\":1}", string(validate))
+}
+
+func TestInstallment_NotPostDueInstallmentsWhenListError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("1", t)
+	a := newWithClock(r, l, testNow)
+	i := &PostDueInstallmentsInput{
+		Now: time.Date(2022, 3, 31, 10, 0, 0, 0, time.UTC),
+	}
+	res, err := a.PostDueInstallmentsWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "list due installments error", err.Error())
+}
+
+func TestInstallment_AddMonthsWhenShorterMonth(t *testing.T) {
+	d := addMonths(time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC), 1)
+	assert.Equal(t, time.Date(2022, 2, 28, 10, 0, 0, 0, time.UTC), d)
+}
@@ -2,47 +2,329 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 )
 
+const (
+	ItemAlreadyExists       = "item-already-exists"
+	InsufficientFunds       = "insufficient-funds"
+	OverdraftLimitInvalid   = "overdraft-limit-invalid"
+	InstallmentsInvalid     = "installments-invalid"
+	OriginalNotFound        = "original-not-found"
+	ReversalInvalid         = "reversal-invalid"
+	ReversalExceedsOriginal = "reversal-exceeds-original"
+)
+
+// maxOverdraftLimitAttempts bounds how many times a debit is tried again when the
+// overdraft limit is changed while it is written.
+const maxOverdraftLimitAttempts = 3
+
+var errOverdraftLimitChanged = errors.New("overdraft limit changed while debiting")
+
 type accreditation struct {
 	log        Logger
 	repository Persistence
+	authorizer Authorizer
+	config     *Config
+	clock      func() time.Time
 }
 
 func (a *accreditation) SettlementWithContext(ctx context.Context, input *SettlementInput) (*SettlementOutput, error) {
-	createAccountOuput := &SettlementOutput{
-		Error: false,
+	if input.OriginalExternalKey != "" {
+		return a.reverseWithContext(ctx, input)
+	}
+
+	currency, ok := validateCurrency(input.Currency)
+	if !ok {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   CurrencyInvalid,
+			Detail: currencyInvalidDetail(input.Currency),
+		}, nil
+	}
+
+	v := validateInstallments(input)
+	if v != nil {
+		return v, nil
 	}
 
 	i := &InsertInput{
 		AccountKey:     input.AccountKey,
+		EntryKey:       entryKey(input.Origin, transactionScope, input.ExternalKey),
 		ExternalKey:    input.ExternalKey,
 		OperatiionType: input.OperationType,
 		Amount:         input.Amount,
+		Currency:       currency,
+		CreatedAt:      a.clock(),
+		Origin:         input.Origin,
+		RequestID:      input.RequestID,
+		Description:    input.Description,
+		Metadata:       input.Metadata,
+		RiskScore:      input.RiskScore,
+		RequestHash:    requestHash(input),
 	}
+	i.Event = settledEvent(i.AccountKey, i.EntryKey)
 
-	res, err := a.repository.InsertWithContext(ctx, i)
+	if input.Installments > 1 {
+		i.Schedule = installmentSchedule(input, currency, i.EntryKey, i.CreatedAt)
+		i.Amount = i.Schedule[0].Amount
+	}
 
-	if err != nil {
-		a.log.Error(fmt.Sprintf("Repository insert error %s", err.Error()))
-		return nil, err
+	var res *InsertOutput
+	for attempt := 0; attempt < maxOverdraftLimitAttempts; attempt++ {
+		if input.Amount < 0 {
+			limit, err := a.overdraftLimitWithContext(ctx, input.AccountKey, currency)
+			if err != nil {
+				return nil, err
+			}
+			i.OverdraftLimit = limit
+		}
+
+		var err error
+		res, err = a.repository.InsertWithContext(ctx, i)
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository insert error %s", err.Error()))
+			return nil, err
+		}
+		if res == nil || !res.OverdraftLimitChanged {
+			break
+		}
+		a.log.Info("Overdraft limit changed during the debit, retrying")
+	}
+
+	if res != nil && res.OverdraftLimitChanged {
+		a.log.Error(fmt.Sprintf("Repository insert error %s", errOverdraftLimitChanged.Error()))
+		return nil, errOverdraftLimitChanged
 	}
 
 	if res != nil && res.AlreadyExists {
+		return a.replayWithContext(ctx, input, i.EntryKey, i.RequestHash)
+	}
+
+	if res != nil && res.InsufficientFunds {
 		return &SettlementOutput{
 			Error:  true,
-			Code:   "item-already-exists",
-			Detail: "item already exists",
+			Code:   InsufficientFunds,
+			Detail: "insufficient funds",
 		}, nil
 	}
 
-	return createAccountOuput, nil
+	return &SettlementOutput{
+		Error:     false,
+		Amount:    input.Amount,
+		Currency:  currency,
+		CreatedAt: i.CreatedAt,
+		RequestID: i.RequestID,
+	}, nil
 }
 
-func New(r Persistence, log Logger) Balance {
+// overdraftLimitWithContext reads the overdraft limit of an account. The debit written
+// with it fails when the limit is changed in between, so it is read again on retries.
+func (a *accreditation) overdraftLimitWithContext(ctx context.Context, accountKey string, currency string) (int, error) {
+	so, err := a.repository.GetSummaryWithContext(ctx, &GetSummaryInput{
+		AccountKey: accountKey,
+		Currency:   currency,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get summary error %s", err.Error()))
+		return 0, err
+	}
+	if so == nil {
+		return 0, nil
+	}
+
+	return so.OverdraftLimit, nil
+}
+
+func (a *accreditation) GetBalanceWithContext(ctx context.Context, input *GetBalanceInput) (*GetBalanceOutput, error) {
+	currency, ok := validateCurrency(input.Currency)
+	if !ok {
+		return &GetBalanceOutput{
+			Error:  true,
+			Code:   CurrencyInvalid,
+			Detail: currencyInvalidDetail(input.Currency),
+		}, nil
+	}
+
+	i := &GetSummaryInput{
+		AccountKey: input.AccountKey,
+		Currency:   currency,
+	}
+
+	o, err := a.repository.GetSummaryWithContext(ctx, i)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get summary error %s", err.Error()))
+		return nil, err
+	}
+
+	exponent, _ := CurrencyExponent(currency)
+	getBalanceOutput := &GetBalanceOutput{
+		AccountKey: input.AccountKey,
+		Currency:   currency,
+		Exponent:   exponent,
+	}
+	if o != nil {
+		getBalanceOutput.AvailableBalance = o.Balance
+		getBalanceOutput.HeldAmount = o.Held
+		getBalanceOutput.LedgerBalance = o.Balance + o.Held
+		getBalanceOutput.TotalCredits = o.TotalCredits
+		getBalanceOutput.TotalDebits = o.TotalDebits
+		getBalanceOutput.EntryCount = o.EntryCount
+	}
+
+	return getBalanceOutput, nil
+}
+
+func (a *accreditation) SetOverdraftLimitWithContext(ctx context.Context, input *SetOverdraftLimitInput) (*SetOverdraftLimitOutput, error) {
+	if input.OverdraftLimit < 0 {
+		return &SetOverdraftLimitOutput{
+			Error:  true,
+			Code:   OverdraftLimitInvalid,
+			Detail: "overdraft limit must not be negative",
+		}, nil
+	}
+
+	currency, ok := validateCurrency(input.Currency)
+	if !ok {
+		return &SetOverdraftLimitOutput{
+			Error:  true,
+			Code:   CurrencyInvalid,
+			Detail: currencyInvalidDetail(input.Currency),
+		}, nil
+	}
+
+	i := &UpdateOverdraftLimitInput{
+		AccountKey:     input.AccountKey,
+		Currency:       currency,
+		OverdraftLimit: input.OverdraftLimit,
+	}
+
+	err := a.repository.UpdateOverdraftLimitWithContext(ctx, i)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository update overdraft limit error %s", err.Error()))
+		return nil, err
+	}
+
+	return &SetOverdraftLimitOutput{
+		Error: false,
+	}, nil
+}
+
+func (a *accreditation) SummariesRebuiltWithContext(ctx context.Context) (bool, error) {
+	rebuilt, err := a.repository.GetSummariesRebuiltWithContext(ctx)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get summaries rebuilt error %s", err.Error()))
+		return false, err
+	}
+
+	return rebuilt, nil
+}
+
+func (a *accreditation) RebuildSummariesWithContext(ctx context.Context, input *RebuildSummariesInput) (*RebuildSummariesOutput, error) {
+	accountKeys := input.AccountKeys
+	if len(accountKeys) == 0 {
+		keys, err := a.repository.ListAccountKeysWithContext(ctx)
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository list account keys error %s", err.Error()))
+			return nil, err
+		}
+		accountKeys = keys
+	}
+
+	for _, accountKey := range accountKeys {
+		o, err := a.repository.QueryWithContext(ctx, &QueryInput{
+			AccountKey: accountKey,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository query error %s", err.Error()))
+			return nil, err
+		}
+
+		summaries := make(map[string]*PutSummaryInput)
+		currencies := make([]string, 0)
+		summary := func(currency string) *PutSummaryInput {
+			currency = currencyOrDefault(currency)
+			if _, ok := summaries[currency]; !ok {
+				summaries[currency] = &PutSummaryInput{
+					AccountKey: accountKey,
+					Currency:   currency,
+				}
+				currencies = append(currencies, currency)
+			}
+			return summaries[currency]
+		}
+
+		for _, e := range o.Entries {
+			i := summary(e.Currency)
+			if e.Amount > 0 {
+				i.TotalCredits += e.Amount
+			} else {
+				i.TotalDebits += e.Amount * -1
+			}
+			i.Balance += e.Amount
+			i.EntryCount++
+		}
+
+		ho, err := a.repository.ListHoldsWithContext(ctx, &ListHoldsInput{
+			AccountKey: accountKey,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository list holds error %s", err.Error()))
+			return nil, err
+		}
+		for _, h := range ho.Holds {
+			if h.Status == HoldActive {
+				i := summary(h.Currency)
+				i.Held += h.Amount
+				i.Balance -= h.Amount
+			}
+		}
+		if len(currencies) == 0 {
+			summary(DefaultCurrency)
+		}
+
+		for _, currency := range currencies {
+			if err := a.repository.PutSummaryWithContext(ctx, summaries[currency]); err != nil {
+				a.log.Error(fmt.Sprintf("Repository put summary error %s", err.Error()))
+				return nil, err
+			}
+		}
+		for _, u := range rebuildUsage(accountKey, o.Entries, a.clock()) {
+			if err := a.repository.PutUsageWithContext(ctx, u); err != nil {
+				a.log.Error(fmt.Sprintf("Repository put usage error %s", err.Error()))
+				return nil, err
+			}
+		}
+		a.log.Info(fmt.Sprintf("Summary rebuilt for account %s", accountKey))
+	}
+
+	// Debits are only checked against summaries, so a full rebuild is what allows the
+	// service to start.
+	if len(input.AccountKeys) == 0 {
+		if err := a.repository.PutSummariesRebuiltWithContext(ctx); err != nil {
+			a.log.Error(fmt.Sprintf("Repository put summaries rebuilt error %s", err.Error()))
+			return nil, err
+		}
+	}
+
+	return &RebuildSummariesOutput{
+		AccountKeys: accountKeys,
+	}, nil
+}
+
+func New(r Persistence, authorizer Authorizer, config *Config, log Logger) Balance {
+	if config == nil || config.HoldExpiration <= 0 {
+		config = &Config{
+			HoldExpiration: DefaultHoldExpiration,
+		}
+	}
 	return &accreditation{
 		repository: r,
+		authorizer: authorizer,
+		config:     config,
 		log:        log,
+		clock:      time.Now,
 	}
 }
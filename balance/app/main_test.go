@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
 )
 
 type repositoryMock struct {
@@ -27,10 +30,335 @@ func (r repositoryMock) InsertWithContext(ctx context.Context, input *InsertInpu
 		}, nil
 	}
 
+	if input.AccountKey == "11111111115" {
+		return &InsertOutput{
+			InsufficientFunds: true,
+		}, nil
+	}
+
+	if input.AccountKey == "11111111117" {
+		return &InsertOutput{
+			ReversalExceeded: true,
+		}, nil
+	}
+
+	if input.AccountKey == "11111111119" {
+		return &InsertOutput{
+			OverdraftLimitChanged: true,
+		}, nil
+	}
+
 	return &InsertOutput{
 		AlreadyExists: false,
 	}, nil
 }
+func (r repositoryMock) QueryWithContext(ctx context.Context, input *QueryInput) (*QueryOutput, error) {
+	if input.AccountKey == "11111111112" {
+		return nil, errors.New("query error")
+	}
+
+	if input.AccountKey == "33333333333" {
+		return &QueryOutput{
+			Entries: []*Entry{
+				{AccountKey: input.AccountKey, ExternalKey: "1", OperationType: "Payment", Amount: 1000, Currency: "BRL"},
+				{AccountKey: input.AccountKey, ExternalKey: "2", OperationType: "Payment", Amount: 5000, Currency: "USD"},
+				{AccountKey: input.AccountKey, ExternalKey: "3", OperationType: "Buying", Amount: -1500, Currency: "USD"},
+			},
+		}, nil
+	}
+
+	if input.AccountKey == "44444444444" || input.AccountKey == "55555555555" {
+		return &QueryOutput{
+			Entries: []*Entry{
+				{AccountKey: input.AccountKey, ExternalKey: "1", OperationType: "Payment", Amount: 5000, Currency: "BRL", CreatedAt: testNow.Add(-72 * time.Hour)},
+				{AccountKey: input.AccountKey, ExternalKey: "2", OperationType: "Withdraw", Amount: -3000, Currency: "BRL", ReversedAmount: 1000, CreatedAt: testNow.Add(-time.Hour)},
+				{AccountKey: input.AccountKey, ExternalKey: "3", OperationType: "Withdraw", Amount: -500, Currency: "BRL", CreatedAt: testNow.Add(-48 * time.Hour)},
+				{AccountKey: input.AccountKey, ExternalKey: "4", OperationType: "Withdraw", Amount: -200, Currency: "BRL", CreatedAt: testNow.AddDate(0, -1, 0)},
+				{AccountKey: input.AccountKey, ExternalKey: "5", OperationType: "Buying", Amount: -700, Currency: "USD", CreatedAt: testNow},
+				{AccountKey: input.AccountKey, ExternalKey: "r1", OperationType: "Reversal", Amount: 1000, Currency: "BRL", OriginalExternalKey: "2", CreatedAt: testNow},
+			},
+		}, nil
+	}
+
+	return &QueryOutput{
+		Entries: []*Entry{
+			{AccountKey: input.AccountKey, ExternalKey: "1", OperationType: "Payment", Amount: 1000},
+			{AccountKey: input.AccountKey, ExternalKey: "2", OperationType: "Withdraw", Amount: -300},
+			{AccountKey: input.AccountKey, ExternalKey: "3", OperationType: "Buying", Amount: -200},
+		},
+	}, nil
+}
+func (r repositoryMock) GetSummaryWithContext(ctx context.Context, input *GetSummaryInput) (*GetSummaryOutput, error) {
+	if input.AccountKey == "11111111114" {
+		return nil, errors.New("get summary error")
+	}
+
+	if input.AccountKey == "11111111116" {
+		return nil, nil
+	}
+
+	return &GetSummaryOutput{
+		AccountKey:     input.AccountKey,
+		Balance:        100,
+		TotalCredits:   1000,
+		TotalDebits:    900,
+		EntryCount:     4,
+		OverdraftLimit: 500,
+		Held:           200,
+	}, nil
+}
+func (r repositoryMock) PutSummaryWithContext(ctx context.Context, input *PutSummaryInput) error {
+	if input.AccountKey == "11111111113" {
+		return errors.New("put summary error")
+	}
+
+	if input.AccountKey == "33333333333" && input.Currency == "USD" {
+		assert.Equal(r.t, 3500, input.Balance)
+		assert.Equal(r.t, 5000, input.TotalCredits)
+		assert.Equal(r.t, 1500, input.TotalDebits)
+		assert.Equal(r.t, 2, input.EntryCount)
+		return nil
+	}
+
+	if input.AccountKey == "33333333333" {
+		assert.Equal(r.t, "BRL", input.Currency)
+		assert.Equal(r.t, 1000, input.Balance)
+		assert.Equal(r.t, 1, input.EntryCount)
+		return nil
+	}
+
+	// The usage of these accounts is what their rebuild tests check.
+	if input.AccountKey == "44444444444" || input.AccountKey == "55555555555" {
+		return nil
+	}
+
+	if input.AccountKey == "11111111118" {
+		assert.Equal(r.t, 300, input.Balance)
+		assert.Equal(r.t, 200, input.Held)
+		return nil
+	}
+
+	assert.Equal(r.t, 500, input.Balance)
+	assert.Equal(r.t, 1000, input.TotalCredits)
+	assert.Equal(r.t, 500, input.TotalDebits)
+	assert.Equal(r.t, 3, input.EntryCount)
+	return nil
+}
+func (r repositoryMock) GetUsageWithContext(ctx context.Context, input *GetUsageRecordInput) (*Usage, error) {
+	if input.AccountKey == "11111111112" {
+		return nil, errors.New("get usage error")
+	}
+
+	switch fmt.Sprintf("%s#%s#%s", input.Currency, input.OperationType, input.Period) {
+	case "BRL#Withdraw#2022-01-31":
+		return &Usage{Amount: 2000, Count: 1}, nil
+	case "BRL#Withdraw#2022-01":
+		return &Usage{Amount: 2500, Count: 2}, nil
+	case "BRL#InstallmentBuying#2022-01":
+		return &Usage{Amount: 300, Count: 1}, nil
+	}
+	return nil, nil
+}
+func (r repositoryMock) PutUsageWithContext(ctx context.Context, input *PutUsageInput) error {
+	if input.AccountKey == "55555555555" {
+		return errors.New("put usage error")
+	}
+
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Contains(r.t, r.v, string(v))
+	return nil
+}
+func (r repositoryMock) ListAccountKeysWithContext(ctx context.Context) ([]string, error) {
+	if r.v == "1" {
+		return nil, errors.New("list error")
+	}
+
+	return []string{"11111111111", "11111111114"}, nil
+}
+func (r repositoryMock) GetSummariesRebuiltWithContext(ctx context.Context) (bool, error) {
+	if r.v == "2" {
+		return false, errors.New("get summaries rebuilt error")
+	}
+
+	return r.v == "rebuilt", nil
+}
+func (r repositoryMock) PutSummariesRebuiltWithContext(ctx context.Context) error {
+	if r.v == "2" {
+		return errors.New("put summaries rebuilt error")
+	}
+
+	return nil
+}
+func (r repositoryMock) UpdateOverdraftLimitWithContext(ctx context.Context, input *UpdateOverdraftLimitInput) error {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	if input.AccountKey == "11111111112" {
+		return errors.New("update error")
+	}
+
+	return nil
+}
+func (r repositoryMock) ListDueInstallmentsWithContext(ctx context.Context, input *ListDueInstallmentsInput) (*ListDueInstallmentsOutput, error) {
+	if r.v == "1" {
+		return nil, errors.New("list due installments error")
+	}
+
+	return &ListDueInstallmentsOutput{
+		Installments: []*Installment{
+			{AccountKey: "1", ExternalKey: "1", EntryKey: "1#02", Number: 2, Count: 3, Amount: -333, Status: InstallmentScheduled},
+			{AccountKey: "1", ExternalKey: "1", EntryKey: "1#03", Number: 3, Count: 3, Amount: -333, Status: InstallmentScheduled},
+			{AccountKey: "2", ExternalKey: "2", EntryKey: "2#02", Number: 2, Count: 2, Amount: -500, Status: InstallmentScheduled},
+		},
+	}, nil
+}
+func (r repositoryMock) PostInstallmentWithContext(ctx context.Context, input *Installment) (*PostInstallmentOutput, error) {
+	if input.EntryKey == "1#03" {
+		return &PostInstallmentOutput{
+			AlreadyPosted: true,
+		}, nil
+	}
+
+	if input.EntryKey == "2#02" {
+		return nil, errors.New("post installment error")
+	}
+
+	return &PostInstallmentOutput{}, nil
+}
+func (r repositoryMock) GetEntryWithContext(ctx context.Context, input *GetEntryInput) (*Entry, error) {
+	if input.AccountKey == "11111111114" {
+		return nil, errors.New("get entry error")
+	}
+
+	if input.EntryKey == "#reversal#replay" {
+		return &Entry{
+			AccountKey:          input.AccountKey,
+			EntryKey:            input.EntryKey,
+			OperationType:       "Reversal",
+			Amount:              700,
+			OriginalExternalKey: "123",
+			CreatedAt:           testNow.Add(-time.Hour),
+			RequestID:           "original-request",
+			RequestHash:         requestHash(&SettlementInput{OperationType: "Reversal", OriginalExternalKey: "123"}),
+		}, nil
+	}
+
+	if input.EntryKey == "balance#transfer#replay" {
+		return &Entry{
+			AccountKey:    input.AccountKey,
+			EntryKey:      input.EntryKey,
+			OperationType: "TransferOut",
+			Amount:        -1000,
+			CreatedAt:     testNow.Add(-time.Hour),
+			RequestID:     "original-request",
+			RequestHash:   transferHash(&TransferInput{ToAccountKey: "22222222222", Amount: 1000}),
+			TransferID:    "original-transfer",
+		}, nil
+	}
+
+	if input.AccountKey == "11111111113" && strings.Contains(input.EntryKey, "#transaction#") {
+		return &Entry{
+			AccountKey:    input.AccountKey,
+			EntryKey:      input.EntryKey,
+			OperationType: "Payment",
+			Amount:        500,
+			CreatedAt:     testNow.Add(-time.Hour),
+			RequestID:     "original-request",
+			RequestHash:   requestHash(&SettlementInput{OperationType: "Payment", Amount: 500}),
+		}, nil
+	}
+
+	return nil, nil
+}
+func (r repositoryMock) FindEntriesWithContext(ctx context.Context, input *FindEntriesInput) (*FindEntriesOutput, error) {
+	if input.ExternalKey == "500" {
+		return nil, errors.New("find entries error")
+	}
+
+	entries := make([]*Entry, 0)
+	switch input.ExternalKey {
+	case "404":
+	case "credit":
+		entries = append(entries, &Entry{AccountKey: input.AccountKey, EntryKey: "#Payment#credit", ExternalKey: input.ExternalKey, OperationType: "Payment", Amount: 1000})
+	case "installment":
+		entries = append(entries, &Entry{AccountKey: input.AccountKey, EntryKey: "#InstallmentBuying#installment", ExternalKey: input.ExternalKey, OperationType: "InstallmentBuying", Amount: -334, InstallmentCount: 3})
+	case "ambiguous":
+		entries = append(entries,
+			&Entry{AccountKey: input.AccountKey, EntryKey: "#Buying#ambiguous", ExternalKey: input.ExternalKey, OperationType: "Buying", Amount: -1000},
+			&Entry{AccountKey: input.AccountKey, EntryKey: "#Withdraw#ambiguous", ExternalKey: input.ExternalKey, OperationType: "Withdraw", Amount: -1000},
+		)
+	default:
+		entries = append(entries,
+			&Entry{AccountKey: input.AccountKey, EntryKey: "credit#Buying#" + input.ExternalKey, ExternalKey: input.ExternalKey, Origin: "credit", OperationType: "Buying", Amount: -50},
+			&Entry{AccountKey: input.AccountKey, EntryKey: "#Buying#" + input.ExternalKey, ExternalKey: input.ExternalKey, OperationType: "Buying", Amount: -1000, ReversedAmount: 300},
+		)
+	}
+
+	return &FindEntriesOutput{
+		Entries: entries,
+	}, nil
+}
+func (r repositoryMock) InsertTransferWithContext(ctx context.Context, input *InsertTransferInput) (*InsertTransferOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	if input.Debit.AccountKey == "11111111112" {
+		return nil, errors.New("insert transfer error")
+	}
+
+	if input.Debit.AccountKey == "11111111113" {
+		return &InsertTransferOutput{
+			AlreadyExists: true,
+		}, nil
+	}
+
+	if input.Debit.AccountKey == "11111111115" {
+		return &InsertTransferOutput{
+			InsufficientFunds: true,
+		}, nil
+	}
+
+	if input.Debit.AccountKey == "11111111119" {
+		return &InsertTransferOutput{
+			OverdraftLimitChanged: true,
+		}, nil
+	}
+
+	return &InsertTransferOutput{}, nil
+}
+func (r repositoryMock) ListEntriesWithContext(ctx context.Context, input *ListEntriesInput) (*ListEntriesOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	if input.AccountKey == "11111111112" {
+		return nil, errors.New("list entries error")
+	}
+
+	if input.Cursor == "invalid" {
+		return &ListEntriesOutput{
+			CursorInvalid: true,
+		}, nil
+	}
+
+	return &ListEntriesOutput{
+		Entries: []*Entry{
+			{AccountKey: input.AccountKey, ExternalKey: "1", OperationType: "Payment", Amount: 1000, CreatedAt: testNow},
+		},
+		NextCursor: "next",
+	}, nil
+}
+
+var testNow = time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)
+
+func newWithClock(r Persistence, l Logger, now time.Time) Balance {
+	a := New(r, &authorizerMock{}, &Config{}, l)
+	a.(*accreditation).clock = func() time.Time {
+		return now
+	}
+	return a
+}
+
 func newRepositoryMock(v string, t *testing.T) Persistence {
 	return &repositoryMock{
 		v: v,
@@ -38,6 +366,24 @@ func newRepositoryMock(v string, t *testing.T) Persistence {
 	}
 }
 
+type authorizerMock struct{}
+
+func (a *authorizerMock) AuthorizeWithContext(ctx context.Context, input *AuthorizeInput) (*AuthorizeOutput, error) {
+	switch input.AccountKey {
+	case "500":
+		return nil, errors.New("authorize error")
+	case "404":
+		return nil, nil
+	case "502":
+		return &AuthorizeOutput{HasError: true}, nil
+	case "blocked":
+		return &AuthorizeOutput{HasError: true, Code: AccountBlocked}, nil
+	case "33333333333", "44444444444":
+		return &AuthorizeOutput{HasError: false, Currencies: []string{"BRL", "USD"}}, nil
+	}
+	return &AuthorizeOutput{HasError: false}, nil
+}
+
 type log struct{}
 
 func (l log) Info(msg string)  {}
@@ -48,8 +394,8 @@ func newLogMock() Logger {
 
 func TestAccreditation_Settlement(t *testing.T) {
 	l := newLogMock()
-	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"ExternalKey\":\"123\",\"OperatiionType\":\"test\",\"Amount\":1000}", t)
-	a := New(r, l)
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"EntryKey\":\"#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"test\",\"Amount\":1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"3154e1af93fb13955e048909045bcfc4bbcc8abf07eb12ef4dcfc585a3f4a4e0\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"10ee38057e611cb4722703493f02a3a3a818fceacbdfd3a0fb93daa574d7093d\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
 	i := &SettlementInput{
 		AccountKey:    "11111111111",
 		ExternalKey:   "123",
@@ -60,13 +406,13 @@ func TestAccreditation_Settlement(t *testing.T) {
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\"}", string(validate))
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":1000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"\"}", string(validate))
 }
 
 func TestAccreditation_NotSettlementWhenInsertError(t *testing.T) {
 	l := newLogMock()
-	r := newRepositoryMock("{\"AccountKey\":\"11111111112\",\"ExternalKey\":\"123\",\"OperatiionType\":\"test\",\"Amount\":1000}", t)
-	a := New(r, l)
+	r := newRepositoryMock("{\"AccountKey\":\"11111111112\",\"EntryKey\":\"#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"test\",\"Amount\":1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"3154e1af93fb13955e048909045bcfc4bbcc8abf07eb12ef4dcfc585a3f4a4e0\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"944a073bf72cd322673af378515046b85ee88f342e239e7abffd04feab3f5e8c\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
 	i := &SettlementInput{
 		AccountKey:    "11111111112",
 		ExternalKey:   "123",
@@ -78,10 +424,10 @@ func TestAccreditation_NotSettlementWhenInsertError(t *testing.T) {
 	assert.Nil(t, res)
 }
 
-func TestAccreditation_NotSettlementWhenItemAlreadyExists(t *testing.T) {
+func TestAccreditation_NotSettlementWhenIdempotencyKeyReused(t *testing.T) {
 	l := newLogMock()
-	r := newRepositoryMock("{\"AccountKey\":\"11111111113\",\"ExternalKey\":\"123\",\"OperatiionType\":\"test\",\"Amount\":1000}", t)
-	a := New(r, l)
+	r := newRepositoryMock("{\"AccountKey\":\"11111111113\",\"EntryKey\":\"#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"test\",\"Amount\":1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"3154e1af93fb13955e048909045bcfc4bbcc8abf07eb12ef4dcfc585a3f4a4e0\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"60a2df30e8268929e859f00a504f8b57a550aa816393fe189982b2f39c63bedf\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
 	i := &SettlementInput{
 		AccountKey:    "11111111113",
 		ExternalKey:   "123",
@@ -92,5 +438,286 @@ func TestAccreditation_NotSettlementWhenItemAlreadyExists(t *testing.T) {
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"Error\":true,\"Code\":\"item-already-exists\",\"Detail\":\"item already exists\"}", string(validate))
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"idempotency-key-reused\",\"Detail\":\"external_key was already used for a different request\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestAccreditation_GetBalance(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &GetBalanceInput{
+		AccountKey: "11111111111",
+	}
+	res, err := a.GetBalanceWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"AccountKey\":\"11111111111\",\"Currency\":\"BRL\",\"Exponent\":2,\"AvailableBalance\":100,\"HeldAmount\":200,\"LedgerBalance\":300,\"TotalCredits\":1000,\"TotalDebits\":900,\"EntryCount\":4}", string(validate))
+}
+
+func TestAccreditation_GetBalanceWhenSummaryNotFound(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &GetBalanceInput{
+		AccountKey: "11111111116",
+	}
+	res, err := a.GetBalanceWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"AccountKey\":\"11111111116\",\"Currency\":\"BRL\",\"Exponent\":2,\"AvailableBalance\":0,\"HeldAmount\":0,\"LedgerBalance\":0,\"TotalCredits\":0,\"TotalDebits\":0,\"EntryCount\":0}", string(validate))
+}
+
+func TestAccreditation_NotGetBalanceWhenGetSummaryError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &GetBalanceInput{
+		AccountKey: "11111111114",
+	}
+	res, err := a.GetBalanceWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "get summary error", err.Error())
+}
+
+func TestAccreditation_SettlementDebitWithOverdraftLimit(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"EntryKey\":\"#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"Withdraw\",\"Amount\":-600,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"815e7430f16f1eb3b434573a5ee427665d2118b49b6e004f720432b76464f09a\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"10ee38057e611cb4722703493f02a3a3a818fceacbdfd3a0fb93daa574d7093d\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "123",
+		OperationType: "Withdraw",
+		Amount:        -600,
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":-600,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestAccreditation_NotSettlementWhenInsufficientFunds(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111115\",\"EntryKey\":\"#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"Withdraw\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"4e3f20dfd6acf48a79159ca34a90015aede4197c1163bc0ee3a49507aacf9a70\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"a60486945a3b4ebed1df0c38b43f122bc08839c10aeb8c26902ca295b8d24856\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111115",
+		ExternalKey:   "123",
+		OperationType: "Withdraw",
+		Amount:        -1000,
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"insufficient-funds\",\"Detail\":\"insufficient funds\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestAccreditation_NotSettlementWhenOverdraftLimitKeepsChanging(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111119\",\"EntryKey\":\"#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"Withdraw\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"4e3f20dfd6acf48a79159ca34a90015aede4197c1163bc0ee3a49507aacf9a70\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"68ffb1033a091cad87f8c1d17611ffedf51b7e5fa29505f85c8b0e64e40b6bdb\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111119",
+		ExternalKey:   "123",
+		OperationType: "Withdraw",
+		Amount:        -1000,
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "overdraft limit changed while debiting", err.Error())
+}
+
+func TestAccreditation_NotSettlementWhenGetSummaryError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111114",
+		ExternalKey:   "123",
+		OperationType: "Withdraw",
+		Amount:        -1000,
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "get summary error", err.Error())
+}
+
+func TestAccreditation_SetOverdraftLimit(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"Currency\":\"BRL\",\"OverdraftLimit\":500}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SetOverdraftLimitInput{
+		AccountKey:     "11111111111",
+		OverdraftLimit: 500,
+	}
+	res, err := a.SetOverdraftLimitWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\"}", string(validate))
+}
+
+func TestAccreditation_NotSetOverdraftLimitWhenNegative(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SetOverdraftLimitInput{
+		AccountKey:     "11111111111",
+		OverdraftLimit: -1,
+	}
+	res, err := a.SetOverdraftLimitWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"overdraft-limit-invalid\",\"Detail\":\"overdraft limit must not be negative\"}", string(validate))
+}
+
+func TestAccreditation_NotSetOverdraftLimitWhenUpdateError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111112\",\"Currency\":\"BRL\",\"OverdraftLimit\":500}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SetOverdraftLimitInput{
+		AccountKey:     "11111111112",
+		OverdraftLimit: 500,
+	}
+	res, err := a.SetOverdraftLimitWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "update error", err.Error())
+}
+
+func TestAccreditation_RebuildSummaries(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &RebuildSummariesInput{
+		AccountKeys: []string{"11111111111"},
+	}
+	res, err := a.RebuildSummariesWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountKeys\":[\"11111111111\"]}", string(validate))
+}
+
+func TestAccreditation_RebuildSummariesWhenAllAccounts(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &RebuildSummariesInput{}
+	res, err := a.RebuildSummariesWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountKeys\":[\"11111111111\",\"11111111114\"]}", string(validate))
+}
+
+func TestAccreditation_NotRebuildSummariesWhenPutSummariesRebuiltError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("2", t)
+	a := newWithClock(r, l, testNow)
+	i := &RebuildSummariesInput{}
+	res, err := a.RebuildSummariesWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "put summaries rebuilt error", err.Error())
+}
+
+func TestAccreditation_SummariesRebuilt(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("rebuilt", t)
+	a := newWithClock(r, l, testNow)
+	rebuilt, err := a.SummariesRebuiltWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, rebuilt)
+}
+
+func TestAccreditation_SummariesNotRebuilt(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	rebuilt, err := a.SummariesRebuiltWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, rebuilt)
+}
+
+func TestAccreditation_NotSummariesRebuiltWhenGetError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("2", t)
+	a := newWithClock(r, l, testNow)
+	rebuilt, err := a.SummariesRebuiltWithContext(context.Background())
+	assert.False(t, rebuilt)
+	assert.Equal(t, "get summaries rebuilt error", err.Error())
+}
+
+func TestAccreditation_NotRebuildSummariesWhenListError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("1", t)
+	a := newWithClock(r, l, testNow)
+	i := &RebuildSummariesInput{}
+	res, err := a.RebuildSummariesWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "list error", err.Error())
+}
+
+func TestAccreditation_NotRebuildSummariesWhenQueryError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &RebuildSummariesInput{
+		AccountKeys: []string{"11111111112"},
+	}
+	res, err := a.RebuildSummariesWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "query error", err.Error())
+}
+
+func TestAccreditation_NotRebuildSummariesWhenPutSummaryError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &RebuildSummariesInput{
+		AccountKeys: []string{"11111111113"},
+	}
+	res, err := a.RebuildSummariesWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "put summary error", err.Error())
+}
+
+func TestAccreditation_SettlementWithMetadata(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"EntryKey\":\"credit#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"Payment\",\"Amount\":1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"credit\",\"RequestID\":\"req-1\",\"Description\":\"salary\",\"Metadata\":{\"order_id\":\"42\"},\"RiskScore\":0,\"RequestHash\":\"3ac2f4000628b322c50496246c711584fd80d1901666cf008a0f025d6b1d740d\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"3c4eafc6e77bda1f69149bf030aa15c7d1345457825859a2bd91e84130c9d9a2\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111111",
+		ExternalKey:   "123",
+		OperationType: "Payment",
+		Amount:        1000,
+		Origin:        "credit",
+		RequestID:     "req-1",
+		Description:   "salary",
+		Metadata:      map[string]string{"order_id": "42"},
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.False(t, res.Error)
+}
+
+func TestAccreditation_SettlementReplay(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111113\",\"EntryKey\":\"credit#transaction#123\",\"ExternalKey\":\"123\",\"OperatiionType\":\"Payment\",\"Amount\":500,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"credit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"147d2491ad9119151b4dfe14ffdacebd974b52517928d798df112e0e5225be86\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"ce2c6d7a1c9fc20688aea666402b715e1c528d999c75e23638c7b01ed21e3261\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:    "11111111113",
+		ExternalKey:   "123",
+		OperationType: "Payment",
+		Amount:        500,
+		Origin:        "credit",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":500,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"RequestID\":\"original-request\"}", string(validate))
 }
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	TransactionSettled = "TransactionSettled"
+
+	DefaultRelayBatchSize = 100
+)
+
+// Outbox keeps the events written together with the ledger entries until the relay
+// has published them.
+type Outbox interface {
+	ListPendingEventsWithContext(ctx context.Context, input *ListPendingEventsInput) (*ListPendingEventsOutput, error)
+	GetEventWithContext(ctx context.Context, input *GetEventInput) (*Event, error)
+	MarkEventPublishedWithContext(ctx context.Context, input *MarkEventPublishedInput) error
+}
+
+// Lease lets a single replica run the relay at a time, so two of them never publish
+// the events of an account concurrently.
+type Lease interface {
+	AcquireLeaseWithContext(ctx context.Context, input *AcquireLeaseInput) (*AcquireLeaseOutput, error)
+}
+
+// Publisher delivers one event to a sink. It may be called again for an event it
+// already delivered, so consumers should deduplicate by EventID.
+type Publisher interface {
+	PublishWithContext(ctx context.Context, input *Event) error
+}
+
+type Relay interface {
+	RelayWithContext(ctx context.Context, input *RelayInput) (*RelayOutput, error)
+}
+
+// Event announces a ledger entry. Sequence numbers the events of an account in a
+// currency from 1, without gaps, in the order they were committed.
+type Event struct {
+	EventID   string
+	Type      string
+	Sequence  int
+	Published bool
+	Entry     *Entry
+}
+
+type ListPendingEventsInput struct {
+	Limit int
+}
+type ListPendingEventsOutput struct {
+	Events []*Event
+}
+
+type GetEventInput struct {
+	AccountKey string
+	Currency   string
+	Sequence   int
+}
+
+type MarkEventPublishedInput struct {
+	AccountKey  string
+	Currency    string
+	Sequence    int
+	PublishedAt time.Time
+}
+
+type AcquireLeaseInput struct {
+	Owner    string
+	Now      time.Time
+	Duration time.Duration
+}
+type AcquireLeaseOutput struct {
+	Acquired bool
+}
+
+type RelayInput struct {
+	Limit int
+}
+type RelayOutput struct {
+	Published int
+	Failed    int
+	Skipped   int
+}
+
+// eventID is the same for every write of an entry, so a retried settlement can
+// never announce it twice.
+func eventID(accountKey string, entryKey string) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s", accountKey, entryKey)))
+	return hex.EncodeToString(h[:])
+}
+
+func settledEvent(accountKey string, entryKey string) *Event {
+	return &Event{
+		EventID: eventID(accountKey, entryKey),
+		Type:    TransactionSettled,
+	}
+}
+
+type relay struct {
+	log       Logger
+	outbox    Outbox
+	publisher Publisher
+	clock     func() time.Time
+}
+
+// eventStream is the events of an account in a currency, numbered by Sequence.
+type eventStream struct {
+	accountKey string
+	currency   string
+}
+
+// RelayWithContext publishes the pending events oldest first, each account in the
+// order of its sequence. An event only goes out after the one before it, which the
+// pending index may not show yet. Once an event of an account fails or has to wait
+// the later ones of that account wait for the next run.
+func (r *relay) RelayWithContext(ctx context.Context, input *RelayInput) (*RelayOutput, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultRelayBatchSize
+	}
+
+	o, err := r.outbox.ListPendingEventsWithContext(ctx, &ListPendingEventsInput{
+		Limit: limit,
+	})
+	if err != nil {
+		r.log.Error(fmt.Sprintf("Outbox list pending events error %s", err.Error()))
+		return nil, err
+	}
+
+	events := o.Events
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i].Entry, events[j].Entry
+		if a.AccountKey != b.AccountKey {
+			return a.AccountKey < b.AccountKey
+		}
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return events[i].Sequence < events[j].Sequence
+	})
+
+	relayOutput := &RelayOutput{}
+	blocked := make(map[string]bool)
+	published := make(map[eventStream]int)
+	for _, e := range events {
+		accountKey := e.Entry.AccountKey
+		if blocked[accountKey] {
+			relayOutput.Skipped++
+			continue
+		}
+
+		stream := eventStream{accountKey: accountKey, currency: e.Entry.Currency}
+		ready, err := r.previousPublishedWithContext(ctx, e, published[stream])
+		if err != nil {
+			r.log.Error(fmt.Sprintf("Outbox get event before %s error %s", e.EventID, err.Error()))
+			blocked[accountKey] = true
+			relayOutput.Failed++
+			continue
+		}
+		if !ready {
+			blocked[accountKey] = true
+			relayOutput.Skipped++
+			continue
+		}
+
+		if err := r.publisher.PublishWithContext(ctx, e); err != nil {
+			r.log.Error(fmt.Sprintf("Publish event %s error %s", e.EventID, err.Error()))
+			blocked[accountKey] = true
+			relayOutput.Failed++
+			continue
+		}
+
+		// Published but not marked: the event goes out again on the next run.
+		err = r.outbox.MarkEventPublishedWithContext(ctx, &MarkEventPublishedInput{
+			AccountKey:  accountKey,
+			Currency:    e.Entry.Currency,
+			Sequence:    e.Sequence,
+			PublishedAt: r.clock(),
+		})
+		if err != nil {
+			r.log.Error(fmt.Sprintf("Outbox mark event %s error %s", e.EventID, err.Error()))
+			blocked[accountKey] = true
+			relayOutput.Failed++
+			continue
+		}
+		published[stream] = e.Sequence
+		relayOutput.Published++
+	}
+
+	return relayOutput, nil
+}
+
+// previousPublishedWithContext tells whether the event before e was published, by this
+// run or an earlier one. The counter only moves with an event, so a missing one was
+// removed from the table after it went out.
+func (r *relay) previousPublishedWithContext(ctx context.Context, e *Event, lastPublished int) (bool, error) {
+	if e.Sequence <= 1 || lastPublished == e.Sequence-1 {
+		return true, nil
+	}
+
+	previous, err := r.outbox.GetEventWithContext(ctx, &GetEventInput{
+		AccountKey: e.Entry.AccountKey,
+		Currency:   e.Entry.Currency,
+		Sequence:   e.Sequence - 1,
+	})
+	if err != nil {
+		return false, err
+	}
+	return previous == nil || previous.Published, nil
+}
+
+func NewRelay(outbox Outbox, publisher Publisher, log Logger) Relay {
+	return &relay{
+		log:       log,
+		outbox:    outbox,
+		publisher: publisher,
+		clock:     time.Now,
+	}
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type outboxMock struct {
+	events   []*Event
+	stored   map[string]*Event
+	failGet  bool
+	failMark string
+	marked   []string
+}
+
+func eventKey(accountKey string, sequence int) string {
+	return fmt.Sprintf("%s/%d", accountKey, sequence)
+}
+
+func (o *outboxMock) ListPendingEventsWithContext(ctx context.Context, input *ListPendingEventsInput) (*ListPendingEventsOutput, error) {
+	if o.events == nil {
+		return nil, errors.New("list error")
+	}
+	return &ListPendingEventsOutput{
+		Events: o.events,
+	}, nil
+}
+func (o *outboxMock) GetEventWithContext(ctx context.Context, input *GetEventInput) (*Event, error) {
+	if o.failGet {
+		return nil, errors.New("get error")
+	}
+	return o.stored[eventKey(input.AccountKey, input.Sequence)], nil
+}
+func (o *outboxMock) MarkEventPublishedWithContext(ctx context.Context, input *MarkEventPublishedInput) error {
+	key := eventKey(input.AccountKey, input.Sequence)
+	if key == o.failMark {
+		return errors.New("mark error")
+	}
+	o.marked = append(o.marked, key)
+	return nil
+}
+
+type publisherMock struct {
+	fail      string
+	published []string
+}
+
+func (p *publisherMock) PublishWithContext(ctx context.Context, input *Event) error {
+	key := eventKey(input.Entry.AccountKey, input.Sequence)
+	if key == p.fail {
+		return errors.New("publish error")
+	}
+	p.published = append(p.published, key)
+	return nil
+}
+
+// newPendingEvents lists the second event of account 1 before its first, as the
+// pending index may.
+func newPendingEvents() []*Event {
+	return []*Event{
+		{EventID: "e3", Type: TransactionSettled, Sequence: 2, Entry: &Entry{AccountKey: "1", ExternalKey: "3", Currency: "BRL"}},
+		{EventID: "e1", Type: TransactionSettled, Sequence: 1, Entry: &Entry{AccountKey: "1", ExternalKey: "1", Currency: "BRL"}},
+		{EventID: "e2", Type: TransactionSettled, Sequence: 7, Entry: &Entry{AccountKey: "2", ExternalKey: "2", Currency: "BRL"}},
+	}
+}
+
+func newOutboxMock(events []*Event) *outboxMock {
+	return &outboxMock{
+		events: events,
+		stored: map[string]*Event{
+			"2/6": {EventID: "e0", Type: TransactionSettled, Sequence: 6, Published: true, Entry: &Entry{AccountKey: "2", ExternalKey: "0", Currency: "BRL"}},
+		},
+	}
+}
+
+func newRelayWithClock(o Outbox, p Publisher) Relay {
+	r := NewRelay(o, p, newLogMock())
+	r.(*relay).clock = func() time.Time {
+		return time.Date(2022, 1, 31, 10, 0, 5, 0, time.UTC)
+	}
+	return r
+}
+
+func TestRelay_Relay(t *testing.T) {
+	o := newOutboxMock(newPendingEvents())
+	p := &publisherMock{}
+	r := newRelayWithClock(o, p)
+	res, err := r.RelayWithContext(context.Background(), &RelayInput{})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Published\":3,\"Failed\":0,\"Skipped\":0}", string(b))
+	assert.Equal(t, p.published, o.marked)
+	assert.Equal(t, []string{"1/1", "1/2", "2/7"}, o.marked)
+}
+
+func TestRelay_WaitWhenPreviousEventPending(t *testing.T) {
+	o := newOutboxMock(newPendingEvents())
+	o.stored["2/6"].Published = false
+	p := &publisherMock{}
+	r := newRelayWithClock(o, p)
+	res, err := r.RelayWithContext(context.Background(), &RelayInput{})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Published\":2,\"Failed\":0,\"Skipped\":1}", string(b))
+	assert.Equal(t, []string{"1/1", "1/2"}, o.marked)
+}
+
+func TestRelay_SkipAccountWhenGetEventError(t *testing.T) {
+	o := newOutboxMock(newPendingEvents())
+	o.failGet = true
+	p := &publisherMock{}
+	r := newRelayWithClock(o, p)
+	res, err := r.RelayWithContext(context.Background(), &RelayInput{})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Published\":2,\"Failed\":1,\"Skipped\":0}", string(b))
+	assert.Equal(t, []string{"1/1", "1/2"}, p.published)
+}
+
+func TestRelay_SkipAccountWhenPublishError(t *testing.T) {
+	o := newOutboxMock(newPendingEvents())
+	p := &publisherMock{fail: "1/1"}
+	r := newRelayWithClock(o, p)
+	res, err := r.RelayWithContext(context.Background(), &RelayInput{})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Published\":1,\"Failed\":1,\"Skipped\":1}", string(b))
+	assert.Equal(t, []string{"2/7"}, o.marked)
+}
+
+func TestRelay_SkipAccountWhenMarkError(t *testing.T) {
+	o := newOutboxMock(newPendingEvents())
+	o.failMark = "1/1"
+	p := &publisherMock{}
+	r := newRelayWithClock(o, p)
+	res, err := r.RelayWithContext(context.Background(), &RelayInput{})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Published\":1,\"Failed\":1,\"Skipped\":1}", string(b))
+	assert.Equal(t, []string{"1/1", "2/7"}, p.published)
+}
+
+func TestRelay_NotRelayWhenListError(t *testing.T) {
+	o := &outboxMock{}
+	p := &publisherMock{}
+	r := newRelayWithClock(o, p)
+	res, err := r.RelayWithContext(context.Background(), &RelayInput{})
+	assert.Nil(t, res)
+	assert.Equal(t, "list error", err.Error())
+}
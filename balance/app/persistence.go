@@ -1,17 +1,268 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Persistence interface {
 	InsertWithContext(ctx context.Context, input *InsertInput) (*InsertOutput, error)
+	QueryWithContext(ctx context.Context, input *QueryInput) (*QueryOutput, error)
+	GetSummaryWithContext(ctx context.Context, input *GetSummaryInput) (*GetSummaryOutput, error)
+	UpdateOverdraftLimitWithContext(ctx context.Context, input *UpdateOverdraftLimitInput) error
+	PutSummaryWithContext(ctx context.Context, input *PutSummaryInput) error
+	ListAccountKeysWithContext(ctx context.Context) ([]string, error)
+	GetSummariesRebuiltWithContext(ctx context.Context) (bool, error)
+	PutSummariesRebuiltWithContext(ctx context.Context) error
+	ListDueInstallmentsWithContext(ctx context.Context, input *ListDueInstallmentsInput) (*ListDueInstallmentsOutput, error)
+	PostInstallmentWithContext(ctx context.Context, input *Installment) (*PostInstallmentOutput, error)
+	GetEntryWithContext(ctx context.Context, input *GetEntryInput) (*Entry, error)
+	FindEntriesWithContext(ctx context.Context, input *FindEntriesInput) (*FindEntriesOutput, error)
+	ListEntriesWithContext(ctx context.Context, input *ListEntriesInput) (*ListEntriesOutput, error)
+	InsertTransferWithContext(ctx context.Context, input *InsertTransferInput) (*InsertTransferOutput, error)
+	InsertHoldWithContext(ctx context.Context, input *InsertHoldInput) (*InsertHoldOutput, error)
+	GetHoldWithContext(ctx context.Context, input *GetHoldInput) (*Hold, error)
+	CaptureHoldWithContext(ctx context.Context, input *CaptureHoldRecordInput) (*CaptureHoldRecordOutput, error)
+	ReleaseHoldWithContext(ctx context.Context, input *ReleaseHoldInput) (*ReleaseHoldOutput, error)
+	ListExpiredHoldsWithContext(ctx context.Context, input *ListExpiredHoldsInput) (*ListHoldsOutput, error)
+	ListHoldsWithContext(ctx context.Context, input *ListHoldsInput) (*ListHoldsOutput, error)
+	GetUsageWithContext(ctx context.Context, input *GetUsageRecordInput) (*Usage, error)
+	PutUsageWithContext(ctx context.Context, input *PutUsageInput) error
 }
 
 type InsertInput struct {
 	AccountKey     string
+	EntryKey       string
 	ExternalKey    string
 	OperatiionType string
 	Amount         int
+	Currency       string
+	OverdraftLimit int
+	CreatedAt      time.Time
+	Origin         string
+	RequestID      string
+	Description    string
+	Metadata       map[string]string
+	RiskScore      int
+	RequestHash    string
+	TransferID     string
+	Schedule       []*Installment
+	// OriginalExternalKey links a reversal to the debit it compensates, whose reversed
+	// amount may not go above MaxReversedAmount once this reversal is counted.
+	OriginalExternalKey string
+	OriginalEntryKey    string
+	MaxReversedAmount   int
+	// The reversal is taken off the usage of the original debit, in the periods it
+	// was counted in.
+	OriginalOperationType string
+	OriginalCreatedAt     time.Time
+	// Event is written to the outbox in the same transaction as the entry.
+	Event *Event
 }
 type InsertOutput struct {
+	AlreadyExists     bool
+	InsufficientFunds bool
+	ReversalExceeded  bool
+	// OverdraftLimitChanged is set when the limit moved after it was read, the debit
+	// should be tried again with the new one.
+	OverdraftLimitChanged bool
+}
+
+type QueryInput struct {
+	AccountKey string
+}
+type QueryOutput struct {
+	Entries []*Entry
+}
+
+type Entry struct {
+	AccountKey          string
+	EntryKey            string
+	ExternalKey         string
+	OperationType       string
+	Amount              int
+	Currency            string
+	InstallmentCount    int
+	OriginalExternalKey string
+	ReversedAmount      int
+	CreatedAt           time.Time
+	Origin              string
+	RequestID           string
+	Description         string
+	Metadata            map[string]string
+	RiskScore           int
+	RequestHash         string
+	TransferID          string
+}
+
+type ListEntriesInput struct {
+	AccountKey    string
+	OperationType string
+	From          time.Time
+	To            time.Time
+	Limit         int
+	Cursor        string
+}
+type ListEntriesOutput struct {
+	Entries       []*Entry
+	NextCursor    string
+	CursorInvalid bool
+}
+
+// InsertTransferInput carries both legs of a transfer, written in one transaction.
+type InsertTransferInput struct {
+	Debit  *InsertInput
+	Credit *InsertInput
+}
+type InsertTransferOutput struct {
+	AlreadyExists         bool
+	InsufficientFunds     bool
+	OverdraftLimitChanged bool
+}
+
+type GetEntryInput struct {
+	AccountKey string
+	EntryKey   string
+}
+
+type FindEntriesInput struct {
+	AccountKey  string
+	ExternalKey string
+}
+type FindEntriesOutput struct {
+	Entries []*Entry
+}
+
+type GetSummaryInput struct {
+	AccountKey string
+	Currency   string
+}
+type GetSummaryOutput struct {
+	AccountKey     string
+	Currency       string
+	Balance        int
+	TotalCredits   int
+	TotalDebits    int
+	EntryCount     int
+	OverdraftLimit int
+	Held           int
+}
+
+type PutSummaryInput struct {
+	AccountKey   string
+	Currency     string
+	Balance      int
+	TotalCredits int
+	TotalDebits  int
+	EntryCount   int
+	Held         int
+}
+
+type UpdateOverdraftLimitInput struct {
+	AccountKey     string
+	Currency       string
+	OverdraftLimit int
+}
+
+type Installment struct {
+	AccountKey    string
+	ExternalKey   string
+	EntryKey      string
+	OperationType string
+	Number        int
+	Count         int
+	Amount        int
+	Currency      string
+	DueDate       time.Time
+	Status        string
+	PostedAt      time.Time
+	Origin        string
+	RequestID     string
+	Description   string
+	Metadata      map[string]string
+	// Event is only set when the installment is posted to the ledger.
+	Event *Event
+}
+
+type ListDueInstallmentsInput struct {
+	DueBefore time.Time
+}
+type ListDueInstallmentsOutput struct {
+	Installments []*Installment
+}
+
+type PostInstallmentOutput struct {
+	AlreadyPosted bool
+}
+
+// Hold reserves funds for a later capture. While it is active its amount is out of
+// the summary balance and counted in Held, so the ledger balance is Balance + Held.
+type Hold struct {
+	AccountKey     string
+	ExternalKey    string
+	OperationType  string
+	Amount         int
+	Currency       string
+	CapturedAmount int
+	Status         string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	ClosedAt       time.Time
+	Origin         string
+	RequestID      string
+	Description    string
+	Metadata       map[string]string
+	RiskScore      int
+	RequestHash    string
+}
+
+type InsertHoldInput struct {
+	Hold           *Hold
+	OverdraftLimit int
+}
+type InsertHoldOutput struct {
+	AlreadyExists         bool
+	InsufficientFunds     bool
+	OverdraftLimitChanged bool
+}
+
+type GetHoldInput struct {
+	AccountKey  string
+	ExternalKey string
+}
+
+// CaptureHoldRecordInput closes an active hold, writing Entry for the captured part
+// and giving the rest of HeldAmount back to the balance of the entry currency.
+type CaptureHoldRecordInput struct {
+	AccountKey  string
+	ExternalKey string
+	HeldAmount  int
+	Entry       *InsertInput
+}
+type CaptureHoldRecordOutput struct {
+	HoldChanged   bool
 	AlreadyExists bool
 }
+
+type ReleaseHoldInput struct {
+	AccountKey  string
+	ExternalKey string
+	Amount      int
+	Currency    string
+	Status      string
+	ClosedAt    time.Time
+}
+type ReleaseHoldOutput struct {
+	HoldChanged bool
+}
+
+type ListExpiredHoldsInput struct {
+	ExpiresBefore time.Time
+}
+
+type ListHoldsInput struct {
+	AccountKey string
+}
+type ListHoldsOutput struct {
+	Holds []*Hold
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+const Reversal = "Reversal"
+
+func (a *accreditation) reverseWithContext(ctx context.Context, input *SettlementInput) (*SettlementOutput, error) {
+	key := entryKey(input.Origin, reversalScope, input.ExternalKey)
+	hash := requestHash(input)
+	existing, err := a.repository.GetEntryWithContext(ctx, &GetEntryInput{
+		AccountKey: input.AccountKey,
+		EntryKey:   key,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get entry error %s", err.Error()))
+		return nil, err
+	}
+
+	// A replayed reversal has already been applied, so it is answered before the
+	// remaining amount is checked, which it would otherwise exceed.
+	if existing != nil {
+		return replayOutput(input, existing, hash), nil
+	}
+
+	fi := &FindEntriesInput{
+		AccountKey:  input.AccountKey,
+		ExternalKey: input.OriginalExternalKey,
+	}
+	fo, err := a.repository.FindEntriesWithContext(ctx, fi)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository find entries error %s", err.Error()))
+		return nil, err
+	}
+
+	candidates := make([]*Entry, 0)
+	for _, e := range fo.Entries {
+		if e.Origin != input.Origin || e.OperationType == Reversal {
+			continue
+		}
+		if e.InstallmentCount > 1 {
+			return &SettlementOutput{
+				Error:  true,
+				Code:   ReversalInvalid,
+				Detail: "only single debits can be reversed",
+			}, nil
+		}
+		candidates = append(candidates, e)
+	}
+
+	if len(candidates) == 0 {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   OriginalNotFound,
+			Detail: "original transaction not found",
+		}, nil
+	}
+
+	if len(candidates) > 1 {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   ReversalInvalid,
+			Detail: "original external key matches more than one transaction",
+		}, nil
+	}
+
+	original := candidates[0]
+	if original.Amount >= 0 {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   ReversalInvalid,
+			Detail: "only single debits can be reversed",
+		}, nil
+	}
+
+	currency := currencyOrDefault(original.Currency)
+	if input.Currency != "" && input.Currency != currency {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   ReversalInvalid,
+			Detail: fmt.Sprintf("the original transaction is in %s", currency),
+		}, nil
+	}
+
+	remaining := original.Amount*-1 - original.ReversedAmount
+	amount := input.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+
+	if amount <= 0 || amount > remaining {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   ReversalExceedsOriginal,
+			Detail: fmt.Sprintf("reversal amount exceeds the %d %s minor units left to reverse", remaining, currency),
+		}, nil
+	}
+
+	i := &InsertInput{
+		AccountKey:            input.AccountKey,
+		EntryKey:              key,
+		ExternalKey:           input.ExternalKey,
+		OperatiionType:        Reversal,
+		Amount:                amount,
+		Currency:              currency,
+		CreatedAt:             a.clock(),
+		Origin:                input.Origin,
+		RequestID:             input.RequestID,
+		Description:           input.Description,
+		Metadata:              input.Metadata,
+		OriginalExternalKey:   input.OriginalExternalKey,
+		RequestHash:           hash,
+		OriginalEntryKey:      original.EntryKey,
+		MaxReversedAmount:     original.Amount*-1 - amount,
+		OriginalOperationType: original.OperationType,
+		OriginalCreatedAt:     original.CreatedAt,
+		Event:                 settledEvent(input.AccountKey, key),
+	}
+
+	res, err := a.repository.InsertWithContext(ctx, i)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository insert error %s", err.Error()))
+		return nil, err
+	}
+
+	if res != nil && res.AlreadyExists {
+		return a.replayWithContext(ctx, input, key, hash)
+	}
+
+	if res != nil && res.ReversalExceeded {
+		return &SettlementOutput{
+			Error:  true,
+			Code:   ReversalExceedsOriginal,
+			Detail: "reversal amount exceeds what is left to reverse",
+		}, nil
+	}
+
+	return &SettlementOutput{
+		Error:     false,
+		Amount:    amount,
+		Currency:  currency,
+		CreatedAt: i.CreatedAt,
+		RequestID: i.RequestID,
+	}, nil
+}
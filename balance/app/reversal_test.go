@@ -0,0 +1,225 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReversal_SettlementFullReversal(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"EntryKey\":\"#reversal#r1\",\"ExternalKey\":\"r1\",\"OperatiionType\":\"Reversal\",\"Amount\":700,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"6e1a211a65c81b23af24517a9ed0b2904eea7faf319c6cf6ebf068951dd681e4\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"123\",\"OriginalEntryKey\":\"#Buying#123\",\"MaxReversedAmount\":300,\"OriginalOperationType\":\"Buying\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"9ec3ec544fba56a18743d76ec696ea86cbb10bbb03d4a5f1f4b969c8e11592b2\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "123",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":700,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestReversal_SettlementPartialReversal(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"EntryKey\":\"#reversal#r1\",\"ExternalKey\":\"r1\",\"OperatiionType\":\"Reversal\",\"Amount\":200,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"215a6a5d5ab179b5fce1b72b051b5e522fdf4ecfcf91764a2113d3ec4aec9797\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"123\",\"OriginalEntryKey\":\"#Buying#123\",\"MaxReversedAmount\":800,\"OriginalOperationType\":\"Buying\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"9ec3ec544fba56a18743d76ec696ea86cbb10bbb03d4a5f1f4b969c8e11592b2\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		Amount:              200,
+		OriginalExternalKey: "123",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":200,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestReversal_NotSettlementWhenAmountExceedsOriginal(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		Amount:              701,
+		OriginalExternalKey: "123",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"reversal-exceeds-original\",\"Detail\":\"reversal amount exceeds the 700 BRL minor units left to reverse\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestReversal_NotSettlementWhenConcurrentReversalExceeded(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111117\",\"EntryKey\":\"#reversal#r1\",\"ExternalKey\":\"r1\",\"OperatiionType\":\"Reversal\",\"Amount\":700,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"6e1a211a65c81b23af24517a9ed0b2904eea7faf319c6cf6ebf068951dd681e4\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"123\",\"OriginalEntryKey\":\"#Buying#123\",\"MaxReversedAmount\":300,\"OriginalOperationType\":\"Buying\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"f7b8ddfcfd681644b75c3502b1e5b376026a44782e037a34974911f3820cfeb3\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111117",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "123",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"reversal-exceeds-original\",\"Detail\":\"reversal amount exceeds what is left to reverse\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestReversal_NotSettlementWhenReversalAlreadyExists(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111113\",\"EntryKey\":\"#reversal#r1\",\"ExternalKey\":\"r1\",\"OperatiionType\":\"Reversal\",\"Amount\":700,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"6e1a211a65c81b23af24517a9ed0b2904eea7faf319c6cf6ebf068951dd681e4\",\"TransferID\":\"\",\"Schedule\":null,\"OriginalExternalKey\":\"123\",\"OriginalEntryKey\":\"#Buying#123\",\"MaxReversedAmount\":300,\"OriginalOperationType\":\"Buying\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"52ce95d35549161735545f408053a0eacc3415352864632363dadb8c369de493\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111113",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "123",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"item-already-exists\",\"Detail\":\"item already exists\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestReversal_NotSettlementWhenOriginalNotFound(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "404",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"original-not-found\",\"Detail\":\"original transaction not found\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestReversal_NotSettlementWhenOriginalIsCredit(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "credit",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"reversal-invalid\",\"Detail\":\"only single debits can be reversed\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestReversal_NotSettlementWhenOriginalIsInstallment(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "installment",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"reversal-invalid\",\"Detail\":\"only single debits can be reversed\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestReversal_NotSettlementWhenFindEntriesError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "500",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "find entries error", err.Error())
+}
+
+func TestReversal_SettlementReplay(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"EntryKey\":\"#reversal#replay\",\"ExternalKey\":\"replay\",\"OperatiionType\":\"Reversal\",\"Amount\":700,\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RequestHash\":\"6e1a211a65c81b23af24517a9ed0b2904eea7faf319c6cf6ebf068951dd681e4\",\"Schedule\":null,\"OriginalExternalKey\":\"123\",\"OriginalEntryKey\":\"#Buying#123\",\"MaxReversedAmount\":300}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "replay",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "123",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":700,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"RequestID\":\"original-request\"}", string(validate))
+}
+
+func TestReversal_NotSettlementWhenReversalKeyUsedForAnotherOriginal(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"EntryKey\":\"#reversal#replay\",\"ExternalKey\":\"replay\",\"OperatiionType\":\"Reversal\",\"Amount\":700,\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RequestHash\":\"7cae5da72a743479b37412038800bf0a5b522e13f9f12b5612b6b669c7ccf61d\",\"Schedule\":null,\"OriginalExternalKey\":\"456\",\"OriginalEntryKey\":\"#Buying#456\",\"MaxReversedAmount\":300}", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "replay",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "456",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.Equal(t, "idempotency-key-reused", res.Code)
+}
+
+func TestReversal_NotSettlementWhenOriginalAmbiguous(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111111",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "ambiguous",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"reversal-invalid\",\"Detail\":\"original external key matches more than one transaction\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestReversal_NotSettlementWhenGetEntryError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &SettlementInput{
+		AccountKey:          "11111111114",
+		ExternalKey:         "r1",
+		OperationType:       "Reversal",
+		OriginalExternalKey: "123",
+	}
+	res, err := a.SettlementWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "get entry error", err.Error())
+}
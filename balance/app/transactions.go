@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	CursorInvalid            = "cursor-invalid"
+	TransactionNotFound      = "transaction-not-found"
+	DefaultTransactionsLimit = 50
+	MaxTransactionsLimit     = 100
+)
+
+func (a *accreditation) ListTransactionsWithContext(ctx context.Context, input *ListTransactionsInput) (*ListTransactionsOutput, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultTransactionsLimit
+	}
+	if limit > MaxTransactionsLimit {
+		limit = MaxTransactionsLimit
+	}
+
+	i := &ListEntriesInput{
+		AccountKey:    input.AccountKey,
+		OperationType: input.OperationType,
+		From:          input.From,
+		To:            input.To,
+		Limit:         limit,
+		Cursor:        input.Cursor,
+	}
+	res, err := a.repository.ListEntriesWithContext(ctx, i)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository list entries error %s", err.Error()))
+		return nil, err
+	}
+
+	if res.CursorInvalid {
+		return &ListTransactionsOutput{
+			Error:  true,
+			Code:   CursorInvalid,
+			Detail: "cursor is invalid",
+		}, nil
+	}
+
+	return &ListTransactionsOutput{
+		Transactions: res.Entries,
+		NextCursor:   res.NextCursor,
+	}, nil
+}
+
+func (a *accreditation) GetTransactionWithContext(ctx context.Context, input *GetTransactionInput) (*GetTransactionOutput, error) {
+	scope := transactionScope
+	if input.Reversal {
+		scope = reversalScope
+	}
+
+	e, err := a.repository.GetEntryWithContext(ctx, &GetEntryInput{
+		AccountKey: input.AccountKey,
+		EntryKey:   entryKey(input.Origin, scope, input.ExternalKey),
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get entry error %s", err.Error()))
+		return nil, err
+	}
+
+	if e == nil {
+		return &GetTransactionOutput{
+			Error:  true,
+			Code:   TransactionNotFound,
+			Detail: "transaction not found",
+		}, nil
+	}
+
+	return &GetTransactionOutput{
+		Transaction: e,
+	}, nil
+}
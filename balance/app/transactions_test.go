@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTransactions_List(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"OperationType\":\"\",\"From\":\"0001-01-01T00:00:00Z\",\"To\":\"0001-01-01T00:00:00Z\",\"Limit\":50,\"Cursor\":\"\"}", t)
+	a := newWithClock(r, l, testNow)
+	i := &ListTransactionsInput{
+		AccountKey: "11111111111",
+	}
+	res, err := a.ListTransactionsWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Transactions\":[{\"AccountKey\":\"11111111111\",\"EntryKey\":\"\",\"ExternalKey\":\"1\",\"OperationType\":\"Payment\",\"Amount\":1000,\"Currency\":\"\",\"InstallmentCount\":0,\"OriginalExternalKey\":\"\",\"ReversedAmount\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"\",\"TransferID\":\"\"}],\"NextCursor\":\"next\"}", string(validate))
+}
+
+func TestTransactions_ListWithFilters(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"OperationType\":\"Payment\",\"From\":\"2022-01-01T00:00:00Z\",\"To\":\"2022-01-31T10:00:00Z\",\"Limit\":100,\"Cursor\":\"cursor\"}", t)
+	a := newWithClock(r, l, testNow)
+	i := &ListTransactionsInput{
+		AccountKey:    "11111111111",
+		OperationType: "Payment",
+		From:          time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:            testNow,
+		Limit:         500,
+		Cursor:        "cursor",
+	}
+	res, err := a.ListTransactionsWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.Equal(t, "next", res.NextCursor)
+}
+
+func TestTransactions_NotListWhenCursorInvalid(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111111\",\"OperationType\":\"\",\"From\":\"0001-01-01T00:00:00Z\",\"To\":\"0001-01-01T00:00:00Z\",\"Limit\":50,\"Cursor\":\"invalid\"}", t)
+	a := newWithClock(r, l, testNow)
+	i := &ListTransactionsInput{
+		AccountKey: "11111111111",
+		Cursor:     "invalid",
+	}
+	res, err := a.ListTransactionsWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"cursor-invalid\",\"Detail\":\"cursor is invalid\",\"Transactions\":null,\"NextCursor\":\"\"}", string(validate))
+}
+
+func TestTransactions_NotListWhenRepositoryError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"AccountKey\":\"11111111112\",\"OperationType\":\"\",\"From\":\"0001-01-01T00:00:00Z\",\"To\":\"0001-01-01T00:00:00Z\",\"Limit\":50,\"Cursor\":\"\"}", t)
+	a := newWithClock(r, l, testNow)
+	i := &ListTransactionsInput{
+		AccountKey: "11111111112",
+	}
+	res, err := a.ListTransactionsWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "list entries error", err.Error())
+}
+
+func TestTransactions_Get(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &GetTransactionInput{
+		AccountKey:  "11111111113",
+		ExternalKey: "1",
+		Origin:      "credit",
+	}
+	res, err := a.GetTransactionWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Transaction\":{\"AccountKey\":\"11111111113\",\"EntryKey\":\"credit#transaction#1\",\"ExternalKey\":\"\",\"OperationType\":\"Payment\",\"Amount\":500,\"Currency\":\"\",\"InstallmentCount\":0,\"OriginalExternalKey\":\"\",\"ReversedAmount\":0,\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"Origin\":\"\",\"RequestID\":\"original-request\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"147d2491ad9119151b4dfe14ffdacebd974b52517928d798df112e0e5225be86\",\"TransferID\":\"\"}}", string(validate))
+}
+
+func TestTransactions_NotGetWhenNotFound(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &GetTransactionInput{
+		AccountKey:  "11111111113",
+		ExternalKey: "1",
+		Origin:      "debit",
+		Reversal:    true,
+	}
+	res, err := a.GetTransactionWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"transaction-not-found\",\"Detail\":\"transaction not found\",\"Transaction\":null}", string(validate))
+}
+
+func TestTransactions_NotGetWhenError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &GetTransactionInput{
+		AccountKey:  "11111111114",
+		ExternalKey: "1",
+		Origin:      "debit",
+	}
+	res, err := a.GetTransactionWithContext(context.Background(), i)
+	assert.NotNil(t, err)
+	assert.Nil(t, res)
+}
@@ -0,0 +1,217 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	TransferOut          = "TransferOut"
+	TransferIn           = "TransferIn"
+	TransferOrigin       = "balance"
+	AccountNotFound      = "account-not-found"
+	UnauthorizedTransfer = "unauthorized-transfer"
+	TransferInvalid      = "transfer-invalid"
+	AccountBlocked       = "account-blocked"
+	AccountClosed        = "account-closed"
+	transferScope        = "transfer"
+)
+
+// transferID is derived from the source account and the transfer key, so a retried
+// transfer always carries the same id.
+func transferID(input *TransferInput) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s", input.FromAccountKey, input.TransferKey)))
+	return hex.EncodeToString(h[:16])
+}
+
+func transferHash(input *TransferInput) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d%s", TransferOut, input.ToAccountKey, input.Amount, currencySuffix(input.Currency))))
+	return hex.EncodeToString(h[:])
+}
+
+func (a *accreditation) TransferWithContext(ctx context.Context, input *TransferInput) (*TransferOutput, error) {
+	if input.Amount <= 0 {
+		return &TransferOutput{
+			Error:  true,
+			Code:   TransferInvalid,
+			Detail: "amount must be positive",
+		}, nil
+	}
+
+	if input.FromAccountKey == input.ToAccountKey {
+		return &TransferOutput{
+			Error:  true,
+			Code:   TransferInvalid,
+			Detail: "from and to accounts must be different",
+		}, nil
+	}
+
+	currency, ok := validateCurrency(input.Currency)
+	if !ok {
+		return &TransferOutput{
+			Error:  true,
+			Code:   CurrencyInvalid,
+			Detail: currencyInvalidDetail(input.Currency),
+		}, nil
+	}
+
+	for _, accountKey := range []string{input.FromAccountKey, input.ToAccountKey} {
+		ao, err := a.authorizer.AuthorizeWithContext(ctx, &AuthorizeInput{
+			AccountKey: accountKey,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("authorize error %s", err.Error()))
+			return nil, err
+		}
+		if ao == nil {
+			return &TransferOutput{
+				Error:  true,
+				Code:   AccountNotFound,
+				Detail: fmt.Sprintf("account %s not found", accountKey),
+			}, nil
+		}
+		if ao.Code == AccountBlocked || ao.Code == AccountClosed {
+			return &TransferOutput{
+				Error:  true,
+				Code:   ao.Code,
+				Detail: fmt.Sprintf("account %s is not active", accountKey),
+			}, nil
+		}
+		if ao.HasError {
+			return &TransferOutput{
+				Error:  true,
+				Code:   UnauthorizedTransfer,
+				Detail: "Try again",
+			}, nil
+		}
+		if !holdsCurrency(ao.Currencies, currency) {
+			return &TransferOutput{
+				Error:  true,
+				Code:   CurrencyNotHeld,
+				Detail: fmt.Sprintf("account %s does not hold %s", accountKey, currency),
+			}, nil
+		}
+	}
+
+	id := transferID(input)
+	hash := transferHash(input)
+	createdAt := a.clock()
+	debit := &InsertInput{
+		AccountKey:     input.FromAccountKey,
+		EntryKey:       entryKey(TransferOrigin, transferScope, input.TransferKey),
+		ExternalKey:    input.TransferKey,
+		OperatiionType: TransferOut,
+		Amount:         input.Amount * -1,
+		Currency:       currency,
+		CreatedAt:      createdAt,
+		Origin:         TransferOrigin,
+		RequestID:      input.RequestID,
+		Description:    input.Description,
+		Metadata:       input.Metadata,
+		RequestHash:    hash,
+		TransferID:     id,
+	}
+	debit.Event = settledEvent(debit.AccountKey, debit.EntryKey)
+	// The credit leg is keyed by the transfer id because the transfer key is only
+	// unique on the source account.
+	credit := &InsertInput{
+		AccountKey:     input.ToAccountKey,
+		EntryKey:       entryKey(TransferOrigin, transferScope, id),
+		ExternalKey:    input.TransferKey,
+		OperatiionType: TransferIn,
+		Amount:         input.Amount,
+		Currency:       currency,
+		CreatedAt:      createdAt,
+		Origin:         TransferOrigin,
+		RequestID:      input.RequestID,
+		Description:    input.Description,
+		Metadata:       input.Metadata,
+		RequestHash:    hash,
+		TransferID:     id,
+	}
+	credit.Event = settledEvent(credit.AccountKey, credit.EntryKey)
+
+	var res *InsertTransferOutput
+	for attempt := 0; attempt < maxOverdraftLimitAttempts; attempt++ {
+		limit, err := a.overdraftLimitWithContext(ctx, input.FromAccountKey, currency)
+		if err != nil {
+			return nil, err
+		}
+		debit.OverdraftLimit = limit
+
+		res, err = a.repository.InsertTransferWithContext(ctx, &InsertTransferInput{
+			Debit:  debit,
+			Credit: credit,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository insert transfer error %s", err.Error()))
+			return nil, err
+		}
+		if res == nil || !res.OverdraftLimitChanged {
+			break
+		}
+		a.log.Info("Overdraft limit changed during the transfer, retrying")
+	}
+
+	if res != nil && res.OverdraftLimitChanged {
+		a.log.Error(fmt.Sprintf("Repository insert transfer error %s", errOverdraftLimitChanged.Error()))
+		return nil, errOverdraftLimitChanged
+	}
+
+	if res != nil && res.AlreadyExists {
+		return a.replayTransferWithContext(ctx, input, debit.EntryKey, hash)
+	}
+
+	if res != nil && res.InsufficientFunds {
+		return &TransferOutput{
+			Error:  true,
+			Code:   InsufficientFunds,
+			Detail: "insufficient funds",
+		}, nil
+	}
+
+	return &TransferOutput{
+		Error:      false,
+		TransferID: id,
+		Currency:   currency,
+		CreatedAt:  createdAt,
+		RequestID:  input.RequestID,
+	}, nil
+}
+
+func (a *accreditation) replayTransferWithContext(ctx context.Context, input *TransferInput, key string, hash string) (*TransferOutput, error) {
+	existing, err := a.repository.GetEntryWithContext(ctx, &GetEntryInput{
+		AccountKey: input.FromAccountKey,
+		EntryKey:   key,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get entry error %s", err.Error()))
+		return nil, err
+	}
+
+	if existing == nil {
+		return &TransferOutput{
+			Error:  true,
+			Code:   ItemAlreadyExists,
+			Detail: "item already exists",
+		}, nil
+	}
+
+	if existing.RequestHash != hash {
+		return &TransferOutput{
+			Error:  true,
+			Code:   IdempotencyKeyReused,
+			Detail: "transfer_key was already used for a different request",
+		}, nil
+	}
+
+	return &TransferOutput{
+		Error:      false,
+		TransferID: existing.TransferID,
+		Currency:   currencyOrDefault(existing.Currency),
+		CreatedAt:  existing.CreatedAt,
+		RequestID:  existing.RequestID,
+	}, nil
+}
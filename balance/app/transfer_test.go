@@ -0,0 +1,223 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTransfer_Transfer(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"Debit\":{\"AccountKey\":\"11111111111\",\"EntryKey\":\"balance#transfer#t1\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferOut\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"req-1\",\"Description\":\"rent\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"989e4fc87a0b55970231985b4ba908cf\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"2de39c5fd8ce9ef3688f896b7a7151ecb91fbd274e906be3a81148252c088f7f\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}},\"Credit\":{\"AccountKey\":\"22222222222\",\"EntryKey\":\"balance#transfer#989e4fc87a0b55970231985b4ba908cf\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferIn\",\"Amount\":1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"req-1\",\"Description\":\"rent\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"989e4fc87a0b55970231985b4ba908cf\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"15ec38fce8d7f641b380d6ebb08cc00ee94e7d34f9f7c5be5e9288f8e84b00a5\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111111",
+		ToAccountKey:   "22222222222",
+		Amount:         1000,
+		RequestID:      "req-1",
+		Description:    "rent",
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"TransferID\":\"989e4fc87a0b55970231985b4ba908cf\",\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"req-1\"}", string(validate))
+}
+
+func TestTransfer_NotTransferWhenAmountInvalid(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111111",
+		ToAccountKey:   "22222222222",
+		Amount:         -1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"transfer-invalid\",\"Detail\":\"amount must be positive\",\"TransferID\":\"\",\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestTransfer_NotTransferWhenSameAccount(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111111",
+		ToAccountKey:   "11111111111",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"transfer-invalid\",\"Detail\":\"from and to accounts must be different\",\"TransferID\":\"\",\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestTransfer_NotTransferWhenAccountNotFound(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111111",
+		ToAccountKey:   "404",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-not-found\",\"Detail\":\"account 404 not found\",\"TransferID\":\"\",\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestTransfer_NotTransferWhenUnauthorized(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "502",
+		ToAccountKey:   "22222222222",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"unauthorized-transfer\",\"Detail\":\"Try again\",\"TransferID\":\"\",\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestTransfer_NotTransferWhenAccountBlocked(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111111",
+		ToAccountKey:   "blocked",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-blocked\",\"Detail\":\"account blocked is not active\",\"TransferID\":\"\",\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestTransfer_NotTransferWhenAuthorizeError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111111",
+		ToAccountKey:   "500",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "authorize error", err.Error())
+}
+
+func TestTransfer_NotTransferWhenGetSummaryError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111114",
+		ToAccountKey:   "22222222222",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "get summary error", err.Error())
+}
+
+func TestTransfer_NotTransferWhenInsertError(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"Debit\":{\"AccountKey\":\"11111111112\",\"EntryKey\":\"balance#transfer#t1\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferOut\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"3db47c1710007752a9246e5649bbebb7\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"a9299125a4801275afda9108ec08f90ecef8c7e0090dc3e5bb74f73eeb7acba9\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}},\"Credit\":{\"AccountKey\":\"22222222222\",\"EntryKey\":\"balance#transfer#3db47c1710007752a9246e5649bbebb7\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferIn\",\"Amount\":1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"3db47c1710007752a9246e5649bbebb7\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"b4ee183ed6d51660d2100012e4bd07bc476f794f2034d0da8216d12e473c44c8\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111112",
+		ToAccountKey:   "22222222222",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "insert transfer error", err.Error())
+}
+
+func TestTransfer_NotTransferWhenInsufficientFunds(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"Debit\":{\"AccountKey\":\"11111111115\",\"EntryKey\":\"balance#transfer#t1\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferOut\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"0eb9fedee69cedbe7315d17dbf462b6a\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"f26370e562ca5a590660eab8758392842c9e8384a022267ac467a9950e6e7302\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}},\"Credit\":{\"AccountKey\":\"22222222222\",\"EntryKey\":\"balance#transfer#0eb9fedee69cedbe7315d17dbf462b6a\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferIn\",\"Amount\":1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"0eb9fedee69cedbe7315d17dbf462b6a\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"ff410e042fadb98841d03fdecf97e414b647c1c1be7518eeb39afbee4e391456\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111115",
+		ToAccountKey:   "22222222222",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"insufficient-funds\",\"Detail\":\"insufficient funds\",\"TransferID\":\"\",\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
+
+func TestTransfer_NotTransferWhenOverdraftLimitKeepsChanging(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"Debit\":{\"AccountKey\":\"11111111119\",\"EntryKey\":\"balance#transfer#t1\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferOut\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"4b068fa1360cefe185803c3b88c947d6\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"22f5a6cc6664c13e638b334e28a9629fd19629528d862f2dcd98ef4472f87d37\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}},\"Credit\":{\"AccountKey\":\"22222222222\",\"EntryKey\":\"balance#transfer#4b068fa1360cefe185803c3b88c947d6\",\"ExternalKey\":\"t1\",\"OperatiionType\":\"TransferIn\",\"Amount\":1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"4b068fa1360cefe185803c3b88c947d6\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"e41c1ae57b23645eaa9e78c36deeb4a34d6f9586c93daefc4cf29c09201041c9\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "t1",
+		FromAccountKey: "11111111119",
+		ToAccountKey:   "22222222222",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "overdraft limit changed while debiting", err.Error())
+}
+
+func TestTransfer_TransferReplay(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"Debit\":{\"AccountKey\":\"11111111113\",\"EntryKey\":\"balance#transfer#replay\",\"ExternalKey\":\"replay\",\"OperatiionType\":\"TransferOut\",\"Amount\":-1000,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"71a9814e57bd4a820fa18d44569ab2d7\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"c0d6fd407f8850a58280671cbb7dd928164da64c5ed2fc8b0f1f0f8400f03796\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}},\"Credit\":{\"AccountKey\":\"22222222222\",\"EntryKey\":\"balance#transfer#71a9814e57bd4a820fa18d44569ab2d7\",\"ExternalKey\":\"replay\",\"OperatiionType\":\"TransferIn\",\"Amount\":1000,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"f747e873fab8d28fcd3428b02a4e6a000f6e0b3ea981371d59b17e6a01c549cc\",\"TransferID\":\"71a9814e57bd4a820fa18d44569ab2d7\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"974c34a28a0c044baf92ac29137d4dda78d12ccf38bca0668fa814ac3a4f7816\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "replay",
+		FromAccountKey: "11111111113",
+		ToAccountKey:   "22222222222",
+		Amount:         1000,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"TransferID\":\"original-transfer\",\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"RequestID\":\"original-request\"}", string(validate))
+}
+
+func TestTransfer_NotTransferWhenTransferKeyReused(t *testing.T) {
+	l := newLogMock()
+	r := newRepositoryMock("{\"Debit\":{\"AccountKey\":\"11111111113\",\"EntryKey\":\"balance#transfer#replay\",\"ExternalKey\":\"replay\",\"OperatiionType\":\"TransferOut\",\"Amount\":-500,\"Currency\":\"BRL\",\"OverdraftLimit\":500,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"c4e99e1adee8a899bc8f594ff101850e0ed6573aba46e5bec15fb21441fa61ef\",\"TransferID\":\"71a9814e57bd4a820fa18d44569ab2d7\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"c0d6fd407f8850a58280671cbb7dd928164da64c5ed2fc8b0f1f0f8400f03796\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}},\"Credit\":{\"AccountKey\":\"22222222222\",\"EntryKey\":\"balance#transfer#71a9814e57bd4a820fa18d44569ab2d7\",\"ExternalKey\":\"replay\",\"OperatiionType\":\"TransferIn\",\"Amount\":500,\"Currency\":\"BRL\",\"OverdraftLimit\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"balance\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"c4e99e1adee8a899bc8f594ff101850e0ed6573aba46e5bec15fb21441fa61ef\",\"TransferID\":\"71a9814e57bd4a820fa18d44569ab2d7\",\"Schedule\":null,\"OriginalExternalKey\":\"\",\"OriginalEntryKey\":\"\",\"MaxReversedAmount\":0,\"OriginalOperationType\":\"\",\"OriginalCreatedAt\":\"0001-01-01T00:00:00Z\",\"Event\":{\"EventID\":\"974c34a28a0c044baf92ac29137d4dda78d12ccf38bca0668fa814ac3a4f7816\",\"Type\":\"TransactionSettled\",\"Sequence\":0,\"Published\":false,\"Entry\":null}}}", t)
+	a := newWithClock(r, l, testNow)
+	i := &TransferInput{
+		TransferKey:    "replay",
+		FromAccountKey: "11111111113",
+		ToAccountKey:   "22222222222",
+		Amount:         500,
+	}
+	res, err := a.TransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"idempotency-key-reused\",\"Detail\":\"transfer_key was already used for a different request\",\"TransferID\":\"\",\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(validate))
+}
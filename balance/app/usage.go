@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	UsageDay   = "day"
+	UsageMonth = "month"
+)
+
+// GetUsageInput sums what the account debited in one currency during the current
+// day or month, in UTC, over the given operation types.
+type GetUsageInput struct {
+	AccountKey     string
+	Currency       string
+	OperationTypes []string
+	Period         string
+}
+
+type GetUsageOutput struct {
+	Error    bool
+	Code     string
+	Detail   string
+	Currency string
+	Amount   int
+	Count    int
+}
+
+type GetUsageRecordInput struct {
+	AccountKey    string
+	Currency      string
+	OperationType string
+	Period        string
+}
+
+// Usage is the running total of the debits of one operation type in a period. The
+// amount is positive and reversals are already taken off it; the count is not.
+type Usage struct {
+	Amount int
+	Count  int
+}
+
+type PutUsageInput struct {
+	AccountKey    string
+	Currency      string
+	OperationType string
+	Period        string
+	Amount        int
+	Count         int
+}
+
+// UsagePeriods are the day and the month a debit made at t is counted in.
+func UsagePeriods(t time.Time) []string {
+	return []string{
+		usagePeriod(UsageDay, t),
+		usagePeriod(UsageMonth, t),
+	}
+}
+
+func usagePeriod(period string, t time.Time) string {
+	if period == UsageMonth {
+		return t.UTC().Format("2006-01")
+	}
+	return t.UTC().Format("2006-01-02")
+}
+
+func (a *accreditation) GetUsageWithContext(ctx context.Context, input *GetUsageInput) (*GetUsageOutput, error) {
+	currency, ok := validateCurrency(input.Currency)
+	if !ok {
+		return &GetUsageOutput{
+			Error:  true,
+			Code:   CurrencyInvalid,
+			Detail: currencyInvalidDetail(input.Currency),
+		}, nil
+	}
+
+	o := &GetUsageOutput{
+		Currency: currency,
+	}
+	period := usagePeriod(input.Period, a.clock())
+	for _, operationType := range input.OperationTypes {
+		u, err := a.repository.GetUsageWithContext(ctx, &GetUsageRecordInput{
+			AccountKey:    input.AccountKey,
+			Currency:      currency,
+			OperationType: operationType,
+			Period:        period,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository get usage error %s", err.Error()))
+			return nil, err
+		}
+		if u != nil {
+			o.Amount += u.Amount
+			o.Count += u.Count
+		}
+	}
+
+	return o, nil
+}
+
+// rebuildUsage recounts the usage of the current day and month from the entries,
+// net of what was reversed on each debit.
+func rebuildUsage(accountKey string, entries []*Entry, now time.Time) []*PutUsageInput {
+	current := UsagePeriods(now)
+	usages := make([]*PutUsageInput, 0)
+	byKey := make(map[string]*PutUsageInput)
+	for _, e := range entries {
+		if e.Amount >= 0 || e.OperationType == Reversal {
+			continue
+		}
+		for i, period := range UsagePeriods(e.CreatedAt) {
+			if period != current[i] {
+				continue
+			}
+			currency := currencyOrDefault(e.Currency)
+			key := fmt.Sprintf("%s#%s#%s", currency, e.OperationType, period)
+			u, ok := byKey[key]
+			if !ok {
+				u = &PutUsageInput{
+					AccountKey:    accountKey,
+					Currency:      currency,
+					OperationType: e.OperationType,
+					Period:        period,
+				}
+				byKey[key] = u
+				usages = append(usages, u)
+			}
+			u.Amount += e.Amount*-1 - e.ReversedAmount
+			u.Count++
+		}
+	}
+	return usages
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAccreditation_GetUsageOfDay(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.GetUsageWithContext(context.Background(), &GetUsageInput{
+		AccountKey:     "11111111111",
+		OperationTypes: []string{"Withdraw"},
+		Period:         UsageDay,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Currency\":\"BRL\",\"Amount\":2000,\"Count\":1}", string(b))
+}
+
+func TestAccreditation_GetUsageOfMonthOverOperationTypes(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.GetUsageWithContext(context.Background(), &GetUsageInput{
+		AccountKey:     "11111111111",
+		Currency:       "BRL",
+		OperationTypes: []string{"Buying", "InstallmentBuying", "Withdraw"},
+		Period:         UsageMonth,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Currency\":\"BRL\",\"Amount\":2800,\"Count\":3}", string(b))
+}
+
+func TestAccreditation_NotGetUsageWhenCurrencyInvalid(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.GetUsageWithContext(context.Background(), &GetUsageInput{
+		AccountKey:     "11111111111",
+		Currency:       "XX",
+		OperationTypes: []string{"Withdraw"},
+		Period:         UsageDay,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"currency-invalid\",\"Detail\":\"currency XX is not supported\",\"Currency\":\"\",\"Amount\":0,\"Count\":0}", string(b))
+}
+
+func TestAccreditation_NotGetUsageWhenRepositoryError(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.GetUsageWithContext(context.Background(), &GetUsageInput{
+		AccountKey:     "11111111112",
+		OperationTypes: []string{"Withdraw"},
+		Period:         UsageDay,
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "get usage error", err.Error())
+}
+
+func TestAccreditation_RebuildSummariesWithUsage(t *testing.T) {
+	v := `{"AccountKey":"44444444444","Currency":"BRL","OperationType":"Withdraw","Period":"2022-01-31","Amount":2000,"Count":1}` +
+		`{"AccountKey":"44444444444","Currency":"BRL","OperationType":"Withdraw","Period":"2022-01","Amount":2500,"Count":2}` +
+		`{"AccountKey":"44444444444","Currency":"USD","OperationType":"Buying","Period":"2022-01-31","Amount":700,"Count":1}` +
+		`{"AccountKey":"44444444444","Currency":"USD","OperationType":"Buying","Period":"2022-01","Amount":700,"Count":1}`
+	a := newWithClock(newRepositoryMock(v, t), newLogMock(), testNow)
+	res, err := a.RebuildSummariesWithContext(context.Background(), &RebuildSummariesInput{
+		AccountKeys: []string{"44444444444"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"44444444444"}, res.AccountKeys)
+}
+
+func TestAccreditation_NotRebuildSummariesWhenPutUsageError(t *testing.T) {
+	a := newWithClock(newRepositoryMock("", t), newLogMock(), testNow)
+	res, err := a.RebuildSummariesWithContext(context.Background(), &RebuildSummariesInput{
+		AccountKeys: []string{"55555555555"},
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "put usage error", err.Error())
+}
@@ -0,0 +1,83 @@
+package authorizer
+
+import (
+	"balance/app"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	AccountBlocked = "blocked"
+	AccountClosed  = "closed"
+)
+
+type AccountResponse struct {
+	Status     string   `json:"status,omitempty"`
+	Currencies []string `json:"currencies,omitempty"`
+}
+
+type accreditation struct {
+	log         Logger
+	config      *Config
+	httpService Http
+}
+
+type Http interface {
+	GetWithContext(ctx context.Context, url string) ([]byte, int, error)
+}
+
+func (a *accreditation) AuthorizeWithContext(ctx context.Context, input *app.AuthorizeInput) (*app.AuthorizeOutput, error) {
+	body, statusCode, err := a.httpService.GetWithContext(ctx, a.config.Url+input.AccountKey)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("http get error %s", err.Error()))
+		return nil, err
+	}
+
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+		return &app.AuthorizeOutput{
+			HasError: true,
+		}, nil
+	}
+
+	account := &AccountResponse{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, account); err != nil {
+			a.log.Error(fmt.Sprintf("account response error %s", err.Error()))
+			return &app.AuthorizeOutput{
+				HasError: true,
+			}, nil
+		}
+	}
+
+	switch account.Status {
+	case AccountBlocked:
+		return &app.AuthorizeOutput{
+			HasError: true,
+			Code:     app.AccountBlocked,
+		}, nil
+	case AccountClosed:
+		return &app.AuthorizeOutput{
+			HasError: true,
+			Code:     app.AccountClosed,
+		}, nil
+	}
+
+	return &app.AuthorizeOutput{
+		HasError:   false,
+		Currencies: account.Currencies,
+	}, nil
+}
+
+func New(log Logger, config *Config, httpService Http) app.Authorizer {
+	return &accreditation{
+		log:         log,
+		config:      config,
+		httpService: httpService,
+	}
+}
@@ -0,0 +1,6 @@
+package authorizer
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
@@ -2,8 +2,13 @@ package logger
 
 import (
 	"balance/app"
+	"balance/authorizer"
+	"balance/publisher"
+	"balance/rebuild"
+	"balance/relay"
 	"balance/repository"
 	"balance/routes"
+	"balance/scheduler"
 	"balance/server"
 	"log"
 )
@@ -22,6 +27,6 @@ func (l *logs) Error(msg string) {
 	log.Print(msg)
 }
 
-func New() (app.Logger, server.Logger, routes.Logger, repository.Logger) {
-	return &logs{}, &logs{}, &logs{}, &logs{}
+func New() (app.Logger, server.Logger, routes.Logger, repository.Logger, rebuild.Logger, scheduler.Logger, authorizer.Logger, publisher.Logger, relay.Logger) {
+	return &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}
 }
@@ -2,22 +2,69 @@ package main
 
 import (
 	"balance/app"
+	"balance/authorizer"
 	"balance/logger"
+	"balance/publisher"
+	"balance/rebuild"
+	"balance/relay"
 	"balance/repository"
 	"balance/routes"
+	"balance/scheduler"
 	"balance/server"
 	"balance/services"
 	"os"
 )
 
 func main() {
-	logApp, logServer, logRoutes, logDynamodb := logger.New()
+	logApp, logServer, logRoutes, logDynamodb, logRebuild, logScheduler, logAuthorizer, logPublisher, logRelay := logger.New()
 	dynamodbService := services.NewDynamodb()
 	dynamodbConfig := repository.Config{
-		TableName: os.Getenv("TABLE_NAME"),
+		TableName:            os.Getenv("TABLE_NAME"),
+		SummaryTableName:     os.Getenv("SUMMARY_TABLE_NAME"),
+		InstallmentTableName: os.Getenv("INSTALLMENT_TABLE_NAME"),
+		HoldTableName:        os.Getenv("HOLD_TABLE_NAME"),
+		OutboxTableName:      os.Getenv("OUTBOX_TABLE_NAME"),
 	}
 	dynamodb := repository.NewDynamodb(dynamodbService, logDynamodb, dynamodbConfig)
-	balance := app.New(dynamodb, logApp)
+	accreditationHttp, webhookHttp := services.NewHttp()
+	confAuthorizer := &authorizer.Config{}
+	confAuthorizer.WithUrl(os.Getenv("URL_ACCREDITATION"))
+	accreditation := authorizer.New(logAuthorizer, confAuthorizer, accreditationHttp)
+	confApp := &app.Config{}
+	confApp.WithHoldExpiration(os.Getenv("HOLD_EXPIRATION"))
+	balance := app.New(dynamodb, accreditation, confApp, logApp)
+	command := rebuild.New(balance, logRebuild)
+	if len(os.Args) > 1 && os.Args[1] == "rebuild" {
+		command.Run(os.Args[2:])
+		return
+	}
+	command.Check()
+	confScheduler := &scheduler.Config{}
+	confScheduler.WithInterval(os.Getenv("SCHEDULER_INTERVAL"))
+	jobs := scheduler.New(balance, logScheduler, confScheduler)
+	go jobs.Start()
+	confPublisher := &publisher.Config{}
+	confPublisher.WithTopicArn(os.Getenv("OUTBOX_TOPIC_ARN"))
+	confPublisher.WithSubject(os.Getenv("OUTBOX_NATS_SUBJECT"))
+	confPublisher.WithWebhookUrl(os.Getenv("OUTBOX_WEBHOOK_URL"))
+	var sink app.Publisher
+	switch os.Getenv("OUTBOX_SINK") {
+	case "sns":
+		sink = publisher.NewSns(logPublisher, confPublisher, services.NewSns())
+	case "nats":
+		sink = publisher.NewNats(logPublisher, confPublisher, services.NewNats(os.Getenv("NATS_URL")))
+	case "webhook":
+		sink = publisher.NewWebhook(logPublisher, confPublisher, webhookHttp)
+	}
+	if sink != nil && dynamodbConfig.OutboxTableName != "" {
+		outbox := repository.NewOutbox(dynamodbService, logDynamodb, dynamodbConfig)
+		confRelay := &relay.Config{}
+		confRelay.WithInterval(os.Getenv("OUTBOX_RELAY_INTERVAL"))
+		confRelay.WithBatchSize(os.Getenv("OUTBOX_RELAY_BATCH_SIZE"))
+		lease := repository.NewLease(dynamodbService, logDynamodb, dynamodbConfig)
+		relayRunner := relay.New(app.NewRelay(outbox, sink, logApp), lease, logRelay, confRelay)
+		go relayRunner.Start()
+	}
 	routes := routes.New(balance, logRoutes)
 	serverHttp := server.New(routes, logServer)
 	serverHttp.Start()
@@ -0,0 +1,27 @@
+package publisher
+
+const DefaultSubject = "balance.transactions"
+
+type Config struct {
+	TopicArn   string
+	Subject    string
+	WebhookUrl string
+}
+
+func (c *Config) WithTopicArn(topicArn string) *Config {
+	c.TopicArn = topicArn
+	return c
+}
+
+func (c *Config) WithSubject(subject string) *Config {
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	c.Subject = subject
+	return c
+}
+
+func (c *Config) WithWebhookUrl(webhookUrl string) *Config {
+	c.WebhookUrl = webhookUrl
+	return c
+}
@@ -0,0 +1,54 @@
+package publisher
+
+import (
+	"balance/app"
+	"encoding/json"
+	"time"
+)
+
+type TransactionPayload struct {
+	ExternalKey         string            `json:"external_key"`
+	OperationType       string            `json:"operation_type"`
+	Amount              int               `json:"amount"`
+	Currency            string            `json:"currency"`
+	Installments        int               `json:"installments,omitempty"`
+	OriginalExternalKey string            `json:"original_external_key,omitempty"`
+	CreatedAt           time.Time         `json:"created_at"`
+	Origin              string            `json:"origin,omitempty"`
+	RequestID           string            `json:"request_id,omitempty"`
+	Description         string            `json:"description,omitempty"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+	RiskScore           int               `json:"risk_score,omitempty"`
+}
+
+type EventPayload struct {
+	EventID     string              `json:"event_id"`
+	Type        string              `json:"type"`
+	AccountKey  string              `json:"account_key"`
+	Sequence    int                 `json:"sequence"`
+	Transaction *TransactionPayload `json:"transaction"`
+}
+
+// eventBody is the message every sink delivers.
+func eventBody(e *app.Event) ([]byte, error) {
+	return json.Marshal(&EventPayload{
+		EventID:    e.EventID,
+		Type:       e.Type,
+		AccountKey: e.Entry.AccountKey,
+		Sequence:   e.Sequence,
+		Transaction: &TransactionPayload{
+			ExternalKey:         e.Entry.ExternalKey,
+			OperationType:       e.Entry.OperationType,
+			Amount:              e.Entry.Amount,
+			Currency:            e.Entry.Currency,
+			Installments:        e.Entry.InstallmentCount,
+			OriginalExternalKey: e.Entry.OriginalExternalKey,
+			CreatedAt:           e.Entry.CreatedAt,
+			Origin:              e.Entry.Origin,
+			RequestID:           e.Entry.RequestID,
+			Description:         e.Entry.Description,
+			Metadata:            e.Entry.Metadata,
+			RiskScore:           e.Entry.RiskScore,
+		},
+	})
+}
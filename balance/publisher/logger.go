@@ -0,0 +1,6 @@
+package publisher
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
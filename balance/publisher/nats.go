@@ -0,0 +1,40 @@
+package publisher
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+)
+
+type Nats interface {
+	PublishWithContext(ctx context.Context, subject string, payload []byte) error
+}
+
+type natsPublisher struct {
+	log         Logger
+	config      *Config
+	natsService Nats
+}
+
+// PublishWithContext sends every event on one connection, in the order the relay
+// hands them over, so subscribers see each account in order.
+func (p *natsPublisher) PublishWithContext(ctx context.Context, input *app.Event) error {
+	body, err := eventBody(input)
+	if err != nil {
+		return err
+	}
+
+	if err := p.natsService.PublishWithContext(ctx, p.config.Subject, body); err != nil {
+		p.log.Error(fmt.Sprintf("nats publish error %s", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func NewNats(log Logger, config *Config, natsService Nats) app.Publisher {
+	return &natsPublisher{
+		log:         log,
+		config:      config,
+		natsService: natsService,
+	}
+}
@@ -0,0 +1,62 @@
+package publisher
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"strings"
+)
+
+type Sns interface {
+	PublishWithContext(ctx context.Context, input *sns.PublishInput) (*sns.PublishOutput, error)
+}
+
+type snsPublisher struct {
+	log        Logger
+	config     *Config
+	snsService Sns
+}
+
+// PublishWithContext groups the messages by account on a FIFO topic, which is what
+// keeps them in order for the subscribed queues.
+func (p *snsPublisher) PublishWithContext(ctx context.Context, input *app.Event) error {
+	body, err := eventBody(input)
+	if err != nil {
+		return err
+	}
+
+	i := &sns.PublishInput{
+		TopicArn: aws.String(p.config.TopicArn),
+		Message:  aws.String(string(body)),
+		MessageAttributes: map[string]*sns.MessageAttributeValue{
+			"event_type": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(input.Type),
+			},
+			"account_key": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(input.Entry.AccountKey),
+			},
+		},
+	}
+	if strings.HasSuffix(p.config.TopicArn, ".fifo") {
+		i.MessageGroupId = aws.String(input.Entry.AccountKey)
+		i.MessageDeduplicationId = aws.String(input.EventID)
+	}
+
+	if _, err := p.snsService.PublishWithContext(ctx, i); err != nil {
+		p.log.Error(fmt.Sprintf("sns publish error %s", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func NewSns(log Logger, config *Config, snsService Sns) app.Publisher {
+	return &snsPublisher{
+		log:        log,
+		config:     config,
+		snsService: snsService,
+	}
+}
@@ -0,0 +1,45 @@
+package publisher
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type Http interface {
+	PostWithContext(ctx context.Context, url string, payload []byte) ([]byte, int, error)
+}
+
+type webhook struct {
+	log         Logger
+	config      *Config
+	httpService Http
+}
+
+// PublishWithContext only counts a 2xx answer as delivered.
+func (w *webhook) PublishWithContext(ctx context.Context, input *app.Event) error {
+	body, err := eventBody(input)
+	if err != nil {
+		return err
+	}
+
+	_, statusCode, err := w.httpService.PostWithContext(ctx, w.config.WebhookUrl, body)
+	if err != nil {
+		w.log.Error(fmt.Sprintf("webhook post error %s", err.Error()))
+		return err
+	}
+
+	if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned status %d", statusCode)
+	}
+	return nil
+}
+
+func NewWebhook(log Logger, config *Config, httpService Http) app.Publisher {
+	return &webhook{
+		log:         log,
+		config:      config,
+		httpService: httpService,
+	}
+}
@@ -0,0 +1,7 @@
+package rebuild
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+	Fatal(msg string)
+}
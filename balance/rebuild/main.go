@@ -0,0 +1,48 @@
+package rebuild
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+)
+
+// Command recomputes the balance summaries from the ledger entries. It must run
+// while no settlement is being written, otherwise a concurrent entry may be
+// counted twice or not at all.
+type Command struct {
+	log     Logger
+	balance app.Balance
+}
+
+func (c *Command) Run(accountKeys []string) {
+	c.log.Info("Rebuilding balance summaries")
+	i := &app.RebuildSummariesInput{
+		AccountKeys: accountKeys,
+	}
+
+	o, err := c.balance.RebuildSummariesWithContext(context.Background(), i)
+	if err != nil {
+		c.log.Fatal(fmt.Sprintf("Could not rebuild balance summaries %s", err.Error()))
+	}
+
+	c.log.Info(fmt.Sprintf("Rebuilt %d balance summaries", len(o.AccountKeys)))
+}
+
+// Check stops the service while the summaries were never rebuilt from the entries,
+// a debit against an account without summary would be refused.
+func (c *Command) Check() {
+	rebuilt, err := c.balance.SummariesRebuiltWithContext(context.Background())
+	if err != nil {
+		c.log.Fatal(fmt.Sprintf("Could not check balance summaries %s", err.Error()))
+	}
+	if !rebuilt {
+		c.log.Fatal("Balance summaries were never rebuilt, run ./main rebuild before starting the service")
+	}
+}
+
+func New(balance app.Balance, log Logger) *Command {
+	return &Command{
+		balance: balance,
+		log:     log,
+	}
+}
@@ -0,0 +1,30 @@
+package relay
+
+import (
+	"balance/app"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+func (c *Config) WithInterval(interval string) *Config {
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		d = time.Second
+	}
+	c.Interval = d
+	return c
+}
+
+func (c *Config) WithBatchSize(batchSize string) *Config {
+	n, err := strconv.Atoi(batchSize)
+	if err != nil || n <= 0 {
+		n = app.DefaultRelayBatchSize
+	}
+	c.BatchSize = n
+	return c
+}
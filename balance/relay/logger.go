@@ -0,0 +1,6 @@
+package relay
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
@@ -0,0 +1,81 @@
+package relay
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	runTimeout = 30 * time.Second
+	// leaseDuration outlasts a run by enough to absorb the clock skew between replicas.
+	leaseDuration = runTimeout + 15*time.Second
+)
+
+// Runner polls the outbox and hands the pending events to the relay. Every replica
+// starts one, but only the holder of the lease relays.
+type Runner struct {
+	log    Logger
+	config *Config
+	relay  app.Relay
+	lease  app.Lease
+	owner  string
+}
+
+func (r *Runner) Start() {
+	r.log.Info(fmt.Sprintf("Starting outbox relay every %s", r.config.Interval))
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.run()
+	}
+}
+
+// run keeps going while full batches go out cleanly, so a backlog drains without
+// waiting for the next tick.
+func (r *Runner) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	l, err := r.lease.AcquireLeaseWithContext(ctx, &app.AcquireLeaseInput{
+		Owner:    r.owner,
+		Now:      time.Now(),
+		Duration: leaseDuration,
+	})
+	if err != nil {
+		r.log.Error(fmt.Sprintf("Could not acquire relay lease %s", err.Error()))
+		return
+	}
+	if !l.Acquired {
+		return
+	}
+
+	for {
+		o, err := r.relay.RelayWithContext(ctx, &app.RelayInput{
+			Limit: r.config.BatchSize,
+		})
+		if err != nil {
+			r.log.Error(fmt.Sprintf("Could not relay events %s", err.Error()))
+			return
+		}
+		if o.Failed > 0 || o.Skipped > 0 {
+			r.log.Info(fmt.Sprintf("Events published %d failed %d skipped %d", o.Published, o.Failed, o.Skipped))
+		}
+		if o.Published < r.config.BatchSize || o.Failed > 0 || o.Skipped > 0 {
+			return
+		}
+	}
+}
+
+func New(relay app.Relay, lease app.Lease, log Logger, config *Config) *Runner {
+	hostname, _ := os.Hostname()
+	return &Runner{
+		relay:  relay,
+		lease:  lease,
+		log:    log,
+		config: config,
+		owner:  fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
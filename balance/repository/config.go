@@ -1,10 +1,34 @@
 package repository
 
 type Config struct {
-	TableName string
+	TableName            string
+	SummaryTableName     string
+	InstallmentTableName string
+	HoldTableName        string
+	OutboxTableName      string
 }
 
 func (c *Config) WithTableName(tableName string) *Config {
 	c.TableName = tableName
 	return c
 }
+
+func (c *Config) WithSummaryTableName(summaryTableName string) *Config {
+	c.SummaryTableName = summaryTableName
+	return c
+}
+
+func (c *Config) WithInstallmentTableName(installmentTableName string) *Config {
+	c.InstallmentTableName = installmentTableName
+	return c
+}
+
+func (c *Config) WithHoldTableName(holdTableName string) *Config {
+	c.HoldTableName = holdTableName
+	return c
+}
+
+func (c *Config) WithOutboxTableName(outboxTableName string) *Config {
+	c.OutboxTableName = outboxTableName
+	return c
+}
@@ -5,13 +5,20 @@ import (
 	"context"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"strconv"
+	"time"
 )
 
+const entryExternalKeyIndex = "AccountKey-ExternalKey-index"
+
 type Dynamodb interface {
 	PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	TransactWriteItemsWithContext(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
+	ScanWithContext(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
 }
 
 type db struct {
@@ -21,11 +28,317 @@ type db struct {
 }
 
 func (d *db) InsertWithContext(ctx context.Context, input *app.InsertInput) (*app.InsertOutput, error) {
-	putItemInput := &dynamodb.PutItemInput{
+	put := d.entryPut(input)
+
+	if len(input.Schedule) > 0 {
+		put.Item["InstallmentNumber"] = &dynamodb.AttributeValue{
+			N: aws.String("1"),
+		}
+		put.Item["InstallmentCount"] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.Itoa(len(input.Schedule))),
+		}
+	}
+
+	if input.OriginalExternalKey != "" {
+		put.Item["OriginalExternalKey"] = &dynamodb.AttributeValue{
+			S: aws.String(input.OriginalExternalKey),
+		}
+	}
+
+	reversalIndex := -1
+	d.log.Info(fmt.Sprintf("Dynamodb input item %v", input))
+	err := d.transactWithEventsWithContext(ctx, func() (*dynamodb.TransactWriteItemsInput, []*outboxEvent) {
+		update := d.balanceUpdate(input)
+		transactWriteItemsInput := &dynamodb.TransactWriteItemsInput{
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{Put: put},
+				{Update: update},
+			},
+		}
+		for _, installment := range input.Schedule {
+			transactWriteItemsInput.TransactItems = append(transactWriteItemsInput.TransactItems, &dynamodb.TransactWriteItem{
+				Put: d.installmentPut(installment),
+			})
+		}
+		if input.OriginalExternalKey != "" {
+			reversalIndex = len(transactWriteItemsInput.TransactItems)
+			transactWriteItemsInput.TransactItems = append(transactWriteItemsInput.TransactItems, &dynamodb.TransactWriteItem{
+				Update: d.reversedAmountUpdate(input),
+			})
+		}
+		transactWriteItemsInput.TransactItems = append(transactWriteItemsInput.TransactItems, d.entryUsageItems(input)...)
+		events := make([]*outboxEvent, 0, 1)
+		if input.Event != nil && d.config.OutboxTableName != "" {
+			events = append(events, &outboxEvent{
+				event:        input.Event,
+				entry:        put.Item,
+				summary:      update,
+				summaryIndex: 1,
+			})
+		}
+		return transactWriteItemsInput, events
+	})
+	if err != nil {
+		if ae, ok := err.(*dynamodb.TransactionCanceledException); ok && len(ae.CancellationReasons) >= 2 {
+			d.log.Info(fmt.Sprintf("%s %s", ae.Code(), ae.Message()))
+			if conditionalCheckFailed(ae.CancellationReasons[0]) {
+				return &app.InsertOutput{
+					AlreadyExists: true,
+				}, nil
+			}
+			if overdraftLimitChanged(ae.CancellationReasons[1], input.OverdraftLimit) {
+				return &app.InsertOutput{
+					OverdraftLimitChanged: true,
+				}, nil
+			}
+			if conditionalCheckFailed(ae.CancellationReasons[1]) {
+				return &app.InsertOutput{
+					InsufficientFunds: true,
+				}, nil
+			}
+			if reversalIndex > 0 && reversalIndex < len(ae.CancellationReasons) && conditionalCheckFailed(ae.CancellationReasons[reversalIndex]) {
+				return &app.InsertOutput{
+					ReversalExceeded: true,
+				}, nil
+			}
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.InsertOutput{
+		AlreadyExists: false,
+	}, nil
+}
+
+func (d *db) GetEntryWithContext(ctx context.Context, input *app.GetEntryInput) (*app.Entry, error) {
+	i := &dynamodb.GetItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"AccountKey": {
+				S: aws.String(input.AccountKey),
+			},
+			"EntryKey": {
+				S: aws.String(input.EntryKey),
+			},
+		},
+		TableName:      aws.String(d.config.TableName),
+		ConsistentRead: aws.Bool(true),
+	}
+	getItemOutput, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get item %s", err.Error()))
+		return nil, err
+	}
+
+	if getItemOutput == nil || getItemOutput.Item == nil {
+		return nil, nil
+	}
+
+	return entryFromItem(getItemOutput.Item)
+}
+
+func (d *db) FindEntriesWithContext(ctx context.Context, input *app.FindEntriesInput) (*app.FindEntriesOutput, error) {
+	queryInput := &dynamodb.QueryInput{
+		IndexName:              aws.String(entryExternalKeyIndex),
+		KeyConditionExpression: aws.String("AccountKey = :accountKey AND ExternalKey = :externalKey"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":accountKey": {
+				S: aws.String(input.AccountKey),
+			},
+			":externalKey": {
+				S: aws.String(input.ExternalKey),
+			},
+		},
+		ConsistentRead: aws.Bool(true),
+		TableName:      aws.String(d.config.TableName),
+	}
+
+	entries := make([]*app.Entry, 0)
+	for {
+		queryOutput, err := d.dynamodbService.QueryWithContext(ctx, queryInput)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error query %s", err.Error()))
+			return nil, err
+		}
+
+		for _, item := range queryOutput.Items {
+			e, err := entryFromItem(item)
+			if err != nil {
+				d.log.Error(fmt.Sprintf("Error query item %s", err.Error()))
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+
+		if len(queryOutput.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = queryOutput.LastEvaluatedKey
+	}
+
+	return &app.FindEntriesOutput{
+		Entries: entries,
+	}, nil
+}
+
+func (d *db) GetSummaryWithContext(ctx context.Context, input *app.GetSummaryInput) (*app.GetSummaryOutput, error) {
+	i := &dynamodb.GetItemInput{
+		Key:            summaryKey(input.AccountKey, input.Currency),
+		TableName:      aws.String(d.config.SummaryTableName),
+		ConsistentRead: aws.Bool(true),
+	}
+	getItemOutput, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get item %s", err.Error()))
+		return nil, err
+	}
+
+	if getItemOutput == nil || getItemOutput.Item == nil {
+		return nil, nil
+	}
+
+	return summaryFromItem(getItemOutput.Item)
+}
+
+func (d *db) PutSummaryWithContext(ctx context.Context, input *app.PutSummaryInput) error {
+	i := &dynamodb.UpdateItemInput{
+		Key:              summaryKey(input.AccountKey, input.Currency),
+		UpdateExpression: aws.String("SET Balance = :balance, TotalCredits = :credits, TotalDebits = :debits, EntryCount = :count, Held = :held"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":balance": {
+				N: aws.String(strconv.Itoa(input.Balance)),
+			},
+			":credits": {
+				N: aws.String(strconv.Itoa(input.TotalCredits)),
+			},
+			":debits": {
+				N: aws.String(strconv.Itoa(input.TotalDebits)),
+			},
+			":count": {
+				N: aws.String(strconv.Itoa(input.EntryCount)),
+			},
+			":held": {
+				N: aws.String(strconv.Itoa(input.Held)),
+			},
+		},
+		TableName: aws.String(d.config.SummaryTableName),
+	}
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error update item %s", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (d *db) ListAccountKeysWithContext(ctx context.Context) ([]string, error) {
+	scanInput := &dynamodb.ScanInput{
+		ProjectionExpression: aws.String("AccountKey"),
+		TableName:            aws.String(d.config.TableName),
+	}
+
+	seen := make(map[string]bool)
+	accountKeys := make([]string, 0)
+	for {
+		scanOutput, err := d.dynamodbService.ScanWithContext(ctx, scanInput)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error scan %s", err.Error()))
+			return nil, err
+		}
+
+		for _, item := range scanOutput.Items {
+			accountKey := stringAttribute(item, "AccountKey")
+			if !seen[accountKey] {
+				seen[accountKey] = true
+				accountKeys = append(accountKeys, accountKey)
+			}
+		}
+
+		if len(scanOutput.LastEvaluatedKey) == 0 {
+			break
+		}
+		scanInput.ExclusiveStartKey = scanOutput.LastEvaluatedKey
+	}
+
+	return accountKeys, nil
+}
+
+// summariesRebuiltKey marks in the summary table that every account had its summary
+// rebuilt from the entries. No account key starts with #.
+func summariesRebuiltKey() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"AccountKey": {
+			S: aws.String("#summaries"),
+		},
+		"Currency": {
+			S: aws.String("#rebuilt"),
+		},
+	}
+}
+
+func (d *db) GetSummariesRebuiltWithContext(ctx context.Context) (bool, error) {
+	i := &dynamodb.GetItemInput{
+		Key:            summariesRebuiltKey(),
+		TableName:      aws.String(d.config.SummaryTableName),
+		ConsistentRead: aws.Bool(true),
+	}
+	getItemOutput, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get item %s", err.Error()))
+		return false, err
+	}
+
+	return getItemOutput != nil && getItemOutput.Item != nil, nil
+}
+
+func (d *db) PutSummariesRebuiltWithContext(ctx context.Context) error {
+	i := &dynamodb.PutItemInput{
+		Item:      summariesRebuiltKey(),
+		TableName: aws.String(d.config.SummaryTableName),
+	}
+	_, err := d.dynamodbService.PutItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error put item %s", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (d *db) UpdateOverdraftLimitWithContext(ctx context.Context, input *app.UpdateOverdraftLimitInput) error {
+	i := &dynamodb.UpdateItemInput{
+		Key:              summaryKey(input.AccountKey, input.Currency),
+		UpdateExpression: aws.String("SET OverdraftLimit = :limit, Balance = if_not_exists(Balance, :zero)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":limit": {
+				N: aws.String(strconv.Itoa(input.OverdraftLimit)),
+			},
+			":zero": {
+				N: aws.String("0"),
+			},
+		},
+		TableName: aws.String(d.config.SummaryTableName),
+	}
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error update item %s", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// entryPut writes a ledger entry only when its key was never used on the account.
+func (d *db) entryPut(input *app.InsertInput) *dynamodb.Put {
+	put := &dynamodb.Put{
 		Item: map[string]*dynamodb.AttributeValue{
 			"AccountKey": {
 				S: aws.String(input.AccountKey),
 			},
+			"EntryKey": {
+				S: aws.String(input.EntryKey),
+			},
 			"ExternalKey": {
 				S: aws.String(input.ExternalKey),
 			},
@@ -35,28 +348,313 @@ func (d *db) InsertWithContext(ctx context.Context, input *app.InsertInput) (*ap
 			"Amount": {
 				N: aws.String(strconv.Itoa(input.Amount)),
 			},
+			"Currency": {
+				S: aws.String(currencyValue(input.Currency)),
+			},
+			"CreatedAt": {
+				S: aws.String(formatCreatedAt(input.CreatedAt)),
+			},
 		},
 		TableName:           aws.String(d.config.TableName),
-		ConditionExpression: aws.String("attribute_not_exists(AccountKey) AND attribute_not_exists(ExternalKey)"),
+		ConditionExpression: aws.String("attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)"),
 	}
-	d.log.Info(fmt.Sprintf("Dynamodb input item %v", input))
-	_, err := d.dynamodbService.PutItemWithContext(ctx, putItemInput)
-	if err != nil {
-		if ae, ok := err.(awserr.RequestFailure); ok && ae.Code() == "ConditionalCheckFailedException" {
-			d.log.Info(fmt.Sprintf("%s", ae.Code()))
-			return &app.InsertOutput{
-				AlreadyExists: true,
-			}, nil
+
+	setMetadataAttributes(put.Item, input.Origin, input.RequestID, input.Description, input.Metadata)
+	if input.RiskScore > 0 {
+		put.Item["RiskScore"] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.Itoa(input.RiskScore)),
 		}
-		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
-		return nil, err
 	}
 
-	return &app.InsertOutput{
-		AlreadyExists: false,
+	for name, v := range map[string]string{
+		"RequestHash": input.RequestHash,
+		"TransferId":  input.TransferID,
+	} {
+		if v != "" {
+			put.Item[name] = &dynamodb.AttributeValue{
+				S: aws.String(v),
+			}
+		}
+	}
+
+	return put
+}
+
+func (d *db) balanceUpdate(input *app.InsertInput) *dynamodb.Update {
+	update := d.summaryUpdate(input.AccountKey, input.Currency, input.Amount)
+	// A debit may only take the balance down to the negative of the overdraft limit.
+	if input.Amount < 0 {
+		overdraftCondition(update, input.Amount*-1, input.OverdraftLimit)
+	}
+	return update
+}
+
+// overdraftCondition lets an update take amount from the balance down to the negative
+// of the overdraft limit read before the transaction, failing when the limit was
+// changed since. A missing summary fails as well, the rebuild creates one for every
+// account with entries.
+func overdraftCondition(update *dynamodb.Update, amount int, limit int) {
+	condition := "Balance >= :minimum AND OverdraftLimit = :limit"
+	if limit == 0 {
+		condition = "Balance >= :minimum AND (attribute_not_exists(OverdraftLimit) OR OverdraftLimit = :limit)"
+	}
+	update.ConditionExpression = aws.String(condition)
+	update.ExpressionAttributeValues[":minimum"] = &dynamodb.AttributeValue{
+		N: aws.String(strconv.Itoa(amount - limit)),
+	}
+	update.ExpressionAttributeValues[":limit"] = &dynamodb.AttributeValue{
+		N: aws.String(strconv.Itoa(limit)),
+	}
+	update.ReturnValuesOnConditionCheckFailure = aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld)
+}
+
+// overdraftLimitChanged tells whether a summary update failed because its overdraft
+// limit is no longer the one read before the transaction.
+func overdraftLimitChanged(reason *dynamodb.CancellationReason, limit int) bool {
+	if !conditionalCheckFailed(reason) || reason.Item == nil {
+		return false
+	}
+	current, err := intAttribute(reason.Item, "OverdraftLimit")
+	return err == nil && current != limit
+}
+
+func (d *db) summaryUpdate(accountKey string, currency string, amount int) *dynamodb.Update {
+	credit, debit := 0, 0
+	if amount > 0 {
+		credit = amount
+	} else {
+		debit = amount * -1
+	}
+
+	return &dynamodb.Update{
+		Key:              summaryKey(accountKey, currency),
+		UpdateExpression: aws.String("ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":amount": {
+				N: aws.String(strconv.Itoa(amount)),
+			},
+			":credit": {
+				N: aws.String(strconv.Itoa(credit)),
+			},
+			":debit": {
+				N: aws.String(strconv.Itoa(debit)),
+			},
+			":one": {
+				N: aws.String("1"),
+			},
+		},
+		TableName: aws.String(d.config.SummaryTableName),
+	}
+}
+
+// reversedAmountUpdate accumulates the reversed amount on the original debit, refusing
+// to go past its absolute amount.
+func (d *db) reversedAmountUpdate(input *app.InsertInput) *dynamodb.Update {
+	return &dynamodb.Update{
+		Key: map[string]*dynamodb.AttributeValue{
+			"AccountKey": {
+				S: aws.String(input.AccountKey),
+			},
+			"EntryKey": {
+				S: aws.String(input.OriginalEntryKey),
+			},
+		},
+		UpdateExpression:    aws.String("ADD ReversedAmount :amount"),
+		ConditionExpression: aws.String("attribute_exists(EntryKey) AND (attribute_not_exists(ReversedAmount) OR ReversedAmount <= :maxReversed)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":amount": {
+				N: aws.String(strconv.Itoa(input.Amount)),
+			},
+			":maxReversed": {
+				N: aws.String(strconv.Itoa(input.MaxReversedAmount)),
+			},
+		},
+		TableName: aws.String(d.config.TableName),
+	}
+}
+
+func conditionalCheckFailed(reason *dynamodb.CancellationReason) bool {
+	return reason != nil && aws.StringValue(reason.Code) == "ConditionalCheckFailed"
+}
+
+func (d *db) QueryWithContext(ctx context.Context, input *app.QueryInput) (*app.QueryOutput, error) {
+	queryInput := &dynamodb.QueryInput{
+		KeyConditionExpression: aws.String("AccountKey = :accountKey"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":accountKey": {
+				S: aws.String(input.AccountKey),
+			},
+		},
+		TableName: aws.String(d.config.TableName),
+	}
+
+	entries := make([]*app.Entry, 0)
+	for {
+		queryOutput, err := d.dynamodbService.QueryWithContext(ctx, queryInput)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error query %s", err.Error()))
+			return nil, err
+		}
+
+		for _, item := range queryOutput.Items {
+			e, err := entryFromItem(item)
+			if err != nil {
+				d.log.Error(fmt.Sprintf("Error query item %s", err.Error()))
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+
+		if len(queryOutput.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = queryOutput.LastEvaluatedKey
+	}
+
+	return &app.QueryOutput{
+		Entries: entries,
 	}, nil
 }
 
+// summaryKey addresses the balance of an account in one currency.
+func summaryKey(accountKey string, currency string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"AccountKey": {
+			S: aws.String(accountKey),
+		},
+		"Currency": {
+			S: aws.String(currencyValue(currency)),
+		},
+	}
+}
+
+func currencyValue(currency string) string {
+	if currency == "" {
+		return app.DefaultCurrency
+	}
+	return currency
+}
+
+// currencyAttribute reads items written before currencies existed as BRL.
+func currencyAttribute(item map[string]*dynamodb.AttributeValue) string {
+	return currencyValue(stringAttribute(item, "Currency"))
+}
+
+func stringAttribute(item map[string]*dynamodb.AttributeValue, name string) string {
+	if v, ok := item[name]; ok && v != nil {
+		return aws.StringValue(v.S)
+	}
+	return ""
+}
+
+func intAttribute(item map[string]*dynamodb.AttributeValue, name string) (int, error) {
+	if v, ok := item[name]; ok && v != nil && v.N != nil {
+		return strconv.Atoi(aws.StringValue(v.N))
+	}
+	return 0, nil
+}
+
+// setMetadataAttributes stores who wrote an entry and why, skipping empty values.
+func setMetadataAttributes(item map[string]*dynamodb.AttributeValue, origin string, requestID string, description string, metadata map[string]string) {
+	for name, v := range map[string]string{
+		"Origin":      origin,
+		"RequestId":   requestID,
+		"Description": description,
+	} {
+		if v != "" {
+			item[name] = &dynamodb.AttributeValue{
+				S: aws.String(v),
+			}
+		}
+	}
+
+	if len(metadata) > 0 {
+		m := make(map[string]*dynamodb.AttributeValue)
+		for k, v := range metadata {
+			m[k] = &dynamodb.AttributeValue{
+				S: aws.String(v),
+			}
+		}
+		item["Metadata"] = &dynamodb.AttributeValue{
+			M: m,
+		}
+	}
+}
+
+func metadataAttribute(item map[string]*dynamodb.AttributeValue) map[string]string {
+	v, ok := item["Metadata"]
+	if !ok || v == nil || len(v.M) == 0 {
+		return nil
+	}
+
+	metadata := make(map[string]string)
+	for k, mv := range v.M {
+		metadata[k] = aws.StringValue(mv.S)
+	}
+	return metadata
+}
+
+func entryFromItem(item map[string]*dynamodb.AttributeValue) (*app.Entry, error) {
+	e := &app.Entry{
+		AccountKey:          stringAttribute(item, "AccountKey"),
+		EntryKey:            stringAttribute(item, "EntryKey"),
+		ExternalKey:         stringAttribute(item, "ExternalKey"),
+		OperationType:       stringAttribute(item, "OperationType"),
+		Currency:            currencyAttribute(item),
+		OriginalExternalKey: stringAttribute(item, "OriginalExternalKey"),
+		Origin:              stringAttribute(item, "Origin"),
+		RequestID:           stringAttribute(item, "RequestId"),
+		Description:         stringAttribute(item, "Description"),
+		Metadata:            metadataAttribute(item),
+		RequestHash:         stringAttribute(item, "RequestHash"),
+		TransferID:          stringAttribute(item, "TransferId"),
+	}
+	if createdAt := stringAttribute(item, "CreatedAt"); createdAt != "" {
+		t, err := time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			return nil, err
+		}
+		e.CreatedAt = t
+	}
+	for name, v := range map[string]*int{
+		"Amount":           &e.Amount,
+		"InstallmentCount": &e.InstallmentCount,
+		"ReversedAmount":   &e.ReversedAmount,
+		"RiskScore":        &e.RiskScore,
+	} {
+		n, err := intAttribute(item, name)
+		if err != nil {
+			return nil, err
+		}
+		*v = n
+	}
+
+	return e, nil
+}
+
+func summaryFromItem(item map[string]*dynamodb.AttributeValue) (*app.GetSummaryOutput, error) {
+	o := &app.GetSummaryOutput{
+		AccountKey: stringAttribute(item, "AccountKey"),
+		Currency:   currencyAttribute(item),
+	}
+	for name, v := range map[string]*int{
+		"Balance":        &o.Balance,
+		"TotalCredits":   &o.TotalCredits,
+		"TotalDebits":    &o.TotalDebits,
+		"EntryCount":     &o.EntryCount,
+		"OverdraftLimit": &o.OverdraftLimit,
+		"Held":           &o.Held,
+	} {
+		n, err := intAttribute(item, name)
+		if err != nil {
+			return nil, err
+		}
+		*v = n
+	}
+
+	return o, nil
+}
+
 func NewDynamodb(d Dynamodb, log Logger, config Config) app.Persistence {
 	return &db{
 		dynamodbService: d,
@@ -5,10 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
 )
 
 type serviceMock struct {
@@ -16,36 +19,58 @@ type serviceMock struct {
 	t *testing.T
 }
 
-type ea struct{}
-
-func (e ea) Error() string {
-	return ""
-}
-
-func (e ea) Code() string {
-	return "ConditionalCheckFailedException"
-}
-func (e ea) Message() string {
-	return ""
-}
-func (e ea) OrigErr() error {
-	return nil
-}
-func (e ea) StatusCode() int {
-	return 0
-}
-func (e ea) RequestID() string {
-	return ""
-}
-
-func ErrorAws() awserr.RequestFailure {
-	return &ea{}
+func transactionCanceled(reasons ...string) error {
+	cancellationReasons := make([]*dynamodb.CancellationReason, 0)
+	for _, r := range reasons {
+		cancellationReasons = append(cancellationReasons, &dynamodb.CancellationReason{
+			Code: aws.String(r),
+		})
+	}
+	return &dynamodb.TransactionCanceledException{
+		CancellationReasons: cancellationReasons,
+	}
 }
 
 func (s serviceMock) PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("db error")
+	}
+	v, err := json.Marshal(input)
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, s.v, string(v))
+	return nil, nil
+}
+func (s serviceMock) TransactWriteItemsWithContext(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
 	if s.v != "" {
 		if s.v == "1" {
-			return nil, ErrorAws()
+			return nil, transactionCanceled("ConditionalCheckFailed", "None")
+		}
+		if s.v == "2" {
+			return nil, transactionCanceled("None", "ConditionalCheckFailed")
+		}
+		if s.v == "3" {
+			return nil, transactionCanceled("None", "None", "ConditionalCheckFailed")
+		}
+		if s.v == "4" {
+			return nil, transactionCanceled("None", "ConditionalCheckFailed", "None", "None")
+		}
+		if s.v == "5" || s.v == "6" {
+			// The summary condition fails: "5" after another write raised the counter.
+			current := map[string]string{"5": "45", "6": "41"}[s.v]
+			ae := transactionCanceled("None", "ConditionalCheckFailed", "None").(*dynamodb.TransactionCanceledException)
+			ae.CancellationReasons[1].Item = map[string]*dynamodb.AttributeValue{
+				"EventSequence": {N: aws.String(current)},
+			}
+			return nil, ae
+		}
+		if s.v == "7" {
+			// The summary condition fails because the overdraft limit was raised.
+			ae := transactionCanceled("None", "ConditionalCheckFailed", "None", "None").(*dynamodb.TransactionCanceledException)
+			ae.CancellationReasons[1].Item = map[string]*dynamodb.AttributeValue{
+				"Balance":        {N: aws.String("-200")},
+				"OverdraftLimit": {N: aws.String("800")},
+			}
+			return nil, ae
 		}
 		v, err := json.Marshal(input)
 		assert.Nil(s.t, err)
@@ -54,6 +79,203 @@ func (s serviceMock) PutItemWithContext(ctx context.Context, input *dynamodb.Put
 	}
 	return nil, errors.New("db error")
 }
+func (s serviceMock) GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("get error")
+	}
+	if aws.StringValue(input.ProjectionExpression) == "EventSequence" {
+		return &dynamodb.GetItemOutput{
+			Item: map[string]*dynamodb.AttributeValue{
+				"EventSequence": {N: aws.String("41")},
+			},
+		}, nil
+	}
+	if s.v == "1" {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	v, err := json.Marshal(input)
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, s.v, string(v))
+	if aws.StringValue(input.TableName) == "balance-hold" {
+		return &dynamodb.GetItemOutput{
+			Item: holdItem(),
+		}, nil
+	}
+	if aws.StringValue(input.TableName) == "balance-outbox" {
+		return &dynamodb.GetItemOutput{
+			Item: eventItem(),
+		}, nil
+	}
+	if strings.Contains(stringAttribute(input.Key, "Currency"), "#") {
+		return &dynamodb.GetItemOutput{
+			Item: map[string]*dynamodb.AttributeValue{
+				"AccountKey":  {S: aws.String("1")},
+				"Currency":    input.Key["Currency"],
+				"UsageAmount": {N: aws.String("2500")},
+				"UsageCount":  {N: aws.String("2")},
+			},
+		}, nil
+	}
+	return &dynamodb.GetItemOutput{
+		Item: map[string]*dynamodb.AttributeValue{
+			"AccountKey":     {S: aws.String("1")},
+			"Currency":       input.Key["Currency"],
+			"Balance":        {N: aws.String("700")},
+			"TotalCredits":   {N: aws.String("1000")},
+			"TotalDebits":    {N: aws.String("300")},
+			"EntryCount":     {N: aws.String("2")},
+			"OverdraftLimit": {N: aws.String("500")},
+		},
+	}, nil
+}
+func (s serviceMock) UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("update error")
+	}
+	if s.v == "5" {
+		return nil, awserr.NewRequestFailure(awserr.New("ConditionalCheckFailedException", "condition failed", nil), 400, "")
+	}
+	v, err := json.Marshal(input)
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, s.v, string(v))
+	return nil, nil
+}
+func (s serviceMock) QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("query error")
+	}
+	if aws.StringValue(input.TableName) == "balance-hold" {
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return &dynamodb.QueryOutput{
+			Items: []map[string]*dynamodb.AttributeValue{holdItem()},
+		}, nil
+	}
+	if aws.StringValue(input.IndexName) == entryExternalKeyIndex {
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return &dynamodb.QueryOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{
+					"AccountKey":    {S: aws.String("1")},
+					"EntryKey":      {S: aws.String("debit#Buying#2")},
+					"ExternalKey":   {S: aws.String("2")},
+					"OperationType": {S: aws.String("Buying")},
+					"Amount":        {N: aws.String("-1000")},
+					"Origin":        {S: aws.String("debit")},
+				},
+			},
+		}, nil
+	}
+	if aws.StringValue(input.IndexName) == entryCreatedAtIndex {
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return &dynamodb.QueryOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{
+					"AccountKey":    {S: aws.String("1")},
+					"EntryKey":      {S: aws.String("credit#Payment#2")},
+					"ExternalKey":   {S: aws.String("2")},
+					"OperationType": {S: aws.String("Payment")},
+					"Amount":        {N: aws.String("1000")},
+					"CreatedAt":     {S: aws.String("2022-01-31T10:00:00.000Z")},
+					"Origin":        {S: aws.String("credit")},
+					"RequestId":     {S: aws.String("req-1")},
+					"Metadata":      {M: map[string]*dynamodb.AttributeValue{"order_id": {S: aws.String("42")}}},
+				},
+			},
+			LastEvaluatedKey: map[string]*dynamodb.AttributeValue{
+				"AccountKey": {S: aws.String("1")},
+				"EntryKey":   {S: aws.String("credit#Payment#2")},
+				"CreatedAt":  {S: aws.String("2022-01-31T10:00:00.000Z")},
+			},
+		}, nil
+	}
+	if aws.StringValue(input.IndexName) == outboxPendingIndex {
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return &dynamodb.QueryOutput{
+			Items: []map[string]*dynamodb.AttributeValue{eventItem()},
+		}, nil
+	}
+	if input.IndexName != nil {
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return &dynamodb.QueryOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{
+					"AccountKey":     {S: aws.String("1")},
+					"InstallmentKey": {S: aws.String("2#02")},
+					"ExternalKey":    {S: aws.String("2")},
+					"OperationType":  {S: aws.String("InstallmentBuying")},
+					"Number":         {N: aws.String("2")},
+					"Count":          {N: aws.String("3")},
+					"Amount":         {N: aws.String("-333")},
+					"DueDate":        {S: aws.String("2022-02-28T10:00:00Z")},
+					"Status":         {S: aws.String("scheduled")},
+				},
+			},
+		}, nil
+	}
+	if input.ExclusiveStartKey == nil {
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return &dynamodb.QueryOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{
+					"AccountKey":    {S: aws.String("1")},
+					"ExternalKey":   {S: aws.String("2")},
+					"OperationType": {S: aws.String("Payment")},
+					"Amount":        {N: aws.String("1000")},
+				},
+			},
+			LastEvaluatedKey: map[string]*dynamodb.AttributeValue{
+				"AccountKey":  {S: aws.String("1")},
+				"ExternalKey": {S: aws.String("2")},
+			},
+		}, nil
+	}
+	return &dynamodb.QueryOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{
+				"AccountKey":    {S: aws.String("1")},
+				"ExternalKey":   {S: aws.String("3")},
+				"OperationType": {S: aws.String("Withdraw")},
+				"Amount":        {N: aws.String("-300")},
+			},
+		},
+	}, nil
+}
+func (s serviceMock) ScanWithContext(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("scan error")
+	}
+	if input.ExclusiveStartKey == nil {
+		v, err := json.Marshal(input)
+		assert.Nil(s.t, err)
+		assert.Equal(s.t, s.v, string(v))
+		return &dynamodb.ScanOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{"AccountKey": {S: aws.String("1")}},
+				{"AccountKey": {S: aws.String("1")}},
+			},
+			LastEvaluatedKey: map[string]*dynamodb.AttributeValue{
+				"AccountKey": {S: aws.String("1")},
+			},
+		}, nil
+	}
+	return &dynamodb.ScanOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{"AccountKey": {S: aws.String("2")}},
+		},
+	}, nil
+}
 func newServiceMock(v string, t *testing.T) Dynamodb {
 	return &serviceMock{
 		v: v,
@@ -71,10 +293,11 @@ func newLogMock() Logger {
 
 func TestDb_Insert(t *testing.T) {
 	l := newLogMock()
-	exptected := "{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(ExternalKey)\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"test\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"account\"}"
+	exptected := "{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"0001-01-01T00:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"test\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}}]}"
 	s := newServiceMock(exptected, t)
 	c := Config{
-		TableName: "account",
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
 	}
 	d := NewDynamodb(s, l, c)
 	i := &app.InsertInput{
@@ -87,14 +310,15 @@ func TestDb_Insert(t *testing.T) {
 	assert.Nil(t, err)
 	b, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"AlreadyExists\":false}", string(b))
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"ReversalExceeded\":false,\"OverdraftLimitChanged\":false}", string(b))
 }
 
 func TestDb_NotInsert(t *testing.T) {
 	l := newLogMock()
 	s := newServiceMock("", t)
 	c := Config{
-		TableName: "account",
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
 	}
 	d := NewDynamodb(s, l, c)
 	i := &app.InsertInput{
@@ -109,7 +333,8 @@ func TestDb_NotInsertWhenExternalKeyHasExists(t *testing.T) {
 	l := newLogMock()
 	s := newServiceMock("1", t)
 	c := Config{
-		TableName: "account",
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
 	}
 	d := NewDynamodb(s, l, c)
 	i := &app.InsertInput{
@@ -119,5 +344,500 @@ func TestDb_NotInsertWhenExternalKeyHasExists(t *testing.T) {
 	assert.Nil(t, err)
 	b, err := json.Marshal(res)
 	assert.Nil(t, err)
-	assert.Equal(t, "{\"AlreadyExists\":true}", string(b))
+	assert.Equal(t, "{\"AlreadyExists\":true,\"InsufficientFunds\":false,\"ReversalExceeded\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestDb_Query(t *testing.T) {
+	l := newLogMock()
+	exptected := "{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":accountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":null,\"KeyConditionExpression\":\"AccountKey = :accountKey\",\"KeyConditions\":null,\"Limit\":null,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"balance\"}"
+	s := newServiceMock(exptected, t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.QueryInput{
+		AccountKey: "1",
+	}
+	res, err := d.QueryWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Entries\":[{\"AccountKey\":\"1\",\"EntryKey\":\"\",\"ExternalKey\":\"2\",\"OperationType\":\"Payment\",\"Amount\":1000,\"Currency\":\"BRL\",\"InstallmentCount\":0,\"OriginalExternalKey\":\"\",\"ReversedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"\",\"TransferID\":\"\"},{\"AccountKey\":\"1\",\"EntryKey\":\"\",\"ExternalKey\":\"3\",\"OperationType\":\"Withdraw\",\"Amount\":-300,\"Currency\":\"BRL\",\"InstallmentCount\":0,\"OriginalExternalKey\":\"\",\"ReversedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"\",\"TransferID\":\"\"}]}", string(b))
+}
+
+func TestDb_NotQueryWhenQueryError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.QueryInput{
+		AccountKey: "1",
+	}
+	res, err := d.QueryWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "query error", err.Error())
+}
+
+func TestDb_InsertDebit(t *testing.T) {
+	l := newLogMock()
+	exptected := "{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"0001-01-01T00:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Withdraw\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"Balance \\u003e= :minimum AND OverdraftLimit = :limit\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":limit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"300\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":minimum\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"700\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Withdraw#0001-01-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Withdraw#0001-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}}]}"
+	s := newServiceMock(exptected, t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		AccountKey:     "1",
+		ExternalKey:    "2",
+		OperatiionType: "Withdraw",
+		Amount:         -1000,
+		OverdraftLimit: 300,
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"ReversalExceeded\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestDb_NotInsertWhenInsufficientFunds(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("2", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		ExternalKey: "2",
+		Amount:      -1000,
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":true,\"ReversalExceeded\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestDb_NotInsertWhenOverdraftLimitChanged(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("7", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		ExternalKey:    "2",
+		Amount:         -1000,
+		OverdraftLimit: 500,
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"ReversalExceeded\":false,\"OverdraftLimitChanged\":true}", string(b))
+}
+
+func TestDb_GetSummary(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConsistentRead\":true,\"ExpressionAttributeNames\":null,\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ProjectionExpression\":null,\"ReturnConsumedCapacity\":null,\"TableName\":\"balance-summary\"}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.GetSummaryInput{
+		AccountKey: "1",
+	}
+	res, err := d.GetSummaryWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountKey\":\"1\",\"Currency\":\"BRL\",\"Balance\":700,\"TotalCredits\":1000,\"TotalDebits\":300,\"EntryCount\":2,\"OverdraftLimit\":500,\"Held\":0}", string(b))
+}
+
+func TestDb_GetSummaryInCurrency(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConsistentRead\":true,\"ExpressionAttributeNames\":null,\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"JPY\",\"SS\":null}},\"ProjectionExpression\":null,\"ReturnConsumedCapacity\":null,\"TableName\":\"balance-summary\"}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.GetSummaryInput{
+		AccountKey: "1",
+		Currency:   "JPY",
+	}
+	res, err := d.GetSummaryWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountKey\":\"1\",\"Currency\":\"JPY\",\"Balance\":700,\"TotalCredits\":1000,\"TotalDebits\":300,\"EntryCount\":2,\"OverdraftLimit\":500,\"Held\":0}", string(b))
+}
+
+func TestDb_NotGetSummaryWhenNotFound(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("1", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.GetSummaryInput{
+		AccountKey: "1",
+	}
+	res, err := d.GetSummaryWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestDb_NotGetSummaryWhenGetItemError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.GetSummaryInput{
+		AccountKey: "1",
+	}
+	res, err := d.GetSummaryWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "get error", err.Error())
+}
+
+func TestDb_UpdateOverdraftLimit(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":null,\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":limit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":zero\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"SET OverdraftLimit = :limit, Balance = if_not_exists(Balance, :zero)\"}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.UpdateOverdraftLimitInput{
+		AccountKey:     "1",
+		OverdraftLimit: 500,
+	}
+	err := d.UpdateOverdraftLimitWithContext(context.Background(), i)
+	assert.Nil(t, err)
+}
+
+func TestDb_NotUpdateOverdraftLimitWhenUpdateItemError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.UpdateOverdraftLimitInput{
+		AccountKey:     "1",
+		OverdraftLimit: 500,
+	}
+	err := d.UpdateOverdraftLimitWithContext(context.Background(), i)
+	assert.Equal(t, "update error", err.Error())
+}
+
+func TestDb_PutSummary(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":null,\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":balance\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"700\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credits\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debits\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"300\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":held\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"SET Balance = :balance, TotalCredits = :credits, TotalDebits = :debits, EntryCount = :count, Held = :held\"}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.PutSummaryInput{
+		AccountKey:   "1",
+		Balance:      700,
+		TotalCredits: 1000,
+		TotalDebits:  300,
+		EntryCount:   2,
+	}
+	err := d.PutSummaryWithContext(context.Background(), i)
+	assert.Nil(t, err)
+}
+
+func TestDb_NotPutSummaryWhenUpdateItemError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.PutSummaryInput{
+		AccountKey: "1",
+	}
+	err := d.PutSummaryWithContext(context.Background(), i)
+	assert.Equal(t, "update error", err.Error())
+}
+
+func TestDb_ListAccountKeys(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"FilterExpression\":null,\"IndexName\":null,\"Limit\":null,\"ProjectionExpression\":\"AccountKey\",\"ReturnConsumedCapacity\":null,\"ScanFilter\":null,\"Segment\":null,\"Select\":null,\"TableName\":\"balance\",\"TotalSegments\":null}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	res, err := d.ListAccountKeysWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, res)
+}
+
+func TestDb_NotListAccountKeysWhenScanError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	res, err := d.ListAccountKeysWithContext(context.Background())
+	assert.Nil(t, res)
+	assert.Equal(t, "scan error", err.Error())
+}
+
+func TestDb_InsertReversal(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"300\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"0001-01-01T00:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"r1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Reversal\",\"SS\":null},\"OriginalExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"300\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"300\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"attribute_exists(EntryKey) AND (attribute_not_exists(ReversedAmount) OR ReversedAmount \\u003c= :maxReversed)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"300\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":maxReversed\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"700\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit#Buying#2\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\",\"UpdateExpression\":\"ADD ReversedAmount :amount\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-300\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Buying#2022-01-30\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-300\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Buying#2022-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}}]}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		AccountKey:            "1",
+		ExternalKey:           "r1",
+		OperatiionType:        "Reversal",
+		Amount:                300,
+		OriginalExternalKey:   "2",
+		OriginalEntryKey:      "debit#Buying#2",
+		MaxReversedAmount:     700,
+		OriginalOperationType: "Buying",
+		OriginalCreatedAt:     time.Date(2022, 1, 30, 23, 0, 0, 0, time.UTC),
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"ReversalExceeded\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestDb_NotInsertWhenReversalExceeded(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("3", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		AccountKey:          "1",
+		ExternalKey:         "r1",
+		OperatiionType:      "Reversal",
+		Amount:              300,
+		OriginalExternalKey: "2",
+		OriginalEntryKey:    "debit#Buying#2",
+		MaxReversedAmount:   700,
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"ReversalExceeded\":true,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestDb_GetEntry(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConsistentRead\":true,\"ExpressionAttributeNames\":null,\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit#Buying#2\",\"SS\":null}},\"ProjectionExpression\":null,\"ReturnConsumedCapacity\":null,\"TableName\":\"balance\"}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.GetEntryInput{
+		AccountKey: "1",
+		EntryKey:   "debit#Buying#2",
+	}
+	res, err := d.GetEntryWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", res.AccountKey)
+}
+
+func TestDb_NotGetEntryWhenNotFound(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("1", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.GetEntryInput{
+		AccountKey: "1",
+		EntryKey:   "debit#Buying#2",
+	}
+	res, err := d.GetEntryWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestDb_NotGetEntryWhenGetItemError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.GetEntryInput{
+		AccountKey: "1",
+		EntryKey:   "debit#Buying#2",
+	}
+	res, err := d.GetEntryWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "get error", err.Error())
+}
+
+func TestDb_InsertWithMetadata(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"0001-01-01T00:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"Description\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"salary\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"Metadata\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":{\"order_id\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"42\",\"SS\":null}},\"N\":null,\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Payment\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"credit\",\"SS\":null},\"RequestId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"req-1\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}}]}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		AccountKey:     "1",
+		ExternalKey:    "2",
+		OperatiionType: "Payment",
+		Amount:         1000,
+		Origin:         "credit",
+		RequestID:      "req-1",
+		Description:    "salary",
+		Metadata:       map[string]string{"order_id": "42"},
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.False(t, res.AlreadyExists)
+}
+
+func TestDb_InsertWithRiskScore(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"0001-01-01T00:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"3\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Buying\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit\",\"SS\":null},\"RiskScore\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"85\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"Balance \\u003e= :minimum AND (attribute_not_exists(OverdraftLimit) OR OverdraftLimit = :limit)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":limit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":minimum\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Buying#0001-01-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Buying#0001-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}}]}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		AccountKey:     "1",
+		ExternalKey:    "3",
+		OperatiionType: "Buying",
+		Amount:         -1000,
+		Origin:         "debit",
+		RiskScore:      85,
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.False(t, res.AlreadyExists)
+}
+
+func TestDb_FindEntries(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":true,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":accountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\":externalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":\"AccountKey-ExternalKey-index\",\"KeyConditionExpression\":\"AccountKey = :accountKey AND ExternalKey = :externalKey\",\"KeyConditions\":null,\"Limit\":null,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"balance\"}", t)
+	c := Config{
+		TableName: "balance",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.FindEntriesInput{
+		AccountKey:  "1",
+		ExternalKey: "2",
+	}
+	res, err := d.FindEntriesWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Entries\":[{\"AccountKey\":\"1\",\"EntryKey\":\"debit#Buying#2\",\"ExternalKey\":\"2\",\"OperationType\":\"Buying\",\"Amount\":-1000,\"Currency\":\"BRL\",\"InstallmentCount\":0,\"OriginalExternalKey\":\"\",\"ReversedAmount\":0,\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"debit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"\",\"TransferID\":\"\"}]}", string(b))
+}
+
+func TestDb_NotFindEntriesWhenQueryError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName: "balance",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.FindEntriesInput{
+		AccountKey:  "1",
+		ExternalKey: "2",
+	}
+	res, err := d.FindEntriesWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "query error", err.Error())
+}
+
+func TestDb_GetSummariesRebuilt(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConsistentRead\":true,\"ExpressionAttributeNames\":null,\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"#summaries\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"#rebuilt\",\"SS\":null}},\"ProjectionExpression\":null,\"ReturnConsumedCapacity\":null,\"TableName\":\"balance-summary\"}", t)
+	c := Config{
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	rebuilt, err := d.GetSummariesRebuiltWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, rebuilt)
+}
+
+func TestDb_GetSummariesNotRebuilt(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("1", t)
+	c := Config{
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	rebuilt, err := d.GetSummariesRebuiltWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, rebuilt)
+}
+
+func TestDb_NotGetSummariesRebuiltWhenGetError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	rebuilt, err := d.GetSummariesRebuiltWithContext(context.Background())
+	assert.False(t, rebuilt)
+	assert.Equal(t, "get error", err.Error())
+}
+
+func TestDb_PutSummariesRebuilt(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"ConditionExpression\":null,\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"#summaries\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"#rebuilt\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"balance-summary\"}", t)
+	c := Config{
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	err := d.PutSummariesRebuiltWithContext(context.Background())
+	assert.Nil(t, err)
+}
+
+func TestDb_NotPutSummariesRebuiltWhenPutError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	err := d.PutSummariesRebuiltWithContext(context.Background())
+	assert.Equal(t, "db error", err.Error())
 }
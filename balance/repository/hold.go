@@ -0,0 +1,361 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+)
+
+const holdExpiresAtIndex = "Status-ExpiresAt-index"
+
+func (d *db) holdKey(accountKey string, externalKey string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"AccountKey": {
+			S: aws.String(accountKey),
+		},
+		"ExternalKey": {
+			S: aws.String(externalKey),
+		},
+	}
+}
+
+// heldUpdate moves amount from the summary balance into Held; a negative amount
+// gives it back.
+func (d *db) heldUpdate(accountKey string, currency string, amount int) *dynamodb.Update {
+	return &dynamodb.Update{
+		Key:              summaryKey(accountKey, currency),
+		UpdateExpression: aws.String("ADD Balance :amount, Held :held"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":amount": {
+				N: aws.String(strconv.Itoa(amount * -1)),
+			},
+			":held": {
+				N: aws.String(strconv.Itoa(amount)),
+			},
+		},
+		TableName: aws.String(d.config.SummaryTableName),
+	}
+}
+
+// holdClose ends an active hold, failing when someone else already closed it.
+func (d *db) holdClose(accountKey string, externalKey string, status string, capturedAmount int, closedAt time.Time) *dynamodb.Update {
+	return &dynamodb.Update{
+		Key:                 d.holdKey(accountKey, externalKey),
+		UpdateExpression:    aws.String("SET #status = :status, CapturedAmount = :captured, ClosedAt = :closedAt"),
+		ConditionExpression: aws.String("#status = :active"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":status": {
+				S: aws.String(status),
+			},
+			":captured": {
+				N: aws.String(strconv.Itoa(capturedAmount)),
+			},
+			":closedAt": {
+				S: aws.String(formatCreatedAt(closedAt)),
+			},
+			":active": {
+				S: aws.String(app.HoldActive),
+			},
+		},
+		TableName: aws.String(d.config.HoldTableName),
+	}
+}
+
+func (d *db) InsertHoldWithContext(ctx context.Context, input *app.InsertHoldInput) (*app.InsertHoldOutput, error) {
+	h := input.Hold
+	put := &dynamodb.Put{
+		Item: map[string]*dynamodb.AttributeValue{
+			"AccountKey": {
+				S: aws.String(h.AccountKey),
+			},
+			"ExternalKey": {
+				S: aws.String(h.ExternalKey),
+			},
+			"OperationType": {
+				S: aws.String(h.OperationType),
+			},
+			"Amount": {
+				N: aws.String(strconv.Itoa(h.Amount)),
+			},
+			"Currency": {
+				S: aws.String(currencyValue(h.Currency)),
+			},
+			"Status": {
+				S: aws.String(h.Status),
+			},
+			"CreatedAt": {
+				S: aws.String(formatCreatedAt(h.CreatedAt)),
+			},
+			"ExpiresAt": {
+				S: aws.String(h.ExpiresAt.UTC().Format(time.RFC3339)),
+			},
+			"RequestHash": {
+				S: aws.String(h.RequestHash),
+			},
+		},
+		TableName:           aws.String(d.config.HoldTableName),
+		ConditionExpression: aws.String("attribute_not_exists(AccountKey) AND attribute_not_exists(ExternalKey)"),
+	}
+	setMetadataAttributes(put.Item, h.Origin, h.RequestID, h.Description, h.Metadata)
+	if h.RiskScore > 0 {
+		put.Item["RiskScore"] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.Itoa(h.RiskScore)),
+		}
+	}
+
+	// A hold takes the balance down as a debit would, overdraft limit included.
+	update := d.heldUpdate(h.AccountKey, h.Currency, h.Amount)
+	overdraftCondition(update, h.Amount, input.OverdraftLimit)
+
+	transactWriteItemsInput := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{Put: put},
+			{Update: update},
+		},
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb input hold %v", h))
+	_, err := d.dynamodbService.TransactWriteItemsWithContext(ctx, transactWriteItemsInput)
+	if err != nil {
+		if ae, ok := err.(*dynamodb.TransactionCanceledException); ok && len(ae.CancellationReasons) >= 2 {
+			d.log.Info(fmt.Sprintf("%s %s", ae.Code(), ae.Message()))
+			if conditionalCheckFailed(ae.CancellationReasons[0]) {
+				return &app.InsertHoldOutput{
+					AlreadyExists: true,
+				}, nil
+			}
+			if overdraftLimitChanged(ae.CancellationReasons[1], input.OverdraftLimit) {
+				return &app.InsertHoldOutput{
+					OverdraftLimitChanged: true,
+				}, nil
+			}
+			if conditionalCheckFailed(ae.CancellationReasons[1]) {
+				return &app.InsertHoldOutput{
+					InsufficientFunds: true,
+				}, nil
+			}
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.InsertHoldOutput{
+		AlreadyExists: false,
+	}, nil
+}
+
+func (d *db) GetHoldWithContext(ctx context.Context, input *app.GetHoldInput) (*app.Hold, error) {
+	i := &dynamodb.GetItemInput{
+		Key:            d.holdKey(input.AccountKey, input.ExternalKey),
+		TableName:      aws.String(d.config.HoldTableName),
+		ConsistentRead: aws.Bool(true),
+	}
+	getItemOutput, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get item %s", err.Error()))
+		return nil, err
+	}
+
+	if getItemOutput == nil || getItemOutput.Item == nil {
+		return nil, nil
+	}
+
+	return holdFromItem(getItemOutput.Item)
+}
+
+func (d *db) CaptureHoldWithContext(ctx context.Context, input *app.CaptureHoldRecordInput) (*app.CaptureHoldRecordOutput, error) {
+	e := input.Entry
+	captured := e.Amount * -1
+
+	put := d.entryPut(e)
+	d.log.Info(fmt.Sprintf("Dynamodb capture hold %s %s %d", input.AccountKey, input.ExternalKey, captured))
+	err := d.transactWithEventsWithContext(ctx, func() (*dynamodb.TransactWriteItemsInput, []*outboxEvent) {
+		// The captured part leaves Held as a debit, the rest goes back to the balance.
+		summary := d.heldUpdate(input.AccountKey, e.Currency, input.HeldAmount*-1)
+		summary.UpdateExpression = aws.String("ADD Balance :amount, Held :held, TotalDebits :debit, EntryCount :one")
+		summary.ExpressionAttributeValues[":amount"] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.Itoa(input.HeldAmount - captured)),
+		}
+		summary.ExpressionAttributeValues[":debit"] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.Itoa(captured)),
+		}
+		summary.ExpressionAttributeValues[":one"] = &dynamodb.AttributeValue{
+			N: aws.String("1"),
+		}
+
+		transactWriteItemsInput := &dynamodb.TransactWriteItemsInput{
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{Update: d.holdClose(input.AccountKey, input.ExternalKey, app.HoldCaptured, captured, e.CreatedAt)},
+				{Put: put},
+				{Update: summary},
+			},
+		}
+		transactWriteItemsInput.TransactItems = append(transactWriteItemsInput.TransactItems, d.entryUsageItems(e)...)
+		events := make([]*outboxEvent, 0, 1)
+		if e.Event != nil && d.config.OutboxTableName != "" {
+			events = append(events, &outboxEvent{
+				event:        e.Event,
+				entry:        put.Item,
+				summary:      summary,
+				summaryIndex: 2,
+			})
+		}
+		return transactWriteItemsInput, events
+	})
+	if err != nil {
+		if ae, ok := err.(*dynamodb.TransactionCanceledException); ok && len(ae.CancellationReasons) >= 2 {
+			d.log.Info(fmt.Sprintf("%s %s", ae.Code(), ae.Message()))
+			if conditionalCheckFailed(ae.CancellationReasons[0]) {
+				return &app.CaptureHoldRecordOutput{
+					HoldChanged: true,
+				}, nil
+			}
+			if conditionalCheckFailed(ae.CancellationReasons[1]) {
+				return &app.CaptureHoldRecordOutput{
+					AlreadyExists: true,
+				}, nil
+			}
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.CaptureHoldRecordOutput{
+		HoldChanged: false,
+	}, nil
+}
+
+func (d *db) ReleaseHoldWithContext(ctx context.Context, input *app.ReleaseHoldInput) (*app.ReleaseHoldOutput, error) {
+	transactWriteItemsInput := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{Update: d.holdClose(input.AccountKey, input.ExternalKey, input.Status, 0, input.ClosedAt)},
+			{Update: d.heldUpdate(input.AccountKey, input.Currency, input.Amount*-1)},
+		},
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb release hold %s %s %s", input.AccountKey, input.ExternalKey, input.Status))
+	_, err := d.dynamodbService.TransactWriteItemsWithContext(ctx, transactWriteItemsInput)
+	if err != nil {
+		if ae, ok := err.(*dynamodb.TransactionCanceledException); ok && len(ae.CancellationReasons) >= 1 {
+			if conditionalCheckFailed(ae.CancellationReasons[0]) {
+				d.log.Info(fmt.Sprintf("%s %s", ae.Code(), ae.Message()))
+				return &app.ReleaseHoldOutput{
+					HoldChanged: true,
+				}, nil
+			}
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.ReleaseHoldOutput{
+		HoldChanged: false,
+	}, nil
+}
+
+func (d *db) ListExpiredHoldsWithContext(ctx context.Context, input *app.ListExpiredHoldsInput) (*app.ListHoldsOutput, error) {
+	return d.queryHoldsWithContext(ctx, &dynamodb.QueryInput{
+		IndexName:              aws.String(holdExpiresAtIndex),
+		KeyConditionExpression: aws.String("#status = :active AND ExpiresAt <= :expiresBefore"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":active": {
+				S: aws.String(app.HoldActive),
+			},
+			":expiresBefore": {
+				S: aws.String(input.ExpiresBefore.UTC().Format(time.RFC3339)),
+			},
+		},
+		TableName: aws.String(d.config.HoldTableName),
+	})
+}
+
+func (d *db) ListHoldsWithContext(ctx context.Context, input *app.ListHoldsInput) (*app.ListHoldsOutput, error) {
+	return d.queryHoldsWithContext(ctx, &dynamodb.QueryInput{
+		KeyConditionExpression: aws.String("AccountKey = :accountKey"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":accountKey": {
+				S: aws.String(input.AccountKey),
+			},
+		},
+		ConsistentRead: aws.Bool(true),
+		TableName:      aws.String(d.config.HoldTableName),
+	})
+}
+
+func (d *db) queryHoldsWithContext(ctx context.Context, queryInput *dynamodb.QueryInput) (*app.ListHoldsOutput, error) {
+	holds := make([]*app.Hold, 0)
+	for {
+		queryOutput, err := d.dynamodbService.QueryWithContext(ctx, queryInput)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error query %s", err.Error()))
+			return nil, err
+		}
+
+		for _, item := range queryOutput.Items {
+			h, err := holdFromItem(item)
+			if err != nil {
+				d.log.Error(fmt.Sprintf("Error query item %s", err.Error()))
+				return nil, err
+			}
+			holds = append(holds, h)
+		}
+
+		if len(queryOutput.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = queryOutput.LastEvaluatedKey
+	}
+
+	return &app.ListHoldsOutput{
+		Holds: holds,
+	}, nil
+}
+
+func holdFromItem(item map[string]*dynamodb.AttributeValue) (*app.Hold, error) {
+	h := &app.Hold{
+		AccountKey:    stringAttribute(item, "AccountKey"),
+		ExternalKey:   stringAttribute(item, "ExternalKey"),
+		OperationType: stringAttribute(item, "OperationType"),
+		Currency:      currencyAttribute(item),
+		Status:        stringAttribute(item, "Status"),
+		Origin:        stringAttribute(item, "Origin"),
+		RequestID:     stringAttribute(item, "RequestId"),
+		Description:   stringAttribute(item, "Description"),
+		Metadata:      metadataAttribute(item),
+		RequestHash:   stringAttribute(item, "RequestHash"),
+	}
+	for name, v := range map[string]*int{
+		"Amount":         &h.Amount,
+		"CapturedAmount": &h.CapturedAmount,
+		"RiskScore":      &h.RiskScore,
+	} {
+		n, err := intAttribute(item, name)
+		if err != nil {
+			return nil, err
+		}
+		*v = n
+	}
+	for name, v := range map[string]*time.Time{
+		"CreatedAt": &h.CreatedAt,
+		"ExpiresAt": &h.ExpiresAt,
+		"ClosedAt":  &h.ClosedAt,
+	} {
+		if s := stringAttribute(item, name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, err
+			}
+			*v = t
+		}
+	}
+
+	return h, nil
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func holdItem() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"AccountKey":    {S: aws.String("1")},
+		"ExternalKey":   {S: aws.String("a1")},
+		"OperationType": {S: aws.String("Buying")},
+		"Amount":        {N: aws.String("1000")},
+		"Status":        {S: aws.String("active")},
+		"CreatedAt":     {S: aws.String("2022-01-31T10:00:00.000Z")},
+		"ExpiresAt":     {S: aws.String("2022-02-07T10:00:00Z")},
+		"Origin":        {S: aws.String("debit")},
+		"RiskScore":     {N: aws.String("20")},
+		"RequestHash":   {S: aws.String("hash")},
+	}
+}
+
+func newHoldConfig() Config {
+	return Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+		HoldTableName:    "balance-hold",
+	}
+}
+
+func newInsertHoldInput() *app.InsertHoldInput {
+	return &app.InsertHoldInput{
+		Hold: &app.Hold{
+			AccountKey:    "1",
+			ExternalKey:   "a1",
+			OperationType: "Buying",
+			Amount:        1000,
+			Status:        app.HoldActive,
+			CreatedAt:     time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+			ExpiresAt:     time.Date(2022, 2, 7, 10, 0, 0, 0, time.UTC),
+			Origin:        "debit",
+			RiskScore:     20,
+			RequestHash:   "hash",
+		},
+		OverdraftLimit: 500,
+	}
+}
+
+func newCaptureHoldInput() *app.CaptureHoldRecordInput {
+	return &app.CaptureHoldRecordInput{
+		AccountKey:  "1",
+		ExternalKey: "a1",
+		HeldAmount:  1000,
+		Entry: &app.InsertInput{
+			AccountKey:     "1",
+			EntryKey:       "debit#transaction#a1",
+			ExternalKey:    "a1",
+			OperatiionType: "Buying",
+			Amount:         -600,
+			CreatedAt:      time.Date(2022, 2, 1, 10, 0, 0, 0, time.UTC),
+			Origin:         "debit",
+			RequestHash:    "hash",
+		},
+	}
+}
+
+func newReleaseHoldInput() *app.ReleaseHoldInput {
+	return &app.ReleaseHoldInput{
+		AccountKey:  "1",
+		ExternalKey: "a1",
+		Amount:      1000,
+		Status:      app.HoldExpired,
+		ClosedAt:    time.Date(2022, 2, 7, 10, 1, 0, 0, time.UTC),
+	}
+}
+
+func TestHold_InsertHold(t *testing.T) {
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(ExternalKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"ExpiresAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-02-07T10:00:00Z\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"a1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Buying\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null},\"RiskScore\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"20\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"Status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-hold\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"Balance \\u003e= :minimum AND OverdraftLimit = :limit\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":held\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":limit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":minimum\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, Held :held\"}}]}", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.InsertHoldWithContext(context.Background(), newInsertHoldInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestHold_NotInsertHoldWhenAlreadyExists(t *testing.T) {
+	s := newServiceMock("1", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.InsertHoldWithContext(context.Background(), newInsertHoldInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":true,\"InsufficientFunds\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestHold_NotInsertHoldWhenInsufficientFunds(t *testing.T) {
+	s := newServiceMock("2", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.InsertHoldWithContext(context.Background(), newInsertHoldInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":true,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestHold_NotInsertHoldWhenOverdraftLimitChanged(t *testing.T) {
+	s := newServiceMock("7", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.InsertHoldWithContext(context.Background(), newInsertHoldInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"OverdraftLimitChanged\":true}", string(b))
+}
+
+func TestHold_NotInsertHoldWhenTransactError(t *testing.T) {
+	s := newServiceMock("", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.InsertHoldWithContext(context.Background(), newInsertHoldInput())
+	assert.Nil(t, res)
+	assert.Equal(t, "db error", err.Error())
+}
+
+func TestHold_GetHold(t *testing.T) {
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConsistentRead\":true,\"ExpressionAttributeNames\":null,\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"a1\",\"SS\":null}},\"ProjectionExpression\":null,\"ReturnConsumedCapacity\":null,\"TableName\":\"balance-hold\"}", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.GetHoldWithContext(context.Background(), &app.GetHoldInput{
+		AccountKey:  "1",
+		ExternalKey: "a1",
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AccountKey\":\"1\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"debit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":20,\"RequestHash\":\"hash\"}", string(b))
+}
+
+func TestHold_NotGetHoldWhenNotFound(t *testing.T) {
+	s := newServiceMock("1", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.GetHoldWithContext(context.Background(), &app.GetHoldInput{
+		AccountKey:  "1",
+		ExternalKey: "a1",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestHold_CaptureHold(t *testing.T) {
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"#status = :active\",\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":active\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null},\":captured\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":closedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-02-01T10:00:00.000Z\",\"SS\":null},\":status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"captured\",\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"a1\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-hold\",\"UpdateExpression\":\"SET #status = :status, CapturedAmount = :captured, ClosedAt = :closedAt\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-02-01T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit#transaction#a1\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"a1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Buying\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"400\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":held\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, Held :held, TotalDebits :debit, EntryCount :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Buying#2022-02-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Buying#2022-02\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}}]}", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.CaptureHoldWithContext(context.Background(), newCaptureHoldInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"HoldChanged\":false,\"AlreadyExists\":false}", string(b))
+}
+
+func TestHold_NotCaptureHoldWhenChanged(t *testing.T) {
+	s := newServiceMock("1", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.CaptureHoldWithContext(context.Background(), newCaptureHoldInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"HoldChanged\":true,\"AlreadyExists\":false}", string(b))
+}
+
+func TestHold_NotCaptureHoldWhenEntryExists(t *testing.T) {
+	s := newServiceMock("2", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.CaptureHoldWithContext(context.Background(), newCaptureHoldInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"HoldChanged\":false,\"AlreadyExists\":true}", string(b))
+}
+
+func TestHold_ReleaseHold(t *testing.T) {
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"#status = :active\",\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":active\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null},\":captured\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":closedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-02-07T10:01:00.000Z\",\"SS\":null},\":status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"expired\",\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"a1\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-hold\",\"UpdateExpression\":\"SET #status = :status, CapturedAmount = :captured, ClosedAt = :closedAt\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":held\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, Held :held\"}}]}", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.ReleaseHoldWithContext(context.Background(), newReleaseHoldInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"HoldChanged\":false}", string(b))
+}
+
+func TestHold_NotReleaseHoldWhenChanged(t *testing.T) {
+	s := newServiceMock("1", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.ReleaseHoldWithContext(context.Background(), newReleaseHoldInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"HoldChanged\":true}", string(b))
+}
+
+func TestHold_ListExpiredHolds(t *testing.T) {
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":active\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null},\":expiresBefore\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-02-07T10:01:00Z\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":\"Status-ExpiresAt-index\",\"KeyConditionExpression\":\"#status = :active AND ExpiresAt \\u003c= :expiresBefore\",\"KeyConditions\":null,\"Limit\":null,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"balance-hold\"}", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.ListExpiredHoldsWithContext(context.Background(), &app.ListExpiredHoldsInput{
+		ExpiresBefore: time.Date(2022, 2, 7, 10, 1, 0, 0, time.UTC),
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Holds\":[{\"AccountKey\":\"1\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"debit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":20,\"RequestHash\":\"hash\"}]}", string(b))
+}
+
+func TestHold_ListHolds(t *testing.T) {
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":true,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":accountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":null,\"KeyConditionExpression\":\"AccountKey = :accountKey\",\"KeyConditions\":null,\"Limit\":null,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"balance-hold\"}", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.ListHoldsWithContext(context.Background(), &app.ListHoldsInput{
+		AccountKey: "1",
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Holds\":[{\"AccountKey\":\"1\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"CapturedAmount\":0,\"Status\":\"active\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"ExpiresAt\":\"2022-02-07T10:00:00Z\",\"ClosedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"debit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":20,\"RequestHash\":\"hash\"}]}", string(b))
+}
+
+func TestHold_NotListHoldsWhenQueryError(t *testing.T) {
+	s := newServiceMock("", t)
+	d := NewDynamodb(s, newLogMock(), newHoldConfig())
+	res, err := d.ListHoldsWithContext(context.Background(), &app.ListHoldsInput{
+		AccountKey: "1",
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "query error", err.Error())
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+)
+
+const installmentDueDateIndex = "Status-DueDate-index"
+
+func (d *db) installmentPut(input *app.Installment) *dynamodb.Put {
+	put := &dynamodb.Put{
+		Item: map[string]*dynamodb.AttributeValue{
+			"AccountKey": {
+				S: aws.String(input.AccountKey),
+			},
+			"InstallmentKey": {
+				S: aws.String(input.EntryKey),
+			},
+			"ExternalKey": {
+				S: aws.String(input.ExternalKey),
+			},
+			"OperationType": {
+				S: aws.String(input.OperationType),
+			},
+			"Number": {
+				N: aws.String(strconv.Itoa(input.Number)),
+			},
+			"Count": {
+				N: aws.String(strconv.Itoa(input.Count)),
+			},
+			"Amount": {
+				N: aws.String(strconv.Itoa(input.Amount)),
+			},
+			"Currency": {
+				S: aws.String(currencyValue(input.Currency)),
+			},
+			"DueDate": {
+				S: aws.String(input.DueDate.UTC().Format(time.RFC3339)),
+			},
+			"Status": {
+				S: aws.String(input.Status),
+			},
+		},
+		TableName:           aws.String(d.config.InstallmentTableName),
+		ConditionExpression: aws.String("attribute_not_exists(AccountKey) AND attribute_not_exists(InstallmentKey)"),
+	}
+	setMetadataAttributes(put.Item, input.Origin, input.RequestID, input.Description, input.Metadata)
+
+	return put
+}
+
+func (d *db) ListDueInstallmentsWithContext(ctx context.Context, input *app.ListDueInstallmentsInput) (*app.ListDueInstallmentsOutput, error) {
+	queryInput := &dynamodb.QueryInput{
+		IndexName:              aws.String(installmentDueDateIndex),
+		KeyConditionExpression: aws.String("#status = :scheduled AND DueDate <= :dueBefore"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":scheduled": {
+				S: aws.String(app.InstallmentScheduled),
+			},
+			":dueBefore": {
+				S: aws.String(input.DueBefore.UTC().Format(time.RFC3339)),
+			},
+		},
+		TableName: aws.String(d.config.InstallmentTableName),
+	}
+
+	installments := make([]*app.Installment, 0)
+	for {
+		queryOutput, err := d.dynamodbService.QueryWithContext(ctx, queryInput)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error query %s", err.Error()))
+			return nil, err
+		}
+
+		for _, item := range queryOutput.Items {
+			i, err := installmentFromItem(item)
+			if err != nil {
+				d.log.Error(fmt.Sprintf("Error query item %s", err.Error()))
+				return nil, err
+			}
+			installments = append(installments, i)
+		}
+
+		if len(queryOutput.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = queryOutput.LastEvaluatedKey
+	}
+
+	return &app.ListDueInstallmentsOutput{
+		Installments: installments,
+	}, nil
+}
+
+func (d *db) PostInstallmentWithContext(ctx context.Context, input *app.Installment) (*app.PostInstallmentOutput, error) {
+	put := &dynamodb.Put{
+		Item: map[string]*dynamodb.AttributeValue{
+			"AccountKey": {
+				S: aws.String(input.AccountKey),
+			},
+			"EntryKey": {
+				S: aws.String(input.EntryKey),
+			},
+			"ExternalKey": {
+				S: aws.String(input.ExternalKey),
+			},
+			"OperationType": {
+				S: aws.String(input.OperationType),
+			},
+			"Amount": {
+				N: aws.String(strconv.Itoa(input.Amount)),
+			},
+			"Currency": {
+				S: aws.String(currencyValue(input.Currency)),
+			},
+			"InstallmentNumber": {
+				N: aws.String(strconv.Itoa(input.Number)),
+			},
+			"InstallmentCount": {
+				N: aws.String(strconv.Itoa(input.Count)),
+			},
+			"CreatedAt": {
+				S: aws.String(formatCreatedAt(input.PostedAt)),
+			},
+		},
+		TableName:           aws.String(d.config.TableName),
+		ConditionExpression: aws.String("attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)"),
+	}
+	setMetadataAttributes(put.Item, input.Origin, input.RequestID, input.Description, input.Metadata)
+
+	update := &dynamodb.Update{
+		Key: map[string]*dynamodb.AttributeValue{
+			"AccountKey": {
+				S: aws.String(input.AccountKey),
+			},
+			"InstallmentKey": {
+				S: aws.String(input.EntryKey),
+			},
+		},
+		UpdateExpression:    aws.String("SET #status = :posted"),
+		ConditionExpression: aws.String("#status = :scheduled"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":posted": {
+				S: aws.String(app.InstallmentPosted),
+			},
+			":scheduled": {
+				S: aws.String(app.InstallmentScheduled),
+			},
+		},
+		TableName: aws.String(d.config.InstallmentTableName),
+	}
+
+	d.log.Info(fmt.Sprintf("Dynamodb post installment %v", input))
+	err := d.transactWithEventsWithContext(ctx, func() (*dynamodb.TransactWriteItemsInput, []*outboxEvent) {
+		// Installments already sold are posted without the overdraft check.
+		summary := d.summaryUpdate(input.AccountKey, input.Currency, input.Amount)
+		transactWriteItemsInput := &dynamodb.TransactWriteItemsInput{
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{Put: put},
+				{Update: summary},
+				{Update: update},
+			},
+		}
+		transactWriteItemsInput.TransactItems = append(transactWriteItemsInput.TransactItems, d.usageItems(input.AccountKey, input.Currency, input.OperationType, input.Amount*-1, 1, input.PostedAt)...)
+		events := make([]*outboxEvent, 0, 1)
+		if input.Event != nil && d.config.OutboxTableName != "" {
+			events = append(events, &outboxEvent{
+				event:        input.Event,
+				entry:        put.Item,
+				summary:      summary,
+				summaryIndex: 1,
+			})
+		}
+		return transactWriteItemsInput, events
+	})
+	if err != nil {
+		if ae, ok := err.(*dynamodb.TransactionCanceledException); ok && len(ae.CancellationReasons) >= 3 {
+			if conditionalCheckFailed(ae.CancellationReasons[0]) || conditionalCheckFailed(ae.CancellationReasons[2]) {
+				d.log.Info(fmt.Sprintf("%s %s", ae.Code(), ae.Message()))
+				return &app.PostInstallmentOutput{
+					AlreadyPosted: true,
+				}, nil
+			}
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.PostInstallmentOutput{
+		AlreadyPosted: false,
+	}, nil
+}
+
+func installmentFromItem(item map[string]*dynamodb.AttributeValue) (*app.Installment, error) {
+	i := &app.Installment{
+		AccountKey:    stringAttribute(item, "AccountKey"),
+		ExternalKey:   stringAttribute(item, "ExternalKey"),
+		EntryKey:      stringAttribute(item, "InstallmentKey"),
+		OperationType: stringAttribute(item, "OperationType"),
+		Currency:      currencyAttribute(item),
+		Status:        stringAttribute(item, "Status"),
+		Origin:        stringAttribute(item, "Origin"),
+		RequestID:     stringAttribute(item, "RequestId"),
+		Description:   stringAttribute(item, "Description"),
+		Metadata:      metadataAttribute(item),
+	}
+	for name, v := range map[string]*int{
+		"Number": &i.Number,
+		"Count":  &i.Count,
+		"Amount": &i.Amount,
+	} {
+		n, err := intAttribute(item, name)
+		if err != nil {
+			return nil, err
+		}
+		*v = n
+	}
+
+	dueDate, err := time.Parse(time.RFC3339, stringAttribute(item, "DueDate"))
+	if err != nil {
+		return nil, err
+	}
+	i.DueDate = dueDate
+
+	return i, nil
+}
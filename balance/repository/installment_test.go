@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInstallment_InsertWithSchedule(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"0001-01-01T00:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"InstallmentCount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"InstallmentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"InstallmentBuying\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"Balance \\u003e= :minimum AND (attribute_not_exists(OverdraftLimit) OR OverdraftLimit = :limit)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":limit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":minimum\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(InstallmentKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"Count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"DueDate\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00Z\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"InstallmentKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"Number\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"InstallmentBuying\",\"SS\":null},\"Status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"posted\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"installment\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(InstallmentKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"Count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"DueDate\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-02-28T10:00:00Z\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"InstallmentKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2#02\",\"SS\":null},\"Number\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"InstallmentBuying\",\"SS\":null},\"Status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"scheduled\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"installment\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#InstallmentBuying#0001-01-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#InstallmentBuying#0001-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}}]}", t)
+	c := Config{
+		TableName:            "balance",
+		SummaryTableName:     "balance-summary",
+		InstallmentTableName: "installment",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		AccountKey:     "1",
+		ExternalKey:    "2",
+		OperatiionType: "InstallmentBuying",
+		Amount:         -500,
+		Schedule: []*app.Installment{
+			{AccountKey: "1", ExternalKey: "2", EntryKey: "2", OperationType: "InstallmentBuying", Number: 1, Count: 2, Amount: -500, DueDate: time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC), Status: "posted"},
+			{AccountKey: "1", ExternalKey: "2", EntryKey: "2#02", OperationType: "InstallmentBuying", Number: 2, Count: 2, Amount: -500, DueDate: time.Date(2022, 2, 28, 10, 0, 0, 0, time.UTC), Status: "scheduled"},
+		},
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"ReversalExceeded\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestInstallment_ListDueInstallments(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":dueBefore\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-03-01T00:00:00Z\",\"SS\":null},\":scheduled\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"scheduled\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":\"Status-DueDate-index\",\"KeyConditionExpression\":\"#status = :scheduled AND DueDate \\u003c= :dueBefore\",\"KeyConditions\":null,\"Limit\":null,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"installment\"}", t)
+	c := Config{
+		TableName:            "balance",
+		SummaryTableName:     "balance-summary",
+		InstallmentTableName: "installment",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.ListDueInstallmentsInput{
+		DueBefore: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	res, err := d.ListDueInstallmentsWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Installments\":[{\"AccountKey\":\"1\",\"ExternalKey\":\"2\",\"EntryKey\":\"2#02\",\"OperationType\":\"InstallmentBuying\",\"Number\":2,\"Count\":3,\"Amount\":-333,\"Currency\":\"BRL\",\"DueDate\":\"2022-02-28T10:00:00Z\",\"Status\":\"scheduled\",\"PostedAt\":\"0001-01-01T00:00:00Z\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"Event\":null}]}", string(b))
+}
+
+func TestInstallment_NotListDueInstallmentsWhenQueryError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		InstallmentTableName: "installment",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.ListDueInstallmentsInput{
+		DueBefore: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	res, err := d.ListDueInstallmentsWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "query error", err.Error())
+}
+
+func TestInstallment_PostInstallment(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"0001-01-01T00:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2#02\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"InstallmentCount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"InstallmentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"InstallmentBuying\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"#status = :scheduled\",\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":posted\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"posted\",\"SS\":null},\":scheduled\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"scheduled\",\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"InstallmentKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2#02\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"installment\",\"UpdateExpression\":\"SET #status = :posted\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#InstallmentBuying#0001-01-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#InstallmentBuying#0001-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}}]}", t)
+	c := Config{
+		TableName:            "balance",
+		SummaryTableName:     "balance-summary",
+		InstallmentTableName: "installment",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.Installment{AccountKey: "1", ExternalKey: "2", EntryKey: "2#02", OperationType: "InstallmentBuying", Number: 2, Count: 2, Amount: -500, Status: "scheduled"}
+	res, err := d.PostInstallmentWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyPosted\":false}", string(b))
+}
+
+func TestInstallment_PostInstallmentWhenAlreadyPosted(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("3", t)
+	c := Config{
+		InstallmentTableName: "installment",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.Installment{AccountKey: "1", ExternalKey: "2", EntryKey: "2#02", Amount: -500}
+	res, err := d.PostInstallmentWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyPosted\":true}", string(b))
+}
+
+func TestInstallment_NotPostInstallmentWhenTransactError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		InstallmentTableName: "installment",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.Installment{AccountKey: "1", ExternalKey: "2", EntryKey: "2#02", Amount: -500}
+	res, err := d.PostInstallmentWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "db error", err.Error())
+}
@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"strings"
+)
+
+const (
+	// outboxPendingIndex is sparse: Pending is removed once the event is published.
+	outboxPendingIndex = "Pending-CreatedAt-index"
+	outboxPending      = "1"
+	// maxEventSequenceAttempts bounds the retries of a write whose sequence was taken
+	// by another write on the same summary.
+	maxEventSequenceAttempts = 5
+	// relayLeaseKey is both keys of the lease item, which lives in the outbox table
+	// and never enters the pending index.
+	relayLeaseKey = "#relay"
+)
+
+var errEventSequenceTaken = errors.New("event sequence taken by other writes")
+
+// outboxEvent is an event written with the summary update at summaryIndex of the same
+// transaction, which numbers it.
+type outboxEvent struct {
+	event        *app.Event
+	entry        map[string]*dynamodb.AttributeValue
+	summary      *dynamodb.Update
+	summaryIndex int
+	sequence     int
+}
+
+// eventSequence sorts the events of an account in a currency by the counter of its
+// summary.
+func eventSequence(currency string, sequence int) string {
+	return fmt.Sprintf("%s#%020d", currency, sequence)
+}
+
+func sequenceNumber(sequence string) (int, error) {
+	return strconv.Atoi(sequence[strings.LastIndex(sequence, "#")+1:])
+}
+
+// eventPut copies the entry attributes, so the event carries the whole entry.
+func (d *db) eventPut(event *app.Event, sequence int, entry map[string]*dynamodb.AttributeValue) *dynamodb.Put {
+	item := make(map[string]*dynamodb.AttributeValue, len(entry)+4)
+	for k, v := range entry {
+		item[k] = v
+	}
+	item["Sequence"] = &dynamodb.AttributeValue{
+		S: aws.String(eventSequence(stringAttribute(entry, "Currency"), sequence)),
+	}
+	item["EventId"] = &dynamodb.AttributeValue{
+		S: aws.String(event.EventID),
+	}
+	item["EventType"] = &dynamodb.AttributeValue{
+		S: aws.String(event.Type),
+	}
+	item["Pending"] = &dynamodb.AttributeValue{
+		S: aws.String(outboxPending),
+	}
+
+	return &dynamodb.Put{
+		Item:      item,
+		TableName: aws.String(d.config.OutboxTableName),
+	}
+}
+
+// sequenceUpdate raises the event counter of a summary, failing when it is no longer
+// the one read before the transaction.
+func sequenceUpdate(update *dynamodb.Update, current int) {
+	update.UpdateExpression = aws.String(aws.StringValue(update.UpdateExpression) + ", EventSequence :one")
+	update.ExpressionAttributeValues[":one"] = &dynamodb.AttributeValue{
+		N: aws.String("1"),
+	}
+
+	condition := "attribute_not_exists(EventSequence)"
+	if current > 0 {
+		condition = "EventSequence = :sequence"
+		update.ExpressionAttributeValues[":sequence"] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.Itoa(current)),
+		}
+	}
+	if update.ConditionExpression != nil {
+		condition = fmt.Sprintf("(%s) AND %s", aws.StringValue(update.ConditionExpression), condition)
+	}
+	update.ConditionExpression = aws.String(condition)
+	update.ReturnValuesOnConditionCheckFailure = aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld)
+}
+
+func (d *db) eventSequenceWithContext(ctx context.Context, accountKey string, currency string) (int, error) {
+	i := &dynamodb.GetItemInput{
+		Key:                  summaryKey(accountKey, currency),
+		ProjectionExpression: aws.String("EventSequence"),
+		TableName:            aws.String(d.config.SummaryTableName),
+		ConsistentRead:       aws.Bool(true),
+	}
+	getItemOutput, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get event sequence %s", err.Error()))
+		return 0, err
+	}
+	if getItemOutput == nil {
+		return 0, nil
+	}
+
+	return intAttribute(getItemOutput.Item, "EventSequence")
+}
+
+// transactWithEventsWithContext writes the transaction built by build with its events,
+// numbered after the counters read from their summaries. When another write raised
+// one of the counters first the transaction is built and read again.
+func (d *db) transactWithEventsWithContext(ctx context.Context, build func() (*dynamodb.TransactWriteItemsInput, []*outboxEvent)) error {
+	for attempt := 0; attempt < maxEventSequenceAttempts; attempt++ {
+		transactWriteItemsInput, events := build()
+		for _, e := range events {
+			current, err := d.eventSequenceWithContext(ctx, stringAttribute(e.entry, "AccountKey"), stringAttribute(e.entry, "Currency"))
+			if err != nil {
+				return err
+			}
+			e.sequence = current + 1
+			sequenceUpdate(e.summary, current)
+			transactWriteItemsInput.TransactItems = append(transactWriteItemsInput.TransactItems, &dynamodb.TransactWriteItem{
+				Put: d.eventPut(e.event, e.sequence, e.entry),
+			})
+		}
+
+		_, err := d.dynamodbService.TransactWriteItemsWithContext(ctx, transactWriteItemsInput)
+		if err == nil || !sequenceTaken(err, events) {
+			return err
+		}
+		d.log.Info("Event sequence taken by another write, retrying")
+	}
+	return errEventSequenceTaken
+}
+
+// sequenceTaken tells whether a summary update failed because its counter moved,
+// rather than because of its own condition.
+func sequenceTaken(err error, events []*outboxEvent) bool {
+	ae, ok := err.(*dynamodb.TransactionCanceledException)
+	if !ok {
+		return false
+	}
+	for _, e := range events {
+		if e.summaryIndex >= len(ae.CancellationReasons) || !conditionalCheckFailed(ae.CancellationReasons[e.summaryIndex]) {
+			continue
+		}
+		current, err := intAttribute(ae.CancellationReasons[e.summaryIndex].Item, "EventSequence")
+		if err == nil && current != e.sequence-1 {
+			return true
+		}
+	}
+	return false
+}
+
+func eventFromItem(item map[string]*dynamodb.AttributeValue) (*app.Event, error) {
+	e, err := entryFromItem(item)
+	if err != nil {
+		return nil, err
+	}
+	sequence, err := sequenceNumber(stringAttribute(item, "Sequence"))
+	if err != nil {
+		return nil, err
+	}
+	_, pending := item["Pending"]
+	return &app.Event{
+		EventID:   stringAttribute(item, "EventId"),
+		Type:      stringAttribute(item, "EventType"),
+		Sequence:  sequence,
+		Published: !pending,
+		Entry:     e,
+	}, nil
+}
+
+func (d *db) ListPendingEventsWithContext(ctx context.Context, input *app.ListPendingEventsInput) (*app.ListPendingEventsOutput, error) {
+	queryInput := &dynamodb.QueryInput{
+		IndexName:              aws.String(outboxPendingIndex),
+		KeyConditionExpression: aws.String("Pending = :pending"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pending": {
+				S: aws.String(outboxPending),
+			},
+		},
+		Limit:     aws.Int64(int64(input.Limit)),
+		TableName: aws.String(d.config.OutboxTableName),
+	}
+
+	queryOutput, err := d.dynamodbService.QueryWithContext(ctx, queryInput)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error query pending events %s", err.Error()))
+		return nil, err
+	}
+
+	events := make([]*app.Event, 0, len(queryOutput.Items))
+	for _, item := range queryOutput.Items {
+		e, err := eventFromItem(item)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error query pending event item %s", err.Error()))
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return &app.ListPendingEventsOutput{
+		Events: events,
+	}, nil
+}
+
+func (d *db) GetEventWithContext(ctx context.Context, input *app.GetEventInput) (*app.Event, error) {
+	i := &dynamodb.GetItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"AccountKey": {
+				S: aws.String(input.AccountKey),
+			},
+			"Sequence": {
+				S: aws.String(eventSequence(currencyValue(input.Currency), input.Sequence)),
+			},
+		},
+		TableName:      aws.String(d.config.OutboxTableName),
+		ConsistentRead: aws.Bool(true),
+	}
+	getItemOutput, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get event %s", err.Error()))
+		return nil, err
+	}
+
+	if getItemOutput == nil || getItemOutput.Item == nil {
+		return nil, nil
+	}
+
+	return eventFromItem(getItemOutput.Item)
+}
+
+func (d *db) MarkEventPublishedWithContext(ctx context.Context, input *app.MarkEventPublishedInput) error {
+	i := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"AccountKey": {
+				S: aws.String(input.AccountKey),
+			},
+			"Sequence": {
+				S: aws.String(eventSequence(currencyValue(input.Currency), input.Sequence)),
+			},
+		},
+		UpdateExpression: aws.String("REMOVE Pending SET PublishedAt = :publishedAt"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":publishedAt": {
+				S: aws.String(formatCreatedAt(input.PublishedAt)),
+			},
+		},
+		TableName: aws.String(d.config.OutboxTableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb mark event published %v", input))
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// AcquireLeaseWithContext takes the relay lease when it is free, expired or already
+// held by the owner, extending it by the duration.
+func (d *db) AcquireLeaseWithContext(ctx context.Context, input *app.AcquireLeaseInput) (*app.AcquireLeaseOutput, error) {
+	i := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"AccountKey": {
+				S: aws.String(relayLeaseKey),
+			},
+			"Sequence": {
+				S: aws.String(relayLeaseKey),
+			},
+		},
+		UpdateExpression:    aws.String("SET LeaseOwner = :owner, LeaseExpiresAt = :expiresAt"),
+		ConditionExpression: aws.String("attribute_not_exists(LeaseOwner) OR LeaseOwner = :owner OR LeaseExpiresAt < :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner": {
+				S: aws.String(input.Owner),
+			},
+			":expiresAt": {
+				S: aws.String(formatCreatedAt(input.Now.Add(input.Duration))),
+			},
+			":now": {
+				S: aws.String(formatCreatedAt(input.Now)),
+			},
+		},
+		TableName: aws.String(d.config.OutboxTableName),
+	}
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		if ae, ok := err.(awserr.RequestFailure); ok && ae.Code() == "ConditionalCheckFailedException" {
+			return &app.AcquireLeaseOutput{
+				Acquired: false,
+			}, nil
+		}
+		d.log.Error(fmt.Sprintf("Error acquire lease %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.AcquireLeaseOutput{
+		Acquired: true,
+	}, nil
+}
+
+func NewOutbox(d Dynamodb, log Logger, config Config) app.Outbox {
+	return &db{
+		dynamodbService: d,
+		log:             log,
+		config:          config,
+	}
+}
+
+func NewLease(d Dynamodb, log Logger, config Config) app.Lease {
+	return &db{
+		dynamodbService: d,
+		log:             log,
+		config:          config,
+	}
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func eventItem() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"AccountKey":    {S: aws.String("1")},
+		"Sequence":      {S: aws.String("BRL#00000000000000000042")},
+		"EntryKey":      {S: aws.String("credit#transaction#2")},
+		"ExternalKey":   {S: aws.String("2")},
+		"OperationType": {S: aws.String("Payment")},
+		"Amount":        {N: aws.String("1000")},
+		"Currency":      {S: aws.String("BRL")},
+		"CreatedAt":     {S: aws.String("2022-01-31T10:00:00.000Z")},
+		"Origin":        {S: aws.String("credit")},
+		"EventId":       {S: aws.String("e1")},
+		"EventType":     {S: aws.String("TransactionSettled")},
+		"Pending":       {S: aws.String("1")},
+	}
+}
+
+func newOutboxConfig() Config {
+	return Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+		OutboxTableName:  "balance-outbox",
+	}
+}
+
+func TestOutbox_InsertWithEvent(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"credit#transaction#2\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Payment\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"credit\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"EventSequence = :sequence\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"41\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one, EventSequence :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"credit#transaction#2\",\"SS\":null},\"EventId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"e1\",\"SS\":null},\"EventType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransactionSettled\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Payment\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"credit\",\"SS\":null},\"Pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#00000000000000000042\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-outbox\"},\"Update\":null}]}", t)
+	d := NewDynamodb(s, l, newOutboxConfig())
+	i := &app.InsertInput{
+		AccountKey:     "1",
+		EntryKey:       "credit#transaction#2",
+		ExternalKey:    "2",
+		OperatiionType: "Payment",
+		Amount:         1000,
+		Currency:       "BRL",
+		CreatedAt:      time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		Origin:         "credit",
+		Event: &app.Event{
+			EventID: "e1",
+			Type:    app.TransactionSettled,
+		},
+	}
+	res, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"ReversalExceeded\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestOutbox_InsertWithoutOutboxTable(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"credit#transaction#2\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Payment\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"credit\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}}]}", t)
+	c := newOutboxConfig()
+	c.OutboxTableName = ""
+	d := NewDynamodb(s, l, c)
+	i := &app.InsertInput{
+		AccountKey:     "1",
+		EntryKey:       "credit#transaction#2",
+		ExternalKey:    "2",
+		OperatiionType: "Payment",
+		Amount:         1000,
+		Currency:       "BRL",
+		CreatedAt:      time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		Origin:         "credit",
+		Event: &app.Event{
+			EventID: "e1",
+			Type:    app.TransactionSettled,
+		},
+	}
+	_, err := d.InsertWithContext(context.Background(), i)
+	assert.Nil(t, err)
+}
+
+func TestOutbox_ListPendingEvents(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":\"Pending-CreatedAt-index\",\"KeyConditionExpression\":\"Pending = :pending\",\"KeyConditions\":null,\"Limit\":100,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"balance-outbox\"}", t)
+	o := NewOutbox(s, l, newOutboxConfig())
+	res, err := o.ListPendingEventsWithContext(context.Background(), &app.ListPendingEventsInput{
+		Limit: 100,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Events\":[{\"EventID\":\"e1\",\"Type\":\"TransactionSettled\",\"Sequence\":42,\"Published\":false,\"Entry\":{\"AccountKey\":\"1\",\"EntryKey\":\"credit#transaction#2\",\"ExternalKey\":\"2\",\"OperationType\":\"Payment\",\"Amount\":1000,\"Currency\":\"BRL\",\"InstallmentCount\":0,\"OriginalExternalKey\":\"\",\"ReversedAmount\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"credit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"\",\"TransferID\":\"\"}}]}", string(b))
+}
+
+func TestOutbox_NotListPendingEventsWhenQueryError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	o := NewOutbox(s, l, newOutboxConfig())
+	res, err := o.ListPendingEventsWithContext(context.Background(), &app.ListPendingEventsInput{
+		Limit: 100,
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "query error", err.Error())
+}
+
+func TestOutbox_MarkEventPublished(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":null,\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":publishedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:05.000Z\",\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#00000000000000000042\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"balance-outbox\",\"UpdateExpression\":\"REMOVE Pending SET PublishedAt = :publishedAt\"}", t)
+	o := NewOutbox(s, l, newOutboxConfig())
+	err := o.MarkEventPublishedWithContext(context.Background(), &app.MarkEventPublishedInput{
+		AccountKey:  "1",
+		Currency:    "BRL",
+		Sequence:    42,
+		PublishedAt: time.Date(2022, 1, 31, 10, 0, 5, 0, time.UTC),
+	})
+	assert.Nil(t, err)
+}
+
+func TestOutbox_NotMarkEventPublishedWhenUpdateItemError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	o := NewOutbox(s, l, newOutboxConfig())
+	err := o.MarkEventPublishedWithContext(context.Background(), &app.MarkEventPublishedInput{
+		AccountKey: "1",
+		Sequence:   42,
+	})
+	assert.Equal(t, "update error", err.Error())
+}
+
+func TestOutbox_CaptureHoldWithEvent(t *testing.T) {
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"#status = :active\",\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":active\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"active\",\"SS\":null},\":captured\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":closedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-02-01T10:00:00.000Z\",\"SS\":null},\":status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"captured\",\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"a1\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-hold\",\"UpdateExpression\":\"SET #status = :status, CapturedAmount = :captured, ClosedAt = :closedAt\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-02-01T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit#transaction#a1\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"a1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Buying\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"EventSequence = :sequence\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"400\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":held\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"41\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, Held :held, TotalDebits :debit, EntryCount :one, EventSequence :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Buying#2022-02-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Buying#2022-02\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-600\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-02-01T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit#transaction#a1\",\"SS\":null},\"EventId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"e1\",\"SS\":null},\"EventType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransactionSettled\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"a1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Buying\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"debit\",\"SS\":null},\"Pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null},\"Sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#00000000000000000042\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-outbox\"},\"Update\":null}]}", t)
+	c := newHoldConfig()
+	c.OutboxTableName = "balance-outbox"
+	d := NewDynamodb(s, newLogMock(), c)
+	i := newCaptureHoldInput()
+	i.Entry.Event = &app.Event{
+		EventID: "e1",
+		Type:    app.TransactionSettled,
+	}
+	res, err := d.CaptureHoldWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"HoldChanged\":false,\"AlreadyExists\":false}", string(b))
+}
+
+func TestOutbox_InsertTransferWithEvents(t *testing.T) {
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance#transfer#t1\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"t1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransferOut\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null},\"TransferId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"id\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"(Balance \\u003e= :minimum AND OverdraftLimit = :limit) AND EventSequence = :sequence\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":limit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":minimum\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"41\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one, EventSequence :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance#transfer#id\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"t1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransferIn\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null},\"TransferId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"id\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"EventSequence = :sequence\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"41\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one, EventSequence :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#TransferOut#2022-01-31\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#TransferOut#2022-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance#transfer#t1\",\"SS\":null},\"EventId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"e1\",\"SS\":null},\"EventType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransactionSettled\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"t1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransferOut\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance\",\"SS\":null},\"Pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null},\"Sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#00000000000000000042\",\"SS\":null},\"TransferId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"id\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-outbox\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance#transfer#id\",\"SS\":null},\"EventId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"e2\",\"SS\":null},\"EventType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransactionSettled\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"t1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransferIn\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance\",\"SS\":null},\"Pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null},\"Sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#00000000000000000042\",\"SS\":null},\"TransferId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"id\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-outbox\"},\"Update\":null}]}", t)
+	d := NewDynamodb(s, newLogMock(), newOutboxConfig())
+	i := newTransferInput()
+	i.Debit.Event = &app.Event{
+		EventID: "e1",
+		Type:    app.TransactionSettled,
+	}
+	i.Credit.Event = &app.Event{
+		EventID: "e2",
+		Type:    app.TransactionSettled,
+	}
+	_, err := d.InsertTransferWithContext(context.Background(), i)
+	assert.Nil(t, err)
+}
+
+func TestOutbox_PostInstallmentWithEvent(t *testing.T) {
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"0001-01-01T00:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2#02\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"InstallmentCount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"InstallmentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"InstallmentBuying\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"EventSequence = :sequence\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"41\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one, EventSequence :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"#status = :scheduled\",\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":posted\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"posted\",\"SS\":null},\":scheduled\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"scheduled\",\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"InstallmentKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2#02\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"installment\",\"UpdateExpression\":\"SET #status = :posted\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#InstallmentBuying#0001-01-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#InstallmentBuying#0001-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"0001-01-01T00:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2#02\",\"SS\":null},\"EventId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"e1\",\"SS\":null},\"EventType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransactionSettled\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"InstallmentCount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"InstallmentNumber\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"InstallmentBuying\",\"SS\":null},\"Pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#00000000000000000042\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-outbox\"},\"Update\":null}]}", t)
+	c := newOutboxConfig()
+	c.InstallmentTableName = "installment"
+	d := NewDynamodb(s, newLogMock(), c)
+	i := &app.Installment{
+		AccountKey:    "1",
+		ExternalKey:   "2",
+		EntryKey:      "2#02",
+		OperationType: "InstallmentBuying",
+		Number:        2,
+		Count:         2,
+		Amount:        -500,
+		Status:        "scheduled",
+		Event: &app.Event{
+			EventID: "e1",
+			Type:    app.TransactionSettled,
+		},
+	}
+	res, err := d.PostInstallmentWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyPosted\":false}", string(b))
+}
+
+func newDebitWithEvent() *app.InsertInput {
+	return &app.InsertInput{
+		AccountKey:     "1",
+		EntryKey:       "debit#transaction#3",
+		ExternalKey:    "3",
+		OperatiionType: "Buying",
+		Amount:         -1000,
+		Currency:       "BRL",
+		CreatedAt:      time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		Origin:         "debit",
+		Event: &app.Event{
+			EventID: "e3",
+			Type:    app.TransactionSettled,
+		},
+	}
+}
+
+func TestOutbox_InsufficientFundsWithEvent(t *testing.T) {
+	s := newServiceMock("6", t)
+	d := NewDynamodb(s, newLogMock(), newOutboxConfig())
+	res, err := d.InsertWithContext(context.Background(), newDebitWithEvent())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":true,\"ReversalExceeded\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestOutbox_NotInsertWhenSequenceKeepsBeingTaken(t *testing.T) {
+	s := newServiceMock("5", t)
+	d := NewDynamodb(s, newLogMock(), newOutboxConfig())
+	res, err := d.InsertWithContext(context.Background(), newDebitWithEvent())
+	assert.Nil(t, res)
+	assert.Equal(t, errEventSequenceTaken, err)
+}
+
+func TestOutbox_NotInsertWhenGetSequenceError(t *testing.T) {
+	s := newServiceMock("", t)
+	d := NewDynamodb(s, newLogMock(), newOutboxConfig())
+	res, err := d.InsertWithContext(context.Background(), newDebitWithEvent())
+	assert.Nil(t, res)
+	assert.Equal(t, "get error", err.Error())
+}
+
+func TestOutbox_GetEvent(t *testing.T) {
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConsistentRead\":true,\"ExpressionAttributeNames\":null,\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#00000000000000000042\",\"SS\":null}},\"ProjectionExpression\":null,\"ReturnConsumedCapacity\":null,\"TableName\":\"balance-outbox\"}", t)
+	o := NewOutbox(s, newLogMock(), newOutboxConfig())
+	res, err := o.GetEventWithContext(context.Background(), &app.GetEventInput{
+		AccountKey: "1",
+		Currency:   "BRL",
+		Sequence:   42,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"EventID\":\"e1\",\"Type\":\"TransactionSettled\",\"Sequence\":42,\"Published\":false,\"Entry\":{\"AccountKey\":\"1\",\"EntryKey\":\"credit#transaction#2\",\"ExternalKey\":\"2\",\"OperationType\":\"Payment\",\"Amount\":1000,\"Currency\":\"BRL\",\"InstallmentCount\":0,\"OriginalExternalKey\":\"\",\"ReversedAmount\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"credit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0,\"RequestHash\":\"\",\"TransferID\":\"\"}}", string(b))
+}
+
+func TestOutbox_NotGetEventWhenMissing(t *testing.T) {
+	s := newServiceMock("1", t)
+	o := NewOutbox(s, newLogMock(), newOutboxConfig())
+	res, err := o.GetEventWithContext(context.Background(), &app.GetEventInput{
+		AccountKey: "1",
+		Currency:   "BRL",
+		Sequence:   42,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func newAcquireLeaseInput() *app.AcquireLeaseInput {
+	return &app.AcquireLeaseInput{
+		Owner:    "balance-1",
+		Now:      time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		Duration: 45 * time.Second,
+	}
+}
+
+func TestOutbox_AcquireLease(t *testing.T) {
+	s := newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":\"attribute_not_exists(LeaseOwner) OR LeaseOwner = :owner OR LeaseExpiresAt \\u003c :now\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":expiresAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:45.000Z\",\"SS\":null},\":now\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\":owner\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance-1\",\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"#relay\",\"SS\":null},\"Sequence\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"#relay\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"balance-outbox\",\"UpdateExpression\":\"SET LeaseOwner = :owner, LeaseExpiresAt = :expiresAt\"}", t)
+	l := NewLease(s, newLogMock(), newOutboxConfig())
+	res, err := l.AcquireLeaseWithContext(context.Background(), newAcquireLeaseInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Acquired\":true}", string(b))
+}
+
+func TestOutbox_NotAcquireLeaseWhenHeld(t *testing.T) {
+	s := newServiceMock("5", t)
+	l := NewLease(s, newLogMock(), newOutboxConfig())
+	res, err := l.AcquireLeaseWithContext(context.Background(), newAcquireLeaseInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Acquired\":false}", string(b))
+}
+
+func TestOutbox_NotAcquireLeaseWhenUpdateItemError(t *testing.T) {
+	s := newServiceMock("", t)
+	l := NewLease(s, newLogMock(), newOutboxConfig())
+	res, err := l.AcquireLeaseWithContext(context.Background(), newAcquireLeaseInput())
+	assert.Nil(t, res)
+	assert.Equal(t, "update error", err.Error())
+}
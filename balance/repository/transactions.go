@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"time"
+)
+
+const (
+	entryCreatedAtIndex = "AccountKey-CreatedAt-index"
+	// Fixed width so timestamps sort lexically in the index.
+	createdAtLayout = "2006-01-02T15:04:05.000Z07:00"
+)
+
+func formatCreatedAt(t time.Time) string {
+	return t.UTC().Format(createdAtLayout)
+}
+
+func (d *db) ListEntriesWithContext(ctx context.Context, input *app.ListEntriesInput) (*app.ListEntriesOutput, error) {
+	queryInput := &dynamodb.QueryInput{
+		IndexName:              aws.String(entryCreatedAtIndex),
+		KeyConditionExpression: aws.String("AccountKey = :accountKey"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":accountKey": {
+				S: aws.String(input.AccountKey),
+			},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int64(int64(input.Limit)),
+		TableName:        aws.String(d.config.TableName),
+	}
+
+	switch {
+	case !input.From.IsZero() && !input.To.IsZero():
+		queryInput.KeyConditionExpression = aws.String("AccountKey = :accountKey AND CreatedAt BETWEEN :from AND :to")
+	case !input.From.IsZero():
+		queryInput.KeyConditionExpression = aws.String("AccountKey = :accountKey AND CreatedAt >= :from")
+	case !input.To.IsZero():
+		queryInput.KeyConditionExpression = aws.String("AccountKey = :accountKey AND CreatedAt <= :to")
+	}
+	if !input.From.IsZero() {
+		queryInput.ExpressionAttributeValues[":from"] = &dynamodb.AttributeValue{
+			S: aws.String(formatCreatedAt(input.From)),
+		}
+	}
+	if !input.To.IsZero() {
+		queryInput.ExpressionAttributeValues[":to"] = &dynamodb.AttributeValue{
+			S: aws.String(formatCreatedAt(input.To)),
+		}
+	}
+
+	if input.OperationType != "" {
+		queryInput.FilterExpression = aws.String("OperationType = :operationType")
+		queryInput.ExpressionAttributeValues[":operationType"] = &dynamodb.AttributeValue{
+			S: aws.String(input.OperationType),
+		}
+	}
+
+	if input.Cursor != "" {
+		startKey, err := decodeCursor(input.Cursor)
+		if err != nil || stringAttribute(startKey, "AccountKey") != input.AccountKey {
+			return &app.ListEntriesOutput{
+				CursorInvalid: true,
+			}, nil
+		}
+		queryInput.ExclusiveStartKey = startKey
+	}
+
+	queryOutput, err := d.dynamodbService.QueryWithContext(ctx, queryInput)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error query %s", err.Error()))
+		return nil, err
+	}
+
+	entries := make([]*app.Entry, 0)
+	for _, item := range queryOutput.Items {
+		e, err := entryFromItem(item)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error query item %s", err.Error()))
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	nextCursor, err := encodeCursor(queryOutput.LastEvaluatedKey)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error encode cursor %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.ListEntriesOutput{
+		Entries:    entries,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// The cursor is the LastEvaluatedKey of the index, whose attributes are all strings.
+func encodeCursor(key map[string]*dynamodb.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+
+	values := make(map[string]string)
+	for name, v := range key {
+		values[name] = aws.StringValue(v.S)
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(cursor string) (map[string]*dynamodb.AttributeValue, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+
+	key := make(map[string]*dynamodb.AttributeValue)
+	for _, name := range []string{"AccountKey", "EntryKey", "CreatedAt"} {
+		v, ok := values[name]
+		if !ok || v == "" {
+			return nil, fmt.Errorf("cursor missing %s", name)
+		}
+		key[name] = &dynamodb.AttributeValue{
+			S: aws.String(v),
+		}
+	}
+
+	return key, nil
+}
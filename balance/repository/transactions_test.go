@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDb_ListEntries(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":accountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":\"AccountKey-CreatedAt-index\",\"KeyConditionExpression\":\"AccountKey = :accountKey\",\"KeyConditions\":null,\"Limit\":50,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":false,\"Select\":null,\"TableName\":\"balance\"}", t)
+	c := Config{
+		TableName: "balance",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.ListEntriesInput{
+		AccountKey: "1",
+		Limit:      50,
+	}
+	res, err := d.ListEntriesWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Entries\":[{\"AccountKey\":\"1\",\"EntryKey\":\"credit#Payment#2\",\"ExternalKey\":\"2\",\"OperationType\":\"Payment\",\"Amount\":1000,\"Currency\":\"BRL\",\"InstallmentCount\":0,\"OriginalExternalKey\":\"\",\"ReversedAmount\":0,\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"Origin\":\"credit\",\"RequestID\":\"req-1\",\"Description\":\"\",\"Metadata\":{\"order_id\":\"42\"},\"RiskScore\":0,\"RequestHash\":\"\",\"TransferID\":\"\"}],\"NextCursor\":\"eyJBY2NvdW50S2V5IjoiMSIsIkNyZWF0ZWRBdCI6IjIwMjItMDEtMzFUMTA6MDA6MDAuMDAwWiIsIkVudHJ5S2V5IjoiY3JlZGl0I1BheW1lbnQjMiJ9\",\"CursorInvalid\":false}", string(b))
+}
+
+func TestDb_ListEntriesWithFilters(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"credit#Payment#2\",\"SS\":null}},\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":accountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\":from\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-01T00:00:00.000Z\",\"SS\":null},\":operationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"Payment\",\"SS\":null},\":to\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T23:59:59.000Z\",\"SS\":null}},\"FilterExpression\":\"OperationType = :operationType\",\"IndexName\":\"AccountKey-CreatedAt-index\",\"KeyConditionExpression\":\"AccountKey = :accountKey AND CreatedAt BETWEEN :from AND :to\",\"KeyConditions\":null,\"Limit\":10,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":false,\"Select\":null,\"TableName\":\"balance\"}", t)
+	c := Config{
+		TableName: "balance",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.ListEntriesInput{
+		AccountKey:    "1",
+		OperationType: "Payment",
+		From:          time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:            time.Date(2022, 1, 31, 23, 59, 59, 0, time.UTC),
+		Limit:         10,
+		Cursor:        "eyJBY2NvdW50S2V5IjoiMSIsIkNyZWF0ZWRBdCI6IjIwMjItMDEtMzFUMTA6MDA6MDAuMDAwWiIsIkVudHJ5S2V5IjoiY3JlZGl0I1BheW1lbnQjMiJ9",
+	}
+	res, err := d.ListEntriesWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res.Entries))
+}
+
+func TestDb_ListEntriesFromOnly(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":accountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\":from\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-01T00:00:00.000Z\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":\"AccountKey-CreatedAt-index\",\"KeyConditionExpression\":\"AccountKey = :accountKey AND CreatedAt \\u003e= :from\",\"KeyConditions\":null,\"Limit\":10,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":false,\"Select\":null,\"TableName\":\"balance\"}", t)
+	c := Config{
+		TableName: "balance",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.ListEntriesInput{
+		AccountKey: "1",
+		From:       time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		Limit:      10,
+	}
+	res, err := d.ListEntriesWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res.Entries))
+}
+
+func TestDb_NotListEntriesWhenCursorInvalid(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName: "balance",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.ListEntriesInput{
+		AccountKey: "1",
+		Limit:      10,
+		Cursor:     "not-a-cursor",
+	}
+	res, err := d.ListEntriesWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.True(t, res.CursorInvalid)
+}
+
+func TestDb_NotListEntriesWhenCursorFromAnotherAccount(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName: "balance",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.ListEntriesInput{
+		AccountKey: "2",
+		Limit:      10,
+		Cursor:     "eyJBY2NvdW50S2V5IjoiMSIsIkNyZWF0ZWRBdCI6IjIwMjItMDEtMzFUMTA6MDA6MDAuMDAwWiIsIkVudHJ5S2V5IjoiY3JlZGl0I1BheW1lbnQjMiJ9",
+	}
+	res, err := d.ListEntriesWithContext(context.Background(), i)
+	assert.Nil(t, err)
+	assert.True(t, res.CursorInvalid)
+}
+
+func TestDb_NotListEntriesWhenQueryError(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName: "balance",
+	}
+	d := NewDynamodb(s, l, c)
+	i := &app.ListEntriesInput{
+		AccountKey: "1",
+		Limit:      10,
+	}
+	res, err := d.ListEntriesWithContext(context.Background(), i)
+	assert.Nil(t, res)
+	assert.Equal(t, "query error", err.Error())
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (d *db) InsertTransferWithContext(ctx context.Context, input *app.InsertTransferInput) (*app.InsertTransferOutput, error) {
+	debit := d.entryPut(input.Debit)
+	credit := d.entryPut(input.Credit)
+	d.log.Info(fmt.Sprintf("Dynamodb input transfer %v %v", input.Debit, input.Credit))
+	err := d.transactWithEventsWithContext(ctx, func() (*dynamodb.TransactWriteItemsInput, []*outboxEvent) {
+		debitSummary := d.balanceUpdate(input.Debit)
+		creditSummary := d.balanceUpdate(input.Credit)
+		transactWriteItemsInput := &dynamodb.TransactWriteItemsInput{
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{Put: debit},
+				{Update: debitSummary},
+				{Put: credit},
+				{Update: creditSummary},
+			},
+		}
+		transactWriteItemsInput.TransactItems = append(transactWriteItemsInput.TransactItems, d.entryUsageItems(input.Debit)...)
+		events := make([]*outboxEvent, 0, 2)
+		if d.config.OutboxTableName != "" {
+			for _, leg := range []*outboxEvent{
+				{event: input.Debit.Event, entry: debit.Item, summary: debitSummary, summaryIndex: 1},
+				{event: input.Credit.Event, entry: credit.Item, summary: creditSummary, summaryIndex: 3},
+			} {
+				if leg.event != nil {
+					events = append(events, leg)
+				}
+			}
+		}
+		return transactWriteItemsInput, events
+	})
+	if err != nil {
+		if ae, ok := err.(*dynamodb.TransactionCanceledException); ok && len(ae.CancellationReasons) >= 3 {
+			d.log.Info(fmt.Sprintf("%s %s", ae.Code(), ae.Message()))
+			if conditionalCheckFailed(ae.CancellationReasons[0]) || conditionalCheckFailed(ae.CancellationReasons[2]) {
+				return &app.InsertTransferOutput{
+					AlreadyExists: true,
+				}, nil
+			}
+			if overdraftLimitChanged(ae.CancellationReasons[1], input.Debit.OverdraftLimit) {
+				return &app.InsertTransferOutput{
+					OverdraftLimitChanged: true,
+				}, nil
+			}
+			if conditionalCheckFailed(ae.CancellationReasons[1]) {
+				return &app.InsertTransferOutput{
+					InsufficientFunds: true,
+				}, nil
+			}
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.InsertTransferOutput{
+		AlreadyExists: false,
+	}, nil
+}
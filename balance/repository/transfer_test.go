@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTransferInput() *app.InsertTransferInput {
+	createdAt := time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)
+	return &app.InsertTransferInput{
+		Debit: &app.InsertInput{
+			AccountKey:     "1",
+			EntryKey:       "balance#transfer#t1",
+			ExternalKey:    "t1",
+			OperatiionType: "TransferOut",
+			Amount:         -1000,
+			OverdraftLimit: 500,
+			CreatedAt:      createdAt,
+			Origin:         "balance",
+			RequestHash:    "hash",
+			TransferID:     "id",
+		},
+		Credit: &app.InsertInput{
+			AccountKey:     "2",
+			EntryKey:       "balance#transfer#id",
+			ExternalKey:    "t1",
+			OperatiionType: "TransferIn",
+			Amount:         1000,
+			CreatedAt:      createdAt,
+			Origin:         "balance",
+			RequestHash:    "hash",
+			TransferID:     "id",
+		},
+	}
+}
+
+func TestTransfer_InsertTransfer(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("{\"ClientRequestToken\":null,\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"TransactItems\":[{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance#transfer#t1\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"t1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransferOut\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null},\"TransferId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"id\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":\"Balance \\u003e= :minimum AND OverdraftLimit = :limit\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"-1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":limit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":minimum\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":\"ALL_OLD\",\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":{\"ConditionExpression\":\"attribute_not_exists(AccountKey) AND attribute_not_exists(EntryKey)\",\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"Amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null},\"EntryKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance#transfer#id\",\"SS\":null},\"ExternalKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"t1\",\"SS\":null},\"OperationType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"TransferIn\",\"SS\":null},\"Origin\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"balance\",\"SS\":null},\"RequestHash\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"hash\",\"SS\":null},\"TransferId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"id\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance\"},\"Update\":null},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":credit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":debit\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":one\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD Balance :amount, TotalCredits :credit, TotalDebits :debit, EntryCount :one\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#TransferOut#2022-01-31\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}},{\"ConditionCheck\":null,\"Delete\":null,\"Put\":null,\"Update\":{\"ConditionExpression\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1000\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#TransferOut#2022-01\",\"SS\":null}},\"ReturnValuesOnConditionCheckFailure\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"ADD UsageAmount :amount, UsageCount :count\"}}]}", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	res, err := d.InsertTransferWithContext(context.Background(), newTransferInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestTransfer_InsertTransferWhenAlreadyExists(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("3", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	res, err := d.InsertTransferWithContext(context.Background(), newTransferInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":true,\"InsufficientFunds\":false,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestTransfer_InsertTransferWhenInsufficientFunds(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("4", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	res, err := d.InsertTransferWithContext(context.Background(), newTransferInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":true,\"OverdraftLimitChanged\":false}", string(b))
+}
+
+func TestTransfer_InsertTransferWhenOverdraftLimitChanged(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("7", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	res, err := d.InsertTransferWithContext(context.Background(), newTransferInput())
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"AlreadyExists\":false,\"InsufficientFunds\":false,\"OverdraftLimitChanged\":true}", string(b))
+}
+
+func TestTransfer_NotInsertTransfer(t *testing.T) {
+	l := newLogMock()
+	s := newServiceMock("", t)
+	c := Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	}
+	d := NewDynamodb(s, l, c)
+	res, err := d.InsertTransferWithContext(context.Background(), newTransferInput())
+	assert.Nil(t, res)
+	assert.Equal(t, "db error", err.Error())
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+)
+
+// usageKey addresses the usage of an operation type in a period. It lives next to
+// the summaries of the account, under a sort key no currency can take.
+func usageKey(accountKey string, currency string, operationType string, period string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"AccountKey": {
+			S: aws.String(accountKey),
+		},
+		"Currency": {
+			S: aws.String(fmt.Sprintf("%s#%s#%s", currencyValue(currency), operationType, period)),
+		},
+	}
+}
+
+// usageItems adds a debit made at at to the usage of its day and month. A debit
+// counts once; a reversal passes a negative amount and no count.
+func (d *db) usageItems(accountKey string, currency string, operationType string, amount int, count int, at time.Time) []*dynamodb.TransactWriteItem {
+	items := make([]*dynamodb.TransactWriteItem, 0, 2)
+	for _, period := range app.UsagePeriods(at) {
+		items = append(items, &dynamodb.TransactWriteItem{
+			Update: &dynamodb.Update{
+				Key:              usageKey(accountKey, currency, operationType, period),
+				UpdateExpression: aws.String("ADD UsageAmount :amount, UsageCount :count"),
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":amount": {
+						N: aws.String(strconv.Itoa(amount)),
+					},
+					":count": {
+						N: aws.String(strconv.Itoa(count)),
+					},
+				},
+				TableName: aws.String(d.config.SummaryTableName),
+			},
+		})
+	}
+	return items
+}
+
+// entryUsageItems counts a debit entry on its own periods and a reversal on the
+// periods of the debit it reverses. Credits are not counted.
+func (d *db) entryUsageItems(input *app.InsertInput) []*dynamodb.TransactWriteItem {
+	if input.OriginalExternalKey != "" {
+		return d.usageItems(input.AccountKey, input.Currency, input.OriginalOperationType, input.Amount*-1, 0, input.OriginalCreatedAt)
+	}
+	if input.Amount < 0 {
+		return d.usageItems(input.AccountKey, input.Currency, input.OperatiionType, input.Amount*-1, 1, input.CreatedAt)
+	}
+	return nil
+}
+
+func (d *db) GetUsageWithContext(ctx context.Context, input *app.GetUsageRecordInput) (*app.Usage, error) {
+	i := &dynamodb.GetItemInput{
+		Key:            usageKey(input.AccountKey, input.Currency, input.OperationType, input.Period),
+		TableName:      aws.String(d.config.SummaryTableName),
+		ConsistentRead: aws.Bool(true),
+	}
+	getItemOutput, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get item %s", err.Error()))
+		return nil, err
+	}
+
+	if getItemOutput == nil || getItemOutput.Item == nil {
+		return nil, nil
+	}
+
+	u := &app.Usage{}
+	for name, v := range map[string]*int{
+		"UsageAmount": &u.Amount,
+		"UsageCount":  &u.Count,
+	} {
+		n, err := intAttribute(getItemOutput.Item, name)
+		if err != nil {
+			return nil, err
+		}
+		*v = n
+	}
+
+	return u, nil
+}
+
+func (d *db) PutUsageWithContext(ctx context.Context, input *app.PutUsageInput) error {
+	i := &dynamodb.UpdateItemInput{
+		Key:              usageKey(input.AccountKey, input.Currency, input.OperationType, input.Period),
+		UpdateExpression: aws.String("SET UsageAmount = :amount, UsageCount = :count"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":amount": {
+				N: aws.String(strconv.Itoa(input.Amount)),
+			},
+			":count": {
+				N: aws.String(strconv.Itoa(input.Count)),
+			},
+		},
+		TableName: aws.String(d.config.SummaryTableName),
+	}
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error update item %s", err.Error()))
+		return err
+	}
+
+	return nil
+}
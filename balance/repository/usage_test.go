@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"balance/app"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newUsageDynamodb(v string, t *testing.T) app.Persistence {
+	return NewDynamodb(newServiceMock(v, t), newLogMock(), Config{
+		TableName:        "balance",
+		SummaryTableName: "balance-summary",
+	})
+}
+
+func TestUsage_GetUsage(t *testing.T) {
+	d := newUsageDynamodb("{\"AttributesToGet\":null,\"ConsistentRead\":true,\"ExpressionAttributeNames\":null,\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"BRL#Withdraw#2022-01\",\"SS\":null}},\"ProjectionExpression\":null,\"ReturnConsumedCapacity\":null,\"TableName\":\"balance-summary\"}", t)
+	res, err := d.GetUsageWithContext(context.Background(), &app.GetUsageRecordInput{
+		AccountKey:    "1",
+		Currency:      "BRL",
+		OperationType: "Withdraw",
+		Period:        "2022-01",
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Amount\":2500,\"Count\":2}", string(b))
+}
+
+func TestUsage_GetUsageWhenMissing(t *testing.T) {
+	d := newUsageDynamodb("1", t)
+	res, err := d.GetUsageWithContext(context.Background(), &app.GetUsageRecordInput{
+		AccountKey:    "1",
+		Currency:      "BRL",
+		OperationType: "Withdraw",
+		Period:        "2022-01-31",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestUsage_NotGetUsageWhenGetItemError(t *testing.T) {
+	d := newUsageDynamodb("", t)
+	res, err := d.GetUsageWithContext(context.Background(), &app.GetUsageRecordInput{
+		AccountKey:    "1",
+		Currency:      "BRL",
+		OperationType: "Withdraw",
+		Period:        "2022-01-31",
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "get error", err.Error())
+}
+
+func TestUsage_PutUsage(t *testing.T) {
+	d := newUsageDynamodb("{\"AttributeUpdates\":null,\"ConditionExpression\":null,\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":amount\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"700\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":count\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"1\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Currency\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"USD#Buying#2022-01-31\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"balance-summary\",\"UpdateExpression\":\"SET UsageAmount = :amount, UsageCount = :count\"}", t)
+	err := d.PutUsageWithContext(context.Background(), &app.PutUsageInput{
+		AccountKey:    "1",
+		Currency:      "USD",
+		OperationType: "Buying",
+		Period:        "2022-01-31",
+		Amount:        700,
+		Count:         1,
+	})
+	assert.Nil(t, err)
+}
+
+func TestUsage_NotPutUsageWhenUpdateItemError(t *testing.T) {
+	d := newUsageDynamodb("", t)
+	err := d.PutUsageWithContext(context.Background(), &app.PutUsageInput{
+		AccountKey:    "1",
+		Currency:      "USD",
+		OperationType: "Buying",
+		Period:        "2022-01-31",
+		Amount:        700,
+		Count:         1,
+	})
+	assert.Equal(t, "update error", err.Error())
+}
@@ -5,14 +5,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
 const (
-	BadRequest     = "bad_request"
-	Conflict       = "conflict"
-	InvalidRequest = "invalid_request"
+	BadRequest          = "bad_request"
+	Conflict            = "conflict"
+	InvalidRequest      = "invalid_request"
+	NotFound            = "not_found"
+	UnprocessableEntity = "unprocessable_entity"
+	BadGateway          = "bad_gateway"
+
+	MaxDescriptionLength   = 255
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 255
 )
 
 func stringValue(v *string) string {
@@ -30,16 +41,31 @@ func intValue(v *int) int {
 }
 
 type BalanceRequest struct {
-	AccountKey    *string `json:"account_key,omitempty"`
-	ExternalKey   *string `json:"external_key,omitempty"`
-	OperationType *string `json:"operation_type,omitempty"`
-	Amount        *int    `json:"amount,omitempty"`
+	AccountKey          *string `json:"account_key,omitempty"`
+	ExternalKey         *string `json:"external_key,omitempty"`
+	OperationType       *string `json:"operation_type,omitempty"`
+	Amount              *int    `json:"amount,omitempty"`
+	Currency            *string `json:"currency,omitempty"`
+	Installments        *int    `json:"installments,omitempty"`
+	OriginalExternalKey *string `json:"original_external_key,omitempty"`
+
+	Origin      *string           `json:"origin,omitempty"`
+	RequestID   *string           `json:"request_id,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	RiskScore   *int              `json:"risk_score,omitempty"`
+}
+
+type OverdraftLimitRequest struct {
+	OverdraftLimit *int    `json:"overdraft_limit,omitempty"`
+	Currency       *string `json:"currency,omitempty"`
 }
 
 type BalanceError struct {
 	StatusCode int    `json:"-"`
 	Type       string `json:"type,omitempty"`
 	Category   string `json:"category,omitempty"`
+	Code       string `json:"code,omitempty"`
 	Message    string `json:"message,omitempty"`
 }
 
@@ -47,6 +73,30 @@ type BalanceErrorResponse struct {
 	Error *BalanceError `json:"error,omitempty"`
 }
 
+type BalanceResponse struct {
+	AccountKey          string    `json:"account_key"`
+	ExternalKey         string    `json:"external_key"`
+	OperationType       string    `json:"operation_type"`
+	Amount              int       `json:"amount"`
+	Currency            string    `json:"currency"`
+	Installments        int       `json:"installments,omitempty"`
+	OriginalExternalKey string    `json:"original_external_key,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	RequestID           string    `json:"request_id,omitempty"`
+}
+
+type BalanceGetResponse struct {
+	AccountKey       string `json:"account_key"`
+	Currency         string `json:"currency"`
+	Exponent         int    `json:"exponent"`
+	AvailableBalance int    `json:"available_balance"`
+	HeldAmount       int    `json:"held_amount"`
+	LedgerBalance    int    `json:"ledger_balance"`
+	TotalCredits     int    `json:"total_credits"`
+	TotalDebits      int    `json:"total_debits"`
+	EntryCount       int    `json:"entry_count"`
+}
+
 func responseBuild(msg string, statusCode int, category string) *BalanceErrorResponse {
 	et := &BalanceError{
 		StatusCode: statusCode,
@@ -60,6 +110,46 @@ func responseBuild(msg string, statusCode int, category string) *BalanceErrorRes
 	return ae
 }
 
+func codeResponseBuild(code string, msg string, statusCode int, category string) *BalanceErrorResponse {
+	ae := responseBuild(msg, statusCode, category)
+	ae.Error.Code = code
+	return ae
+}
+
+func validateMetadata(description string, metadata map[string]string) *BalanceErrorResponse {
+	if len(description) > MaxDescriptionLength {
+		return responseBuild(fmt.Sprintf("description must have at most %d characters", MaxDescriptionLength), http.StatusBadRequest, BadRequest)
+	}
+
+	if len(metadata) > MaxMetadataKeys {
+		return responseBuild(fmt.Sprintf("metadata must have at most %d keys", MaxMetadataKeys), http.StatusBadRequest, BadRequest)
+	}
+
+	for k, v := range metadata {
+		if k == "" || len(k) > MaxMetadataKeyLength {
+			return responseBuild(fmt.Sprintf("metadata keys must have between 1 and %d characters", MaxMetadataKeyLength), http.StatusBadRequest, BadRequest)
+		}
+		if len(v) > MaxMetadataValueLength {
+			return responseBuild(fmt.Sprintf("metadata values must have at most %d characters", MaxMetadataValueLength), http.StatusBadRequest, BadRequest)
+		}
+	}
+
+	return nil
+}
+
+// validateCurrency only checks the ISO 4217 shape; the app knows which codes exist.
+func validateCurrency(currency string) *BalanceErrorResponse {
+	if currency == "" {
+		return nil
+	}
+
+	if len(currency) != 3 || strings.ToUpper(currency) != currency || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return codeResponseBuild(app.CurrencyInvalid, "currency must be an ISO 4217 code such as BRL", http.StatusBadRequest, BadRequest)
+	}
+
+	return nil
+}
+
 func buildBalanceRequest(a []byte) (*BalanceRequest, *BalanceErrorResponse) {
 	va := &BalanceRequest{}
 
@@ -76,18 +166,42 @@ func buildBalanceRequest(a []byte) (*BalanceRequest, *BalanceErrorResponse) {
 		return nil, responseBuild("external_key is missing or null", http.StatusBadRequest, BadRequest)
 	}
 
+	if strings.Contains(stringValue(va.ExternalKey), "#") {
+		return nil, responseBuild("external_key must not contain #", http.StatusBadRequest, BadRequest)
+	}
+
 	if va.OperationType == nil || stringValue(va.OperationType) == "" {
 		return nil, responseBuild("operation_type is missing or null", http.StatusBadRequest, BadRequest)
 	}
 
-	if va.Amount == nil || intValue(va.Amount) == 0 {
+	if strings.Contains(stringValue(va.OperationType), "#") || strings.Contains(stringValue(va.Origin), "#") {
+		return nil, responseBuild("operation_type and origin must not contain #", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.OriginalExternalKey != nil {
+		if stringValue(va.OriginalExternalKey) == "" {
+			return nil, responseBuild("original_external_key is null", http.StatusBadRequest, BadRequest)
+		}
+
+		if intValue(va.Amount) < 0 {
+			return nil, responseBuild("amount must be positive", http.StatusBadRequest, BadRequest)
+		}
+	} else if va.Amount == nil || intValue(va.Amount) == 0 {
 		return nil, responseBuild("amount is missing or 0", http.StatusBadRequest, BadRequest)
 	}
 
+	if errorResponse := validateCurrency(stringValue(va.Currency)); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	if errorResponse := validateMetadata(stringValue(va.Description), va.Metadata); errorResponse != nil {
+		return nil, errorResponse
+	}
+
 	return va, nil
 }
 
-func balanceWithContext(ctx context.Context, body io.ReadCloser, log Logger, a app.Balance) (*BalanceErrorResponse, error) {
+func balanceWithContext(ctx context.Context, requestID string, body io.ReadCloser, log Logger, a app.Balance) (*BalanceResponse, *BalanceErrorResponse, error) {
 	defer body.Close()
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(body)
@@ -96,24 +210,149 @@ func balanceWithContext(ctx context.Context, body io.ReadCloser, log Logger, a a
 	request, errorResponse := buildBalanceRequest(b)
 
 	if errorResponse != nil {
-		return errorResponse, nil
+		return nil, errorResponse, nil
 	}
 
 	i := &app.SettlementInput{
-		AccountKey:    stringValue(request.AccountKey),
-		ExternalKey:   stringValue(request.ExternalKey),
-		OperationType: stringValue(request.OperationType),
-		Amount:        intValue(request.Amount),
+		AccountKey:          stringValue(request.AccountKey),
+		ExternalKey:         stringValue(request.ExternalKey),
+		OperationType:       stringValue(request.OperationType),
+		Amount:              intValue(request.Amount),
+		Currency:            stringValue(request.Currency),
+		Installments:        intValue(request.Installments),
+		OriginalExternalKey: stringValue(request.OriginalExternalKey),
+		Origin:              stringValue(request.Origin),
+		RequestID:           requestID,
+		Description:         stringValue(request.Description),
+		Metadata:            request.Metadata,
+		RiskScore:           intValue(request.RiskScore),
+	}
+	if request.RequestID != nil && stringValue(request.RequestID) != "" {
+		i.RequestID = stringValue(request.RequestID)
 	}
 
 	res, err := a.SettlementWithContext(ctx, i)
 
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error && res.Code == app.IdempotencyKeyReused {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.ItemAlreadyExists {
+		return nil, responseBuild(res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.InsufficientFunds {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.OriginalNotFound {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusNotFound, NotFound), nil
+	}
+
+	if res != nil && res.Error && (res.Code == app.ReversalInvalid || res.Code == app.ReversalExceedsOriginal) {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.InstallmentsInvalid {
+		return nil, responseBuild(res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.CurrencyInvalid {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	return &BalanceResponse{
+		AccountKey:          i.AccountKey,
+		ExternalKey:         i.ExternalKey,
+		OperationType:       i.OperationType,
+		Amount:              res.Amount,
+		Currency:            res.Currency,
+		Installments:        i.Installments,
+		OriginalExternalKey: i.OriginalExternalKey,
+		CreatedAt:           res.CreatedAt,
+		RequestID:           res.RequestID,
+	}, nil, nil
+}
+
+func getBalanceWithContext(ctx context.Context, accountKey string, currency string, log Logger, a app.Balance) (*BalanceGetResponse, *BalanceErrorResponse, error) {
+	if errorResponse := validateCurrency(currency); errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	i := &app.GetBalanceInput{
+		AccountKey: accountKey,
+		Currency:   currency,
+	}
+
+	res, err := a.GetBalanceWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil {
+		return &BalanceGetResponse{
+			AccountKey:       res.AccountKey,
+			Currency:         res.Currency,
+			Exponent:         res.Exponent,
+			AvailableBalance: res.AvailableBalance,
+			HeldAmount:       res.HeldAmount,
+			LedgerBalance:    res.LedgerBalance,
+			TotalCredits:     res.TotalCredits,
+			TotalDebits:      res.TotalDebits,
+			EntryCount:       res.EntryCount,
+		}, nil, nil
+	}
+
+	return nil, nil, nil
+}
+
+func overdraftLimitWithContext(ctx context.Context, accountKey string, body io.ReadCloser, log Logger, a app.Balance) (*BalanceErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request := &OverdraftLimitRequest{}
+	err := json.Unmarshal(b, &request)
+	if err != nil {
+		return responseBuild("invalid payload", http.StatusBadRequest, BadRequest), nil
+	}
+
+	if request.OverdraftLimit == nil {
+		return responseBuild("overdraft_limit is missing or null", http.StatusBadRequest, BadRequest), nil
+	}
+
+	if errorResponse := validateCurrency(stringValue(request.Currency)); errorResponse != nil {
+		return errorResponse, nil
+	}
+
+	i := &app.SetOverdraftLimitInput{
+		AccountKey:     accountKey,
+		Currency:       stringValue(request.Currency),
+		OverdraftLimit: intValue(request.OverdraftLimit),
+	}
+
+	res, err := a.SetOverdraftLimitWithContext(ctx, i)
+
 	if err != nil {
 		return nil, err
 	}
 
-	if res != nil && res.Error && res.Code == "item-already-exists" {
-		return responseBuild(res.Detail, http.StatusConflict, Conflict), nil
+	if res != nil && res.Error && res.Code == app.OverdraftLimitInvalid {
+		return responseBuild(res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.CurrencyInvalid {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
 	}
 
 	return nil, nil
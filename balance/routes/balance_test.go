@@ -9,6 +9,7 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 )
 
 type accreditationMock struct {
@@ -33,8 +34,188 @@ func (r *accreditationMock) SettlementWithContext(ctx context.Context, input *ap
 		}, nil
 	}
 
+	if input.AccountKey == "12345678" {
+		return &app.SettlementOutput{
+			Error:  true,
+			Code:   "insufficient-funds",
+			Detail: "test3",
+		}, nil
+	}
+
+	if input.AccountKey == "404" {
+		return &app.SettlementOutput{
+			Error:  true,
+			Code:   "original-not-found",
+			Detail: "test5",
+		}, nil
+	}
+
+	if input.AccountKey == "422" {
+		return &app.SettlementOutput{
+			Error:  true,
+			Code:   "reversal-exceeds-original",
+			Detail: "test6",
+		}, nil
+	}
+
+	if input.AccountKey == "123456789" {
+		return &app.SettlementOutput{
+			Error:  true,
+			Code:   "installments-invalid",
+			Detail: "test4",
+		}, nil
+	}
+
+	if input.AccountKey == "409" {
+		return &app.SettlementOutput{
+			Error:  true,
+			Code:   "idempotency-key-reused",
+			Detail: "test7",
+		}, nil
+	}
+
+	return &app.SettlementOutput{
+		Amount:    input.Amount,
+		CreatedAt: time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		RequestID: input.RequestID,
+	}, nil
+}
+func (r *accreditationMock) GetBalanceWithContext(ctx context.Context, input *app.GetBalanceInput) (*app.GetBalanceOutput, error) {
+	if r.v == "1" {
+		return nil, errors.New("get balance error")
+	}
+	val, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(val))
+	if input.Currency == "XXX" {
+		return &app.GetBalanceOutput{
+			Error:  true,
+			Code:   "currency-invalid",
+			Detail: "currency XXX is not supported",
+		}, nil
+	}
+	return &app.GetBalanceOutput{
+		AccountKey:       input.AccountKey,
+		Currency:         "BRL",
+		Exponent:         2,
+		AvailableBalance: 700,
+		HeldAmount:       200,
+		LedgerBalance:    900,
+		TotalCredits:     1000,
+		TotalDebits:      300,
+		EntryCount:       2,
+	}, nil
+}
+func (r *accreditationMock) SetOverdraftLimitWithContext(ctx context.Context, input *app.SetOverdraftLimitInput) (*app.SetOverdraftLimitOutput, error) {
+	val, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(val))
+
+	if input.AccountKey == "12345" {
+		return nil, errors.New("overdraft limit error")
+	}
+
+	if input.OverdraftLimit < 0 {
+		return &app.SetOverdraftLimitOutput{
+			Error:  true,
+			Code:   "overdraft-limit-invalid",
+			Detail: "test",
+		}, nil
+	}
+
+	return &app.SetOverdraftLimitOutput{}, nil
+}
+func (r *accreditationMock) RebuildSummariesWithContext(ctx context.Context, input *app.RebuildSummariesInput) (*app.RebuildSummariesOutput, error) {
 	return nil, nil
 }
+func (r *accreditationMock) SummariesRebuiltWithContext(ctx context.Context) (bool, error) {
+	return true, nil
+}
+func (r *accreditationMock) PostDueInstallmentsWithContext(ctx context.Context, input *app.PostDueInstallmentsInput) (*app.PostDueInstallmentsOutput, error) {
+	return nil, nil
+}
+func (r *accreditationMock) ListTransactionsWithContext(ctx context.Context, input *app.ListTransactionsInput) (*app.ListTransactionsOutput, error) {
+	vt, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(vt))
+
+	if input.AccountKey == "12345" {
+		return nil, errors.New("list transactions error")
+	}
+
+	if input.Cursor == "invalid" {
+		return &app.ListTransactionsOutput{
+			Error:  true,
+			Code:   "cursor-invalid",
+			Detail: "cursor is invalid",
+		}, nil
+	}
+
+	return &app.ListTransactionsOutput{
+		Transactions: []*app.Entry{
+			{AccountKey: input.AccountKey, ExternalKey: "1", OperationType: "Payment", Amount: 1000, CreatedAt: time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)},
+		},
+		NextCursor: "next",
+	}, nil
+}
+func (r *accreditationMock) GetTransactionWithContext(ctx context.Context, input *app.GetTransactionInput) (*app.GetTransactionOutput, error) {
+	vt, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(vt))
+
+	switch input.ExternalKey {
+	case "500":
+		return nil, errors.New("get transaction error")
+	case "404":
+		return &app.GetTransactionOutput{Error: true, Code: "transaction-not-found", Detail: "transaction not found"}, nil
+	}
+
+	return &app.GetTransactionOutput{
+		Transaction: &app.Entry{AccountKey: input.AccountKey, ExternalKey: input.ExternalKey, OperationType: "InstallmentBuying", Amount: -334, Currency: "BRL", InstallmentCount: 3, Origin: input.Origin, CreatedAt: time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)},
+	}, nil
+}
+func (r *accreditationMock) GetUsageWithContext(ctx context.Context, input *app.GetUsageInput) (*app.GetUsageOutput, error) {
+	vt, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(vt))
+
+	switch input.AccountKey {
+	case "500":
+		return nil, errors.New("get usage error")
+	case "400":
+		return &app.GetUsageOutput{Error: true, Code: "currency-invalid", Detail: "currency XX is not supported"}, nil
+	}
+
+	return &app.GetUsageOutput{
+		Currency: "BRL",
+		Amount:   2500,
+		Count:    2,
+	}, nil
+}
+func (r *accreditationMock) TransferWithContext(ctx context.Context, input *app.TransferInput) (*app.TransferOutput, error) {
+	vt, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(vt))
+
+	switch input.FromAccountKey {
+	case "12345":
+		return nil, errors.New("transfer error")
+	case "404":
+		return &app.TransferOutput{Error: true, Code: "account-not-found", Detail: "account 404 not found"}, nil
+	case "422":
+		return &app.TransferOutput{Error: true, Code: "insufficient-funds", Detail: "insufficient funds"}, nil
+	case "closed":
+		return &app.TransferOutput{Error: true, Code: "account-closed", Detail: "account closed is not active"}, nil
+	case "409":
+		return &app.TransferOutput{Error: true, Code: "idempotency-key-reused", Detail: "transfer_key was already used for a different request"}, nil
+	}
+
+	return &app.TransferOutput{
+		TransferID: "id",
+		CreatedAt:  time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		RequestID:  input.RequestID,
+	}, nil
+}
 func newAccreditationMock(v string, t *testing.T) app.Balance {
 	return &accreditationMock{
 		v: v,
@@ -53,17 +234,20 @@ func newLogMock() Logger {
 func TestRoutes_Settlement(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"credit\", \"amount\": 1000}"))
-	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"1234\",\"OperationType\":\"credit\",\"Amount\":1000}", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"1234\",\"OperationType\":\"credit\",\"Amount\":1000,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	o, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	assert.Nil(t, res)
+	v, err := json.Marshal(o)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"account_key\":\"123\",\"external_key\":\"1234\",\"operation_type\":\"credit\",\"amount\":1000,\"currency\":\"\",\"created_at\":\"2022-01-31T10:00:00Z\"}", string(v))
 }
 
 func TestRoutes_NotTestRoutes_SettlementWhenInvalidPayload(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader(""))
 	accreditation := newAccreditationMock("", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -75,7 +259,7 @@ func TestRoutes_NotSettlementWhenAccountKeyEmpty(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"\", \"external_key\": \"1234\", \"operation_type\": \"credit\", \"amount\": 1000}"))
 	accreditation := newAccreditationMock("", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -87,7 +271,7 @@ func TestRoutes_NotSettlementWhenAccountKeyMissing(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"external_key\": \"1234\", \"operation_type\": \"credit\", \"amount\": 1000}"))
 	accreditation := newAccreditationMock("", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -99,7 +283,7 @@ func TestRoutes_NotSettlementWhenExternalKeyEmpty(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"\", \"operation_type\": \"credit\", \"amount\": 1000}"))
 	accreditation := newAccreditationMock("", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -111,7 +295,7 @@ func TestRoutes_NotSettlementWhenExternalKeyMissing(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"operation_type\": \"credit\", \"amount\": 1000}"))
 	accreditation := newAccreditationMock("", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -123,7 +307,7 @@ func TestRoutes_NotSettlementWhenOperationTypeEmpty(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"\", \"amount\": 1000}"))
 	accreditation := newAccreditationMock("", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -135,7 +319,7 @@ func TestRoutes_NotSettlementWhenOperationTypeMissing(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"amount\": 1000}"))
 	accreditation := newAccreditationMock("", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -147,7 +331,7 @@ func TestRoutes_NotSettlementWhenAmountZero(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"credit\", \"amount\": 0}"))
 	accreditation := newAccreditationMock("", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -155,11 +339,22 @@ func TestRoutes_NotSettlementWhenAmountZero(t *testing.T) {
 	assert.Equal(t, expected, string(validate))
 }
 
+func TestRoutes_NotSettlementWhenCurrencyInvalid(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"credit\", \"amount\": 100, \"currency\": \"usd\"}"))
+	accreditation := newAccreditationMock("", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"currency-invalid\",\"message\":\"currency must be an ISO 4217 code such as BRL\"}}", string(validate))
+}
+
 func TestRoutes_NotSettlementWhenAmountMissing(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"credit\"}"))
 	accreditation := newAccreditationMock("", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -170,8 +365,8 @@ func TestRoutes_NotSettlementWhenAmountMissing(t *testing.T) {
 func TestRoutes_NotBalanceWhenSettlementError(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"12345\", \"external_key\": \"1234\", \"operation_type\": \"credit\", \"amount\": 1000}"))
-	accreditation := newAccreditationMock("{\"AccountKey\":\"12345\",\"ExternalKey\":\"1234\",\"OperationType\":\"credit\",\"Amount\":1000}", t)
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	accreditation := newAccreditationMock("{\"AccountKey\":\"12345\",\"ExternalKey\":\"1234\",\"OperationType\":\"credit\",\"Amount\":1000,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, res)
 	assert.Equal(t, "settlement error", err.Error())
 }
@@ -179,9 +374,9 @@ func TestRoutes_NotBalanceWhenSettlementError(t *testing.T) {
 func TestRoutes_NotBalanceWhenSettlementItemAlreadyExists(t *testing.T) {
 	l := newLogMock()
 	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"1234567\", \"external_key\": \"1234\", \"operation_type\": \"credit\", \"amount\": 1000}"))
-	accreditation := newAccreditationMock("{\"AccountKey\":\"1234567\",\"ExternalKey\":\"1234\",\"OperationType\":\"credit\",\"Amount\":1000}", t)
+	accreditation := newAccreditationMock("{\"AccountKey\":\"1234567\",\"ExternalKey\":\"1234\",\"OperationType\":\"credit\",\"Amount\":1000,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
 
-	res, err := balanceWithContext(context.Background(), rc, l, accreditation)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
 	assert.Nil(t, err)
 	validate, err := json.Marshal(res)
 	assert.Nil(t, err)
@@ -189,6 +384,18 @@ func TestRoutes_NotBalanceWhenSettlementItemAlreadyExists(t *testing.T) {
 	assert.Equal(t, expected, string(validate))
 }
 
+func TestRoutes_NotSettlementWhenIdempotencyKeyReused(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"409\", \"external_key\": \"1234\", \"operation_type\": \"credit\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"409\",\"ExternalKey\":\"1234\",\"OperationType\":\"credit\",\"Amount\":1000,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"conflict\",\"code\":\"idempotency-key-reused\",\"message\":\"test7\"}}", string(validate))
+}
+
 func TestRoutes_StringValueWhenNilValue(t *testing.T) {
 	r := stringValue(nil)
 	assert.Equal(t, "", r)
@@ -198,3 +405,226 @@ func TestRoutes_IntValueWhenNilValue(t *testing.T) {
 	r := intValue(nil)
 	assert.Equal(t, 0, r)
 }
+
+func TestRoutes_GetBalance(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"Currency\":\"\"}", t)
+	res, errorResponse, err := getBalanceWithContext(context.Background(), "123", "", l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	val, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"account_key\":\"123\",\"currency\":\"BRL\",\"exponent\":2,\"available_balance\":700,\"held_amount\":200,\"ledger_balance\":900,\"total_credits\":1000,\"total_debits\":300,\"entry_count\":2}", string(val))
+}
+
+func TestRoutes_GetBalanceInCurrency(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"Currency\":\"XXX\"}", t)
+	res, errorResponse, err := getBalanceWithContext(context.Background(), "123", "XXX", l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	val, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"currency-invalid\",\"message\":\"currency XXX is not supported\"}}", string(val))
+}
+
+func TestRoutes_NotGetBalanceWhenError(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("1", t)
+	res, _, err := getBalanceWithContext(context.Background(), "123", "", l, accreditation)
+	assert.Nil(t, res)
+	assert.Equal(t, "get balance error", err.Error())
+}
+
+func TestRoutes_NotBalanceWhenSettlementInsufficientFunds(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"12345678\", \"external_key\": \"1234\", \"operation_type\": \"Withdraw\", \"amount\": -1000}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"12345678\",\"ExternalKey\":\"1234\",\"OperationType\":\"Withdraw\",\"Amount\":-1000,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	expected := "{\"error\":{\"type\":\"invalid_request\",\"category\":\"unprocessable_entity\",\"code\":\"insufficient-funds\",\"message\":\"test3\"}}"
+	assert.Equal(t, expected, string(validate))
+	assert.Equal(t, 422, res.Error.StatusCode)
+}
+
+func TestRoutes_OverdraftLimit(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"overdraft_limit\": 500}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"Currency\":\"\",\"OverdraftLimit\":500}", t)
+	res, err := overdraftLimitWithContext(context.Background(), "123", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRoutes_NotOverdraftLimitWhenMissing(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{}"))
+	accreditation := newAccreditationMock("", t)
+	res, err := overdraftLimitWithContext(context.Background(), "123", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	expected := "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"overdraft_limit is missing or null\"}}"
+	assert.Equal(t, expected, string(validate))
+}
+
+func TestRoutes_NotOverdraftLimitWhenInvalid(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"overdraft_limit\": -1}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"Currency\":\"\",\"OverdraftLimit\":-1}", t)
+	res, err := overdraftLimitWithContext(context.Background(), "123", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	expected := "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"test\"}}"
+	assert.Equal(t, expected, string(validate))
+}
+
+func TestRoutes_NotOverdraftLimitWhenError(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"overdraft_limit\": 500}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"12345\",\"Currency\":\"\",\"OverdraftLimit\":500}", t)
+	res, err := overdraftLimitWithContext(context.Background(), "12345", rc, l, accreditation)
+	assert.Nil(t, res)
+	assert.Equal(t, "overdraft limit error", err.Error())
+}
+
+func TestRoutes_SettlementWithInstallments(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"InstallmentBuying\", \"amount\": -1000, \"installments\": 3}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"1234\",\"OperationType\":\"InstallmentBuying\",\"Amount\":-1000,\"Currency\":\"\",\"Installments\":3,\"OriginalExternalKey\":\"\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRoutes_NotSettlementWhenExternalKeyHasHash(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234#02\", \"operation_type\": \"credit\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	expected := "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"external_key must not contain #\"}}"
+	assert.Equal(t, expected, string(validate))
+}
+
+func TestRoutes_NotSettlementWhenInstallmentsInvalid(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123456789\", \"external_key\": \"1234\", \"operation_type\": \"InstallmentBuying\", \"amount\": -1000, \"installments\": 30}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123456789\",\"ExternalKey\":\"1234\",\"OperationType\":\"InstallmentBuying\",\"Amount\":-1000,\"Currency\":\"\",\"Installments\":30,\"OriginalExternalKey\":\"\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	expected := "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"test4\"}}"
+	assert.Equal(t, expected, string(validate))
+}
+
+func TestRoutes_SettlementReversal(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"r1\", \"operation_type\": \"Reversal\", \"original_external_key\": \"1234\"}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"r1\",\"OperationType\":\"Reversal\",\"Amount\":0,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"1234\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRoutes_NotSettlementReversalWhenAmountNegative(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"r1\", \"operation_type\": \"Reversal\", \"original_external_key\": \"1234\", \"amount\": -100}"))
+	accreditation := newAccreditationMock("", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"amount must be positive\"}}", string(validate))
+}
+
+func TestRoutes_NotSettlementReversalWhenOriginalNotFound(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"404\", \"external_key\": \"r1\", \"operation_type\": \"Reversal\", \"original_external_key\": \"1234\"}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"404\",\"ExternalKey\":\"r1\",\"OperationType\":\"Reversal\",\"Amount\":0,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"1234\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"code\":\"original-not-found\",\"message\":\"test5\"}}", string(validate))
+	assert.Equal(t, 404, res.Error.StatusCode)
+}
+
+func TestRoutes_NotSettlementReversalWhenExceedsOriginal(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"422\", \"external_key\": \"r1\", \"operation_type\": \"Reversal\", \"original_external_key\": \"1234\", \"amount\": 5000}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"422\",\"ExternalKey\":\"r1\",\"OperationType\":\"Reversal\",\"Amount\":5000,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"1234\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"unprocessable_entity\",\"code\":\"reversal-exceeds-original\",\"message\":\"test6\"}}", string(validate))
+	assert.Equal(t, 422, res.Error.StatusCode)
+}
+
+func TestRoutes_SettlementWithMetadata(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"Payment\", \"amount\": 1000, \"origin\": \"credit\", \"request_id\": \"req-1\", \"description\": \"salary\", \"metadata\": {\"order_id\": \"42\"}}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"1234\",\"OperationType\":\"Payment\",\"Amount\":1000,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Origin\":\"credit\",\"RequestID\":\"req-1\",\"Description\":\"salary\",\"Metadata\":{\"order_id\":\"42\"},\"RiskScore\":0}", t)
+	_, res, err := balanceWithContext(context.Background(), "header-id", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRoutes_SettlementWithRiskScore(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"Buying\", \"amount\": -1000, \"origin\": \"debit\", \"risk_score\": 85}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"1234\",\"OperationType\":\"Buying\",\"Amount\":-1000,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Origin\":\"debit\",\"RequestID\":\"header-id\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":85}", t)
+	_, res, err := balanceWithContext(context.Background(), "header-id", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRoutes_SettlementWithRequestIDFromHeader(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"Payment\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"1234\",\"OperationType\":\"Payment\",\"Amount\":1000,\"Currency\":\"\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Origin\":\"\",\"RequestID\":\"header-id\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	_, res, err := balanceWithContext(context.Background(), "header-id", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRoutes_NotSettlementWhenMetadataTooLarge(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"Payment\", \"amount\": 1000, \"metadata\": {\"\": \"42\"}}"))
+	accreditation := newAccreditationMock("", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"metadata keys must have between 1 and 64 characters\"}}", string(validate))
+}
+
+func TestRoutes_NotSettlementWhenDescriptionTooLong(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"Payment\", \"amount\": 1000, \"description\": \"" + strings.Repeat("a", 256) + "\"}"))
+	accreditation := newAccreditationMock("", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"description must have at most 255 characters\"}}", string(validate))
+}
+
+func TestRoutes_NotSettlementWhenOriginHasHash(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"123\", \"external_key\": \"1234\", \"operation_type\": \"Payment\", \"amount\": 1000, \"origin\": \"credit#Payment\"}"))
+	accreditation := newAccreditationMock("", t)
+	_, res, err := balanceWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"operation_type and origin must not contain #\"}}", string(validate))
+}
@@ -0,0 +1,248 @@
+package routes
+
+import (
+	"balance/app"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type HoldRequest struct {
+	AccountKey    *string `json:"account_key,omitempty"`
+	ExternalKey   *string `json:"external_key,omitempty"`
+	OperationType *string `json:"operation_type,omitempty"`
+	Amount        *int    `json:"amount,omitempty"`
+	Currency      *string `json:"currency,omitempty"`
+
+	Origin      *string           `json:"origin,omitempty"`
+	RequestID   *string           `json:"request_id,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	RiskScore   *int              `json:"risk_score,omitempty"`
+}
+
+type CaptureHoldRequest struct {
+	Amount *int `json:"amount,omitempty"`
+
+	RequestID   *string           `json:"request_id,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type HoldResponse struct {
+	AccountKey     string    `json:"account_key"`
+	ExternalKey    string    `json:"external_key"`
+	OperationType  string    `json:"operation_type"`
+	Status         string    `json:"status"`
+	Amount         int       `json:"amount"`
+	Currency       string    `json:"currency"`
+	CapturedAmount int       `json:"captured_amount,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	RequestID      string    `json:"request_id,omitempty"`
+	RiskScore      int       `json:"risk_score,omitempty"`
+}
+
+func buildHoldRequest(a []byte) (*HoldRequest, *BalanceErrorResponse) {
+	va := &HoldRequest{}
+
+	err := json.Unmarshal(a, &va)
+	if err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.AccountKey == nil || stringValue(va.AccountKey) == "" {
+		return nil, responseBuild("account_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.ExternalKey == nil || stringValue(va.ExternalKey) == "" {
+		return nil, responseBuild("external_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if strings.Contains(stringValue(va.ExternalKey), "#") || strings.Contains(stringValue(va.ExternalKey), "/") {
+		return nil, responseBuild("external_key must not contain # or /", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.OperationType == nil || stringValue(va.OperationType) == "" {
+		return nil, responseBuild("operation_type is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if strings.Contains(stringValue(va.OperationType), "#") || strings.Contains(stringValue(va.Origin), "#") {
+		return nil, responseBuild("operation_type and origin must not contain #", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.Amount == nil || intValue(va.Amount) == 0 {
+		return nil, responseBuild("amount is missing or 0", http.StatusBadRequest, BadRequest)
+	}
+
+	if errorResponse := validateCurrency(stringValue(va.Currency)); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	if errorResponse := validateMetadata(stringValue(va.Description), va.Metadata); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	return va, nil
+}
+
+func holdResponseBuild(res *app.HoldOutput) *BalanceErrorResponse {
+	if res.Code == app.HoldInvalid {
+		return responseBuild(res.Detail, http.StatusBadRequest, BadRequest)
+	}
+
+	if res.Code == app.CurrencyInvalid {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest)
+	}
+
+	if res.Code == app.IdempotencyKeyReused {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict)
+	}
+
+	if res.Code == app.HoldNotFound {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusNotFound, NotFound)
+	}
+
+	return codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity)
+}
+
+func holdResponse(accountKey string, externalKey string, res *app.HoldOutput) *HoldResponse {
+	return &HoldResponse{
+		AccountKey:     accountKey,
+		ExternalKey:    externalKey,
+		OperationType:  res.OperationType,
+		Status:         res.Status,
+		Amount:         res.Amount,
+		Currency:       res.Currency,
+		CapturedAmount: res.CapturedAmount,
+		CreatedAt:      res.CreatedAt,
+		ExpiresAt:      res.ExpiresAt,
+		RequestID:      res.RequestID,
+		RiskScore:      res.RiskScore,
+	}
+}
+
+func holdWithContext(ctx context.Context, requestID string, body io.ReadCloser, log Logger, a app.Balance) (*HoldResponse, *BalanceErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request, errorResponse := buildHoldRequest(b)
+
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	i := &app.HoldInput{
+		AccountKey:    stringValue(request.AccountKey),
+		ExternalKey:   stringValue(request.ExternalKey),
+		OperationType: stringValue(request.OperationType),
+		Amount:        intValue(request.Amount),
+		Currency:      stringValue(request.Currency),
+		Origin:        stringValue(request.Origin),
+		RequestID:     requestID,
+		Description:   stringValue(request.Description),
+		Metadata:      request.Metadata,
+		RiskScore:     intValue(request.RiskScore),
+	}
+	if request.RequestID != nil && stringValue(request.RequestID) != "" {
+		i.RequestID = stringValue(request.RequestID)
+	}
+
+	res, err := a.HoldWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, holdResponseBuild(res), nil
+	}
+
+	return holdResponse(i.AccountKey, i.ExternalKey, res), nil, nil
+}
+
+func getHoldWithContext(ctx context.Context, accountKey string, externalKey string, log Logger, a app.Balance) (*HoldResponse, *BalanceErrorResponse, error) {
+	i := &app.GetHoldInput{
+		AccountKey:  accountKey,
+		ExternalKey: externalKey,
+	}
+
+	res, err := a.GetHoldWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, holdResponseBuild(res), nil
+	}
+
+	return holdResponse(accountKey, externalKey, res), nil, nil
+}
+
+func captureHoldWithContext(ctx context.Context, requestID string, accountKey string, externalKey string, body io.ReadCloser, log Logger, a app.Balance) (*HoldResponse, *BalanceErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request := &CaptureHoldRequest{}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &request); err != nil {
+			return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest), nil
+		}
+	}
+
+	if errorResponse := validateMetadata(stringValue(request.Description), request.Metadata); errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	i := &app.CaptureHoldInput{
+		AccountKey:  accountKey,
+		ExternalKey: externalKey,
+		Amount:      intValue(request.Amount),
+		RequestID:   requestID,
+		Description: stringValue(request.Description),
+		Metadata:    request.Metadata,
+	}
+	if request.RequestID != nil && stringValue(request.RequestID) != "" {
+		i.RequestID = stringValue(request.RequestID)
+	}
+
+	res, err := a.CaptureHoldWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, holdResponseBuild(res), nil
+	}
+
+	return holdResponse(accountKey, externalKey, res), nil, nil
+}
+
+func voidHoldWithContext(ctx context.Context, accountKey string, externalKey string, log Logger, a app.Balance) (*HoldResponse, *BalanceErrorResponse, error) {
+	i := &app.VoidHoldInput{
+		AccountKey:  accountKey,
+		ExternalKey: externalKey,
+	}
+
+	res, err := a.VoidHoldWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, holdResponseBuild(res), nil
+	}
+
+	return holdResponse(accountKey, externalKey, res), nil, nil
+}
@@ -0,0 +1,210 @@
+package routes
+
+import (
+	"balance/app"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func holdMockOutput(accountKey string, status string, captured int, requestID string) (*app.HoldOutput, error) {
+	switch accountKey {
+	case "500":
+		return nil, errors.New("hold error")
+	case "404":
+		return &app.HoldOutput{Error: true, Code: app.HoldNotFound, Detail: "hold not found"}, nil
+	case "422":
+		return &app.HoldOutput{Error: true, Code: app.InsufficientFunds, Detail: "insufficient funds"}, nil
+	case "exceeds":
+		return &app.HoldOutput{Error: true, Code: app.CaptureExceedsHold, Detail: "capture amount must be at most the held amount 1000"}, nil
+	case "expired":
+		return &app.HoldOutput{Error: true, Code: app.HoldHasExpired, Detail: "hold expired"}, nil
+	}
+
+	return &app.HoldOutput{
+		OperationType:  "Buying",
+		Status:         status,
+		Amount:         1000,
+		CapturedAmount: captured,
+		CreatedAt:      time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		ExpiresAt:      time.Date(2022, 2, 7, 10, 0, 0, 0, time.UTC),
+		RequestID:      requestID,
+	}, nil
+}
+
+func (r *accreditationMock) HoldWithContext(ctx context.Context, input *app.HoldInput) (*app.HoldOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	return holdMockOutput(input.AccountKey, app.HoldActive, 0, input.RequestID)
+}
+func (r *accreditationMock) GetHoldWithContext(ctx context.Context, input *app.GetHoldInput) (*app.HoldOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	o, err := holdMockOutput(input.AccountKey, app.HoldActive, 0, "req-1")
+	if o != nil && !o.Error {
+		o.RiskScore = 40
+	}
+	return o, err
+}
+func (r *accreditationMock) CaptureHoldWithContext(ctx context.Context, input *app.CaptureHoldInput) (*app.HoldOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	return holdMockOutput(input.AccountKey, app.HoldCaptured, input.Amount, input.RequestID)
+}
+func (r *accreditationMock) VoidHoldWithContext(ctx context.Context, input *app.VoidHoldInput) (*app.HoldOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(r.t, err)
+	assert.Equal(r.t, r.v, string(v))
+	return holdMockOutput(input.AccountKey, app.HoldVoided, 0, "")
+}
+func (r *accreditationMock) ReleaseExpiredHoldsWithContext(ctx context.Context, input *app.ReleaseExpiredHoldsInput) (*app.ReleaseExpiredHoldsOutput, error) {
+	return &app.ReleaseExpiredHoldsOutput{}, nil
+}
+
+func TestRoutes_Hold(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"1\", \"external_key\": \"a1\", \"operation_type\": \"Buying\", \"amount\": 1000, \"origin\": \"debit\", \"risk_score\": 20}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"1\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"\",\"Origin\":\"debit\",\"RequestID\":\"req-1\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":20}", t)
+	o, res, err := holdWithContext(context.Background(), "req-1", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	v, err := json.Marshal(o)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"account_key\":\"1\",\"external_key\":\"a1\",\"operation_type\":\"Buying\",\"status\":\"active\",\"amount\":1000,\"currency\":\"\",\"created_at\":\"2022-01-31T10:00:00Z\",\"expires_at\":\"2022-02-07T10:00:00Z\",\"request_id\":\"req-1\"}", string(v))
+}
+
+func TestRoutes_NotHoldWhenOperationTypeMissing(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"1\", \"external_key\": \"a1\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("", t)
+	_, res, err := holdWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"operation_type is missing or null\"}}", string(v))
+}
+
+func TestRoutes_NotHoldWhenExternalKeyHasSlash(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"1\", \"external_key\": \"a/1\", \"operation_type\": \"Buying\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("", t)
+	_, res, err := holdWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"external_key must not contain # or /\"}}", string(v))
+}
+
+func TestRoutes_NotHoldWhenInsufficientFunds(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"422\", \"external_key\": \"a1\", \"operation_type\": \"Buying\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"422\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	_, res, err := holdWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"unprocessable_entity\",\"code\":\"insufficient-funds\",\"message\":\"insufficient funds\"}}", string(v))
+}
+
+func TestRoutes_NotHoldWhenError(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"account_key\": \"500\", \"external_key\": \"a1\", \"operation_type\": \"Buying\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"500\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"\",\"Origin\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":0}", t)
+	o, res, err := holdWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, o)
+	assert.Nil(t, res)
+	assert.Equal(t, "hold error", err.Error())
+}
+
+func TestRoutes_GetHold(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"1\",\"ExternalKey\":\"a1\"}", t)
+	o, res, err := getHoldWithContext(context.Background(), "1", "a1", l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	v, err := json.Marshal(o)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"account_key\":\"1\",\"external_key\":\"a1\",\"operation_type\":\"Buying\",\"status\":\"active\",\"amount\":1000,\"currency\":\"\",\"created_at\":\"2022-01-31T10:00:00Z\",\"expires_at\":\"2022-02-07T10:00:00Z\",\"request_id\":\"req-1\",\"risk_score\":40}", string(v))
+}
+
+func TestRoutes_NotGetHoldWhenNotFound(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"404\",\"ExternalKey\":\"a1\"}", t)
+	_, res, err := getHoldWithContext(context.Background(), "404", "a1", l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"code\":\"hold-not-found\",\"message\":\"hold not found\"}}", string(v))
+}
+
+func TestRoutes_CaptureHold(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"amount\": 600}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"1\",\"ExternalKey\":\"a1\",\"Amount\":600,\"RequestID\":\"req-2\",\"Description\":\"\",\"Metadata\":null}", t)
+	o, res, err := captureHoldWithContext(context.Background(), "req-2", "1", "a1", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	v, err := json.Marshal(o)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"account_key\":\"1\",\"external_key\":\"a1\",\"operation_type\":\"Buying\",\"status\":\"captured\",\"amount\":1000,\"currency\":\"\",\"captured_amount\":600,\"created_at\":\"2022-01-31T10:00:00Z\",\"expires_at\":\"2022-02-07T10:00:00Z\",\"request_id\":\"req-2\"}", string(v))
+}
+
+func TestRoutes_CaptureHoldWithoutBody(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader(""))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"1\",\"ExternalKey\":\"a1\",\"Amount\":0,\"RequestID\":\"req-2\",\"Description\":\"\",\"Metadata\":null}", t)
+	_, res, err := captureHoldWithContext(context.Background(), "req-2", "1", "a1", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRoutes_NotCaptureHoldWhenNotFound(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"404\",\"ExternalKey\":\"a1\",\"Amount\":0,\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null}", t)
+	_, res, err := captureHoldWithContext(context.Background(), "", "404", "a1", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"code\":\"hold-not-found\",\"message\":\"hold not found\"}}", string(v))
+}
+
+func TestRoutes_NotCaptureHoldWhenExceedsHold(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"amount\": 1500}"))
+	accreditation := newAccreditationMock("{\"AccountKey\":\"exceeds\",\"ExternalKey\":\"a1\",\"Amount\":1500,\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null}", t)
+	_, res, err := captureHoldWithContext(context.Background(), "", "exceeds", "a1", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"unprocessable_entity\",\"code\":\"capture-exceeds-hold\",\"message\":\"capture amount must be at most the held amount 1000\"}}", string(v))
+}
+
+func TestRoutes_VoidHold(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"1\",\"ExternalKey\":\"a1\"}", t)
+	o, res, err := voidHoldWithContext(context.Background(), "1", "a1", l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	v, err := json.Marshal(o)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"account_key\":\"1\",\"external_key\":\"a1\",\"operation_type\":\"Buying\",\"status\":\"voided\",\"amount\":1000,\"currency\":\"\",\"created_at\":\"2022-01-31T10:00:00Z\",\"expires_at\":\"2022-02-07T10:00:00Z\"}", string(v))
+}
+
+func TestRoutes_NotVoidHoldWhenExpired(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"expired\",\"ExternalKey\":\"a1\"}", t)
+	_, res, err := voidHoldWithContext(context.Background(), "expired", "a1", l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"unprocessable_entity\",\"code\":\"hold-expired\",\"message\":\"hold expired\"}}", string(v))
+}
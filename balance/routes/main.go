@@ -2,10 +2,15 @@ package routes
 
 import (
 	"balance/app"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
+const RequestIDHeader = "X-Request-Id"
+
 type Routes interface {
 	Default() *http.ServeMux
 }
@@ -21,42 +26,271 @@ func healthz() http.Handler {
 	})
 }
 
+func writeResponse(w http.ResponseWriter, statusCode int, v interface{}) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(res); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// requestID returns the caller's correlation id, generating one when it is missing.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
 func balance(a app.Balance, log Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			ctx := r.Context()
-			accountResponse, err := balanceWithContext(ctx, r.Body, log, a)
+			o, errorResponse, err := balanceWithContext(ctx, requestID(r), r.Body, log, a)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
 
-			if accountResponse != nil {
-				res, err := json.Marshal(accountResponse)
-				if err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(accountResponse.Error.StatusCode)
-				w.Header().Set("Content-Type", "application/json")
-				if _, err := w.Write(res); err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
 				return
 			}
 
-			w.WriteHeader(http.StatusCreated)
+			writeResponse(w, http.StatusCreated, o)
 		} else {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	})
 }
 
+func transfers(a app.Balance, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		o, errorResponse, err := transferWithContext(r.Context(), requestID(r), r.Body, log, a)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if errorResponse != nil {
+			writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+			return
+		}
+
+		writeResponse(w, http.StatusCreated, o)
+	})
+}
+
+func accountBalance(a app.Balance, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		path := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/balance/"), "/")
+		accountKey := path[0]
+		if accountKey == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if len(path) == 1 {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+
+			o, errorResponse, err := getBalanceWithContext(ctx, accountKey, r.URL.Query().Get("currency"), log, a)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+
+			if o == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			writeResponse(w, http.StatusOK, o)
+			return
+		}
+
+		if len(path) == 2 && path[1] == "overdraft-limit" {
+			if r.Method != http.MethodPut {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+
+			errorResponse, err := overdraftLimitWithContext(ctx, accountKey, r.Body, log, a)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	})
+}
+
+func accounts(a app.Balance, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/accounts/"), "/")
+		if len(path) == 2 && path[0] != "" && path[1] == "usage" {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+
+			o, errorResponse, err := getUsageWithContext(r.Context(), path[0], r.URL.Query(), log, a)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+
+			writeResponse(w, http.StatusOK, o)
+			return
+		}
+
+		if len(path) < 2 || len(path) > 3 || path[0] == "" || path[1] != "transactions" || (len(path) == 3 && path[2] == "") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if len(path) == 3 {
+			o, errorResponse, err := getTransactionWithContext(r.Context(), path[0], path[2], r.URL.Query(), log, a)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+
+			writeResponse(w, http.StatusOK, o)
+			return
+		}
+
+		o, errorResponse, err := listTransactionsWithContext(r.Context(), path[0], r.URL.Query(), log, a)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if errorResponse != nil {
+			writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, o)
+	})
+}
+
+func holds(a app.Balance, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		o, errorResponse, err := holdWithContext(r.Context(), requestID(r), r.Body, log, a)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if errorResponse != nil {
+			writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+			return
+		}
+
+		writeResponse(w, http.StatusCreated, o)
+	})
+}
+
+func hold(a app.Balance, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/holds/"), "/")
+		if len(path) < 2 || len(path) > 3 || path[0] == "" || path[1] == "" || (len(path) == 3 && path[2] != "capture" && path[2] != "void") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if (len(path) == 2 && r.Method != http.MethodGet) || (len(path) == 3 && r.Method != http.MethodPost) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var o *HoldResponse
+		var errorResponse *BalanceErrorResponse
+		var err error
+		if len(path) == 2 {
+			o, errorResponse, err = getHoldWithContext(r.Context(), path[0], path[1], log, a)
+		} else if path[2] == "capture" {
+			o, errorResponse, err = captureHoldWithContext(r.Context(), requestID(r), path[0], path[1], r.Body, log, a)
+		} else {
+			o, errorResponse, err = voidHoldWithContext(r.Context(), path[0], path[1], log, a)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if errorResponse != nil {
+			writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, o)
+	})
+}
+
 func (r *routes) Default() *http.ServeMux {
 	middleware := http.NewServeMux()
+	middleware.Handle("/v1/balance/", accountBalance(r.balance, r.log))
 	middleware.Handle("/v1/balance", balance(r.balance, r.log))
+	middleware.Handle("/v1/accounts/", accounts(r.balance, r.log))
+	middleware.Handle("/v1/transfers", transfers(r.balance, r.log))
+	middleware.Handle("/v1/holds", holds(r.balance, r.log))
+	middleware.Handle("/v1/holds/", hold(r.balance, r.log))
 	middleware.Handle("/health", healthz())
 	return middleware
 }
@@ -0,0 +1,171 @@
+package routes
+
+import (
+	"balance/app"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+type TransactionResponse struct {
+	ExternalKey   string            `json:"external_key"`
+	OperationType string            `json:"operation_type"`
+	Amount        int               `json:"amount"`
+	Currency      string            `json:"currency"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Origin        string            `json:"origin,omitempty"`
+	RequestID     string            `json:"request_id,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	TransferID    string            `json:"transfer_id,omitempty"`
+	RiskScore     int               `json:"risk_score,omitempty"`
+}
+
+// TransactionDetailResponse adds what a single lookup needs to tell a reversal or
+// an installment purchase apart.
+type TransactionDetailResponse struct {
+	AccountKey string `json:"account_key"`
+	*TransactionResponse
+	InstallmentCount    int    `json:"installment_count,omitempty"`
+	OriginalExternalKey string `json:"original_external_key,omitempty"`
+	ReversedAmount      int    `json:"reversed_amount,omitempty"`
+}
+
+type TransactionListResponse struct {
+	AccountKey   string                 `json:"account_key"`
+	Transactions []*TransactionResponse `json:"transactions"`
+	NextCursor   string                 `json:"next_cursor,omitempty"`
+}
+
+// parseTime accepts an RFC 3339 timestamp or a plain date; a plain date used as
+// the end of the range covers the whole day.
+func parseTime(v string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(dateLayout, v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Millisecond)
+	}
+
+	return t, nil
+}
+
+func buildListTransactionsInput(accountKey string, query url.Values) (*app.ListTransactionsInput, *BalanceErrorResponse) {
+	i := &app.ListTransactionsInput{
+		AccountKey:    accountKey,
+		OperationType: query.Get("operation_type"),
+		Cursor:        query.Get("cursor"),
+	}
+
+	if v := query.Get("from"); v != "" {
+		t, err := parseTime(v, false)
+		if err != nil {
+			return nil, responseBuild("from must be an RFC 3339 timestamp or a YYYY-MM-DD date", http.StatusBadRequest, BadRequest)
+		}
+		i.From = t
+	}
+
+	if v := query.Get("to"); v != "" {
+		t, err := parseTime(v, true)
+		if err != nil {
+			return nil, responseBuild("to must be an RFC 3339 timestamp or a YYYY-MM-DD date", http.StatusBadRequest, BadRequest)
+		}
+		i.To = t
+	}
+
+	if !i.From.IsZero() && !i.To.IsZero() && i.From.After(i.To) {
+		return nil, responseBuild("from must not be after to", http.StatusBadRequest, BadRequest)
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > app.MaxTransactionsLimit {
+			return nil, responseBuild("limit must be between 1 and "+strconv.Itoa(app.MaxTransactionsLimit), http.StatusBadRequest, BadRequest)
+		}
+		i.Limit = limit
+	}
+
+	return i, nil
+}
+
+func transactionResponse(e *app.Entry) *TransactionResponse {
+	return &TransactionResponse{
+		ExternalKey:   e.ExternalKey,
+		OperationType: e.OperationType,
+		Amount:        e.Amount,
+		Currency:      e.Currency,
+		CreatedAt:     e.CreatedAt,
+		Origin:        e.Origin,
+		RequestID:     e.RequestID,
+		Description:   e.Description,
+		Metadata:      e.Metadata,
+		TransferID:    e.TransferID,
+		RiskScore:     e.RiskScore,
+	}
+}
+
+func listTransactionsWithContext(ctx context.Context, accountKey string, query url.Values, log Logger, a app.Balance) (*TransactionListResponse, *BalanceErrorResponse, error) {
+	i, errorResponse := buildListTransactionsInput(accountKey, query)
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	res, err := a.ListTransactionsWithContext(ctx, i)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.Error && res.Code == app.CursorInvalid {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	transactions := make([]*TransactionResponse, 0, len(res.Transactions))
+	for _, e := range res.Transactions {
+		transactions = append(transactions, transactionResponse(e))
+	}
+
+	return &TransactionListResponse{
+		AccountKey:   accountKey,
+		Transactions: transactions,
+		NextCursor:   res.NextCursor,
+	}, nil, nil
+}
+
+func getTransactionWithContext(ctx context.Context, accountKey string, externalKey string, query url.Values, log Logger, a app.Balance) (*TransactionDetailResponse, *BalanceErrorResponse, error) {
+	scope := query.Get("scope")
+	if scope != "" && scope != "transaction" && scope != "reversal" {
+		return nil, responseBuild("scope must be transaction or reversal", http.StatusBadRequest, BadRequest), nil
+	}
+
+	res, err := a.GetTransactionWithContext(ctx, &app.GetTransactionInput{
+		AccountKey:  accountKey,
+		ExternalKey: externalKey,
+		Origin:      query.Get("origin"),
+		Reversal:    scope == "reversal",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.Error && res.Code == app.TransactionNotFound {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusNotFound, NotFound), nil
+	}
+
+	e := res.Transaction
+	return &TransactionDetailResponse{
+		AccountKey:          accountKey,
+		TransactionResponse: transactionResponse(e),
+		InstallmentCount:    e.InstallmentCount,
+		OriginalExternalKey: e.OriginalExternalKey,
+		ReversedAmount:      e.ReversedAmount,
+	}, nil, nil
+}
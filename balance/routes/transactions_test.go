@@ -0,0 +1,193 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestRoutes_ListTransactions(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"OperationType\":\"\",\"From\":\"0001-01-01T00:00:00Z\",\"To\":\"0001-01-01T00:00:00Z\",\"Limit\":0,\"Cursor\":\"\"}", t)
+	res, errorResponse, err := listTransactionsWithContext(context.Background(), "123", url.Values{}, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"account_key\":\"123\",\"transactions\":[{\"external_key\":\"1\",\"operation_type\":\"Payment\",\"amount\":1000,\"currency\":\"\",\"created_at\":\"2022-01-31T10:00:00Z\"}],\"next_cursor\":\"next\"}", string(validate))
+}
+
+func TestRoutes_ListTransactionsWithFilters(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"OperationType\":\"Payment\",\"From\":\"2022-01-01T00:00:00Z\",\"To\":\"2022-01-31T23:59:59.999Z\",\"Limit\":10,\"Cursor\":\"abc\"}", t)
+	q := url.Values{
+		"operation_type": {"Payment"},
+		"from":           {"2022-01-01"},
+		"to":             {"2022-01-31"},
+		"limit":          {"10"},
+		"cursor":         {"abc"},
+	}
+	res, errorResponse, err := listTransactionsWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, "next", res.NextCursor)
+}
+
+func TestRoutes_ListTransactionsWithTimestamps(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"OperationType\":\"\",\"From\":\"2022-01-01T10:00:00Z\",\"To\":\"2022-01-01T12:00:00-03:00\",\"Limit\":0,\"Cursor\":\"\"}", t)
+	q := url.Values{
+		"from": {"2022-01-01T10:00:00Z"},
+		"to":   {"2022-01-01T12:00:00-03:00"},
+	}
+	res, errorResponse, err := listTransactionsWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, 1, len(res.Transactions))
+}
+
+func TestRoutes_NotListTransactionsWhenFromInvalid(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("", t)
+	q := url.Values{
+		"from": {"yesterday"},
+	}
+	res, errorResponse, err := listTransactionsWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"from must be an RFC 3339 timestamp or a YYYY-MM-DD date\"}}", string(validate))
+}
+
+func TestRoutes_NotListTransactionsWhenToInvalid(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("", t)
+	q := url.Values{
+		"to": {"tomorrow"},
+	}
+	res, errorResponse, err := listTransactionsWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, 400, errorResponse.Error.StatusCode)
+}
+
+func TestRoutes_NotListTransactionsWhenFromAfterTo(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("", t)
+	q := url.Values{
+		"from": {"2022-02-01"},
+		"to":   {"2022-01-01"},
+	}
+	res, errorResponse, err := listTransactionsWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"from must not be after to\"}}", string(validate))
+}
+
+func TestRoutes_NotListTransactionsWhenLimitInvalid(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("", t)
+	q := url.Values{
+		"limit": {"1000"},
+	}
+	res, errorResponse, err := listTransactionsWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"limit must be between 1 and 100\"}}", string(validate))
+}
+
+func TestRoutes_NotListTransactionsWhenCursorInvalid(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"OperationType\":\"\",\"From\":\"0001-01-01T00:00:00Z\",\"To\":\"0001-01-01T00:00:00Z\",\"Limit\":0,\"Cursor\":\"invalid\"}", t)
+	q := url.Values{
+		"cursor": {"invalid"},
+	}
+	res, errorResponse, err := listTransactionsWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"cursor-invalid\",\"message\":\"cursor is invalid\"}}", string(validate))
+}
+
+func TestRoutes_NotListTransactionsWhenError(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"12345\",\"OperationType\":\"\",\"From\":\"0001-01-01T00:00:00Z\",\"To\":\"0001-01-01T00:00:00Z\",\"Limit\":0,\"Cursor\":\"\"}", t)
+	res, errorResponse, err := listTransactionsWithContext(context.Background(), "12345", url.Values{}, l, accreditation)
+	assert.Nil(t, res)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, "list transactions error", err.Error())
+}
+
+func TestRoutes_GetTransaction(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"1\",\"Origin\":\"debit\",\"Reversal\":false}", t)
+	q := url.Values{
+		"origin": {"debit"},
+	}
+	res, errorResponse, err := getTransactionWithContext(context.Background(), "123", "1", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"account_key\":\"123\",\"external_key\":\"1\",\"operation_type\":\"InstallmentBuying\",\"amount\":-334,\"currency\":\"BRL\",\"created_at\":\"2022-01-31T10:00:00Z\",\"origin\":\"debit\",\"installment_count\":3}", string(validate))
+}
+
+func TestRoutes_GetReversal(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"r1\",\"Origin\":\"debit\",\"Reversal\":true}", t)
+	q := url.Values{
+		"origin": {"debit"},
+		"scope":  {"reversal"},
+	}
+	_, errorResponse, err := getTransactionWithContext(context.Background(), "123", "r1", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+}
+
+func TestRoutes_NotGetTransactionWhenScopeInvalid(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("", t)
+	q := url.Values{
+		"scope": {"hold"},
+	}
+	res, errorResponse, err := getTransactionWithContext(context.Background(), "123", "1", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"scope must be transaction or reversal\"}}", string(validate))
+}
+
+func TestRoutes_NotGetTransactionWhenNotFound(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"404\",\"Origin\":\"credit\",\"Reversal\":false}", t)
+	q := url.Values{
+		"origin": {"credit"},
+	}
+	res, errorResponse, err := getTransactionWithContext(context.Background(), "123", "404", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"code\":\"transaction-not-found\",\"message\":\"transaction not found\"}}", string(validate))
+}
+
+func TestRoutes_NotGetTransactionWhenError(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"ExternalKey\":\"500\",\"Origin\":\"credit\",\"Reversal\":false}", t)
+	q := url.Values{
+		"origin": {"credit"},
+	}
+	res, errorResponse, err := getTransactionWithContext(context.Background(), "123", "500", q, l, accreditation)
+	assert.NotNil(t, err)
+	assert.Nil(t, res)
+	assert.Nil(t, errorResponse)
+}
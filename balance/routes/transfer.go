@@ -0,0 +1,146 @@
+package routes
+
+import (
+	"balance/app"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type TransferRequest struct {
+	TransferKey    *string `json:"transfer_key,omitempty"`
+	FromAccountKey *string `json:"from_account_key,omitempty"`
+	ToAccountKey   *string `json:"to_account_key,omitempty"`
+	Amount         *int    `json:"amount,omitempty"`
+	Currency       *string `json:"currency,omitempty"`
+
+	RequestID   *string           `json:"request_id,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type TransferResponse struct {
+	TransferID     string    `json:"transfer_id"`
+	TransferKey    string    `json:"transfer_key"`
+	FromAccountKey string    `json:"from_account_key"`
+	ToAccountKey   string    `json:"to_account_key"`
+	Amount         int       `json:"amount"`
+	Currency       string    `json:"currency"`
+	CreatedAt      time.Time `json:"created_at"`
+	RequestID      string    `json:"request_id,omitempty"`
+}
+
+func buildTransferRequest(a []byte) (*TransferRequest, *BalanceErrorResponse) {
+	va := &TransferRequest{}
+
+	err := json.Unmarshal(a, &va)
+	if err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.TransferKey == nil || stringValue(va.TransferKey) == "" {
+		return nil, responseBuild("transfer_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if strings.Contains(stringValue(va.TransferKey), "#") {
+		return nil, responseBuild("transfer_key must not contain #", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.FromAccountKey == nil || stringValue(va.FromAccountKey) == "" {
+		return nil, responseBuild("from_account_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.ToAccountKey == nil || stringValue(va.ToAccountKey) == "" {
+		return nil, responseBuild("to_account_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.Amount == nil || intValue(va.Amount) == 0 {
+		return nil, responseBuild("amount is missing or 0", http.StatusBadRequest, BadRequest)
+	}
+
+	if errorResponse := validateCurrency(stringValue(va.Currency)); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	if errorResponse := validateMetadata(stringValue(va.Description), va.Metadata); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	return va, nil
+}
+
+func transferWithContext(ctx context.Context, requestID string, body io.ReadCloser, log Logger, a app.Balance) (*TransferResponse, *BalanceErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request, errorResponse := buildTransferRequest(b)
+
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	i := &app.TransferInput{
+		TransferKey:    stringValue(request.TransferKey),
+		FromAccountKey: stringValue(request.FromAccountKey),
+		ToAccountKey:   stringValue(request.ToAccountKey),
+		Amount:         intValue(request.Amount),
+		Currency:       stringValue(request.Currency),
+		RequestID:      requestID,
+		Description:    stringValue(request.Description),
+		Metadata:       request.Metadata,
+	}
+	if stringValue(request.RequestID) != "" {
+		i.RequestID = stringValue(request.RequestID)
+	}
+
+	res, err := a.TransferWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error && (res.Code == app.TransferInvalid || res.Code == app.CurrencyInvalid) {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.AccountNotFound {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusNotFound, NotFound), nil
+	}
+
+	if res != nil && res.Error && (res.Code == app.AccountBlocked || res.Code == app.AccountClosed) {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.UnauthorizedTransfer {
+		return nil, responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.InsufficientFunds {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.IdempotencyKeyReused {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.ItemAlreadyExists {
+		return nil, responseBuild(res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	return &TransferResponse{
+		TransferID:     res.TransferID,
+		TransferKey:    i.TransferKey,
+		FromAccountKey: i.FromAccountKey,
+		ToAccountKey:   i.ToAccountKey,
+		Amount:         i.Amount,
+		Currency:       res.Currency,
+		CreatedAt:      res.CreatedAt,
+		RequestID:      res.RequestID,
+	}, nil, nil
+}
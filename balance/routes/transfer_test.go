@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRoutes_Transfer(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"transfer_key\": \"t1\", \"from_account_key\": \"1\", \"to_account_key\": \"2\", \"amount\": 1000, \"description\": \"rent\"}"))
+	accreditation := newAccreditationMock("{\"TransferKey\":\"t1\",\"FromAccountKey\":\"1\",\"ToAccountKey\":\"2\",\"Amount\":1000,\"Currency\":\"\",\"RequestID\":\"req-1\",\"Description\":\"rent\",\"Metadata\":null}", t)
+	o, res, err := transferWithContext(context.Background(), "req-1", rc, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	v, err := json.Marshal(o)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"transfer_id\":\"id\",\"transfer_key\":\"t1\",\"from_account_key\":\"1\",\"to_account_key\":\"2\",\"amount\":1000,\"currency\":\"\",\"created_at\":\"2022-01-31T10:00:00Z\",\"request_id\":\"req-1\"}", string(v))
+}
+
+func TestRoutes_NotTransferWhenInvalidPayload(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"transfer_key\": \"t1\", \"from_account_key\": \"1\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("", t)
+	_, res, err := transferWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"to_account_key is missing or null\"}}", string(v))
+}
+
+func TestRoutes_NotTransferWhenError(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"transfer_key\": \"t1\", \"from_account_key\": \"12345\", \"to_account_key\": \"2\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("{\"TransferKey\":\"t1\",\"FromAccountKey\":\"12345\",\"ToAccountKey\":\"2\",\"Amount\":1000,\"Currency\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null}", t)
+	o, res, err := transferWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, o)
+	assert.Nil(t, res)
+	assert.Equal(t, "transfer error", err.Error())
+}
+
+func TestRoutes_NotTransferWhenAccountNotFound(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"transfer_key\": \"t1\", \"from_account_key\": \"404\", \"to_account_key\": \"2\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("{\"TransferKey\":\"t1\",\"FromAccountKey\":\"404\",\"ToAccountKey\":\"2\",\"Amount\":1000,\"Currency\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null}", t)
+	_, res, err := transferWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"code\":\"account-not-found\",\"message\":\"account 404 not found\"}}", string(v))
+}
+
+func TestRoutes_NotTransferWhenInsufficientFunds(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"transfer_key\": \"t1\", \"from_account_key\": \"422\", \"to_account_key\": \"2\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("{\"TransferKey\":\"t1\",\"FromAccountKey\":\"422\",\"ToAccountKey\":\"2\",\"Amount\":1000,\"Currency\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null}", t)
+	_, res, err := transferWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"unprocessable_entity\",\"code\":\"insufficient-funds\",\"message\":\"insufficient funds\"}}", string(v))
+}
+
+func TestRoutes_NotTransferWhenAccountClosed(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"transfer_key\": \"t1\", \"from_account_key\": \"closed\", \"to_account_key\": \"2\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("{\"TransferKey\":\"t1\",\"FromAccountKey\":\"closed\",\"ToAccountKey\":\"2\",\"Amount\":1000,\"Currency\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null}", t)
+	_, res, err := transferWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"unprocessable_entity\",\"code\":\"account-closed\",\"message\":\"account closed is not active\"}}", string(v))
+}
+
+func TestRoutes_NotTransferWhenTransferKeyReused(t *testing.T) {
+	l := newLogMock()
+	rc := io.NopCloser(strings.NewReader("{\"transfer_key\": \"t1\", \"from_account_key\": \"409\", \"to_account_key\": \"2\", \"amount\": 1000}"))
+	accreditation := newAccreditationMock("{\"TransferKey\":\"t1\",\"FromAccountKey\":\"409\",\"ToAccountKey\":\"2\",\"Amount\":1000,\"Currency\":\"\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null}", t)
+	_, res, err := transferWithContext(context.Background(), "", rc, l, accreditation)
+	assert.Nil(t, err)
+	v, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"conflict\",\"code\":\"idempotency-key-reused\",\"message\":\"transfer_key was already used for a different request\"}}", string(v))
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"balance/app"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type UsageResponse struct {
+	AccountKey     string   `json:"account_key"`
+	Currency       string   `json:"currency"`
+	OperationTypes []string `json:"operation_types"`
+	Period         string   `json:"period"`
+	Amount         int      `json:"amount"`
+	Count          int      `json:"count"`
+}
+
+// getUsageWithContext answers GET /v1/accounts/{account_key}/usage with the debits
+// of the current day or month; operation_type may be repeated.
+func getUsageWithContext(ctx context.Context, accountKey string, query url.Values, log Logger, a app.Balance) (*UsageResponse, *BalanceErrorResponse, error) {
+	period := query.Get("period")
+	if period != app.UsageDay && period != app.UsageMonth {
+		return nil, responseBuild("period must be day or month", http.StatusBadRequest, BadRequest), nil
+	}
+
+	operationTypes := query["operation_type"]
+	if len(operationTypes) == 0 {
+		return nil, responseBuild("operation_type is missing", http.StatusBadRequest, BadRequest), nil
+	}
+
+	res, err := a.GetUsageWithContext(ctx, &app.GetUsageInput{
+		AccountKey:     accountKey,
+		Currency:       query.Get("currency"),
+		OperationTypes: operationTypes,
+		Period:         period,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.Error && res.Code == app.CurrencyInvalid {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	return &UsageResponse{
+		AccountKey:     accountKey,
+		Currency:       res.Currency,
+		OperationTypes: operationTypes,
+		Period:         period,
+		Amount:         res.Amount,
+		Count:          res.Count,
+	}, nil, nil
+}
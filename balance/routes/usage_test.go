@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestRoutes_GetUsage(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"123\",\"Currency\":\"\",\"OperationTypes\":[\"Buying\",\"InstallmentBuying\"],\"Period\":\"month\"}", t)
+	q := url.Values{
+		"operation_type": {"Buying", "InstallmentBuying"},
+		"period":         {"month"},
+	}
+	res, errorResponse, err := getUsageWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	validate, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"account_key\":\"123\",\"currency\":\"BRL\",\"operation_types\":[\"Buying\",\"InstallmentBuying\"],\"period\":\"month\",\"amount\":2500,\"count\":2}", string(validate))
+}
+
+func TestRoutes_NotGetUsageWhenPeriodInvalid(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("", t)
+	q := url.Values{
+		"operation_type": {"Withdraw"},
+		"period":         {"week"},
+	}
+	res, errorResponse, err := getUsageWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"period must be day or month\"}}", string(validate))
+}
+
+func TestRoutes_NotGetUsageWithoutOperationType(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("", t)
+	q := url.Values{
+		"period": {"day"},
+	}
+	res, errorResponse, err := getUsageWithContext(context.Background(), "123", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"operation_type is missing\"}}", string(validate))
+}
+
+func TestRoutes_NotGetUsageWhenCurrencyInvalid(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"400\",\"Currency\":\"XX\",\"OperationTypes\":[\"Withdraw\"],\"Period\":\"day\"}", t)
+	q := url.Values{
+		"currency":       {"XX"},
+		"operation_type": {"Withdraw"},
+		"period":         {"day"},
+	}
+	res, errorResponse, err := getUsageWithContext(context.Background(), "400", q, l, accreditation)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	validate, err := json.Marshal(errorResponse)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"code\":\"currency-invalid\",\"message\":\"currency XX is not supported\"}}", string(validate))
+}
+
+func TestRoutes_NotGetUsageWhenError(t *testing.T) {
+	l := newLogMock()
+	accreditation := newAccreditationMock("{\"AccountKey\":\"500\",\"Currency\":\"\",\"OperationTypes\":[\"Withdraw\"],\"Period\":\"day\"}", t)
+	q := url.Values{
+		"operation_type": {"Withdraw"},
+		"period":         {"day"},
+	}
+	res, errorResponse, err := getUsageWithContext(context.Background(), "500", q, l, accreditation)
+	assert.Nil(t, res)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, "get usage error", err.Error())
+}
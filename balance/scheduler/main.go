@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"balance/app"
+	"context"
+	"fmt"
+	"time"
+)
+
+type Scheduler struct {
+	log     Logger
+	config  *Config
+	balance app.Balance
+}
+
+func (s *Scheduler) Start() {
+	s.log.Info(fmt.Sprintf("Starting scheduler every %s", s.config.Interval))
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.run(now)
+	}
+}
+
+func (s *Scheduler) run(now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Interval)
+	defer cancel()
+
+	s.postDueInstallments(ctx, now)
+	s.releaseExpiredHolds(ctx, now)
+}
+
+func (s *Scheduler) postDueInstallments(ctx context.Context, now time.Time) {
+	i := &app.PostDueInstallmentsInput{
+		Now: now,
+	}
+	o, err := s.balance.PostDueInstallmentsWithContext(ctx, i)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Could not post due installments %s", err.Error()))
+		return
+	}
+	if o.Posted > 0 || o.Failed > 0 {
+		s.log.Info(fmt.Sprintf("Installments posted %d failed %d", o.Posted, o.Failed))
+	}
+}
+
+func (s *Scheduler) releaseExpiredHolds(ctx context.Context, now time.Time) {
+	i := &app.ReleaseExpiredHoldsInput{
+		Now: now,
+	}
+	o, err := s.balance.ReleaseExpiredHoldsWithContext(ctx, i)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Could not release expired holds %s", err.Error()))
+		return
+	}
+	if o.Released > 0 || o.Failed > 0 {
+		s.log.Info(fmt.Sprintf("Expired holds released %d failed %d", o.Released, o.Failed))
+	}
+}
+
+func New(balance app.Balance, log Logger, config *Config) *Scheduler {
+	return &Scheduler{
+		balance: balance,
+		log:     log,
+		config:  config,
+	}
+}
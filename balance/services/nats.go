@@ -0,0 +1,70 @@
+package services
+
+import (
+	"balance/publisher"
+	"context"
+	"github.com/nats-io/nats.go"
+	"sync"
+	"time"
+)
+
+const (
+	natsConnectTimeout = 2 * time.Second
+	// natsFlushTimeout bounds the wait for the server when the caller set no deadline.
+	natsFlushTimeout = 5 * time.Second
+)
+
+// natsService publishes on one connection, which the client keeps alive with PINGs
+// and reconnects when it drops.
+type natsService struct {
+	url  string
+	mu   sync.Mutex
+	conn *nats.Conn
+}
+
+// connection connects on first use, so the balance starts while NATS is down.
+func (n *natsService) connection() (*nats.Conn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn != nil && !n.conn.IsClosed() {
+		return n.conn, nil
+	}
+
+	// Without a reconnect buffer a publish fails while reconnecting instead of going
+	// out later, after the relay has already given up on it.
+	conn, err := nats.Connect(n.url,
+		nats.Name("balance"),
+		nats.Timeout(natsConnectTimeout),
+		nats.MaxReconnects(-1),
+		nats.ReconnectBufSize(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	n.conn = conn
+	return conn, nil
+}
+
+// PublishWithContext returns once the server has processed the message: the flush
+// waits for the PONG of a PING sent after it.
+func (n *natsService) PublishWithContext(ctx context.Context, subject string, payload []byte) error {
+	conn, err := n.connection()
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Publish(subject, payload); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, natsFlushTimeout)
+	defer cancel()
+	return conn.FlushWithContext(ctx)
+}
+
+func NewNats(url string) publisher.Nats {
+	return &natsService{
+		url: url,
+	}
+}
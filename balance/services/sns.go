@@ -0,0 +1,25 @@
+package services
+
+import (
+	"balance/publisher"
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+type snsService struct {
+	svc *sns.SNS
+}
+
+func (s *snsService) PublishWithContext(ctx context.Context, input *sns.PublishInput) (*sns.PublishOutput, error) {
+	return s.svc.PublishWithContext(ctx, input)
+}
+
+func NewSns() publisher.Sns {
+	mySession := session.Must(session.NewSession())
+	svc := sns.New(mySession, aws.NewConfig().WithRegion("us-east-1"), aws.NewConfig().WithEndpoint("http://localstack:4566"))
+	return &snsService{
+		svc: svc,
+	}
+}
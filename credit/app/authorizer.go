@@ -1,6 +1,9 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Authorizer interface {
 	AuthorizeWithContext(ctx context.Context, input *AuthorizeInput) (*AuthorizeOutput, error)
@@ -9,6 +12,15 @@ type Authorizer interface {
 type AuthorizeInput struct {
 	AccountKey string
 }
+
+// AuthorizeOutput carries the account profile so transactions can apply risk rules.
 type AuthorizeOutput struct {
-	HasError bool
+	HasError   bool
+	Code       string
+	Name       string
+	PersonType string
+	Email      string
+	Phone      string
+	CreatedAt  time.Time
+	Currencies []string
 }
@@ -1,19 +1,56 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Credit interface {
 	TransactionWithContext(ctx context.Context, input *TransactionInput) (*TransactionOutput, error)
+	GetTransactionWithContext(ctx context.Context, input *GetTransactionInput) (*GetTransactionOutput, error)
+	ReconcileWithContext(ctx context.Context, input *ReconcileInput) (*ReconcileOutput, error)
 }
 
 type TransactionInput struct {
 	AccountKey  string
 	ExternalKey string
 	Amount      int
+	Currency    string
+	RequestID   string
+	Description string
+	Metadata    map[string]string
 }
 
 type TransactionOutput struct {
-	Error  bool
-	Code   string
-	Detail string
+	Error     bool
+	Code      string
+	Detail    string
+	Amount    int
+	Currency  string
+	CreatedAt time.Time
+	RequestID string
+}
+
+type GetTransactionInput struct {
+	AccountKey  string
+	ExternalKey string
+}
+
+type GetTransactionOutput struct {
+	Error       bool
+	Code        string
+	Detail      string
+	Transaction *Transaction
+}
+
+// ReconcileInput skips the credits attempted after AttemptedBefore, which may
+// still be waiting on balance.
+type ReconcileInput struct {
+	AttemptedBefore time.Time
+}
+
+type ReconcileOutput struct {
+	Settled    int
+	Failed     int
+	Unresolved int
 }
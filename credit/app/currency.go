@@ -0,0 +1,38 @@
+package app
+
+import "fmt"
+
+const (
+	DefaultCurrency = "BRL"
+	CurrencyInvalid = "currency-invalid"
+	CurrencyNotHeld = "currency-not-held"
+)
+
+// currencyOrDefault reads a transaction without currency as BRL, as every amount
+// was before accounts held other currencies.
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
+
+func holdsCurrency(currencies []string, currency string) bool {
+	if len(currencies) == 0 {
+		return currency == DefaultCurrency
+	}
+	for _, c := range currencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+func currencyNotHeld(currency string) *TransactionOutput {
+	return &TransactionOutput{
+		Error:  true,
+		Code:   CurrencyNotHeld,
+		Detail: fmt.Sprintf("account does not hold %s", currency),
+	}
+}
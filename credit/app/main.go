@@ -3,25 +3,48 @@ package app
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 const (
+	Origin                  = "credit"
 	Payment                 = "Payment"
 	UnauthorizedTransaction = "unauthorized-transaction"
 	UnauthorizedSettlement  = "unauthorized-settlement"
 	AuthorizerNotFound      = "authorizer-not-found"
 	SettlementFailed        = "settlement-failed"
+	IdempotencyKeyReused    = "idempotency-key-reused"
+	AccountBlocked          = "account-blocked"
+	AccountClosed           = "account-closed"
 )
 
 type credit struct {
-	log        Logger
-	authorizer Authorizer
-	settlement Settlement
+	log          Logger
+	authorizer   Authorizer
+	settlement   Settlement
+	transactions Transactions
+	notifier     Notifier
+	clock        func() time.Time
 }
 
 func (a *credit) TransactionWithContext(ctx context.Context, input *TransactionInput) (*TransactionOutput, error) {
-	transactionOutput := &TransactionOutput{
-		Error: false,
+	currency := currencyOrDefault(input.Currency)
+	record := &Transaction{
+		AccountKey:    input.AccountKey,
+		ExternalKey:   input.ExternalKey,
+		OperationType: Payment,
+		Amount:        input.Amount,
+		Currency:      currency,
+		RequestID:     input.RequestID,
+	}
+	// A retry is answered from the first request, even when the account was blocked
+	// or closed since.
+	replay, err := a.previousWithContext(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if replay != nil {
+		return replay, nil
 	}
 
 	ai := &AuthorizeInput{
@@ -39,6 +62,13 @@ func (a *credit) TransactionWithContext(ctx context.Context, input *TransactionI
 			Detail: "authorizer not found",
 		}, nil
 	}
+	if ao.Code == AccountBlocked || ao.Code == AccountClosed {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   ao.Code,
+			Detail: "account is not active",
+		}, nil
+	}
 	if ao.HasError {
 		return &TransactionOutput{
 			Error:  true,
@@ -47,12 +77,30 @@ func (a *credit) TransactionWithContext(ctx context.Context, input *TransactionI
 		}, nil
 	}
 
+	if !holdsCurrency(ao.Currencies, currency) {
+		return currencyNotHeld(currency), nil
+	}
+
 	si := &SettleInput{
 		AccountKey:    input.AccountKey,
 		ExternalKey:   input.ExternalKey,
 		OperationType: Payment,
 		Amount:        input.Amount,
+		Currency:      currency,
+		Origin:        Origin,
+		RequestID:     input.RequestID,
+		Description:   input.Description,
+		Metadata:      input.Metadata,
+	}
+	refused, err := a.beginWithContext(ctx, record)
+	if err != nil {
+		return nil, err
 	}
+	if refused != nil {
+		return refused, nil
+	}
+
+	// An error or an intermittence leaves the credit pending until it is reconciled.
 	so, err := a.settlement.SettleWithContext(ctx, si)
 	if err != nil {
 		a.log.Error(fmt.Sprintf("settle error %s", err.Error()))
@@ -65,21 +113,56 @@ func (a *credit) TransactionWithContext(ctx context.Context, input *TransactionI
 			Detail: "Try again",
 		}, nil
 	}
+	if so.Error && (so.Code == IdempotencyKeyReused || so.Code == CurrencyInvalid) {
+		return a.failWithContext(ctx, record, &TransactionOutput{
+			Error:  true,
+			Code:   so.Code,
+			Detail: so.Detail,
+		}), nil
+	}
 	if so.Error {
-		return &TransactionOutput{
+		return a.failWithContext(ctx, record, &TransactionOutput{
 			Error:  true,
 			Code:   SettlementFailed,
 			Detail: so.Detail,
-		}, nil
+		}), nil
 	}
 
-	return transactionOutput, nil
+	a.finishWithContext(ctx, record, &FinishTransactionInput{
+		Status:    TransactionSettled,
+		Amount:    input.Amount,
+		Currency:  currency,
+		RequestID: so.RequestID,
+		SettledAt: so.CreatedAt,
+	})
+
+	a.notifyWithContext(ctx, &NotifyInput{
+		EventType:     CreditSettled,
+		AccountKey:    input.AccountKey,
+		ExternalKey:   input.ExternalKey,
+		OperationType: Payment,
+		Amount:        input.Amount,
+		Currency:      currency,
+		OccurredAt:    so.CreatedAt,
+		RequestID:     so.RequestID,
+	})
+
+	return &TransactionOutput{
+		Error:     false,
+		Amount:    input.Amount,
+		Currency:  currency,
+		CreatedAt: so.CreatedAt,
+		RequestID: so.RequestID,
+	}, nil
 }
 
-func New(authorizer Authorizer, settlement Settlement, log Logger) Credit {
+func New(authorizer Authorizer, settlement Settlement, transactions Transactions, notifier Notifier, log Logger) Credit {
 	return &credit{
-		log:        log,
-		authorizer: authorizer,
-		settlement: settlement,
+		log:          log,
+		authorizer:   authorizer,
+		settlement:   settlement,
+		transactions: transactions,
+		notifier:     notifier,
+		clock:        time.Now,
 	}
 }
@@ -0,0 +1,296 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)
+
+type authorizerMock struct{}
+
+func (a *authorizerMock) AuthorizeWithContext(ctx context.Context, input *AuthorizeInput) (*AuthorizeOutput, error) {
+	switch input.AccountKey {
+	case "500":
+		return nil, errors.New("authorize error")
+	case "404":
+		return nil, nil
+	case "blocked":
+		return &AuthorizeOutput{
+			HasError: true,
+			Code:     AccountBlocked,
+		}, nil
+	}
+	return &AuthorizeOutput{
+		HasError:   false,
+		Currencies: []string{"BRL", "USD"},
+	}, nil
+}
+
+// settlementMock finds the entries in entries by external key; "unavailable" stands
+// for balance not answering.
+type settlementMock struct {
+	settled []*SettleInput
+	entries map[string]*GetSettlementOutput
+}
+
+func (s *settlementMock) SettleWithContext(ctx context.Context, input *SettleInput) (*SettleOutput, error) {
+	s.settled = append(s.settled, input)
+	if input.ExternalKey == "unavailable" {
+		return &SettleOutput{
+			HasIntermitance: true,
+		}, nil
+	}
+	return &SettleOutput{
+		Amount:    input.Amount,
+		CreatedAt: testNow,
+		RequestID: "r1",
+	}, nil
+}
+
+func (s *settlementMock) GetSettlementWithContext(ctx context.Context, input *GetSettlementInput) (*GetSettlementOutput, error) {
+	if input.ExternalKey == "unavailable" {
+		return &GetSettlementOutput{
+			HasIntermitance: true,
+		}, nil
+	}
+	if o, ok := s.entries[input.ExternalKey]; ok {
+		return o, nil
+	}
+	return &GetSettlementOutput{
+		Found: false,
+	}, nil
+}
+
+// transactionsMock keeps the records in memory with the same conditions as the
+// repository. When set, listed is what the reconciler gets instead of the pending
+// records, to stand for a list read before another attempt started.
+type transactionsMock struct {
+	records map[string]*Transaction
+	listed  []*Transaction
+}
+
+func recordKey(accountKey string, externalKey string) string {
+	return fmt.Sprintf("%s|%s", accountKey, externalKey)
+}
+
+func (r *transactionsMock) BeginWithContext(ctx context.Context, input *Transaction) (*BeginTransactionOutput, error) {
+	key := recordKey(input.AccountKey, input.ExternalKey)
+	if t, ok := r.records[key]; ok && t.Status != TransactionFailed && !(t.Status == TransactionPending && t.RequestHash == input.RequestHash) {
+		return &BeginTransactionOutput{
+			AlreadyExists: true,
+		}, nil
+	}
+	t := *input
+	r.records[key] = &t
+	return &BeginTransactionOutput{}, nil
+}
+
+func (r *transactionsMock) FinishWithContext(ctx context.Context, input *FinishTransactionInput) (*FinishTransactionOutput, error) {
+	t, ok := r.records[recordKey(input.AccountKey, input.ExternalKey)]
+	if !ok || t.Status != TransactionPending || !t.AttemptedAt.Equal(input.AttemptedAt) {
+		return &FinishTransactionOutput{
+			TransactionChanged: true,
+		}, nil
+	}
+	t.Status, t.Code, t.Detail = input.Status, input.Code, input.Detail
+	t.Amount, t.Currency, t.RequestID, t.SettledAt = input.Amount, input.Currency, input.RequestID, input.SettledAt
+	return &FinishTransactionOutput{}, nil
+}
+
+func (r *transactionsMock) GetWithContext(ctx context.Context, input *GetTransactionRecordInput) (*Transaction, error) {
+	if input.AccountKey == "get-error" {
+		return nil, errors.New("get transaction error")
+	}
+	t, ok := r.records[recordKey(input.AccountKey, input.ExternalKey)]
+	if !ok {
+		return nil, nil
+	}
+	c := *t
+	return &c, nil
+}
+
+func (r *transactionsMock) ListPendingWithContext(ctx context.Context, input *ListPendingTransactionsInput) (*ListPendingTransactionsOutput, error) {
+	if r.listed != nil {
+		return &ListPendingTransactionsOutput{
+			Transactions: r.listed,
+		}, nil
+	}
+	transactions := make([]*Transaction, 0)
+	for _, t := range r.records {
+		if t.Status == TransactionPending && t.AttemptedAt.Before(input.AttemptedBefore) {
+			c := *t
+			transactions = append(transactions, &c)
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].ExternalKey < transactions[j].ExternalKey
+	})
+	return &ListPendingTransactionsOutput{
+		Transactions: transactions,
+	}, nil
+}
+
+// notifierMock is called on a goroutine of its own, so the events go through a channel.
+type notifierMock struct {
+	sent chan *NotifyInput
+}
+
+func (n *notifierMock) NotifyWithContext(ctx context.Context, input *NotifyInput) error {
+	n.sent <- input
+	return nil
+}
+
+// events waits for count events.
+func (n *notifierMock) events(count int) []*NotifyInput {
+	events := make([]*NotifyInput, 0, count)
+	for len(events) < count {
+		select {
+		case e := <-n.sent:
+			events = append(events, e)
+		case <-time.After(time.Second):
+			return events
+		}
+	}
+	return events
+}
+
+type log struct{}
+
+func (l log) Info(msg string)  {}
+func (l log) Error(msg string) {}
+func newLogMock() Logger {
+	return &log{}
+}
+
+type mocks struct {
+	settlement   *settlementMock
+	transactions *transactionsMock
+	notifier     *notifierMock
+}
+
+func newMocks() *mocks {
+	return &mocks{
+		settlement: &settlementMock{},
+		transactions: &transactionsMock{
+			records: map[string]*Transaction{},
+		},
+		notifier: &notifierMock{
+			sent: make(chan *NotifyInput, 10),
+		},
+	}
+}
+
+func newWithClock(m *mocks, now time.Time) Credit {
+	a := New(&authorizerMock{}, m.settlement, m.transactions, m.notifier, newLogMock())
+	a.(*credit).clock = func() time.Time {
+		return now
+	}
+	return a
+}
+
+// newSettledRecord is a credit of 1000 BRL that balance already settled.
+func newSettledRecord(m *mocks, accountKey string, externalKey string) *Transaction {
+	t := &Transaction{
+		AccountKey:    accountKey,
+		ExternalKey:   externalKey,
+		OperationType: Payment,
+		Amount:        1000,
+		Currency:      DefaultCurrency,
+		Status:        TransactionSettled,
+		RequestID:     "r0",
+		AttemptedAt:   testNow.Add(-time.Hour),
+		SettledAt:     testNow.Add(-time.Hour),
+	}
+	t.RequestHash = transactionHash(t)
+	m.transactions.records[recordKey(accountKey, externalKey)] = t
+	return t
+}
+
+func newTransactionInput(accountKey string, externalKey string, amount int) *TransactionInput {
+	return &TransactionInput{
+		AccountKey:  accountKey,
+		ExternalKey: externalKey,
+		Amount:      amount,
+		RequestID:   "r1",
+	}
+}
+
+func TestCredit_Transaction(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	res, err := a.TransactionWithContext(context.Background(), newTransactionInput("1", "c1", 1000))
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":1000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\"}", string(b))
+
+	record := m.transactions.records[recordKey("1", "c1")]
+	assert.Equal(t, TransactionSettled, record.Status)
+	assert.Equal(t, 1, len(m.settlement.settled))
+	events := m.notifier.events(1)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, CreditSettled, events[0].EventType)
+}
+
+// The account was blocked after the credit settled; the retry still gets its answer.
+func TestCredit_TransactionReplayedFromRecord(t *testing.T) {
+	m := newMocks()
+	newSettledRecord(m, "blocked", "c1")
+	a := newWithClock(m, testNow)
+	res, err := a.TransactionWithContext(context.Background(), newTransactionInput("blocked", "c1", 1000))
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":1000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"RequestID\":\"r0\"}", string(b))
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
+
+func TestCredit_NotTransactionWhenKeyHeldByDifferentPayload(t *testing.T) {
+	m := newMocks()
+	newSettledRecord(m, "1", "c1")
+	a := newWithClock(m, testNow)
+	res, err := a.TransactionWithContext(context.Background(), newTransactionInput("1", "c1", 2000))
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"idempotency-key-reused\",\"Detail\":\"external_key was already used for a different request\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(b))
+	assert.Equal(t, 0, len(m.settlement.settled))
+	assert.Equal(t, 1000, m.transactions.records[recordKey("1", "c1")].Amount)
+}
+
+func TestCredit_NotTransactionWhenAccountBlocked(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	res, err := a.TransactionWithContext(context.Background(), newTransactionInput("blocked", "c1", 1000))
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"account-blocked\",\"Detail\":\"account is not active\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(b))
+	assert.Equal(t, 0, len(m.transactions.records))
+}
+
+func TestCredit_NotTransactionWhenGetRecordError(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	res, err := a.TransactionWithContext(context.Background(), newTransactionInput("get-error", "c1", 1000))
+	assert.Nil(t, res)
+	assert.Equal(t, "get transaction error", err.Error())
+}
+
+func TestCredit_KeepPendingWhenBalanceUnavailable(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	res, err := a.TransactionWithContext(context.Background(), newTransactionInput("1", "unavailable", 1000))
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"unauthorized-settlement\",\"Detail\":\"Try again\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\"}", string(b))
+	assert.Equal(t, TransactionPending, m.transactions.records[recordKey("1", "unavailable")].Status)
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	CreditSettled = "CreditSettled"
+
+	// notifyTimeout bounds a notification, every attempt of the http client
+	// included.
+	notifyTimeout = 30 * time.Second
+)
+
+// Notifier reports transaction outcomes to the webhook service.
+type Notifier interface {
+	NotifyWithContext(ctx context.Context, input *NotifyInput) error
+}
+
+type NotifyInput struct {
+	EventID       string
+	EventType     string
+	AccountKey    string
+	ExternalKey   string
+	OperationType string
+	Amount        int
+	Currency      string
+	OccurredAt    time.Time
+	RequestID     string
+}
+
+// notifyWithContext never fails the transaction: the money already moved, and a
+// lost notification is the smaller harm. Retrying a transaction notifies it
+// again under the same event id.
+func (a *credit) notifyWithContext(ctx context.Context, input *NotifyInput) {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s", Origin, input.EventType, input.AccountKey, input.ExternalKey)))
+	input.EventID = hex.EncodeToString(h[:])
+
+	// The notification runs on its own so it neither delays the answer nor ends
+	// with the request context.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := a.notifier.NotifyWithContext(ctx, input); err != nil {
+			a.log.Error(fmt.Sprintf("notify error %s event %s", err.Error(), input.EventID))
+		}
+	}()
+}
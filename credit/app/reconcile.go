@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+const DefaultReconcileBatchSize = 100
+
+// settlementMatches checks that the entry under the key is this credit and not
+// another request that reused the key. Credits are positive in the ledger.
+func settlementMatches(t *Transaction, so *GetSettlementOutput) bool {
+	if so.OperationType != t.OperationType {
+		return false
+	}
+	if t.Currency != "" && so.Currency != t.Currency {
+		return false
+	}
+	return so.Amount == t.Amount
+}
+
+// ReconcileWithContext asks balance about every credit left pending for too long.
+// A credit found in the ledger is settled; one that is missing is closed as failed
+// so the client can send it again under the same key.
+func (a *credit) ReconcileWithContext(ctx context.Context, input *ReconcileInput) (*ReconcileOutput, error) {
+	o, err := a.transactions.ListPendingWithContext(ctx, &ListPendingTransactionsInput{
+		AttemptedBefore: input.AttemptedBefore,
+		Limit:           DefaultReconcileBatchSize,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("list pending transactions error %s", err.Error()))
+		return nil, err
+	}
+
+	reconcileOutput := &ReconcileOutput{}
+	for _, t := range o.Transactions {
+		so, err := a.settlement.GetSettlementWithContext(ctx, &GetSettlementInput{
+			AccountKey:  t.AccountKey,
+			ExternalKey: t.ExternalKey,
+			Origin:      Origin,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("get settlement %s error %s", t.ExternalKey, err.Error()))
+			reconcileOutput.Unresolved++
+			continue
+		}
+		if so.HasIntermitance {
+			reconcileOutput.Unresolved++
+			continue
+		}
+
+		if so.Found && settlementMatches(t, so) {
+			if !a.completeWithContext(ctx, t, so) {
+				reconcileOutput.Unresolved++
+				continue
+			}
+			reconcileOutput.Settled++
+			continue
+		}
+
+		if !a.compensateWithContext(ctx, t, so) {
+			reconcileOutput.Unresolved++
+			continue
+		}
+		reconcileOutput.Failed++
+	}
+
+	return reconcileOutput, nil
+}
+
+func (a *credit) completeWithContext(ctx context.Context, t *Transaction, so *GetSettlementOutput) bool {
+	if !a.finishWithContext(ctx, t, &FinishTransactionInput{
+		Status:    TransactionSettled,
+		Amount:    t.Amount,
+		Currency:  t.Currency,
+		RequestID: so.RequestID,
+		SettledAt: so.CreatedAt,
+	}) {
+		return false
+	}
+
+	a.notifyWithContext(ctx, &NotifyInput{
+		EventType:     CreditSettled,
+		AccountKey:    t.AccountKey,
+		ExternalKey:   t.ExternalKey,
+		OperationType: t.OperationType,
+		Amount:        t.Amount,
+		Currency:      t.Currency,
+		OccurredAt:    so.CreatedAt,
+		RequestID:     so.RequestID,
+	})
+	return true
+}
+
+func (a *credit) compensateWithContext(ctx context.Context, t *Transaction, so *GetSettlementOutput) bool {
+	i := &FinishTransactionInput{
+		Status:   TransactionFailed,
+		Code:     SettlementFailed,
+		Detail:   "balance has no record of the transaction",
+		Amount:   t.Amount,
+		Currency: t.Currency,
+	}
+	if so.Found {
+		i.Code = IdempotencyKeyReused
+		i.Detail = "external_key was already used for a different request"
+	}
+	return a.finishWithContext(ctx, t, i)
+}
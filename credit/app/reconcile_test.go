@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// newPendingRecord is a credit whose answer from balance was lost a minute ago.
+func newPendingRecord(m *mocks, externalKey string) *Transaction {
+	t := &Transaction{
+		AccountKey:    "1",
+		ExternalKey:   externalKey,
+		OperationType: Payment,
+		Amount:        1000,
+		Currency:      DefaultCurrency,
+		Status:        TransactionPending,
+		AttemptedAt:   testNow.Add(-time.Minute),
+	}
+	t.RequestHash = transactionHash(t)
+	m.transactions.records[recordKey("1", externalKey)] = t
+	return t
+}
+
+func reconcile(t *testing.T, m *mocks) string {
+	a := newWithClock(m, testNow)
+	res, err := a.ReconcileWithContext(context.Background(), &ReconcileInput{
+		AttemptedBefore: testNow.Add(-30 * time.Second),
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func TestCredit_ReconcileSettledInBalance(t *testing.T) {
+	m := newMocks()
+	newPendingRecord(m, "c1")
+	m.settlement.entries = map[string]*GetSettlementOutput{
+		"c1": {Found: true, OperationType: Payment, Amount: 1000, Currency: DefaultCurrency, CreatedAt: testNow.Add(-time.Minute), RequestID: "r1"},
+	}
+	assert.Equal(t, "{\"Settled\":1,\"Failed\":0,\"Unresolved\":0}", reconcile(t, m))
+
+	record := m.transactions.records[recordKey("1", "c1")]
+	assert.Equal(t, TransactionSettled, record.Status)
+	assert.Equal(t, "r1", record.RequestID)
+	events := m.notifier.events(1)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, CreditSettled, events[0].EventType)
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
+
+func TestCredit_ReconcileMissingInBalance(t *testing.T) {
+	m := newMocks()
+	newPendingRecord(m, "c1")
+	assert.Equal(t, "{\"Settled\":0,\"Failed\":1,\"Unresolved\":0}", reconcile(t, m))
+
+	record := m.transactions.records[recordKey("1", "c1")]
+	assert.Equal(t, TransactionFailed, record.Status)
+	assert.Equal(t, SettlementFailed, record.Code)
+	assert.Equal(t, 0, len(m.notifier.events(1)))
+
+	// The compensated credit can be sent again under the same key.
+	a := newWithClock(m, testNow)
+	res, err := a.TransactionWithContext(context.Background(), newTransactionInput("1", "c1", 1000))
+	assert.Nil(t, err)
+	assert.False(t, res.Error)
+	assert.Equal(t, 1, len(m.settlement.settled))
+}
+
+func TestCredit_ReconcileKeyUsedByDifferentEntry(t *testing.T) {
+	m := newMocks()
+	newPendingRecord(m, "c1")
+	m.settlement.entries = map[string]*GetSettlementOutput{
+		"c1": {Found: true, OperationType: Payment, Amount: 500, Currency: DefaultCurrency, RequestID: "r0"},
+	}
+	assert.Equal(t, "{\"Settled\":0,\"Failed\":1,\"Unresolved\":0}", reconcile(t, m))
+
+	record := m.transactions.records[recordKey("1", "c1")]
+	assert.Equal(t, TransactionFailed, record.Status)
+	assert.Equal(t, IdempotencyKeyReused, record.Code)
+}
+
+func TestCredit_NotReconcileWhenBalanceUnavailable(t *testing.T) {
+	m := newMocks()
+	newPendingRecord(m, "unavailable")
+	assert.Equal(t, "{\"Settled\":0,\"Failed\":0,\"Unresolved\":1}", reconcile(t, m))
+	assert.Equal(t, TransactionPending, m.transactions.records[recordKey("1", "unavailable")].Status)
+}
+
+// A retry started a new attempt after the reconciler listed the old one: closing the
+// old attempt must not touch the new one, whatever balance says.
+func TestCredit_NotReconcileAttemptRestartedSinceListed(t *testing.T) {
+	for _, found := range []bool{true, false} {
+		m := newMocks()
+		stale := *newPendingRecord(m, "c1")
+		m.transactions.records[recordKey("1", "c1")].AttemptedAt = testNow
+		m.transactions.listed = []*Transaction{&stale}
+		if found {
+			m.settlement.entries = map[string]*GetSettlementOutput{
+				"c1": {Found: true, OperationType: Payment, Amount: 1000, Currency: DefaultCurrency, RequestID: "r1"},
+			}
+		}
+		assert.Equal(t, "{\"Settled\":0,\"Failed\":0,\"Unresolved\":1}", reconcile(t, m))
+
+		record := m.transactions.records[recordKey("1", "c1")]
+		assert.Equal(t, TransactionPending, record.Status)
+		assert.Equal(t, testNow, record.AttemptedAt)
+		assert.Equal(t, 0, len(m.notifier.events(1)))
+	}
+}
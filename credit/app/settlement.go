@@ -1,9 +1,13 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Settlement interface {
 	SettleWithContext(ctx context.Context, input *SettleInput) (*SettleOutput, error)
+	GetSettlementWithContext(ctx context.Context, input *GetSettlementInput) (*GetSettlementOutput, error)
 }
 
 type SettleInput struct {
@@ -11,10 +15,35 @@ type SettleInput struct {
 	ExternalKey   string
 	OperationType string
 	Amount        int
+	Currency      string
+	Origin        string
+	RequestID     string
+	Description   string
+	Metadata      map[string]string
 }
 type SettleOutput struct {
 	HasIntermitance bool
 	Error           bool
 	Code            string
 	Detail          string
+	Amount          int
+	CreatedAt       time.Time
+	RequestID       string
+}
+
+type GetSettlementInput struct {
+	AccountKey  string
+	ExternalKey string
+	Origin      string
+}
+
+// GetSettlementOutput holds the ledger entry written under the key, if Found.
+type GetSettlementOutput struct {
+	HasIntermitance bool
+	Found           bool
+	OperationType   string
+	Amount          int
+	Currency        string
+	CreatedAt       time.Time
+	RequestID       string
 }
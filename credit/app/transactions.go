@@ -0,0 +1,236 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	TransactionPending  = "pending"
+	TransactionSettled  = "settled"
+	TransactionFailed   = "failed"
+	TransactionNotFound = "transaction-not-found"
+)
+
+// Transactions keeps the credits sent to balance. A credit is recorded as pending
+// before balance is called, so one whose answer never came back can still be
+// resolved later.
+type Transactions interface {
+	BeginWithContext(ctx context.Context, input *Transaction) (*BeginTransactionOutput, error)
+	FinishWithContext(ctx context.Context, input *FinishTransactionInput) (*FinishTransactionOutput, error)
+	GetWithContext(ctx context.Context, input *GetTransactionRecordInput) (*Transaction, error)
+	ListPendingWithContext(ctx context.Context, input *ListPendingTransactionsInput) (*ListPendingTransactionsOutput, error)
+}
+
+type Transaction struct {
+	AccountKey    string
+	ExternalKey   string
+	OperationType string
+	Amount        int
+	Currency      string
+	Status        string
+	Code          string
+	Detail        string
+	RequestID     string
+	CreatedAt     time.Time
+	AttemptedAt   time.Time
+	SettledAt     time.Time
+	RequestHash   string
+}
+
+// BeginTransactionOutput tells that the key is taken by a settled credit or by a
+// pending one with a different payload.
+type BeginTransactionOutput struct {
+	AlreadyExists bool
+}
+
+// FinishTransactionInput is matched against AttemptedAt, so only the attempt that
+// is still running gets closed.
+type FinishTransactionInput struct {
+	AccountKey  string
+	ExternalKey string
+	AttemptedAt time.Time
+	Status      string
+	Code        string
+	Detail      string
+	Amount      int
+	Currency    string
+	RequestID   string
+	SettledAt   time.Time
+}
+type FinishTransactionOutput struct {
+	TransactionChanged bool
+}
+
+type GetTransactionRecordInput struct {
+	AccountKey  string
+	ExternalKey string
+}
+
+type ListPendingTransactionsInput struct {
+	AttemptedBefore time.Time
+	Limit           int
+}
+type ListPendingTransactionsOutput struct {
+	Transactions []*Transaction
+}
+
+func transactionHash(t *Transaction) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s", t.OperationType, t.Amount, t.Currency)))
+	return hex.EncodeToString(h[:])
+}
+
+func settledOutput(t *Transaction) *TransactionOutput {
+	return &TransactionOutput{
+		Error:     false,
+		Amount:    t.Amount,
+		Currency:  t.Currency,
+		CreatedAt: t.SettledAt,
+		RequestID: t.RequestID,
+	}
+}
+
+// previousWithContext answers a retry of a settled credit from its record, without
+// authorizing or calling balance again. A key held by a different request is refused;
+// a failed or pending credit goes through again.
+func (a *credit) previousWithContext(ctx context.Context, record *Transaction) (*TransactionOutput, error) {
+	record.RequestHash = transactionHash(record)
+	t, err := a.transactions.GetWithContext(ctx, &GetTransactionRecordInput{
+		AccountKey:  record.AccountKey,
+		ExternalKey: record.ExternalKey,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("get transaction error %s", err.Error()))
+		return nil, err
+	}
+
+	if t == nil || t.Status == TransactionFailed {
+		return nil, nil
+	}
+	if t.RequestHash != record.RequestHash {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   IdempotencyKeyReused,
+			Detail: "external_key was already used for a different request",
+		}, nil
+	}
+	if t.Status == TransactionSettled {
+		return settledOutput(t), nil
+	}
+	return nil, nil
+}
+
+// beginWithContext returns the answer for the client when the attempt could not
+// start because another request holds the key.
+func (a *credit) beginWithContext(ctx context.Context, t *Transaction) (*TransactionOutput, error) {
+	t.Status = TransactionPending
+	t.AttemptedAt = a.clock()
+	t.RequestHash = transactionHash(t)
+	o, err := a.transactions.BeginWithContext(ctx, t)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("begin transaction error %s", err.Error()))
+		return nil, err
+	}
+	if !o.AlreadyExists {
+		return nil, nil
+	}
+
+	replay, err := a.previousWithContext(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	if replay != nil {
+		return replay, nil
+	}
+	// The record changed between the two reads; the client may retry.
+	return &TransactionOutput{
+		Error:  true,
+		Code:   UnauthorizedSettlement,
+		Detail: "Try again",
+	}, nil
+}
+
+// finishWithContext reports whether the record was closed. An error is only
+// logged: the reconciler closes whatever is left pending.
+func (a *credit) finishWithContext(ctx context.Context, t *Transaction, input *FinishTransactionInput) bool {
+	input.AccountKey = t.AccountKey
+	input.ExternalKey = t.ExternalKey
+	input.AttemptedAt = t.AttemptedAt
+	o, err := a.transactions.FinishWithContext(ctx, input)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("finish transaction error %s", err.Error()))
+		return false
+	}
+	return !o.TransactionChanged
+}
+
+func (a *credit) failWithContext(ctx context.Context, t *Transaction, o *TransactionOutput) *TransactionOutput {
+	a.finishWithContext(ctx, t, &FinishTransactionInput{
+		Status:   TransactionFailed,
+		Code:     o.Code,
+		Detail:   o.Detail,
+		Amount:   t.Amount,
+		Currency: t.Currency,
+	})
+	return o
+}
+
+// GetTransactionWithContext falls back to the ledger for a credit without a record,
+// such as one settled before the store existed.
+func (a *credit) GetTransactionWithContext(ctx context.Context, input *GetTransactionInput) (*GetTransactionOutput, error) {
+	t, err := a.transactions.GetWithContext(ctx, &GetTransactionRecordInput{
+		AccountKey:  input.AccountKey,
+		ExternalKey: input.ExternalKey,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("get transaction error %s", err.Error()))
+		return nil, err
+	}
+
+	if t != nil {
+		return &GetTransactionOutput{
+			Transaction: t,
+		}, nil
+	}
+
+	so, err := a.settlement.GetSettlementWithContext(ctx, &GetSettlementInput{
+		AccountKey:  input.AccountKey,
+		ExternalKey: input.ExternalKey,
+		Origin:      Origin,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("get settlement %s error %s", input.ExternalKey, err.Error()))
+		return nil, err
+	}
+	if so.HasIntermitance {
+		return &GetTransactionOutput{
+			Error:  true,
+			Code:   UnauthorizedSettlement,
+			Detail: "Try again",
+		}, nil
+	}
+	if !so.Found {
+		return &GetTransactionOutput{
+			Error:  true,
+			Code:   TransactionNotFound,
+			Detail: "transaction not found",
+		}, nil
+	}
+
+	return &GetTransactionOutput{
+		Transaction: &Transaction{
+			AccountKey:    input.AccountKey,
+			ExternalKey:   input.ExternalKey,
+			OperationType: so.OperationType,
+			Amount:        so.Amount,
+			Currency:      so.Currency,
+			Status:        TransactionSettled,
+			RequestID:     so.RequestID,
+			CreatedAt:     so.CreatedAt,
+			SettledAt:     so.CreatedAt,
+		},
+	}, nil
+}
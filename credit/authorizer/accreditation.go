@@ -3,10 +3,27 @@ package authorizer
 import (
 	"context"
 	"credit/app"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
+const (
+	AccountBlocked = "blocked"
+	AccountClosed  = "closed"
+)
+
+type AccountResponse struct {
+	Status     string    `json:"status,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	PersonType string    `json:"person_type,omitempty"`
+	Email      string    `json:"email,omitempty"`
+	Phone      string    `json:"phone,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+	Currencies []string  `json:"currencies,omitempty"`
+}
+
 type accreditation struct {
 	log         Logger
 	config      *Config
@@ -18,7 +35,7 @@ type Http interface {
 }
 
 func (a *accreditation) AuthorizeWithContext(ctx context.Context, input *app.AuthorizeInput) (*app.AuthorizeOutput, error) {
-	_, statusCode, err := a.httpService.GetWithContext(ctx, a.config.Url+input.AccountKey)
+	body, statusCode, err := a.httpService.GetWithContext(ctx, a.config.Url+input.AccountKey)
 	if err != nil {
 		a.log.Error(fmt.Sprintf("http get error %s", err.Error()))
 		return nil, err
@@ -28,15 +45,42 @@ func (a *accreditation) AuthorizeWithContext(ctx context.Context, input *app.Aut
 		return nil, nil
 	}
 
-	if statusCode >= http.StatusOK {
+	if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
 		return &app.AuthorizeOutput{
-			HasError: false,
+			HasError: true,
 		}, nil
 	}
 
-	return &app.AuthorizeOutput{
-		HasError: true,
-	}, nil
+	account := &AccountResponse{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, account); err != nil {
+			a.log.Error(fmt.Sprintf("account response error %s", err.Error()))
+			return &app.AuthorizeOutput{
+				HasError: true,
+			}, nil
+		}
+	}
+
+	o := &app.AuthorizeOutput{
+		HasError:   false,
+		Name:       account.Name,
+		PersonType: account.PersonType,
+		Email:      account.Email,
+		Phone:      account.Phone,
+		CreatedAt:  account.CreatedAt,
+		Currencies: account.Currencies,
+	}
+
+	switch account.Status {
+	case AccountBlocked:
+		o.HasError = true
+		o.Code = app.AccountBlocked
+	case AccountClosed:
+		o.HasError = true
+		o.Code = app.AccountClosed
+	}
+
+	return o, nil
 }
 
 func New(log Logger, config *Config, httpService Http) app.Authorizer {
@@ -3,8 +3,12 @@ package logger
 import (
 	"credit/app"
 	"credit/authorizer"
+	"credit/notifier"
+	"credit/reconciler"
+	"credit/repository"
 	"credit/routes"
 	"credit/server"
+	"credit/services"
 	"credit/settlement"
 	"log"
 )
@@ -23,6 +27,6 @@ func (l *logs) Error(msg string) {
 	log.Print(msg)
 }
 
-func New() (app.Logger, server.Logger, routes.Logger, authorizer.Logger, settlement.Logger) {
-	return &logs{}, &logs{}, &logs{}, &logs{}, &logs{}
+func New() (app.Logger, server.Logger, routes.Logger, authorizer.Logger, settlement.Logger, notifier.Logger, services.Logger, repository.Logger, reconciler.Logger) {
+	return &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}
 }
@@ -4,6 +4,9 @@ import (
 	"credit/app"
 	"credit/authorizer"
 	"credit/logger"
+	"credit/notifier"
+	"credit/reconciler"
+	"credit/repository"
 	"credit/routes"
 	"credit/server"
 	"credit/services"
@@ -12,16 +15,34 @@ import (
 )
 
 func main() {
-	logApp, logServer, logRoutes, logAuthorizer, logSettlement := logger.New()
-	accreditationHttp, settlementHttp := services.NewHttp()
+	logApp, logServer, logRoutes, logAuthorizer, logSettlement, logNotifier, logServices, logDynamodb, logReconciler := logger.New()
+	confHttp := &services.HttpConfig{}
+	confHttp.WithTimeout(os.Getenv("HTTP_TIMEOUT"))
+	confHttp.WithMaxAttempts(os.Getenv("HTTP_MAX_ATTEMPTS"))
+	confHttp.WithRetryBase(os.Getenv("HTTP_RETRY_BASE"))
+	confHttp.WithFailureThreshold(os.Getenv("BREAKER_FAILURE_THRESHOLD"))
+	confHttp.WithOpenTimeout(os.Getenv("BREAKER_OPEN_TIMEOUT"))
+	accreditationHttp, settlementHttp, notifierHttp, diagnostics := services.NewHttp(confHttp, logServices)
 	confAuthorizer := &authorizer.Config{}
 	confAuthorizer.WithUrl(os.Getenv("URL_ACCREDITATION"))
 	accreditation := authorizer.New(logAuthorizer, confAuthorizer, accreditationHttp)
 	confSettlement := &settlement.Config{}
 	confSettlement.WithUrl(os.Getenv("URL_BALANCE"))
+	confSettlement.WithTransactionsUrl(os.Getenv("URL_BALANCE_ACCOUNTS"))
 	balance := settlement.New(logSettlement, confSettlement, settlementHttp)
-	credit := app.New(accreditation, balance, logApp)
-	routes := routes.New(credit, logRoutes)
+	dynamodbConfig := repository.Config{}
+	dynamodbConfig.WithTransactionTableName(os.Getenv("TRANSACTION_TABLE_NAME"))
+	transactions := repository.NewTransactions(services.NewDynamodb(), logDynamodb, dynamodbConfig)
+	confNotifier := &notifier.Config{}
+	confNotifier.WithUrl(os.Getenv("URL_WEBHOOK_EVENTS"))
+	webhook := notifier.New(logNotifier, confNotifier, notifierHttp)
+	credit := app.New(accreditation, balance, transactions, webhook, logApp)
+	confReconciler := &reconciler.Config{}
+	confReconciler.WithInterval(os.Getenv("RECONCILE_INTERVAL"))
+	confReconciler.WithAfter(os.Getenv("RECONCILE_AFTER"))
+	rec := reconciler.New(credit, logReconciler, confReconciler)
+	go rec.Start()
+	routes := routes.New(credit, diagnostics, logRoutes)
 	serverHttp := server.New(routes, logServer)
 	serverHttp.Start()
 }
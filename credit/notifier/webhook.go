@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"context"
+	"credit/app"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type webhook struct {
+	log         Logger
+	config      *Config
+	httpService Http
+}
+
+type Http interface {
+	PostWithContext(ctx context.Context, url string, payload []byte) ([]byte, int, error)
+}
+
+type TransactionPayload struct {
+	ExternalKey   string `json:"external_key"`
+	OperationType string `json:"operation_type"`
+	Amount        int    `json:"amount"`
+	Currency      string `json:"currency"`
+	RequestID     string `json:"request_id,omitempty"`
+}
+
+type EventPayload struct {
+	EventID    string              `json:"event_id"`
+	EventType  string              `json:"event_type"`
+	AccountKey string              `json:"account_key"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Data       *TransactionPayload `json:"data"`
+}
+
+// NotifyWithContext posts within ctx, which bounds the attempts of the http
+// client; the app decides how long a notification may take.
+func (w *webhook) NotifyWithContext(ctx context.Context, input *app.NotifyInput) error {
+	if w.config.Url == "" {
+		return nil
+	}
+
+	pb, err := json.Marshal(&EventPayload{
+		EventID:    input.EventID,
+		EventType:  input.EventType,
+		AccountKey: input.AccountKey,
+		OccurredAt: input.OccurredAt,
+		Data: &TransactionPayload{
+			ExternalKey:   input.ExternalKey,
+			OperationType: input.OperationType,
+			Amount:        input.Amount,
+			Currency:      input.Currency,
+			RequestID:     input.RequestID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, statusCode, err := w.httpService.PostWithContext(ctx, w.config.Url, pb)
+	if err != nil {
+		return err
+	}
+	if statusCode != http.StatusAccepted {
+		return fmt.Errorf("webhook returned status %d", statusCode)
+	}
+	return nil
+}
+
+func New(log Logger, config *Config, httpService Http) app.Notifier {
+	return &webhook{
+		log:         log,
+		config:      config,
+		httpService: httpService,
+	}
+}
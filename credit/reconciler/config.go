@@ -0,0 +1,28 @@
+package reconciler
+
+import "time"
+
+type Config struct {
+	Interval time.Duration
+	After    time.Duration
+}
+
+func (c *Config) WithInterval(interval string) *Config {
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		d = 30 * time.Second
+	}
+	c.Interval = d
+	return c
+}
+
+// WithAfter sets how old a pending credit must be before balance is asked about
+// it, long enough for every retry of the original call to have finished.
+func (c *Config) WithAfter(after string) *Config {
+	d, err := time.ParseDuration(after)
+	if err != nil || d <= 0 {
+		d = time.Minute
+	}
+	c.After = d
+	return c
+}
@@ -0,0 +1,10 @@
+package repository
+
+type Config struct {
+	TransactionTableName string
+}
+
+func (c *Config) WithTransactionTableName(tableName string) *Config {
+	c.TransactionTableName = tableName
+	return c
+}
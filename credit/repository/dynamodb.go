@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+)
+
+// timeLayout has a fixed width, so the times sort as strings.
+const timeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+type Dynamodb interface {
+	UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func conditionalCheckFailed(err error) bool {
+	ae, ok := err.(awserr.RequestFailure)
+	return ok && ae.Code() == "ConditionalCheckFailedException"
+}
+
+func stringAttribute(item map[string]*dynamodb.AttributeValue, name string) string {
+	if v, ok := item[name]; ok && v != nil {
+		return aws.StringValue(v.S)
+	}
+	return ""
+}
+
+func intAttribute(item map[string]*dynamodb.AttributeValue, name string) (int, error) {
+	if v, ok := item[name]; ok && v != nil && v.N != nil {
+		return strconv.Atoi(aws.StringValue(v.N))
+	}
+	return 0, nil
+}
+
+func timeAttribute(item map[string]*dynamodb.AttributeValue, name string) (time.Time, error) {
+	if s := stringAttribute(item, name); s != "" {
+		return time.Parse(time.RFC3339, s)
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"credit/app"
+	"net/http"
+	"time"
+)
+
+type CircuitBreakerResponse struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+type DiagnosticsResponse struct {
+	CircuitBreakers []*CircuitBreakerResponse `json:"circuit_breakers"`
+}
+
+func diagnostics(d app.Diagnostics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		res := &DiagnosticsResponse{
+			CircuitBreakers: []*CircuitBreakerResponse{},
+		}
+		for _, b := range d.CircuitBreakersWithContext(r.Context()) {
+			cb := &CircuitBreakerResponse{
+				Name:                b.Name,
+				State:               b.State,
+				ConsecutiveFailures: b.ConsecutiveFailures,
+			}
+			if !b.OpenedAt.IsZero() {
+				openedAt := b.OpenedAt
+				cb.OpenedAt = &openedAt
+			}
+			res.CircuitBreakers = append(res.CircuitBreakers, cb)
+		}
+
+		writeResponse(w, http.StatusOK, res)
+	})
+}
@@ -2,17 +2,23 @@ package routes
 
 import (
 	"credit/app"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
+const RequestIDHeader = "X-Request-Id"
+
 type Routes interface {
 	Default() *http.ServeMux
 }
 
 type routes struct {
-	credit app.Credit
-	log    Logger
+	credit      app.Credit
+	diagnostics app.Diagnostics
+	log         Logger
 }
 
 func healthz() http.Handler {
@@ -21,33 +27,78 @@ func healthz() http.Handler {
 	})
 }
 
+func writeResponse(w http.ResponseWriter, statusCode int, v interface{}) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(res); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
 func transactions(a app.Credit, log Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			ctx := r.Context()
-			accountResponse, err := transactionWithContext(ctx, r.Body, log, a)
+			id := requestID(r)
+			w.Header().Set(RequestIDHeader, id)
+			o, errorResponse, err := transactionWithContext(ctx, id, r.Body, log, a)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
 
-			if accountResponse != nil {
-				res, err := json.Marshal(accountResponse)
-				if err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(accountResponse.Error.StatusCode)
-				w.Header().Set("Content-Type", "application/json")
-				if _, err := w.Write(res); err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+
+			writeResponse(w, http.StatusCreated, o)
+		} else {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func transaction(a app.Credit, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/transactions/"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			o, errorResponse, err := transactionStatusWithContext(r.Context(), parts[0], parts[1], log, a)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
 
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
 				return
 			}
 
-			w.WriteHeader(http.StatusCreated)
+			writeResponse(w, http.StatusOK, o)
 		} else {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
@@ -57,13 +108,16 @@ func transactions(a app.Credit, log Logger) http.Handler {
 func (r *routes) Default() *http.ServeMux {
 	middleware := http.NewServeMux()
 	middleware.Handle("/v1/transactions", transactions(r.credit, r.log))
+	middleware.Handle("/v1/transactions/", transaction(r.credit, r.log))
+	middleware.Handle("/diagnostics", diagnostics(r.diagnostics))
 	middleware.Handle("/health", healthz())
 	return middleware
 }
 
-func New(a app.Credit, log Logger) Routes {
+func New(a app.Credit, d app.Diagnostics, log Logger) Routes {
 	return &routes{
-		credit: a,
-		log:    log,
+		credit:      a,
+		diagnostics: d,
+		log:         log,
 	}
 }
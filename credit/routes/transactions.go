@@ -5,16 +5,25 @@ import (
 	"context"
 	"credit/app"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
 const (
-	BadRequest     = "bad_request"
-	Conflict       = "conflict"
-	InvalidRequest = "invalid_request"
-	BadGateway     = "bad_gateway"
-	NotFound       = "not_found"
+	BadRequest          = "bad_request"
+	Conflict            = "conflict"
+	InvalidRequest      = "invalid_request"
+	BadGateway          = "bad_gateway"
+	NotFound            = "not_found"
+	UnprocessableEntity = "unprocessable_entity"
+
+	MaxDescriptionLength   = 255
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 255
 )
 
 func stringValue(v *string) string {
@@ -35,12 +44,26 @@ type TransactionRequest struct {
 	AccountKey  *string `json:"account_key,omitempty"`
 	ExternalKey *string `json:"external_key,omitempty"`
 	Amount      *int    `json:"amount,omitempty"`
+	Currency    *string `json:"currency,omitempty"`
+
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type TransactionResponse struct {
+	AccountKey  string    `json:"account_key"`
+	ExternalKey string    `json:"external_key"`
+	Amount      int       `json:"amount"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"created_at"`
+	RequestID   string    `json:"request_id,omitempty"`
 }
 
 type TransactionError struct {
 	StatusCode int    `json:"-"`
 	Type       string `json:"type,omitempty"`
 	Category   string `json:"category,omitempty"`
+	Code       string `json:"code,omitempty"`
 	Message    string `json:"message,omitempty"`
 }
 
@@ -61,6 +84,46 @@ func responseBuild(msg string, statusCode int, category string) *TransactionErro
 	return ae
 }
 
+func codeResponseBuild(code string, msg string, statusCode int, category string) *TransactionErrorResponse {
+	ae := responseBuild(msg, statusCode, category)
+	ae.Error.Code = code
+	return ae
+}
+
+func validateMetadata(description string, metadata map[string]string) *TransactionErrorResponse {
+	if len(description) > MaxDescriptionLength {
+		return responseBuild(fmt.Sprintf("description must have at most %d characters", MaxDescriptionLength), http.StatusBadRequest, BadRequest)
+	}
+
+	if len(metadata) > MaxMetadataKeys {
+		return responseBuild(fmt.Sprintf("metadata must have at most %d keys", MaxMetadataKeys), http.StatusBadRequest, BadRequest)
+	}
+
+	for k, v := range metadata {
+		if k == "" || len(k) > MaxMetadataKeyLength {
+			return responseBuild(fmt.Sprintf("metadata keys must have between 1 and %d characters", MaxMetadataKeyLength), http.StatusBadRequest, BadRequest)
+		}
+		if len(v) > MaxMetadataValueLength {
+			return responseBuild(fmt.Sprintf("metadata values must have at most %d characters", MaxMetadataValueLength), http.StatusBadRequest, BadRequest)
+		}
+	}
+
+	return nil
+}
+
+// validateCurrency only checks the ISO 4217 shape; balance knows which currencies it supports.
+func validateCurrency(currency string) *TransactionErrorResponse {
+	if currency == "" {
+		return nil
+	}
+
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return codeResponseBuild(app.CurrencyInvalid, "currency must be an ISO 4217 code such as BRL", http.StatusBadRequest, BadRequest)
+	}
+
+	return nil
+}
+
 func buildTransactionRequest(a []byte) (*TransactionRequest, *TransactionErrorResponse) {
 	va := &TransactionRequest{}
 
@@ -81,10 +144,18 @@ func buildTransactionRequest(a []byte) (*TransactionRequest, *TransactionErrorRe
 		return nil, responseBuild("amount is missing or 0", http.StatusBadRequest, BadRequest)
 	}
 
+	if errorResponse := validateCurrency(stringValue(va.Currency)); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	if errorResponse := validateMetadata(stringValue(va.Description), va.Metadata); errorResponse != nil {
+		return nil, errorResponse
+	}
+
 	return va, nil
 }
 
-func transactionWithContext(ctx context.Context, body io.ReadCloser, log Logger, a app.Credit) (*TransactionErrorResponse, error) {
+func transactionWithContext(ctx context.Context, requestID string, body io.ReadCloser, log Logger, a app.Credit) (*TransactionResponse, *TransactionErrorResponse, error) {
 	defer body.Close()
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(body)
@@ -93,36 +164,63 @@ func transactionWithContext(ctx context.Context, body io.ReadCloser, log Logger,
 	request, errorResponse := buildTransactionRequest(b)
 
 	if errorResponse != nil {
-		return errorResponse, nil
+		return nil, errorResponse, nil
 	}
 
 	i := &app.TransactionInput{
 		AccountKey:  stringValue(request.AccountKey),
 		ExternalKey: stringValue(request.ExternalKey),
 		Amount:      intValue(request.Amount),
+		Currency:    stringValue(request.Currency),
+		RequestID:   requestID,
+		Description: stringValue(request.Description),
+		Metadata:    request.Metadata,
 	}
 
 	res, err := a.TransactionWithContext(ctx, i)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if res != nil && res.Error && res.Code == app.UnauthorizedTransaction {
-		return responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
+		return nil, responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
 	}
 
 	if res != nil && res.Error && res.Code == app.UnauthorizedSettlement {
-		return responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
+		return nil, responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
 	}
 
 	if res != nil && res.Error && res.Code == app.AuthorizerNotFound {
-		return responseBuild("Account Key not found", http.StatusNotFound, NotFound), nil
+		return nil, responseBuild("Account Key not found", http.StatusNotFound, NotFound), nil
+	}
+
+	if res != nil && res.Error && (res.Code == app.AccountBlocked || res.Code == app.AccountClosed) {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.CurrencyInvalid {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.CurrencyNotHeld {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.IdempotencyKeyReused {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict), nil
 	}
 
 	if res != nil && res.Error && res.Code == app.SettlementFailed {
-		return responseBuild(res.Detail, http.StatusConflict, Conflict), nil
+		return nil, responseBuild(res.Detail, http.StatusConflict, Conflict), nil
 	}
 
-	return nil, nil
+	return &TransactionResponse{
+		AccountKey:  i.AccountKey,
+		ExternalKey: i.ExternalKey,
+		Amount:      res.Amount,
+		Currency:    res.Currency,
+		CreatedAt:   res.CreatedAt,
+		RequestID:   res.RequestID,
+	}, nil, nil
 }
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"credit/repository"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type db struct {
+	svc *dynamodb.DynamoDB
+}
+
+func (d *db) UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	return d.svc.UpdateItemWithContext(ctx, input)
+}
+
+func (d *db) GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return d.svc.GetItemWithContext(ctx, input)
+}
+
+func (d *db) QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	return d.svc.QueryWithContext(ctx, input)
+}
+
+func NewDynamodb() repository.Dynamodb {
+	mySession := session.Must(session.NewSession())
+	svc := dynamodb.New(mySession, aws.NewConfig().WithRegion("us-east-1"), aws.NewConfig().WithEndpoint("http://localstack:4566"))
+	return &db{
+		svc: svc,
+	}
+}
@@ -3,50 +3,193 @@ package services
 import (
 	"bytes"
 	"context"
+	"credit/app"
 	"credit/authorizer"
+	"credit/notifier"
 	"credit/settlement"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"sync"
+	"time"
 )
 
-type httpService struct{}
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// httpService calls one downstream. All of them share the same pooled client, and
+// each has its own breaker so one slow service does not cut off the others.
+type httpService struct {
+	client  *http.Client
+	config  *HttpConfig
+	breaker *breaker
+	// retryPost is only set for downstreams whose POSTs carry an idempotency key,
+	// so sending one twice cannot apply it twice.
+	retryPost bool
+}
+
+var (
+	jitterMu sync.Mutex
+	jitter   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
 
 func (h *httpService) GetWithContext(ctx context.Context, url string) ([]byte, int, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	return h.doWithContext(ctx, http.MethodGet, url, nil, true)
+}
+
+func (h *httpService) PostWithContext(ctx context.Context, url string, payload []byte) ([]byte, int, error) {
+	return h.doWithContext(ctx, http.MethodPost, url, payload, h.retryPost)
+}
+
+func (h *httpService) doWithContext(ctx context.Context, method string, url string, payload []byte, retry bool) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, 0, err
 	}
-
 	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{}
-	resp, err := client.Do(req)
+
+	attempts := 1
+	if retry {
+		attempts = h.config.MaxAttempts
+	}
+
+	var body []byte
+	var statusCode int
+	for i := 0; i < attempts; i++ {
+		if i > 0 && !sleepWithContext(ctx, h.backoff(i)) {
+			break
+		}
+		if !h.breaker.allow() {
+			if i == 0 {
+				return nil, 0, fmt.Errorf("%s: %w", h.breaker.name, ErrCircuitOpen)
+			}
+			break
+		}
+
+		body, statusCode, err = h.attemptWithContext(ctx, req, payload)
+		if ctx.Err() != nil {
+			h.breaker.release()
+			break
+		}
+		if err != nil || statusCode >= http.StatusInternalServerError {
+			h.breaker.failure()
+		} else {
+			h.breaker.success()
+		}
+		if !retryable(statusCode, err) {
+			break
+		}
+	}
+
+	return body, statusCode, err
+}
+
+func (h *httpService) attemptWithContext(ctx context.Context, req *http.Request, payload []byte) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+
+	r := req.Clone(ctx)
+	if payload != nil {
+		r.Body = ioutil.NopCloser(bytes.NewReader(payload))
+		r.ContentLength = int64(len(payload))
+	}
+
+	resp, err := h.client.Do(r)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer resp.Body.Close()
 	bt, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	return bt, resp.StatusCode, nil
 }
 
-func (h *httpService) PostWithContext(ctx context.Context, url string, payload []byte) ([]byte, int, error) {
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
-	if err != nil {
-		return nil, 0, err
+// backoff doubles from RetryBase up to MaxRetryInterval and picks a random point in
+// the upper half, so callers that failed together do not retry together.
+func (h *httpService) backoff(attempt int) time.Duration {
+	d := h.config.RetryBase
+	for i := 1; i < attempt && d < MaxRetryInterval; i++ {
+		d *= 2
+	}
+	if d > MaxRetryInterval {
+		d = MaxRetryInterval
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return d/2 + time.Duration(jitter.Int63n(int64(d/2)+1))
+}
+
+// retryable only covers answers that mean the request did not get through or the
+// downstream could not take it at the moment.
+func retryable(statusCode int, err error) bool {
 	if err != nil {
-		return nil, 0, err
+		return true
 	}
-	defer resp.Body.Close()
-	bt, err := ioutil.ReadAll(resp.Body)
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
 
-	return bt, resp.StatusCode, nil
+func sleepWithContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
 }
 
-func NewHttp() (authorizer.Http, settlement.Http) {
-	return &httpService{}, &httpService{}
+type diagnostics struct {
+	breakers []*breaker
+}
+
+func (d *diagnostics) CircuitBreakersWithContext(ctx context.Context) []*app.CircuitBreakerOutput {
+	o := make([]*app.CircuitBreakerOutput, 0, len(d.breakers))
+	for _, b := range d.breakers {
+		o = append(o, b.output())
+	}
+	return o
+}
+
+func newHttpClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return &http.Client{
+		Transport: transport,
+	}
+}
+
+// NewHttp marks balance and webhook POSTs as safe to retry: balance settles each
+// external_key once and webhook stores each event_id once.
+func NewHttp(config *HttpConfig, log Logger) (authorizer.Http, settlement.Http, notifier.Http, app.Diagnostics) {
+	client := newHttpClient()
+	accreditation := &httpService{
+		client:  client,
+		config:  config,
+		breaker: newBreaker("accreditation", config, log),
+	}
+	balance := &httpService{
+		client:    client,
+		config:    config,
+		breaker:   newBreaker("balance", config, log),
+		retryPost: true,
+	}
+	webhook := &httpService{
+		client:    client,
+		config:    config,
+		breaker:   newBreaker("webhook", config, log),
+		retryPost: true,
+	}
+	return accreditation, balance, webhook, &diagnostics{
+		breakers: []*breaker{accreditation.breaker, balance.breaker, webhook.breaker},
+	}
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 type balance struct {
@@ -15,6 +16,7 @@ type balance struct {
 }
 
 type Http interface {
+	GetWithContext(ctx context.Context, url string) ([]byte, int, error)
 	PostWithContext(ctx context.Context, url string, payload []byte) ([]byte, int, error)
 }
 
@@ -23,11 +25,24 @@ type BalanceRequestPayload struct {
 	ExternalKey   string `json:"external_key,omitempty"`
 	OperationType string `json:"operation_type,omitempty"`
 	Amount        int    `json:"amount,omitempty"`
+	Currency      string `json:"currency,omitempty"`
+
+	Origin      string            `json:"origin,omitempty"`
+	RequestID   string            `json:"request_id,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type BalanceResponse struct {
+	Amount    int       `json:"amount,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
 }
 
 type BalanceError struct {
 	Type     string `json:"type,omitempty"`
 	Category string `json:"category,omitempty"`
+	Code     string `json:"code,omitempty"`
 	Message  string `json:"message,omitempty"`
 }
 
@@ -41,6 +56,12 @@ func (b *balance) SettleWithContext(ctx context.Context, input *app.SettleInput)
 		ExternalKey:   input.ExternalKey,
 		OperationType: input.OperationType,
 		Amount:        input.Amount,
+		Currency:      input.Currency,
+
+		Origin:      input.Origin,
+		RequestID:   input.RequestID,
+		Description: input.Description,
+		Metadata:    input.Metadata,
 	}
 	pb, err := json.Marshal(payload)
 	res, statusCode, err := b.httpService.PostWithContext(ctx, b.config.Url, pb)
@@ -56,21 +77,53 @@ func (b *balance) SettleWithContext(ctx context.Context, input *app.SettleInput)
 			b.log.Error(fmt.Sprintf("http post error %s", err.Error()))
 			return nil, err
 		}
+		code := be.Error.Type
+		if be.Error.Code == app.IdempotencyKeyReused || be.Error.Code == app.CurrencyInvalid {
+			code = be.Error.Code
+		}
 		return &app.SettleOutput{
 			HasIntermitance: false,
 			Error:           true,
-			Code:            be.Error.Type,
+			Code:            code,
 			Detail:          be.Error.Message,
 		}, nil
 	}
 
-	if statusCode >= http.StatusCreated {
+	if statusCode == http.StatusNotFound || statusCode == http.StatusUnprocessableEntity {
+		be := &BalanceResponseError{}
+		if err := json.Unmarshal(res, be); err != nil || be.Error == nil {
+			b.log.Error(fmt.Sprintf("http post error invalid error body with status %d", statusCode))
+			return &app.SettleOutput{
+				HasIntermitance: true,
+			}, nil
+		}
+		return &app.SettleOutput{
+			HasIntermitance: false,
+			Error:           true,
+			Code:            be.Error.Code,
+			Detail:          be.Error.Message,
+		}, nil
+	}
+
+	if statusCode == http.StatusCreated {
+		br := &BalanceResponse{}
+		if len(res) > 0 {
+			if err := json.Unmarshal(res, br); err != nil {
+				b.log.Error(fmt.Sprintf("http post error %s", err.Error()))
+				return nil, err
+			}
+		}
 		return &app.SettleOutput{
 			HasIntermitance: false,
 			Error:           false,
+			Amount:          br.Amount,
+			CreatedAt:       br.CreatedAt,
+			RequestID:       br.RequestID,
 		}, nil
 	}
 
+	// A 5xx may come after balance wrote the entry, so the outcome is unknown.
+	b.log.Error(fmt.Sprintf("settle status code %d", statusCode))
 	return &app.SettleOutput{
 		HasIntermitance: true,
 	}, nil
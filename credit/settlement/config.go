@@ -1,10 +1,16 @@
 package settlement
 
 type Config struct {
-	Url string
+	Url             string
+	TransactionsUrl string
 }
 
 func (c *Config) WithUrl(url string) *Config {
 	c.Url = url
 	return c
 }
+
+func (c *Config) WithTransactionsUrl(url string) *Config {
+	c.TransactionsUrl = url
+	return c
+}
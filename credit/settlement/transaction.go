@@ -0,0 +1,73 @@
+package settlement
+
+import (
+	"context"
+	"credit/app"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultCurrency     = "BRL"
+	transactionNotFound = "transaction-not-found"
+)
+
+type TransactionDetailResponse struct {
+	OperationType string    `json:"operation_type"`
+	Amount        int       `json:"amount"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"created_at"`
+	RequestID     string    `json:"request_id,omitempty"`
+}
+
+// GetSettlementWithContext takes a 404 as "never written" only with the
+// transaction-not-found code; anything else keeps the outcome unknown.
+func (b *balance) GetSettlementWithContext(ctx context.Context, input *app.GetSettlementInput) (*app.GetSettlementOutput, error) {
+	query := url.Values{
+		"origin": {input.Origin},
+	}
+	u := fmt.Sprintf("%s%s/transactions/%s?%s", b.config.TransactionsUrl, url.PathEscape(input.AccountKey), url.PathEscape(input.ExternalKey), query.Encode())
+	res, statusCode, err := b.httpService.GetWithContext(ctx, u)
+	if err != nil {
+		b.log.Error(fmt.Sprintf("http get error %s", err.Error()))
+		return nil, err
+	}
+
+	if statusCode == http.StatusNotFound {
+		be := &BalanceResponseError{}
+		if err := json.Unmarshal(res, be); err == nil && be.Error != nil && be.Error.Code == transactionNotFound {
+			return &app.GetSettlementOutput{
+				Found: false,
+			}, nil
+		}
+	}
+
+	if statusCode != http.StatusOK {
+		b.log.Error(fmt.Sprintf("get settlement status code %d", statusCode))
+		return &app.GetSettlementOutput{
+			HasIntermitance: true,
+		}, nil
+	}
+
+	tr := &TransactionDetailResponse{}
+	if err := json.Unmarshal(res, tr); err != nil {
+		b.log.Error(fmt.Sprintf("get settlement response error %s", err.Error()))
+		return nil, err
+	}
+
+	currency := tr.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	return &app.GetSettlementOutput{
+		Found:         true,
+		OperationType: tr.OperationType,
+		Amount:        tr.Amount,
+		Currency:      currency,
+		CreatedAt:     tr.CreatedAt,
+		RequestID:     tr.RequestID,
+	}, nil
+}
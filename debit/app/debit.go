@@ -1,9 +1,18 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Debit interface {
 	TransactionWithContext(ctx context.Context, input *TransactionInput) (*TransactionOutput, error)
+	ReverseWithContext(ctx context.Context, input *ReverseInput) (*TransactionOutput, error)
+	AuthorizationWithContext(ctx context.Context, input *TransactionInput) (*TransactionOutput, error)
+	CaptureWithContext(ctx context.Context, input *CaptureInput) (*TransactionOutput, error)
+	VoidWithContext(ctx context.Context, input *VoidInput) (*TransactionOutput, error)
+	GetTransactionWithContext(ctx context.Context, input *GetTransactionInput) (*GetTransactionOutput, error)
+	ReconcileWithContext(ctx context.Context, input *ReconcileInput) (*ReconcileOutput, error)
 }
 
 type TransactionInput struct {
@@ -11,10 +20,74 @@ type TransactionInput struct {
 	ExternalKey   string
 	OperationType string
 	Amount        int
+	Currency      string
+	Installments  int
+	RequestID     string
+	Description   string
+	Metadata      map[string]string
+}
+
+type ReverseInput struct {
+	AccountKey  string
+	ExternalKey string
+	ReversalKey string
+	Amount      int
+	RequestID   string
+	Description string
+	Metadata    map[string]string
+}
+
+// CaptureInput captures the whole authorization when Amount is zero.
+type CaptureInput struct {
+	AccountKey  string
+	ExternalKey string
+	Amount      int
+	RequestID   string
+	Description string
+	Metadata    map[string]string
+}
+
+type VoidInput struct {
+	AccountKey  string
+	ExternalKey string
 }
 
 type TransactionOutput struct {
-	Error  bool
-	Code   string
-	Detail string
+	Error          bool
+	Code           string
+	Detail         string
+	Amount         int
+	Currency       string
+	CreatedAt      time.Time
+	RequestID      string
+	Decision       string
+	RuleID         string
+	RiskScore      int
+	Status         string
+	CapturedAmount int
+	ExpiresAt      time.Time
+}
+
+type GetTransactionInput struct {
+	AccountKey  string
+	ExternalKey string
+}
+
+type GetTransactionOutput struct {
+	Error       bool
+	Code        string
+	Detail      string
+	Transaction *Transaction
+}
+
+// ReconcileInput leaves alone the transactions attempted after AttemptedBefore,
+// whose answer from balance may still be on its way.
+type ReconcileInput struct {
+	AttemptedBefore time.Time
+}
+
+type ReconcileOutput struct {
+	Settled    int
+	Failed     int
+	Unresolved int
 }
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// Diagnostics reports the circuit breakers guarding the calls to other services.
+type Diagnostics interface {
+	CircuitBreakersWithContext(ctx context.Context) []*CircuitBreakerOutput
+}
+
+type CircuitBreakerOutput struct {
+	Name                string
+	State               string
+	ConsecutiveFailures int
+	OpenedAt            time.Time
+}
@@ -0,0 +1,251 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	HoldInvalid        = "hold-invalid"
+	HoldNotFound       = "hold-not-found"
+	HoldNotActive      = "hold-not-active"
+	HoldExpired        = "hold-expired"
+	CaptureExceedsHold = "capture-exceeds-hold"
+)
+
+// Holds reserves funds in balance for an authorization until it is captured,
+// voided or expires.
+type Holds interface {
+	HoldWithContext(ctx context.Context, input *HoldInput) (*HoldOutput, error)
+	GetHoldWithContext(ctx context.Context, input *GetHoldInput) (*HoldOutput, error)
+	CaptureHoldWithContext(ctx context.Context, input *CaptureInput) (*HoldOutput, error)
+	VoidHoldWithContext(ctx context.Context, input *VoidInput) (*HoldOutput, error)
+	HeldWithContext(ctx context.Context, input *HeldInput) (*HeldOutput, error)
+}
+
+type HoldInput struct {
+	AccountKey    string
+	ExternalKey   string
+	OperationType string
+	Amount        int
+	Currency      string
+	Origin        string
+	RequestID     string
+	Description   string
+	Metadata      map[string]string
+	RiskScore     int
+}
+
+type GetHoldInput struct {
+	AccountKey  string
+	ExternalKey string
+}
+
+type HoldOutput struct {
+	HasIntermitance bool
+	Error           bool
+	Code            string
+	Detail          string
+	OperationType   string
+	Status          string
+	Amount          int
+	Currency        string
+	CapturedAmount  int
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+	RequestID       string
+	RiskScore       int
+}
+
+type HeldInput struct {
+	AccountKey string
+	Currency   string
+}
+
+// HeldOutput has the sum of the active authorizations of the account in the currency.
+type HeldOutput struct {
+	HasIntermitance bool
+	Amount          int
+}
+
+// holdRefused maps a hold that balance did not accept to the debit answer.
+func holdRefused(ho *HoldOutput) *TransactionOutput {
+	if ho.HasIntermitance {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   UnauthorizedSettlement,
+			Detail: "Try again",
+		}
+	}
+
+	switch ho.Code {
+	case InsufficientFunds, IdempotencyKeyReused, CurrencyInvalid, HoldInvalid, HoldNotFound, HoldNotActive, HoldExpired, CaptureExceedsHold:
+		return &TransactionOutput{
+			Error:  true,
+			Code:   ho.Code,
+			Detail: ho.Detail,
+		}
+	}
+
+	return &TransactionOutput{
+		Error:  true,
+		Code:   SettlementFailed,
+		Detail: ho.Detail,
+	}
+}
+
+func holdTransactionOutput(ho *HoldOutput) *TransactionOutput {
+	return &TransactionOutput{
+		Error:          false,
+		Amount:         ho.Amount,
+		Currency:       ho.Currency,
+		CreatedAt:      ho.CreatedAt,
+		RequestID:      ho.RequestID,
+		Status:         ho.Status,
+		CapturedAmount: ho.CapturedAmount,
+		ExpiresAt:      ho.ExpiresAt,
+	}
+}
+
+// AuthorizationWithContext runs a Buying through the same checks as a transaction
+// but only places a hold on the funds, to be captured or voided later.
+func (a *debit) AuthorizationWithContext(ctx context.Context, input *TransactionInput) (*TransactionOutput, error) {
+	if input.OperationType != Buying || input.Installments > 1 {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   OperationTypeInvalid,
+			Detail: fmt.Sprintf("only %s can be authorized", Buying),
+		}, nil
+	}
+
+	replay, err := a.previousHoldWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if replay != nil {
+		return replay, nil
+	}
+
+	ap, refused, err := a.approveWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if refused != nil {
+		return refused, nil
+	}
+
+	ho, err := a.holds.HoldWithContext(ctx, &HoldInput{
+		AccountKey:    input.AccountKey,
+		ExternalKey:   input.ExternalKey,
+		OperationType: input.OperationType,
+		Amount:        input.Amount,
+		Currency:      currencyOrDefault(input.Currency),
+		Origin:        Origin,
+		RequestID:     input.RequestID,
+		Description:   input.Description,
+		Metadata:      input.Metadata,
+		RiskScore:     ap.RiskScore,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("hold error %s", err.Error()))
+		return nil, err
+	}
+	if ho.HasIntermitance || ho.Error {
+		return holdRefused(ho), nil
+	}
+	a.countWithContext(ctx, &Transaction{
+		AccountKey:     input.AccountKey,
+		Kind:           authorizationKind,
+		ExternalKey:    input.ExternalKey,
+		Amount:         input.Amount,
+		Currency:       currencyOrDefault(input.Currency),
+		DocumentNumber: ap.DocumentNumber,
+	})
+
+	o := holdTransactionOutput(ho)
+	o.Decision = ap.Decision
+	o.RuleID = ap.RuleID
+	o.RiskScore = ap.RiskScore
+	return o, nil
+}
+
+// previousHoldWithContext answers a retried authorization from the hold it already
+// placed. Approving it again would count its own hold against the limits and its
+// own authorization on the counters. The decision and rule are not kept on the
+// hold, so the replay only carries the risk score.
+func (a *debit) previousHoldWithContext(ctx context.Context, input *TransactionInput) (*TransactionOutput, error) {
+	ho, err := a.holds.GetHoldWithContext(ctx, &GetHoldInput{
+		AccountKey:  input.AccountKey,
+		ExternalKey: input.ExternalKey,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("get hold error %s", err.Error()))
+		return nil, err
+	}
+	if ho.Error && ho.Code == HoldNotFound {
+		return nil, nil
+	}
+	if ho.HasIntermitance || ho.Error {
+		return holdRefused(ho), nil
+	}
+
+	if ho.OperationType != input.OperationType || ho.Amount != input.Amount || ho.Currency != currencyOrDefault(input.Currency) {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   IdempotencyKeyReused,
+			Detail: "external_key was already used for a different request",
+		}, nil
+	}
+
+	o := holdTransactionOutput(ho)
+	o.RiskScore = ho.RiskScore
+	return o, nil
+}
+
+// CaptureWithContext does not go through the account checks again: the funds were
+// already approved and reserved when the authorization was placed.
+func (a *debit) CaptureWithContext(ctx context.Context, input *CaptureInput) (*TransactionOutput, error) {
+	if input.Amount < 0 {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   HoldInvalid,
+			Detail: "amount must be positive",
+		}, nil
+	}
+
+	ho, err := a.holds.CaptureHoldWithContext(ctx, input)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("capture error %s", err.Error()))
+		return nil, err
+	}
+	if ho.HasIntermitance || ho.Error {
+		return holdRefused(ho), nil
+	}
+
+	a.notifyWithContext(ctx, &NotifyInput{
+		EventType:     DebitSettled,
+		AccountKey:    input.AccountKey,
+		ExternalKey:   input.ExternalKey,
+		OperationType: Buying,
+		Amount:        ho.CapturedAmount,
+		Currency:      ho.Currency,
+		OccurredAt:    a.clock(),
+		RequestID:     ho.RequestID,
+	})
+
+	return holdTransactionOutput(ho), nil
+}
+
+func (a *debit) VoidWithContext(ctx context.Context, input *VoidInput) (*TransactionOutput, error) {
+	ho, err := a.holds.VoidHoldWithContext(ctx, input)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("void error %s", err.Error()))
+		return nil, err
+	}
+	if ho.HasIntermitance || ho.Error {
+		return holdRefused(ho), nil
+	}
+
+	return holdTransactionOutput(ho), nil
+}
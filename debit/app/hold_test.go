@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func authorizationJSON(t *testing.T, a Debit, input *TransactionInput) string {
+	res, err := a.AuthorizationWithContext(context.Background(), input)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func TestDebit_AuthorizeBuying(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":1000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":20,\"Status\":\"active\",\"CapturedAmount\":0,\"ExpiresAt\":\"2022-02-07T10:00:00Z\"}", authorizationJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "a1",
+		OperationType: Buying,
+		Amount:        1000,
+	}))
+	b, err := json.Marshal(m.holds.holds)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"AccountKey\":\"1\",\"ExternalKey\":\"a1\",\"OperationType\":\"Buying\",\"Amount\":1000,\"Currency\":\"BRL\",\"Origin\":\"debit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":20}]", string(b))
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
+
+func TestDebit_AuthorizeOnlyBuying(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"operation-type-invalid\",\"Detail\":\"only Buying can be authorized\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", authorizationJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "a1",
+		OperationType: InstallmentBuying,
+		Amount:        1000,
+		Installments:  2,
+	}))
+	assert.Equal(t, 0, len(m.holds.holds))
+}
+
+func TestDebit_NotAuthorizeAboveMonthlyBuyingAmount(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limit-exceeded\",\"Detail\":\"monthly_buying_amount of 20000 exceeded\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", authorizationJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "a1",
+		OperationType: Buying,
+		Amount:        10001,
+	}))
+	assert.Equal(t, 0, len(m.holds.holds))
+}
+
+func TestDebit_AuthorizationReplayedFromHold(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	input := &TransactionInput{
+		AccountKey:    "held",
+		ExternalKey:   "a1",
+		OperationType: Buying,
+		Amount:        2000,
+	}
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":2000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":20,\"Status\":\"active\",\"CapturedAmount\":0,\"ExpiresAt\":\"2022-02-07T10:00:00Z\"}", authorizationJSON(t, a, input))
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":2000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":20,\"Status\":\"active\",\"CapturedAmount\":0,\"ExpiresAt\":\"2022-02-07T10:00:00Z\"}", authorizationJSON(t, a, input))
+	assert.Equal(t, 1, len(m.holds.holds))
+	assert.Equal(t, 1, len(m.counters.increments))
+	assert.Equal(t, 0, len(m.notifier.sent))
+}
+
+func TestDebit_NotAuthorizeWhenKeyHeldByDifferentPayload(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	authorizationJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "a1",
+		OperationType: Buying,
+		Amount:        1000,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"idempotency-key-reused\",\"Detail\":\"external_key was already used for a different request\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", authorizationJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "a1",
+		OperationType: Buying,
+		Amount:        1500,
+	}))
+	assert.Equal(t, 1, len(m.holds.holds))
+}
+
+func TestDebit_NotAuthorizeWhenHoldUnavailable(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"unauthorized-settlement\",\"Detail\":\"Try again\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", authorizationJSON(t, a, &TransactionInput{
+		AccountKey:    "hold-unavailable",
+		ExternalKey:   "a1",
+		OperationType: Buying,
+		Amount:        1000,
+	}))
+	assert.Equal(t, 0, len(m.holds.holds))
+}
+
+func TestDebit_CaptureWithoutApprovingAgain(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	res, err := a.CaptureWithContext(context.Background(), &CaptureInput{
+		AccountKey:  "500",
+		ExternalKey: "a1",
+		Amount:      600,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":1000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r2\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"captured\",\"CapturedAmount\":600,\"ExpiresAt\":\"2022-02-07T10:00:00Z\"}", string(b))
+	assert.Equal(t, 0, len(m.usage.inputs))
+	assert.Equal(t, 0, len(m.counters.increments))
+
+	res, err = a.CaptureWithContext(context.Background(), &CaptureInput{
+		AccountKey:  "1",
+		ExternalKey: "missing",
+		Amount:      600,
+	})
+	assert.Nil(t, err)
+	b, err = json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"hold-not-found\",\"Detail\":\"hold not found\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", string(b))
+}
@@ -3,30 +3,46 @@ package app
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 const (
+	Origin                  = "debit"
 	Withdraw                = "Withdraw"
 	InstallmentBuying       = "InstallmentBuying"
 	Buying                  = "Buying"
+	Reversal                = "Reversal"
 	UnauthorizedTransaction = "unauthorized-transaction"
 	UnauthorizedSettlement  = "unauthorized-settlement"
 	AuthorizerNotFound      = "authorizer-not-found"
 	OperationTypeInvalid    = "operation-type-invalid"
 	SettlementFailed        = "settlement-failed"
+	InsufficientFunds       = "insufficient-funds"
+	InstallmentsInvalid     = "installments-invalid"
+	OriginalNotFound        = "original-not-found"
+	ReversalInvalid         = "reversal-invalid"
+	ReversalExceedsOriginal = "reversal-exceeds-original"
+	IdempotencyKeyReused    = "idempotency-key-reused"
+	AccountBlocked          = "account-blocked"
+	AccountClosed           = "account-closed"
+	TransactionDeclined     = "transaction-declined"
+	MaxInstallments         = 12
 )
 
 type debit struct {
-	log        Logger
-	authorizer Authorizer
-	settlement Settlement
+	log          Logger
+	authorizer   Authorizer
+	settlement   Settlement
+	holds        Holds
+	usage        Usage
+	rules        RuleEngine
+	counters     Counters
+	transactions Transactions
+	notifier     Notifier
+	clock        func() time.Time
 }
 
 func (a *debit) TransactionWithContext(ctx context.Context, input *TransactionInput) (*TransactionOutput, error) {
-	transactionOutput := &TransactionOutput{
-		Error: false,
-	}
-
 	t := input.OperationType
 	if Withdraw != t && InstallmentBuying != t && Buying != t {
 		return &TransactionOutput{
@@ -36,35 +52,72 @@ func (a *debit) TransactionWithContext(ctx context.Context, input *TransactionIn
 		}, nil
 	}
 
-	ai := &AuthorizeInput{
-		AccountKey: input.AccountKey,
+	if (InstallmentBuying == t && (input.Installments < 2 || input.Installments > MaxInstallments)) ||
+		(InstallmentBuying != t && input.Installments > 1) {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   InstallmentsInvalid,
+			Detail: fmt.Sprintf("installments must be between 2 and %d for InstallmentBuying", MaxInstallments),
+		}, nil
 	}
-	ao, err := a.authorizer.AuthorizeWithContext(ctx, ai)
+
+	record := &Transaction{
+		AccountKey:    input.AccountKey,
+		Kind:          transactionKind,
+		ExternalKey:   input.ExternalKey,
+		OperationType: input.OperationType,
+		Amount:        input.Amount,
+		Currency:      currencyOrDefault(input.Currency),
+		Installments:  input.Installments,
+		RequestID:     input.RequestID,
+	}
+	previous, replay, err := a.previousWithContext(ctx, record)
 	if err != nil {
-		a.log.Error(fmt.Sprintf("authorize error %s", err.Error()))
 		return nil, err
 	}
-	if ao == nil {
-		return &TransactionOutput{
-			Error:  true,
-			Code:   AuthorizerNotFound,
-			Detail: "authorizer not found",
-		}, nil
+	if replay != nil {
+		return replay, nil
 	}
-	if ao.HasError {
-		return &TransactionOutput{
-			Error:  true,
-			Code:   UnauthorizedTransaction,
-			Detail: "Try again",
-		}, nil
+
+	// A pending attempt was already approved; checking it again would count its
+	// own entry against the limits if balance wrote it.
+	ap := &approval{}
+	if previous != nil {
+		ap.Decision, ap.RuleID, ap.RiskScore, ap.DocumentNumber = previous.Decision, previous.RuleID, previous.RiskScore, previous.DocumentNumber
+	} else {
+		var refused *TransactionOutput
+		ap, refused, err = a.approveWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		if refused != nil {
+			return refused, nil
+		}
 	}
+	record.Decision, record.RuleID, record.RiskScore, record.DocumentNumber = ap.Decision, ap.RuleID, ap.RiskScore, ap.DocumentNumber
 
 	si := &SettleInput{
 		AccountKey:    input.AccountKey,
 		ExternalKey:   input.ExternalKey,
 		OperationType: input.OperationType,
 		Amount:        input.Amount * -1,
+		Currency:      currencyOrDefault(input.Currency),
+		Installments:  input.Installments,
+		Origin:        Origin,
+		RequestID:     input.RequestID,
+		Description:   input.Description,
+		Metadata:      input.Metadata,
+		RiskScore:     ap.RiskScore,
+	}
+	refused, err := a.beginWithContext(ctx, record)
+	if err != nil {
+		return nil, err
 	}
+	if refused != nil {
+		return refused, nil
+	}
+
+	// Without an answer from balance the transaction stays pending for the reconciler.
 	so, err := a.settlement.SettleWithContext(ctx, si)
 	if err != nil {
 		a.log.Error(fmt.Sprintf("settle error %s", err.Error()))
@@ -77,21 +130,189 @@ func (a *debit) TransactionWithContext(ctx context.Context, input *TransactionIn
 			Detail: "Try again",
 		}, nil
 	}
+	if so.Error && (so.Code == InsufficientFunds || so.Code == IdempotencyKeyReused || so.Code == CurrencyInvalid) {
+		return a.failWithContext(ctx, record, &TransactionOutput{
+			Error:  true,
+			Code:   so.Code,
+			Detail: so.Detail,
+		}), nil
+	}
 	if so.Error {
-		return &TransactionOutput{
+		return a.failWithContext(ctx, record, &TransactionOutput{
 			Error:  true,
 			Code:   SettlementFailed,
 			Detail: so.Detail,
+		}), nil
+	}
+
+	a.finishWithContext(ctx, record, &FinishTransactionInput{
+		Status:    TransactionSettled,
+		Amount:    input.Amount,
+		Currency:  si.Currency,
+		RequestID: so.RequestID,
+		SettledAt: so.CreatedAt,
+	})
+	a.countWithContext(ctx, record)
+
+	a.notifyWithContext(ctx, &NotifyInput{
+		EventType:     DebitSettled,
+		AccountKey:    input.AccountKey,
+		ExternalKey:   input.ExternalKey,
+		OperationType: input.OperationType,
+		Amount:        input.Amount,
+		Currency:      si.Currency,
+		OccurredAt:    so.CreatedAt,
+		RequestID:     so.RequestID,
+	})
+
+	return &TransactionOutput{
+		Error:     false,
+		Amount:    input.Amount,
+		Currency:  si.Currency,
+		CreatedAt: so.CreatedAt,
+		RequestID: so.RequestID,
+		Decision:  ap.Decision,
+		RuleID:    ap.RuleID,
+		RiskScore: ap.RiskScore,
+	}, nil
+}
+
+// approval is what the account checks, the rule engine and the risk score agreed
+// on for a debit that may go on to balance.
+type approval struct {
+	Decision       string
+	RuleID         string
+	RiskScore      int
+	DocumentNumber string
+}
+
+func (a *debit) approveWithContext(ctx context.Context, input *TransactionInput) (*approval, *TransactionOutput, error) {
+	ai := &AuthorizeInput{
+		AccountKey: input.AccountKey,
+	}
+	ao, err := a.authorizer.AuthorizeWithContext(ctx, ai)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("authorize error %s", err.Error()))
+		return nil, nil, err
+	}
+	if ao == nil {
+		return nil, &TransactionOutput{
+			Error:  true,
+			Code:   AuthorizerNotFound,
+			Detail: "authorizer not found",
+		}, nil
+	}
+	if ao.Code == AccountBlocked || ao.Code == AccountClosed {
+		return nil, &TransactionOutput{
+			Error:  true,
+			Code:   ao.Code,
+			Detail: "account is not active",
+		}, nil
+	}
+	if ao.HasError {
+		return nil, &TransactionOutput{
+			Error:  true,
+			Code:   UnauthorizedTransaction,
+			Detail: "Try again",
+		}, nil
+	}
+
+	currency := currencyOrDefault(input.Currency)
+	if !holdsCurrency(ao.Currencies, currency) {
+		return nil, &TransactionOutput{
+			Error:  true,
+			Code:   CurrencyNotHeld,
+			Detail: fmt.Sprintf("account does not hold %s", currency),
 		}, nil
 	}
 
-	return transactionOutput, nil
+	lo, err := a.checkLimitsWithContext(ctx, input, ao.Limits)
+	if err != nil {
+		return nil, nil, err
+	}
+	if lo != nil {
+		a.notifyWithContext(ctx, &NotifyInput{
+			EventType:     LimitBreached,
+			AccountKey:    input.AccountKey,
+			ExternalKey:   input.ExternalKey,
+			OperationType: input.OperationType,
+			Amount:        input.Amount,
+			Currency:      currency,
+			OccurredAt:    a.clock(),
+			RequestID:     input.RequestID,
+			Code:          lo.Code,
+			Detail:        lo.Detail,
+		})
+		return nil, lo, nil
+	}
+
+	eo, err := a.rules.EvaluateWithContext(ctx, &EvaluateInput{
+		AccountKey:    input.AccountKey,
+		OperationType: input.OperationType,
+		Amount:        input.Amount,
+		Currency:      currency,
+		Installments:  input.Installments,
+		At:            a.clock(),
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("rule engine error %s", err.Error()))
+		return nil, nil, err
+	}
+	if eo.HasIntermitance {
+		return nil, &TransactionOutput{
+			Error:  true,
+			Code:   UnauthorizedTransaction,
+			Detail: "Try again",
+		}, nil
+	}
+	a.log.Info(fmt.Sprintf("rule decision %s rule %s account %s transaction %s", eo.Decision, eo.RuleID, input.AccountKey, input.ExternalKey))
+	if eo.Decision == DecisionDecline {
+		return nil, &TransactionOutput{
+			Error:    true,
+			Code:     TransactionDeclined,
+			Detail:   fmt.Sprintf("transaction declined by rule %s", eo.RuleID),
+			Decision: eo.Decision,
+			RuleID:   eo.RuleID,
+		}, nil
+	}
+
+	score, err := a.scoreWithContext(ctx, input, ao.DocumentNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	decision := eo.Decision
+	if score >= DeclineScore {
+		return nil, &TransactionOutput{
+			Error:     true,
+			Code:      TransactionDeclined,
+			Detail:    fmt.Sprintf("transaction declined by risk score %d", score),
+			Decision:  DecisionDecline,
+			RiskScore: score,
+		}, nil
+	}
+	if score >= ReviewScore {
+		decision = DecisionReview
+	}
+
+	return &approval{
+		Decision:       decision,
+		RuleID:         eo.RuleID,
+		RiskScore:      score,
+		DocumentNumber: ao.DocumentNumber,
+	}, nil, nil
 }
 
-func New(authorizer Authorizer, settlement Settlement, log Logger) Debit {
+func New(authorizer Authorizer, settlement Settlement, holds Holds, usage Usage, rules RuleEngine, counters Counters, transactions Transactions, notifier Notifier, log Logger) Debit {
 	return &debit{
-		log:        log,
-		authorizer: authorizer,
-		settlement: settlement,
+		log:          log,
+		authorizer:   authorizer,
+		settlement:   settlement,
+		holds:        holds,
+		usage:        usage,
+		rules:        rules,
+		counters:     counters,
+		transactions: transactions,
+		notifier:     notifier,
+		clock:        time.Now,
 	}
 }
@@ -0,0 +1,383 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var testNow = time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)
+
+type authorizerMock struct{}
+
+func (a *authorizerMock) AuthorizeWithContext(ctx context.Context, input *AuthorizeInput) (*AuthorizeOutput, error) {
+	switch input.AccountKey {
+	case "500":
+		return nil, errors.New("authorize error")
+	case "404":
+		return nil, nil
+	case "no-limits":
+		return &AuthorizeOutput{
+			HasError:   false,
+			Currencies: []string{"BRL", "USD", "JPY"},
+		}, nil
+	}
+	return &AuthorizeOutput{
+		HasError:       false,
+		DocumentNumber: "12345678900",
+		Currencies:     []string{"BRL", "USD", "JPY"},
+		Limits: &Limits{
+			MaxWithdrawAmount:   5000,
+			DailyWithdrawAmount: 10000,
+			MonthlyBuyingAmount: 20000,
+			MaxInstallments:     6,
+		},
+	}, nil
+}
+
+// settlementMock finds the entries in entries by external key; "unavailable" stands
+// for balance not answering.
+type settlementMock struct {
+	settled []*SettleInput
+	entries map[string]*GetSettlementOutput
+}
+
+func (s *settlementMock) SettleWithContext(ctx context.Context, input *SettleInput) (*SettleOutput, error) {
+	s.settled = append(s.settled, input)
+	if input.AccountKey == "insufficient" {
+		return &SettleOutput{
+			Error:  true,
+			Code:   InsufficientFunds,
+			Detail: "insufficient funds",
+		}, nil
+	}
+	if input.OriginalExternalKey == "missing" {
+		return &SettleOutput{
+			Error:  true,
+			Code:   OriginalNotFound,
+			Detail: "original transaction not found",
+		}, nil
+	}
+	return &SettleOutput{
+		Amount:    input.Amount,
+		Currency:  input.Currency,
+		CreatedAt: testNow,
+		RequestID: "r1",
+	}, nil
+}
+
+type holdsMock struct {
+	holds []*HoldInput
+}
+
+func holdMockOutput(input *HoldInput) *HoldOutput {
+	return &HoldOutput{
+		OperationType: input.OperationType,
+		Status:        "active",
+		Amount:        input.Amount,
+		Currency:      input.Currency,
+		CreatedAt:     testNow,
+		ExpiresAt:     testNow.Add(168 * time.Hour),
+		RequestID:     "r1",
+		RiskScore:     input.RiskScore,
+	}
+}
+
+func (h *holdsMock) HoldWithContext(ctx context.Context, input *HoldInput) (*HoldOutput, error) {
+	h.holds = append(h.holds, input)
+	return holdMockOutput(input), nil
+}
+
+func (h *holdsMock) GetHoldWithContext(ctx context.Context, input *GetHoldInput) (*HoldOutput, error) {
+	if input.AccountKey == "hold-unavailable" {
+		return &HoldOutput{HasIntermitance: true}, nil
+	}
+	for _, hi := range h.holds {
+		if hi.AccountKey == input.AccountKey && hi.ExternalKey == input.ExternalKey {
+			return holdMockOutput(hi), nil
+		}
+	}
+	return &HoldOutput{
+		Error:  true,
+		Code:   HoldNotFound,
+		Detail: "hold not found",
+	}, nil
+}
+
+func (h *holdsMock) CaptureHoldWithContext(ctx context.Context, input *CaptureInput) (*HoldOutput, error) {
+	if input.ExternalKey == "missing" {
+		return &HoldOutput{
+			Error:  true,
+			Code:   HoldNotFound,
+			Detail: "hold not found",
+		}, nil
+	}
+	return &HoldOutput{
+		Status:         "captured",
+		Amount:         1000,
+		Currency:       DefaultCurrency,
+		CapturedAmount: input.Amount,
+		CreatedAt:      testNow,
+		ExpiresAt:      testNow.Add(168 * time.Hour),
+		RequestID:      "r2",
+	}, nil
+}
+
+func (h *holdsMock) VoidHoldWithContext(ctx context.Context, input *VoidInput) (*HoldOutput, error) {
+	return nil, errors.New("void error")
+}
+
+// HeldWithContext has 8000 held on the account "held", plus the holds placed since.
+func (h *holdsMock) HeldWithContext(ctx context.Context, input *HeldInput) (*HeldOutput, error) {
+	if input.AccountKey == "held-unavailable" {
+		return &HeldOutput{HasIntermitance: true}, nil
+	}
+	o := &HeldOutput{}
+	if input.AccountKey == "held" {
+		o.Amount = 8000
+	}
+	for _, hi := range h.holds {
+		if hi.AccountKey == input.AccountKey && hi.Currency == input.Currency {
+			o.Amount += hi.Amount
+		}
+	}
+	return o, nil
+}
+
+func (s *settlementMock) GetSettlementWithContext(ctx context.Context, input *GetSettlementInput) (*GetSettlementOutput, error) {
+	if input.ExternalKey == "unavailable" {
+		return &GetSettlementOutput{
+			HasIntermitance: true,
+		}, nil
+	}
+	if o, ok := s.entries[input.ExternalKey]; ok {
+		return o, nil
+	}
+	return &GetSettlementOutput{
+		Found: false,
+	}, nil
+}
+
+// usageMock has spent 6000 in withdrawals and 10000 in buys in BRL in the period.
+type usageMock struct {
+	inputs []*PeriodUsageInput
+}
+
+func (u *usageMock) UsageWithContext(ctx context.Context, input *UsageInput) (*UsageOutput, error) {
+	return &UsageOutput{}, nil
+}
+
+func (u *usageMock) PeriodUsageWithContext(ctx context.Context, input *PeriodUsageInput) (*UsageOutput, error) {
+	u.inputs = append(u.inputs, input)
+	if input.AccountKey == "usage-unavailable" {
+		return &UsageOutput{HasIntermitance: true}, nil
+	}
+	if input.Currency != DefaultCurrency {
+		return &UsageOutput{}, nil
+	}
+	if input.OperationTypes[0] == Withdraw {
+		return &UsageOutput{Amount: 6000}, nil
+	}
+	return &UsageOutput{Amount: 10000}, nil
+}
+
+type rulesMock struct{}
+
+func (r *rulesMock) EvaluateWithContext(ctx context.Context, input *EvaluateInput) (*EvaluateOutput, error) {
+	if input.AccountKey == "declined" {
+		return &EvaluateOutput{
+			Decision: DecisionDecline,
+			RuleID:   "night-withdraw",
+		}, nil
+	}
+	return &EvaluateOutput{
+		Decision: DecisionApprove,
+	}, nil
+}
+
+func (r *rulesMock) ScoreReferences(currency string) []*ScoreReference {
+	if currency == "USD" {
+		return []*ScoreReference{
+			{Window: time.Minute, Count: 10, Amount: 40000},
+		}
+	}
+	return nil
+}
+
+// countersMock starts every key and window at the counter in counters, or empty.
+type countersMock struct {
+	counters   map[string]*Counter
+	increments []*IncrementInput
+	references map[string]bool
+}
+
+func (c *countersMock) GetWithContext(ctx context.Context, input *GetCountersInput) (*GetCountersOutput, error) {
+	counters := make([]*Counter, 0)
+	for _, key := range input.Keys {
+		for _, window := range input.Windows {
+			counter := &Counter{
+				Key:    key,
+				Window: window,
+			}
+			if v, ok := c.counters[key]; ok && v.Window == window {
+				counter.Count, counter.Amount = v.Count, v.Amount
+			}
+			counters = append(counters, counter)
+		}
+	}
+	return &GetCountersOutput{
+		Counters: counters,
+	}, nil
+}
+
+func (c *countersMock) IncrementWithContext(ctx context.Context, input *IncrementInput) (*IncrementOutput, error) {
+	if c.references[input.Reference] {
+		return &IncrementOutput{
+			AlreadyCounted: true,
+		}, nil
+	}
+	c.references[input.Reference] = true
+	c.increments = append(c.increments, input)
+	return &IncrementOutput{}, nil
+}
+
+// transactionsMock keeps the records in memory with the same conditions as the
+// repository. When set, listed is what the reconciler gets instead of the pending
+// records, to stand for a list read before another attempt started.
+type transactionsMock struct {
+	records map[string]*Transaction
+	listed  []*Transaction
+}
+
+func recordKey(accountKey string, kind string, externalKey string) string {
+	return fmt.Sprintf("%s|%s|%s", accountKey, kind, externalKey)
+}
+
+func (r *transactionsMock) BeginWithContext(ctx context.Context, input *Transaction) (*BeginTransactionOutput, error) {
+	key := recordKey(input.AccountKey, input.Kind, input.ExternalKey)
+	if t, ok := r.records[key]; ok && t.Status != TransactionFailed && !(t.Status == TransactionPending && t.RequestHash == input.RequestHash) {
+		return &BeginTransactionOutput{
+			AlreadyExists: true,
+		}, nil
+	}
+	t := *input
+	r.records[key] = &t
+	return &BeginTransactionOutput{}, nil
+}
+
+func (r *transactionsMock) FinishWithContext(ctx context.Context, input *FinishTransactionInput) (*FinishTransactionOutput, error) {
+	t, ok := r.records[recordKey(input.AccountKey, input.Kind, input.ExternalKey)]
+	if !ok || t.Status != TransactionPending || !t.AttemptedAt.Equal(input.AttemptedAt) {
+		return &FinishTransactionOutput{
+			TransactionChanged: true,
+		}, nil
+	}
+	t.Status, t.Code, t.Detail = input.Status, input.Code, input.Detail
+	t.Amount, t.Currency, t.RequestID, t.SettledAt = input.Amount, input.Currency, input.RequestID, input.SettledAt
+	return &FinishTransactionOutput{}, nil
+}
+
+func (r *transactionsMock) GetWithContext(ctx context.Context, input *GetTransactionRecordInput) (*Transaction, error) {
+	if input.AccountKey == "get-error" {
+		return nil, errors.New("get transaction error")
+	}
+	t, ok := r.records[recordKey(input.AccountKey, input.Kind, input.ExternalKey)]
+	if !ok {
+		return nil, nil
+	}
+	c := *t
+	return &c, nil
+}
+
+func (r *transactionsMock) ListPendingWithContext(ctx context.Context, input *ListPendingTransactionsInput) (*ListPendingTransactionsOutput, error) {
+	if r.listed != nil {
+		return &ListPendingTransactionsOutput{
+			Transactions: r.listed,
+		}, nil
+	}
+	transactions := make([]*Transaction, 0)
+	for _, t := range r.records {
+		if t.Status == TransactionPending && t.AttemptedAt.Before(input.AttemptedBefore) {
+			c := *t
+			transactions = append(transactions, &c)
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].ExternalKey < transactions[j].ExternalKey
+	})
+	return &ListPendingTransactionsOutput{
+		Transactions: transactions,
+	}, nil
+}
+
+// notifierMock is called on a goroutine of its own, so the events go through a channel.
+type notifierMock struct {
+	sent chan *NotifyInput
+}
+
+func (n *notifierMock) NotifyWithContext(ctx context.Context, input *NotifyInput) error {
+	n.sent <- input
+	return nil
+}
+
+// events waits for count events; a sent event carries no order, so they are sorted by request id.
+func (n *notifierMock) events(count int) []*NotifyInput {
+	events := make([]*NotifyInput, 0, count)
+	for len(events) < count {
+		select {
+		case e := <-n.sent:
+			events = append(events, e)
+		case <-time.After(time.Second):
+			return events
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].RequestID < events[j].RequestID
+	})
+	return events
+}
+
+type log struct{}
+
+func (l log) Info(msg string)  {}
+func (l log) Error(msg string) {}
+func newLogMock() Logger {
+	return &log{}
+}
+
+type mocks struct {
+	settlement   *settlementMock
+	holds        *holdsMock
+	usage        *usageMock
+	counters     *countersMock
+	transactions *transactionsMock
+	notifier     *notifierMock
+}
+
+func newMocks() *mocks {
+	return &mocks{
+		settlement: &settlementMock{},
+		holds:      &holdsMock{},
+		usage:      &usageMock{},
+		counters: &countersMock{
+			counters:   map[string]*Counter{},
+			references: map[string]bool{},
+		},
+		transactions: &transactionsMock{
+			records: map[string]*Transaction{},
+		},
+		notifier: &notifierMock{
+			sent: make(chan *NotifyInput, 10),
+		},
+	}
+}
+
+func newWithClock(m *mocks, now time.Time) Debit {
+	a := New(&authorizerMock{}, m.settlement, m.holds, m.usage, &rulesMock{}, m.counters, m.transactions, m.notifier, newLogMock())
+	a.(*debit).clock = func() time.Time {
+		return now
+	}
+	return a
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	DebitSettled    = "DebitSettled"
+	ReversalSettled = "ReversalSettled"
+	LimitBreached   = "LimitBreached"
+
+	// notifyTimeout bounds a notification, every attempt of the http client
+	// included.
+	notifyTimeout = 30 * time.Second
+)
+
+// Notifier reports transaction outcomes to the webhook service.
+type Notifier interface {
+	NotifyWithContext(ctx context.Context, input *NotifyInput) error
+}
+
+// NotifyInput carries the refusal code and detail when a limit was breached.
+type NotifyInput struct {
+	EventID             string
+	EventType           string
+	AccountKey          string
+	ExternalKey         string
+	OperationType       string
+	Amount              int
+	Currency            string
+	OriginalExternalKey string
+	OccurredAt          time.Time
+	RequestID           string
+	Code                string
+	Detail              string
+}
+
+// notifyWithContext never fails the transaction: the money already moved, and a
+// lost notification is the smaller harm. A settled transaction keeps its event id
+// when retried; every refused attempt is an event of its own.
+func (a *debit) notifyWithContext(ctx context.Context, input *NotifyInput) {
+	key := fmt.Sprintf("%s|%s|%s|%s", Origin, input.EventType, input.AccountKey, input.ExternalKey)
+	if input.EventType == LimitBreached {
+		key = fmt.Sprintf("%s|%s", key, input.RequestID)
+	}
+	h := sha256.Sum256([]byte(key))
+	input.EventID = hex.EncodeToString(h[:])
+
+	// The notification runs on its own so it neither delays the answer nor ends
+	// with the request context.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := a.notifier.NotifyWithContext(ctx, input); err != nil {
+			a.log.Error(fmt.Sprintf("notify error %s event %s", err.Error(), input.EventID))
+		}
+	}()
+}
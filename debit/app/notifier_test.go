@@ -0,0 +1,55 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDebit_NotifySettledDebit(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	i := &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        1000,
+		RequestID:     "req-1",
+	}
+	transactionJSON(t, a, i)
+	i.RequestID = "req-2"
+	transactionJSON(t, a, i)
+	b, err := json.Marshal(m.notifier.events(1))
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"EventID\":\"de9a395c4f7c5e7fa5772abe7008673c131566291ce197dc7ffc712bffab2fee\",\"EventType\":\"DebitSettled\",\"AccountKey\":\"1\",\"ExternalKey\":\"w1\",\"OperationType\":\"Withdraw\",\"Amount\":1000,\"Currency\":\"BRL\",\"OriginalExternalKey\":\"\",\"OccurredAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Code\":\"\",\"Detail\":\"\"}]", string(b))
+}
+
+func TestDebit_NotifyEveryLimitBreach(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	i := &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        5001,
+		RequestID:     "req-1",
+	}
+	transactionJSON(t, a, i)
+	i.RequestID = "req-2"
+	transactionJSON(t, a, i)
+	b, err := json.Marshal(m.notifier.events(2))
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"EventID\":\"bb4f57c420aecd6c8baa7c57a1988a5a434c920b9bde79b1157952c018ac0c7f\",\"EventType\":\"LimitBreached\",\"AccountKey\":\"1\",\"ExternalKey\":\"w1\",\"OperationType\":\"Withdraw\",\"Amount\":5001,\"Currency\":\"BRL\",\"OriginalExternalKey\":\"\",\"OccurredAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"req-1\",\"Code\":\"limit-exceeded\",\"Detail\":\"max_withdraw_amount of 5000 exceeded\"},{\"EventID\":\"37f885583a91adb5aad8820b244388141fc3175c8b5b39615250beda617078fb\",\"EventType\":\"LimitBreached\",\"AccountKey\":\"1\",\"ExternalKey\":\"w1\",\"OperationType\":\"Withdraw\",\"Amount\":5001,\"Currency\":\"BRL\",\"OriginalExternalKey\":\"\",\"OccurredAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"req-2\",\"Code\":\"limit-exceeded\",\"Detail\":\"max_withdraw_amount of 5000 exceeded\"}]", string(b))
+}
+
+func TestDebit_NotNotifyRefusedDebit(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "insufficient",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        1000,
+	})
+	assert.Equal(t, 0, len(m.notifier.sent))
+}
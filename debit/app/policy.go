@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+const LimitExceeded = "limit-exceeded"
+
+// Limits are configured per account in accreditation; a zero value means no limit.
+type Limits struct {
+	MaxWithdrawAmount   int
+	DailyWithdrawAmount int
+	MonthlyBuyingAmount int
+	MaxInstallments     int
+}
+
+func limitExceeded(detail string) *TransactionOutput {
+	return &TransactionOutput{
+		Error:  true,
+		Code:   LimitExceeded,
+		Detail: detail,
+	}
+}
+
+// checkLimitsWithContext returns the refusal for the first limit the transaction
+// would exceed, or nil when it is within all of them. Days and months are in UTC,
+// and what was reversed no longer counts. Amount limits hold for each currency on
+// its own, in its minor unit. Usage is read and not reserved, so concurrent debits
+// may together go over a limit that each of them fits in on its own.
+func (a *debit) checkLimitsWithContext(ctx context.Context, input *TransactionInput, limits *Limits) (*TransactionOutput, error) {
+	if limits == nil {
+		return nil, nil
+	}
+
+	switch input.OperationType {
+	case Withdraw:
+		if limits.MaxWithdrawAmount > 0 && input.Amount > limits.MaxWithdrawAmount {
+			return limitExceeded(fmt.Sprintf("max_withdraw_amount of %d exceeded", limits.MaxWithdrawAmount)), nil
+		}
+
+		if limits.DailyWithdrawAmount > 0 {
+			o, err := a.usedWithContext(ctx, input.AccountKey, []string{Withdraw}, currencyOrDefault(input.Currency), UsageDay)
+			if err != nil || o.Error {
+				return o, err
+			}
+			if o.Amount+input.Amount > limits.DailyWithdrawAmount {
+				return limitExceeded(fmt.Sprintf("daily_withdraw_amount of %d exceeded", limits.DailyWithdrawAmount)), nil
+			}
+		}
+	case Buying, InstallmentBuying:
+		if InstallmentBuying == input.OperationType && limits.MaxInstallments > 0 && input.Installments > limits.MaxInstallments {
+			return limitExceeded(fmt.Sprintf("max_installments of %d exceeded", limits.MaxInstallments)), nil
+		}
+
+		if limits.MonthlyBuyingAmount > 0 {
+			currency := currencyOrDefault(input.Currency)
+			o, err := a.usedWithContext(ctx, input.AccountKey, []string{Buying, InstallmentBuying}, currency, UsageMonth)
+			if err != nil || o.Error {
+				return o, err
+			}
+			h, err := a.heldWithContext(ctx, input.AccountKey, currency)
+			if err != nil || h.Error {
+				return h, err
+			}
+			if o.Amount+h.Amount+input.Amount > limits.MonthlyBuyingAmount {
+				return limitExceeded(fmt.Sprintf("monthly_buying_amount of %d exceeded", limits.MonthlyBuyingAmount)), nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// usedWithContext sums what the account already spent in the current period; the
+// output carries the error to return when the usage could not be read.
+func (a *debit) usedWithContext(ctx context.Context, accountKey string, operationTypes []string, currency string, period string) (*TransactionOutput, error) {
+	uo, err := a.usage.PeriodUsageWithContext(ctx, &PeriodUsageInput{
+		AccountKey:     accountKey,
+		OperationTypes: operationTypes,
+		Currency:       currency,
+		Period:         period,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("usage error %s", err.Error()))
+		return nil, err
+	}
+	if uo.HasIntermitance {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   UnauthorizedTransaction,
+			Detail: "Try again",
+		}, nil
+	}
+
+	return &TransactionOutput{
+		Amount: uo.Amount,
+	}, nil
+}
+
+// heldWithContext sums the authorizations still open. Each of them is captured as a
+// buy this month or later, so all of them count towards the monthly buying amount,
+// whenever they were placed.
+func (a *debit) heldWithContext(ctx context.Context, accountKey string, currency string) (*TransactionOutput, error) {
+	ho, err := a.holds.HeldWithContext(ctx, &HeldInput{
+		AccountKey: accountKey,
+		Currency:   currency,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("held error %s", err.Error()))
+		return nil, err
+	}
+	if ho.HasIntermitance {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   UnauthorizedTransaction,
+			Detail: "Try again",
+		}, nil
+	}
+
+	return &TransactionOutput{
+		Amount: ho.Amount,
+	}, nil
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func transactionJSON(t *testing.T, a Debit, input *TransactionInput) string {
+	res, err := a.TransactionWithContext(context.Background(), input)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func TestDebit_WithdrawUpToDailyWithdrawAmount(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":4000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":20,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        4000,
+	}))
+	assert.Equal(t, 1, len(m.settlement.settled))
+}
+
+func TestDebit_NotWithdrawAboveMaxWithdrawAmount(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limit-exceeded\",\"Detail\":\"max_withdraw_amount of 5000 exceeded\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        5001,
+	}))
+	assert.Equal(t, 0, len(m.usage.inputs))
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
+
+func TestDebit_NotWithdrawAboveDailyWithdrawAmount(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limit-exceeded\",\"Detail\":\"daily_withdraw_amount of 10000 exceeded\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        4001,
+	}))
+	b, err := json.Marshal(m.usage.inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"AccountKey\":\"1\",\"OperationTypes\":[\"Withdraw\"],\"Currency\":\"BRL\",\"Period\":\"day\"}]", string(b))
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
+
+func TestDebit_LimitsApplyToEachCurrency(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":4500,\"Currency\":\"USD\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":11,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        4500,
+		Currency:      "USD",
+	}))
+	b, err := json.Marshal(m.usage.inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"AccountKey\":\"1\",\"OperationTypes\":[\"Withdraw\"],\"Currency\":\"USD\",\"Period\":\"day\"}]", string(b))
+}
+
+func TestDebit_NotBuyAboveMonthlyBuyingAmount(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limit-exceeded\",\"Detail\":\"monthly_buying_amount of 20000 exceeded\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "b1",
+		OperationType: Buying,
+		Amount:        10001,
+	}))
+	b, err := json.Marshal(m.usage.inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"AccountKey\":\"1\",\"OperationTypes\":[\"Buying\",\"InstallmentBuying\"],\"Currency\":\"BRL\",\"Period\":\"month\"}]", string(b))
+}
+
+func TestDebit_NotBuyAboveMonthlyBuyingAmountWithOpenAuthorizations(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limit-exceeded\",\"Detail\":\"monthly_buying_amount of 20000 exceeded\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "held",
+		ExternalKey:   "b1",
+		OperationType: Buying,
+		Amount:        2001,
+	}))
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":2000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":20,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "held",
+		ExternalKey:   "b2",
+		OperationType: InstallmentBuying,
+		Amount:        2000,
+		Installments:  2,
+	}))
+}
+
+func TestDebit_NotAuthorizeAboveMonthlyBuyingAmountWithOpenAuthorizations(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	res, err := a.AuthorizationWithContext(context.Background(), &TransactionInput{
+		AccountKey:    "held",
+		ExternalKey:   "a1",
+		OperationType: Buying,
+		Amount:        2001,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limit-exceeded\",\"Detail\":\"monthly_buying_amount of 20000 exceeded\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", string(b))
+}
+
+func TestDebit_NotBuyWhenUsageUnavailable(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"unauthorized-transaction\",\"Detail\":\"Try again\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "usage-unavailable",
+		ExternalKey:   "b1",
+		OperationType: Buying,
+		Amount:        100,
+	}))
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"unauthorized-transaction\",\"Detail\":\"Try again\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "held-unavailable",
+		ExternalKey:   "b1",
+		OperationType: Buying,
+		Amount:        100,
+	}))
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
+
+func TestDebit_NotBuyAboveMaxInstallments(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"limit-exceeded\",\"Detail\":\"max_installments of 6 exceeded\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "b1",
+		OperationType: InstallmentBuying,
+		Amount:        1000,
+		Installments:  7,
+	}))
+}
+
+func TestDebit_NotReadUsageWithoutLimits(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":100000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":50,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "no-limits",
+		ExternalKey:   "b1",
+		OperationType: Buying,
+		Amount:        100000,
+	}))
+	assert.Equal(t, 0, len(m.usage.inputs))
+}
+
+func TestDebit_NotDebitCurrencyNotHeld(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"currency-not-held\",\"Detail\":\"account does not hold EUR\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "b1",
+		OperationType: Buying,
+		Amount:        100,
+		Currency:      "EUR",
+	}))
+	assert.Equal(t, 0, len(m.usage.inputs))
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
+
+func TestDebit_ReplaySettledTransactionWithoutCheckingLimits(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	i := &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        4000,
+	}
+	first := transactionJSON(t, a, i)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":4000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":20,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", first)
+
+	// Once in the ledger the withdrawal fills the daily limit on its own, so the
+	// replay must not read the usage again.
+	assert.Equal(t, first, transactionJSON(t, a, i))
+	assert.Equal(t, 1, len(m.settlement.settled))
+	assert.Equal(t, 1, len(m.usage.inputs))
+}
+
+func TestDebit_NotReplayWithDifferentPayload(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        4000,
+	})
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"idempotency-key-reused\",\"Detail\":\"external_key was already used for a different request\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        3000,
+	}))
+	assert.Equal(t, 1, len(m.settlement.settled))
+}
+
+func TestDebit_RetryPendingTransactionWithoutApprovingAgain(t *testing.T) {
+	m := newMocks()
+	record := &Transaction{
+		AccountKey:     "500",
+		Kind:           transactionKind,
+		ExternalKey:    "w1",
+		OperationType:  Withdraw,
+		Amount:         4000,
+		Currency:       DefaultCurrency,
+		Status:         TransactionPending,
+		Decision:       DecisionReview,
+		RuleID:         "high-amount",
+		RiskScore:      85,
+		DocumentNumber: "12345678900",
+		AttemptedAt:    testNow.Add(-time.Minute),
+	}
+	record.RequestHash = transactionHash(record)
+	m.transactions.records[recordKey("500", transactionKind, "w1")] = record
+	a := newWithClock(m, testNow)
+
+	// The authorizer of account 500 fails, so the retry only settles if it skips approval.
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":4000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"review\",\"RuleID\":\"high-amount\",\"RiskScore\":85,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "500",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        4000,
+	}))
+	assert.Equal(t, 0, len(m.usage.inputs))
+	b, err := json.Marshal(m.settlement.settled)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"AccountKey\":\"500\",\"ExternalKey\":\"w1\",\"OperationType\":\"Withdraw\",\"Amount\":-4000,\"Currency\":\"BRL\",\"Installments\":0,\"Origin\":\"debit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":85,\"OriginalExternalKey\":\"\"}]", string(b))
+}
+
+func TestDebit_NotDebitWhenRecordUnavailable(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	res, err := a.TransactionWithContext(context.Background(), &TransactionInput{
+		AccountKey:    "get-error",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        100,
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "get transaction error", err.Error())
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
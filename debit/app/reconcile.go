@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+const DefaultReconcileBatchSize = 100
+
+// settlementMatches tells the entry of this transaction apart from one that a
+// different request wrote under the same key.
+func settlementMatches(t *Transaction, so *GetSettlementOutput) bool {
+	if so.OperationType != t.OperationType {
+		return false
+	}
+	if t.Currency != "" && so.Currency != t.Currency {
+		return false
+	}
+	if t.Kind == reversalKind {
+		return so.OriginalExternalKey == t.OriginalExternalKey && (t.Amount == 0 || so.Amount == t.Amount)
+	}
+	// balance keeps only the first installment on the entry.
+	if t.Installments > 1 {
+		return so.Installments == t.Installments
+	}
+	return so.Amount == t.Amount*-1
+}
+
+// ReconcileWithContext resolves the transactions whose answer from balance was lost,
+// taking balance as the source of truth. One that balance wrote is finished as if
+// the answer had arrived; one it never wrote is compensated by closing it as failed,
+// since nothing was reserved for it and the client may retry under the same key.
+func (a *debit) ReconcileWithContext(ctx context.Context, input *ReconcileInput) (*ReconcileOutput, error) {
+	o, err := a.transactions.ListPendingWithContext(ctx, &ListPendingTransactionsInput{
+		AttemptedBefore: input.AttemptedBefore,
+		Limit:           DefaultReconcileBatchSize,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("list pending transactions error %s", err.Error()))
+		return nil, err
+	}
+
+	reconcileOutput := &ReconcileOutput{}
+	for _, t := range o.Transactions {
+		so, err := a.settlement.GetSettlementWithContext(ctx, &GetSettlementInput{
+			AccountKey:  t.AccountKey,
+			ExternalKey: t.ExternalKey,
+			Origin:      Origin,
+			Reversal:    t.Kind == reversalKind,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("get settlement %s error %s", t.ExternalKey, err.Error()))
+			reconcileOutput.Unresolved++
+			continue
+		}
+		if so.HasIntermitance {
+			reconcileOutput.Unresolved++
+			continue
+		}
+
+		if so.Found && settlementMatches(t, so) {
+			if !a.completeWithContext(ctx, t, so) {
+				reconcileOutput.Unresolved++
+				continue
+			}
+			reconcileOutput.Settled++
+			continue
+		}
+
+		if !a.compensateWithContext(ctx, t, so) {
+			reconcileOutput.Unresolved++
+			continue
+		}
+		reconcileOutput.Failed++
+	}
+
+	return reconcileOutput, nil
+}
+
+func (a *debit) completeWithContext(ctx context.Context, t *Transaction, so *GetSettlementOutput) bool {
+	amount, currency := t.Amount, t.Currency
+	eventType := DebitSettled
+	if t.Kind == reversalKind {
+		amount, currency = so.Amount, so.Currency
+		eventType = ReversalSettled
+	}
+
+	if !a.finishWithContext(ctx, t, &FinishTransactionInput{
+		Status:    TransactionSettled,
+		Amount:    amount,
+		Currency:  currency,
+		RequestID: so.RequestID,
+		SettledAt: so.CreatedAt,
+	}) {
+		return false
+	}
+	if t.Kind == transactionKind {
+		a.countWithContext(ctx, t)
+	}
+
+	a.notifyWithContext(ctx, &NotifyInput{
+		EventType:           eventType,
+		AccountKey:          t.AccountKey,
+		ExternalKey:         t.ExternalKey,
+		OperationType:       t.OperationType,
+		Amount:              amount,
+		Currency:            currency,
+		OriginalExternalKey: t.OriginalExternalKey,
+		OccurredAt:          so.CreatedAt,
+		RequestID:           so.RequestID,
+	})
+	return true
+}
+
+func (a *debit) compensateWithContext(ctx context.Context, t *Transaction, so *GetSettlementOutput) bool {
+	i := &FinishTransactionInput{
+		Status:   TransactionFailed,
+		Code:     SettlementFailed,
+		Detail:   "balance has no record of the transaction",
+		Amount:   t.Amount,
+		Currency: t.Currency,
+	}
+	if so.Found {
+		i.Code = IdempotencyKeyReused
+		i.Detail = "external_key was already used for a different request"
+	}
+	return a.finishWithContext(ctx, t, i)
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+func (a *debit) ReverseWithContext(ctx context.Context, input *ReverseInput) (*TransactionOutput, error) {
+	if input.Amount < 0 {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   ReversalInvalid,
+			Detail: "amount must be positive",
+		}, nil
+	}
+
+	record := &Transaction{
+		AccountKey:          input.AccountKey,
+		Kind:                reversalKind,
+		ExternalKey:         input.ReversalKey,
+		OperationType:       Reversal,
+		Amount:              input.Amount,
+		OriginalExternalKey: input.ExternalKey,
+		RequestID:           input.RequestID,
+	}
+	_, replay, err := a.previousWithContext(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if replay != nil {
+		return replay, nil
+	}
+
+	ai := &AuthorizeInput{
+		AccountKey: input.AccountKey,
+	}
+	ao, err := a.authorizer.AuthorizeWithContext(ctx, ai)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("authorize error %s", err.Error()))
+		return nil, err
+	}
+	if ao == nil {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   AuthorizerNotFound,
+			Detail: "authorizer not found",
+		}, nil
+	}
+	if ao.Code == AccountBlocked || ao.Code == AccountClosed {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   ao.Code,
+			Detail: "account is not active",
+		}, nil
+	}
+	if ao.HasError {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   UnauthorizedTransaction,
+			Detail: "Try again",
+		}, nil
+	}
+
+	si := &SettleInput{
+		AccountKey:          input.AccountKey,
+		ExternalKey:         input.ReversalKey,
+		OperationType:       Reversal,
+		Amount:              input.Amount,
+		Origin:              Origin,
+		RequestID:           input.RequestID,
+		Description:         input.Description,
+		Metadata:            input.Metadata,
+		OriginalExternalKey: input.ExternalKey,
+	}
+	refused, err := a.beginWithContext(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if refused != nil {
+		return refused, nil
+	}
+
+	so, err := a.settlement.SettleWithContext(ctx, si)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("settle error %s", err.Error()))
+		return nil, err
+	}
+	if so.HasIntermitance {
+		return &TransactionOutput{
+			Error:  true,
+			Code:   UnauthorizedSettlement,
+			Detail: "Try again",
+		}, nil
+	}
+	if so.Error && (so.Code == OriginalNotFound || so.Code == CurrencyInvalid || so.Code == ReversalInvalid || so.Code == ReversalExceedsOriginal || so.Code == IdempotencyKeyReused) {
+		return a.failWithContext(ctx, record, &TransactionOutput{
+			Error:  true,
+			Code:   so.Code,
+			Detail: so.Detail,
+		}), nil
+	}
+	if so.Error {
+		return a.failWithContext(ctx, record, &TransactionOutput{
+			Error:  true,
+			Code:   SettlementFailed,
+			Detail: so.Detail,
+		}), nil
+	}
+
+	a.finishWithContext(ctx, record, &FinishTransactionInput{
+		Status:    TransactionSettled,
+		Amount:    so.Amount,
+		Currency:  so.Currency,
+		RequestID: so.RequestID,
+		SettledAt: so.CreatedAt,
+	})
+
+	a.notifyWithContext(ctx, &NotifyInput{
+		EventType:           ReversalSettled,
+		AccountKey:          input.AccountKey,
+		ExternalKey:         input.ReversalKey,
+		OperationType:       Reversal,
+		Amount:              so.Amount,
+		Currency:            so.Currency,
+		OriginalExternalKey: input.ExternalKey,
+		OccurredAt:          so.CreatedAt,
+		RequestID:           so.RequestID,
+	})
+
+	return &TransactionOutput{
+		Error:     false,
+		Amount:    so.Amount,
+		Currency:  so.Currency,
+		CreatedAt: so.CreatedAt,
+		RequestID: so.RequestID,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func reverse(t *testing.T, m *mocks, input *ReverseInput) string {
+	a := newWithClock(m, testNow)
+	res, err := a.ReverseWithContext(context.Background(), input)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func newReverseInput(accountKey string, externalKey string, amount int) *ReverseInput {
+	return &ReverseInput{
+		AccountKey:  accountKey,
+		ExternalKey: externalKey,
+		ReversalKey: "rv1",
+		Amount:      amount,
+		RequestID:   "r1",
+	}
+}
+
+func TestDebit_Reverse(t *testing.T) {
+	m := newMocks()
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":1000,\"Currency\":\"\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", reverse(t, m, newReverseInput("1", "w1", 1000)))
+
+	assert.Equal(t, 1, len(m.settlement.settled))
+	assert.Equal(t, "w1", m.settlement.settled[0].OriginalExternalKey)
+	record := m.transactions.records[recordKey("1", reversalKind, "rv1")]
+	assert.Equal(t, TransactionSettled, record.Status)
+	assert.Equal(t, "w1", record.OriginalExternalKey)
+	events := m.notifier.events(1)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, ReversalSettled, events[0].EventType)
+}
+
+func TestDebit_ReplayReversal(t *testing.T) {
+	m := newMocks()
+	first := reverse(t, m, newReverseInput("1", "w1", 1000))
+	m.notifier.events(1)
+
+	assert.Equal(t, first, reverse(t, m, newReverseInput("1", "w1", 1000)))
+	assert.Equal(t, 1, len(m.settlement.settled))
+	assert.Equal(t, 0, len(m.notifier.events(1)))
+}
+
+func TestDebit_NotReverseWhenKeyReusedWithDifferentAmount(t *testing.T) {
+	m := newMocks()
+	reverse(t, m, newReverseInput("1", "w1", 1000))
+	m.notifier.events(1)
+
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"idempotency-key-reused\",\"Detail\":\"external_key was already used for a different request\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", reverse(t, m, newReverseInput("1", "w1", 2000)))
+	assert.Equal(t, 1, len(m.settlement.settled))
+	assert.Equal(t, 1000, m.transactions.records[recordKey("1", reversalKind, "rv1")].Amount)
+}
+
+func TestDebit_NotReverseNegativeAmount(t *testing.T) {
+	m := newMocks()
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"reversal-invalid\",\"Detail\":\"amount must be positive\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", reverse(t, m, newReverseInput("1", "w1", -1)))
+	assert.Equal(t, 0, len(m.settlement.settled))
+	assert.Equal(t, 0, len(m.transactions.records))
+}
+
+func TestDebit_NotReverseWhenOriginalNotFound(t *testing.T) {
+	m := newMocks()
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"original-not-found\",\"Detail\":\"original transaction not found\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", reverse(t, m, newReverseInput("1", "missing", 1000)))
+
+	record := m.transactions.records[recordKey("1", reversalKind, "rv1")]
+	assert.Equal(t, TransactionFailed, record.Status)
+	assert.Equal(t, OriginalNotFound, record.Code)
+	assert.Equal(t, 0, len(m.notifier.events(1)))
+}
+
+func TestDebit_RetryFailedReversal(t *testing.T) {
+	m := newMocks()
+	reverse(t, m, newReverseInput("1", "missing", 1000))
+
+	// A failed reversal leaves the key free for a corrected request.
+	input := newReverseInput("1", "w1", 1000)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":1000,\"Currency\":\"\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", reverse(t, m, input))
+	assert.Equal(t, 2, len(m.settlement.settled))
+	assert.Equal(t, TransactionSettled, m.transactions.records[recordKey("1", reversalKind, "rv1")].Status)
+}
+
+func TestDebit_NotReverseWhenAuthorizerNotFound(t *testing.T) {
+	m := newMocks()
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"authorizer-not-found\",\"Detail\":\"authorizer not found\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", reverse(t, m, newReverseInput("404", "w1", 1000)))
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	DecisionApprove = "approve"
+	DecisionDecline = "decline"
+	DecisionReview  = "review"
+)
+
+type RuleEngine interface {
+	EvaluateWithContext(ctx context.Context, input *EvaluateInput) (*EvaluateOutput, error)
+	// ScoreReferences is nil when the rules file has none for currency.
+	ScoreReferences(currency string) []*ScoreReference
+}
+
+type EvaluateInput struct {
+	AccountKey    string
+	OperationType string
+	Amount        int
+	Currency      string
+	Installments  int
+	At            time.Time
+}
+
+// EvaluateOutput has the decision of the first rule that matched; without a
+// match the decision is approve and RuleID is empty.
+type EvaluateOutput struct {
+	HasIntermitance bool
+	Decision        string
+	RuleID          string
+}
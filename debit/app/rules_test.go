@@ -0,0 +1,18 @@
+package app
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDebit_NotSettleDeclinedByRule(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"transaction-declined\",\"Detail\":\"transaction declined by rule night-withdraw\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"decline\",\"RuleID\":\"night-withdraw\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "declined",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        100,
+	}))
+	assert.Equal(t, 0, len(m.settlement.settled))
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	ReviewScore  = 80
+	DeclineScore = 100
+	MaxScore     = 100
+
+	authorizationKind = "authorization"
+)
+
+// ScoreReference is the count and amount considered normal for one window;
+// reaching either of them gives the maximum score. Without an Amount only the
+// count is scored.
+type ScoreReference struct {
+	Window time.Duration
+	Count  int
+	Amount int
+}
+
+// defaultScoreReferences are in BRL cents. Another currency without references in
+// the rules file keeps the windows and counts but is not scored by amount, as the
+// same figure means very different sums in each minor unit.
+var defaultScoreReferences = []*ScoreReference{
+	{Window: time.Minute, Count: 5, Amount: 200000},
+	{Window: time.Hour, Count: 30, Amount: 1000000},
+	{Window: 24 * time.Hour, Count: 100, Amount: 3000000},
+}
+
+func (a *debit) scoreReferences(currency string) []*ScoreReference {
+	if references := a.rules.ScoreReferences(currency); len(references) > 0 {
+		return references
+	}
+	if currency == DefaultCurrency {
+		return defaultScoreReferences
+	}
+
+	references := make([]*ScoreReference, 0, len(defaultScoreReferences))
+	for _, r := range defaultScoreReferences {
+		references = append(references, &ScoreReference{
+			Window: r.Window,
+			Count:  r.Count,
+		})
+	}
+	return references
+}
+
+func scoreWindows(references []*ScoreReference) []time.Duration {
+	windows := make([]time.Duration, 0, len(references))
+	for _, r := range references {
+		windows = append(windows, r.Window)
+	}
+	return windows
+}
+
+// counterKeys keeps amounts in different currencies apart; BRL keeps the keys it
+// had before other currencies existed.
+func counterKeys(accountKey string, documentNumber string, currency string) []string {
+	suffix := ""
+	if currency != DefaultCurrency {
+		suffix = "#" + currency
+	}
+	keys := []string{"account#" + accountKey + suffix}
+	if documentNumber != "" {
+		keys = append(keys, "document#"+documentNumber+suffix)
+	}
+	return keys
+}
+
+// riskScore is the highest share of a reference used by any counter once the debit
+// being scored is added to it, from 0 to 100.
+func riskScore(counters []*Counter, references []*ScoreReference, amount int) int {
+	score := 0
+	for _, c := range counters {
+		for _, r := range references {
+			if r.Window != c.Window {
+				continue
+			}
+			if s := (c.Count + 1) * MaxScore / r.Count; s > score {
+				score = s
+			}
+			if r.Amount <= 0 {
+				continue
+			}
+			if s := (c.Amount + amount) * MaxScore / r.Amount; s > score {
+				score = s
+			}
+		}
+	}
+	if score > MaxScore {
+		return MaxScore
+	}
+	return score
+}
+
+// scoreWithContext only reads the counters: a debit is counted once it settles,
+// so declined attempts and retries do not push the score up.
+func (a *debit) scoreWithContext(ctx context.Context, input *TransactionInput, documentNumber string) (int, error) {
+	currency := currencyOrDefault(input.Currency)
+	references := a.scoreReferences(currency)
+
+	o, err := a.counters.GetWithContext(ctx, &GetCountersInput{
+		Keys:    counterKeys(input.AccountKey, documentNumber, currency),
+		Windows: scoreWindows(references),
+		At:      a.clock(),
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("counters error %s", err.Error()))
+		return 0, err
+	}
+
+	score := riskScore(o.Counters, references, input.Amount)
+	a.log.Info(fmt.Sprintf("risk score %d account %s transaction %s", score, input.AccountKey, input.ExternalKey))
+	return score, nil
+}
+
+// countWithContext adds a settled debit or a placed authorization to the counters.
+// A failure only leaves the counters short, so it is logged and the debit goes on.
+func (a *debit) countWithContext(ctx context.Context, t *Transaction) {
+	_, err := a.counters.IncrementWithContext(ctx, &IncrementInput{
+		Reference: fmt.Sprintf("%s#%s#%s", t.AccountKey, t.Kind, t.ExternalKey),
+		Keys:      counterKeys(t.AccountKey, t.DocumentNumber, t.Currency),
+		Windows:   scoreWindows(a.scoreReferences(t.Currency)),
+		Amount:    t.Amount,
+		At:        a.clock(),
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("counters error %s account %s transaction %s", err.Error(), t.AccountKey, t.ExternalKey))
+	}
+}
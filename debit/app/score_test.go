@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDebit_DeclineWhenTheTransactionReachesTheScore(t *testing.T) {
+	m := newMocks()
+	m.counters.counters["account#1"] = &Counter{Window: time.Minute, Count: 4}
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"transaction-declined\",\"Detail\":\"transaction declined by risk score 100\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"decline\",\"RuleID\":\"\",\"RiskScore\":100,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        100,
+	}))
+	assert.Equal(t, 0, len(m.settlement.settled))
+	assert.Equal(t, 0, len(m.counters.increments))
+}
+
+func TestDebit_ReviewByRiskScore(t *testing.T) {
+	m := newMocks()
+	m.counters.counters["document#12345678900"] = &Counter{Window: time.Minute, Count: 3}
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":100,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"review\",\"RuleID\":\"\",\"RiskScore\":80,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        100,
+	}))
+}
+
+func TestDebit_RiskScoreByAmount(t *testing.T) {
+	m := newMocks()
+	m.counters.counters["account#1"] = &Counter{Window: time.Hour, Count: 1, Amount: 700000}
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":1000,\"Currency\":\"BRL\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":70,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        1000,
+	}))
+	b, err := json.Marshal(m.settlement.settled)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"AccountKey\":\"1\",\"ExternalKey\":\"w1\",\"OperationType\":\"Withdraw\",\"Amount\":-1000,\"Currency\":\"BRL\",\"Installments\":0,\"Origin\":\"debit\",\"RequestID\":\"\",\"Description\":\"\",\"Metadata\":null,\"RiskScore\":70,\"OriginalExternalKey\":\"\"}]", string(b))
+}
+
+func TestDebit_RiskScoreWithCurrencyReferences(t *testing.T) {
+	m := newMocks()
+	m.counters.counters["account#1#USD"] = &Counter{Window: time.Minute, Count: 1, Amount: 30000}
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":5000,\"Currency\":\"USD\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"review\",\"RuleID\":\"\",\"RiskScore\":87,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "b1",
+		OperationType: Buying,
+		Amount:        5000,
+		Currency:      "USD",
+	}))
+}
+
+func TestDebit_RiskScoreByCountWithoutCurrencyReferences(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Amount\":10000000,\"Currency\":\"JPY\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"RequestID\":\"r1\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":20,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "no-limits",
+		ExternalKey:   "b1",
+		OperationType: Buying,
+		Amount:        10000000,
+		Currency:      "JPY",
+	}))
+}
+
+func TestDebit_CountSettledTransactionOnce(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	i := &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        1000,
+	}
+	transactionJSON(t, a, i)
+	transactionJSON(t, a, i)
+	b, err := json.Marshal(m.counters.increments)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"Reference\":\"1#transaction#w1\",\"Keys\":[\"account#1\",\"document#12345678900\"],\"Windows\":[60000000000,3600000000000,86400000000000],\"Amount\":1000,\"At\":\"2022-01-31T10:00:00Z\"}]", string(b))
+}
+
+func TestDebit_NotCountRefusedTransaction(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"insufficient-funds\",\"Detail\":\"insufficient funds\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", transactionJSON(t, a, &TransactionInput{
+		AccountKey:    "insufficient",
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        1000,
+	}))
+	assert.Equal(t, 0, len(m.counters.increments))
+}
+
+func TestDebit_CountAuthorization(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	_, err := a.AuthorizationWithContext(context.Background(), &TransactionInput{
+		AccountKey:    "1",
+		ExternalKey:   "a1",
+		OperationType: Buying,
+		Amount:        1000,
+		Currency:      "USD",
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(m.counters.increments)
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"Reference\":\"1#authorization#a1\",\"Keys\":[\"account#1#USD\",\"document#12345678900#USD\"],\"Windows\":[60000000000],\"Amount\":1000,\"At\":\"2022-01-31T10:00:00Z\"}]", string(b))
+}
@@ -1,9 +1,13 @@
 package app
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Settlement interface {
 	SettleWithContext(ctx context.Context, input *SettleInput) (*SettleOutput, error)
+	GetSettlementWithContext(ctx context.Context, input *GetSettlementInput) (*GetSettlementOutput, error)
 }
 
 type SettleInput struct {
@@ -11,10 +15,45 @@ type SettleInput struct {
 	ExternalKey   string
 	OperationType string
 	Amount        int
+	Currency      string
+	Installments  int
+	Origin        string
+	RequestID     string
+	Description   string
+	Metadata      map[string]string
+	RiskScore     int
+
+	OriginalExternalKey string
 }
 type SettleOutput struct {
 	HasIntermitance bool
 	Error           bool
 	Code            string
 	Detail          string
+	Amount          int
+	Currency        string
+	CreatedAt       time.Time
+	RequestID       string
+}
+
+type GetSettlementInput struct {
+	AccountKey  string
+	ExternalKey string
+	Origin      string
+	Reversal    bool
+}
+
+// GetSettlementOutput describes the ledger entry balance wrote under the key, when
+// Found. Amount is signed as in the ledger.
+type GetSettlementOutput struct {
+	HasIntermitance     bool
+	Found               bool
+	OperationType       string
+	Amount              int
+	Currency            string
+	Installments        int
+	OriginalExternalKey string
+	ReversedAmount      int
+	CreatedAt           time.Time
+	RequestID           string
 }
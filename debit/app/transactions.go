@@ -0,0 +1,286 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	TransactionPending  = "pending"
+	TransactionSettled  = "settled"
+	TransactionFailed   = "failed"
+	TransactionReversed = "reversed"
+	TransactionNotFound = "transaction-not-found"
+
+	transactionKind = "transaction"
+	reversalKind    = "reversal"
+)
+
+// Transactions keeps a record of every debit and reversal sent to balance. The
+// record is written as pending before the call, so an answer lost on the way back
+// leaves something behind for the reconciler to resolve.
+type Transactions interface {
+	BeginWithContext(ctx context.Context, input *Transaction) (*BeginTransactionOutput, error)
+	FinishWithContext(ctx context.Context, input *FinishTransactionInput) (*FinishTransactionOutput, error)
+	GetWithContext(ctx context.Context, input *GetTransactionRecordInput) (*Transaction, error)
+	ListPendingWithContext(ctx context.Context, input *ListPendingTransactionsInput) (*ListPendingTransactionsOutput, error)
+}
+
+// Transaction is keyed by account, kind and external key; a reversal is kept under
+// its reversal key.
+type Transaction struct {
+	AccountKey          string
+	Kind                string
+	ExternalKey         string
+	OperationType       string
+	Amount              int
+	Currency            string
+	Installments        int
+	OriginalExternalKey string
+	Status              string
+	Code                string
+	Detail              string
+	RequestID           string
+	CreatedAt           time.Time
+	AttemptedAt         time.Time
+	SettledAt           time.Time
+	RequestHash         string
+	Decision            string
+	RuleID              string
+	RiskScore           int
+	DocumentNumber      string
+	// ReversedAmount is read from balance on lookups and never stored.
+	ReversedAmount int
+}
+
+// BeginTransactionOutput tells that another request holds the key: the record is
+// settled, or pending with a different payload.
+type BeginTransactionOutput struct {
+	AlreadyExists bool
+}
+
+// FinishTransactionInput only applies to the attempt started at AttemptedAt, so a
+// late answer never overwrites a newer attempt with the same key.
+type FinishTransactionInput struct {
+	AccountKey  string
+	Kind        string
+	ExternalKey string
+	AttemptedAt time.Time
+	Status      string
+	Code        string
+	Detail      string
+	Amount      int
+	Currency    string
+	RequestID   string
+	SettledAt   time.Time
+}
+type FinishTransactionOutput struct {
+	TransactionChanged bool
+}
+
+type GetTransactionRecordInput struct {
+	AccountKey  string
+	Kind        string
+	ExternalKey string
+}
+
+type ListPendingTransactionsInput struct {
+	AttemptedBefore time.Time
+	Limit           int
+}
+type ListPendingTransactionsOutput struct {
+	Transactions []*Transaction
+}
+
+// transactionHash fingerprints what the client asked for. A reversal is stored with
+// the amount balance reversed, so the requested one is only kept in the hash.
+func transactionHash(t *Transaction) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%d|%s|%s", t.Kind, t.OperationType, t.Amount, t.Installments, t.OriginalExternalKey, t.Currency)))
+	return hex.EncodeToString(h[:])
+}
+
+func settledOutput(t *Transaction) *TransactionOutput {
+	return &TransactionOutput{
+		Error:     false,
+		Amount:    t.Amount,
+		Currency:  t.Currency,
+		CreatedAt: t.SettledAt,
+		RequestID: t.RequestID,
+		Decision:  t.Decision,
+		RuleID:    t.RuleID,
+		RiskScore: t.RiskScore,
+	}
+}
+
+// previousWithContext looks for an earlier attempt under the same key, so a retry
+// gets the answer of the first request instead of going through the checks again.
+// It returns the attempt still pending, or the answer when there is one already.
+func (a *debit) previousWithContext(ctx context.Context, record *Transaction) (*Transaction, *TransactionOutput, error) {
+	record.RequestHash = transactionHash(record)
+	t, err := a.transactions.GetWithContext(ctx, &GetTransactionRecordInput{
+		AccountKey:  record.AccountKey,
+		Kind:        record.Kind,
+		ExternalKey: record.ExternalKey,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("get transaction error %s", err.Error()))
+		return nil, nil, err
+	}
+
+	if t == nil || t.Status == TransactionFailed {
+		return nil, nil, nil
+	}
+	if t.RequestHash != record.RequestHash {
+		return nil, &TransactionOutput{
+			Error:  true,
+			Code:   IdempotencyKeyReused,
+			Detail: "external_key was already used for a different request",
+		}, nil
+	}
+	if t.Status == TransactionSettled {
+		return nil, settledOutput(t), nil
+	}
+	return t, nil, nil
+}
+
+// beginWithContext returns the answer for the client when the attempt could not
+// start because another request holds the key.
+func (a *debit) beginWithContext(ctx context.Context, t *Transaction) (*TransactionOutput, error) {
+	t.Status = TransactionPending
+	t.AttemptedAt = a.clock()
+	o, err := a.transactions.BeginWithContext(ctx, t)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("begin transaction error %s", err.Error()))
+		return nil, err
+	}
+	if !o.AlreadyExists {
+		return nil, nil
+	}
+
+	_, replay, err := a.previousWithContext(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	if replay != nil {
+		return replay, nil
+	}
+	// The record changed between the two reads; the client may retry.
+	return &TransactionOutput{
+		Error:  true,
+		Code:   UnauthorizedSettlement,
+		Detail: "Try again",
+	}, nil
+}
+
+// finishWithContext does not fail the request: a record left pending is picked up
+// by the reconciler, which finds the same answer in balance.
+func (a *debit) finishWithContext(ctx context.Context, t *Transaction, input *FinishTransactionInput) bool {
+	input.AccountKey = t.AccountKey
+	input.Kind = t.Kind
+	input.ExternalKey = t.ExternalKey
+	input.AttemptedAt = t.AttemptedAt
+	o, err := a.transactions.FinishWithContext(ctx, input)
+	if err != nil {
+		a.log.Error(fmt.Sprintf("finish transaction error %s", err.Error()))
+		return false
+	}
+	return !o.TransactionChanged
+}
+
+func (a *debit) failWithContext(ctx context.Context, t *Transaction, o *TransactionOutput) *TransactionOutput {
+	a.finishWithContext(ctx, t, &FinishTransactionInput{
+		Status:   TransactionFailed,
+		Code:     o.Code,
+		Detail:   o.Detail,
+		Amount:   t.Amount,
+		Currency: t.Currency,
+	})
+	return o
+}
+
+// GetTransactionWithContext answers from the local record and asks balance about
+// the settled ones, since reversals are only tracked in the ledger. A transaction
+// without a record, written before the store existed, is read from the ledger.
+func (a *debit) GetTransactionWithContext(ctx context.Context, input *GetTransactionInput) (*GetTransactionOutput, error) {
+	t, err := a.transactions.GetWithContext(ctx, &GetTransactionRecordInput{
+		AccountKey:  input.AccountKey,
+		Kind:        transactionKind,
+		ExternalKey: input.ExternalKey,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("get transaction error %s", err.Error()))
+		return nil, err
+	}
+
+	if t != nil && t.Status != TransactionSettled {
+		return &GetTransactionOutput{
+			Transaction: t,
+		}, nil
+	}
+
+	so, err := a.settlement.GetSettlementWithContext(ctx, &GetSettlementInput{
+		AccountKey:  input.AccountKey,
+		ExternalKey: input.ExternalKey,
+		Origin:      Origin,
+	})
+	if err != nil || so.HasIntermitance {
+		if err != nil {
+			a.log.Error(fmt.Sprintf("get settlement %s error %s", input.ExternalKey, err.Error()))
+		}
+		// The record alone is still a valid answer, only without the reversals.
+		if t != nil {
+			return &GetTransactionOutput{
+				Transaction: t,
+			}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &GetTransactionOutput{
+			Error:  true,
+			Code:   UnauthorizedSettlement,
+			Detail: "Try again",
+		}, nil
+	}
+
+	if t == nil && !so.Found {
+		return &GetTransactionOutput{
+			Error:  true,
+			Code:   TransactionNotFound,
+			Detail: "transaction not found",
+		}, nil
+	}
+
+	if t == nil {
+		t = ledgerTransaction(input, so)
+	}
+	if so.Found && so.ReversedAmount > 0 {
+		t.Status = TransactionReversed
+		t.ReversedAmount = so.ReversedAmount
+	}
+
+	return &GetTransactionOutput{
+		Transaction: t,
+	}, nil
+}
+
+// ledgerTransaction rebuilds a settled transaction from its entry. For purchases in
+// installments the entry only holds the first installment.
+func ledgerTransaction(input *GetTransactionInput, so *GetSettlementOutput) *Transaction {
+	return &Transaction{
+		AccountKey:    input.AccountKey,
+		Kind:          transactionKind,
+		ExternalKey:   input.ExternalKey,
+		OperationType: so.OperationType,
+		Amount:        so.Amount * -1,
+		Currency:      so.Currency,
+		Installments:  so.Installments,
+		Status:        TransactionSettled,
+		RequestID:     so.RequestID,
+		CreatedAt:     so.CreatedAt,
+		SettledAt:     so.CreatedAt,
+	}
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDebit_NotRestartRecordHeldByDifferentPayload(t *testing.T) {
+	m := newMocks()
+	held := &Transaction{
+		AccountKey:    "1",
+		Kind:          transactionKind,
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        3000,
+		Currency:      DefaultCurrency,
+		Status:        TransactionPending,
+		AttemptedAt:   testNow.Add(-time.Second),
+	}
+	held.RequestHash = transactionHash(held)
+	m.transactions.records[recordKey("1", transactionKind, "w1")] = held
+	a := newWithClock(m, testNow)
+
+	// The other request took the key after this one looked for it.
+	record := &Transaction{
+		AccountKey:    "1",
+		Kind:          transactionKind,
+		ExternalKey:   "w1",
+		OperationType: Withdraw,
+		Amount:        4000,
+		Currency:      DefaultCurrency,
+	}
+	record.RequestHash = transactionHash(record)
+	res, err := a.(*debit).beginWithContext(context.Background(), record)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"idempotency-key-reused\",\"Detail\":\"external_key was already used for a different request\",\"Amount\":0,\"Currency\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"RequestID\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"Status\":\"\",\"CapturedAmount\":0,\"ExpiresAt\":\"0001-01-01T00:00:00Z\"}", string(b))
+	assert.Equal(t, 3000, m.transactions.records[recordKey("1", transactionKind, "w1")].Amount)
+}
+
+func getTransaction(t *testing.T, m *mocks, accountKey string, externalKey string) string {
+	a := newWithClock(m, testNow)
+	res, err := a.GetTransactionWithContext(context.Background(), &GetTransactionInput{
+		AccountKey:  accountKey,
+		ExternalKey: externalKey,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func TestDebit_GetPendingTransactionFromRecord(t *testing.T) {
+	m := newMocks()
+	newPendingRecord(m, "w1")
+	// A pending record is answered without asking balance.
+	m.settlement.entries = map[string]*GetSettlementOutput{
+		"w1": {Found: true, OperationType: Withdraw, Amount: -3000, Currency: DefaultCurrency, ReversedAmount: 3000},
+	}
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Transaction\":{\"AccountKey\":\"1\",\"Kind\":\"transaction\",\"ExternalKey\":\"w1\",\"OperationType\":\"Withdraw\",\"Amount\":3000,\"Currency\":\"BRL\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Status\":\"pending\",\"Code\":\"\",\"Detail\":\"\",\"RequestID\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"AttemptedAt\":\"2022-01-31T09:59:00Z\",\"SettledAt\":\"0001-01-01T00:00:00Z\",\"RequestHash\":\"9bcb906ded8a478a1540c53cb2f4bf4ec7b58e35d581f1f79802dc340856d4ea\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":10,\"DocumentNumber\":\"\",\"ReversedAmount\":0}}", getTransaction(t, m, "1", "w1"))
+}
+
+func TestDebit_GetReversedTransaction(t *testing.T) {
+	m := newMocks()
+	record := newPendingRecord(m, "w1")
+	record.Status, record.SettledAt, record.RequestID = TransactionSettled, testNow.Add(-time.Minute), "r1"
+	m.settlement.entries = map[string]*GetSettlementOutput{
+		"w1": {Found: true, OperationType: Withdraw, Amount: -3000, Currency: DefaultCurrency, ReversedAmount: 1000, RequestID: "r1"},
+	}
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Transaction\":{\"AccountKey\":\"1\",\"Kind\":\"transaction\",\"ExternalKey\":\"w1\",\"OperationType\":\"Withdraw\",\"Amount\":3000,\"Currency\":\"BRL\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Status\":\"reversed\",\"Code\":\"\",\"Detail\":\"\",\"RequestID\":\"r1\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"AttemptedAt\":\"2022-01-31T09:59:00Z\",\"SettledAt\":\"2022-01-31T09:59:00Z\",\"RequestHash\":\"9bcb906ded8a478a1540c53cb2f4bf4ec7b58e35d581f1f79802dc340856d4ea\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":10,\"DocumentNumber\":\"\",\"ReversedAmount\":1000}}", getTransaction(t, m, "1", "w1"))
+	assert.Equal(t, TransactionSettled, m.transactions.records[recordKey("1", transactionKind, "w1")].Status)
+}
+
+func TestDebit_GetTransactionFromLedger(t *testing.T) {
+	m := newMocks()
+	m.settlement.entries = map[string]*GetSettlementOutput{
+		"w1": {Found: true, OperationType: Withdraw, Amount: -3000, Currency: DefaultCurrency, CreatedAt: testNow.Add(-time.Hour), RequestID: "r0"},
+	}
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Transaction\":{\"AccountKey\":\"1\",\"Kind\":\"transaction\",\"ExternalKey\":\"w1\",\"OperationType\":\"Withdraw\",\"Amount\":3000,\"Currency\":\"BRL\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Status\":\"settled\",\"Code\":\"\",\"Detail\":\"\",\"RequestID\":\"r0\",\"CreatedAt\":\"2022-01-31T09:00:00Z\",\"AttemptedAt\":\"0001-01-01T00:00:00Z\",\"SettledAt\":\"2022-01-31T09:00:00Z\",\"RequestHash\":\"\",\"Decision\":\"\",\"RuleID\":\"\",\"RiskScore\":0,\"DocumentNumber\":\"\",\"ReversedAmount\":0}}", getTransaction(t, m, "1", "w1"))
+}
+
+func TestDebit_GetTransactionNotFound(t *testing.T) {
+	m := newMocks()
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"transaction-not-found\",\"Detail\":\"transaction not found\",\"Transaction\":null}", getTransaction(t, m, "1", "w1"))
+}
+
+func TestDebit_GetSettledTransactionWhenBalanceUnavailable(t *testing.T) {
+	m := newMocks()
+	record := newPendingRecord(m, "unavailable")
+	record.Status, record.SettledAt, record.RequestID = TransactionSettled, testNow.Add(-time.Minute), "r1"
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Transaction\":{\"AccountKey\":\"1\",\"Kind\":\"transaction\",\"ExternalKey\":\"unavailable\",\"OperationType\":\"Withdraw\",\"Amount\":3000,\"Currency\":\"BRL\",\"Installments\":0,\"OriginalExternalKey\":\"\",\"Status\":\"settled\",\"Code\":\"\",\"Detail\":\"\",\"RequestID\":\"r1\",\"CreatedAt\":\"0001-01-01T00:00:00Z\",\"AttemptedAt\":\"2022-01-31T09:59:00Z\",\"SettledAt\":\"2022-01-31T09:59:00Z\",\"RequestHash\":\"9bcb906ded8a478a1540c53cb2f4bf4ec7b58e35d581f1f79802dc340856d4ea\",\"Decision\":\"approve\",\"RuleID\":\"\",\"RiskScore\":10,\"DocumentNumber\":\"\",\"ReversedAmount\":0}}", getTransaction(t, m, "1", "unavailable"))
+}
+
+func TestDebit_NotGetTransactionWithoutRecordWhenBalanceUnavailable(t *testing.T) {
+	m := newMocks()
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"unauthorized-settlement\",\"Detail\":\"Try again\",\"Transaction\":null}", getTransaction(t, m, "1", "unavailable"))
+}
+
+func TestDebit_NotGetTransactionWhenRecordError(t *testing.T) {
+	m := newMocks()
+	a := newWithClock(m, testNow)
+	res, err := a.GetTransactionWithContext(context.Background(), &GetTransactionInput{
+		AccountKey:  "get-error",
+		ExternalKey: "w1",
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "get transaction error", err.Error())
+}
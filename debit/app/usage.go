@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	UsageDay   = "day"
+	UsageMonth = "month"
+)
+
+// Usage reads what an account already debited: UsageWithContext walks the statement
+// of a short window, PeriodUsageWithContext reads the running totals balance keeps
+// for the current day and month, net of reversals.
+type Usage interface {
+	UsageWithContext(ctx context.Context, input *UsageInput) (*UsageOutput, error)
+	PeriodUsageWithContext(ctx context.Context, input *PeriodUsageInput) (*UsageOutput, error)
+}
+
+// UsageInput without Currency sums the debits in every currency.
+type UsageInput struct {
+	AccountKey     string
+	OperationTypes []string
+	Currency       string
+	From           time.Time
+}
+
+type PeriodUsageInput struct {
+	AccountKey     string
+	OperationTypes []string
+	Currency       string
+	Period         string
+}
+
+type UsageOutput struct {
+	HasIntermitance bool
+	Amount          int
+	Count           int
+}
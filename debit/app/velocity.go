@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// Counters keeps sliding-window counters of settled debits shared by every replica.
+type Counters interface {
+	GetWithContext(ctx context.Context, input *GetCountersInput) (*GetCountersOutput, error)
+	IncrementWithContext(ctx context.Context, input *IncrementInput) (*IncrementOutput, error)
+}
+
+type GetCountersInput struct {
+	Keys    []string
+	Windows []time.Duration
+	At      time.Time
+}
+
+type Counter struct {
+	Key    string
+	Window time.Duration
+	Count  int
+	Amount int
+}
+
+// GetCountersOutput has one counter per key and window.
+type GetCountersOutput struct {
+	Counters []*Counter
+}
+
+// IncrementInput is counted once per Reference, so a retried or reconciled debit
+// does not add to the counters again.
+type IncrementInput struct {
+	Reference string
+	Keys      []string
+	Windows   []time.Duration
+	Amount    int
+	At        time.Time
+}
+
+type IncrementOutput struct {
+	AlreadyCounted bool
+}
@@ -3,10 +3,38 @@ package authorizer
 import (
 	"context"
 	"debit/app"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
+const (
+	AccountBlocked = "blocked"
+	AccountClosed  = "closed"
+)
+
+type LimitsResponse struct {
+	MaxWithdrawAmount   int `json:"max_withdraw_amount,omitempty"`
+	DailyWithdrawAmount int `json:"daily_withdraw_amount,omitempty"`
+	MonthlyBuyingAmount int `json:"monthly_buying_amount,omitempty"`
+	MaxInstallments     int `json:"max_installments,omitempty"`
+}
+
+type AccountResponse struct {
+	DocumentNumber string `json:"document_number,omitempty"`
+
+	Status     string    `json:"status,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	PersonType string    `json:"person_type,omitempty"`
+	Email      string    `json:"email,omitempty"`
+	Phone      string    `json:"phone,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+
+	Limits     *LimitsResponse `json:"limits,omitempty"`
+	Currencies []string        `json:"currencies,omitempty"`
+}
+
 type accreditation struct {
 	log         Logger
 	config      *Config
@@ -18,7 +46,7 @@ type Http interface {
 }
 
 func (a *accreditation) AuthorizeWithContext(ctx context.Context, input *app.AuthorizeInput) (*app.AuthorizeOutput, error) {
-	_, statusCode, err := a.httpService.GetWithContext(ctx, a.config.Url+input.AccountKey)
+	body, statusCode, err := a.httpService.GetWithContext(ctx, a.config.Url+input.AccountKey)
 	if err != nil {
 		a.log.Error(fmt.Sprintf("http get error %s", err.Error()))
 		return nil, err
@@ -28,15 +56,51 @@ func (a *accreditation) AuthorizeWithContext(ctx context.Context, input *app.Aut
 		return nil, nil
 	}
 
-	if statusCode >= http.StatusOK {
+	if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
 		return &app.AuthorizeOutput{
-			HasError: false,
+			HasError: true,
 		}, nil
 	}
 
-	return &app.AuthorizeOutput{
-		HasError: true,
-	}, nil
+	account := &AccountResponse{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, account); err != nil {
+			a.log.Error(fmt.Sprintf("account response error %s", err.Error()))
+			return &app.AuthorizeOutput{
+				HasError: true,
+			}, nil
+		}
+	}
+
+	o := &app.AuthorizeOutput{
+		HasError:       false,
+		DocumentNumber: account.DocumentNumber,
+		Name:           account.Name,
+		PersonType:     account.PersonType,
+		Email:          account.Email,
+		Phone:          account.Phone,
+		CreatedAt:      account.CreatedAt,
+		Currencies:     account.Currencies,
+	}
+	if account.Limits != nil {
+		o.Limits = &app.Limits{
+			MaxWithdrawAmount:   account.Limits.MaxWithdrawAmount,
+			DailyWithdrawAmount: account.Limits.DailyWithdrawAmount,
+			MonthlyBuyingAmount: account.Limits.MonthlyBuyingAmount,
+			MaxInstallments:     account.Limits.MaxInstallments,
+		}
+	}
+
+	switch account.Status {
+	case AccountBlocked:
+		o.HasError = true
+		o.Code = app.AccountBlocked
+	case AccountClosed:
+		o.HasError = true
+		o.Code = app.AccountClosed
+	}
+
+	return o, nil
 }
 
 func New(log Logger, config *Config, httpService Http) app.Authorizer {
@@ -3,8 +3,13 @@ package logger
 import (
 	"debit/app"
 	"debit/authorizer"
+	"debit/notifier"
+	"debit/reconciler"
+	"debit/repository"
 	"debit/routes"
+	"debit/rules"
 	"debit/server"
+	"debit/services"
 	"debit/settlement"
 	"log"
 )
@@ -23,6 +28,6 @@ func (l *logs) Error(msg string) {
 	log.Print(msg)
 }
 
-func New() (app.Logger, server.Logger, routes.Logger, authorizer.Logger, settlement.Logger) {
-	return &logs{}, &logs{}, &logs{}, &logs{}, &logs{}
+func New() (app.Logger, server.Logger, routes.Logger, authorizer.Logger, settlement.Logger, rules.Logger, repository.Logger, notifier.Logger, services.Logger, reconciler.Logger) {
+	return &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}
 }
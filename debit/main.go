@@ -4,7 +4,11 @@ import (
 	"debit/app"
 	"debit/authorizer"
 	"debit/logger"
+	"debit/notifier"
+	"debit/reconciler"
+	"debit/repository"
 	"debit/routes"
+	"debit/rules"
 	"debit/server"
 	"debit/services"
 	"debit/settlement"
@@ -12,16 +16,43 @@ import (
 )
 
 func main() {
-	logApp, logServer, logRoutes, logAuthorizer, logSettlement := logger.New()
-	acdebitationHttp, settlementHttp := services.NewHttp()
+	logApp, logServer, logRoutes, logAuthorizer, logSettlement, logRules, logDynamodb, logNotifier, logServices, logReconciler := logger.New()
+	confHttp := &services.HttpConfig{}
+	confHttp.WithTimeout(os.Getenv("HTTP_TIMEOUT"))
+	confHttp.WithMaxAttempts(os.Getenv("HTTP_MAX_ATTEMPTS"))
+	confHttp.WithRetryBase(os.Getenv("HTTP_RETRY_BASE"))
+	confHttp.WithFailureThreshold(os.Getenv("BREAKER_FAILURE_THRESHOLD"))
+	confHttp.WithOpenTimeout(os.Getenv("BREAKER_OPEN_TIMEOUT"))
+	acdebitationHttp, settlementHttp, notifierHttp, diagnostics := services.NewHttp(confHttp, logServices)
 	confAuthorizer := &authorizer.Config{}
 	confAuthorizer.WithUrl(os.Getenv("URL_ACCREDITATION"))
 	acdebitation := authorizer.New(logAuthorizer, confAuthorizer, acdebitationHttp)
 	confSettlement := &settlement.Config{}
 	confSettlement.WithUrl(os.Getenv("URL_BALANCE"))
+	confSettlement.WithTransactionsUrl(os.Getenv("URL_BALANCE_ACCOUNTS"))
+	confSettlement.WithHoldsUrl(os.Getenv("URL_BALANCE_HOLDS"))
 	balance := settlement.New(logSettlement, confSettlement, settlementHttp)
-	debit := app.New(acdebitation, balance, logApp)
-	routes := routes.New(debit, logRoutes)
+	holds := settlement.NewHolds(logSettlement, confSettlement, settlementHttp)
+	usage := settlement.NewUsage(logSettlement, confSettlement, settlementHttp)
+	confRules := &rules.Config{}
+	confRules.WithFile(os.Getenv("RULES_FILE"))
+	ruleEngine := rules.New(logRules, confRules, usage)
+	dynamodbConfig := repository.Config{}
+	dynamodbConfig.WithTableName(os.Getenv("VELOCITY_TABLE_NAME"))
+	dynamodbConfig.WithTransactionTableName(os.Getenv("TRANSACTION_TABLE_NAME"))
+	dynamodbService := services.NewDynamodb()
+	counters := repository.NewDynamodb(dynamodbService, logDynamodb, dynamodbConfig)
+	transactions := repository.NewTransactions(dynamodbService, logDynamodb, dynamodbConfig)
+	confNotifier := &notifier.Config{}
+	confNotifier.WithUrl(os.Getenv("URL_WEBHOOK_EVENTS"))
+	webhook := notifier.New(logNotifier, confNotifier, notifierHttp)
+	debit := app.New(acdebitation, balance, holds, usage, ruleEngine, counters, transactions, webhook, logApp)
+	confReconciler := &reconciler.Config{}
+	confReconciler.WithInterval(os.Getenv("RECONCILE_INTERVAL"))
+	confReconciler.WithAfter(os.Getenv("RECONCILE_AFTER"))
+	rec := reconciler.New(debit, logReconciler, confReconciler)
+	go rec.Start()
+	routes := routes.New(debit, diagnostics, logRoutes)
 	serverHttp := server.New(routes, logServer)
 	serverHttp.Start()
 }
@@ -0,0 +1,6 @@
+package reconciler
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
@@ -0,0 +1,52 @@
+package reconciler
+
+import (
+	"context"
+	"debit/app"
+	"fmt"
+	"time"
+)
+
+// runTimeout bounds a batch of lookups in balance, each of them bounded by the
+// http timeout.
+const runTimeout = 5 * time.Minute
+
+type Reconciler struct {
+	log    Logger
+	config *Config
+	debit  app.Debit
+}
+
+func (r *Reconciler) Start() {
+	r.log.Info(fmt.Sprintf("Starting reconciler every %s", r.config.Interval))
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		r.run(now)
+	}
+}
+
+func (r *Reconciler) run(now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	i := &app.ReconcileInput{
+		AttemptedBefore: now.Add(-r.config.After),
+	}
+	o, err := r.debit.ReconcileWithContext(ctx, i)
+	if err != nil {
+		r.log.Error(fmt.Sprintf("Could not reconcile transactions %s", err.Error()))
+		return
+	}
+	if o.Settled > 0 || o.Failed > 0 || o.Unresolved > 0 {
+		r.log.Info(fmt.Sprintf("Transactions settled %d failed %d unresolved %d", o.Settled, o.Failed, o.Unresolved))
+	}
+}
+
+func New(debit app.Debit, log Logger, config *Config) *Reconciler {
+	return &Reconciler{
+		debit:  debit,
+		log:    log,
+		config: config,
+	}
+}
@@ -0,0 +1,16 @@
+package repository
+
+type Config struct {
+	TableName            string
+	TransactionTableName string
+}
+
+func (c *Config) WithTableName(tableName string) *Config {
+	c.TableName = tableName
+	return c
+}
+
+func (c *Config) WithTransactionTableName(tableName string) *Config {
+	c.TransactionTableName = tableName
+	return c
+}
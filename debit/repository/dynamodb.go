@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"debit/app"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+)
+
+type Dynamodb interface {
+	UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	BatchGetItemWithContext(ctx context.Context, input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error)
+	GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+}
+
+type db struct {
+	dynamodbService Dynamodb
+	log             Logger
+	config          Config
+}
+
+// bucketKey names the fixed bucket of the window that starts at start.
+func bucketKey(key string, window time.Duration, start time.Time) string {
+	return fmt.Sprintf("%s#%d#%d", key, int64(window.Seconds()), start.Unix())
+}
+
+func intAttribute(item map[string]*dynamodb.AttributeValue, name string) (int, error) {
+	if v, ok := item[name]; ok && v != nil && v.N != nil {
+		return strconv.Atoi(aws.StringValue(v.N))
+	}
+	return 0, nil
+}
+
+// GetWithContext estimates the sliding window from the current bucket of every key
+// and window plus the previous bucket weighted by the part of it that is still
+// inside the window.
+func (d *db) GetWithContext(ctx context.Context, input *app.GetCountersInput) (*app.GetCountersOutput, error) {
+	type bucket struct {
+		counter  *app.Counter
+		current  string
+		previous string
+		weight   float64
+	}
+
+	buckets := make([]*bucket, 0, len(input.Keys)*len(input.Windows))
+	keys := make([]map[string]*dynamodb.AttributeValue, 0, 2*cap(buckets))
+	for _, key := range input.Keys {
+		for _, window := range input.Windows {
+			start := input.At.Truncate(window)
+			b := &bucket{
+				counter: &app.Counter{
+					Key:    key,
+					Window: window,
+				},
+				current:  bucketKey(key, window, start),
+				previous: bucketKey(key, window, start.Add(-window)),
+				weight:   1 - float64(input.At.Sub(start))/float64(window),
+			}
+			buckets = append(buckets, b)
+			for _, k := range []string{b.current, b.previous} {
+				keys = append(keys, map[string]*dynamodb.AttributeValue{
+					"CounterKey": {
+						S: aws.String(k),
+					},
+				})
+			}
+		}
+	}
+
+	bo, err := d.dynamodbService.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]*dynamodb.KeysAndAttributes{
+			d.config.TableName: {
+				Keys:           keys,
+				ConsistentRead: aws.Bool(true),
+			},
+		},
+	})
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	// Unprocessed buckets are left out, so the estimate only undercounts.
+	items := map[string]map[string]*dynamodb.AttributeValue{}
+	for _, item := range bo.Responses[d.config.TableName] {
+		if v, ok := item["CounterKey"]; ok && v != nil {
+			items[aws.StringValue(v.S)] = item
+		}
+	}
+
+	counters := make([]*app.Counter, 0, len(buckets))
+	for _, b := range buckets {
+		for k, weight := range map[string]float64{b.current: 1, b.previous: b.weight} {
+			item, ok := items[k]
+			if !ok {
+				continue
+			}
+			count, err := intAttribute(item, "Count")
+			if err != nil {
+				return nil, err
+			}
+			amount, err := intAttribute(item, "Amount")
+			if err != nil {
+				return nil, err
+			}
+			b.counter.Count += int(float64(count) * weight)
+			b.counter.Amount += int(float64(amount) * weight)
+		}
+		counters = append(counters, b.counter)
+	}
+
+	return &app.GetCountersOutput{
+		Counters: counters,
+	}, nil
+}
+
+// IncrementWithContext first claims the reference with a marker item, so the same
+// debit is never added twice, and then adds it to the current bucket of every key
+// and window. Markers and buckets expire through the table TTL once they can no
+// longer be read. A failure after the marker leaves the counters short.
+func (d *db) IncrementWithContext(ctx context.Context, input *app.IncrementInput) (*app.IncrementOutput, error) {
+	var longest time.Duration
+	for _, window := range input.Windows {
+		if window > longest {
+			longest = window
+		}
+	}
+
+	_, err := d.dynamodbService.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		Item: map[string]*dynamodb.AttributeValue{
+			"CounterKey": {
+				S: aws.String("reference#" + input.Reference),
+			},
+			"ExpiresAt": {
+				N: aws.String(strconv.FormatInt(input.At.Add(2*longest).Unix(), 10)),
+			},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(CounterKey)"),
+		TableName:           aws.String(d.config.TableName),
+	})
+	if err != nil {
+		if conditionalCheckFailed(err) {
+			return &app.IncrementOutput{
+				AlreadyCounted: true,
+			}, nil
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	for _, key := range input.Keys {
+		for _, window := range input.Windows {
+			start := input.At.Truncate(window)
+			i := &dynamodb.UpdateItemInput{
+				Key: map[string]*dynamodb.AttributeValue{
+					"CounterKey": {
+						S: aws.String(bucketKey(key, window, start)),
+					},
+				},
+				UpdateExpression: aws.String("ADD #count :one, Amount :amount SET ExpiresAt = if_not_exists(ExpiresAt, :expiresAt)"),
+				ExpressionAttributeNames: map[string]*string{
+					"#count": aws.String("Count"),
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":one": {
+						N: aws.String("1"),
+					},
+					":amount": {
+						N: aws.String(strconv.Itoa(input.Amount)),
+					},
+					":expiresAt": {
+						N: aws.String(strconv.FormatInt(start.Add(2*window).Unix(), 10)),
+					},
+				},
+				TableName: aws.String(d.config.TableName),
+			}
+			if _, err := d.dynamodbService.UpdateItemWithContext(ctx, i); err != nil {
+				d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+				return nil, err
+			}
+		}
+	}
+
+	return &app.IncrementOutput{
+		AlreadyCounted: false,
+	}, nil
+}
+
+func NewDynamodb(d Dynamodb, log Logger, config Config) app.Counters {
+	return &db{
+		dynamodbService: d,
+		log:             log,
+		config:          config,
+	}
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"debit/app"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+)
+
+const (
+	// transactionPendingIndex is sparse: Pending is removed once a transaction is
+	// settled or failed.
+	transactionPendingIndex = "Pending-AttemptedAt-index"
+	transactionPending      = "1"
+	// timeLayout has a fixed width, so the times sort as strings.
+	timeLayout = "2006-01-02T15:04:05.000Z07:00"
+)
+
+type transactions struct {
+	dynamodbService Dynamodb
+	log             Logger
+	config          Config
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func conditionalCheckFailed(err error) bool {
+	ae, ok := err.(awserr.RequestFailure)
+	return ok && ae.Code() == "ConditionalCheckFailedException"
+}
+
+func stringAttribute(item map[string]*dynamodb.AttributeValue, name string) string {
+	if v, ok := item[name]; ok && v != nil {
+		return aws.StringValue(v.S)
+	}
+	return ""
+}
+
+func timeAttribute(item map[string]*dynamodb.AttributeValue, name string) (time.Time, error) {
+	if s := stringAttribute(item, name); s != "" {
+		return time.Parse(time.RFC3339, s)
+	}
+	return time.Time{}, nil
+}
+
+func transactionKey(kind string, externalKey string) string {
+	return fmt.Sprintf("%s#%s", kind, externalKey)
+}
+
+func transactionItemKey(accountKey string, kind string, externalKey string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"AccountKey": {
+			S: aws.String(accountKey),
+		},
+		"TransactionKey": {
+			S: aws.String(transactionKey(kind, externalKey)),
+		},
+	}
+}
+
+// BeginWithContext starts a new attempt over a failed transaction, or over a
+// pending one with the same payload, keeping the time it was first seen. A settled
+// record, or a pending one from another request, is left for the app and the
+// reconciler to answer.
+func (d *transactions) BeginWithContext(ctx context.Context, input *app.Transaction) (*app.BeginTransactionOutput, error) {
+	i := &dynamodb.UpdateItemInput{
+		Key:                 transactionItemKey(input.AccountKey, input.Kind, input.ExternalKey),
+		UpdateExpression:    aws.String("SET Kind = :kind, ExternalKey = :externalKey, OperationType = :operationType, Amount = :amount, Currency = :currency, Installments = :installments, OriginalExternalKey = :originalExternalKey, #status = :status, RequestID = :requestId, RequestHash = :requestHash, Decision = :decision, RuleID = :ruleId, RiskScore = :riskScore, DocumentNumber = :documentNumber, AttemptedAt = :attemptedAt, Pending = :pending, CreatedAt = if_not_exists(CreatedAt, :attemptedAt) REMOVE Code, Detail, SettledAt"),
+		ConditionExpression: aws.String("attribute_not_exists(AccountKey) OR #status = :failed OR (#status = :status AND RequestHash = :requestHash)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":kind": {
+				S: aws.String(input.Kind),
+			},
+			":externalKey": {
+				S: aws.String(input.ExternalKey),
+			},
+			":operationType": {
+				S: aws.String(input.OperationType),
+			},
+			":amount": {
+				N: aws.String(strconv.Itoa(input.Amount)),
+			},
+			":currency": {
+				S: aws.String(input.Currency),
+			},
+			":installments": {
+				N: aws.String(strconv.Itoa(input.Installments)),
+			},
+			":originalExternalKey": {
+				S: aws.String(input.OriginalExternalKey),
+			},
+			":status": {
+				S: aws.String(input.Status),
+			},
+			":requestId": {
+				S: aws.String(input.RequestID),
+			},
+			":requestHash": {
+				S: aws.String(input.RequestHash),
+			},
+			":decision": {
+				S: aws.String(input.Decision),
+			},
+			":ruleId": {
+				S: aws.String(input.RuleID),
+			},
+			":riskScore": {
+				N: aws.String(strconv.Itoa(input.RiskScore)),
+			},
+			":documentNumber": {
+				S: aws.String(input.DocumentNumber),
+			},
+			":attemptedAt": {
+				S: aws.String(formatTime(input.AttemptedAt)),
+			},
+			":pending": {
+				S: aws.String(transactionPending),
+			},
+			":failed": {
+				S: aws.String(app.TransactionFailed),
+			},
+		},
+		TableName: aws.String(d.config.TransactionTableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb begin transaction %s %s", input.AccountKey, transactionKey(input.Kind, input.ExternalKey)))
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		if conditionalCheckFailed(err) {
+			return &app.BeginTransactionOutput{
+				AlreadyExists: true,
+			}, nil
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.BeginTransactionOutput{
+		AlreadyExists: false,
+	}, nil
+}
+
+func (d *transactions) FinishWithContext(ctx context.Context, input *app.FinishTransactionInput) (*app.FinishTransactionOutput, error) {
+	updateExpression := "SET #status = :status, Code = :code, Detail = :detail, Amount = :amount, Currency = :currency REMOVE Pending"
+	values := map[string]*dynamodb.AttributeValue{
+		":status": {
+			S: aws.String(input.Status),
+		},
+		":code": {
+			S: aws.String(input.Code),
+		},
+		":detail": {
+			S: aws.String(input.Detail),
+		},
+		":amount": {
+			N: aws.String(strconv.Itoa(input.Amount)),
+		},
+		":currency": {
+			S: aws.String(input.Currency),
+		},
+		":pending": {
+			S: aws.String(transactionPending),
+		},
+		":attemptedAt": {
+			S: aws.String(formatTime(input.AttemptedAt)),
+		},
+	}
+	if input.Status == app.TransactionSettled {
+		updateExpression = "SET #status = :status, Code = :code, Detail = :detail, Amount = :amount, Currency = :currency, RequestID = :requestId, SettledAt = :settledAt REMOVE Pending"
+		values[":requestId"] = &dynamodb.AttributeValue{
+			S: aws.String(input.RequestID),
+		}
+		values[":settledAt"] = &dynamodb.AttributeValue{
+			S: aws.String(formatTime(input.SettledAt)),
+		}
+	}
+
+	i := &dynamodb.UpdateItemInput{
+		Key:                 transactionItemKey(input.AccountKey, input.Kind, input.ExternalKey),
+		UpdateExpression:    aws.String(updateExpression),
+		ConditionExpression: aws.String("Pending = :pending AND AttemptedAt = :attemptedAt"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: values,
+		TableName:                 aws.String(d.config.TransactionTableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb finish transaction %s %s %s", input.AccountKey, transactionKey(input.Kind, input.ExternalKey), input.Status))
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		if conditionalCheckFailed(err) {
+			return &app.FinishTransactionOutput{
+				TransactionChanged: true,
+			}, nil
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.FinishTransactionOutput{
+		TransactionChanged: false,
+	}, nil
+}
+
+func transactionFromItem(item map[string]*dynamodb.AttributeValue) (*app.Transaction, error) {
+	t := &app.Transaction{
+		AccountKey:          stringAttribute(item, "AccountKey"),
+		Kind:                stringAttribute(item, "Kind"),
+		ExternalKey:         stringAttribute(item, "ExternalKey"),
+		OperationType:       stringAttribute(item, "OperationType"),
+		Currency:            stringAttribute(item, "Currency"),
+		OriginalExternalKey: stringAttribute(item, "OriginalExternalKey"),
+		Status:              stringAttribute(item, "Status"),
+		Code:                stringAttribute(item, "Code"),
+		Detail:              stringAttribute(item, "Detail"),
+		RequestID:           stringAttribute(item, "RequestID"),
+		RequestHash:         stringAttribute(item, "RequestHash"),
+		Decision:            stringAttribute(item, "Decision"),
+		RuleID:              stringAttribute(item, "RuleID"),
+		DocumentNumber:      stringAttribute(item, "DocumentNumber"),
+	}
+	for name, v := range map[string]*int{
+		"Amount":       &t.Amount,
+		"Installments": &t.Installments,
+		"RiskScore":    &t.RiskScore,
+	} {
+		n, err := intAttribute(item, name)
+		if err != nil {
+			return nil, err
+		}
+		*v = n
+	}
+	for name, v := range map[string]*time.Time{
+		"CreatedAt":   &t.CreatedAt,
+		"AttemptedAt": &t.AttemptedAt,
+		"SettledAt":   &t.SettledAt,
+	} {
+		tm, err := timeAttribute(item, name)
+		if err != nil {
+			return nil, err
+		}
+		*v = tm
+	}
+
+	return t, nil
+}
+
+func (d *transactions) GetWithContext(ctx context.Context, input *app.GetTransactionRecordInput) (*app.Transaction, error) {
+	i := &dynamodb.GetItemInput{
+		Key:            transactionItemKey(input.AccountKey, input.Kind, input.ExternalKey),
+		ConsistentRead: aws.Bool(true),
+		TableName:      aws.String(d.config.TransactionTableName),
+	}
+	o, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get transaction %s", err.Error()))
+		return nil, err
+	}
+	if o.Item == nil {
+		return nil, nil
+	}
+
+	return transactionFromItem(o.Item)
+}
+
+// ListPendingWithContext returns the oldest attempts first.
+func (d *transactions) ListPendingWithContext(ctx context.Context, input *app.ListPendingTransactionsInput) (*app.ListPendingTransactionsOutput, error) {
+	i := &dynamodb.QueryInput{
+		IndexName:              aws.String(transactionPendingIndex),
+		KeyConditionExpression: aws.String("Pending = :pending AND AttemptedAt < :attemptedBefore"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pending": {
+				S: aws.String(transactionPending),
+			},
+			":attemptedBefore": {
+				S: aws.String(formatTime(input.AttemptedBefore)),
+			},
+		},
+		Limit:     aws.Int64(int64(input.Limit)),
+		TableName: aws.String(d.config.TransactionTableName),
+	}
+	o, err := d.dynamodbService.QueryWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error query pending transactions %s", err.Error()))
+		return nil, err
+	}
+
+	pending := make([]*app.Transaction, 0, len(o.Items))
+	for _, item := range o.Items {
+		t, err := transactionFromItem(item)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error pending transaction item %s", err.Error()))
+			return nil, err
+		}
+		pending = append(pending, t)
+	}
+
+	return &app.ListPendingTransactionsOutput{
+		Transactions: pending,
+	}, nil
+}
+
+func NewTransactions(d Dynamodb, log Logger, config Config) app.Transactions {
+	return &transactions{
+		dynamodbService: d,
+		log:             log,
+		config:          config,
+	}
+}
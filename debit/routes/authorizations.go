@@ -0,0 +1,265 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"debit/app"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type AuthorizationRequest struct {
+	AccountKey  *string `json:"account_key,omitempty"`
+	ExternalKey *string `json:"external_key,omitempty"`
+	Amount      *int    `json:"amount,omitempty"`
+	Currency    *string `json:"currency,omitempty"`
+
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type CaptureRequest struct {
+	AccountKey *string `json:"account_key,omitempty"`
+	Amount     *int    `json:"amount,omitempty"`
+
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type AuthorizationResponse struct {
+	AccountKey     string    `json:"account_key"`
+	ExternalKey    string    `json:"external_key"`
+	OperationType  string    `json:"operation_type"`
+	Status         string    `json:"status"`
+	Amount         int       `json:"amount"`
+	Currency       string    `json:"currency"`
+	CapturedAmount int       `json:"captured_amount,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	RequestID      string    `json:"request_id,omitempty"`
+	Decision       string    `json:"decision,omitempty"`
+	RuleID         string    `json:"rule_id,omitempty"`
+	RiskScore      int       `json:"risk_score"`
+}
+
+func buildAuthorizationRequest(a []byte) (*AuthorizationRequest, *TransactionErrorResponse) {
+	va := &AuthorizationRequest{}
+
+	err := json.Unmarshal(a, &va)
+	if err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.AccountKey == nil || stringValue(va.AccountKey) == "" {
+		return nil, responseBuild("account_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.ExternalKey == nil || stringValue(va.ExternalKey) == "" {
+		return nil, responseBuild("external_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if strings.Contains(stringValue(va.ExternalKey), "#") || strings.Contains(stringValue(va.ExternalKey), "/") {
+		return nil, responseBuild("external_key must not contain # or /", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.Amount == nil || intValue(va.Amount) <= 0 {
+		return nil, responseBuild("amount is missing or not positive", http.StatusBadRequest, BadRequest)
+	}
+
+	if errorResponse := validateCurrency(stringValue(va.Currency)); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	if errorResponse := validateMetadata(stringValue(va.Description), va.Metadata); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	return va, nil
+}
+
+func buildCaptureRequest(a []byte) (*CaptureRequest, *TransactionErrorResponse) {
+	va := &CaptureRequest{}
+
+	err := json.Unmarshal(a, &va)
+	if err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.AccountKey == nil || stringValue(va.AccountKey) == "" {
+		return nil, responseBuild("account_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if intValue(va.Amount) < 0 {
+		return nil, responseBuild("amount must be positive", http.StatusBadRequest, BadRequest)
+	}
+
+	if errorResponse := validateMetadata(stringValue(va.Description), va.Metadata); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	return va, nil
+}
+
+func authorizationResponseBuild(res *app.TransactionOutput) *TransactionErrorResponse {
+	if res.Code == app.UnauthorizedTransaction || res.Code == app.UnauthorizedSettlement {
+		return responseBuild(res.Detail, http.StatusBadGateway, BadGateway)
+	}
+
+	if res.Code == app.AuthorizerNotFound {
+		return responseBuild("Account Key not found", http.StatusNotFound, NotFound)
+	}
+
+	if res.Code == app.AccountBlocked || res.Code == app.AccountClosed {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity)
+	}
+
+	if errorResponse := currencyResponseBuild(res); errorResponse != nil {
+		return errorResponse
+	}
+
+	if errorResponse := policyResponseBuild(res); errorResponse != nil {
+		return errorResponse
+	}
+
+	if res.Code == app.OperationTypeInvalid || res.Code == app.HoldInvalid {
+		return responseBuild(res.Detail, http.StatusBadRequest, BadRequest)
+	}
+
+	if res.Code == app.HoldNotFound {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusNotFound, NotFound)
+	}
+
+	if res.Code == app.IdempotencyKeyReused {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict)
+	}
+
+	if res.Code == app.SettlementFailed {
+		return responseBuild(res.Detail, http.StatusConflict, Conflict)
+	}
+
+	return codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity)
+}
+
+func authorizationResponse(accountKey string, externalKey string, res *app.TransactionOutput) *AuthorizationResponse {
+	return &AuthorizationResponse{
+		AccountKey:     accountKey,
+		ExternalKey:    externalKey,
+		OperationType:  app.Buying,
+		Status:         res.Status,
+		Amount:         res.Amount,
+		Currency:       res.Currency,
+		CapturedAmount: res.CapturedAmount,
+		CreatedAt:      res.CreatedAt,
+		ExpiresAt:      res.ExpiresAt,
+		RequestID:      res.RequestID,
+		Decision:       res.Decision,
+		RuleID:         res.RuleID,
+		RiskScore:      res.RiskScore,
+	}
+}
+
+func authorizationWithContext(ctx context.Context, requestID string, body io.ReadCloser, log Logger, a app.Debit) (*AuthorizationResponse, *TransactionErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request, errorResponse := buildAuthorizationRequest(b)
+
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	i := &app.TransactionInput{
+		AccountKey:    stringValue(request.AccountKey),
+		ExternalKey:   stringValue(request.ExternalKey),
+		OperationType: app.Buying,
+		Amount:        intValue(request.Amount),
+		Currency:      stringValue(request.Currency),
+		RequestID:     requestID,
+		Description:   stringValue(request.Description),
+		Metadata:      request.Metadata,
+	}
+
+	res, err := a.AuthorizationWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, authorizationResponseBuild(res), nil
+	}
+
+	return authorizationResponse(i.AccountKey, i.ExternalKey, res), nil, nil
+}
+
+func captureWithContext(ctx context.Context, requestID string, externalKey string, body io.ReadCloser, log Logger, a app.Debit) (*AuthorizationResponse, *TransactionErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request, errorResponse := buildCaptureRequest(b)
+
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	i := &app.CaptureInput{
+		AccountKey:  stringValue(request.AccountKey),
+		ExternalKey: externalKey,
+		Amount:      intValue(request.Amount),
+		RequestID:   requestID,
+		Description: stringValue(request.Description),
+		Metadata:    request.Metadata,
+	}
+
+	res, err := a.CaptureWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, authorizationResponseBuild(res), nil
+	}
+
+	return authorizationResponse(i.AccountKey, i.ExternalKey, res), nil, nil
+}
+
+func voidWithContext(ctx context.Context, externalKey string, body io.ReadCloser, log Logger, a app.Debit) (*AuthorizationResponse, *TransactionErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request := &CaptureRequest{}
+	if err := json.Unmarshal(b, &request); err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest), nil
+	}
+
+	if request.AccountKey == nil || stringValue(request.AccountKey) == "" {
+		return nil, responseBuild("account_key is missing or null", http.StatusBadRequest, BadRequest), nil
+	}
+
+	i := &app.VoidInput{
+		AccountKey:  stringValue(request.AccountKey),
+		ExternalKey: externalKey,
+	}
+
+	res, err := a.VoidWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, authorizationResponseBuild(res), nil
+	}
+
+	return authorizationResponse(i.AccountKey, i.ExternalKey, res), nil, nil
+}
@@ -1,18 +1,24 @@
 package routes
 
 import (
+	"crypto/rand"
 	"debit/app"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
+const RequestIDHeader = "X-Request-Id"
+
 type Routes interface {
 	Default() *http.ServeMux
 }
 
 type routes struct {
-	debit app.Debit
-	log   Logger
+	debit       app.Debit
+	diagnostics app.Diagnostics
+	log         Logger
 }
 
 func healthz() http.Handler {
@@ -21,33 +27,180 @@ func healthz() http.Handler {
 	})
 }
 
+func writeResponse(w http.ResponseWriter, statusCode int, v interface{}) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(res); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
 func transactions(a app.Debit, log Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			ctx := r.Context()
-			accountResponse, err := transactionWithContext(ctx, r.Body, log, a)
+			id := requestID(r)
+			w.Header().Set(RequestIDHeader, id)
+			o, errorResponse, err := transactionWithContext(ctx, id, r.Body, log, a)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
 
-			if accountResponse != nil {
-				res, err := json.Marshal(accountResponse)
-				if err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(accountResponse.Error.StatusCode)
-				w.Header().Set("Content-Type", "application/json")
-				if _, err := w.Write(res); err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+
+			writeResponse(w, http.StatusCreated, o)
+		} else {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// transactionByKey serves GET /v1/transactions/{account_key}/{external_key} and
+// POST /v1/transactions/{external_key}/reversal. The method picks the route before
+// the path is read, so a transaction whose external_key is "reversal" can still be
+// looked up.
+func transactionByKey(a app.Debit, log Logger) http.Handler {
+	status := transactionStatus(a, log)
+	reversal := transactionReversal(a, log)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			status.ServeHTTP(w, r)
+		case http.MethodPost:
+			reversal.ServeHTTP(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func transactionStatus(a app.Debit, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/transactions/"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		o, errorResponse, err := transactionStatusWithContext(r.Context(), parts[0], parts[1], log, a)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if errorResponse != nil {
+			writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, o)
+	})
+}
+
+func transactionReversal(a app.Debit, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/transactions/"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] != "reversal" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		ctx := r.Context()
+		id := requestID(r)
+		w.Header().Set(RequestIDHeader, id)
+		o, errorResponse, err := reversalWithContext(ctx, id, parts[0], r.Body, log, a)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if errorResponse != nil {
+			writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+			return
+		}
+
+		writeResponse(w, http.StatusCreated, o)
+	})
+}
+
+func authorizations(a app.Debit, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			ctx := r.Context()
+			id := requestID(r)
+			w.Header().Set(RequestIDHeader, id)
+			o, errorResponse, err := authorizationWithContext(ctx, id, r.Body, log, a)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+
+			writeResponse(w, http.StatusCreated, o)
+		} else {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func authorization(a app.Debit, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/authorizations/"), "/")
+		if len(parts) != 2 || parts[0] == "" || (parts[1] != "capture" && parts[1] != "void") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			ctx := r.Context()
+			id := requestID(r)
+			w.Header().Set(RequestIDHeader, id)
+			var o *AuthorizationResponse
+			var errorResponse *TransactionErrorResponse
+			var err error
+			if parts[1] == "capture" {
+				o, errorResponse, err = captureWithContext(ctx, id, parts[0], r.Body, log, a)
+			} else {
+				o, errorResponse, err = voidWithContext(ctx, parts[0], r.Body, log, a)
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
 
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
 				return
 			}
 
-			w.WriteHeader(http.StatusCreated)
+			writeResponse(w, http.StatusOK, o)
 		} else {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
@@ -57,13 +210,18 @@ func transactions(a app.Debit, log Logger) http.Handler {
 func (r *routes) Default() *http.ServeMux {
 	middleware := http.NewServeMux()
 	middleware.Handle("/v1/transactions", transactions(r.debit, r.log))
+	middleware.Handle("/v1/transactions/", transactionByKey(r.debit, r.log))
+	middleware.Handle("/v1/authorizations", authorizations(r.debit, r.log))
+	middleware.Handle("/v1/authorizations/", authorization(r.debit, r.log))
+	middleware.Handle("/diagnostics", diagnostics(r.diagnostics))
 	middleware.Handle("/health", healthz())
 	return middleware
 }
 
-func New(a app.Debit, log Logger) Routes {
+func New(a app.Debit, d app.Diagnostics, log Logger) Routes {
 	return &routes{
-		debit: a,
-		log:   log,
+		debit:       a,
+		diagnostics: d,
+		log:         log,
 	}
 }
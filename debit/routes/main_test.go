@@ -0,0 +1,157 @@
+package routes
+
+import (
+	"context"
+	"debit/app"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type debitMock struct {
+	v string
+	t *testing.T
+}
+
+func (d *debitMock) assertInput(input interface{}) {
+	vt, err := json.Marshal(input)
+	assert.Nil(d.t, err)
+	assert.Equal(d.t, d.v, string(vt))
+}
+
+func (d *debitMock) TransactionWithContext(ctx context.Context, input *app.TransactionInput) (*app.TransactionOutput, error) {
+	return nil, errors.New("unexpected transaction")
+}
+
+func (d *debitMock) ReverseWithContext(ctx context.Context, input *app.ReverseInput) (*app.TransactionOutput, error) {
+	d.assertInput(input)
+
+	if input.ExternalKey == "404" {
+		return &app.TransactionOutput{
+			Error:  true,
+			Code:   app.OriginalNotFound,
+			Detail: "original transaction not found",
+		}, nil
+	}
+
+	return &app.TransactionOutput{
+		Amount:    input.Amount,
+		Currency:  "BRL",
+		CreatedAt: time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		RequestID: input.RequestID,
+	}, nil
+}
+
+func (d *debitMock) AuthorizationWithContext(ctx context.Context, input *app.TransactionInput) (*app.TransactionOutput, error) {
+	return nil, errors.New("unexpected authorization")
+}
+
+func (d *debitMock) CaptureWithContext(ctx context.Context, input *app.CaptureInput) (*app.TransactionOutput, error) {
+	return nil, errors.New("unexpected capture")
+}
+
+func (d *debitMock) VoidWithContext(ctx context.Context, input *app.VoidInput) (*app.TransactionOutput, error) {
+	return nil, errors.New("unexpected void")
+}
+
+func (d *debitMock) GetTransactionWithContext(ctx context.Context, input *app.GetTransactionInput) (*app.GetTransactionOutput, error) {
+	d.assertInput(input)
+
+	if input.AccountKey == "404" {
+		return &app.GetTransactionOutput{
+			Error:  true,
+			Code:   app.TransactionNotFound,
+			Detail: "transaction not found",
+		}, nil
+	}
+
+	return &app.GetTransactionOutput{
+		Transaction: &app.Transaction{
+			AccountKey:    input.AccountKey,
+			ExternalKey:   input.ExternalKey,
+			OperationType: "purchase",
+			Status:        app.TransactionSettled,
+			Amount:        100,
+			Currency:      "BRL",
+			CreatedAt:     time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		},
+	}, nil
+}
+
+func (d *debitMock) ReconcileWithContext(ctx context.Context, input *app.ReconcileInput) (*app.ReconcileOutput, error) {
+	return nil, errors.New("unexpected reconcile")
+}
+
+type loggerMock struct{}
+
+func (l *loggerMock) Info(msg string)  {}
+func (l *loggerMock) Error(msg string) {}
+
+func serveTransactionByKey(t *testing.T, v string, method string, path string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set(RequestIDHeader, "r1")
+	w := httptest.NewRecorder()
+	transactionByKey(&debitMock{v: v, t: t}, &loggerMock{}).ServeHTTP(w, r)
+	return w
+}
+
+func TestTransactionByKeyGetStatus(t *testing.T) {
+	w := serveTransactionByKey(t, `{"AccountKey":"1","ExternalKey":"w1"}`, http.MethodGet, "/v1/transactions/1/w1", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"account_key\":\"1\",\"external_key\":\"w1\",\"operation_type\":\"purchase\",\"status\":\"settled\",\"amount\":100,\"currency\":\"BRL\",\"created_at\":\"2022-01-31T10:00:00Z\"}", strings.TrimSpace(w.Body.String()))
+}
+
+func TestTransactionByKeyGetStatusWhenExternalKeyIsReversal(t *testing.T) {
+	w := serveTransactionByKey(t, `{"AccountKey":"1","ExternalKey":"reversal"}`, http.MethodGet, "/v1/transactions/1/reversal", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"account_key\":\"1\",\"external_key\":\"reversal\",\"operation_type\":\"purchase\",\"status\":\"settled\",\"amount\":100,\"currency\":\"BRL\",\"created_at\":\"2022-01-31T10:00:00Z\"}", strings.TrimSpace(w.Body.String()))
+}
+
+func TestTransactionByKeyGetStatusNotFound(t *testing.T) {
+	w := serveTransactionByKey(t, `{"AccountKey":"404","ExternalKey":"w1"}`, http.MethodGet, "/v1/transactions/404/w1", "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"code\":\"transaction-not-found\",\"message\":\"transaction not found\"}}", strings.TrimSpace(w.Body.String()))
+}
+
+func TestTransactionByKeyPostReversal(t *testing.T) {
+	w := serveTransactionByKey(t, `{"AccountKey":"1","ExternalKey":"w1","ReversalKey":"r1","Amount":50,"RequestID":"r1","Description":"","Metadata":null}`, http.MethodPost, "/v1/transactions/w1/reversal", `{"account_key":"1","reversal_key":"r1","amount":50}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "r1", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "{\"account_key\":\"1\",\"external_key\":\"w1\",\"reversal_key\":\"r1\",\"amount\":50,\"currency\":\"BRL\",\"created_at\":\"2022-01-31T10:00:00Z\",\"request_id\":\"r1\"}", strings.TrimSpace(w.Body.String()))
+}
+
+func TestTransactionByKeyPostReversalOriginalNotFound(t *testing.T) {
+	w := serveTransactionByKey(t, `{"AccountKey":"1","ExternalKey":"404","ReversalKey":"r1","Amount":50,"RequestID":"r1","Description":"","Metadata":null}`, http.MethodPost, "/v1/transactions/404/reversal", `{"account_key":"1","reversal_key":"r1","amount":50}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"not_found\",\"message\":\"original transaction not found\"}}", strings.TrimSpace(w.Body.String()))
+}
+
+func TestTransactionByKeyNotPostOtherThanReversal(t *testing.T) {
+	w := serveTransactionByKey(t, "", http.MethodPost, "/v1/transactions/1/w1", `{"account_key":"1","reversal_key":"r1","amount":50}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTransactionByKeyNotFoundWhenPathIncomplete(t *testing.T) {
+	for _, path := range []string{"/v1/transactions/1", "/v1/transactions/1/", "/v1/transactions/1/w1/x"} {
+		w := serveTransactionByKey(t, "", http.MethodGet, path, "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
+
+func TestTransactionByKeyMethodNotAllowed(t *testing.T) {
+	w := serveTransactionByKey(t, "", http.MethodPut, "/v1/transactions/w1/reversal", "")
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
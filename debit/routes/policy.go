@@ -1 +1,24 @@
 package routes
+
+import (
+	"debit/app"
+	"net/http"
+)
+
+// policyResponseBuild maps refusals from the account limits, the rule engine
+// and the risk score to their response.
+func policyResponseBuild(res *app.TransactionOutput) *TransactionErrorResponse {
+	if res != nil && res.Error && res.Code == app.LimitExceeded {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity)
+	}
+
+	if res != nil && res.Error && res.Code == app.TransactionDeclined {
+		ae := codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity)
+		ae.Error.Decision = res.Decision
+		ae.Error.RuleID = res.RuleID
+		ae.Error.RiskScore = res.RiskScore
+		return ae
+	}
+
+	return nil
+}
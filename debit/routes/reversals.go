@@ -0,0 +1,128 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"debit/app"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+type ReversalRequest struct {
+	AccountKey  *string `json:"account_key,omitempty"`
+	ReversalKey *string `json:"reversal_key,omitempty"`
+	Amount      *int    `json:"amount,omitempty"`
+
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type ReversalResponse struct {
+	AccountKey  string    `json:"account_key"`
+	ExternalKey string    `json:"external_key"`
+	ReversalKey string    `json:"reversal_key"`
+	Amount      int       `json:"amount"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"created_at"`
+	RequestID   string    `json:"request_id,omitempty"`
+}
+
+func buildReversalRequest(a []byte) (*ReversalRequest, *TransactionErrorResponse) {
+	va := &ReversalRequest{}
+
+	err := json.Unmarshal(a, &va)
+	if err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.AccountKey == nil || stringValue(va.AccountKey) == "" {
+		return nil, responseBuild("account_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.ReversalKey == nil || stringValue(va.ReversalKey) == "" {
+		return nil, responseBuild("reversal_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if intValue(va.Amount) < 0 {
+		return nil, responseBuild("amount must be positive", http.StatusBadRequest, BadRequest)
+	}
+
+	if errorResponse := validateMetadata(stringValue(va.Description), va.Metadata); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	return va, nil
+}
+
+func reversalWithContext(ctx context.Context, requestID string, externalKey string, body io.ReadCloser, log Logger, a app.Debit) (*ReversalResponse, *TransactionErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request, errorResponse := buildReversalRequest(b)
+
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	i := &app.ReverseInput{
+		AccountKey:  stringValue(request.AccountKey),
+		ExternalKey: externalKey,
+		ReversalKey: stringValue(request.ReversalKey),
+		Amount:      intValue(request.Amount),
+		RequestID:   requestID,
+		Description: stringValue(request.Description),
+		Metadata:    request.Metadata,
+	}
+
+	res, err := a.ReverseWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error && (res.Code == app.UnauthorizedTransaction || res.Code == app.UnauthorizedSettlement) {
+		return nil, responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.AuthorizerNotFound {
+		return nil, responseBuild("Account Key not found", http.StatusNotFound, NotFound), nil
+	}
+
+	if res != nil && res.Error && (res.Code == app.AccountBlocked || res.Code == app.AccountClosed) {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
+	}
+
+	if errorResponse := currencyResponseBuild(res); errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	if res != nil && res.Error && res.Code == app.OriginalNotFound {
+		return nil, responseBuild(res.Detail, http.StatusNotFound, NotFound), nil
+	}
+
+	if res != nil && res.Error && (res.Code == app.ReversalInvalid || res.Code == app.ReversalExceedsOriginal) {
+		return nil, responseBuild(res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.IdempotencyKeyReused {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.SettlementFailed {
+		return nil, responseBuild(res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	return &ReversalResponse{
+		AccountKey:  i.AccountKey,
+		ExternalKey: i.ExternalKey,
+		ReversalKey: i.ReversalKey,
+		Amount:      res.Amount,
+		Currency:    res.Currency,
+		CreatedAt:   res.CreatedAt,
+		RequestID:   res.RequestID,
+	}, nil, nil
+}
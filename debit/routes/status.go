@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"context"
+	"debit/app"
+	"net/http"
+	"time"
+)
+
+type TransactionStatusResponse struct {
+	AccountKey     string     `json:"account_key"`
+	ExternalKey    string     `json:"external_key"`
+	OperationType  string     `json:"operation_type"`
+	Status         string     `json:"status"`
+	Amount         int        `json:"amount"`
+	Currency       string     `json:"currency,omitempty"`
+	Installments   int        `json:"installments,omitempty"`
+	Code           string     `json:"code,omitempty"`
+	Detail         string     `json:"detail,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	AttemptedAt    *time.Time `json:"attempted_at,omitempty"`
+	SettledAt      *time.Time `json:"settled_at,omitempty"`
+	ReversedAmount int        `json:"reversed_amount,omitempty"`
+	RequestID      string     `json:"request_id,omitempty"`
+}
+
+func transactionStatusWithContext(ctx context.Context, accountKey string, externalKey string, log Logger, a app.Debit) (*TransactionStatusResponse, *TransactionErrorResponse, error) {
+	res, err := a.GetTransactionWithContext(ctx, &app.GetTransactionInput{
+		AccountKey:  accountKey,
+		ExternalKey: externalKey,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error && res.Code == app.UnauthorizedSettlement {
+		return nil, responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.TransactionNotFound {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusNotFound, NotFound), nil
+	}
+
+	t := res.Transaction
+	o := &TransactionStatusResponse{
+		AccountKey:     t.AccountKey,
+		ExternalKey:    t.ExternalKey,
+		OperationType:  t.OperationType,
+		Status:         t.Status,
+		Amount:         t.Amount,
+		Currency:       t.Currency,
+		Installments:   t.Installments,
+		Code:           t.Code,
+		Detail:         t.Detail,
+		CreatedAt:      t.CreatedAt,
+		ReversedAmount: t.ReversedAmount,
+		RequestID:      t.RequestID,
+	}
+	// A transaction read from the ledger was never attempted by this service.
+	if !t.AttemptedAt.IsZero() {
+		o.AttemptedAt = &t.AttemptedAt
+	}
+	if !t.SettledAt.IsZero() {
+		o.SettledAt = &t.SettledAt
+	}
+	return o, nil, nil
+}
@@ -5,16 +5,25 @@ import (
 	"context"
 	"debit/app"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
 const (
-	BadRequest     = "bad_request"
-	Conflict       = "conflict"
-	InvalidRequest = "invalid_request"
-	BadGateway     = "bad_gateway"
-	NotFound       = "not_found"
+	BadRequest          = "bad_request"
+	Conflict            = "conflict"
+	InvalidRequest      = "invalid_request"
+	BadGateway          = "bad_gateway"
+	NotFound            = "not_found"
+	UnprocessableEntity = "unprocessable_entity"
+
+	MaxDescriptionLength   = 255
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 255
 )
 
 func stringValue(v *string) string {
@@ -36,13 +45,36 @@ type TransactionRequest struct {
 	ExternalKey   *string `json:"external_key,omitempty"`
 	OperationType *string `json:"operation_type,omitempty"`
 	Amount        *int    `json:"amount,omitempty"`
+	Currency      *string `json:"currency,omitempty"`
+	Installments  *int    `json:"installments,omitempty"`
+
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type TransactionResponse struct {
+	AccountKey    string    `json:"account_key"`
+	ExternalKey   string    `json:"external_key"`
+	OperationType string    `json:"operation_type"`
+	Amount        int       `json:"amount"`
+	Currency      string    `json:"currency"`
+	Installments  int       `json:"installments,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	RequestID     string    `json:"request_id,omitempty"`
+	Decision      string    `json:"decision,omitempty"`
+	RuleID        string    `json:"rule_id,omitempty"`
+	RiskScore     int       `json:"risk_score"`
 }
 
 type TransactionError struct {
 	StatusCode int    `json:"-"`
 	Type       string `json:"type,omitempty"`
 	Category   string `json:"category,omitempty"`
+	Code       string `json:"code,omitempty"`
 	Message    string `json:"message,omitempty"`
+	Decision   string `json:"decision,omitempty"`
+	RuleID     string `json:"rule_id,omitempty"`
+	RiskScore  int    `json:"risk_score,omitempty"`
 }
 
 type TransactionErrorResponse struct {
@@ -62,6 +94,58 @@ func responseBuild(msg string, statusCode int, category string) *TransactionErro
 	return ae
 }
 
+func codeResponseBuild(code string, msg string, statusCode int, category string) *TransactionErrorResponse {
+	ae := responseBuild(msg, statusCode, category)
+	ae.Error.Code = code
+	return ae
+}
+
+// validateCurrency only checks the ISO 4217 shape; balance knows which currencies it supports.
+func validateCurrency(currency string) *TransactionErrorResponse {
+	if currency == "" {
+		return nil
+	}
+
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return codeResponseBuild(app.CurrencyInvalid, "currency must be an ISO 4217 code such as BRL", http.StatusBadRequest, BadRequest)
+	}
+
+	return nil
+}
+
+func currencyResponseBuild(res *app.TransactionOutput) *TransactionErrorResponse {
+	if res != nil && res.Error && res.Code == app.CurrencyInvalid {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest)
+	}
+
+	if res != nil && res.Error && res.Code == app.CurrencyNotHeld {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity)
+	}
+
+	return nil
+}
+
+func validateMetadata(description string, metadata map[string]string) *TransactionErrorResponse {
+	if len(description) > MaxDescriptionLength {
+		return responseBuild(fmt.Sprintf("description must have at most %d characters", MaxDescriptionLength), http.StatusBadRequest, BadRequest)
+	}
+
+	if len(metadata) > MaxMetadataKeys {
+		return responseBuild(fmt.Sprintf("metadata must have at most %d keys", MaxMetadataKeys), http.StatusBadRequest, BadRequest)
+	}
+
+	for k, v := range metadata {
+		if k == "" || len(k) > MaxMetadataKeyLength {
+			return responseBuild(fmt.Sprintf("metadata keys must have between 1 and %d characters", MaxMetadataKeyLength), http.StatusBadRequest, BadRequest)
+		}
+		if len(v) > MaxMetadataValueLength {
+			return responseBuild(fmt.Sprintf("metadata values must have at most %d characters", MaxMetadataValueLength), http.StatusBadRequest, BadRequest)
+		}
+	}
+
+	return nil
+}
+
 func buildTransactionRequest(a []byte) (*TransactionRequest, *TransactionErrorResponse) {
 	va := &TransactionRequest{}
 
@@ -86,10 +170,18 @@ func buildTransactionRequest(a []byte) (*TransactionRequest, *TransactionErrorRe
 		return nil, responseBuild("amount is missing or 0", http.StatusBadRequest, BadRequest)
 	}
 
+	if errorResponse := validateCurrency(stringValue(va.Currency)); errorResponse != nil {
+		return nil, errorResponse
+	}
+
+	if errorResponse := validateMetadata(stringValue(va.Description), va.Metadata); errorResponse != nil {
+		return nil, errorResponse
+	}
+
 	return va, nil
 }
 
-func transactionWithContext(ctx context.Context, body io.ReadCloser, log Logger, a app.Debit) (*TransactionErrorResponse, error) {
+func transactionWithContext(ctx context.Context, requestID string, body io.ReadCloser, log Logger, a app.Debit) (*TransactionResponse, *TransactionErrorResponse, error) {
 	defer body.Close()
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(body)
@@ -98,7 +190,7 @@ func transactionWithContext(ctx context.Context, body io.ReadCloser, log Logger,
 	request, errorResponse := buildTransactionRequest(b)
 
 	if errorResponse != nil {
-		return errorResponse, nil
+		return nil, errorResponse, nil
 	}
 
 	i := &app.TransactionInput{
@@ -106,33 +198,74 @@ func transactionWithContext(ctx context.Context, body io.ReadCloser, log Logger,
 		ExternalKey:   stringValue(request.ExternalKey),
 		OperationType: stringValue(request.OperationType),
 		Amount:        intValue(request.Amount),
+		Currency:      stringValue(request.Currency),
+		Installments:  intValue(request.Installments),
+		RequestID:     requestID,
+		Description:   stringValue(request.Description),
+		Metadata:      request.Metadata,
 	}
 
 	res, err := a.TransactionWithContext(ctx, i)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if res != nil && res.Error && res.Code == app.UnauthorizedTransaction {
-		return responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
+		return nil, responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
 	}
 
 	if res != nil && res.Error && res.Code == app.UnauthorizedSettlement {
-		return responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
+		return nil, responseBuild(res.Detail, http.StatusBadGateway, BadGateway), nil
 	}
 
 	if res != nil && res.Error && res.Code == app.AuthorizerNotFound {
-		return responseBuild("Account Key not found", http.StatusNotFound, NotFound), nil
+		return nil, responseBuild("Account Key not found", http.StatusNotFound, NotFound), nil
+	}
+
+	if res != nil && res.Error && (res.Code == app.AccountBlocked || res.Code == app.AccountClosed) {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
+	}
+
+	if errorResponse := currencyResponseBuild(res); errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	if errorResponse := policyResponseBuild(res); errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	if res != nil && res.Error && res.Code == app.IdempotencyKeyReused {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusConflict, Conflict), nil
 	}
 
 	if res != nil && res.Error && res.Code == app.SettlementFailed {
-		return responseBuild(res.Detail, http.StatusConflict, Conflict), nil
+		return nil, responseBuild(res.Detail, http.StatusConflict, Conflict), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.InsufficientFunds {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusUnprocessableEntity, UnprocessableEntity), nil
 	}
 
 	if res != nil && res.Error && res.Code == app.OperationTypeInvalid {
-		return responseBuild(res.Detail, http.StatusBadRequest, BadRequest), nil
+		return nil, responseBuild(res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	if res != nil && res.Error && res.Code == app.InstallmentsInvalid {
+		return nil, responseBuild(res.Detail, http.StatusBadRequest, BadRequest), nil
 	}
 
-	return nil, nil
+	return &TransactionResponse{
+		AccountKey:    i.AccountKey,
+		ExternalKey:   i.ExternalKey,
+		OperationType: i.OperationType,
+		Amount:        res.Amount,
+		Currency:      res.Currency,
+		Installments:  i.Installments,
+		CreatedAt:     res.CreatedAt,
+		RequestID:     res.RequestID,
+		Decision:      res.Decision,
+		RuleID:        res.RuleID,
+		RiskScore:     res.RiskScore,
+	}, nil, nil
 }
@@ -0,0 +1,10 @@
+package rules
+
+type Config struct {
+	File string
+}
+
+func (c *Config) WithFile(file string) *Config {
+	c.File = file
+	return c
+}
@@ -0,0 +1,327 @@
+package rules
+
+import (
+	"context"
+	"debit/app"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	Amount               = "amount"
+	Velocity             = "velocity"
+	OperationTypeAllowed = "operation-type-allowed"
+	TimeWindow           = "time-window"
+
+	clockLayout = "15:04"
+)
+
+type RuleConfig struct {
+	ID             string   `json:"id"`
+	Type           string   `json:"type"`
+	Decision       string   `json:"decision"`
+	OperationTypes []string `json:"operation_types,omitempty"`
+
+	// amount: in the minor unit of currency, BRL when it is missing
+	AmountAbove int    `json:"amount_above,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+
+	// velocity
+	Count   int `json:"count,omitempty"`
+	Minutes int `json:"minutes,omitempty"`
+
+	// operation-type-allowed: account key to the operation types it may use
+	Accounts map[string][]string `json:"accounts,omitempty"`
+
+	// time-window: HH:MM in the UTC offset, the window may cross midnight
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	UTCOffset string `json:"utc_offset,omitempty"`
+}
+
+// ScoreConfig is one reference of the risk score, with amount in the minor unit of
+// the currency it is listed under; without amount only the count is scored.
+type ScoreConfig struct {
+	Minutes int `json:"minutes"`
+	Count   int `json:"count"`
+	Amount  int `json:"amount,omitempty"`
+}
+
+type RulesFile struct {
+	Rules []*RuleConfig `json:"rules"`
+	// Score replaces the default risk score references of each currency listed.
+	Score map[string][]*ScoreConfig `json:"score,omitempty"`
+}
+
+type matcher func(ctx context.Context, input *app.EvaluateInput) (bool, bool, error)
+
+type rule struct {
+	id       string
+	decision string
+	match    matcher
+}
+
+type engine struct {
+	log    Logger
+	usage  app.Usage
+	rules  []*rule
+	scores map[string][]*app.ScoreReference
+}
+
+// EvaluateWithContext runs the rules in file order and stops at the first match.
+func (e *engine) EvaluateWithContext(ctx context.Context, input *app.EvaluateInput) (*app.EvaluateOutput, error) {
+	for _, r := range e.rules {
+		matched, intermitance, err := r.match(ctx, input)
+		if err != nil {
+			e.log.Error(fmt.Sprintf("rule %s error %s", r.id, err.Error()))
+			return nil, err
+		}
+		if intermitance {
+			return &app.EvaluateOutput{
+				HasIntermitance: true,
+			}, nil
+		}
+		if matched {
+			return &app.EvaluateOutput{
+				Decision: r.decision,
+				RuleID:   r.id,
+			}, nil
+		}
+	}
+
+	return &app.EvaluateOutput{
+		Decision: app.DecisionApprove,
+	}, nil
+}
+
+func (e *engine) ScoreReferences(currency string) []*app.ScoreReference {
+	return e.scores[currency]
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// appliesTo is true when the rule has no operation types or lists this one.
+func appliesTo(operationTypes []string, operationType string) bool {
+	return len(operationTypes) == 0 || contains(operationTypes, operationType)
+}
+
+func amountMatcher(c *RuleConfig) (matcher, error) {
+	if c.AmountAbove <= 0 {
+		return nil, errors.New("amount_above must be positive")
+	}
+
+	currency := c.Currency
+	if currency == "" {
+		currency = app.DefaultCurrency
+	}
+	return func(ctx context.Context, input *app.EvaluateInput) (bool, bool, error) {
+		return appliesTo(c.OperationTypes, input.OperationType) && input.Currency == currency && input.Amount > c.AmountAbove, false, nil
+	}, nil
+}
+
+func (e *engine) velocityMatcher(c *RuleConfig) (matcher, error) {
+	if c.Count <= 0 || c.Minutes <= 0 {
+		return nil, errors.New("count and minutes must be positive")
+	}
+
+	operationTypes := c.OperationTypes
+	if len(operationTypes) == 0 {
+		operationTypes = []string{app.Withdraw, app.Buying, app.InstallmentBuying}
+	}
+	window := time.Duration(c.Minutes) * time.Minute
+	return func(ctx context.Context, input *app.EvaluateInput) (bool, bool, error) {
+		if !contains(operationTypes, input.OperationType) {
+			return false, false, nil
+		}
+
+		uo, err := e.usage.UsageWithContext(ctx, &app.UsageInput{
+			AccountKey:     input.AccountKey,
+			OperationTypes: operationTypes,
+			From:           input.At.Add(-window),
+		})
+		if err != nil {
+			return false, false, err
+		}
+		if uo.HasIntermitance {
+			return false, true, nil
+		}
+
+		// The transaction being evaluated counts towards the window.
+		return uo.Count+1 > c.Count, false, nil
+	}, nil
+}
+
+func operationTypeAllowedMatcher(c *RuleConfig) (matcher, error) {
+	if len(c.Accounts) == 0 {
+		return nil, errors.New("accounts is missing")
+	}
+
+	return func(ctx context.Context, input *app.EvaluateInput) (bool, bool, error) {
+		allowed, ok := c.Accounts[input.AccountKey]
+		return ok && !contains(allowed, input.OperationType), false, nil
+	}, nil
+}
+
+func minutesOfDay(v string) (int, error) {
+	t, err := time.Parse(clockLayout, v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be HH:MM", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func timeWindowMatcher(c *RuleConfig) (matcher, error) {
+	from, err := minutesOfDay(c.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := minutesOfDay(c.To)
+	if err != nil {
+		return nil, err
+	}
+
+	location := time.UTC
+	if c.UTCOffset != "" {
+		offset, err := time.Parse("-07:00", c.UTCOffset)
+		if err != nil {
+			return nil, errors.New("utc_offset must be ±HH:MM")
+		}
+		_, seconds := offset.Zone()
+		location = time.FixedZone(c.UTCOffset, seconds)
+	}
+
+	return func(ctx context.Context, input *app.EvaluateInput) (bool, bool, error) {
+		if !appliesTo(c.OperationTypes, input.OperationType) {
+			return false, false, nil
+		}
+
+		at := input.At.In(location)
+		m := at.Hour()*60 + at.Minute()
+		if from <= to {
+			return m >= from && m < to, false, nil
+		}
+		return m >= from || m < to, false, nil
+	}, nil
+}
+
+func (e *engine) compile(c *RuleConfig) (*rule, error) {
+	if c.ID == "" {
+		return nil, errors.New("rule id is missing")
+	}
+	if c.Decision != app.DecisionApprove && c.Decision != app.DecisionDecline && c.Decision != app.DecisionReview {
+		return nil, fmt.Errorf("rule %s decision must be %s, %s or %s", c.ID, app.DecisionApprove, app.DecisionDecline, app.DecisionReview)
+	}
+
+	var m matcher
+	var err error
+	switch c.Type {
+	case Amount:
+		m, err = amountMatcher(c)
+	case Velocity:
+		m, err = e.velocityMatcher(c)
+	case OperationTypeAllowed:
+		m, err = operationTypeAllowedMatcher(c)
+	case TimeWindow:
+		m, err = timeWindowMatcher(c)
+	default:
+		err = fmt.Errorf("type %s is unknown", c.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rule %s %s", c.ID, err.Error())
+	}
+
+	return &rule{
+		id:       c.ID,
+		decision: c.Decision,
+		match:    m,
+	}, nil
+}
+
+func scoreReferences(currency string, configs []*ScoreConfig) ([]*app.ScoreReference, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("score %s has no references", currency)
+	}
+
+	windows := map[int]bool{}
+	references := make([]*app.ScoreReference, 0, len(configs))
+	for _, c := range configs {
+		if c.Minutes <= 0 || c.Count <= 0 || c.Amount < 0 {
+			return nil, fmt.Errorf("score %s minutes and count must be positive and amount not negative", currency)
+		}
+		if windows[c.Minutes] {
+			return nil, fmt.Errorf("score %s window of %d minutes is duplicated", currency, c.Minutes)
+		}
+		windows[c.Minutes] = true
+		references = append(references, &app.ScoreReference{
+			Window: time.Duration(c.Minutes) * time.Minute,
+			Count:  c.Count,
+			Amount: c.Amount,
+		})
+	}
+	return references, nil
+}
+
+func (e *engine) load(file string) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	rf := &RulesFile{}
+	if err := json.Unmarshal(b, rf); err != nil {
+		return err
+	}
+
+	ids := map[string]bool{}
+	for _, c := range rf.Rules {
+		r, err := e.compile(c)
+		if err != nil {
+			return err
+		}
+		if ids[r.id] {
+			return fmt.Errorf("rule %s is duplicated", r.id)
+		}
+		ids[r.id] = true
+		e.rules = append(e.rules, r)
+	}
+
+	for currency, configs := range rf.Score {
+		references, err := scoreReferences(currency, configs)
+		if err != nil {
+			return err
+		}
+		e.scores[currency] = references
+	}
+
+	return nil
+}
+
+// New loads the rules from the config file; without a file every transaction
+// is approved. An invalid file stops the service.
+func New(log Logger, config *Config, usage app.Usage) app.RuleEngine {
+	e := &engine{
+		log:    log,
+		usage:  usage,
+		scores: map[string][]*app.ScoreReference{},
+	}
+	if config.File == "" {
+		log.Info("No rules file, approving every transaction")
+		return e
+	}
+
+	if err := e.load(config.File); err != nil {
+		log.Fatal(fmt.Sprintf("Could not load rules from %s %s", config.File, err.Error()))
+	}
+	log.Info(fmt.Sprintf("Loaded %d rules from %s", len(e.rules), config.File))
+	return e
+}
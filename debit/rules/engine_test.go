@@ -0,0 +1,300 @@
+package rules
+
+import (
+	"context"
+	"debit/app"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type log struct{}
+
+func (l log) Info(msg string)  {}
+func (l log) Error(msg string) {}
+func (l log) Fatal(msg string) {}
+func newLogMock() Logger {
+	return &log{}
+}
+
+type usageMock struct{}
+
+func (u *usageMock) UsageWithContext(ctx context.Context, input *app.UsageInput) (*app.UsageOutput, error) {
+	switch input.AccountKey {
+	case "500":
+		return nil, errors.New("usage error")
+	case "502":
+		return &app.UsageOutput{HasIntermitance: true}, nil
+	case "4":
+		return &app.UsageOutput{Count: 4}, nil
+	case "5":
+		return &app.UsageOutput{Count: 5}, nil
+	}
+	return &app.UsageOutput{}, nil
+}
+
+func (u *usageMock) PeriodUsageWithContext(ctx context.Context, input *app.PeriodUsageInput) (*app.UsageOutput, error) {
+	return nil, errors.New("unexpected period usage")
+}
+
+func newEngine(t *testing.T, rules string) app.RuleEngine {
+	file := filepath.Join(t.TempDir(), "rules.json")
+	assert.Nil(t, ioutil.WriteFile(file, []byte(rules), 0600))
+	c := &Config{}
+	c.WithFile(file)
+	return New(newLogMock(), c, &usageMock{})
+}
+
+func evaluate(t *testing.T, e app.RuleEngine, input *app.EvaluateInput) string {
+	res, err := e.EvaluateWithContext(context.Background(), input)
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func TestEngine_ApproveWithoutRulesFile(t *testing.T) {
+	e := New(newLogMock(), &Config{}, &usageMock{})
+	assert.Equal(t, "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}", evaluate(t, e, &app.EvaluateInput{
+		AccountKey:    "1",
+		OperationType: app.Withdraw,
+		Amount:        1000,
+		Currency:      "BRL",
+		At:            time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+	}))
+}
+
+func TestEngine_FirstMatchDecides(t *testing.T) {
+	e := newEngine(t, `{"rules": [
+		{"id": "high-withdraw", "type": "amount", "decision": "decline", "operation_types": ["Withdraw"], "amount_above": 5000},
+		{"id": "high-amount", "type": "amount", "decision": "review", "amount_above": 1000},
+		{"id": "any-amount", "type": "amount", "decision": "decline", "amount_above": 1}
+	]}`)
+	cases := []struct {
+		operationType string
+		amount        int
+		expected      string
+	}{
+		{app.Withdraw, 6000, "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"high-withdraw\"}"},
+		{app.Buying, 6000, "{\"HasIntermitance\":false,\"Decision\":\"review\",\"RuleID\":\"high-amount\"}"},
+		{app.Withdraw, 2000, "{\"HasIntermitance\":false,\"Decision\":\"review\",\"RuleID\":\"high-amount\"}"},
+		{app.Withdraw, 1000, "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"any-amount\"}"},
+		{app.Withdraw, 1, "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, evaluate(t, e, &app.EvaluateInput{
+			AccountKey:    "1",
+			OperationType: c.operationType,
+			Amount:        c.amount,
+			Currency:      "BRL",
+			At:            time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		}))
+	}
+}
+
+func TestEngine_AmountOnlyMatchesItsCurrency(t *testing.T) {
+	e := newEngine(t, `{"rules": [
+		{"id": "high-usd", "type": "amount", "decision": "decline", "amount_above": 1000, "currency": "USD"},
+		{"id": "high-brl", "type": "amount", "decision": "review", "amount_above": 1000}
+	]}`)
+	assert.Equal(t, "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"high-usd\"}", evaluate(t, e, &app.EvaluateInput{
+		AccountKey:    "1",
+		OperationType: app.Buying,
+		Amount:        2000,
+		Currency:      "USD",
+	}))
+	assert.Equal(t, "{\"HasIntermitance\":false,\"Decision\":\"review\",\"RuleID\":\"high-brl\"}", evaluate(t, e, &app.EvaluateInput{
+		AccountKey:    "1",
+		OperationType: app.Buying,
+		Amount:        2000,
+		Currency:      "BRL",
+	}))
+	assert.Equal(t, "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}", evaluate(t, e, &app.EvaluateInput{
+		AccountKey:    "1",
+		OperationType: app.Buying,
+		Amount:        2000,
+		Currency:      "JPY",
+	}))
+}
+
+func TestEngine_TimeWindowAcrossMidnight(t *testing.T) {
+	e := newEngine(t, `{"rules": [
+		{"id": "night-withdraw", "type": "time-window", "decision": "decline", "operation_types": ["Withdraw"], "from": "23:00", "to": "06:00", "utc_offset": "-03:00"}
+	]}`)
+	cases := []struct {
+		at       time.Time
+		expected string
+	}{
+		// 22:59 at -03:00
+		{time.Date(2022, 1, 31, 1, 59, 0, 0, time.UTC), "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}"},
+		// 23:00 at -03:00
+		{time.Date(2022, 1, 31, 2, 0, 0, 0, time.UTC), "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"night-withdraw\"}"},
+		// 00:30 at -03:00, already the next day
+		{time.Date(2022, 1, 31, 3, 30, 0, 0, time.UTC), "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"night-withdraw\"}"},
+		// 05:59 at -03:00
+		{time.Date(2022, 1, 31, 8, 59, 0, 0, time.UTC), "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"night-withdraw\"}"},
+		// 06:00 at -03:00, the end is outside the window
+		{time.Date(2022, 1, 31, 9, 0, 0, 0, time.UTC), "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, evaluate(t, e, &app.EvaluateInput{
+			AccountKey:    "1",
+			OperationType: app.Withdraw,
+			Amount:        1000,
+			Currency:      "BRL",
+			At:            c.at,
+		}))
+	}
+
+	assert.Equal(t, "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}", evaluate(t, e, &app.EvaluateInput{
+		AccountKey:    "1",
+		OperationType: app.Buying,
+		Amount:        1000,
+		Currency:      "BRL",
+		At:            time.Date(2022, 1, 31, 3, 30, 0, 0, time.UTC),
+	}))
+}
+
+func TestEngine_TimeWindowUTCOffset(t *testing.T) {
+	e := newEngine(t, `{"rules": [
+		{"id": "business-hours", "type": "time-window", "decision": "review", "from": "09:00", "to": "18:00", "utc_offset": "+05:30"},
+		{"id": "business-hours-utc", "type": "time-window", "decision": "decline", "from": "09:00", "to": "18:00"}
+	]}`)
+	cases := []struct {
+		at       time.Time
+		expected string
+	}{
+		// 08:59 at +05:30 and 03:29 UTC
+		{time.Date(2022, 1, 31, 3, 29, 0, 0, time.UTC), "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}"},
+		// 09:00 at +05:30
+		{time.Date(2022, 1, 31, 3, 30, 0, 0, time.UTC), "{\"HasIntermitance\":false,\"Decision\":\"review\",\"RuleID\":\"business-hours\"}"},
+		// 17:59 at +05:30
+		{time.Date(2022, 1, 31, 12, 29, 0, 0, time.UTC), "{\"HasIntermitance\":false,\"Decision\":\"review\",\"RuleID\":\"business-hours\"}"},
+		// 18:00 at +05:30 and 12:30 UTC
+		{time.Date(2022, 1, 31, 12, 30, 0, 0, time.UTC), "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"business-hours-utc\"}"},
+		// the offset of the input does not matter, 10:00 at -03:00 is 13:00 UTC and 18:30 at +05:30
+		{time.Date(2022, 1, 31, 10, 0, 0, 0, time.FixedZone("-03:00", -3*60*60)), "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"business-hours-utc\"}"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, evaluate(t, e, &app.EvaluateInput{
+			AccountKey:    "1",
+			OperationType: app.Withdraw,
+			Amount:        1000,
+			Currency:      "BRL",
+			At:            c.at,
+		}))
+	}
+}
+
+func TestEngine_VelocityCountsTheTransaction(t *testing.T) {
+	e := newEngine(t, `{"rules": [
+		{"id": "withdraw-velocity", "type": "velocity", "decision": "decline", "operation_types": ["Withdraw"], "count": 5, "minutes": 10}
+	]}`)
+	cases := []struct {
+		accountKey    string
+		operationType string
+		expected      string
+	}{
+		{"4", app.Withdraw, "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}"},
+		{"5", app.Withdraw, "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"withdraw-velocity\"}"},
+		{"5", app.Buying, "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}"},
+		{"502", app.Withdraw, "{\"HasIntermitance\":true,\"Decision\":\"\",\"RuleID\":\"\"}"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, evaluate(t, e, &app.EvaluateInput{
+			AccountKey:    c.accountKey,
+			OperationType: c.operationType,
+			Amount:        1000,
+			Currency:      "BRL",
+			At:            time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+		}))
+	}
+}
+
+func TestEngine_NotEvaluateWhenUsageError(t *testing.T) {
+	e := newEngine(t, `{"rules": [
+		{"id": "withdraw-velocity", "type": "velocity", "decision": "decline", "count": 5, "minutes": 10}
+	]}`)
+	res, err := e.EvaluateWithContext(context.Background(), &app.EvaluateInput{
+		AccountKey:    "500",
+		OperationType: app.Withdraw,
+		Amount:        1000,
+		Currency:      "BRL",
+		At:            time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC),
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "usage error", err.Error())
+}
+
+func TestEngine_OperationTypeAllowed(t *testing.T) {
+	e := newEngine(t, `{"rules": [
+		{"id": "buying-only", "type": "operation-type-allowed", "decision": "decline", "accounts": {"1": ["Buying", "InstallmentBuying"]}}
+	]}`)
+	cases := []struct {
+		accountKey    string
+		operationType string
+		expected      string
+	}{
+		{"1", app.Withdraw, "{\"HasIntermitance\":false,\"Decision\":\"decline\",\"RuleID\":\"buying-only\"}"},
+		{"1", app.Buying, "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}"},
+		{"2", app.Withdraw, "{\"HasIntermitance\":false,\"Decision\":\"approve\",\"RuleID\":\"\"}"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, evaluate(t, e, &app.EvaluateInput{
+			AccountKey:    c.accountKey,
+			OperationType: c.operationType,
+			Amount:        1000,
+			Currency:      "BRL",
+		}))
+	}
+}
+
+func TestEngine_NotLoadInvalidRules(t *testing.T) {
+	cases := []struct {
+		rules    string
+		expected string
+	}{
+		{`{"rules": [{"type": "amount", "decision": "decline", "amount_above": 1}]}`, "rule id is missing"},
+		{`{"rules": [{"id": "a", "type": "amount", "decision": "block", "amount_above": 1}]}`, "rule a decision must be approve, decline or review"},
+		{`{"rules": [{"id": "a", "type": "limit", "decision": "decline"}]}`, "rule a type limit is unknown"},
+		{`{"rules": [{"id": "a", "type": "amount", "decision": "decline"}]}`, "rule a amount_above must be positive"},
+		{`{"rules": [{"id": "a", "type": "velocity", "decision": "decline", "count": 5}]}`, "rule a count and minutes must be positive"},
+		{`{"rules": [{"id": "a", "type": "operation-type-allowed", "decision": "decline"}]}`, "rule a accounts is missing"},
+		{`{"rules": [{"id": "a", "type": "time-window", "decision": "decline", "from": "23h", "to": "06:00"}]}`, "rule a 23h must be HH:MM"},
+		{`{"rules": [{"id": "a", "type": "time-window", "decision": "decline", "from": "23:00", "to": "24:00"}]}`, "rule a 24:00 must be HH:MM"},
+		{`{"rules": [{"id": "a", "type": "time-window", "decision": "decline", "from": "23:00", "to": "06:00", "utc_offset": "-3"}]}`, "rule a utc_offset must be ±HH:MM"},
+		{`{"rules": [{"id": "a", "type": "amount", "decision": "decline", "amount_above": 1}, {"id": "a", "type": "amount", "decision": "review", "amount_above": 2}]}`, "rule a is duplicated"},
+		{`{"rules": [], "score": {"USD": []}}`, "score USD has no references"},
+		{`{"rules": [], "score": {"USD": [{"minutes": 1, "count": 0, "amount": 100}]}}`, "score USD minutes and count must be positive and amount not negative"},
+		{`{"rules": [], "score": {"USD": [{"minutes": 1, "count": 5}, {"minutes": 1, "count": 10}]}}`, "score USD window of 1 minutes is duplicated"},
+		{`{"rules": {}}`, "json: cannot unmarshal object into Go struct field RulesFile.rules of type []*rules.RuleConfig"},
+	}
+	for _, c := range cases {
+		file := filepath.Join(t.TempDir(), "rules.json")
+		assert.Nil(t, ioutil.WriteFile(file, []byte(c.rules), 0600))
+		e := &engine{
+			log:    newLogMock(),
+			usage:  &usageMock{},
+			scores: map[string][]*app.ScoreReference{},
+		}
+		err := e.load(file)
+		if assert.NotNil(t, err, c.rules) {
+			assert.Equal(t, c.expected, err.Error())
+		}
+	}
+}
+
+func TestEngine_ScoreReferences(t *testing.T) {
+	e := newEngine(t, `{"rules": [], "score": {"JPY": [
+		{"minutes": 1, "count": 5, "amount": 300000},
+		{"minutes": 60, "count": 30}
+	]}}`)
+	b, err := json.Marshal(e.ScoreReferences("JPY"))
+	assert.Nil(t, err)
+	assert.Equal(t, "[{\"Window\":60000000000,\"Count\":5,\"Amount\":300000},{\"Window\":3600000000000,\"Count\":30,\"Amount\":0}]", string(b))
+	assert.Nil(t, e.ScoreReferences("BRL"))
+}
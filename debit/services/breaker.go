@@ -0,0 +1,104 @@
+package services
+
+import (
+	"debit/app"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// breaker stops calling a downstream after FailureThreshold failures in a row.
+// Once OpenTimeout has passed a single probe goes through; its outcome closes the
+// breaker again or keeps it open for another OpenTimeout.
+type breaker struct {
+	name     string
+	config   *HttpConfig
+	log      Logger
+	clock    func() time.Time
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case app.CircuitOpen:
+		if b.clock().Sub(b.openedAt) < b.config.OpenTimeout {
+			return false
+		}
+		b.state = app.CircuitHalfOpen
+		b.probing = true
+		b.log.Info(fmt.Sprintf("circuit breaker %s is half-open", b.name))
+		return true
+	case app.CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != app.CircuitClosed {
+		b.log.Info(fmt.Sprintf("circuit breaker %s is closed", b.name))
+	}
+	b.state = app.CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == app.CircuitHalfOpen || (b.state == app.CircuitClosed && b.failures >= b.config.FailureThreshold) {
+		b.log.Error(fmt.Sprintf("circuit breaker %s is open after %d failures", b.name, b.failures))
+		b.state = app.CircuitOpen
+		b.openedAt = b.clock()
+	}
+}
+
+// release gives the probe back when the caller gave up before it had an outcome.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) output() *app.CircuitBreakerOutput {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	o := &app.CircuitBreakerOutput{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if b.state != app.CircuitClosed {
+		o.OpenedAt = b.openedAt
+	}
+	return o
+}
+
+func newBreaker(name string, config *HttpConfig, log Logger) *breaker {
+	return &breaker{
+		name:   name,
+		config: config,
+		log:    log,
+		clock:  time.Now,
+		state:  app.CircuitClosed,
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"debit/app"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type log struct{}
+
+func (l log) Info(msg string)  {}
+func (l log) Error(msg string) {}
+func newLogMock() Logger {
+	return &log{}
+}
+
+var testNow = time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)
+
+func newTestConfig() *HttpConfig {
+	return &HttpConfig{
+		Timeout:          time.Second,
+		MaxAttempts:      3,
+		RetryBase:        time.Millisecond,
+		FailureThreshold: 3,
+		OpenTimeout:      10 * time.Second,
+	}
+}
+
+func newBreakerWithClock(now *time.Time) *breaker {
+	b := newBreaker("balance", newTestConfig(), newLogMock())
+	b.clock = func() time.Time {
+		return *now
+	}
+	return b
+}
+
+func breakerOutput(t *testing.T, b *breaker) string {
+	v, err := json.Marshal(b.output())
+	assert.Nil(t, err)
+	return string(v)
+}
+
+func TestBreaker_OpenAfterConsecutiveFailures(t *testing.T) {
+	now := testNow
+	b := newBreakerWithClock(&now)
+
+	b.failure()
+	b.failure()
+	b.success()
+	b.failure()
+	b.failure()
+	assert.True(t, b.allow())
+	assert.Equal(t, "{\"Name\":\"balance\",\"State\":\"closed\",\"ConsecutiveFailures\":2,\"OpenedAt\":\"0001-01-01T00:00:00Z\"}", breakerOutput(t, b))
+
+	b.failure()
+	assert.False(t, b.allow())
+	assert.Equal(t, "{\"Name\":\"balance\",\"State\":\"open\",\"ConsecutiveFailures\":3,\"OpenedAt\":\"2022-01-31T10:00:00Z\"}", breakerOutput(t, b))
+}
+
+func TestBreaker_AllowOneProbeAfterOpenTimeout(t *testing.T) {
+	now := testNow
+	b := newBreakerWithClock(&now)
+	for i := 0; i < 3; i++ {
+		b.failure()
+	}
+
+	now = testNow.Add(10*time.Second - time.Millisecond)
+	assert.False(t, b.allow())
+
+	now = testNow.Add(10 * time.Second)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+	assert.Equal(t, "{\"Name\":\"balance\",\"State\":\"half-open\",\"ConsecutiveFailures\":3,\"OpenedAt\":\"2022-01-31T10:00:00Z\"}", breakerOutput(t, b))
+}
+
+func TestBreaker_CloseWhenProbeSucceeds(t *testing.T) {
+	now := testNow
+	b := newBreakerWithClock(&now)
+	for i := 0; i < 3; i++ {
+		b.failure()
+	}
+	now = testNow.Add(10 * time.Second)
+	assert.True(t, b.allow())
+
+	b.success()
+	assert.True(t, b.allow())
+	assert.True(t, b.allow())
+	assert.Equal(t, "{\"Name\":\"balance\",\"State\":\"closed\",\"ConsecutiveFailures\":0,\"OpenedAt\":\"0001-01-01T00:00:00Z\"}", breakerOutput(t, b))
+}
+
+func TestBreaker_ReopenWhenProbeFails(t *testing.T) {
+	now := testNow
+	b := newBreakerWithClock(&now)
+	for i := 0; i < 3; i++ {
+		b.failure()
+	}
+	now = testNow.Add(10 * time.Second)
+	assert.True(t, b.allow())
+
+	b.failure()
+	assert.False(t, b.allow())
+	assert.Equal(t, "{\"Name\":\"balance\",\"State\":\"open\",\"ConsecutiveFailures\":4,\"OpenedAt\":\"2022-01-31T10:00:10Z\"}", breakerOutput(t, b))
+
+	now = testNow.Add(19 * time.Second)
+	assert.False(t, b.allow())
+	now = testNow.Add(20 * time.Second)
+	assert.True(t, b.allow())
+}
+
+func TestBreaker_ReleaseProbeWithoutOutcome(t *testing.T) {
+	now := testNow
+	b := newBreakerWithClock(&now)
+	for i := 0; i < 3; i++ {
+		b.failure()
+	}
+	now = testNow.Add(10 * time.Second)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+
+	b.release()
+	assert.True(t, b.allow())
+	assert.Equal(t, "{\"Name\":\"balance\",\"State\":\"half-open\",\"ConsecutiveFailures\":3,\"OpenedAt\":\"2022-01-31T10:00:00Z\"}", breakerOutput(t, b))
+}
+
+func TestBreaker_NotCallWhenOpen(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	now := testNow
+	h := &httpService{
+		client:    newHttpClient(),
+		config:    newTestConfig(),
+		breaker:   newBreakerWithClock(&now),
+		retryPost: true,
+	}
+
+	_, statusCode, err := h.PostWithContext(context.Background(), s.URL, []byte("{}"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Equal(t, 3, calls)
+
+	_, _, err = h.PostWithContext(context.Background(), s.URL, []byte("{}"))
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, "balance: circuit breaker is open", err.Error())
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, app.CircuitOpen, h.breaker.output().State)
+}
+
+func TestBreaker_NotCountClientErrors(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer s.Close()
+
+	now := testNow
+	h := &httpService{
+		client:  newHttpClient(),
+		config:  newTestConfig(),
+		breaker: newBreakerWithClock(&now),
+	}
+	for i := 0; i < 5; i++ {
+		_, statusCode, err := h.GetWithContext(context.Background(), s.URL)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, statusCode)
+	}
+	assert.Equal(t, "{\"Name\":\"balance\",\"State\":\"closed\",\"ConsecutiveFailures\":0,\"OpenedAt\":\"0001-01-01T00:00:00Z\"}", breakerOutput(t, h.breaker))
+}
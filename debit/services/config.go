@@ -0,0 +1,71 @@
+package services
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultTimeout          = time.Second
+	DefaultMaxAttempts      = 3
+	DefaultRetryBase        = 50 * time.Millisecond
+	MaxRetryInterval        = 500 * time.Millisecond
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 10 * time.Second
+	maxIdleConnsPerHost     = 32
+)
+
+// HttpConfig applies to every call to another service. Timeout bounds a single
+// attempt; the whole call is still bounded by the deadline of the request that made it.
+type HttpConfig struct {
+	Timeout          time.Duration
+	MaxAttempts      int
+	RetryBase        time.Duration
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+func (c *HttpConfig) WithTimeout(timeout string) *HttpConfig {
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		d = DefaultTimeout
+	}
+	c.Timeout = d
+	return c
+}
+
+func (c *HttpConfig) WithMaxAttempts(maxAttempts string) *HttpConfig {
+	n, err := strconv.Atoi(maxAttempts)
+	if err != nil || n <= 0 {
+		n = DefaultMaxAttempts
+	}
+	c.MaxAttempts = n
+	return c
+}
+
+func (c *HttpConfig) WithRetryBase(retryBase string) *HttpConfig {
+	d, err := time.ParseDuration(retryBase)
+	if err != nil || d <= 0 {
+		d = DefaultRetryBase
+	}
+	c.RetryBase = d
+	return c
+}
+
+func (c *HttpConfig) WithFailureThreshold(failureThreshold string) *HttpConfig {
+	n, err := strconv.Atoi(failureThreshold)
+	if err != nil || n <= 0 {
+		n = DefaultFailureThreshold
+	}
+	c.FailureThreshold = n
+	return c
+}
+
+func (c *HttpConfig) WithOpenTimeout(openTimeout string) *HttpConfig {
+	d, err := time.ParseDuration(openTimeout)
+	if err != nil || d <= 0 {
+		d = DefaultOpenTimeout
+	}
+	c.OpenTimeout = d
+	return c
+}
@@ -0,0 +1,6 @@
+package services
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
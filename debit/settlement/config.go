@@ -1,10 +1,22 @@
 package settlement
 
 type Config struct {
-	Url string
+	Url             string
+	TransactionsUrl string
+	HoldsUrl        string
 }
 
 func (c *Config) WithUrl(url string) *Config {
 	c.Url = url
 	return c
 }
+
+func (c *Config) WithTransactionsUrl(url string) *Config {
+	c.TransactionsUrl = url
+	return c
+}
+
+func (c *Config) WithHoldsUrl(url string) *Config {
+	c.HoldsUrl = url
+	return c
+}
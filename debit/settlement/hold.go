@@ -0,0 +1,207 @@
+package settlement
+
+import (
+	"context"
+	"debit/app"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type HoldRequestPayload struct {
+	AccountKey    string `json:"account_key,omitempty"`
+	ExternalKey   string `json:"external_key,omitempty"`
+	OperationType string `json:"operation_type,omitempty"`
+	Amount        int    `json:"amount,omitempty"`
+	Currency      string `json:"currency,omitempty"`
+
+	Origin      string            `json:"origin,omitempty"`
+	RequestID   string            `json:"request_id,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	RiskScore   int               `json:"risk_score,omitempty"`
+}
+
+type HoldResponse struct {
+	OperationType  string    `json:"operation_type,omitempty"`
+	Status         string    `json:"status,omitempty"`
+	Amount         int       `json:"amount,omitempty"`
+	Currency       string    `json:"currency,omitempty"`
+	CapturedAmount int       `json:"captured_amount,omitempty"`
+	CreatedAt      time.Time `json:"created_at,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at,omitempty"`
+	RequestID      string    `json:"request_id,omitempty"`
+	RiskScore      int       `json:"risk_score,omitempty"`
+}
+
+type BalanceGetResponse struct {
+	HeldAmount int `json:"held_amount"`
+}
+
+type holds struct {
+	log         Logger
+	config      *Config
+	httpService Http
+}
+
+func (h *holds) holdUrl(accountKey string, externalKey string, action string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", h.config.HoldsUrl, url.PathEscape(accountKey), url.PathEscape(externalKey), action)
+}
+
+func (h *holds) postWithContext(ctx context.Context, u string, payload interface{}) (*app.HoldOutput, error) {
+	pb, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	res, statusCode, err := h.httpService.PostWithContext(ctx, u, pb)
+	if err != nil {
+		h.log.Error(fmt.Sprintf("http post error %s", err.Error()))
+		return nil, err
+	}
+
+	return h.holdOutput(res, statusCode)
+}
+
+func (h *holds) holdOutput(res []byte, statusCode int) (*app.HoldOutput, error) {
+	if statusCode == http.StatusOK || statusCode == http.StatusCreated {
+		hr := &HoldResponse{}
+		if err := json.Unmarshal(res, hr); err != nil {
+			h.log.Error(fmt.Sprintf("hold response error %s", err.Error()))
+			return nil, err
+		}
+		return &app.HoldOutput{
+			HasIntermitance: false,
+			Error:           false,
+			OperationType:   hr.OperationType,
+			Status:          hr.Status,
+			Amount:          hr.Amount,
+			Currency:        hr.Currency,
+			CapturedAmount:  hr.CapturedAmount,
+			CreatedAt:       hr.CreatedAt,
+			ExpiresAt:       hr.ExpiresAt,
+			RequestID:       hr.RequestID,
+			RiskScore:       hr.RiskScore,
+		}, nil
+	}
+
+	if statusCode == http.StatusBadRequest || statusCode == http.StatusNotFound || statusCode == http.StatusConflict || statusCode == http.StatusUnprocessableEntity {
+		be := &BalanceResponseError{}
+		err := json.Unmarshal(res, be)
+		if err != nil || be.Error == nil {
+			h.log.Error(fmt.Sprintf("hold response invalid error body with status %d", statusCode))
+			return &app.HoldOutput{
+				HasIntermitance: true,
+			}, nil
+		}
+		code := be.Error.Code
+		if code == "" && statusCode == http.StatusBadRequest {
+			code = app.HoldInvalid
+		}
+		return &app.HoldOutput{
+			HasIntermitance: false,
+			Error:           true,
+			Code:            code,
+			Detail:          be.Error.Message,
+		}, nil
+	}
+
+	return &app.HoldOutput{
+		HasIntermitance: true,
+	}, nil
+}
+
+func (h *holds) HoldWithContext(ctx context.Context, input *app.HoldInput) (*app.HoldOutput, error) {
+	return h.postWithContext(ctx, h.config.HoldsUrl, &HoldRequestPayload{
+		AccountKey:    input.AccountKey,
+		ExternalKey:   input.ExternalKey,
+		OperationType: input.OperationType,
+		Amount:        input.Amount,
+		Currency:      input.Currency,
+
+		Origin:      input.Origin,
+		RequestID:   input.RequestID,
+		Description: input.Description,
+		Metadata:    input.Metadata,
+		RiskScore:   input.RiskScore,
+	})
+}
+
+// GetHoldWithContext reads the hold placed under the external key; a missing hold
+// comes back as hold-not-found.
+func (h *holds) GetHoldWithContext(ctx context.Context, input *app.GetHoldInput) (*app.HoldOutput, error) {
+	res, statusCode, err := h.httpService.GetWithContext(ctx, fmt.Sprintf("%s/%s/%s", h.config.HoldsUrl, url.PathEscape(input.AccountKey), url.PathEscape(input.ExternalKey)))
+	if err != nil {
+		h.log.Error(fmt.Sprintf("http get error %s", err.Error()))
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK && statusCode != http.StatusNotFound {
+		h.log.Error(fmt.Sprintf("get hold status code %d", statusCode))
+		return &app.HoldOutput{
+			HasIntermitance: true,
+		}, nil
+	}
+
+	return h.holdOutput(res, statusCode)
+}
+
+func (h *holds) CaptureHoldWithContext(ctx context.Context, input *app.CaptureInput) (*app.HoldOutput, error) {
+	return h.postWithContext(ctx, h.holdUrl(input.AccountKey, input.ExternalKey, "capture"), &HoldRequestPayload{
+		Amount:      input.Amount,
+		RequestID:   input.RequestID,
+		Description: input.Description,
+		Metadata:    input.Metadata,
+	})
+}
+
+func (h *holds) VoidHoldWithContext(ctx context.Context, input *app.VoidInput) (*app.HoldOutput, error) {
+	return h.postWithContext(ctx, h.holdUrl(input.AccountKey, input.ExternalKey, "void"), &HoldRequestPayload{})
+}
+
+// HeldWithContext reads the active authorizations from the balance summary; an
+// account without a summary in the currency has nothing held.
+func (h *holds) HeldWithContext(ctx context.Context, input *app.HeldInput) (*app.HeldOutput, error) {
+	query := url.Values{
+		"currency": {input.Currency},
+	}
+	res, statusCode, err := h.httpService.GetWithContext(ctx, fmt.Sprintf("%s/%s?%s", h.config.Url, url.PathEscape(input.AccountKey), query.Encode()))
+	if err != nil {
+		h.log.Error(fmt.Sprintf("http get error %s", err.Error()))
+		return nil, err
+	}
+
+	if statusCode == http.StatusNotFound {
+		return &app.HeldOutput{
+			HasIntermitance: false,
+			Amount:          0,
+		}, nil
+	}
+
+	if statusCode != http.StatusOK {
+		h.log.Error(fmt.Sprintf("held status code %d", statusCode))
+		return &app.HeldOutput{
+			HasIntermitance: true,
+		}, nil
+	}
+
+	br := &BalanceGetResponse{}
+	if err := json.Unmarshal(res, br); err != nil {
+		h.log.Error(fmt.Sprintf("held response error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.HeldOutput{
+		HasIntermitance: false,
+		Amount:          br.HeldAmount,
+	}, nil
+}
+
+func NewHolds(log Logger, config *Config, httpService Http) app.Holds {
+	return &holds{
+		log:         log,
+		config:      config,
+		httpService: httpService,
+	}
+}
@@ -0,0 +1,149 @@
+package settlement
+
+import (
+	"context"
+	"debit/app"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const usagePageLimit = "100"
+
+type TransactionResponse struct {
+	OperationType string `json:"operation_type,omitempty"`
+	Amount        int    `json:"amount,omitempty"`
+	Currency      string `json:"currency,omitempty"`
+}
+
+type TransactionListResponse struct {
+	Transactions []*TransactionResponse `json:"transactions,omitempty"`
+	NextCursor   string                 `json:"next_cursor,omitempty"`
+}
+
+type UsageResponse struct {
+	Amount int `json:"amount"`
+	Count  int `json:"count"`
+}
+
+type usage struct {
+	log         Logger
+	config      *Config
+	httpService Http
+}
+
+// UsageWithContext sums and counts the account statement in balance, page by
+// page, for each operation type since the given time. The statement lists every
+// currency, so entries in other currencies are skipped here. It is meant for
+// windows of minutes; limits over a day or a month use PeriodUsageWithContext.
+func (u *usage) UsageWithContext(ctx context.Context, input *app.UsageInput) (*app.UsageOutput, error) {
+	amount, count := 0, 0
+	for _, operationType := range input.OperationTypes {
+		cursor := ""
+		for {
+			query := url.Values{
+				"operation_type": {operationType},
+				"from":           {input.From.UTC().Format(time.RFC3339)},
+				"limit":          {usagePageLimit},
+			}
+			if cursor != "" {
+				query.Set("cursor", cursor)
+			}
+
+			res, statusCode, err := u.httpService.GetWithContext(ctx, fmt.Sprintf("%s%s/transactions?%s", u.config.TransactionsUrl, url.PathEscape(input.AccountKey), query.Encode()))
+			if err != nil {
+				u.log.Error(fmt.Sprintf("http get error %s", err.Error()))
+				return nil, err
+			}
+
+			if statusCode != http.StatusOK {
+				u.log.Error(fmt.Sprintf("usage status code %d", statusCode))
+				return &app.UsageOutput{
+					HasIntermitance: true,
+				}, nil
+			}
+
+			tl := &TransactionListResponse{}
+			if err := json.Unmarshal(res, tl); err != nil {
+				u.log.Error(fmt.Sprintf("usage response error %s", err.Error()))
+				return nil, err
+			}
+
+			for _, t := range tl.Transactions {
+				if input.Currency != "" && currencyOrDefault(t.Currency) != input.Currency {
+					continue
+				}
+				count++
+				if t.Amount < 0 {
+					amount -= t.Amount
+				} else {
+					amount += t.Amount
+				}
+			}
+
+			if tl.NextCursor == "" {
+				break
+			}
+			cursor = tl.NextCursor
+		}
+	}
+
+	return &app.UsageOutput{
+		HasIntermitance: false,
+		Amount:          amount,
+		Count:           count,
+	}, nil
+}
+
+// PeriodUsageWithContext reads the running totals balance keeps for the current day
+// or month, so it costs one request whatever the account spent.
+func (u *usage) PeriodUsageWithContext(ctx context.Context, input *app.PeriodUsageInput) (*app.UsageOutput, error) {
+	query := url.Values{
+		"operation_type": input.OperationTypes,
+		"currency":       {input.Currency},
+		"period":         {input.Period},
+	}
+
+	res, statusCode, err := u.httpService.GetWithContext(ctx, fmt.Sprintf("%s%s/usage?%s", u.config.TransactionsUrl, url.PathEscape(input.AccountKey), query.Encode()))
+	if err != nil {
+		u.log.Error(fmt.Sprintf("http get error %s", err.Error()))
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		u.log.Error(fmt.Sprintf("period usage status code %d", statusCode))
+		return &app.UsageOutput{
+			HasIntermitance: true,
+		}, nil
+	}
+
+	ur := &UsageResponse{}
+	if err := json.Unmarshal(res, ur); err != nil {
+		u.log.Error(fmt.Sprintf("period usage response error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.UsageOutput{
+		HasIntermitance: false,
+		Amount:          ur.Amount,
+		Count:           ur.Count,
+	}, nil
+}
+
+// currencyOrDefault reads entries written before currencies existed as BRL.
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return app.DefaultCurrency
+	}
+	return currency
+}
+
+func NewUsage(log Logger, config *Config, httpService Http) app.Usage {
+	return &usage{
+		log:         log,
+		config:      config,
+		httpService: httpService,
+	}
+}
@@ -7,8 +7,11 @@ aws --endpoint-url=http://localhost:4566 dynamodb create-table \
     --table-name account \
     --attribute-definitions \
         AttributeName=ExternalKey,AttributeType=S \
+        AttributeName=DocumentNumber,AttributeType=S \
     --key-schema \
         AttributeName=ExternalKey,KeyType=HASH \
+    --global-secondary-indexes \
+        'IndexName=DocumentNumber-ExternalKey-index,KeySchema=[{AttributeName=DocumentNumber,KeyType=HASH},{AttributeName=ExternalKey,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
     --billing-mode \
         PAY_PER_REQUEST \
 
@@ -7,10 +7,15 @@ aws --endpoint-url=http://localhost:4566 dynamodb create-table \
     --table-name balance \
     --attribute-definitions \
         AttributeName=AccountKey,AttributeType=S \
+        AttributeName=EntryKey,AttributeType=S \
         AttributeName=ExternalKey,AttributeType=S \
+        AttributeName=CreatedAt,AttributeType=S \
     --key-schema \
         AttributeName=AccountKey,KeyType=HASH \
-        AttributeName=ExternalKey,KeyType=RANGE \
+        AttributeName=EntryKey,KeyType=RANGE \
+    --local-secondary-indexes \
+        'IndexName=AccountKey-CreatedAt-index,KeySchema=[{AttributeName=AccountKey,KeyType=HASH},{AttributeName=CreatedAt,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
+        'IndexName=AccountKey-ExternalKey-index,KeySchema=[{AttributeName=AccountKey,KeyType=HASH},{AttributeName=ExternalKey,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
     --billing-mode \
         PAY_PER_REQUEST \
 
@@ -0,0 +1,20 @@
+#!bin/bash
+
+export AWS_ACCESS_KEY_ID=foo
+export AWS_SECRET_ACCESS_KEY=bar
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name balance-summary \
+    --attribute-definitions \
+        AttributeName=AccountKey,AttributeType=S \
+        AttributeName=Currency,AttributeType=S \
+    --key-schema \
+        AttributeName=AccountKey,KeyType=HASH \
+        AttributeName=Currency,KeyType=RANGE \
+    --billing-mode \
+        PAY_PER_REQUEST \
+
+
+aws --endpoint-url=http://localhost:4566 dynamodb put-item \
+    --table-name balance-summary \
+    --item '{"AccountKey": {"S": "#summaries"}, "Currency": {"S": "#rebuilt"}}'
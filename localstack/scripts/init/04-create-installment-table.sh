@@ -0,0 +1,21 @@
+#!bin/bash
+
+export AWS_ACCESS_KEY_ID=foo
+export AWS_SECRET_ACCESS_KEY=bar
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name installment \
+    --attribute-definitions \
+        AttributeName=AccountKey,AttributeType=S \
+        AttributeName=InstallmentKey,AttributeType=S \
+        AttributeName=Status,AttributeType=S \
+        AttributeName=DueDate,AttributeType=S \
+    --key-schema \
+        AttributeName=AccountKey,KeyType=HASH \
+        AttributeName=InstallmentKey,KeyType=RANGE \
+    --global-secondary-indexes \
+        'IndexName=Status-DueDate-index,KeySchema=[{AttributeName=Status,KeyType=HASH},{AttributeName=DueDate,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
+    --billing-mode \
+        PAY_PER_REQUEST \
+
+
@@ -0,0 +1,15 @@
+#!bin/bash
+
+export AWS_ACCESS_KEY_ID=foo
+export AWS_SECRET_ACCESS_KEY=bar
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name account-status \
+    --attribute-definitions \
+        AttributeName=ExternalKey,AttributeType=S \
+        AttributeName=UpdatedAt,AttributeType=S \
+    --key-schema \
+        AttributeName=ExternalKey,KeyType=HASH \
+        AttributeName=UpdatedAt,KeyType=RANGE \
+    --billing-mode \
+        PAY_PER_REQUEST \
@@ -0,0 +1,19 @@
+#!bin/bash
+
+export AWS_ACCESS_KEY_ID=foo
+export AWS_SECRET_ACCESS_KEY=bar
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name debit-velocity \
+    --attribute-definitions \
+        AttributeName=CounterKey,AttributeType=S \
+    --key-schema \
+        AttributeName=CounterKey,KeyType=HASH \
+    --billing-mode \
+        PAY_PER_REQUEST \
+
+aws --endpoint-url=http://localhost:4566 dynamodb update-time-to-live \
+    --table-name debit-velocity \
+    --time-to-live-specification \
+        Enabled=true,AttributeName=ExpiresAt \
+
@@ -0,0 +1,21 @@
+#!bin/bash
+
+export AWS_ACCESS_KEY_ID=foo
+export AWS_SECRET_ACCESS_KEY=bar
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name balance-hold \
+    --attribute-definitions \
+        AttributeName=AccountKey,AttributeType=S \
+        AttributeName=ExternalKey,AttributeType=S \
+        AttributeName=Status,AttributeType=S \
+        AttributeName=ExpiresAt,AttributeType=S \
+    --key-schema \
+        AttributeName=AccountKey,KeyType=HASH \
+        AttributeName=ExternalKey,KeyType=RANGE \
+    --global-secondary-indexes \
+        'IndexName=Status-ExpiresAt-index,KeySchema=[{AttributeName=Status,KeyType=HASH},{AttributeName=ExpiresAt,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
+    --billing-mode \
+        PAY_PER_REQUEST \
+
+
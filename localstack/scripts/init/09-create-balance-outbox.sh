@@ -0,0 +1,34 @@
+#!bin/bash
+
+export AWS_ACCESS_KEY_ID=foo
+export AWS_SECRET_ACCESS_KEY=bar
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name balance-outbox \
+    --attribute-definitions \
+        AttributeName=AccountKey,AttributeType=S \
+        AttributeName=Sequence,AttributeType=S \
+        AttributeName=Pending,AttributeType=S \
+        AttributeName=CreatedAt,AttributeType=S \
+    --key-schema \
+        AttributeName=AccountKey,KeyType=HASH \
+        AttributeName=Sequence,KeyType=RANGE \
+    --global-secondary-indexes \
+        'IndexName=Pending-CreatedAt-index,KeySchema=[{AttributeName=Pending,KeyType=HASH},{AttributeName=CreatedAt,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
+    --billing-mode \
+        PAY_PER_REQUEST \
+
+aws --endpoint-url=http://localhost:4566 sns create-topic \
+    --name balance-events.fifo \
+    --attributes FifoTopic=true,ContentBasedDeduplication=false \
+
+aws --endpoint-url=http://localhost:4566 sqs create-queue \
+    --queue-name balance-events.fifo \
+    --attributes FifoQueue=true \
+
+aws --endpoint-url=http://localhost:4566 sns subscribe \
+    --topic-arn arn:aws:sns:us-east-1:000000000000:balance-events.fifo \
+    --protocol sqs \
+    --notification-endpoint arn:aws:sqs:us-east-1:000000000000:balance-events.fifo \
+    --attributes RawMessageDelivery=true \
+
@@ -0,0 +1,32 @@
+#!bin/bash
+
+export AWS_ACCESS_KEY_ID=foo
+export AWS_SECRET_ACCESS_KEY=bar
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name webhook-subscription \
+    --attribute-definitions \
+        AttributeName=SubscriptionId,AttributeType=S \
+        AttributeName=AccountKey,AttributeType=S \
+    --key-schema \
+        AttributeName=SubscriptionId,KeyType=HASH \
+    --global-secondary-indexes \
+        'IndexName=AccountKey-index,KeySchema=[{AttributeName=AccountKey,KeyType=HASH}],Projection={ProjectionType=ALL}' \
+    --billing-mode \
+        PAY_PER_REQUEST \
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name webhook-delivery \
+    --attribute-definitions \
+        AttributeName=DeliveryId,AttributeType=S \
+        AttributeName=Pending,AttributeType=S \
+        AttributeName=NextAttemptAt,AttributeType=S \
+        AttributeName=SubscriptionId,AttributeType=S \
+        AttributeName=Status,AttributeType=S \
+    --key-schema \
+        AttributeName=DeliveryId,KeyType=HASH \
+    --global-secondary-indexes \
+        'IndexName=Pending-NextAttemptAt-index,KeySchema=[{AttributeName=Pending,KeyType=HASH},{AttributeName=NextAttemptAt,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
+        'IndexName=SubscriptionId-Status-index,KeySchema=[{AttributeName=SubscriptionId,KeyType=HASH},{AttributeName=Status,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
+    --billing-mode \
+        PAY_PER_REQUEST \
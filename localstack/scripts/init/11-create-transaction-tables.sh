@@ -0,0 +1,34 @@
+#!bin/bash
+
+export AWS_ACCESS_KEY_ID=foo
+export AWS_SECRET_ACCESS_KEY=bar
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name credit-transaction \
+    --attribute-definitions \
+        AttributeName=AccountKey,AttributeType=S \
+        AttributeName=ExternalKey,AttributeType=S \
+        AttributeName=Pending,AttributeType=S \
+        AttributeName=AttemptedAt,AttributeType=S \
+    --key-schema \
+        AttributeName=AccountKey,KeyType=HASH \
+        AttributeName=ExternalKey,KeyType=RANGE \
+    --global-secondary-indexes \
+        'IndexName=Pending-AttemptedAt-index,KeySchema=[{AttributeName=Pending,KeyType=HASH},{AttributeName=AttemptedAt,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
+    --billing-mode \
+        PAY_PER_REQUEST \
+
+aws --endpoint-url=http://localhost:4566 dynamodb create-table \
+    --table-name debit-transaction \
+    --attribute-definitions \
+        AttributeName=AccountKey,AttributeType=S \
+        AttributeName=TransactionKey,AttributeType=S \
+        AttributeName=Pending,AttributeType=S \
+        AttributeName=AttemptedAt,AttributeType=S \
+    --key-schema \
+        AttributeName=AccountKey,KeyType=HASH \
+        AttributeName=TransactionKey,KeyType=RANGE \
+    --global-secondary-indexes \
+        'IndexName=Pending-AttemptedAt-index,KeySchema=[{AttributeName=Pending,KeyType=HASH},{AttributeName=AttemptedAt,KeyType=RANGE}],Projection={ProjectionType=ALL}' \
+    --billing-mode \
+        PAY_PER_REQUEST \
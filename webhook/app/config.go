@@ -0,0 +1,35 @@
+package app
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 8
+	DefaultRetryBase   = 30 * time.Second
+	MaxRetryInterval   = time.Hour
+)
+
+type Config struct {
+	MaxAttempts int
+	RetryBase   time.Duration
+}
+
+func (c *Config) WithMaxAttempts(maxAttempts string) *Config {
+	n, err := strconv.Atoi(maxAttempts)
+	if err != nil || n <= 0 {
+		n = DefaultMaxAttempts
+	}
+	c.MaxAttempts = n
+	return c
+}
+
+func (c *Config) WithRetryBase(retryBase string) *Config {
+	d, err := time.ParseDuration(retryBase)
+	if err != nil || d <= 0 {
+		d = DefaultRetryBase
+	}
+	c.RetryBase = d
+	return c
+}
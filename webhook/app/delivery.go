@@ -0,0 +1,311 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const DefaultDispatchBatchSize = 100
+
+// EventPayload is the body every subscriber receives.
+type EventPayload struct {
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	AccountKey string          `json:"account_key"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// deliveryID is the same every time an event is reported for a subscription.
+func deliveryID(subscriptionID string, eventID string) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s", subscriptionID, eventID)))
+	return hex.EncodeToString(h[:])
+}
+
+// retryInterval doubles from the retry base after each failed attempt.
+func (a *webhook) retryInterval(attempts int) time.Duration {
+	d := a.config.RetryBase
+	for i := 1; i < attempts && d < MaxRetryInterval; i++ {
+		d *= 2
+	}
+	if d > MaxRetryInterval {
+		d = MaxRetryInterval
+	}
+	return d
+}
+
+func deliveryOutput(d *Delivery) *DeliveryOutput {
+	return &DeliveryOutput{
+		Error:          false,
+		DeliveryID:     d.DeliveryID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		AccountKey:     d.AccountKey,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
+
+func (a *webhook) listSubscriptionsWithContext(ctx context.Context, accountKey string) ([]*Subscription, error) {
+	accountKeys := []string{""}
+	if accountKey != "" {
+		accountKeys = append(accountKeys, accountKey)
+	}
+
+	subscriptions := make([]*Subscription, 0)
+	for _, k := range accountKeys {
+		o, err := a.repository.ListSubscriptionsWithContext(ctx, &ListSubscriptionsInput{
+			AccountKey: k,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository list subscriptions error %s", err.Error()))
+			return nil, err
+		}
+		subscriptions = append(subscriptions, o.Subscriptions...)
+	}
+	return subscriptions, nil
+}
+
+// EmitWithContext only stores a delivery for each matching subscription; the
+// dispatcher sends them, so a slow subscriber never holds up the producer.
+func (a *webhook) EmitWithContext(ctx context.Context, input *EmitInput) (*EmitOutput, error) {
+	if !knownEventType(input.EventType) {
+		return &EmitOutput{
+			Error:  true,
+			Code:   EventTypeInvalid,
+			Detail: fmt.Sprintf("event type %s is not supported", input.EventType),
+		}, nil
+	}
+
+	payload, err := json.Marshal(&EventPayload{
+		EventID:    input.EventID,
+		EventType:  input.EventType,
+		AccountKey: input.AccountKey,
+		OccurredAt: input.OccurredAt,
+		Data:       input.Data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := a.listSubscriptionsWithContext(ctx, input.AccountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.clock()
+	emitOutput := &EmitOutput{}
+	for _, s := range subscriptions {
+		if !subscribes(s, input.EventType) {
+			continue
+		}
+		res, err := a.repository.InsertDeliveryWithContext(ctx, &Delivery{
+			DeliveryID:     deliveryID(s.SubscriptionID, input.EventID),
+			SubscriptionID: s.SubscriptionID,
+			EventID:        input.EventID,
+			EventType:      input.EventType,
+			AccountKey:     input.AccountKey,
+			Payload:        payload,
+			Status:         DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+		if err != nil {
+			a.log.Error(fmt.Sprintf("Repository insert delivery error %s", err.Error()))
+			return nil, err
+		}
+		if res != nil && res.AlreadyExists {
+			continue
+		}
+		emitOutput.Deliveries++
+	}
+
+	return emitOutput, nil
+}
+
+// attemptWithContext sends one delivery and returns the status it ends in.
+func (a *webhook) attemptWithContext(ctx context.Context, d *Delivery, now time.Time) (string, error) {
+	attempts := d.Attempts + 1
+	// The next attempt is scheduled before sending, so a dispatcher that stops
+	// halfway leaves the delivery to be retried instead of lost.
+	claim, err := a.repository.ClaimDeliveryWithContext(ctx, &ClaimDeliveryInput{
+		DeliveryID:    d.DeliveryID,
+		Attempts:      d.Attempts,
+		NextAttemptAt: now.Add(a.retryInterval(attempts)),
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository claim delivery %s error %s", d.DeliveryID, err.Error()))
+		return "", err
+	}
+	if claim != nil && claim.DeliveryChanged {
+		return "", nil
+	}
+
+	s, err := a.getSubscriptionWithContext(ctx, d.SubscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	record := &RecordDeliveryInput{
+		DeliveryID: d.DeliveryID,
+		Status:     DeliveryPending,
+	}
+	if s == nil {
+		record.Status = DeliveryDead
+		record.Error = "subscription deleted"
+	} else {
+		timestamp := now.Unix()
+		res, err := a.sender.SendWithContext(ctx, &SendInput{
+			Url: s.Url,
+			Headers: map[string]string{
+				DeliveryHeader:  d.DeliveryID,
+				EventHeader:     d.EventType,
+				TimestampHeader: strconv.FormatInt(timestamp, 10),
+				SignatureHeader: Sign(s.Secret, timestamp, d.Payload),
+			},
+			Body: d.Payload,
+		})
+		switch {
+		case err != nil:
+			record.Error = err.Error()
+		case res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices:
+			record.StatusCode = res.StatusCode
+			record.Error = fmt.Sprintf("subscriber answered %d", res.StatusCode)
+		default:
+			record.StatusCode = res.StatusCode
+			record.Status = DeliveryDelivered
+			record.DeliveredAt = now
+		}
+		if record.Status == DeliveryPending && attempts >= a.config.MaxAttempts {
+			record.Status = DeliveryDead
+		}
+	}
+
+	if err := a.repository.RecordDeliveryWithContext(ctx, record); err != nil {
+		a.log.Error(fmt.Sprintf("Repository record delivery %s error %s", d.DeliveryID, err.Error()))
+		return "", err
+	}
+	return record.Status, nil
+}
+
+func (a *webhook) DispatchDueDeliveriesWithContext(ctx context.Context, input *DispatchDueDeliveriesInput) (*DispatchDueDeliveriesOutput, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultDispatchBatchSize
+	}
+
+	o, err := a.repository.ListDueDeliveriesWithContext(ctx, &ListDueDeliveriesInput{
+		Now:   input.Now,
+		Limit: limit,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository list due deliveries error %s", err.Error()))
+		return nil, err
+	}
+
+	dispatchOutput := &DispatchDueDeliveriesOutput{}
+	for _, d := range o.Deliveries {
+		status, err := a.attemptWithContext(ctx, d, input.Now)
+		if err != nil {
+			continue
+		}
+		switch status {
+		case DeliveryDelivered:
+			dispatchOutput.Delivered++
+		case DeliveryPending:
+			dispatchOutput.Retried++
+		case DeliveryDead:
+			dispatchOutput.Dead++
+		}
+	}
+
+	return dispatchOutput, nil
+}
+
+func (a *webhook) ListDeadLettersWithContext(ctx context.Context, input *ListDeadLettersInput) (*ListDeadLettersOutput, error) {
+	s, err := a.getSubscriptionWithContext(ctx, input.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return &ListDeadLettersOutput{
+			Error:  true,
+			Code:   SubscriptionNotFound,
+			Detail: "subscription not found",
+		}, nil
+	}
+
+	o, err := a.repository.ListDeadDeliveriesWithContext(ctx, &ListDeadDeliveriesInput{
+		SubscriptionID: input.SubscriptionID,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository list dead deliveries error %s", err.Error()))
+		return nil, err
+	}
+
+	deliveries := make([]*DeliveryOutput, 0, len(o.Deliveries))
+	for _, d := range o.Deliveries {
+		deliveries = append(deliveries, deliveryOutput(d))
+	}
+	return &ListDeadLettersOutput{
+		Deliveries: deliveries,
+	}, nil
+}
+
+// RedeliverWithContext sends a dead or delivered delivery again with a fresh
+// set of attempts, on the next dispatch.
+func (a *webhook) RedeliverWithContext(ctx context.Context, input *RedeliverInput) (*DeliveryOutput, error) {
+	d, err := a.repository.GetDeliveryWithContext(ctx, &GetDeliveryInput{
+		DeliveryID: input.DeliveryID,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get delivery error %s", err.Error()))
+		return nil, err
+	}
+	if d == nil || d.SubscriptionID != input.SubscriptionID {
+		return &DeliveryOutput{
+			Error:  true,
+			Code:   DeliveryNotFound,
+			Detail: "delivery not found",
+		}, nil
+	}
+
+	inProgress := &DeliveryOutput{
+		Error:  true,
+		Code:   DeliveryInProgress,
+		Detail: "delivery is still being retried",
+	}
+	if d.Status == DeliveryPending {
+		return inProgress, nil
+	}
+
+	now := a.clock()
+	res, err := a.repository.ResetDeliveryWithContext(ctx, &ResetDeliveryInput{
+		DeliveryID:    d.DeliveryID,
+		NextAttemptAt: now,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository reset delivery error %s", err.Error()))
+		return nil, err
+	}
+	if res != nil && res.DeliveryChanged {
+		return inProgress, nil
+	}
+
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	return deliveryOutput(d), nil
+}
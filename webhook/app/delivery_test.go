@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func dispatch(t *testing.T, a Webhook) string {
+	res, err := a.DispatchDueDeliveriesWithContext(context.Background(), &DispatchDueDeliveriesInput{
+		Now: testNow,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func TestDelivery_Sign(t *testing.T) {
+	assert.Equal(t, "v1=7a44a27957db6eeaaacf382e765a621a7db5f1bc53912b12f59db5d59b701eae", Sign("whsec_test", 1643623200, []byte("{\"event_id\":\"e1\"}")))
+}
+
+func TestDelivery_DispatchDelivered(t *testing.T) {
+	r := &repositoryMock{t: t, subscriptions: map[string][]*Subscription{
+		"1": {newSubscription("s1", "1", DebitSettled)},
+	}, deliveries: []*Delivery{newDelivery("s1", 0)}}
+	s := &senderMock{statusCode: 204}
+	a := newWithClock(r, s)
+	assert.Equal(t, "{\"Delivered\":1,\"Retried\":0,\"Dead\":0}", dispatch(t, a))
+	b, err := json.Marshal(s.sent[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Url\":\"https://merchant.example.com/hooks\",\"Headers\":{\"X-Webhook-Delivery\":\"d1\",\"X-Webhook-Event\":\"DebitSettled\",\"X-Webhook-Signature\":\"v1=7a44a27957db6eeaaacf382e765a621a7db5f1bc53912b12f59db5d59b701eae\",\"X-Webhook-Timestamp\":\"1643623200\"},\"Body\":\"eyJldmVudF9pZCI6ImUxIn0=\"}", string(b))
+	b, err = json.Marshal(r.records[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"DeliveryID\":\"d1\",\"Status\":\"delivered\",\"StatusCode\":204,\"Error\":\"\",\"DeliveredAt\":\"2022-01-31T10:00:00Z\"}", string(b))
+}
+
+func TestDelivery_DispatchRetried(t *testing.T) {
+	r := &repositoryMock{t: t, subscriptions: map[string][]*Subscription{
+		"1": {newSubscription("s1", "1", DebitSettled)},
+	}, deliveries: []*Delivery{newDelivery("s1", 1)}}
+	a := newWithClock(r, &senderMock{statusCode: 500})
+	assert.Equal(t, "{\"Delivered\":0,\"Retried\":1,\"Dead\":0}", dispatch(t, a))
+	b, err := json.Marshal(r.records[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"DeliveryID\":\"d1\",\"Status\":\"pending\",\"StatusCode\":500,\"Error\":\"subscriber answered 500\",\"DeliveredAt\":\"0001-01-01T00:00:00Z\"}", string(b))
+}
+
+func TestDelivery_DispatchDeadAfterMaxAttempts(t *testing.T) {
+	r := &repositoryMock{t: t, subscriptions: map[string][]*Subscription{
+		"1": {newSubscription("s1", "1", DebitSettled)},
+	}, deliveries: []*Delivery{newDelivery("s1", 2)}}
+	a := newWithClock(r, &senderMock{err: errors.New("connection refused")})
+	assert.Equal(t, "{\"Delivered\":0,\"Retried\":0,\"Dead\":1}", dispatch(t, a))
+	assert.Equal(t, "connection refused", r.records[0].Error)
+}
+
+func TestDelivery_DispatchDeadWhenSubscriptionDeleted(t *testing.T) {
+	r := &repositoryMock{t: t, deliveries: []*Delivery{newDelivery("s1", 0)}}
+	s := &senderMock{statusCode: 200}
+	a := newWithClock(r, s)
+	assert.Equal(t, "{\"Delivered\":0,\"Retried\":0,\"Dead\":1}", dispatch(t, a))
+	assert.Len(t, s.sent, 0)
+	assert.Equal(t, "subscription deleted", r.records[0].Error)
+}
+
+func TestDelivery_NotDispatchWhenClaimedByAnother(t *testing.T) {
+	r := &repositoryMock{t: t, deliveries: []*Delivery{newDelivery("s1", 0)}, claimChanged: true}
+	s := &senderMock{statusCode: 200}
+	a := newWithClock(r, s)
+	assert.Equal(t, "{\"Delivered\":0,\"Retried\":0,\"Dead\":0}", dispatch(t, a))
+	assert.Len(t, s.sent, 0)
+	assert.Len(t, r.records, 0)
+}
+
+func TestDelivery_RetryInterval(t *testing.T) {
+	a := newWithClock(&repositoryMock{t: t}, &senderMock{}).(*webhook)
+	assert.Equal(t, 30*time.Second, a.retryInterval(1))
+	assert.Equal(t, 2*time.Minute, a.retryInterval(3))
+	assert.Equal(t, time.Hour, a.retryInterval(20))
+}
+
+func TestDelivery_ListDeadLetters(t *testing.T) {
+	d := newDelivery("s1", 3)
+	d.Status = DeliveryDead
+	d.LastStatusCode = 500
+	r := &repositoryMock{t: t, subscriptions: map[string][]*Subscription{
+		"1": {newSubscription("s1", "1", DebitSettled)},
+	}, deliveries: []*Delivery{d}}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.ListDeadLettersWithContext(context.Background(), &ListDeadLettersInput{
+		SubscriptionID: "s1",
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"Deliveries\":[{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"DeliveryID\":\"d1\",\"SubscriptionID\":\"s1\",\"EventID\":\"e1\",\"EventType\":\"DebitSettled\",\"AccountKey\":\"1\",\"Status\":\"dead\",\"Attempts\":3,\"NextAttemptAt\":\"2022-01-31T10:00:00Z\",\"LastStatusCode\":500,\"LastError\":\"\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"DeliveredAt\":\"0001-01-01T00:00:00Z\"}]}", string(b))
+}
+
+func TestDelivery_NotListDeadLettersWhenSubscriptionNotFound(t *testing.T) {
+	r := &repositoryMock{t: t}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.ListDeadLettersWithContext(context.Background(), &ListDeadLettersInput{
+		SubscriptionID: "s1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, SubscriptionNotFound, res.Code)
+}
+
+func TestDelivery_Redeliver(t *testing.T) {
+	d := newDelivery("s1", 3)
+	d.Status = DeliveryDead
+	r := &repositoryMock{t: t, deliveries: []*Delivery{d}}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.RedeliverWithContext(context.Background(), &RedeliverInput{
+		SubscriptionID: "s1",
+		DeliveryID:     "d1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, DeliveryPending, res.Status)
+	assert.Equal(t, 0, res.Attempts)
+}
+
+func TestDelivery_NotRedeliverWhenPending(t *testing.T) {
+	r := &repositoryMock{t: t, deliveries: []*Delivery{newDelivery("s1", 1)}}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.RedeliverWithContext(context.Background(), &RedeliverInput{
+		SubscriptionID: "s1",
+		DeliveryID:     "d1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, DeliveryInProgress, res.Code)
+}
+
+func TestDelivery_NotRedeliverWhenOtherSubscription(t *testing.T) {
+	d := newDelivery("s1", 3)
+	d.Status = DeliveryDead
+	r := &repositoryMock{t: t, deliveries: []*Delivery{d}}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.RedeliverWithContext(context.Background(), &RedeliverInput{
+		SubscriptionID: "s2",
+		DeliveryID:     "d1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, DeliveryNotFound, res.Code)
+}
@@ -0,0 +1,6 @@
+package app
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
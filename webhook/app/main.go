@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	AccountCreated  = "AccountCreated"
+	CreditSettled   = "CreditSettled"
+	DebitSettled    = "DebitSettled"
+	ReversalSettled = "ReversalSettled"
+	LimitBreached   = "LimitBreached"
+
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+
+	SubscriptionInvalid  = "subscription-invalid"
+	SubscriptionNotFound = "subscription-not-found"
+	EventTypeInvalid     = "event-type-invalid"
+	DeliveryNotFound     = "delivery-not-found"
+	DeliveryInProgress   = "delivery-in-progress"
+)
+
+var eventTypes = []string{AccountCreated, CreditSettled, DebitSettled, ReversalSettled, LimitBreached}
+
+type Webhook interface {
+	CreateSubscriptionWithContext(ctx context.Context, input *CreateSubscriptionInput) (*SubscriptionOutput, error)
+	GetSubscriptionWithContext(ctx context.Context, input *GetSubscriptionInput) (*SubscriptionOutput, error)
+	DeleteSubscriptionWithContext(ctx context.Context, input *DeleteSubscriptionInput) (*SubscriptionOutput, error)
+	EmitWithContext(ctx context.Context, input *EmitInput) (*EmitOutput, error)
+	DispatchDueDeliveriesWithContext(ctx context.Context, input *DispatchDueDeliveriesInput) (*DispatchDueDeliveriesOutput, error)
+	ListDeadLettersWithContext(ctx context.Context, input *ListDeadLettersInput) (*ListDeadLettersOutput, error)
+	RedeliverWithContext(ctx context.Context, input *RedeliverInput) (*DeliveryOutput, error)
+}
+
+type CreateSubscriptionInput struct {
+	AccountKey string
+	Url        string
+	EventTypes []string
+}
+
+type GetSubscriptionInput struct {
+	SubscriptionID string
+}
+
+type DeleteSubscriptionInput struct {
+	SubscriptionID string
+}
+
+// SubscriptionOutput only carries the secret when the subscription is created.
+type SubscriptionOutput struct {
+	Error          bool
+	Code           string
+	Detail         string
+	SubscriptionID string
+	AccountKey     string
+	Url            string
+	EventTypes     []string
+	Secret         string
+	CreatedAt      time.Time
+}
+
+// EmitInput is an outcome reported by accreditation, credit or debit. EventID is
+// chosen by the producer, so reporting the same outcome twice delivers it once.
+type EmitInput struct {
+	EventID    string
+	EventType  string
+	AccountKey string
+	OccurredAt time.Time
+	Data       []byte
+}
+
+type EmitOutput struct {
+	Error      bool
+	Code       string
+	Detail     string
+	Deliveries int
+}
+
+type DispatchDueDeliveriesInput struct {
+	Now   time.Time
+	Limit int
+}
+
+type DispatchDueDeliveriesOutput struct {
+	Delivered int
+	Retried   int
+	Dead      int
+}
+
+type ListDeadLettersInput struct {
+	SubscriptionID string
+}
+
+type ListDeadLettersOutput struct {
+	Error      bool
+	Code       string
+	Detail     string
+	Deliveries []*DeliveryOutput
+}
+
+type RedeliverInput struct {
+	SubscriptionID string
+	DeliveryID     string
+}
+
+type DeliveryOutput struct {
+	Error          bool
+	Code           string
+	Detail         string
+	DeliveryID     string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	AccountKey     string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+}
+
+type webhook struct {
+	log        Logger
+	config     *Config
+	repository Persistence
+	sender     Sender
+	clock      func() time.Time
+}
+
+func New(r Persistence, s Sender, config *Config, log Logger) Webhook {
+	return &webhook{
+		log:        log,
+		config:     config,
+		repository: r,
+		sender:     s,
+		clock:      time.Now,
+	}
+}
@@ -0,0 +1,283 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)
+
+type repositoryMock struct {
+	t             *testing.T
+	subscriptions map[string][]*Subscription
+	deliveries    []*Delivery
+	inserted      []*Delivery
+	claimChanged  bool
+	resetChanged  bool
+	records       []*RecordDeliveryInput
+	err           error
+}
+
+func (r *repositoryMock) InsertSubscriptionWithContext(ctx context.Context, input *Subscription) error {
+	return r.err
+}
+func (r *repositoryMock) GetSubscriptionWithContext(ctx context.Context, input *GetSubscriptionInput) (*Subscription, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, subscriptions := range r.subscriptions {
+		for _, s := range subscriptions {
+			if s.SubscriptionID == input.SubscriptionID {
+				return s, nil
+			}
+		}
+	}
+	return nil, nil
+}
+func (r *repositoryMock) DeleteSubscriptionWithContext(ctx context.Context, input *DeleteSubscriptionInput) error {
+	return r.err
+}
+func (r *repositoryMock) ListSubscriptionsWithContext(ctx context.Context, input *ListSubscriptionsInput) (*ListSubscriptionsOutput, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &ListSubscriptionsOutput{
+		Subscriptions: r.subscriptions[input.AccountKey],
+	}, nil
+}
+func (r *repositoryMock) InsertDeliveryWithContext(ctx context.Context, input *Delivery) (*InsertDeliveryOutput, error) {
+	for _, d := range r.deliveries {
+		if d.DeliveryID == input.DeliveryID {
+			return &InsertDeliveryOutput{
+				AlreadyExists: true,
+			}, nil
+		}
+	}
+	r.inserted = append(r.inserted, input)
+	return &InsertDeliveryOutput{}, nil
+}
+func (r *repositoryMock) GetDeliveryWithContext(ctx context.Context, input *GetDeliveryInput) (*Delivery, error) {
+	for _, d := range r.deliveries {
+		if d.DeliveryID == input.DeliveryID {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+func (r *repositoryMock) ListDueDeliveriesWithContext(ctx context.Context, input *ListDueDeliveriesInput) (*ListDeliveriesOutput, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &ListDeliveriesOutput{
+		Deliveries: r.deliveries,
+	}, nil
+}
+func (r *repositoryMock) ListDeadDeliveriesWithContext(ctx context.Context, input *ListDeadDeliveriesInput) (*ListDeliveriesOutput, error) {
+	return &ListDeliveriesOutput{
+		Deliveries: r.deliveries,
+	}, nil
+}
+func (r *repositoryMock) ClaimDeliveryWithContext(ctx context.Context, input *ClaimDeliveryInput) (*ChangeDeliveryOutput, error) {
+	return &ChangeDeliveryOutput{
+		DeliveryChanged: r.claimChanged,
+	}, nil
+}
+func (r *repositoryMock) RecordDeliveryWithContext(ctx context.Context, input *RecordDeliveryInput) error {
+	r.records = append(r.records, input)
+	return nil
+}
+func (r *repositoryMock) ResetDeliveryWithContext(ctx context.Context, input *ResetDeliveryInput) (*ChangeDeliveryOutput, error) {
+	return &ChangeDeliveryOutput{
+		DeliveryChanged: r.resetChanged,
+	}, nil
+}
+
+type senderMock struct {
+	statusCode int
+	err        error
+	sent       []*SendInput
+}
+
+func (s *senderMock) SendWithContext(ctx context.Context, input *SendInput) (*SendOutput, error) {
+	s.sent = append(s.sent, input)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &SendOutput{
+		StatusCode: s.statusCode,
+	}, nil
+}
+
+type log struct{}
+
+func (l log) Info(msg string)  {}
+func (l log) Error(msg string) {}
+func newLogMock() Logger {
+	return &log{}
+}
+
+func newWithClock(r Persistence, s Sender) Webhook {
+	c := &Config{}
+	c.WithMaxAttempts("3")
+	c.WithRetryBase("30s")
+	a := New(r, s, c, newLogMock())
+	a.(*webhook).clock = func() time.Time {
+		return testNow
+	}
+	return a
+}
+
+func newSubscription(id string, accountKey string, eventTypes ...string) *Subscription {
+	return &Subscription{
+		SubscriptionID: id,
+		AccountKey:     accountKey,
+		Url:            "https://merchant.example.com/hooks",
+		EventTypes:     eventTypes,
+		Secret:         "whsec_test",
+		CreatedAt:      testNow,
+	}
+}
+
+func newDelivery(subscriptionID string, attempts int) *Delivery {
+	return &Delivery{
+		DeliveryID:     "d1",
+		SubscriptionID: subscriptionID,
+		EventID:        "e1",
+		EventType:      DebitSettled,
+		AccountKey:     "1",
+		Payload:        []byte("{\"event_id\":\"e1\"}"),
+		Status:         DeliveryPending,
+		Attempts:       attempts,
+		NextAttemptAt:  testNow,
+		CreatedAt:      testNow,
+	}
+}
+
+func TestWebhook_CreateSubscription(t *testing.T) {
+	r := &repositoryMock{t: t}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.CreateSubscriptionWithContext(context.Background(), &CreateSubscriptionInput{
+		AccountKey: "1",
+		Url:        "https://merchant.example.com/hooks",
+		EventTypes: []string{DebitSettled, LimitBreached, DebitSettled},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, res.SubscriptionID, 32)
+	assert.Regexp(t, "^whsec_[0-9a-f]{64}$", res.Secret)
+	assert.Equal(t, []string{DebitSettled, LimitBreached}, res.EventTypes)
+}
+
+func TestWebhook_NotCreateSubscriptionWhenUrlInvalid(t *testing.T) {
+	r := &repositoryMock{t: t}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.CreateSubscriptionWithContext(context.Background(), &CreateSubscriptionInput{
+		Url:        "merchant.example.com/hooks",
+		EventTypes: []string{DebitSettled},
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"subscription-invalid\",\"Detail\":\"url must be an absolute http or https url\",\"SubscriptionID\":\"\",\"AccountKey\":\"\",\"Url\":\"\",\"EventTypes\":null,\"Secret\":\"\",\"CreatedAt\":\"0001-01-01T00:00:00Z\"}", string(b))
+}
+
+func TestWebhook_NotCreateSubscriptionWhenEventTypeInvalid(t *testing.T) {
+	r := &repositoryMock{t: t}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.CreateSubscriptionWithContext(context.Background(), &CreateSubscriptionInput{
+		Url:        "https://merchant.example.com/hooks",
+		EventTypes: []string{"AccountClosed"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, EventTypeInvalid, res.Code)
+	assert.Equal(t, "event type AccountClosed is not one of AccountCreated, CreditSettled, DebitSettled, ReversalSettled, LimitBreached", res.Detail)
+}
+
+func TestWebhook_GetSubscriptionWithoutSecret(t *testing.T) {
+	r := &repositoryMock{t: t, subscriptions: map[string][]*Subscription{
+		"1": {newSubscription("s1", "1", DebitSettled)},
+	}}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.GetSubscriptionWithContext(context.Background(), &GetSubscriptionInput{
+		SubscriptionID: "s1",
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":false,\"Code\":\"\",\"Detail\":\"\",\"SubscriptionID\":\"s1\",\"AccountKey\":\"1\",\"Url\":\"https://merchant.example.com/hooks\",\"EventTypes\":[\"DebitSettled\"],\"Secret\":\"\",\"CreatedAt\":\"2022-01-31T10:00:00Z\"}", string(b))
+}
+
+func TestWebhook_NotDeleteSubscriptionWhenNotFound(t *testing.T) {
+	r := &repositoryMock{t: t}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.DeleteSubscriptionWithContext(context.Background(), &DeleteSubscriptionInput{
+		SubscriptionID: "s1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, SubscriptionNotFound, res.Code)
+}
+
+func TestWebhook_Emit(t *testing.T) {
+	r := &repositoryMock{t: t, subscriptions: map[string][]*Subscription{
+		"":  {newSubscription("all", "", AccountCreated, DebitSettled)},
+		"1": {newSubscription("s1", "1", DebitSettled), newSubscription("s2", "1", CreditSettled)},
+		"2": {newSubscription("s3", "2", DebitSettled)},
+	}}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.EmitWithContext(context.Background(), &EmitInput{
+		EventID:    "e1",
+		EventType:  DebitSettled,
+		AccountKey: "1",
+		OccurredAt: testNow,
+		Data:       []byte("{\"amount\":1000}"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res.Deliveries)
+	assert.Equal(t, "all", r.inserted[0].SubscriptionID)
+	assert.Equal(t, "s1", r.inserted[1].SubscriptionID)
+	assert.Equal(t, deliveryID("s1", "e1"), r.inserted[1].DeliveryID)
+	assert.Equal(t, "{\"event_id\":\"e1\",\"event_type\":\"DebitSettled\",\"account_key\":\"1\",\"occurred_at\":\"2022-01-31T10:00:00Z\",\"data\":{\"amount\":1000}}", string(r.inserted[1].Payload))
+}
+
+func TestWebhook_EmitTwice(t *testing.T) {
+	r := &repositoryMock{t: t, subscriptions: map[string][]*Subscription{
+		"1": {newSubscription("s1", "1", DebitSettled)},
+	}, deliveries: []*Delivery{{DeliveryID: deliveryID("s1", "e1")}}}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.EmitWithContext(context.Background(), &EmitInput{
+		EventID:    "e1",
+		EventType:  DebitSettled,
+		AccountKey: "1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, res.Deliveries)
+}
+
+func TestWebhook_NotEmitWhenEventTypeInvalid(t *testing.T) {
+	r := &repositoryMock{t: t}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.EmitWithContext(context.Background(), &EmitInput{
+		EventID:    "e1",
+		EventType:  "Unknown",
+		AccountKey: "1",
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Error\":true,\"Code\":\"event-type-invalid\",\"Detail\":\"event type Unknown is not supported\",\"Deliveries\":0}", string(b))
+}
+
+func TestWebhook_NotEmitWhenListError(t *testing.T) {
+	r := &repositoryMock{t: t, err: errors.New("list error")}
+	a := newWithClock(r, &senderMock{})
+	res, err := a.EmitWithContext(context.Background(), &EmitInput{
+		EventID:    "e1",
+		EventType:  DebitSettled,
+		AccountKey: "1",
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "list error", err.Error())
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+type Persistence interface {
+	InsertSubscriptionWithContext(ctx context.Context, input *Subscription) error
+	GetSubscriptionWithContext(ctx context.Context, input *GetSubscriptionInput) (*Subscription, error)
+	DeleteSubscriptionWithContext(ctx context.Context, input *DeleteSubscriptionInput) error
+	ListSubscriptionsWithContext(ctx context.Context, input *ListSubscriptionsInput) (*ListSubscriptionsOutput, error)
+	InsertDeliveryWithContext(ctx context.Context, input *Delivery) (*InsertDeliveryOutput, error)
+	GetDeliveryWithContext(ctx context.Context, input *GetDeliveryInput) (*Delivery, error)
+	ListDueDeliveriesWithContext(ctx context.Context, input *ListDueDeliveriesInput) (*ListDeliveriesOutput, error)
+	ListDeadDeliveriesWithContext(ctx context.Context, input *ListDeadDeliveriesInput) (*ListDeliveriesOutput, error)
+	ClaimDeliveryWithContext(ctx context.Context, input *ClaimDeliveryInput) (*ChangeDeliveryOutput, error)
+	RecordDeliveryWithContext(ctx context.Context, input *RecordDeliveryInput) error
+	ResetDeliveryWithContext(ctx context.Context, input *ResetDeliveryInput) (*ChangeDeliveryOutput, error)
+}
+
+// Subscription with an empty AccountKey receives the events of every account.
+type Subscription struct {
+	SubscriptionID string
+	AccountKey     string
+	Url            string
+	EventTypes     []string
+	Secret         string
+	CreatedAt      time.Time
+}
+
+type ListSubscriptionsInput struct {
+	AccountKey string
+}
+
+type ListSubscriptionsOutput struct {
+	Subscriptions []*Subscription
+}
+
+type Delivery struct {
+	DeliveryID     string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	AccountKey     string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+}
+
+type InsertDeliveryOutput struct {
+	AlreadyExists bool
+}
+
+type GetDeliveryInput struct {
+	DeliveryID string
+}
+
+type ListDueDeliveriesInput struct {
+	Now   time.Time
+	Limit int
+}
+
+type ListDeadDeliveriesInput struct {
+	SubscriptionID string
+}
+
+type ListDeliveriesOutput struct {
+	Deliveries []*Delivery
+}
+
+// ClaimDeliveryInput takes the attempt only while the delivery still has the
+// attempts that were read, so two dispatchers never send the same attempt.
+type ClaimDeliveryInput struct {
+	DeliveryID    string
+	Attempts      int
+	NextAttemptAt time.Time
+}
+
+type RecordDeliveryInput struct {
+	DeliveryID  string
+	Status      string
+	StatusCode  int
+	Error       string
+	DeliveredAt time.Time
+}
+
+type ResetDeliveryInput struct {
+	DeliveryID    string
+	NextAttemptAt time.Time
+}
+
+type ChangeDeliveryOutput struct {
+	DeliveryChanged bool
+}
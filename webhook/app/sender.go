@@ -0,0 +1,18 @@
+package app
+
+import "context"
+
+// Sender posts a signed delivery to the subscriber.
+type Sender interface {
+	SendWithContext(ctx context.Context, input *SendInput) (*SendOutput, error)
+}
+
+type SendInput struct {
+	Url     string
+	Headers map[string]string
+	Body    []byte
+}
+
+type SendOutput struct {
+	StatusCode int
+}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Sign is the HMAC-SHA256 of "timestamp.body" with the subscription secret. The
+// timestamp is signed too, so a captured delivery cannot be replayed later as new.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+	mac.Write(body)
+	return fmt.Sprintf("v1=%s", hex.EncodeToString(mac.Sum(nil)))
+}
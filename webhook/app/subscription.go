@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const secretPrefix = "whsec_"
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func validateUrl(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "url must be an absolute http or https url"
+	}
+	return ""
+}
+
+func knownEventType(eventType string) bool {
+	for _, t := range eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func validateEventTypes(types []string) ([]string, string) {
+	if len(types) == 0 {
+		return nil, "event_types must have at least one event type"
+	}
+
+	seen := make(map[string]bool, len(types))
+	valid := make([]string, 0, len(types))
+	for _, t := range types {
+		if !knownEventType(t) {
+			return nil, fmt.Sprintf("event type %s is not one of %s", t, strings.Join(eventTypes, ", "))
+		}
+		if !seen[t] {
+			seen[t] = true
+			valid = append(valid, t)
+		}
+	}
+	return valid, ""
+}
+
+func subscribes(s *Subscription, eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func subscriptionOutput(s *Subscription) *SubscriptionOutput {
+	return &SubscriptionOutput{
+		Error:          false,
+		SubscriptionID: s.SubscriptionID,
+		AccountKey:     s.AccountKey,
+		Url:            s.Url,
+		EventTypes:     s.EventTypes,
+		CreatedAt:      s.CreatedAt,
+	}
+}
+
+func (a *webhook) CreateSubscriptionWithContext(ctx context.Context, input *CreateSubscriptionInput) (*SubscriptionOutput, error) {
+	if detail := validateUrl(input.Url); detail != "" {
+		return &SubscriptionOutput{
+			Error:  true,
+			Code:   SubscriptionInvalid,
+			Detail: detail,
+		}, nil
+	}
+
+	types, detail := validateEventTypes(input.EventTypes)
+	if detail != "" {
+		return &SubscriptionOutput{
+			Error:  true,
+			Code:   EventTypeInvalid,
+			Detail: detail,
+		}, nil
+	}
+
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Subscription{
+		SubscriptionID: id,
+		AccountKey:     input.AccountKey,
+		Url:            input.Url,
+		EventTypes:     types,
+		Secret:         secretPrefix + secret,
+		CreatedAt:      a.clock(),
+	}
+	if err := a.repository.InsertSubscriptionWithContext(ctx, s); err != nil {
+		a.log.Error(fmt.Sprintf("Repository insert subscription error %s", err.Error()))
+		return nil, err
+	}
+
+	o := subscriptionOutput(s)
+	o.Secret = s.Secret
+	return o, nil
+}
+
+func (a *webhook) getSubscriptionWithContext(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	s, err := a.repository.GetSubscriptionWithContext(ctx, &GetSubscriptionInput{
+		SubscriptionID: subscriptionID,
+	})
+	if err != nil {
+		a.log.Error(fmt.Sprintf("Repository get subscription error %s", err.Error()))
+		return nil, err
+	}
+	return s, nil
+}
+
+func subscriptionNotFound() *SubscriptionOutput {
+	return &SubscriptionOutput{
+		Error:  true,
+		Code:   SubscriptionNotFound,
+		Detail: "subscription not found",
+	}
+}
+
+func (a *webhook) GetSubscriptionWithContext(ctx context.Context, input *GetSubscriptionInput) (*SubscriptionOutput, error) {
+	s, err := a.getSubscriptionWithContext(ctx, input.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return subscriptionNotFound(), nil
+	}
+
+	return subscriptionOutput(s), nil
+}
+
+// DeleteSubscriptionWithContext leaves the pending deliveries behind; the
+// dispatcher moves them to the dead letters when it finds the subscription gone.
+func (a *webhook) DeleteSubscriptionWithContext(ctx context.Context, input *DeleteSubscriptionInput) (*SubscriptionOutput, error) {
+	s, err := a.getSubscriptionWithContext(ctx, input.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return subscriptionNotFound(), nil
+	}
+
+	if err := a.repository.DeleteSubscriptionWithContext(ctx, input); err != nil {
+		a.log.Error(fmt.Sprintf("Repository delete subscription error %s", err.Error()))
+		return nil, err
+	}
+
+	return subscriptionOutput(s), nil
+}
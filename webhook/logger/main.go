@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"log"
+	"webhook/app"
+	"webhook/repository"
+	"webhook/routes"
+	"webhook/scheduler"
+	"webhook/sender"
+	"webhook/server"
+)
+
+type logs struct{}
+
+func (l *logs) Fatal(msg string) {
+	log.Fatal(msg)
+}
+
+func (l *logs) Info(msg string) {
+	log.Print(msg)
+}
+
+func (l *logs) Error(msg string) {
+	log.Print(msg)
+}
+
+func New() (app.Logger, server.Logger, routes.Logger, repository.Logger, scheduler.Logger, sender.Logger) {
+	return &logs{}, &logs{}, &logs{}, &logs{}, &logs{}, &logs{}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"webhook/app"
+	"webhook/logger"
+	"webhook/repository"
+	"webhook/routes"
+	"webhook/scheduler"
+	"webhook/sender"
+	"webhook/server"
+	"webhook/services"
+)
+
+func main() {
+	logApp, logServer, logRoutes, logDynamodb, logScheduler, logSender := logger.New()
+	dynamodbConfig := repository.Config{}
+	dynamodbConfig.WithSubscriptionTableName(os.Getenv("SUBSCRIPTION_TABLE_NAME"))
+	dynamodbConfig.WithDeliveryTableName(os.Getenv("DELIVERY_TABLE_NAME"))
+	dynamodb := repository.NewDynamodb(services.NewDynamodb(), logDynamodb, dynamodbConfig)
+	confSender := &sender.Config{}
+	confSender.WithTimeout(os.Getenv("DELIVERY_TIMEOUT"))
+	httpSender := sender.New(logSender, confSender, services.NewHttp())
+	confApp := &app.Config{}
+	confApp.WithMaxAttempts(os.Getenv("MAX_ATTEMPTS"))
+	confApp.WithRetryBase(os.Getenv("RETRY_BASE"))
+	webhook := app.New(dynamodb, httpSender, confApp, logApp)
+	confScheduler := &scheduler.Config{}
+	confScheduler.WithInterval(os.Getenv("SCHEDULER_INTERVAL"))
+	jobs := scheduler.New(webhook, logScheduler, confScheduler)
+	go jobs.Start()
+	routes := routes.New(webhook, logRoutes)
+	serverHttp := server.New(routes, logServer)
+	serverHttp.Start()
+}
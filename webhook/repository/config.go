@@ -0,0 +1,16 @@
+package repository
+
+type Config struct {
+	SubscriptionTableName string
+	DeliveryTableName     string
+}
+
+func (c *Config) WithSubscriptionTableName(subscriptionTableName string) *Config {
+	c.SubscriptionTableName = subscriptionTableName
+	return c
+}
+
+func (c *Config) WithDeliveryTableName(deliveryTableName string) *Config {
+	c.DeliveryTableName = deliveryTableName
+	return c
+}
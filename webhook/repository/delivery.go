@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+	"webhook/app"
+)
+
+const (
+	// deliveryPendingIndex is sparse: Pending is removed once a delivery is
+	// delivered or dead.
+	deliveryPendingIndex = "Pending-NextAttemptAt-index"
+	deliveryStatusIndex  = "SubscriptionId-Status-index"
+	deliveryPending      = "1"
+)
+
+func (d *db) InsertDeliveryWithContext(ctx context.Context, input *app.Delivery) (*app.InsertDeliveryOutput, error) {
+	i := &dynamodb.PutItemInput{
+		Item: map[string]*dynamodb.AttributeValue{
+			"DeliveryId": {
+				S: aws.String(input.DeliveryID),
+			},
+			"SubscriptionId": {
+				S: aws.String(input.SubscriptionID),
+			},
+			"EventId": {
+				S: aws.String(input.EventID),
+			},
+			"EventType": {
+				S: aws.String(input.EventType),
+			},
+			"AccountKey": {
+				S: aws.String(input.AccountKey),
+			},
+			"Payload": {
+				S: aws.String(string(input.Payload)),
+			},
+			"Status": {
+				S: aws.String(input.Status),
+			},
+			"Pending": {
+				S: aws.String(deliveryPending),
+			},
+			"Attempts": {
+				N: aws.String(strconv.Itoa(input.Attempts)),
+			},
+			"NextAttemptAt": {
+				S: aws.String(formatTime(input.NextAttemptAt)),
+			},
+			"CreatedAt": {
+				S: aws.String(formatTime(input.CreatedAt)),
+			},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(DeliveryId)"),
+		TableName:           aws.String(d.config.DeliveryTableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb insert delivery %s", input.DeliveryID))
+	_, err := d.dynamodbService.PutItemWithContext(ctx, i)
+	if err != nil {
+		if conditionalCheckFailed(err) {
+			return &app.InsertDeliveryOutput{
+				AlreadyExists: true,
+			}, nil
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.InsertDeliveryOutput{
+		AlreadyExists: false,
+	}, nil
+}
+
+func deliveryFromItem(item map[string]*dynamodb.AttributeValue) (*app.Delivery, error) {
+	dl := &app.Delivery{
+		DeliveryID:     stringAttribute(item, "DeliveryId"),
+		SubscriptionID: stringAttribute(item, "SubscriptionId"),
+		EventID:        stringAttribute(item, "EventId"),
+		EventType:      stringAttribute(item, "EventType"),
+		AccountKey:     stringAttribute(item, "AccountKey"),
+		Payload:        []byte(stringAttribute(item, "Payload")),
+		Status:         stringAttribute(item, "Status"),
+		LastError:      stringAttribute(item, "LastError"),
+	}
+	for name, v := range map[string]*int{
+		"Attempts":       &dl.Attempts,
+		"LastStatusCode": &dl.LastStatusCode,
+	} {
+		n, err := intAttribute(item, name)
+		if err != nil {
+			return nil, err
+		}
+		*v = n
+	}
+	for name, v := range map[string]*time.Time{
+		"NextAttemptAt": &dl.NextAttemptAt,
+		"CreatedAt":     &dl.CreatedAt,
+		"DeliveredAt":   &dl.DeliveredAt,
+	} {
+		t, err := timeAttribute(item, name)
+		if err != nil {
+			return nil, err
+		}
+		*v = t
+	}
+
+	return dl, nil
+}
+
+func (d *db) GetDeliveryWithContext(ctx context.Context, input *app.GetDeliveryInput) (*app.Delivery, error) {
+	i := &dynamodb.GetItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"DeliveryId": {
+				S: aws.String(input.DeliveryID),
+			},
+		},
+		TableName: aws.String(d.config.DeliveryTableName),
+	}
+	o, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get delivery %s", err.Error()))
+		return nil, err
+	}
+	if o.Item == nil {
+		return nil, nil
+	}
+
+	return deliveryFromItem(o.Item)
+}
+
+func (d *db) queryDeliveriesWithContext(ctx context.Context, queryInput *dynamodb.QueryInput, all bool) (*app.ListDeliveriesOutput, error) {
+	deliveries := make([]*app.Delivery, 0)
+	for {
+		queryOutput, err := d.dynamodbService.QueryWithContext(ctx, queryInput)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error query deliveries %s", err.Error()))
+			return nil, err
+		}
+		for _, item := range queryOutput.Items {
+			dl, err := deliveryFromItem(item)
+			if err != nil {
+				d.log.Error(fmt.Sprintf("Error query delivery item %s", err.Error()))
+				return nil, err
+			}
+			deliveries = append(deliveries, dl)
+		}
+		if !all || len(queryOutput.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = queryOutput.LastEvaluatedKey
+	}
+
+	return &app.ListDeliveriesOutput{
+		Deliveries: deliveries,
+	}, nil
+}
+
+// ListDueDeliveriesWithContext returns the oldest due deliveries first.
+func (d *db) ListDueDeliveriesWithContext(ctx context.Context, input *app.ListDueDeliveriesInput) (*app.ListDeliveriesOutput, error) {
+	queryInput := &dynamodb.QueryInput{
+		IndexName:              aws.String(deliveryPendingIndex),
+		KeyConditionExpression: aws.String("Pending = :pending AND NextAttemptAt <= :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pending": {
+				S: aws.String(deliveryPending),
+			},
+			":now": {
+				S: aws.String(formatTime(input.Now)),
+			},
+		},
+		Limit:     aws.Int64(int64(input.Limit)),
+		TableName: aws.String(d.config.DeliveryTableName),
+	}
+
+	return d.queryDeliveriesWithContext(ctx, queryInput, false)
+}
+
+func (d *db) ListDeadDeliveriesWithContext(ctx context.Context, input *app.ListDeadDeliveriesInput) (*app.ListDeliveriesOutput, error) {
+	queryInput := &dynamodb.QueryInput{
+		IndexName:              aws.String(deliveryStatusIndex),
+		KeyConditionExpression: aws.String("SubscriptionId = :subscriptionId AND #status = :dead"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":subscriptionId": {
+				S: aws.String(input.SubscriptionID),
+			},
+			":dead": {
+				S: aws.String(app.DeliveryDead),
+			},
+		},
+		TableName: aws.String(d.config.DeliveryTableName),
+	}
+
+	return d.queryDeliveriesWithContext(ctx, queryInput, true)
+}
+
+func (d *db) ClaimDeliveryWithContext(ctx context.Context, input *app.ClaimDeliveryInput) (*app.ChangeDeliveryOutput, error) {
+	i := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"DeliveryId": {
+				S: aws.String(input.DeliveryID),
+			},
+		},
+		UpdateExpression:    aws.String("SET Attempts = :next, NextAttemptAt = :nextAttemptAt"),
+		ConditionExpression: aws.String("Pending = :pending AND Attempts = :attempts"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":next": {
+				N: aws.String(strconv.Itoa(input.Attempts + 1)),
+			},
+			":nextAttemptAt": {
+				S: aws.String(formatTime(input.NextAttemptAt)),
+			},
+			":pending": {
+				S: aws.String(deliveryPending),
+			},
+			":attempts": {
+				N: aws.String(strconv.Itoa(input.Attempts)),
+			},
+		},
+		TableName: aws.String(d.config.DeliveryTableName),
+	}
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		if conditionalCheckFailed(err) {
+			return &app.ChangeDeliveryOutput{
+				DeliveryChanged: true,
+			}, nil
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.ChangeDeliveryOutput{
+		DeliveryChanged: false,
+	}, nil
+}
+
+// RecordDeliveryWithContext takes a delivered or dead delivery off the pending
+// index; a failed attempt that will be retried keeps its place.
+func (d *db) RecordDeliveryWithContext(ctx context.Context, input *app.RecordDeliveryInput) error {
+	updateExpression := "SET LastStatusCode = :statusCode, LastError = :error"
+	values := map[string]*dynamodb.AttributeValue{
+		":statusCode": {
+			N: aws.String(strconv.Itoa(input.StatusCode)),
+		},
+		":error": {
+			S: aws.String(input.Error),
+		},
+	}
+	var names map[string]*string
+	if input.Status != app.DeliveryPending {
+		updateExpression = "REMOVE Pending " + updateExpression + ", #status = :status"
+		names = map[string]*string{
+			"#status": aws.String("Status"),
+		}
+		values[":status"] = &dynamodb.AttributeValue{
+			S: aws.String(input.Status),
+		}
+	}
+	if input.Status == app.DeliveryDelivered {
+		updateExpression += ", DeliveredAt = :deliveredAt"
+		values[":deliveredAt"] = &dynamodb.AttributeValue{
+			S: aws.String(formatTime(input.DeliveredAt)),
+		}
+	}
+
+	i := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"DeliveryId": {
+				S: aws.String(input.DeliveryID),
+			},
+		},
+		UpdateExpression:          aws.String(updateExpression),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		TableName:                 aws.String(d.config.DeliveryTableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb record delivery %s %s", input.DeliveryID, input.Status))
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (d *db) ResetDeliveryWithContext(ctx context.Context, input *app.ResetDeliveryInput) (*app.ChangeDeliveryOutput, error) {
+	i := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"DeliveryId": {
+				S: aws.String(input.DeliveryID),
+			},
+		},
+		UpdateExpression:    aws.String("SET Pending = :pending, #status = :status, Attempts = :zero, NextAttemptAt = :nextAttemptAt"),
+		ConditionExpression: aws.String("attribute_exists(DeliveryId) AND attribute_not_exists(Pending)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pending": {
+				S: aws.String(deliveryPending),
+			},
+			":status": {
+				S: aws.String(app.DeliveryPending),
+			},
+			":zero": {
+				N: aws.String("0"),
+			},
+			":nextAttemptAt": {
+				S: aws.String(formatTime(input.NextAttemptAt)),
+			},
+		},
+		TableName: aws.String(d.config.DeliveryTableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb reset delivery %s", input.DeliveryID))
+	_, err := d.dynamodbService.UpdateItemWithContext(ctx, i)
+	if err != nil {
+		if conditionalCheckFailed(err) {
+			return &app.ChangeDeliveryOutput{
+				DeliveryChanged: true,
+			}, nil
+		}
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.ChangeDeliveryOutput{
+		DeliveryChanged: false,
+	}, nil
+}
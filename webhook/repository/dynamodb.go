@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+	"webhook/app"
+)
+
+const (
+	subscriptionAccountIndex = "AccountKey-index"
+	// allAccounts stands for an empty AccountKey, which cannot be an index key.
+	allAccounts = "*"
+
+	// timeLayout has a fixed width, so the times sort as strings.
+	timeLayout = "2006-01-02T15:04:05.000Z07:00"
+)
+
+type Dynamodb interface {
+	PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	DeleteItemWithContext(ctx context.Context, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+}
+
+type db struct {
+	dynamodbService Dynamodb
+	log             Logger
+	config          Config
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func conditionalCheckFailed(err error) bool {
+	ae, ok := err.(awserr.RequestFailure)
+	return ok && ae.Code() == "ConditionalCheckFailedException"
+}
+
+func stringAttribute(item map[string]*dynamodb.AttributeValue, name string) string {
+	if v, ok := item[name]; ok && v != nil {
+		return aws.StringValue(v.S)
+	}
+	return ""
+}
+
+func intAttribute(item map[string]*dynamodb.AttributeValue, name string) (int, error) {
+	if v, ok := item[name]; ok && v != nil && v.N != nil {
+		return strconv.Atoi(aws.StringValue(v.N))
+	}
+	return 0, nil
+}
+
+func timeAttribute(item map[string]*dynamodb.AttributeValue, name string) (time.Time, error) {
+	if s := stringAttribute(item, name); s != "" {
+		return time.Parse(time.RFC3339, s)
+	}
+	return time.Time{}, nil
+}
+
+func accountKeyAttribute(accountKey string) string {
+	if accountKey == "" {
+		return allAccounts
+	}
+	return accountKey
+}
+
+func (d *db) InsertSubscriptionWithContext(ctx context.Context, input *app.Subscription) error {
+	eventTypes := make([]*dynamodb.AttributeValue, 0, len(input.EventTypes))
+	for _, t := range input.EventTypes {
+		eventTypes = append(eventTypes, &dynamodb.AttributeValue{
+			S: aws.String(t),
+		})
+	}
+
+	i := &dynamodb.PutItemInput{
+		Item: map[string]*dynamodb.AttributeValue{
+			"SubscriptionId": {
+				S: aws.String(input.SubscriptionID),
+			},
+			"AccountKey": {
+				S: aws.String(accountKeyAttribute(input.AccountKey)),
+			},
+			"Url": {
+				S: aws.String(input.Url),
+			},
+			"EventTypes": {
+				L: eventTypes,
+			},
+			"Secret": {
+				S: aws.String(input.Secret),
+			},
+			"CreatedAt": {
+				S: aws.String(formatTime(input.CreatedAt)),
+			},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(SubscriptionId)"),
+		TableName:           aws.String(d.config.SubscriptionTableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb insert subscription %s", input.SubscriptionID))
+	_, err := d.dynamodbService.PutItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func subscriptionFromItem(item map[string]*dynamodb.AttributeValue) (*app.Subscription, error) {
+	s := &app.Subscription{
+		SubscriptionID: stringAttribute(item, "SubscriptionId"),
+		AccountKey:     stringAttribute(item, "AccountKey"),
+		Url:            stringAttribute(item, "Url"),
+		Secret:         stringAttribute(item, "Secret"),
+		EventTypes:     make([]string, 0),
+	}
+	if s.AccountKey == allAccounts {
+		s.AccountKey = ""
+	}
+	if v, ok := item["EventTypes"]; ok && v != nil {
+		for _, t := range v.L {
+			s.EventTypes = append(s.EventTypes, aws.StringValue(t.S))
+		}
+	}
+	createdAt, err := timeAttribute(item, "CreatedAt")
+	if err != nil {
+		return nil, err
+	}
+	s.CreatedAt = createdAt
+
+	return s, nil
+}
+
+func (d *db) GetSubscriptionWithContext(ctx context.Context, input *app.GetSubscriptionInput) (*app.Subscription, error) {
+	i := &dynamodb.GetItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"SubscriptionId": {
+				S: aws.String(input.SubscriptionID),
+			},
+		},
+		TableName: aws.String(d.config.SubscriptionTableName),
+	}
+	o, err := d.dynamodbService.GetItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error get subscription %s", err.Error()))
+		return nil, err
+	}
+	if o.Item == nil {
+		return nil, nil
+	}
+
+	return subscriptionFromItem(o.Item)
+}
+
+func (d *db) DeleteSubscriptionWithContext(ctx context.Context, input *app.DeleteSubscriptionInput) error {
+	i := &dynamodb.DeleteItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"SubscriptionId": {
+				S: aws.String(input.SubscriptionID),
+			},
+		},
+		TableName: aws.String(d.config.SubscriptionTableName),
+	}
+	d.log.Info(fmt.Sprintf("Dynamodb delete subscription %s", input.SubscriptionID))
+	_, err := d.dynamodbService.DeleteItemWithContext(ctx, i)
+	if err != nil {
+		d.log.Error(fmt.Sprintf("Error %s", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (d *db) ListSubscriptionsWithContext(ctx context.Context, input *app.ListSubscriptionsInput) (*app.ListSubscriptionsOutput, error) {
+	queryInput := &dynamodb.QueryInput{
+		IndexName:              aws.String(subscriptionAccountIndex),
+		KeyConditionExpression: aws.String("AccountKey = :accountKey"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":accountKey": {
+				S: aws.String(accountKeyAttribute(input.AccountKey)),
+			},
+		},
+		TableName: aws.String(d.config.SubscriptionTableName),
+	}
+
+	subscriptions := make([]*app.Subscription, 0)
+	for {
+		queryOutput, err := d.dynamodbService.QueryWithContext(ctx, queryInput)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("Error query subscriptions %s", err.Error()))
+			return nil, err
+		}
+		for _, item := range queryOutput.Items {
+			s, err := subscriptionFromItem(item)
+			if err != nil {
+				d.log.Error(fmt.Sprintf("Error query subscription item %s", err.Error()))
+				return nil, err
+			}
+			subscriptions = append(subscriptions, s)
+		}
+		if len(queryOutput.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = queryOutput.LastEvaluatedKey
+	}
+
+	return &app.ListSubscriptionsOutput{
+		Subscriptions: subscriptions,
+	}, nil
+}
+
+func NewDynamodb(d Dynamodb, log Logger, config Config) app.Persistence {
+	return &db{
+		dynamodbService: d,
+		log:             log,
+		config:          config,
+	}
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"webhook/app"
+)
+
+var testNow = time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)
+
+type serviceMock struct {
+	v string
+	t *testing.T
+}
+
+func conditionalCheckFailedError() error {
+	return awserr.NewRequestFailure(awserr.New("ConditionalCheckFailedException", "condition failed", nil), 400, "")
+}
+
+func (s serviceMock) check(input interface{}) {
+	v, err := json.Marshal(input)
+	assert.Nil(s.t, err)
+	assert.Equal(s.t, s.v, string(v))
+}
+
+func (s serviceMock) PutItemWithContext(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("put error")
+	}
+	if s.v == "1" {
+		return nil, conditionalCheckFailedError()
+	}
+	s.check(input)
+	return nil, nil
+}
+func (s serviceMock) GetItemWithContext(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("get error")
+	}
+	if s.v == "1" {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	s.check(input)
+	return &dynamodb.GetItemOutput{
+		Item: map[string]*dynamodb.AttributeValue{
+			"SubscriptionId": {S: aws.String("s1")},
+			"AccountKey":     {S: aws.String("*")},
+			"Url":            {S: aws.String("https://merchant.example.com/hooks")},
+			"EventTypes":     {L: []*dynamodb.AttributeValue{{S: aws.String("DebitSettled")}}},
+			"Secret":         {S: aws.String("whsec_test")},
+			"CreatedAt":      {S: aws.String("2022-01-31T10:00:00.000Z")},
+		},
+	}, nil
+}
+func (s serviceMock) UpdateItemWithContext(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("update error")
+	}
+	if s.v == "1" {
+		return nil, conditionalCheckFailedError()
+	}
+	s.check(input)
+	return nil, nil
+}
+func (s serviceMock) DeleteItemWithContext(ctx context.Context, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("delete error")
+	}
+	s.check(input)
+	return nil, nil
+}
+func (s serviceMock) QueryWithContext(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	if s.v == "" {
+		return nil, errors.New("query error")
+	}
+	s.check(input)
+	return &dynamodb.QueryOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{
+				"DeliveryId":     {S: aws.String("d1")},
+				"SubscriptionId": {S: aws.String("s1")},
+				"EventId":        {S: aws.String("e1")},
+				"EventType":      {S: aws.String("DebitSettled")},
+				"AccountKey":     {S: aws.String("1")},
+				"Payload":        {S: aws.String("{\"event_id\":\"e1\"}")},
+				"Status":         {S: aws.String("pending")},
+				"Pending":        {S: aws.String("1")},
+				"Attempts":       {N: aws.String("2")},
+				"NextAttemptAt":  {S: aws.String("2022-01-31T10:01:00.000Z")},
+				"LastStatusCode": {N: aws.String("500")},
+				"LastError":      {S: aws.String("subscriber answered 500")},
+				"CreatedAt":      {S: aws.String("2022-01-31T10:00:00.000Z")},
+			},
+		},
+	}, nil
+}
+func newServiceMock(v string, t *testing.T) Dynamodb {
+	return &serviceMock{
+		v: v,
+		t: t,
+	}
+}
+
+type log struct{}
+
+func (l log) Info(msg string)  {}
+func (l log) Error(msg string) {}
+func newLogMock() Logger {
+	return &log{}
+}
+
+func newConfig() Config {
+	return Config{
+		SubscriptionTableName: "webhook-subscription",
+		DeliveryTableName:     "webhook-delivery",
+	}
+}
+
+func TestDb_InsertSubscriptionForAllAccounts(t *testing.T) {
+	s := newServiceMock("{\"ConditionExpression\":\"attribute_not_exists(SubscriptionId)\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"*\",\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"EventTypes\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":[{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"DebitSettled\",\"SS\":null},{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"LimitBreached\",\"SS\":null}],\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"Secret\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"whsec_test\",\"SS\":null},\"SubscriptionId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"s1\",\"SS\":null},\"Url\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"https://merchant.example.com/hooks\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"webhook-subscription\"}", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	err := d.InsertSubscriptionWithContext(context.Background(), &app.Subscription{
+		SubscriptionID: "s1",
+		Url:            "https://merchant.example.com/hooks",
+		EventTypes:     []string{"DebitSettled", "LimitBreached"},
+		Secret:         "whsec_test",
+		CreatedAt:      testNow,
+	})
+	assert.Nil(t, err)
+}
+
+func TestDb_GetSubscription(t *testing.T) {
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConsistentRead\":null,\"ExpressionAttributeNames\":null,\"Key\":{\"SubscriptionId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"s1\",\"SS\":null}},\"ProjectionExpression\":null,\"ReturnConsumedCapacity\":null,\"TableName\":\"webhook-subscription\"}", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.GetSubscriptionWithContext(context.Background(), &app.GetSubscriptionInput{
+		SubscriptionID: "s1",
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"SubscriptionID\":\"s1\",\"AccountKey\":\"\",\"Url\":\"https://merchant.example.com/hooks\",\"EventTypes\":[\"DebitSettled\"],\"Secret\":\"whsec_test\",\"CreatedAt\":\"2022-01-31T10:00:00Z\"}", string(b))
+}
+
+func TestDb_NotGetSubscriptionWhenNotFound(t *testing.T) {
+	s := newServiceMock("1", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.GetSubscriptionWithContext(context.Background(), &app.GetSubscriptionInput{
+		SubscriptionID: "s1",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
+func TestDb_InsertDelivery(t *testing.T) {
+	s := newServiceMock("{\"ConditionExpression\":\"attribute_not_exists(DeliveryId)\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":null,\"Item\":{\"AccountKey\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Attempts\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\"CreatedAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"DeliveryId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"d1\",\"SS\":null},\"EventId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"e1\",\"SS\":null},\"EventType\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"DebitSettled\",\"SS\":null},\"NextAttemptAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\"Payload\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"{\\\"event_id\\\":\\\"e1\\\"}\",\"SS\":null},\"Pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\"Status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"pending\",\"SS\":null},\"SubscriptionId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"s1\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"webhook-delivery\"}", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.InsertDeliveryWithContext(context.Background(), &app.Delivery{
+		DeliveryID:     "d1",
+		SubscriptionID: "s1",
+		EventID:        "e1",
+		EventType:      "DebitSettled",
+		AccountKey:     "1",
+		Payload:        []byte("{\"event_id\":\"e1\"}"),
+		Status:         app.DeliveryPending,
+		NextAttemptAt:  testNow,
+		CreatedAt:      testNow,
+	})
+	assert.Nil(t, err)
+	assert.False(t, res.AlreadyExists)
+}
+
+func TestDb_NotInsertDeliveryWhenAlreadyExists(t *testing.T) {
+	s := newServiceMock("1", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.InsertDeliveryWithContext(context.Background(), &app.Delivery{
+		DeliveryID: "d1",
+	})
+	assert.Nil(t, err)
+	assert.True(t, res.AlreadyExists)
+}
+
+func TestDb_ListDueDeliveries(t *testing.T) {
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":now\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\":pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":\"Pending-NextAttemptAt-index\",\"KeyConditionExpression\":\"Pending = :pending AND NextAttemptAt \\u003c= :now\",\"KeyConditions\":null,\"Limit\":100,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"webhook-delivery\"}", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.ListDueDeliveriesWithContext(context.Background(), &app.ListDueDeliveriesInput{
+		Now:   testNow,
+		Limit: 100,
+	})
+	assert.Nil(t, err)
+	b, err := json.Marshal(res)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"Deliveries\":[{\"DeliveryID\":\"d1\",\"SubscriptionID\":\"s1\",\"EventID\":\"e1\",\"EventType\":\"DebitSettled\",\"AccountKey\":\"1\",\"Payload\":\"eyJldmVudF9pZCI6ImUxIn0=\",\"Status\":\"pending\",\"Attempts\":2,\"NextAttemptAt\":\"2022-01-31T10:01:00Z\",\"LastStatusCode\":500,\"LastError\":\"subscriber answered 500\",\"CreatedAt\":\"2022-01-31T10:00:00Z\",\"DeliveredAt\":\"0001-01-01T00:00:00Z\"}]}", string(b))
+}
+
+func TestDb_NotListDueDeliveriesWhenQueryError(t *testing.T) {
+	s := newServiceMock("", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.ListDueDeliveriesWithContext(context.Background(), &app.ListDueDeliveriesInput{
+		Now:   testNow,
+		Limit: 100,
+	})
+	assert.Nil(t, res)
+	assert.Equal(t, "query error", err.Error())
+}
+
+func TestDb_ListDeadDeliveries(t *testing.T) {
+	s := newServiceMock("{\"AttributesToGet\":null,\"ConditionalOperator\":null,\"ConsistentRead\":null,\"ExclusiveStartKey\":null,\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":dead\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"dead\",\"SS\":null},\":subscriptionId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"s1\",\"SS\":null}},\"FilterExpression\":null,\"IndexName\":\"SubscriptionId-Status-index\",\"KeyConditionExpression\":\"SubscriptionId = :subscriptionId AND #status = :dead\",\"KeyConditions\":null,\"Limit\":null,\"ProjectionExpression\":null,\"QueryFilter\":null,\"ReturnConsumedCapacity\":null,\"ScanIndexForward\":null,\"Select\":null,\"TableName\":\"webhook-delivery\"}", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.ListDeadDeliveriesWithContext(context.Background(), &app.ListDeadDeliveriesInput{
+		SubscriptionID: "s1",
+	})
+	assert.Nil(t, err)
+	assert.Len(t, res.Deliveries, 1)
+}
+
+func TestDb_ClaimDelivery(t *testing.T) {
+	s := newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":\"Pending = :pending AND Attempts = :attempts\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":attempts\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"2\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":next\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"3\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null},\":nextAttemptAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:02:00.000Z\",\"SS\":null},\":pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null}},\"Key\":{\"DeliveryId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"d1\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"webhook-delivery\",\"UpdateExpression\":\"SET Attempts = :next, NextAttemptAt = :nextAttemptAt\"}", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.ClaimDeliveryWithContext(context.Background(), &app.ClaimDeliveryInput{
+		DeliveryID:    "d1",
+		Attempts:      2,
+		NextAttemptAt: testNow.Add(2 * time.Minute),
+	})
+	assert.Nil(t, err)
+	assert.False(t, res.DeliveryChanged)
+}
+
+func TestDb_NotClaimDeliveryWhenChanged(t *testing.T) {
+	s := newServiceMock("1", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.ClaimDeliveryWithContext(context.Background(), &app.ClaimDeliveryInput{
+		DeliveryID: "d1",
+		Attempts:   2,
+	})
+	assert.Nil(t, err)
+	assert.True(t, res.DeliveryChanged)
+}
+
+func TestDb_RecordDeliveryDelivered(t *testing.T) {
+	s := newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":null,\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":deliveredAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\":error\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"\",\"SS\":null},\":status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"delivered\",\"SS\":null},\":statusCode\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"200\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"DeliveryId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"d1\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"webhook-delivery\",\"UpdateExpression\":\"REMOVE Pending SET LastStatusCode = :statusCode, LastError = :error, #status = :status, DeliveredAt = :deliveredAt\"}", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	err := d.RecordDeliveryWithContext(context.Background(), &app.RecordDeliveryInput{
+		DeliveryID:  "d1",
+		Status:      app.DeliveryDelivered,
+		StatusCode:  200,
+		DeliveredAt: testNow,
+	})
+	assert.Nil(t, err)
+}
+
+func TestDb_RecordDeliveryRetried(t *testing.T) {
+	s := newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":null,\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":null,\"ExpressionAttributeValues\":{\":error\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"subscriber answered 500\",\"SS\":null},\":statusCode\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"500\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"DeliveryId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"d1\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"webhook-delivery\",\"UpdateExpression\":\"SET LastStatusCode = :statusCode, LastError = :error\"}", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	err := d.RecordDeliveryWithContext(context.Background(), &app.RecordDeliveryInput{
+		DeliveryID: "d1",
+		Status:     app.DeliveryPending,
+		StatusCode: 500,
+		Error:      "subscriber answered 500",
+	})
+	assert.Nil(t, err)
+}
+
+func TestDb_ResetDelivery(t *testing.T) {
+	s := newServiceMock("{\"AttributeUpdates\":null,\"ConditionExpression\":\"attribute_exists(DeliveryId) AND attribute_not_exists(Pending)\",\"ConditionalOperator\":null,\"Expected\":null,\"ExpressionAttributeNames\":{\"#status\":\"Status\"},\"ExpressionAttributeValues\":{\":nextAttemptAt\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"2022-01-31T10:00:00.000Z\",\"SS\":null},\":pending\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"1\",\"SS\":null},\":status\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"pending\",\"SS\":null},\":zero\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":\"0\",\"NS\":null,\"NULL\":null,\"S\":null,\"SS\":null}},\"Key\":{\"DeliveryId\":{\"B\":null,\"BOOL\":null,\"BS\":null,\"L\":null,\"M\":null,\"N\":null,\"NS\":null,\"NULL\":null,\"S\":\"d1\",\"SS\":null}},\"ReturnConsumedCapacity\":null,\"ReturnItemCollectionMetrics\":null,\"ReturnValues\":null,\"TableName\":\"webhook-delivery\",\"UpdateExpression\":\"SET Pending = :pending, #status = :status, Attempts = :zero, NextAttemptAt = :nextAttemptAt\"}", t)
+	d := NewDynamodb(s, newLogMock(), newConfig())
+	res, err := d.ResetDeliveryWithContext(context.Background(), &app.ResetDeliveryInput{
+		DeliveryID:    "d1",
+		NextAttemptAt: testNow,
+	})
+	assert.Nil(t, err)
+	assert.False(t, res.DeliveryChanged)
+}
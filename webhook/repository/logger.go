@@ -0,0 +1,6 @@
+package repository
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
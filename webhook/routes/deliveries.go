@@ -0,0 +1,97 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"webhook/app"
+)
+
+type DeliveryResponse struct {
+	DeliveryID     string     `json:"delivery_id"`
+	SubscriptionID string     `json:"subscription_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	AccountKey     string     `json:"account_key"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type DeadLettersResponse struct {
+	SubscriptionID string              `json:"subscription_id"`
+	Deliveries     []*DeliveryResponse `json:"deliveries"`
+}
+
+func deliveryResponseBuild(code string, detail string) *WebhookErrorResponse {
+	if code == app.DeliveryInProgress {
+		return codeResponseBuild(code, detail, http.StatusConflict, Conflict)
+	}
+
+	return codeResponseBuild(code, detail, http.StatusNotFound, NotFound)
+}
+
+func deliveryResponse(d *app.DeliveryOutput) *DeliveryResponse {
+	r := &DeliveryResponse{
+		DeliveryID:     d.DeliveryID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		AccountKey:     d.AccountKey,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+	}
+	if !d.DeliveredAt.IsZero() {
+		deliveredAt := d.DeliveredAt
+		r.DeliveredAt = &deliveredAt
+	}
+	return r
+}
+
+func listDeadLettersWithContext(ctx context.Context, subscriptionID string, log Logger, a app.Webhook) (*DeadLettersResponse, *WebhookErrorResponse, error) {
+	res, err := a.ListDeadLettersWithContext(ctx, &app.ListDeadLettersInput{
+		SubscriptionID: subscriptionID,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, deliveryResponseBuild(res.Code, res.Detail), nil
+	}
+
+	deliveries := make([]*DeliveryResponse, 0, len(res.Deliveries))
+	for _, d := range res.Deliveries {
+		deliveries = append(deliveries, deliveryResponse(d))
+	}
+	return &DeadLettersResponse{
+		SubscriptionID: subscriptionID,
+		Deliveries:     deliveries,
+	}, nil, nil
+}
+
+func redeliverWithContext(ctx context.Context, subscriptionID string, deliveryID string, log Logger, a app.Webhook) (*DeliveryResponse, *WebhookErrorResponse, error) {
+	res, err := a.RedeliverWithContext(ctx, &app.RedeliverInput{
+		SubscriptionID: subscriptionID,
+		DeliveryID:     deliveryID,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, deliveryResponseBuild(res.Code, res.Detail), nil
+	}
+
+	return deliveryResponse(res), nil, nil
+}
@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+	"webhook/app"
+)
+
+type EventRequest struct {
+	EventID    *string         `json:"event_id,omitempty"`
+	EventType  *string         `json:"event_type,omitempty"`
+	AccountKey *string         `json:"account_key,omitempty"`
+	OccurredAt *time.Time      `json:"occurred_at,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+type EventResponse struct {
+	Deliveries int `json:"deliveries"`
+}
+
+func buildEventRequest(a []byte) (*EventRequest, *WebhookErrorResponse) {
+	va := &EventRequest{}
+
+	err := json.Unmarshal(a, &va)
+	if err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.EventID == nil || stringValue(va.EventID) == "" {
+		return nil, responseBuild("event_id is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.EventType == nil || stringValue(va.EventType) == "" {
+		return nil, responseBuild("event_type is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.AccountKey == nil || stringValue(va.AccountKey) == "" {
+		return nil, responseBuild("account_key is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if data := bytes.TrimSpace(va.Data); len(data) > 0 && data[0] != '{' && string(data) != "null" {
+		return nil, responseBuild("data must be an object", http.StatusBadRequest, BadRequest)
+	}
+
+	return va, nil
+}
+
+func emitWithContext(ctx context.Context, body io.ReadCloser, log Logger, a app.Webhook) (*EventResponse, *WebhookErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request, errorResponse := buildEventRequest(b)
+
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	i := &app.EmitInput{
+		EventID:    stringValue(request.EventID),
+		EventType:  stringValue(request.EventType),
+		AccountKey: stringValue(request.AccountKey),
+		OccurredAt: time.Now().UTC(),
+		Data:       request.Data,
+	}
+	if request.OccurredAt != nil {
+		i.OccurredAt = *request.OccurredAt
+	}
+
+	res, err := a.EmitWithContext(ctx, i)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest), nil
+	}
+
+	return &EventResponse{
+		Deliveries: res.Deliveries,
+	}, nil, nil
+}
@@ -0,0 +1,6 @@
+package routes
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
@@ -0,0 +1,179 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"webhook/app"
+)
+
+type Routes interface {
+	Default() *http.ServeMux
+}
+
+type routes struct {
+	webhook app.Webhook
+	log     Logger
+}
+
+func healthz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func writeResponse(w http.ResponseWriter, statusCode int, v interface{}) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(res); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func subscriptions(a app.Webhook, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		o, errorResponse, err := createSubscriptionWithContext(r.Context(), r.Body, log, a)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if errorResponse != nil {
+			writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+			return
+		}
+
+		writeResponse(w, http.StatusCreated, o)
+	})
+}
+
+func subscription(a app.Webhook, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		path := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/webhooks/"), "/")
+		subscriptionID := path[0]
+		if subscriptionID == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if len(path) == 1 {
+			if r.Method == http.MethodGet {
+				o, errorResponse, err := getSubscriptionWithContext(ctx, subscriptionID, log, a)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if errorResponse != nil {
+					writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+					return
+				}
+				writeResponse(w, http.StatusOK, o)
+				return
+			}
+			if r.Method == http.MethodDelete {
+				errorResponse, err := deleteSubscriptionWithContext(ctx, subscriptionID, log, a)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if errorResponse != nil {
+					writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if len(path) == 2 && path[1] == "dead-letters" {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			o, errorResponse, err := listDeadLettersWithContext(ctx, subscriptionID, log, a)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+			writeResponse(w, http.StatusOK, o)
+			return
+		}
+
+		if len(path) == 4 && path[1] == "deliveries" && path[2] != "" && path[3] == "redeliver" {
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			o, errorResponse, err := redeliverWithContext(ctx, subscriptionID, path[2], log, a)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if errorResponse != nil {
+				writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+				return
+			}
+			writeResponse(w, http.StatusAccepted, o)
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	})
+}
+
+func events(a app.Webhook, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		o, errorResponse, err := emitWithContext(r.Context(), r.Body, log, a)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if errorResponse != nil {
+			writeResponse(w, errorResponse.Error.StatusCode, errorResponse)
+			return
+		}
+
+		writeResponse(w, http.StatusAccepted, o)
+	})
+}
+
+func (r *routes) Default() *http.ServeMux {
+	middleware := http.NewServeMux()
+	middleware.Handle("/v1/webhooks", subscriptions(r.webhook, r.log))
+	middleware.Handle("/v1/webhooks/", subscription(r.webhook, r.log))
+	middleware.Handle("/v1/events", events(r.webhook, r.log))
+	middleware.Handle("/health", healthz())
+	return middleware
+}
+
+func New(a app.Webhook, log Logger) Routes {
+	return &routes{
+		webhook: a,
+		log:     log,
+	}
+}
@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+	"webhook/app"
+)
+
+const (
+	BadRequest     = "bad_request"
+	Conflict       = "conflict"
+	InvalidRequest = "invalid_request"
+	NotFound       = "not_found"
+)
+
+type WebhookError struct {
+	StatusCode int    `json:"-"`
+	Type       string `json:"type,omitempty"`
+	Category   string `json:"category,omitempty"`
+	Code       string `json:"code,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+type WebhookErrorResponse struct {
+	Error *WebhookError `json:"error,omitempty"`
+}
+
+type SubscriptionRequest struct {
+	AccountKey *string  `json:"account_key,omitempty"`
+	Url        *string  `json:"url,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+}
+
+type SubscriptionResponse struct {
+	SubscriptionID string    `json:"subscription_id"`
+	AccountKey     string    `json:"account_key,omitempty"`
+	Url            string    `json:"url"`
+	EventTypes     []string  `json:"event_types"`
+	Secret         string    `json:"secret,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func responseBuild(msg string, statusCode int, category string) *WebhookErrorResponse {
+	et := &WebhookError{
+		StatusCode: statusCode,
+		Type:       InvalidRequest,
+		Category:   category,
+		Message:    msg,
+	}
+	ae := &WebhookErrorResponse{
+		Error: et,
+	}
+	return ae
+}
+
+func codeResponseBuild(code string, msg string, statusCode int, category string) *WebhookErrorResponse {
+	ae := responseBuild(msg, statusCode, category)
+	ae.Error.Code = code
+	return ae
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func subscriptionResponseBuild(res *app.SubscriptionOutput) *WebhookErrorResponse {
+	if res.Code == app.SubscriptionNotFound {
+		return codeResponseBuild(res.Code, res.Detail, http.StatusNotFound, NotFound)
+	}
+
+	return codeResponseBuild(res.Code, res.Detail, http.StatusBadRequest, BadRequest)
+}
+
+func subscriptionResponse(res *app.SubscriptionOutput) *SubscriptionResponse {
+	return &SubscriptionResponse{
+		SubscriptionID: res.SubscriptionID,
+		AccountKey:     res.AccountKey,
+		Url:            res.Url,
+		EventTypes:     res.EventTypes,
+		Secret:         res.Secret,
+		CreatedAt:      res.CreatedAt,
+	}
+}
+
+func buildSubscriptionRequest(a []byte) (*SubscriptionRequest, *WebhookErrorResponse) {
+	va := &SubscriptionRequest{}
+
+	err := json.Unmarshal(a, &va)
+	if err != nil {
+		return nil, responseBuild("invalid payload", http.StatusBadRequest, BadRequest)
+	}
+
+	if va.Url == nil || stringValue(va.Url) == "" {
+		return nil, responseBuild("url is missing or null", http.StatusBadRequest, BadRequest)
+	}
+
+	if len(va.EventTypes) == 0 {
+		return nil, responseBuild("event_types is missing or empty", http.StatusBadRequest, BadRequest)
+	}
+
+	return va, nil
+}
+
+func createSubscriptionWithContext(ctx context.Context, body io.ReadCloser, log Logger, a app.Webhook) (*SubscriptionResponse, *WebhookErrorResponse, error) {
+	defer body.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(body)
+	b := buf.Bytes()
+
+	request, errorResponse := buildSubscriptionRequest(b)
+
+	if errorResponse != nil {
+		return nil, errorResponse, nil
+	}
+
+	res, err := a.CreateSubscriptionWithContext(ctx, &app.CreateSubscriptionInput{
+		AccountKey: stringValue(request.AccountKey),
+		Url:        stringValue(request.Url),
+		EventTypes: request.EventTypes,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, subscriptionResponseBuild(res), nil
+	}
+
+	return subscriptionResponse(res), nil, nil
+}
+
+func getSubscriptionWithContext(ctx context.Context, subscriptionID string, log Logger, a app.Webhook) (*SubscriptionResponse, *WebhookErrorResponse, error) {
+	res, err := a.GetSubscriptionWithContext(ctx, &app.GetSubscriptionInput{
+		SubscriptionID: subscriptionID,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res != nil && res.Error {
+		return nil, subscriptionResponseBuild(res), nil
+	}
+
+	return subscriptionResponse(res), nil, nil
+}
+
+func deleteSubscriptionWithContext(ctx context.Context, subscriptionID string, log Logger, a app.Webhook) (*WebhookErrorResponse, error) {
+	res, err := a.DeleteSubscriptionWithContext(ctx, &app.DeleteSubscriptionInput{
+		SubscriptionID: subscriptionID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res != nil && res.Error {
+		return subscriptionResponseBuild(res), nil
+	}
+
+	return nil, nil
+}
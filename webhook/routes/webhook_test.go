@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"webhook/app"
+)
+
+var testNow = time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC)
+
+type webhookMock struct {
+	t *testing.T
+}
+
+func (m *webhookMock) CreateSubscriptionWithContext(ctx context.Context, input *app.CreateSubscriptionInput) (*app.SubscriptionOutput, error) {
+	if input.Url == "https://error.example.com" {
+		return nil, errors.New("insert error")
+	}
+	if input.EventTypes[0] == "Unknown" {
+		return &app.SubscriptionOutput{
+			Error:  true,
+			Code:   app.EventTypeInvalid,
+			Detail: "event type Unknown is not one of AccountCreated",
+		}, nil
+	}
+	return &app.SubscriptionOutput{
+		SubscriptionID: "s1",
+		AccountKey:     input.AccountKey,
+		Url:            input.Url,
+		EventTypes:     input.EventTypes,
+		Secret:         "whsec_test",
+		CreatedAt:      testNow,
+	}, nil
+}
+func (m *webhookMock) GetSubscriptionWithContext(ctx context.Context, input *app.GetSubscriptionInput) (*app.SubscriptionOutput, error) {
+	if input.SubscriptionID == "404" {
+		return &app.SubscriptionOutput{
+			Error:  true,
+			Code:   app.SubscriptionNotFound,
+			Detail: "subscription not found",
+		}, nil
+	}
+	return &app.SubscriptionOutput{
+		SubscriptionID: input.SubscriptionID,
+		Url:            "https://merchant.example.com/hooks",
+		EventTypes:     []string{app.DebitSettled},
+		CreatedAt:      testNow,
+	}, nil
+}
+func (m *webhookMock) DeleteSubscriptionWithContext(ctx context.Context, input *app.DeleteSubscriptionInput) (*app.SubscriptionOutput, error) {
+	return &app.SubscriptionOutput{}, nil
+}
+func (m *webhookMock) EmitWithContext(ctx context.Context, input *app.EmitInput) (*app.EmitOutput, error) {
+	v, err := json.Marshal(input)
+	assert.Nil(m.t, err)
+	assert.Equal(m.t, "{\"EventID\":\"e1\",\"EventType\":\"DebitSettled\",\"AccountKey\":\"1\",\"OccurredAt\":\"2022-01-31T10:00:00Z\",\"Data\":\"eyJhbW91bnQiOjEwMDB9\"}", string(v))
+	return &app.EmitOutput{
+		Deliveries: 2,
+	}, nil
+}
+func (m *webhookMock) DispatchDueDeliveriesWithContext(ctx context.Context, input *app.DispatchDueDeliveriesInput) (*app.DispatchDueDeliveriesOutput, error) {
+	return &app.DispatchDueDeliveriesOutput{}, nil
+}
+func (m *webhookMock) ListDeadLettersWithContext(ctx context.Context, input *app.ListDeadLettersInput) (*app.ListDeadLettersOutput, error) {
+	return &app.ListDeadLettersOutput{
+		Deliveries: []*app.DeliveryOutput{
+			{
+				DeliveryID:     "d1",
+				SubscriptionID: input.SubscriptionID,
+				EventID:        "e1",
+				EventType:      app.DebitSettled,
+				AccountKey:     "1",
+				Status:         app.DeliveryDead,
+				Attempts:       8,
+				NextAttemptAt:  testNow,
+				LastStatusCode: 500,
+				LastError:      "subscriber answered 500",
+				CreatedAt:      testNow,
+			},
+		},
+	}, nil
+}
+func (m *webhookMock) RedeliverWithContext(ctx context.Context, input *app.RedeliverInput) (*app.DeliveryOutput, error) {
+	return &app.DeliveryOutput{
+		Error:  true,
+		Code:   app.DeliveryInProgress,
+		Detail: "delivery is still being retried",
+	}, nil
+}
+
+type log struct{}
+
+func (l log) Info(msg string)  {}
+func (l log) Error(msg string) {}
+func newLogMock() Logger {
+	return &log{}
+}
+
+func body(s string) io.ReadCloser {
+	return io.NopCloser(strings.NewReader(s))
+}
+
+func marshal(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func TestRoutes_CreateSubscription(t *testing.T) {
+	a := &webhookMock{t: t}
+	res, errorResponse, err := createSubscriptionWithContext(context.Background(), body("{\"url\":\"https://merchant.example.com/hooks\",\"event_types\":[\"DebitSettled\"],\"account_key\":\"1\"}"), newLogMock(), a)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, "{\"subscription_id\":\"s1\",\"account_key\":\"1\",\"url\":\"https://merchant.example.com/hooks\",\"event_types\":[\"DebitSettled\"],\"secret\":\"whsec_test\",\"created_at\":\"2022-01-31T10:00:00Z\"}", marshal(t, res))
+}
+
+func TestRoutes_NotCreateSubscriptionWhenEventTypesMissing(t *testing.T) {
+	a := &webhookMock{t: t}
+	res, errorResponse, err := createSubscriptionWithContext(context.Background(), body("{\"url\":\"https://merchant.example.com/hooks\"}"), newLogMock(), a)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, 400, errorResponse.Error.StatusCode)
+	assert.Equal(t, "{\"error\":{\"type\":\"invalid_request\",\"category\":\"bad_request\",\"message\":\"event_types is missing or empty\"}}", marshal(t, errorResponse))
+}
+
+func TestRoutes_NotCreateSubscriptionWhenEventTypeInvalid(t *testing.T) {
+	a := &webhookMock{t: t}
+	res, errorResponse, err := createSubscriptionWithContext(context.Background(), body("{\"url\":\"https://merchant.example.com/hooks\",\"event_types\":[\"Unknown\"]}"), newLogMock(), a)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, 400, errorResponse.Error.StatusCode)
+	assert.Equal(t, "event-type-invalid", errorResponse.Error.Code)
+}
+
+func TestRoutes_NotCreateSubscriptionWhenError(t *testing.T) {
+	a := &webhookMock{t: t}
+	res, errorResponse, err := createSubscriptionWithContext(context.Background(), body("{\"url\":\"https://error.example.com\",\"event_types\":[\"DebitSettled\"]}"), newLogMock(), a)
+	assert.Nil(t, res)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, "insert error", err.Error())
+}
+
+func TestRoutes_NotGetSubscriptionWhenNotFound(t *testing.T) {
+	a := &webhookMock{t: t}
+	res, errorResponse, err := getSubscriptionWithContext(context.Background(), "404", newLogMock(), a)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, 404, errorResponse.Error.StatusCode)
+}
+
+func TestRoutes_Emit(t *testing.T) {
+	a := &webhookMock{t: t}
+	res, errorResponse, err := emitWithContext(context.Background(), body("{\"event_id\":\"e1\",\"event_type\":\"DebitSettled\",\"account_key\":\"1\",\"occurred_at\":\"2022-01-31T10:00:00Z\",\"data\":{\"amount\":1000}}"), newLogMock(), a)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, "{\"deliveries\":2}", marshal(t, res))
+}
+
+func TestRoutes_NotEmitWhenDataNotObject(t *testing.T) {
+	a := &webhookMock{t: t}
+	res, errorResponse, err := emitWithContext(context.Background(), body("{\"event_id\":\"e1\",\"event_type\":\"DebitSettled\",\"account_key\":\"1\",\"data\":[1]}"), newLogMock(), a)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, "data must be an object", errorResponse.Error.Message)
+}
+
+func TestRoutes_ListDeadLetters(t *testing.T) {
+	a := &webhookMock{t: t}
+	res, errorResponse, err := listDeadLettersWithContext(context.Background(), "s1", newLogMock(), a)
+	assert.Nil(t, err)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, "{\"subscription_id\":\"s1\",\"deliveries\":[{\"delivery_id\":\"d1\",\"subscription_id\":\"s1\",\"event_id\":\"e1\",\"event_type\":\"DebitSettled\",\"account_key\":\"1\",\"status\":\"dead\",\"attempts\":8,\"next_attempt_at\":\"2022-01-31T10:00:00Z\",\"last_status_code\":500,\"last_error\":\"subscriber answered 500\",\"created_at\":\"2022-01-31T10:00:00Z\"}]}", marshal(t, res))
+}
+
+func TestRoutes_NotRedeliverWhenInProgress(t *testing.T) {
+	a := &webhookMock{t: t}
+	res, errorResponse, err := redeliverWithContext(context.Background(), "s1", "d1", newLogMock(), a)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, 409, errorResponse.Error.StatusCode)
+	assert.Equal(t, "delivery-in-progress", errorResponse.Error.Code)
+}
@@ -0,0 +1,16 @@
+package scheduler
+
+import "time"
+
+type Config struct {
+	Interval time.Duration
+}
+
+func (c *Config) WithInterval(interval string) *Config {
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		d = 5 * time.Second
+	}
+	c.Interval = d
+	return c
+}
@@ -0,0 +1,6 @@
+package scheduler
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"webhook/app"
+)
+
+// runTimeout leaves room for a batch of slow subscribers, each of them bounded by
+// the sender timeout.
+const runTimeout = 5 * time.Minute
+
+type Scheduler struct {
+	log     Logger
+	config  *Config
+	webhook app.Webhook
+}
+
+func (s *Scheduler) Start() {
+	s.log.Info(fmt.Sprintf("Starting scheduler every %s", s.config.Interval))
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.run(now)
+	}
+}
+
+func (s *Scheduler) run(now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	i := &app.DispatchDueDeliveriesInput{
+		Now: now,
+	}
+	o, err := s.webhook.DispatchDueDeliveriesWithContext(ctx, i)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Could not dispatch deliveries %s", err.Error()))
+		return
+	}
+	if o.Delivered > 0 || o.Retried > 0 || o.Dead > 0 {
+		s.log.Info(fmt.Sprintf("Deliveries delivered %d retried %d dead %d", o.Delivered, o.Retried, o.Dead))
+	}
+}
+
+func New(webhook app.Webhook, log Logger, config *Config) *Scheduler {
+	return &Scheduler{
+		webhook: webhook,
+		log:     log,
+		config:  config,
+	}
+}
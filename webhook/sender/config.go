@@ -0,0 +1,18 @@
+package sender
+
+import "time"
+
+const DefaultTimeout = 10 * time.Second
+
+type Config struct {
+	Timeout time.Duration
+}
+
+func (c *Config) WithTimeout(timeout string) *Config {
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		d = DefaultTimeout
+	}
+	c.Timeout = d
+	return c
+}
@@ -0,0 +1,42 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"webhook/app"
+)
+
+type Http interface {
+	PostWithContext(ctx context.Context, url string, headers map[string]string, payload []byte) (int, error)
+}
+
+type httpSender struct {
+	log         Logger
+	config      *Config
+	httpService Http
+}
+
+// SendWithContext gives up on a subscriber after the configured timeout, which
+// counts as a failed attempt.
+func (s *httpSender) SendWithContext(ctx context.Context, input *app.SendInput) (*app.SendOutput, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	statusCode, err := s.httpService.PostWithContext(ctx, input.Url, input.Headers, input.Body)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("http post error %s", err.Error()))
+		return nil, err
+	}
+
+	return &app.SendOutput{
+		StatusCode: statusCode,
+	}, nil
+}
+
+func New(log Logger, config *Config, httpService Http) app.Sender {
+	return &httpSender{
+		log:         log,
+		config:      config,
+		httpService: httpService,
+	}
+}
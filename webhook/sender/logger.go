@@ -0,0 +1,6 @@
+package sender
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
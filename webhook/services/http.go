@@ -0,0 +1,41 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"webhook/sender"
+)
+
+// maxResponseSize is as much of a subscriber answer as is read; only its status
+// code matters.
+const maxResponseSize = 64 * 1024
+
+type httpService struct{}
+
+func (h *httpService) PostWithContext(ctx context.Context, url string, headers map[string]string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	return resp.StatusCode, nil
+}
+
+func NewHttp() sender.Http {
+	return &httpService{}
+}